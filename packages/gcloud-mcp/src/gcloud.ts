@@ -18,7 +18,7 @@ import { z } from 'zod';
 import { findExecutable } from './gcloud_executor.js';
 
 export interface GcloudExecutable {
-  invoke: (args: string[]) => Promise<GcloudInvocationResult>;
+  invoke: (args: string[], signal?: AbortSignal) => Promise<GcloudInvocationResult>;
   lint: (command: string) => Promise<ParsedGcloudLintResult>;
 }
 
@@ -101,6 +101,23 @@ describe('gcloud_executor', () => {
       });
     });
 
+    it('should pass the abort signal to gcloud', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0));
+      const { signal } = new AbortController();
+
+      const executor = await findExecutable();
+      await executor.execute(['logging', 'read'], signal);
+
+      expect(spawnSpy).toHaveBeenCalledWith('gcloud', ['logging', 'read'], {
+        stdio: ['ignore', 'pipe', 'pipe'],
+        signal,
+      });
+    });
+
     it('should create a Windows executor when on Windows', async () => {
       Object.defineProperty(process, 'platform', {
         value: 'win32',
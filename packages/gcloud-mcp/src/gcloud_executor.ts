@@ -26,20 +26,21 @@ export interface GcloudExecutionResult {
 }
 
 export interface GcloudExecutor {
-  execute: (args: string[]) => Promise<GcloudExecutionResult>;
+  /** Runs gcloud with args, killing it if signal aborts. */
+  execute: (args: string[], signal?: AbortSignal) => Promise<GcloudExecutionResult>;
 }
 
 export const findExecutable = async (): Promise<GcloudExecutor> => {
   const executor = await createExecutor();
   return {
-    execute: async (args: string[]): Promise<GcloudExecutionResult> =>
+    execute: async (args: string[], signal?: AbortSignal): Promise<GcloudExecutionResult> =>
       new Promise((resolve, reject) => {
         let stdout = '';
         let stderr = '';
 
         let gcloud;
         try {
-          gcloud = executor.execute(args);
+          gcloud = executor.execute(args, signal);
         } catch (err) {
           reject(err);
           return;
@@ -57,7 +58,7 @@ export const findExecutable = async (): Promise<GcloudExecutor> => {
           resolve({ code, stdout, stderr });
         });
         gcloud.on('error', (err) => {
-          // Process failed to start. gcloud isn't able to be invoked.
+          // Process failed to start, or was killed because signal aborted.
           reject(err);
         });
       }),
@@ -87,9 +88,10 @@ const createExecutor = async () => {
 
 /** Creates an executor that directly invokes the gcloud binary on the current PATH. */
 const createDirectExecutor = () => ({
-  execute: (args: string[]) =>
+  execute: (args: string[], signal?: AbortSignal) =>
     child_process.spawn('gcloud', args, {
       stdio: ['ignore', 'pipe', 'pipe'],
+      signal,
     }),
 });
 
@@ -103,12 +105,13 @@ const createWindowsExecutor = async () => {
   const pythonPath = settings.cloudSdkPython;
 
   return {
-    execute: (args: string[]) =>
+    execute: (args: string[], signal?: AbortSignal) =>
       child_process.spawn(
         pythonPath,
         [...settings.cloudSdkPythonArgsList, settings.gcloudPyPath, ...args],
         {
           stdio: ['ignore', 'pipe', 'pipe'],
+          signal,
         },
       ),
   };
@@ -124,7 +124,7 @@ describe('createRunGcloudCommand', () => {
 
       const result = await tool({ args: inputArgs });
 
-      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, undefined);
       expect(result).toEqual({
         content: [
           {
@@ -144,7 +144,7 @@ describe('createRunGcloudCommand', () => {
 
       const result = await tool({ args: inputArgs });
 
-      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, undefined);
       expect(result).toEqual({
         content: [
           {
@@ -190,7 +190,7 @@ describe('createRunGcloudCommand', () => {
 
       const result = await tool({ args: inputArgs });
 
-      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, undefined);
       expect(result).toEqual({
         content: [
           {
@@ -201,6 +201,46 @@ describe('createRunGcloudCommand', () => {
       });
     });
 
+    test('passes the request abort signal to gcloud', async () => {
+      const tool = createTool();
+      const inputArgs = ['a', 'c'];
+      mockGcloudInvoke('output');
+      const { signal } = new AbortController();
+
+      await tool({ args: inputArgs }, { signal });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, signal);
+    });
+
+    test('reports progress for the request progress token', async () => {
+      const tool = createTool();
+      const inputArgs = ['a', 'c'];
+      mockGcloudInvoke('output');
+      const sendNotification = vi.fn();
+
+      await tool({ args: inputArgs }, { _meta: { progressToken: 'token' }, sendNotification });
+
+      expect(sendNotification.mock.calls).toEqual([
+        [
+          {
+            method: 'notifications/progress',
+            params: { progressToken: 'token', progress: 0, total: 1, message: 'Running gcloud a c' },
+          },
+        ],
+        [
+          {
+            method: 'notifications/progress',
+            params: {
+              progressToken: 'token',
+              progress: 1,
+              total: 1,
+              message: 'gcloud exited with status 0',
+            },
+          },
+        ],
+      ]);
+    });
+
     test('returns error when gcloud invocation throws an error', async () => {
       const tool = createTool();
       const inputArgs = ['a', 'c'];
@@ -210,7 +250,7 @@ describe('createRunGcloudCommand', () => {
 
       const result = await tool({ args: inputArgs });
 
-      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, undefined);
       expect(result).toEqual({
         content: [{ type: 'text', text: 'gcloud error' }],
         isError: true,
@@ -226,7 +266,7 @@ describe('createRunGcloudCommand', () => {
 
       const result = await tool({ args: inputArgs });
 
-      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs, undefined);
       expect(result).toEqual({
         content: [{ type: 'text', text: 'An unknown error occurred.' }],
         isError: true,
@@ -64,7 +64,7 @@ export const createRunGcloudCommand = (gcloud: GcloudExecutable, acl: AccessCont
 - **No pipes**: Do not use pipes (i.e., |) or any other shell-specific operators
 - **No redirection**: Do not use redirection operators (e.g., >, >>, <)`,
       },
-      async ({ args }) => {
+      async ({ args }, extra) => {
         const toolLogger = log.mcp('run_gcloud_command', args);
 
         if (args.join(' ') === 'gcloud-mcp debug config') {
@@ -99,7 +99,21 @@ export const createRunGcloudCommand = (gcloud: GcloudExecutable, acl: AccessCont
           }
 
           toolLogger.info('Executing run_gcloud_command');
-          const { code, stdout, stderr } = await gcloud.invoke(args);
+          // A client that sent a progress token is told when gcloud starts and
+          // when it exits.
+          const progressToken = extra?._meta?.progressToken;
+          const reportProgress = async (progress: number, message: string) => {
+            if (progressToken !== undefined) {
+              await extra.sendNotification({
+                method: 'notifications/progress',
+                params: { progressToken, progress, total: 1, message },
+              });
+            }
+          };
+          await reportProgress(0, `Running gcloud ${args.join(' ')}`);
+          // Cancelling the request aborts extra.signal, which kills gcloud.
+          const { code, stdout, stderr } = await gcloud.invoke(args, extra?.signal);
+          await reportProgress(1, `gcloud exited with status ${code}`);
           // If the exit status is not zero, an error occurred and the output may be
           // incomplete unless the command documentation notes otherwise. For example,
           // a command that creates multiple resources may only create a few, list them
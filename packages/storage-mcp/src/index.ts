@@ -31,6 +31,7 @@ import yargs, { ArgumentsCamelCase, CommandModule } from 'yargs';
 import { hideBin } from 'yargs/helpers';
 import { init } from './commands/init.js';
 import { log } from './utility/logger.js';
+import { registerObjectResource } from './tools/objects/index.js';
 import * as process from 'process';
 
 const exitProcessAfter = <T, U>(cmd: CommandModule<T, U>): CommandModule<T, U> => ({
@@ -73,7 +74,7 @@ const main = async () => {
         name: 'storage-mcp-server',
         version: pkg.version,
       },
-      { capabilities: { tools: {} } },
+      { capabilities: { tools: {}, resources: {} } },
     );
 
     log.info('Registering tools...');
@@ -92,6 +93,7 @@ const main = async () => {
       registerTool(server);
     }
     log.info(`Total tools registered: ${allTools.length}`);
+    registerObjectResource(server);
 
     const PORT_ENV = process.env['PORT'] || (process.env['K_SERVICE'] ? '8080' : null);
 
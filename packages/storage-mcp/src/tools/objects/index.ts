@@ -20,6 +20,7 @@ export * from './delete_object.js';
 export * from './download_object.js';
 export * from './list_objects.js';
 export * from './move_object.js';
+export * from './object_resource.js';
 export * from './read_object_content.js';
 export * from './read_object_metadata.js';
 export * from './update_object_metadata.js';
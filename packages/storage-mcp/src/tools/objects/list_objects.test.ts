@@ -57,6 +57,8 @@ describe('listObjects', () => {
         type: 'text',
         text: JSON.stringify(expectedJson, null, 2),
       },
+      { type: 'resource_link', uri: 'gcs://test-bucket/object-1', name: 'object-1' },
+      { type: 'resource_link', uri: 'gcs://test-bucket/object-2', name: 'object-2' },
     ]);
   });
 
@@ -104,6 +106,8 @@ describe('listObjects', () => {
         type: 'text',
         text: JSON.stringify(expectedJson, null, 2),
       },
+      { type: 'resource_link', uri: 'gcs://test-bucket/object-1', name: 'object-1' },
+      { type: 'resource_link', uri: 'gcs://test-bucket/object-2', name: 'object-2' },
     ]);
   });
 
@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { objectUri } from './object_resource.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -91,8 +92,16 @@ export async function listObjects(params: ListObjectsParams): Promise<CallToolRe
     logger.info(
       `Successfully listed ${objectList.length} objects from bucket ${params.bucket_name}`,
     );
+    // Each object is also linked, so a client can read it as a resource.
     return {
-      content: [{ type: 'text', text: JSON.stringify(result, null, 2) }],
+      content: [
+        { type: 'text', text: JSON.stringify(result, null, 2) },
+        ...objectList.map((name: string) => ({
+          type: 'resource_link' as const,
+          uri: objectUri(params.bucket_name, name),
+          name,
+        })),
+      ],
     };
   } catch (e: unknown) {
     const error = e as Error;
@@ -0,0 +1,103 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+/// <reference types="vitest/globals" />
+import { describe, it, expect, vi } from 'vitest';
+import { readObjectResource, registerObjectResource } from './object_resource.js';
+import { readObjectContent } from './read_object_content.js';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+
+vi.mock('./read_object_content.js');
+vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
+
+describe('readObjectResource', () => {
+  it('should return the text content of a text object', async () => {
+    (readObjectContent as vi.Mock).mockResolvedValue({
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify({ content_type: 'text/plain', content: 'hello' }),
+        },
+      ],
+    });
+
+    const result = await readObjectResource(new URL('gcs://test-bucket/dir/a.txt'), {
+      bucket: 'test-bucket',
+      object: 'dir/a.txt',
+    });
+
+    expect(readObjectContent).toHaveBeenCalledWith({
+      bucket_name: 'test-bucket',
+      object_name: 'dir/a.txt',
+    });
+    expect(result.contents).toEqual([
+      { uri: 'gcs://test-bucket/dir/a.txt', mimeType: 'text/plain', text: 'hello' },
+    ]);
+  });
+
+  it('should return the blob of a raw object', async () => {
+    (readObjectContent as vi.Mock).mockResolvedValue({
+      content: [
+        {
+          type: 'resource',
+          resource: { uri: 'gcs://test-bucket/a.png', mimeType: 'image/png', blob: 'aGk=' },
+        },
+      ],
+    });
+
+    const result = await readObjectResource(new URL('gcs://test-bucket/a.png'), {
+      bucket: 'test-bucket',
+      object: 'a.png',
+    });
+
+    expect(result.contents).toEqual([
+      { uri: 'gcs://test-bucket/a.png', mimeType: 'image/png', blob: 'aGk=' },
+    ]);
+  });
+
+  it('should throw the error read_object_content reports', async () => {
+    (readObjectContent as vi.Mock).mockResolvedValue({
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify({ error: 'Error reading object content: Not Found' }),
+        },
+      ],
+    });
+
+    await expect(
+      readObjectResource(new URL('gcs://test-bucket/missing'), {
+        bucket: 'test-bucket',
+        object: 'missing',
+      }),
+    ).rejects.toThrow('Not Found');
+  });
+});
+
+describe('registerObjectResource', () => {
+  it('should register the object resource template with the server', () => {
+    const mockServer = new McpServer();
+    registerObjectResource(mockServer);
+
+    expect(mockServer.registerResource).toHaveBeenCalledWith(
+      'object',
+      expect.anything(),
+      expect.any(Object),
+      readObjectResource,
+    );
+  });
+});
@@ -0,0 +1,53 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { ReadResourceResult } from '@modelcontextprotocol/sdk/types.js';
+import { readObjectContent } from './read_object_content.js';
+
+// The URI of an object, as list_objects links to it and read_object_content
+// names its raw content.
+export const objectUri = (bucket: string, object: string): string => `gcs://${bucket}/${object}`;
+
+export async function readObjectResource(
+  uri: URL,
+  variables: Record<string, string | string[]>,
+): Promise<ReadResourceResult> {
+  const bucket = String(variables['bucket']);
+  const object = String(variables['object']);
+  const result = await readObjectContent({ bucket_name: bucket, object_name: object });
+  const [content] = result.content;
+  if (content?.type === 'resource') {
+    return { contents: [{ ...content.resource, uri: uri.href }] };
+  }
+  const parsed = JSON.parse(content?.type === 'text' ? content.text : '{}');
+  if (parsed.error) {
+    throw new Error(parsed.error);
+  }
+  return { contents: [{ uri: uri.href, mimeType: parsed.content_type, text: parsed.content }] };
+}
+
+export const registerObjectResource = (server: McpServer) => {
+  server.registerResource(
+    'object',
+    new ResourceTemplate('gcs://{bucket}/{+object}', { list: undefined }),
+    {
+      description: 'The content of a Google Cloud Storage object, as read_object_content reads it.',
+    },
+    readObjectResource,
+  );
+};
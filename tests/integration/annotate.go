@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// annotationFlags are the -note and -label flags shared by a run and the
+// annotate command.
+type annotationFlags struct {
+	notes, labels stringList
+}
+
+func (a *annotationFlags) register(fs *flag.FlagSet) {
+	fs.Var(&a.notes, "note", "attach this remark to the run's results, e.g. \"ran against the canary backend\" (repeatable)")
+	fs.Var(&a.labels, "label", "attach this key=value label to the run's results (repeatable)")
+}
+
+// parse returns the notes, signed by the current user, and labels given.
+func (a *annotationFlags) parse() ([]report.Note, map[string]string, error) {
+	labels := map[string]string{}
+	for _, l := range a.labels {
+		k, v, err := report.ParseLabel(l)
+		if err != nil {
+			return nil, nil, err
+		}
+		labels[k] = v
+	}
+	author := currentUser()
+	now := time.Now()
+	var notes []report.Note
+	for _, text := range a.notes {
+		notes = append(notes, report.Note{Text: text, Author: author, At: now})
+	}
+	return notes, labels, nil
+}
+
+// currentUser returns the name notes and triage decisions are signed with.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// runAnnotate implements `annotate [-note TEXT] [-label KEY=VALUE]
+// <results.json>`, adding notes and labels to the results of a finished run.
+func runAnnotate(args []string) int {
+	fs := flag.NewFlagSet("annotate", flag.ContinueOnError)
+	var annotations annotationFlags
+	annotations.register(fs)
+	junitPath := fs.String("junit", "", "also rewrite this JUnit XML report of the run")
+	htmlPath := fs.String("html", "", "also rewrite this HTML report of the run")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 || len(annotations.notes)+len(annotations.labels) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test annotate [-note TEXT]... [-label KEY=VALUE]... [-junit FILE] [-html FILE] <results.json>")
+		return exitUsage
+	}
+	notes, labels, err := annotations.parse()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	results, err := report.ReadJSON(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	results.Annotate(notes, labels)
+	writeReports(results, fs.Arg(0), *junitPath, *htmlPath)
+	logger.Printf("📝 Annotated %s\n", fs.Arg(0))
+	return exitPass
+}
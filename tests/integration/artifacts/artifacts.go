@@ -0,0 +1,257 @@
+// Package artifacts keeps the files a run writes for its tests within size
+// budgets, so that one chatty test cannot turn into gigabytes of CI
+// artifacts.
+//
+// Each test writes into a directory of its own. Once a directory, or all of
+// them together, outgrows its budget, the largest files of the test (or of
+// the oldest tests, for the run's budget) are compressed or trimmed until the
+// budget is met, and the overage is returned for the report.
+package artifacts
+
+import (
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Policy is how files are shrunk to fit a budget. It implements flag.Value.
+type Policy string
+
+const (
+	// Compress gzips the largest files, keeping all of their content.
+	Compress Policy = "compress"
+	// Trim cuts the middle out of the largest files, keeping their head and
+	// tail readable without decompressing anything.
+	Trim Policy = "trim"
+)
+
+func (p Policy) String() string { return string(p) }
+
+// Set parses a policy name.
+func (p *Policy) Set(s string) error {
+	switch Policy(s) {
+	case Compress, Trim:
+		*p = Policy(s)
+		return nil
+	}
+	return fmt.Errorf("unknown artifact policy %q; want %s or %s", s, Compress, Trim)
+}
+
+// minTrimmed is the least a trimmed file keeps of its head and tail together.
+const minTrimmed = 4 << 10
+
+// Budget limits the size of a run's artifacts. A zero limit is unlimited.
+type Budget struct {
+	PerTest Size
+	PerRun  Size
+	Policy  Policy
+	// Keep names files, by base name, that are never shrunk, such as a
+	// test's repro script.
+	Keep []string
+}
+
+// Overage records a budget that was exceeded and what was done to meet it.
+type Overage struct {
+	Budget Size `json:"budget_bytes"`
+	// Before and After are the sizes before and after shrinking. After is
+	// still over Budget if not enough could be shrunk.
+	Before Size `json:"before_bytes"`
+	After  Size `json:"after_bytes"`
+	// Compressed and Trimmed list the shrunk files relative to the artifacts
+	// directory, as <testID>/<file>.
+	Compressed []string `json:"compressed,omitempty"`
+	Trimmed    []string `json:"trimmed,omitempty"`
+}
+
+// Over reports whether the budget is still exceeded.
+func (o *Overage) Over() bool {
+	return o.After > o.Budget
+}
+
+func (o *Overage) String() string {
+	s := fmt.Sprintf("%s, over the %s budget", o.Before, o.Budget)
+	if n := len(o.Compressed); n > 0 {
+		s += fmt.Sprintf("; compressed %s", strings.Join(o.Compressed, ", "))
+	}
+	if n := len(o.Trimmed); n > 0 {
+		s += fmt.Sprintf("; trimmed %s", strings.Join(o.Trimmed, ", "))
+	}
+	s += fmt.Sprintf("; now %s", o.After)
+	if o.Over() {
+		s += ", still over"
+	}
+	return s
+}
+
+// EnforceTest shrinks the largest files in dir, a test's artifacts directory,
+// until it fits in b.PerTest. It returns nil if the directory already fits.
+func (b *Budget) EnforceTest(dir string) (*Overage, error) {
+	if b.PerTest <= 0 {
+		return nil, nil
+	}
+	total, files, err := b.list(dir)
+	if err != nil {
+		return nil, err
+	}
+	sortLargestFirst(files)
+	return b.shrink(files, total, b.PerTest)
+}
+
+// EnforceRun shrinks the files in dirs, the tests' artifacts directories from
+// oldest to newest, until they fit in b.PerRun together. The oldest tests'
+// files are shrunk first, largest first within a test. It returns nil if the
+// directories already fit.
+func (b *Budget) EnforceRun(dirs []string) (*Overage, error) {
+	if b.PerRun <= 0 {
+		return nil, nil
+	}
+	var (
+		total Size
+		files []file
+	)
+	for _, dir := range dirs {
+		size, inDir, err := b.list(dir)
+		if err != nil {
+			return nil, err
+		}
+		sortLargestFirst(inDir)
+		total += size
+		files = append(files, inDir...)
+	}
+	return b.shrink(files, total, b.PerRun)
+}
+
+type file struct {
+	path string
+	size Size
+}
+
+// list returns the total size of the files in dir and those that may be
+// shrunk. A missing directory is empty.
+func (b *Budget) list(dir string) (Size, []file, error) {
+	var (
+		total Size
+		files []file
+	)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += Size(info.Size())
+		if !slices.Contains(b.Keep, d.Name()) {
+			files = append(files, file{path, Size(info.Size())})
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil, nil
+	}
+	return total, files, err
+}
+
+func sortLargestFirst(files []file) {
+	slices.SortStableFunc(files, func(a, b file) int { return cmp.Compare(b.size, a.size) })
+}
+
+// shrink applies the policy to files in order until total fits in budget.
+func (b *Budget) shrink(files []file, total, budget Size) (*Overage, error) {
+	if total <= budget {
+		return nil, nil
+	}
+	o := &Overage{Budget: budget, Before: total}
+	for _, f := range files {
+		if total <= budget {
+			break
+		}
+		var (
+			size Size
+			err  error
+		)
+		if b.Policy == Trim {
+			size, err = trim(f.path, f.size, max(f.size-(total-budget), minTrimmed))
+		} else {
+			size, err = compress(f.path, f.size)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to shrink artifact %s: %w", f.path, err)
+		}
+		if size >= f.size {
+			continue
+		}
+		total -= f.size - size
+		name := filepath.Join(filepath.Base(filepath.Dir(f.path)), filepath.Base(f.path))
+		if b.Policy == Trim {
+			o.Trimmed = append(o.Trimmed, name)
+		} else {
+			o.Compressed = append(o.Compressed, name)
+		}
+	}
+	o.After = total
+	return o, nil
+}
+
+// compress replaces the file at path with path.gz if that is smaller, and
+// returns the resulting size.
+func compress(path string, size Size) (Size, error) {
+	if strings.HasSuffix(path, ".gz") {
+		return size, nil
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	info, statErr := os.Stat(path + ".gz")
+	if err == nil {
+		err = statErr
+	}
+	if err != nil || Size(info.Size()) >= size {
+		os.Remove(path + ".gz")
+		return size, err
+	}
+	return Size(info.Size()), os.Remove(path)
+}
+
+// trim cuts the middle out of the file at path, replacing it with a marker,
+// so that the file is at most target bytes, and returns the resulting size.
+func trim(path string, size, target Size) (Size, error) {
+	if target >= size || strings.HasSuffix(path, ".gz") {
+		return size, nil
+	}
+	marker := fmt.Sprintf("\n[… trimmed from %s to fit the artifact budget …]\n", size)
+	keep := target - Size(len(marker))
+	if keep <= 0 {
+		return size, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	trimmed := slices.Concat(data[:keep/2], []byte(marker), data[len(data)-int(keep-keep/2):])
+	if err := os.WriteFile(path, trimmed, 0o644); err != nil {
+		return 0, err
+	}
+	return Size(len(trimmed)), nil
+}
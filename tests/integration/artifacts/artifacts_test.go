@@ -0,0 +1,127 @@
+package artifacts
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnforceTestCompresses(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "chatty")
+	log := bytes.Repeat([]byte("the same line over and over\n"), 10000)
+	writeFile(t, filepath.Join(dir, "output.log"), log)
+	writeFile(t, filepath.Join(dir, "repro.sh"), bytes.Repeat([]byte("#"), 20000))
+
+	b := &Budget{PerTest: 64 << 10, Policy: Compress, Keep: []string{"repro.sh"}}
+	o, err := b.EnforceTest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o == nil || !slices.Equal(o.Compressed, []string{"chatty/output.log"}) || o.Over() {
+		t.Fatalf("EnforceTest = %+v, want output.log compressed to fit", o)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "repro.sh")); err != nil {
+		t.Errorf("kept file was touched: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "output.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(zr); err != nil || !bytes.Equal(got, log) {
+		t.Errorf("compressed log does not round-trip: %v", err)
+	}
+}
+
+func TestEnforceTestWithinBudget(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "output.log"), []byte("short"))
+	b := &Budget{PerTest: 1 << 10, Policy: Compress}
+	if o, err := b.EnforceTest(dir); o != nil || err != nil {
+		t.Errorf("EnforceTest = %+v, %v; want nil", o, err)
+	}
+}
+
+func TestEnforceRunTrimsOldestFirst(t *testing.T) {
+	root := t.TempDir()
+	old, recent := filepath.Join(root, "old"), filepath.Join(root, "recent")
+	writeFile(t, filepath.Join(old, "output.log"), []byte("head"+strings.Repeat("x", 100<<10)+"tail"))
+	writeFile(t, filepath.Join(recent, "output.log"), bytes.Repeat([]byte("y"), 100<<10))
+
+	b := &Budget{PerRun: 150 << 10, Policy: Trim}
+	o, err := b.EnforceRun([]string{old, recent, filepath.Join(root, "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o == nil || !slices.Equal(o.Trimmed, []string{"old/output.log"}) || o.Over() {
+		t.Fatalf("EnforceRun = %+v, want only the oldest test trimmed to fit", o)
+	}
+	data, err := os.ReadFile(filepath.Join(old, "output.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("head")) || !bytes.HasSuffix(data, []byte("tail")) || !bytes.Contains(data, []byte("trimmed from 100.0KiB to fit")) {
+		t.Errorf("trimmed log lost its head, tail or marker: %q…", data[:40])
+	}
+}
+
+func TestEnforceRunStillOver(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "t")
+	writeFile(t, filepath.Join(dir, "repro.sh"), bytes.Repeat([]byte("#"), 10<<10))
+	b := &Budget{PerRun: 1 << 10, Policy: Compress, Keep: []string{"repro.sh"}}
+	o, err := b.EnforceRun([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o == nil || !o.Over() || !strings.HasSuffix(o.String(), "still over") {
+		t.Errorf("EnforceRun = %v, want the overage reported as unmet", o)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := map[string]Size{"512": 512, "64KiB": 64 << 10, "64k": 64 << 10, "1.5MB": 3 << 19, "2GiB": 2 << 30}
+	for in, want := range tests {
+		var s Size
+		if err := s.Set(in); err != nil || s != want {
+			t.Errorf("Set(%q) = %d, %v; want %d", in, s, err, want)
+		}
+	}
+	for _, in := range []string{"", "MiB", "-1K", "lots"} {
+		var s Size
+		if err := s.Set(in); err == nil {
+			t.Errorf("Set(%q) succeeded", in)
+		}
+	}
+	if got := Size(3 << 19).String(); got != "1.5MiB" {
+		t.Errorf("String = %s", got)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	var p Policy
+	if err := p.Set("trim"); err != nil || p != Trim {
+		t.Errorf("Set(trim) = %s, %v", p, err)
+	}
+	if err := p.Set("delete"); err == nil {
+		t.Error("Set(delete) succeeded")
+	}
+}
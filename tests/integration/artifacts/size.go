@@ -0,0 +1,44 @@
+package artifacts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Size is a number of bytes. It implements flag.Value, parsing sizes such as
+// 512KiB, 64MiB or 1GiB; "64M" and "64MB" mean the same as "64MiB".
+type Size int64
+
+var units = []struct {
+	suffix string
+	bytes  Size
+}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}}
+
+func (s Size) String() string {
+	for _, u := range units {
+		if s >= u.bytes {
+			return strconv.FormatFloat(float64(s)/float64(u.bytes), 'f', 1, 64) + u.suffix + "iB"
+		}
+	}
+	return fmt.Sprintf("%dB", int64(s))
+}
+
+// Set parses a size.
+func (s *Size) Set(v string) error {
+	n := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(v)), "B")
+	n = strings.TrimSuffix(n, "I")
+	unit := Size(1)
+	for _, u := range units {
+		if rest, ok := strings.CutSuffix(n, u.suffix); ok {
+			n, unit = rest, u.bytes
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid size %q", v)
+	}
+	*s = Size(f * float64(unit))
+	return nil
+}
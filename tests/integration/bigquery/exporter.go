@@ -0,0 +1,219 @@
+// Package bigquery appends one row per test of an integration run to a
+// BigQuery table, so flakiness and latency can be analyzed across months of
+// nightly runs.
+package bigquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+const defaultEndpoint = "https://bigquery.googleapis.com/bigquery/v2"
+
+// maxRowsPerRequest keeps each insertAll request well under the API's
+// limit.
+const maxRowsPerRequest = 500
+
+// Table is a BigQuery table.
+type Table struct {
+	Project, Dataset, Table string
+}
+
+// ParseTable parses PROJECT.DATASET.TABLE or PROJECT:DATASET.TABLE.
+func ParseTable(s string) (Table, error) {
+	parts := strings.Split(strings.Replace(s, ":", ".", 1), ".")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return Table{}, fmt.Errorf("invalid BigQuery table %q; want PROJECT.DATASET.TABLE", s)
+	}
+	return Table{parts[0], parts[1], parts[2]}, nil
+}
+
+func (t Table) String() string {
+	return t.Project + "." + t.Dataset + "." + t.Table
+}
+
+// Row is one test of a run.
+type Row struct {
+	RunStarted time.Time `json:"run_started"`
+	Seed       int64     `json:"seed"`
+	Shard      string    `json:"shard,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	Harness    string    `json:"harness,omitempty"`
+	Platform   string    `json:"platform,omitempty"`
+	Test       string    `json:"test"`
+	Started    time.Time `json:"started"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	DurationMS float64   `json:"duration_ms"`
+	// Attempts is 1 plus the failed attempts before the recorded one.
+	Attempts       int             `json:"attempts"`
+	ServerVersions []ServerVersion `json:"server_versions,omitempty"`
+	Labels         []Label         `json:"labels,omitempty"`
+}
+
+// ServerVersion is the version a server under test ran as.
+type ServerVersion struct {
+	Server  string `json:"server"`
+	Version string `json:"version"`
+}
+
+// Label is one of the run's labels; BigQuery has no map type.
+type Label struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Rows returns the rows of run, one per test, taking the server versions
+// from its fingerprints.
+func Rows(run *report.Run) []Row {
+	var versions []ServerVersion
+	for _, f := range run.Fingerprints {
+		if f.Version != "" {
+			versions = append(versions, ServerVersion{Server: f.Server, Version: f.Version})
+		}
+	}
+	var labels []Label
+	for _, k := range slices.Sorted(maps.Keys(run.Labels)) {
+		labels = append(labels, Label{Key: k, Value: run.Labels[k]})
+	}
+	shard := ""
+	if run.Shard != nil {
+		shard = run.Shard.String()
+	}
+	rows := make([]Row, 0, len(run.Tests))
+	for _, t := range run.Tests {
+		rows = append(rows, Row{
+			RunStarted:     run.Started,
+			Seed:           run.Seed,
+			Shard:          shard,
+			Commit:         run.Commit,
+			Harness:        run.Harness,
+			Platform:       run.Platform,
+			Test:           t.ID,
+			Started:        t.Started,
+			Status:         string(t.Status),
+			Reason:         t.Reason,
+			DurationMS:     float64(t.Duration) / float64(time.Millisecond),
+			Attempts:       len(t.FailedAttempts) + 1,
+			ServerVersions: versions,
+			Labels:         labels,
+		})
+	}
+	return rows
+}
+
+// Exporter appends runs to a table, which must exist with the schema of
+// schema.json.
+type Exporter struct {
+	Table Table
+	// Endpoint overrides the BigQuery API base URL.
+	Endpoint string
+	// Token returns an OAuth access token. Defaults to gcloudauth.AccessToken.
+	Token      func(context.Context) (string, error)
+	HTTPClient *http.Client
+}
+
+// Export appends the rows of run with tabledata.insertAll. Each row's insert
+// ID is derived from the run and the test, so a retried export does not
+// duplicate rows.
+func (e *Exporter) Export(ctx context.Context, run *report.Run) error {
+	token, err := e.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+	rows := Rows(run)
+	for start := 0; start < len(rows); start += maxRowsPerRequest {
+		end := min(start+maxRowsPerRequest, len(rows))
+		if err := e.insert(ctx, token, rows[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type insertRow struct {
+	InsertID string `json:"insertId"`
+	JSON     Row    `json:"json"`
+}
+
+func (e *Exporter) insert(ctx context.Context, token string, rows []Row) error {
+	req := struct {
+		Rows []insertRow `json:"rows"`
+	}{}
+	for _, r := range rows {
+		id := fmt.Sprintf("%d-%d-%s-%s", r.RunStarted.UnixNano(), r.Seed, r.Shard, r.Test)
+		req.Rows = append(req.Rows, insertRow{InsertID: id, JSON: r})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	endpoint := e.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	url := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", endpoint, e.Table.Project, e.Table.Dataset, e.Table.Table)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpClient := e.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to insert rows into %s: %w", e.Table, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to insert rows into %s: %s: %s", e.Table, resp.Status, bytes.TrimSpace(data))
+	}
+	// A 200 can still reject rows, e.g. for a schema mismatch.
+	var result struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse the insertAll response: %w", err)
+	}
+	if len(result.InsertErrors) > 0 {
+		first := result.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) > 0 {
+			msg = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		test := "?"
+		if first.Index >= 0 && first.Index < len(rows) {
+			test = rows[first.Index].Test
+		}
+		return fmt.Errorf("%s rejected %d of %d rows; the row of %s: %s", e.Table, len(result.InsertErrors), len(rows), test, msg)
+	}
+	return nil
+}
+
+func (e *Exporter) token(ctx context.Context) (string, error) {
+	if e.Token != nil {
+		return e.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
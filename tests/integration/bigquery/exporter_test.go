@@ -0,0 +1,131 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestParseTable(t *testing.T) {
+	for _, s := range []string{"p.d.t", "p:d.t"} {
+		if got, err := ParseTable(s); err != nil || got != (Table{"p", "d", "t"}) {
+			t.Errorf("ParseTable(%q) = %+v, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"d.t", "p.d.t.x", "p..t", ""} {
+		if _, err := ParseTable(s); err == nil {
+			t.Errorf("ParseTable(%q) succeeded", s)
+		}
+	}
+}
+
+func testRun() *report.Run {
+	return &report.Run{
+		Started:      time.Unix(100, 0).UTC(),
+		Seed:         7,
+		Commit:       "abc123",
+		Harness:      "v1.2.0",
+		Labels:       map[string]string{"z": "1", "backend": "canary"},
+		Fingerprints: []fingerprint.Fingerprint{{Server: "gcloud", Version: "0.5.1"}, {Server: "local"}},
+		Tests: []report.TestResult{
+			{ID: "a", Status: report.StatusPassed, Started: time.Unix(101, 0).UTC(), Duration: 1500 * time.Microsecond},
+			{ID: "b", Status: report.StatusFlaky, Reason: report.ReasonHang, FailedAttempts: []report.Attempt{{Reason: report.ReasonHang}}},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	var got struct {
+		Rows []insertRow `json:"rows"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/p/datasets/d/tables/t/insertAll" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"kind":"bigquery#tableDataInsertAllResponse"}`))
+	}))
+	defer srv.Close()
+
+	e := &Exporter{Table: Table{"p", "d", "t"}, Endpoint: srv.URL, Token: func(context.Context) (string, error) { return "tok", nil }}
+	if err := e.Export(context.Background(), testRun()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rows) != 2 {
+		t.Fatalf("inserted %d rows, want 2", len(got.Rows))
+	}
+	a, b := got.Rows[0].JSON, got.Rows[1].JSON
+	if a.Test != "a" || a.Status != "passed" || a.DurationMS != 1.5 || a.Attempts != 1 || a.Commit != "abc123" || a.Seed != 7 {
+		t.Errorf("row a = %+v", a)
+	}
+	if b.Attempts != 2 || b.Reason != report.ReasonHang {
+		t.Errorf("row b = %+v", b)
+	}
+	if !slices.Equal(a.ServerVersions, []ServerVersion{{"gcloud", "0.5.1"}}) {
+		t.Errorf("server versions = %+v", a.ServerVersions)
+	}
+	if !slices.Equal(a.Labels, []Label{{"backend", "canary"}, {"z", "1"}}) {
+		t.Errorf("labels = %+v", a.Labels)
+	}
+	if got.Rows[0].InsertID == got.Rows[1].InsertID || got.Rows[0].InsertID != "100000000000-7--a" {
+		t.Errorf("insert IDs = %s, %s", got.Rows[0].InsertID, got.Rows[1].InsertID)
+	}
+}
+
+func TestExportInsertErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"insertErrors":[{"index":1,"errors":[{"reason":"invalid","message":"no such field: attempts"}]}]}`))
+	}))
+	defer srv.Close()
+	e := &Exporter{Table: Table{"p", "d", "t"}, Endpoint: srv.URL, Token: func(context.Context) (string, error) { return "tok", nil }}
+	err := e.Export(context.Background(), testRun())
+	if err == nil || !strings.Contains(err.Error(), "the row of b: invalid: no such field: attempts") {
+		t.Errorf("Export() = %v, want the rejected row", err)
+	}
+}
+
+// TestSchema checks that schema.json, which tables are created with, has a
+// column for every field of Row and no others.
+func TestSchema(t *testing.T) {
+	data, err := os.ReadFile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema []struct {
+		Name   string `json:"name"`
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	var columns []string
+	for _, c := range schema {
+		columns = append(columns, c.Name)
+	}
+	var fields []string
+	rt := reflect.TypeFor[Row]()
+	for i := range rt.NumField() {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	if !slices.Equal(columns, fields) {
+		t.Errorf("schema.json columns %v, Row fields %v", columns, fields)
+	}
+}
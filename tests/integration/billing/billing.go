@@ -0,0 +1,201 @@
+// Package billing caps the tool calls of a run that cost money in the test
+// project, such as storage writes and log ingestion, so a test stuck in a
+// loop aborts the run instead of running up charges.
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+	"github.com/googleapis/gcloud-mcp/tests/integration/oracle"
+)
+
+// ErrExceeded is wrapped by the error of a call an exhausted budget refused.
+var ErrExceeded = errors.New("billing budget exceeded")
+
+// File is the parsed budget file.
+type File struct {
+	Operations []Operation `yaml:"operations"`
+}
+
+// Operation is a kind of billable call and how many of them a run may make.
+type Operation struct {
+	Name string `yaml:"name"`
+	// Limit is the most calls of the operation a run may make.
+	Limit int `yaml:"limit"`
+	// Calls select the tool calls that count as the operation.
+	Calls []Match `yaml:"calls"`
+}
+
+// Match selects tool calls by server, tool and, for run_gcloud_command,
+// gcloud command.
+type Match struct {
+	// Server is the registered name of the tool's server; empty matches the
+	// tool on every server.
+	Server string `yaml:"server,omitempty"`
+	Tool   string `yaml:"tool"`
+	// Command, if set, matches only calls whose args argument, such as
+	// run_gcloud_command's, starts with these words once flags and their
+	// values are left out, e.g. [storage, cp].
+	Command []string `yaml:"command,omitempty"`
+}
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Load reads a budget file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*File, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse billing budget %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, op := range f.Operations {
+		if !validName.MatchString(op.Name) {
+			return nil, fmt.Errorf("%s: operation name %q must be lowercase letters, digits and dashes", path, op.Name)
+		}
+		if seen[op.Name] {
+			return nil, fmt.Errorf("%s: operation %s is declared more than once", path, op.Name)
+		}
+		seen[op.Name] = true
+		if op.Limit <= 0 || len(op.Calls) == 0 {
+			return nil, fmt.Errorf("%s: operation %s needs a positive limit and calls", path, op.Name)
+		}
+		for i, m := range op.Calls {
+			if m.Tool == "" {
+				return nil, fmt.Errorf("%s: operation %s call %d needs a tool", path, op.Name, i+1)
+			}
+		}
+	}
+	return &f, nil
+}
+
+// operation returns the first operation a call of tool on server with args
+// counts as, or nil if the call is free.
+func (f *File) operation(server, tool string, args any) *Operation {
+	var words []string
+	parsed := false
+	for i := range f.Operations {
+		op := &f.Operations[i]
+		for _, m := range op.Calls {
+			if m.Tool != tool || m.Server != "" && m.Server != server {
+				continue
+			}
+			if len(m.Command) > 0 {
+				if !parsed {
+					words, parsed = commandWords(args), true
+				}
+				if len(words) < len(m.Command) || !slices.Equal(words[:len(m.Command)], m.Command) {
+					continue
+				}
+			}
+			return op
+		}
+	}
+	return nil
+}
+
+// commandWords returns the args argument of a call's arguments without its
+// flags and their values, as oracle.Command parses them, or nil if it has
+// none.
+func commandWords(args any) []string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil
+	}
+	var parsed struct {
+		Args []string `json:"args"`
+	}
+	if json.Unmarshal(data, &parsed) != nil {
+		return nil
+	}
+	return oracle.Command(parsed.Args)
+}
+
+// Usage is how many calls of an operation a run made.
+type Usage struct {
+	Operation string `json:"operation"`
+	Calls     int    `json:"calls"`
+	Limit     int    `json:"limit"`
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%s %d/%d", u.Operation, u.Calls, u.Limit)
+}
+
+// Budget counts a run's billable calls against the limits of a File. Once a
+// call would exceed its operation's limit, the budget refuses it and every
+// later billable call. It is safe for concurrent use; a nil *Budget allows
+// everything.
+type Budget struct {
+	file *File
+
+	mu       sync.Mutex
+	calls    map[string]int
+	exceeded error
+}
+
+// New returns a budget for the operations of f.
+func New(f *File) *Budget {
+	return &Budget{file: f, calls: map[string]int{}}
+}
+
+// Charge counts a call of tool on server with args against its operation,
+// if it is billable. It returns an error wrapping ErrExceeded, and counts
+// nothing, if the call would exceed the limit or the budget was already
+// exceeded; the call must then not be made.
+func (b *Budget) Charge(server, tool string, args any) error {
+	if b == nil {
+		return nil
+	}
+	op := b.file.operation(server, tool, args)
+	if op == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exceeded != nil {
+		return b.exceeded
+	}
+	if b.calls[op.Name] >= op.Limit {
+		b.exceeded = fmt.Errorf("%w: %s would make %s call %d of the %d the run may make", ErrExceeded, tool, op.Name, op.Limit+1, op.Limit)
+		return b.exceeded
+	}
+	b.calls[op.Name]++
+	return nil
+}
+
+// Exceeded returns the error of the first call the budget refused, or nil.
+func (b *Budget) Exceeded() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// Usage returns the calls made of each operation, in the file's order.
+func (b *Budget) Usage() []Usage {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	usage := make([]Usage, len(b.file.Operations))
+	for i, op := range b.file.Operations {
+		usage[i] = Usage{Operation: op.Name, Calls: b.calls[op.Name], Limit: op.Limit}
+	}
+	return usage
+}
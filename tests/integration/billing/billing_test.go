@@ -0,0 +1,92 @@
+package billing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if f, err := Load(filepath.Join(dir, "missing.yaml"), true); err != nil || len(f.Operations) != 0 {
+		t.Errorf("Load(missing) = %v, %v", f, err)
+	}
+	for body, want := range map[string]string{
+		"operations:\n  - {name: Writes, limit: 1, calls: [{tool: t}]}\n":                                         "lowercase",
+		"operations:\n  - {name: a, limit: 1, calls: [{tool: t}]}\n  - {name: a, limit: 1, calls: [{tool: t}]}\n": "more than once",
+		"operations:\n  - {name: a, limit: 0, calls: [{tool: t}]}\n":                                              "positive limit",
+		"operations:\n  - {name: a, limit: 1, calls: [{server: s}]}\n":                                            "needs a tool",
+	} {
+		path := filepath.Join(dir, "budget.yaml")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, false); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) = %v, want an error containing %q", body, err, want)
+		}
+	}
+}
+
+type gcloudArgs struct {
+	Args []string `json:"args"`
+}
+
+func TestCharge(t *testing.T) {
+	b := New(&File{Operations: []Operation{
+		{Name: "storage-writes", Limit: 2, Calls: []Match{
+			{Server: "storage", Tool: "write_object"},
+			{Server: "gcloud", Tool: "run_gcloud_command", Command: []string{"storage", "cp"}},
+		}},
+		{Name: "log-ingestion", Limit: 5, Calls: []Match{{Tool: "write_log_entries"}}},
+	}})
+	// Free calls are never counted.
+	for _, call := range []struct {
+		server, tool string
+		args         any
+	}{
+		{"storage", "list_objects", nil},
+		{"other", "write_object", nil},
+		{"gcloud", "run_gcloud_command", gcloudArgs{Args: []string{"storage", "ls", "gs://b"}}},
+	} {
+		if err := b.Charge(call.server, call.tool, call.args); err != nil {
+			t.Errorf("Charge(%s, %s) = %v, want a free call", call.server, call.tool, err)
+		}
+	}
+	if err := b.Charge("storage", "write_object", map[string]any{"bucket_name": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	// Flags and their values are left out of the command.
+	if err := b.Charge("gcloud", "run_gcloud_command", gcloudArgs{Args: []string{"--project", "p", "storage", "cp", "a", "gs://b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if usage := b.Usage(); usage[0].Calls != 2 {
+		t.Errorf("storage-writes calls = %d after a storage cp with a flag value first, want 2", usage[0].Calls)
+	}
+	if err := b.Charge("logging", "write_log_entries", nil); err != nil {
+		t.Fatal(err)
+	}
+	err := b.Charge("storage", "write_object", nil)
+	if !errors.Is(err, ErrExceeded) || !strings.Contains(err.Error(), "storage-writes call 3 of the 2") {
+		t.Errorf("third storage write = %v, want ErrExceeded", err)
+	}
+	// An exceeded budget refuses every billable call, but not free ones.
+	if err := b.Charge("logging", "write_log_entries", nil); !errors.Is(err, ErrExceeded) {
+		t.Errorf("log write after the budget was exceeded = %v, want ErrExceeded", err)
+	}
+	if err := b.Charge("storage", "list_objects", nil); err != nil {
+		t.Errorf("free call after the budget was exceeded = %v", err)
+	}
+	if b.Exceeded() == nil {
+		t.Error("Exceeded() = nil after a refused call")
+	}
+	want := []Usage{{"storage-writes", 2, 2}, {"log-ingestion", 1, 5}}
+	if got := b.Usage(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Usage() = %v, want %v", got, want)
+	}
+	var none *Budget
+	if err := none.Charge("storage", "write_object", nil); err != nil || none.Exceeded() != nil {
+		t.Errorf("nil budget refused a call: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// chargeBilling is the Guard of every call while the run has a billing
+// budget: it charges the call to its operation, refusing it once the budget
+// is spent.
+func chargeBilling(call client.ToolCall) error {
+	var server string
+	if len(call.ServerCmd) > 0 {
+		server = registeredName(filepath.Base(call.ServerCmd[0]))
+	}
+	return billingBudget.Charge(server, call.ToolName, call.ToolArgs)
+}
+
+// joinUsage lists the billable operations made, e.g. "storage-writes 3/200".
+func joinUsage(usage []billing.Usage) string {
+	parts := make([]string, len(usage))
+	for i, u := range usage {
+		parts[i] = u.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,128 @@
+// Package blackboard lets tests and fixtures hand values to each other within
+// a run. Every value is published under a typed key together with the name of
+// its publisher, so a consumer that finds nothing can say who it expected to
+// provide the value instead of failing on a missing environment variable.
+package blackboard
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotPublished is returned by Get when nothing was published for a key.
+var ErrNotPublished = errors.New("blackboard value not published")
+
+// Key names a blackboard value of type T.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns the key for values of type T published under name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the key's name.
+func (k Key[T]) Name() string { return k.name }
+
+// Entry is a published value and its provenance.
+type Entry struct {
+	Key       string
+	Value     any
+	Publisher string
+	At        time.Time
+}
+
+// Board is a run-scoped set of published values. It is safe for concurrent
+// use.
+type Board struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// New returns an empty Board.
+func New() *Board {
+	return &Board{entries: map[string]Entry{}}
+}
+
+// Publish stores v under k on behalf of publisher. Values are write-once: a
+// second Publish for the same key fails and names the original publisher.
+func Publish[T any](b *Board, k Key[T], v T, publisher string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if prev, ok := b.entries[k.name]; ok {
+		return fmt.Errorf("blackboard key %q already published by %s", k.name, prev.Publisher)
+	}
+	b.entries[k.name] = Entry{Key: k.name, Value: v, Publisher: publisher, At: time.Now()}
+	return nil
+}
+
+// Get returns the value published under k, or an error wrapping
+// ErrNotPublished.
+func Get[T any](b *Board, k Key[T]) (T, error) {
+	v, _, err := Lookup(b, k)
+	return v, err
+}
+
+// Lookup is like Get but also returns the entry describing who published the
+// value and when.
+func Lookup[T any](b *Board, k Key[T]) (T, Entry, error) {
+	var zero T
+	b.mu.RLock()
+	e, ok := b.entries[k.name]
+	b.mu.RUnlock()
+	if !ok {
+		return zero, Entry{}, fmt.Errorf("%w: %q (published so far: %s)", ErrNotPublished, k.name, b.keyList())
+	}
+	v, ok := e.Value.(T)
+	if !ok {
+		return zero, e, fmt.Errorf("blackboard key %q holds %T published by %s, not %T", k.name, e.Value, e.Publisher, zero)
+	}
+	return v, e, nil
+}
+
+// Clone returns a Board holding the entries of b, which later Publish calls
+// on either board do not affect.
+func (b *Board) Clone() *Board {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return &Board{entries: maps.Clone(b.entries)}
+}
+
+// Restore replaces the entries of b with those of snapshot, such as a Clone
+// taken before a test that is about to be retried.
+func (b *Board) Restore(snapshot *Board) {
+	entries := snapshot.Clone().entries
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = entries
+}
+
+// Entries returns every published entry sorted by key.
+func (b *Board) Entries() []Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make([]Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b Entry) int { return strings.Compare(a.Key, b.Key) })
+	return out
+}
+
+func (b *Board) keyList() string {
+	entries := b.Entries()
+	if len(entries) == 0 {
+		return "none"
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Key
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,111 @@
+package blackboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	bucketKey = NewKey[string]("bucket")
+	countKey  = NewKey[int]("count")
+)
+
+func TestPublishGet(t *testing.T) {
+	b := New()
+	if err := Publish(b, bucketKey, "mcp-test-bucket", "create-bucket"); err != nil {
+		t.Fatal(err)
+	}
+	got, e, err := Lookup(b, bucketKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "mcp-test-bucket" || e.Publisher != "create-bucket" {
+		t.Errorf("Lookup() = %q, %+v", got, e)
+	}
+}
+
+func TestPublishTwice(t *testing.T) {
+	b := New()
+	if err := Publish(b, bucketKey, "a", "first"); err != nil {
+		t.Fatal(err)
+	}
+	err := Publish(b, bucketKey, "b", "second")
+	if err == nil || !strings.Contains(err.Error(), "first") {
+		t.Errorf("second Publish() = %v, want error naming first publisher", err)
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	b := New()
+	Publish(b, countKey, 1, "counter")
+	_, err := Get(b, bucketKey)
+	if !errors.Is(err, ErrNotPublished) || !strings.Contains(err.Error(), "count") {
+		t.Errorf("Get() = %v", err)
+	}
+}
+
+func TestGetWrongType(t *testing.T) {
+	b := New()
+	Publish(b, NewKey[int]("bucket"), 3, "confused")
+	if _, err := Get(b, bucketKey); err == nil || errors.Is(err, ErrNotPublished) {
+		t.Errorf("Get() = %v, want type mismatch", err)
+	}
+}
+
+func TestConcurrentPublish(t *testing.T) {
+	b := New()
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k := NewKey[int](fmt.Sprintf("k%02d", i))
+			if err := Publish(b, k, i, "worker"); err != nil {
+				t.Error(err)
+			}
+			Get(b, k)
+		}()
+	}
+	wg.Wait()
+	if n := len(b.Entries()); n != 50 {
+		t.Errorf("got %d entries, want 50", n)
+	}
+}
+
+func TestClone(t *testing.T) {
+	b := New()
+	if err := Publish(b, bucketKey, "mcp-test-bucket", "create-bucket"); err != nil {
+		t.Fatal(err)
+	}
+	c := b.Clone()
+	if err := Publish(c, countKey, 1, "count"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get(c, bucketKey); err != nil || got != "mcp-test-bucket" {
+		t.Errorf("Get(clone) = %q, %v", got, err)
+	}
+	if _, err := Get(b, countKey); !errors.Is(err, ErrNotPublished) {
+		t.Errorf("publishing on the clone reached the original: %v", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	b := New()
+	if err := Publish(b, bucketKey, "mcp-test-bucket", "create-bucket"); err != nil {
+		t.Fatal(err)
+	}
+	before := b.Clone()
+	if err := Publish(b, countKey, 1, "flaky-attempt"); err != nil {
+		t.Fatal(err)
+	}
+	b.Restore(before)
+	if err := Publish(b, countKey, 2, "retry"); err != nil {
+		t.Errorf("Publish after Restore = %v, want the attempt's entry gone", err)
+	}
+	if _, err := Get(before, countKey); !errors.Is(err, ErrNotPublished) {
+		t.Errorf("publishing after Restore reached the snapshot: %v", err)
+	}
+}
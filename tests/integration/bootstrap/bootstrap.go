@@ -0,0 +1,450 @@
+// Package bootstrap installs the MCP servers under test and registers them
+// with the Gemini CLI, so a fresh machine can run the suite without manual
+// setup.
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// Server is one manifest entry.
+type Server struct {
+	// Name is the server's name in the Gemini CLI configuration.
+	Name string `yaml:"name"`
+	// Package is the npm package providing the server.
+	Package string `yaml:"package"`
+	// Version is an npm version, range or dist-tag. Defaults to "latest".
+	Version string `yaml:"version,omitempty"`
+	// Bin is the executable the package installs.
+	Bin string `yaml:"bin"`
+	// Command is what the Gemini CLI runs to start the server. Defaults to
+	// `npx -y <bin>`.
+	Command []string `yaml:"command,omitempty"`
+	// Endpoints lists the ways the harness reaches the server, in order of
+	// preference. Stdio endpoints launch Bin. Defaults to stdio only.
+	Endpoints []client.Endpoint `yaml:"endpoints,omitempty"`
+	// RateLimit, if set, throttles every tool call the harness makes to the
+	// server, across parallel tests, and backs off when it reports exhausted
+	// quota.
+	RateLimit *client.RateLimit `yaml:"rate_limit,omitempty"`
+	// Readiness, if set, retries the initialize handshake while the server
+	// is not ready, e.g. during an npx cold start, before its tool calls fail
+	// to connect.
+	Readiness *client.Readiness `yaml:"readiness,omitempty"`
+	// Env is added to the environment of each stdio launch of the server by
+	// the harness, after its defaults and before the call's own Env, e.g. the
+	// project or log level it is configured with. Values may reference
+	// ${NAME} and ${file:PATH} (see config.Expand), keeping secrets out of
+	// the manifest.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Dir is the working directory of each stdio launch, relative to the
+	// manifest's directory. Defaults to the harness's.
+	Dir string `yaml:"dir,omitempty"`
+	// Scope is the Gemini CLI settings scope, user or project, setup adds
+	// the server to and gemini-mcp-list expects it in. Defaults to setup's
+	// -scope, and is then not checked.
+	Scope string `yaml:"scope,omitempty"`
+	// Trust adds the server trusted, so the Gemini CLI runs its tools
+	// without asking for confirmation.
+	Trust bool `yaml:"trust,omitempty"`
+	// Optional servers may be missing from `gemini mcp list` or not
+	// connected, e.g. one needing credentials only some environments have.
+	Optional bool `yaml:"optional,omitempty"`
+	// StartupSLO, if set, bounds how long each launch of the server may take
+	// to start.
+	StartupSLO *StartupSLO `yaml:"startup_slo,omitempty"`
+	// Limits, if set, bounds the memory and CPU the server's processes may
+	// use during a test.
+	Limits *ResourceLimits `yaml:"limits,omitempty"`
+}
+
+// ResourceLimits bounds what a server's processes use during one test, as
+// sampled every -resource-interval; zero fields are unlimited.
+type ResourceLimits struct {
+	// MaxRSSMB is the peak resident memory, in MiB, of any one launch.
+	MaxRSSMB int `yaml:"max_rss_mb,omitempty"`
+	// MaxCPU is the CPU time the launches of the server use in total.
+	MaxCPU time.Duration `yaml:"max_cpu,omitempty"`
+	// Warn records a server over a limit without failing the test.
+	Warn bool `yaml:"warn,omitempty"`
+}
+
+// StartupSLO bounds the time from spawning a server over stdio to its
+// completing the initialize handshake, which a slow npx cold start makes
+// every Gemini CLI session wait for.
+type StartupSLO struct {
+	Max time.Duration `yaml:"max"`
+	// Warn records a slower startup without failing the test that launched
+	// the server.
+	Warn bool `yaml:"warn,omitempty"`
+}
+
+// Spec returns the npm install argument for the server, e.g. pkg@latest.
+func (s *Server) Spec() string {
+	version := s.Version
+	if version == "" {
+		version = "latest"
+	}
+	return s.Package + "@" + version
+}
+
+// Environ returns Env as sorted KEY=VALUE entries with their references
+// resolved, and the values read from files, which are secrets.
+func (s *Server) Environ() (env, secrets []string, err error) {
+	for _, name := range slices.Sorted(maps.Keys(s.Env)) {
+		value, read, err := config.Expand(s.Env[name])
+		if err != nil {
+			return nil, nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		env = append(env, name+"="+value)
+		secrets = append(secrets, read...)
+	}
+	return env, secrets, nil
+}
+
+// GeminiCommand returns the command the Gemini CLI runs to start the server.
+func (s *Server) GeminiCommand() []string {
+	if len(s.Command) > 0 {
+		return s.Command
+	}
+	return []string{"npx", "-y", s.Bin}
+}
+
+// Gemini CLI settings scopes.
+const (
+	ScopeUser    = "user"
+	ScopeProject = "project"
+)
+
+// Manifest lists the servers to install.
+type Manifest struct {
+	Servers []Server `yaml:"servers"`
+}
+
+// Load reads a manifest file.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	for i, s := range m.Servers {
+		if s.Name == "" || s.Package == "" || s.Bin == "" {
+			return nil, fmt.Errorf("%s: server %d needs name, package and bin", path, i+1)
+		}
+		for _, e := range s.Endpoints {
+			switch {
+			case e.Transport == client.TransportStdio:
+			case e.Transport != client.TransportSSE && e.Transport != client.TransportHTTP:
+				return nil, fmt.Errorf("%s: server %s: unknown transport %q", path, s.Name, e.Transport)
+			case e.URL == "":
+				return nil, fmt.Errorf("%s: server %s: %s endpoint needs a url", path, s.Name, e.Transport)
+			}
+		}
+		switch s.Scope {
+		case "", ScopeUser, ScopeProject:
+		default:
+			return nil, fmt.Errorf("%s: server %s: unknown scope %q; want %s or %s", path, s.Name, s.Scope, ScopeUser, ScopeProject)
+		}
+		if s.StartupSLO != nil && s.StartupSLO.Max <= 0 {
+			return nil, fmt.Errorf("%s: server %s: startup_slo needs a positive max", path, s.Name)
+		}
+		if l := s.Limits; l != nil && (l.MaxRSSMB < 0 || l.MaxCPU < 0 || l.MaxRSSMB == 0 && l.MaxCPU == 0) {
+			return nil, fmt.Errorf("%s: server %s: limits needs a positive max_rss_mb or max_cpu", path, s.Name)
+		}
+		if s.RateLimit != nil {
+			if err := s.RateLimit.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: server %s: %w", path, s.Name, err)
+			}
+		}
+		if s.Readiness != nil {
+			if err := s.Readiness.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: server %s: %w", path, s.Name, err)
+			}
+		}
+		for name := range s.Env {
+			if name == "" || strings.ContainsAny(name, "= ") {
+				return nil, fmt.Errorf("%s: server %s: invalid env name %q", path, s.Name, name)
+			}
+		}
+		if s.Dir != "" && !filepath.IsAbs(s.Dir) {
+			m.Servers[i].Dir = filepath.Join(filepath.Dir(path), s.Dir)
+		}
+	}
+	return &m, nil
+}
+
+// ByName returns the server named name, or nil.
+func (m *Manifest) ByName(name string) *Server {
+	if m == nil {
+		return nil
+	}
+	for i := range m.Servers {
+		if m.Servers[i].Name == name {
+			return &m.Servers[i]
+		}
+	}
+	return nil
+}
+
+// ByBin returns the server whose executable is bin, or nil.
+func (m *Manifest) ByBin(bin string) *Server {
+	if m == nil {
+		return nil
+	}
+	for i := range m.Servers {
+		if m.Servers[i].Bin == bin {
+			return &m.Servers[i]
+		}
+	}
+	return nil
+}
+
+// Runner runs a command and returns its combined output.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// ExecRunner runs commands with os/exec.
+func ExecRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// Bootstrapper installs, verifies and registers the servers of a manifest.
+type Bootstrapper struct {
+	// Run executes npm and gemini. Defaults to ExecRunner.
+	Run Runner
+	// Verify starts the server binary and returns the number of tools it
+	// lists. Defaults to VerifyLaunch.
+	Verify func(ctx context.Context, bin string) (int, error)
+	// Prefix, if set, is passed to npm install as --prefix.
+	Prefix string
+	// Scope is the Gemini CLI settings scope servers are added to.
+	Scope string
+	// SkipInstall only verifies and registers already installed servers.
+	SkipInstall bool
+	// Cache, if set, keeps npm version resolutions between runs.
+	Cache *cache.Cache
+	// Log receives progress output.
+	Log io.Writer
+}
+
+// ErrVerify is returned when an installed server does not start or list its
+// tools.
+var ErrVerify = errors.New("server failed to launch")
+
+// Bootstrap installs every server of m, checks it launches, and adds it to
+// the Gemini CLI configuration unless a server of that name is already
+// configured. It stops at the first error.
+func (b *Bootstrapper) Bootstrap(ctx context.Context, m *Manifest) error {
+	run := b.Run
+	if run == nil {
+		run = ExecRunner
+	}
+	verify := b.Verify
+	if verify == nil {
+		verify = VerifyLaunch
+	}
+	log := b.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	if !b.SkipInstall && len(m.Servers) > 0 {
+		args := []string{"install", "--global"}
+		if b.Prefix != "" {
+			args = append(args, "--prefix", b.Prefix)
+		}
+		for _, s := range m.Servers {
+			spec := s.Spec()
+			// Installing the resolved version pins every package to what
+			// was logged, even if a dist-tag moves during the install.
+			if version, err := b.Resolve(ctx, &s); err != nil {
+				fmt.Fprintf(log, "⚠️  Could not resolve %s, installing it unpinned: %v\n", spec, err)
+			} else if s.Package+"@"+version != spec {
+				spec = s.Package + "@" + version
+				fmt.Fprintf(log, "📌 %s resolves to %s\n", s.Spec(), version)
+			}
+			args = append(args, spec)
+		}
+		fmt.Fprintf(log, "📦 npm %s\n", strings.Join(args, " "))
+		if out, err := run(ctx, "npm", args...); err != nil {
+			return fmt.Errorf("npm install failed: %w\n%s", err, out)
+		}
+	}
+
+	for _, s := range m.Servers {
+		bin := s.Bin
+		if b.Prefix != "" {
+			bin = filepath.Join(b.Prefix, "bin", s.Bin)
+		}
+		tools, err := verify(ctx, bin)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrVerify, s.Name, err)
+		}
+		fmt.Fprintf(log, "✅ %s launches and lists %d tools\n", s.Name, tools)
+	}
+
+	out, err := run(ctx, "gemini", "mcp", "list")
+	if err != nil {
+		return fmt.Errorf("gemini mcp list failed: %w\n%s", err, out)
+	}
+	configured := ConfiguredServers(out)
+	for _, s := range m.Servers {
+		if configured[s.Name] {
+			fmt.Fprintf(log, "✅ %s is already configured in the Gemini CLI\n", s.Name)
+			continue
+		}
+		args := []string{"mcp", "add"}
+		scope := b.Scope
+		if s.Scope != "" {
+			scope = s.Scope
+		}
+		if scope != "" {
+			args = append(args, "--scope", scope)
+		}
+		if s.Trust {
+			args = append(args, "--trust")
+		}
+		args = append(append(args, s.Name), s.GeminiCommand()...)
+		fmt.Fprintf(log, "➕ gemini %s\n", strings.Join(args, " "))
+		if out, err := run(ctx, "gemini", args...); err != nil {
+			return fmt.Errorf("gemini mcp add %s failed: %w\n%s", s.Name, err, out)
+		}
+	}
+	return nil
+}
+
+// Previous is the Version of the last stable release before latest, which
+// npm itself has no dist-tag for.
+const Previous = "previous"
+
+// Resolve returns the exact version npm installs for s, e.g. 1.2.3 for
+// latest or the highest version in a range, looked up with npm view.
+func (b *Bootstrapper) Resolve(ctx context.Context, s *Server) (string, error) {
+	run := b.Run
+	if run == nil {
+		run = ExecRunner
+	}
+	if s.Version == Previous {
+		return b.previous(ctx, s)
+	}
+	return cache.Fetch(b.Cache, "npm-version:"+s.Spec(), func() (string, error) {
+		out, err := run(ctx, "npm", "view", s.Spec(), "version", "--json")
+		if err != nil {
+			return "", fmt.Errorf("npm view failed: %w\n%s", err, out)
+		}
+		// A range matching several versions yields them all, in ascending
+		// order, and one matching none yields nothing.
+		var versions []string
+		if len(bytes.TrimSpace(out)) == 0 {
+			return "", fmt.Errorf("no version of %s matches", s.Spec())
+		}
+		if err := json.Unmarshal(out, &versions); err != nil {
+			var version string
+			if err := json.Unmarshal(out, &version); err != nil {
+				return "", fmt.Errorf("failed to parse npm view output: %w\n%s", err, out)
+			}
+			versions = []string{version}
+		}
+		if len(versions) == 0 || versions[len(versions)-1] == "" {
+			return "", fmt.Errorf("no version of %s matches", s.Spec())
+		}
+		return versions[len(versions)-1], nil
+	})
+}
+
+// previous resolves Previous: the highest version without a prerelease
+// suffix published before latest.
+func (b *Bootstrapper) previous(ctx context.Context, s *Server) (string, error) {
+	latest, err := b.Resolve(ctx, &Server{Package: s.Package})
+	if err != nil {
+		return "", err
+	}
+	run := b.Run
+	if run == nil {
+		run = ExecRunner
+	}
+	return cache.Fetch(b.Cache, "npm-previous:"+s.Package+"@"+latest, func() (string, error) {
+		out, err := run(ctx, "npm", "view", s.Package, "versions", "--json")
+		if err != nil {
+			return "", fmt.Errorf("npm view failed: %w\n%s", err, out)
+		}
+		var versions []string
+		if err := json.Unmarshal(out, &versions); err != nil {
+			return "", fmt.Errorf("failed to parse npm view output: %w\n%s", err, out)
+		}
+		// npm lists versions in ascending order.
+		i := slices.Index(versions, latest)
+		for i--; i >= 0; i-- {
+			if !strings.Contains(versions[i], "-") {
+				return versions[i], nil
+			}
+		}
+		return "", fmt.Errorf("%s has no release before %s", s.Package, latest)
+	})
+}
+
+// Install installs version of s with npm, under Prefix if set, and returns
+// the path of its executable.
+func (b *Bootstrapper) Install(ctx context.Context, s *Server, version string) (string, error) {
+	run := b.Run
+	if run == nil {
+		run = ExecRunner
+	}
+	args := []string{"install", "--global"}
+	if b.Prefix != "" {
+		args = append(args, "--prefix", b.Prefix)
+	}
+	args = append(args, s.Package+"@"+version)
+	if b.Log != nil {
+		fmt.Fprintf(b.Log, "📦 npm %s\n", strings.Join(args, " "))
+	}
+	if out, err := run(ctx, "npm", args...); err != nil {
+		return "", fmt.Errorf("npm install failed: %w\n%s", err, out)
+	}
+	if b.Prefix == "" {
+		return s.Bin, nil
+	}
+	return filepath.Join(b.Prefix, "bin", s.Bin), nil
+}
+
+// VerifyLaunch starts bin as an MCP server over stdio and lists its tools.
+func VerifyLaunch(_ context.Context, bin string) (int, error) {
+	tools, err := client.ListTools(client.ToolCall{ServerCmd: []string{bin}})
+	return len(tools), err
+}
+
+// serverLine matches a server in `gemini mcp list` output, e.g.
+// "✓ gcloud: npx -y gcloud-mcp (stdio) - Connected".
+var serverLine = regexp.MustCompile(`(?m)^\s*\S*\s*([\w.-]+): .*\((?:stdio|sse|http)\)`)
+
+// ConfiguredServers returns the names of the servers listed in the output of
+// `gemini mcp list`, whether or not they are connected.
+func ConfiguredServers(output []byte) map[string]bool {
+	names := make(map[string]bool)
+	for _, m := range serverLine.FindAllSubmatch(output, -1) {
+		names[string(m[1])] = true
+	}
+	return names
+}
@@ -0,0 +1,288 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+)
+
+func TestConfiguredServers(t *testing.T) {
+	output := []byte(`Configured MCP servers:
+
+✓ gcloud: npx -y gcloud-mcp  (stdio) - Connected
+✗ storage: npx -y storage-mcp  (stdio) - Disconnected
+`)
+	got := ConfiguredServers(output)
+	if !got["gcloud"] || !got["storage"] || len(got) != 2 {
+		t.Errorf("ConfiguredServers() = %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servers.yaml")
+	os.WriteFile(path, []byte("servers:\n  - {name: gcloud, package: '@google-cloud/gcloud-mcp', bin: gcloud-mcp}\n"), 0o644)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Servers[0].Spec(); got != "@google-cloud/gcloud-mcp@latest" {
+		t.Errorf("Spec() = %q", got)
+	}
+
+	if m.ByBin("gcloud-mcp") != &m.Servers[0] || m.ByBin("storage-mcp") != nil {
+		t.Error("ByBin() did not find the server by its executable")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: gcloud}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a server without package and bin")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, endpoints: [{transport: sse}]}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted an sse endpoint without a url")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, endpoints: [{transport: sse, url: 'http://localhost:8080/sse'}, {transport: stdio}]}\n"), 0o644)
+	if m, err = Load(path); err != nil || len(m.Servers[0].Endpoints) != 2 || m.Servers[0].Endpoints[0].URL != "http://localhost:8080/sse" {
+		t.Errorf("Load() = %+v, %v", m, err)
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, rate_limit: {requests_per_second: 2.5, max_in_flight: 4, initial_backoff: 2s}}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Servers[0].RateLimit; r == nil || r.RequestsPerSecond != 2.5 || r.MaxInFlight != 4 || r.InitialBackoff != 2*time.Second {
+		t.Errorf("RateLimit = %+v", r)
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, rate_limit: {max_in_flight: -1}}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a negative max_in_flight")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, readiness: {window: 30s, initial_backoff: 500ms}}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Servers[0].Readiness; r == nil || r.Window != 30*time.Second || r.InitialBackoff != 500*time.Millisecond {
+		t.Errorf("Readiness = %+v", r)
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, readiness: {initial_backoff: 1s}}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a readiness without a window")
+	}
+
+	secret := filepath.Join(filepath.Dir(path), "key")
+	os.WriteFile(secret, []byte("s3cret\n"), 0o600)
+	t.Setenv("BOOTSTRAP_TEST_PROJECT", "my-project")
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, dir: work, env: {PROJECT: '${BOOTSTRAP_TEST_PROJECT}', API_KEY: '${file:"+secret+"}', LOG_LEVEL: debug}}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.Servers[0]; s.Dir != filepath.Join(filepath.Dir(path), "work") {
+		t.Errorf("Dir = %q, want it relative to the manifest", s.Dir)
+	}
+	env, secrets, err := m.Servers[0].Environ()
+	if want := []string{"API_KEY=s3cret", "LOG_LEVEL=debug", "PROJECT=my-project"}; err != nil || !slices.Equal(env, want) || !slices.Equal(secrets, []string{"s3cret"}) {
+		t.Errorf("Environ = %q, %q, %v, want %q and the secret", env, secrets, err, want)
+	}
+	os.Remove(secret)
+	if _, _, err := m.Servers[0].Environ(); err == nil || !strings.Contains(err.Error(), "API_KEY") {
+		t.Errorf("Environ with a missing secret = %v, want an error naming API_KEY", err)
+	}
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, env: {'A=B': c}}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted an invalid env name")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, scope: project, trust: true, optional: true}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.ByName("s"); s == nil || s.Scope != ScopeProject || !s.Trust || !s.Optional || m.ByName("b") != nil {
+		t.Errorf("ByName(s) = %+v", s)
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, startup_slo: {max: 5s, warn: true}}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if slo := m.Servers[0].StartupSLO; slo == nil || slo.Max != 5*time.Second || !slo.Warn {
+		t.Errorf("StartupSLO = %+v", slo)
+	}
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, startup_slo: {warn: true}}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a startup_slo without a max")
+	}
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, limits: {max_rss_mb: 512, max_cpu: 30s}}\n"), 0o644)
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if l := m.Servers[0].Limits; l == nil || l.MaxRSSMB != 512 || l.MaxCPU != 30*time.Second || l.Warn {
+		t.Errorf("Limits = %+v", l)
+	}
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, limits: {warn: true}}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted limits without a limit")
+	}
+
+	os.WriteFile(path, []byte("servers:\n  - {name: s, package: p, bin: b, scope: system}\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted an unknown scope")
+	}
+}
+
+// fakeRunner records commands and answers `gemini mcp list` with listed and
+// `npm view <spec> version --json` with views[spec].
+type fakeRunner struct {
+	listed string
+	views  map[string]string
+	calls  []string
+}
+
+func (f *fakeRunner) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, cmd)
+	if cmd == "gemini mcp list" {
+		return []byte(f.listed), nil
+	}
+	if len(args) == 4 && args[0] == "view" {
+		view, ok := f.views[args[1]]
+		if !ok {
+			return []byte("npm error code E404"), errors.New("exit status 1")
+		}
+		return []byte(view), nil
+	}
+	return nil, nil
+}
+
+func TestBootstrap(t *testing.T) {
+	m := &Manifest{Servers: []Server{
+		{Name: "gcloud", Package: "@google-cloud/gcloud-mcp", Version: "0.5.3", Bin: "gcloud-mcp"},
+		{Name: "storage", Package: "@google-cloud/storage-mcp", Bin: "storage-mcp"},
+		{Name: "observability", Package: "@google-cloud/observability-mcp", Version: "1.0.0", Bin: "observability-mcp", Scope: ScopeProject, Trust: true},
+	}}
+	f := &fakeRunner{
+		listed: "✓ gcloud: npx -y gcloud-mcp  (stdio) - Connected\n",
+		views: map[string]string{
+			"@google-cloud/gcloud-mcp@0.5.3":        `"0.5.3"`,
+			"@google-cloud/storage-mcp@latest":      `"1.2.0"`,
+			"@google-cloud/observability-mcp@1.0.0": `"1.0.0"`,
+		},
+	}
+	var verified []string
+	b := &Bootstrapper{
+		Run:   f.run,
+		Scope: "user",
+		Verify: func(_ context.Context, bin string) (int, error) {
+			verified = append(verified, bin)
+			return 3, nil
+		},
+	}
+	if err := b.Bootstrap(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"npm view @google-cloud/gcloud-mcp@0.5.3 version --json",
+		"npm view @google-cloud/storage-mcp@latest version --json",
+		"npm view @google-cloud/observability-mcp@1.0.0 version --json",
+		"npm install --global @google-cloud/gcloud-mcp@0.5.3 @google-cloud/storage-mcp@1.2.0 @google-cloud/observability-mcp@1.0.0",
+		"gemini mcp list",
+		"gemini mcp add --scope user storage npx -y storage-mcp",
+		"gemini mcp add --scope project --trust observability npx -y observability-mcp",
+	}
+	if !slices.Equal(f.calls, want) {
+		t.Errorf("commands = %q, want %q", f.calls, want)
+	}
+	if !slices.Equal(verified, []string{"gcloud-mcp", "storage-mcp", "observability-mcp"}) {
+		t.Errorf("verified = %q", verified)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f := &fakeRunner{views: map[string]string{"p@^1.0": `["1.0.0", "1.3.1"]`, "p@latest": `"2.0.0"`, "p@^3": ""}}
+	b := &Bootstrapper{Run: f.run, Cache: &cache.Cache{Dir: t.TempDir(), TTL: time.Hour}}
+	tests := map[string]string{"^1.0": "1.3.1", "latest": "2.0.0", "": "2.0.0"}
+	for version, want := range tests {
+		if got, err := b.Resolve(context.Background(), &Server{Package: "p", Version: version}); got != want || err != nil {
+			t.Errorf("Resolve(p@%s) = %q, %v; want %s", version, got, err, want)
+		}
+	}
+	if len(f.calls) != 2 {
+		t.Errorf("ran %q, want each spec looked up once", f.calls)
+	}
+	for _, version := range []string{"^3", "missing"} {
+		if got, err := b.Resolve(context.Background(), &Server{Package: "p", Version: version}); err == nil {
+			t.Errorf("Resolve(p@%s) = %q, want an error", version, got)
+		}
+	}
+}
+
+func TestResolvePrevious(t *testing.T) {
+	f := &fakeRunner{views: map[string]string{"p@latest": `"2.1.0"`, "p": `["1.9.0", "2.0.0", "2.1.0-rc.1", "2.1.0", "2.2.0-canary.3"]`}}
+	b := &Bootstrapper{Run: f.run}
+	if got, err := b.Resolve(context.Background(), &Server{Package: "p", Version: Previous}); got != "2.0.0" || err != nil {
+		t.Errorf("Resolve(p@previous) = %q, %v; want 2.0.0", got, err)
+	}
+	f.views["p"] = `["2.1.0"]`
+	if got, err := b.Resolve(context.Background(), &Server{Package: "p", Version: Previous}); err == nil {
+		t.Errorf("Resolve(p@previous) of a first release = %q, want an error", got)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	f := &fakeRunner{}
+	b := &Bootstrapper{Run: f.run, Prefix: "/tmp/m/latest"}
+	bin, err := b.Install(context.Background(), &Server{Package: "p", Bin: "gcloud-mcp"}, "2.1.0")
+	if err != nil || bin != filepath.Join("/tmp/m/latest", "bin", "gcloud-mcp") {
+		t.Errorf("Install() = %q, %v", bin, err)
+	}
+	if want := []string{"npm install --global --prefix /tmp/m/latest p@2.1.0"}; !slices.Equal(f.calls, want) {
+		t.Errorf("commands = %q, want %q", f.calls, want)
+	}
+}
+
+func TestBootstrapInstallsUnresolvedUnpinned(t *testing.T) {
+	m := &Manifest{Servers: []Server{{Name: "gcloud", Package: "p", Bin: "gcloud-mcp"}}}
+	f := &fakeRunner{}
+	var log strings.Builder
+	b := &Bootstrapper{Run: f.run, Log: &log, Verify: func(context.Context, string) (int, error) { return 1, nil }}
+	if err := b.Bootstrap(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(f.calls, "npm install --global p@latest") || !strings.Contains(log.String(), "Could not resolve p@latest") {
+		t.Errorf("commands = %q, log:\n%s", f.calls, log.String())
+	}
+}
+
+func TestBootstrapVerifyFailure(t *testing.T) {
+	m := &Manifest{Servers: []Server{{Name: "gcloud", Package: "p", Bin: "gcloud-mcp"}}}
+	f := &fakeRunner{}
+	b := &Bootstrapper{
+		Run:         f.run,
+		SkipInstall: true,
+		Prefix:      "/opt/mcp",
+		Verify: func(_ context.Context, bin string) (int, error) {
+			if bin != filepath.Join("/opt/mcp", "bin", "gcloud-mcp") {
+				t.Errorf("verified %q", bin)
+			}
+			return 0, errors.New("exit status 1")
+		},
+	}
+	err := b.Bootstrap(context.Background(), m)
+	if !errors.Is(err, ErrVerify) {
+		t.Fatalf("Bootstrap() = %v, want ErrVerify", err)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("ran %q after a failed verification", f.calls)
+	}
+}
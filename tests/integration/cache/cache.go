@@ -0,0 +1,145 @@
+// Package cache keeps the results of external lookups, such as npm version
+// resolution and published schema fetches, on disk for a while, so repeated
+// local runs do not hit the registries every time and keep working briefly
+// offline.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTTL is how long a lookup is reused before it is repeated.
+const DefaultTTL = time.Hour
+
+// DefaultMaxStale is how long past its TTL a lookup is still used when
+// repeating it fails.
+const DefaultMaxStale = 24 * time.Hour
+
+// Cache stores lookups as one JSON file per key. A nil *Cache caches nothing.
+type Cache struct {
+	Dir string
+	// TTL is how long an entry is fresh.
+	TTL time.Duration
+	// MaxStale is how long after going stale an entry is still returned if
+	// the lookup fails, e.g. offline.
+	MaxStale time.Duration
+	// Log receives a warning whenever a stale entry stands in for a failed
+	// lookup.
+	Log io.Writer
+
+	now func() time.Time
+}
+
+// Default returns a cache in the user's cache directory with the given TTL,
+// or nil if ttl is not positive or there is no cache directory.
+func Default(ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return &Cache{Dir: filepath.Join(dir, "gcloud-mcp-integration"), TTL: ttl, MaxStale: DefaultMaxStale, Log: os.Stderr}
+}
+
+type entry struct {
+	Key    string          `json:"key"`
+	Stored time.Time       `json:"stored"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// Fetch returns the value cached under key while it is fresh, and otherwise
+// calls fetch and caches its result. If fetch fails, an entry that went
+// stale less than MaxStale ago is returned instead of the error.
+func Fetch[T any](c *Cache, key string, fetch func() (T, error)) (T, error) {
+	if c == nil {
+		return fetch()
+	}
+	var cached T
+	e, err := c.read(key)
+	haveCached := err == nil && json.Unmarshal(e.Value, &cached) == nil
+	age := c.clock().Sub(e.Stored)
+	if haveCached && age < c.TTL {
+		return cached, nil
+	}
+	v, err := fetch()
+	if err != nil {
+		if haveCached && age < c.TTL+c.MaxStale {
+			if c.Log != nil {
+				fmt.Fprintf(c.Log, "⚠️  Using %s cached %s ago: %v\n", key, age.Round(time.Minute), err)
+			}
+			return cached, nil
+		}
+		return v, err
+	}
+	if err := c.write(key, v); err != nil && c.Log != nil {
+		fmt.Fprintf(c.Log, "⚠️  Could not cache %s: %v\n", key, err)
+	}
+	return v, nil
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:12])+".json")
+}
+
+func (c *Cache) read(key string) (entry, error) {
+	var e entry
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, err
+	}
+	if e.Key != key {
+		return entry{}, fmt.Errorf("cache entry %s holds %q", c.path(key), e.Key)
+	}
+	return e, nil
+}
+
+// write stores v through a temporary file, so a concurrent run never reads a
+// partial entry.
+func (c *Cache) write(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Key: key, Stored: c.clock(), Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var log strings.Builder
+	c := &Cache{Dir: t.TempDir(), TTL: time.Hour, MaxStale: 24 * time.Hour, Log: &log, now: func() time.Time { return now }}
+	calls := 0
+	lookup := func(v string, err error) func() (string, error) {
+		return func() (string, error) {
+			calls++
+			return v, err
+		}
+	}
+	offline := errors.New("offline")
+
+	if v, err := Fetch(c, "npm:gcloud-mcp@latest", lookup("1.0.0", nil)); v != "1.0.0" || err != nil {
+		t.Fatalf("first Fetch = %q, %v", v, err)
+	}
+	now = now.Add(30 * time.Minute)
+	if v, _ := Fetch(c, "npm:gcloud-mcp@latest", lookup("1.1.0", nil)); v != "1.0.0" || calls != 1 {
+		t.Errorf("fresh Fetch = %q after %d lookups, want the cached 1.0.0 without a lookup", v, calls)
+	}
+	if v, _ := Fetch(c, "npm:storage-mcp@latest", lookup("2.0.0", nil)); v != "2.0.0" {
+		t.Errorf("Fetch of another key = %q", v)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if v, err := Fetch(c, "npm:gcloud-mcp@latest", lookup("", offline)); v != "1.0.0" || err != nil {
+		t.Errorf("offline Fetch of a stale entry = %q, %v; want the stale 1.0.0", v, err)
+	}
+	if !strings.Contains(log.String(), "Using npm:gcloud-mcp@latest cached 2h30m0s ago: offline") {
+		t.Errorf("stale use not logged: %q", log.String())
+	}
+	if v, _ := Fetch(c, "npm:gcloud-mcp@latest", lookup("1.2.0", nil)); v != "1.2.0" {
+		t.Errorf("Fetch of a stale entry = %q, want a new lookup", v)
+	}
+
+	now = now.Add(48 * time.Hour)
+	if _, err := Fetch(c, "npm:gcloud-mcp@latest", lookup("", offline)); !errors.Is(err, offline) {
+		t.Errorf("offline Fetch past MaxStale = %v, want the lookup's error", err)
+	}
+}
+
+func TestNilCacheAlwaysFetches(t *testing.T) {
+	var c *Cache
+	for i := range 2 {
+		if v, _ := Fetch(c, "k", func() (int, error) { return i, nil }); v != i {
+			t.Errorf("Fetch = %d, want %d", v, i)
+		}
+	}
+	if Default(0) != nil {
+		t.Error("Default(0) is not nil")
+	}
+}
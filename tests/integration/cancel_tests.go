@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+)
+
+// cancelGrace bounds how long a server may take to stop working on a
+// cancelled call: to kill the process running it and to let the call
+// return.
+const cancelGrace = 5 * time.Second
+
+// linuxOnly restricts the cancellation tests to where checkCancellation can
+// list a server's processes.
+var linuxOnly = platform.Constraint{"linux"}
+
+// cancellation is a long-running tool call that a test cancels mid-flight.
+type cancellation struct {
+	// call names the server, the tool and its arguments.
+	call client.ToolCall
+	// after is how long the call runs before it is cancelled.
+	after time.Duration
+	// child is part of the command line of the process the server runs the
+	// call in, e.g. the gcloud command, which must exit once the call is
+	// cancelled.
+	child string
+}
+
+// checkCancellation makes c's call in a session, cancels it after c.after
+// and checks that the server acknowledged the cancellation: the process
+// running the call exits within cancelGrace, the server does not complete the
+// call anyway and it keeps serving the session. It reads the server's
+// processes from /proc, so tests using it run only on Linux.
+func checkCancellation(t *testContext, c cancellation) error {
+	session, err := t.openSession(c.call)
+	if err != nil {
+		return fmt.Errorf("error opening session: %w", err)
+	}
+	defer session.Close()
+	pid := session.ServerPID()
+	if pid == 0 {
+		return report.Skip("the server of %s is not a stdio server, so its processes cannot be checked", c.call.ToolName)
+	}
+
+	ctx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := session.CallToolContext(ctx, c.call.ToolName, c.call.ToolArgs)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error calling %s: %w", c.call.ToolName, err)
+		}
+		// A fast call says nothing about how the server handles
+		// cancellation.
+		return report.Skip("%s finished within %s, before it could be cancelled", c.call.ToolName, c.after)
+	case <-time.After(c.after):
+	}
+	if procs, err := childProcesses(pid, c.child); err != nil {
+		return err
+	} else if len(procs) == 0 {
+		return report.Fail(report.ReasonAssertion, "assertion failed: no process running %q after %s of %s", c.child, c.after, c.call.ToolName)
+	}
+	cancel()
+	cancelled := time.Now()
+	select {
+	case err := <-done:
+		if !errors.Is(err, client.ErrCancelled) {
+			return fmt.Errorf("error calling %s: %w", c.call.ToolName, err)
+		}
+	case <-time.After(cancelGrace):
+		return fmt.Errorf("the client did not return from the cancelled %s within %s", c.call.ToolName, cancelGrace)
+	}
+	logger.Printf("🛑 Cancelled %s after %s\n", c.call.ToolName, c.after)
+
+	for {
+		procs, err := childProcesses(pid, c.child)
+		if err != nil {
+			return err
+		}
+		if len(procs) == 0 {
+			break
+		}
+		if time.Since(cancelled) > cancelGrace {
+			return report.Fail(report.ReasonCancellation, "the server still ran %q %s after %s was cancelled", strings.Join(procs[0].Command, " "), cancelGrace, c.call.ToolName)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	logger.Printf("✅ Assertion passed: the process running %q exited within %s\n", c.child, time.Since(cancelled).Round(time.Millisecond))
+
+	// A result would arrive by now from a server that finished the call
+	// anyway.
+	if cancellations := session.Cancellations(); len(cancellations) == 0 {
+		return fmt.Errorf("the client sent no notifications/cancelled for %s", c.call.ToolName)
+	} else if last := cancellations[len(cancellations)-1]; last.Completed {
+		return report.Fail(report.ReasonCancellation, "the server completed %s despite its cancellation: %s", c.call.ToolName, last)
+	}
+	pingCtx, cancelPing := context.WithTimeout(runCtx, cancelGrace)
+	defer cancelPing()
+	if err := session.Ping(pingCtx); err != nil {
+		return report.Fail(report.ReasonCancellation, "the server stopped answering after %s was cancelled: %v", c.call.ToolName, err)
+	}
+	logger.Println("✅ Assertion passed: the server did not complete the cancelled call and kept serving the session")
+	return nil
+}
+
+// childProcesses returns the processes in the process group of the server
+// with PID pid whose command line contains child.
+func childProcesses(pid int, child string) ([]resources.Process, error) {
+	procs, err := resources.GroupProcesses(pid)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the server's processes: %w", err)
+	}
+	return slices.DeleteFunc(procs, func(p resources.Process) bool {
+		return p.PID == pid || !strings.Contains(strings.Join(p.Command, " "), child)
+	}), nil
+}
+
+func testExampleCancel(t *testContext) error {
+	logger.Println("🚀 Starting example server cancellation test...")
+	return checkCancellation(t, cancellation{
+		call:  client.ToolCall{ServerCmd: exampleServerCmd(), ToolName: "wait", ToolArgs: exampleserver.WaitArgs{Seconds: 60}},
+		after: time.Second,
+		child: "sleep 60",
+	})
+}
+
+func testGcloudCancel(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp cancellation integration test...")
+	// Reading every log entry of the test project usually takes longer than
+	// the call is given; gcloud-mcp kills gcloud once the request's abort
+	// signal fires.
+	return checkCancellation(t, cancellation{
+		call: client.ToolCall{ServerCmd: gcloudServer.Command, ToolName: "run_gcloud_command", ToolArgs: map[string]any{
+			"args": []string{"logging", "read", "--freshness=3650d", "--limit=1000000", "--format=json"},
+		}},
+		after: 2 * time.Second,
+		child: "logging read",
+	})
+}
@@ -0,0 +1,172 @@
+// Package catalog snapshots the tools a server advertises and diffs them
+// against a checked-in copy, so any change to a server's MCP surface shows up
+// as a failing test until the snapshot is deliberately updated.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Tool is the snapshotted part of an mcp.Tool.
+type Tool struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema,omitempty"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+}
+
+// Snapshot is a server's tool catalog, sorted by tool name.
+type Snapshot struct {
+	Server string `json:"server"`
+	Tools  []Tool `json:"tools"`
+}
+
+// FromTools builds a Snapshot of tools. Schemas are re-encoded so that key
+// order in the server's output does not matter.
+func FromTools(server string, tools []*mcp.Tool) (*Snapshot, error) {
+	s := &Snapshot{Server: server}
+	for _, t := range tools {
+		in, err := canonical(t.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: input schema: %w", t.Name, err)
+		}
+		out, err := canonical(t.OutputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: output schema: %w", t.Name, err)
+		}
+		s.Tools = append(s.Tools, Tool{Name: t.Name, Description: t.Description, InputSchema: in, OutputSchema: out})
+	}
+	slices.SortFunc(s.Tools, func(a, b Tool) int { return strings.Compare(a.Name, b.Name) })
+	return s, nil
+}
+
+// canonical encodes v as indented JSON with sorted object keys.
+func canonical(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(generic, "", "  ")
+}
+
+// Load reads a snapshot written by Save.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	for i, t := range s.Tools {
+		// Normalize whitespace so hand-edited snapshots still compare equal.
+		if s.Tools[i].InputSchema, err = canonical(t.InputSchema); err != nil {
+			return nil, err
+		}
+		if s.Tools[i].OutputSchema, err = canonical(t.OutputSchema); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Save writes s to path, creating its directory.
+func Save(path string, s *Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Marshal returns s as Save writes it.
+func Marshal(s *Snapshot) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ChangeKind classifies a difference between two snapshots.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is one difference between the snapshot and the live catalog.
+type Change struct {
+	Kind ChangeKind
+	Tool string
+	// Field is the changed property for Changed: description, inputSchema or
+	// outputSchema.
+	Field string
+	Want  string
+	Got   string
+}
+
+func (c Change) String() string {
+	if c.Kind != Changed {
+		return fmt.Sprintf("%s tool %s", c.Kind, c.Tool)
+	}
+	return fmt.Sprintf("changed %s of tool %s", c.Field, c.Tool)
+}
+
+// Diff lists the differences between want (the checked-in snapshot) and got
+// (the live catalog), ordered by tool name.
+func Diff(want, got *Snapshot) []Change {
+	wantTools := map[string]Tool{}
+	for _, t := range want.Tools {
+		wantTools[t.Name] = t
+	}
+	gotTools := map[string]Tool{}
+	for _, t := range got.Tools {
+		gotTools[t.Name] = t
+	}
+
+	var changes []Change
+	for _, w := range want.Tools {
+		g, ok := gotTools[w.Name]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Tool: w.Name})
+			continue
+		}
+		for _, f := range []struct {
+			name      string
+			want, got string
+		}{
+			{"description", w.Description, g.Description},
+			{"inputSchema", string(w.InputSchema), string(g.InputSchema)},
+			{"outputSchema", string(w.OutputSchema), string(g.OutputSchema)},
+		} {
+			if f.want != f.got {
+				changes = append(changes, Change{Kind: Changed, Tool: w.Name, Field: f.name, Want: f.want, Got: f.got})
+			}
+		}
+	}
+	for _, g := range got.Tools {
+		if _, ok := wantTools[g.Name]; !ok {
+			changes = append(changes, Change{Kind: Added, Tool: g.Name})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b Change) int { return strings.Compare(a.Tool, b.Tool) })
+	return changes
+}
@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func schema(props ...string) map[string]any {
+	p := map[string]any{}
+	for _, name := range props {
+		p[name] = map[string]any{"type": "string"}
+	}
+	return map[string]any{"type": "object", "properties": p}
+}
+
+func TestDiff(t *testing.T) {
+	want, err := FromTools("srv", []*mcp.Tool{
+		{Name: "keep", Description: "same", InputSchema: schema("a")},
+		{Name: "gone", InputSchema: schema()},
+		{Name: "edit", Description: "old", InputSchema: schema("a")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromTools("srv", []*mcp.Tool{
+		{Name: "keep", Description: "same", InputSchema: schema("a")},
+		{Name: "edit", Description: "new", InputSchema: schema("a", "b")},
+		{Name: "fresh", InputSchema: schema()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summary []string
+	for _, c := range Diff(want, got) {
+		summary = append(summary, c.String())
+	}
+	wantSummary := []string{
+		"changed description of tool edit",
+		"changed inputSchema of tool edit",
+		"added tool fresh",
+		"removed tool gone",
+	}
+	if len(summary) != len(wantSummary) {
+		t.Fatalf("Diff() = %q, want %q", summary, wantSummary)
+	}
+	for i := range summary {
+		if summary[i] != wantSummary[i] {
+			t.Errorf("change %d = %q, want %q", i, summary[i], wantSummary[i])
+		}
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	s, err := FromTools("srv", []*mcp.Tool{{Name: "b", InputSchema: schema("x")}, {Name: "a", InputSchema: schema()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Tools[0].Name != "a" {
+		t.Errorf("tools not sorted: %+v", s.Tools)
+	}
+	path := filepath.Join(t.TempDir(), "nested", "srv.json")
+	if err := Save(path, s); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(loaded, s); len(changes) != 0 {
+		t.Errorf("round trip produced changes: %v", changes)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/catalog"
+	"github.com/googleapis/gcloud-mcp/tests/integration/registry"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+var (
+	// snapshotDir holds one <server>.json tool catalog snapshot per server.
+	snapshotDir = filepath.Join("testdata", "tool_catalog")
+	// updateSnapshots rewrites the snapshots from the live servers instead of
+	// comparing against them.
+	updateSnapshots bool
+)
+
+// catalogTests returns a tool-catalog-<name> test for every registered
+// server and one for the example server.
+func catalogTests() []testCase {
+	example := &registry.Server{Name: "example", Command: exampleServerCmd()}
+	tests := []testCase{{
+		id:   "tool-catalog-example",
+		tags: smoke,
+		run:  func(t *testContext) error { return testToolCatalog(t, example) },
+	}}
+	for _, s := range serverRegistry.All() {
+		tests = append(tests, testCase{
+			id:       "tool-catalog-" + s.Name,
+			requires: s.Command[:1],
+			run: func(t *testContext) error {
+				return testToolCatalog(t, s)
+			},
+		})
+	}
+	return tests
+}
+
+// testToolCatalog checks that s lists the tools its registration expects and
+// diffs its tools against the checked-in snapshot for it. A catalog without
+// a matching snapshot is saved as the artifact <server>.json, ready to be
+// reviewed and checked in.
+func testToolCatalog(t *testContext, s *registry.Server) error {
+	server := s.Name
+	logger.Printf("🚀 Starting %s tool catalog snapshot test...\n", server)
+	tools, err := t.listTools(s.Command)
+	if err != nil {
+		return fmt.Errorf("error listing tools: %w", err)
+	}
+	got, err := catalog.FromTools(server, tools)
+	if err != nil {
+		return report.Fail(report.ReasonParse, "error reading tool catalog: %v", err)
+	}
+	names := make([]string, len(got.Tools))
+	for i, t := range got.Tools {
+		names[i] = t.Name
+	}
+	if missing := s.MissingTools(names); len(missing) > 0 {
+		return report.Fail(report.ReasonAssertion, "assertion failed: %s does not list the registered tools %s", server, strings.Join(missing, ", "))
+	}
+
+	path := filepath.Join(snapshotDir, server+".json")
+	if updateSnapshots {
+		if err := catalog.Save(path, got); err != nil {
+			return fmt.Errorf("error writing snapshot: %w", err)
+		}
+		logger.Printf("📸 Updated %s with %d tools\n", path, len(got.Tools))
+		return nil
+	}
+
+	want, err := catalog.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		saveCatalog(t, got)
+		return report.Skip("no snapshot at %s; create it with -update-snapshots", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := catalog.Diff(want, got)
+	if len(changes) == 0 {
+		logger.Printf("✅ Assertion passed: %d tools match %s\n", len(got.Tools), path)
+		return nil
+	}
+	saveCatalog(t, got)
+	summary := make([]string, len(changes))
+	for i, c := range changes {
+		summary[i] = c.String()
+	}
+	message := fmt.Sprintf("assertion failed: %s tool catalog differs from %s (%s); rerun with -update-snapshots if intended",
+		server, path, strings.Join(summary, ", "))
+	if err := report.Compare(message, want, got); err != nil {
+		return err
+	}
+	// The snapshots differ only in ways Compare normalizes away.
+	return nil
+}
+
+// saveCatalog saves got as an artifact of t, logging a failure to.
+func saveCatalog(t *testContext, got *catalog.Snapshot) {
+	data, err := catalog.Marshal(got)
+	if err == nil {
+		err = t.SaveArtifact(got.Server+".json", data)
+	}
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not save the live %s catalog: %v\n", got.Server, err)
+	}
+}
@@ -0,0 +1,430 @@
+// Package chaos decorates an mcp.Transport with the faults of an unreliable
+// connection: latency, dropped and duplicated messages, truncated frames and
+// a connection that dies in the middle of a tool call. A Policy decides which
+// faults to inject and how often, from a seed, so a failure it provokes can
+// be replayed.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Policy is how much chaos to inject. Rates are probabilities per message,
+// from 0 to 1; a message suffers at most one of drop, duplicate and
+// truncate. The handshake is left alone, so every session starts.
+type Policy struct {
+	// Latency is the most a message is delayed, uniformly between 0 and it.
+	Latency time.Duration
+	// Drop is the rate of messages lost in either direction. A lost request
+	// or response leaves its call waiting until it times out.
+	Drop float64
+	// Duplicate is the rate of messages delivered twice.
+	Duplicate float64
+	// Truncate is the rate of messages from the server cut off mid-frame,
+	// which the client fails to decode.
+	Truncate float64
+	// KillAfter closes the connection right after sending the KillAfter-th
+	// tools/call of the session, so that call is in flight. Zero never does.
+	KillAfter int
+	// Seed seeds the random faults.
+	Seed uint64
+}
+
+// Parse reads a policy written as comma-separated key=value pairs, e.g.
+// "latency=200ms,drop=0.05,duplicate=0.1,truncate=0.01,kill-after=3,seed=7".
+func Parse(s string) (*Policy, error) {
+	p := &Policy{}
+	for _, field := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, fmt.Errorf("chaos policy %q: want key=value, got %q", s, field)
+		}
+		var err error
+		switch key {
+		case "latency":
+			p.Latency, err = time.ParseDuration(value)
+		case "drop":
+			p.Drop, err = parseRate(value)
+		case "duplicate":
+			p.Duplicate, err = parseRate(value)
+		case "truncate":
+			p.Truncate, err = parseRate(value)
+		case "kill-after":
+			p.KillAfter, err = strconv.Atoi(value)
+		case "seed":
+			p.Seed, err = strconv.ParseUint(value, 10, 64)
+		default:
+			return nil, fmt.Errorf("chaos policy %q: unknown key %q; want latency, drop, duplicate, truncate, kill-after or seed", s, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos policy %q: %s: %w", s, key, err)
+		}
+	}
+	if p.Drop+p.Duplicate+p.Truncate > 1 {
+		return nil, fmt.Errorf("chaos policy %q: drop, duplicate and truncate add up to more than 1", s)
+	}
+	if p.Latency < 0 || p.KillAfter < 0 {
+		return nil, fmt.Errorf("chaos policy %q: latency and kill-after must not be negative", s)
+	}
+	return p, nil
+}
+
+func parseRate(s string) (float64, error) {
+	rate, err := strconv.ParseFloat(s, 64)
+	if err == nil && (rate < 0 || rate > 1) {
+		err = fmt.Errorf("%s is not between 0 and 1", s)
+	}
+	return rate, err
+}
+
+func (p *Policy) String() string {
+	var fields []string
+	if p.Latency > 0 {
+		fields = append(fields, "latency="+p.Latency.String())
+	}
+	for _, r := range []struct {
+		key  string
+		rate float64
+	}{{"drop", p.Drop}, {"duplicate", p.Duplicate}, {"truncate", p.Truncate}} {
+		if r.rate > 0 {
+			fields = append(fields, r.key+"="+strconv.FormatFloat(r.rate, 'g', -1, 64))
+		}
+	}
+	if p.KillAfter > 0 {
+		fields = append(fields, "kill-after="+strconv.Itoa(p.KillAfter))
+	}
+	return strings.Join(append(fields, "seed="+strconv.FormatUint(p.Seed, 10)), ",")
+}
+
+// Fault is a kind of injected fault.
+type Fault string
+
+const (
+	Dropped    Fault = "dropped"
+	Duplicated Fault = "duplicated"
+	Truncated  Fault = "truncated"
+	// Killed closes the connection after a message was sent.
+	Killed Fault = "killed"
+)
+
+// Event is one injected fault.
+type Event struct {
+	Fault Fault
+	// Sent is set for a message from the client, clear for one from the
+	// server.
+	Sent bool
+	// Message describes the message, e.g. "tools/call request 3".
+	Message string
+}
+
+func (e Event) String() string {
+	if e.Fault == Killed {
+		return "killed the connection after sending " + e.Message
+	}
+	direction := "received"
+	if e.Sent {
+		direction = "sent"
+	}
+	return fmt.Sprintf("%s %s %s", e.Fault, direction, e.Message)
+}
+
+// Transport injects the faults of Policy into the connections of the
+// transport it wraps.
+type Transport struct {
+	mcp.Transport
+	Policy *Policy
+	// OnFault, if set, is called for each fault as it is injected.
+	OnFault func(Event)
+}
+
+// Connect connects the wrapped transport and wraps the connection.
+func (t *Transport) Connect(ctx context.Context) (mcp.Connection, error) {
+	inner, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{
+		Connection: inner,
+		policy:     t.Policy,
+		onFault:    t.OnFault,
+		rand:       rand.New(rand.NewPCG(t.Policy.Seed, 0)),
+		lost:       map[jsonrpc.ID]bool{},
+		incoming:   make(chan read),
+		injected:   make(chan jsonrpc.Message, 16),
+		done:       make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+type read struct {
+	msg jsonrpc.Message
+	err error
+}
+
+type conn struct {
+	mcp.Connection
+	policy  *Policy
+	onFault func(Event)
+
+	// incoming carries what the wrapped connection reads; injected carries
+	// the duplicates and stand-in responses to deliver before it.
+	incoming chan read
+	injected chan jsonrpc.Message
+	done     chan struct{}
+	close    sync.Once
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	// started is set once the client sends anything but the handshake.
+	started bool
+	calls   int
+	killed  bool
+	// lost holds the IDs of the client's calls whose request or response
+	// was dropped.
+	lost map[jsonrpc.ID]bool
+}
+
+// readLoop reads the wrapped connection until it fails, so Read can also
+// wait for injected messages.
+func (c *conn) readLoop() {
+	for {
+		msg, err := c.Connection.Read(context.Background())
+		select {
+		case c.incoming <- read{msg, err}:
+		case <-c.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// draw picks the fault for a message, if any, and its delay.
+func (c *conn) draw(msg jsonrpc.Message, sent bool) (Fault, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sent && !handshake(msg) {
+		c.started = true
+	}
+	if !c.started {
+		return "", 0
+	}
+	var delay time.Duration
+	if c.policy.Latency > 0 {
+		delay = time.Duration(c.rand.Int64N(int64(c.policy.Latency) + 1))
+	}
+	r := c.rand.Float64()
+	switch {
+	case r < c.policy.Drop:
+		return Dropped, delay
+	case r < c.policy.Drop+c.policy.Duplicate:
+		return Duplicated, delay
+	case !sent && r < c.policy.Drop+c.policy.Duplicate+c.policy.Truncate:
+		return Truncated, delay
+	}
+	return "", delay
+}
+
+func handshake(msg jsonrpc.Message) bool {
+	req, ok := msg.(*jsonrpc.Request)
+	return ok && (req.Method == "initialize" || req.Method == "notifications/initialized")
+}
+
+func (c *conn) record(fault Fault, sent bool, msg jsonrpc.Message) {
+	if c.onFault != nil {
+		c.onFault(Event{Fault: fault, Sent: sent, Message: describe(msg)})
+	}
+}
+
+// describe names msg by its method or the ID it answers.
+func describe(msg jsonrpc.Message) string {
+	switch m := msg.(type) {
+	case *jsonrpc.Request:
+		if !m.ID.IsValid() {
+			return m.Method + " notification"
+		}
+		return fmt.Sprintf("%s request %v", m.Method, m.ID.Raw())
+	case *jsonrpc.Response:
+		return fmt.Sprintf("response %v", m.ID.Raw())
+	}
+	return "message"
+}
+
+// lose remembers the client's call a dropped message belonged to.
+func (c *conn) lose(msg jsonrpc.Message) {
+	var id jsonrpc.ID
+	switch m := msg.(type) {
+	case *jsonrpc.Request:
+		id = m.ID
+	case *jsonrpc.Response:
+		id = m.ID
+	}
+	if id.IsValid() {
+		c.mu.Lock()
+		c.lost[id] = true
+		c.mu.Unlock()
+	}
+}
+
+// cancelled answers a lost call the client gave up on, which it otherwise
+// keeps waiting for, so a dropped message costs the call its timeout and not
+// the whole session: the SDK does not close a connection with calls still
+// outstanding.
+func (c *conn) cancelled(msg jsonrpc.Message) {
+	req, ok := msg.(*jsonrpc.Request)
+	if !ok || req.Method != "notifications/cancelled" {
+		return
+	}
+	var params struct {
+		RequestID any `json:"requestId"`
+	}
+	if json.Unmarshal(req.Params, &params) != nil {
+		return
+	}
+	id, err := jsonrpc.MakeID(params.RequestID)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	lost := c.lost[id]
+	delete(c.lost, id)
+	c.mu.Unlock()
+	if lost {
+		c.inject(&jsonrpc.Response{ID: id, Error: errors.New("chaos: the call's request or response was dropped")})
+	}
+}
+
+func (c *conn) inject(msg jsonrpc.Message) {
+	select {
+	case c.injected <- msg:
+	case <-c.done:
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *conn) isKilled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.killed
+}
+
+func (c *conn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		if c.isKilled() {
+			return nil, mcp.ErrConnectionClosed
+		}
+		var r read
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, mcp.ErrConnectionClosed
+		case msg := <-c.injected:
+			return msg, nil
+		case r = <-c.incoming:
+		}
+		// A response racing the kill must not reach the call in flight.
+		if c.isKilled() {
+			return nil, mcp.ErrConnectionClosed
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		msg := r.msg
+		fault, delay := c.draw(msg, false)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		switch fault {
+		case Dropped:
+			c.record(fault, false, msg)
+			c.lose(msg)
+			continue
+		case Duplicated:
+			c.record(fault, false, msg)
+			c.inject(msg)
+		case Truncated:
+			c.record(fault, false, msg)
+			return nil, truncate(msg)
+		}
+		return msg, nil
+	}
+}
+
+// truncate returns the error decoding the first half of msg's frame gives.
+func truncate(msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	_, err = jsonrpc.DecodeMessage(data[:len(data)/2])
+	return fmt.Errorf("chaos: truncated frame %q: %w", data[:len(data)/2], err)
+}
+
+func (c *conn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	c.cancelled(msg)
+	fault, delay := c.draw(msg, true)
+	if err := sleep(ctx, delay); err != nil {
+		return err
+	}
+	switch fault {
+	case Dropped:
+		c.record(fault, true, msg)
+		c.lose(msg)
+		return nil
+	case Duplicated:
+		c.record(fault, true, msg)
+		if err := c.Connection.Write(ctx, msg); err != nil {
+			return err
+		}
+	}
+	// Decide on the kill before writing: the response can race the write.
+	kill := false
+	if req, ok := msg.(*jsonrpc.Request); ok && req.Method == "tools/call" && req.ID.IsValid() {
+		c.mu.Lock()
+		c.calls++
+		kill = c.calls == c.policy.KillAfter
+		c.killed = c.killed || kill
+		c.mu.Unlock()
+	}
+	if err := c.Connection.Write(ctx, msg); err != nil {
+		return err
+	}
+	if kill {
+		c.record(Killed, true, msg)
+		// The call is in flight: it fails as the connection goes down.
+		c.Connection.Close()
+	}
+	return nil
+}
+
+func (c *conn) Close() error {
+	c.close.Do(func() { close(c.done) })
+	return c.Connection.Close()
+}
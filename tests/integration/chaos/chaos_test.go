@@ -0,0 +1,123 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse("latency=200ms, drop=0.05,duplicate=0.1,truncate=0.01,kill-after=3,seed=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Policy{Latency: 200 * time.Millisecond, Drop: 0.05, Duplicate: 0.1, Truncate: 0.01, KillAfter: 3, Seed: 7}
+	if *p != want {
+		t.Errorf("Parse = %+v, want %+v", *p, want)
+	}
+	if got := p.String(); got != "latency=200ms,drop=0.05,duplicate=0.1,truncate=0.01,kill-after=3,seed=7" {
+		t.Errorf("String = %q", got)
+	}
+	for _, bad := range []string{"drop", "drop=2", "drop=0.6,duplicate=0.6", "jitter=1s", "latency=-1s"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+type echoArgs struct {
+	Text string `json:"text"`
+}
+
+// session connects a client through policy to a server with an echo tool,
+// returning the faults injected.
+func session(t *testing.T, policy *Policy) (*mcp.ClientSession, func() []Event) {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "server"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo"}, func(_ context.Context, _ *mcp.CallToolRequest, args echoArgs) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: args.Text}}}, nil, nil
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ctx := context.Background()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var events []Event
+	transport := &Transport{Transport: clientTransport, Policy: policy, OnFault: func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}}
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, transport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, func() []Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Event(nil), events...)
+	}
+}
+
+func callEcho(cs *mcp.ClientSession, timeout time.Duration) (*mcp.CallToolResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return cs.CallTool(ctx, &mcp.CallToolParams{Name: "echo", Arguments: echoArgs{Text: "hi"}})
+}
+
+func TestLatencyAndDuplicatesAreSurvivable(t *testing.T) {
+	cs, events := session(t, &Policy{Latency: 5 * time.Millisecond, Duplicate: 1})
+	for range 3 {
+		res, err := callEcho(cs, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := res.Content[0].(*mcp.TextContent).Text; text != "hi" {
+			t.Errorf("echo = %q", text)
+		}
+	}
+	if got := events(); len(got) == 0 || got[0].Fault != Duplicated {
+		t.Errorf("events = %v, want duplicates", got)
+	}
+}
+
+func TestKillAfterFailsTheCallInFlight(t *testing.T) {
+	cs, events := session(t, &Policy{KillAfter: 2})
+	if _, err := callEcho(cs, 5*time.Second); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	_, err := callEcho(cs, 5*time.Second)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second call = %v, want a connection error", err)
+	}
+	if got := events(); len(got) != 1 || !strings.HasPrefix(got[0].String(), "killed the connection after sending tools/call request") {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestTruncatedResponseFailsTheCall(t *testing.T) {
+	cs, events := session(t, &Policy{Truncate: 1})
+	if _, err := callEcho(cs, 5*time.Second); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("call = %v, want a decoding error", err)
+	}
+	if got := events(); len(got) == 0 || got[0].Fault != Truncated || got[0].Sent {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestDroppedRequestLeavesTheCallWaiting(t *testing.T) {
+	cs, events := session(t, &Policy{Drop: 1})
+	if _, err := callEcho(cs, 100*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("call = %v, want it to time out", err)
+	}
+	if got := events(); len(got) == 0 || got[0].String() != "dropped sent tools/call request 2" {
+		t.Errorf("events = %v", got)
+	}
+}
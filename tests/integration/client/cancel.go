@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// methodCancelled is the notification a client sends to cancel a request.
+const methodCancelled = "notifications/cancelled"
+
+// Cancellation is a request the client cancelled.
+type Cancellation struct {
+	// RequestID is the JSON-RPC ID of the cancelled request.
+	RequestID string
+	// Sent is when notifications/cancelled was sent.
+	Sent time.Time
+	// Answered is when a response to the request arrived after it was
+	// cancelled; zero if none did. A server may answer with an error, as one
+	// built on the Go SDK does, but a server that stops working on cancelled
+	// requests does not send a result.
+	Answered time.Time
+	// Completed is set if the response was a successful result: the server
+	// finished the call despite its cancellation. Over streamable HTTP the client
+	// stops reading the request's response stream when it cancels, so a
+	// late result is not seen.
+	Completed bool
+
+	id jsonrpc.ID
+}
+
+func (c Cancellation) String() string {
+	switch after := c.Answered.Sub(c.Sent).Round(time.Millisecond); {
+	case c.Answered.IsZero():
+		return fmt.Sprintf("request %s, cancelled and not answered", c.RequestID)
+	case c.Completed:
+		return fmt.Sprintf("request %s, completed %s after it was cancelled", c.RequestID, after)
+	default:
+		return fmt.Sprintf("request %s, answered with an error %s after it was cancelled", c.RequestID, after)
+	}
+}
+
+// sawCancel records a notifications/cancelled the client sent.
+func (t *timingTransport) sawCancel(req *jsonrpc.Request) {
+	var params struct {
+		RequestID any `json:"requestId"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return
+	}
+	id, err := jsonrpc.MakeID(params.RequestID)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancelled = append(t.cancelled, &Cancellation{RequestID: fmt.Sprint(id.Raw()), Sent: time.Now(), id: id})
+}
+
+// sawResponse records a response to a cancelled request.
+func (t *timingTransport) sawResponse(resp *jsonrpc.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.cancelled {
+		if c.id == resp.ID && c.Answered.IsZero() {
+			c.Answered, c.Completed = time.Now(), completed(resp)
+		}
+	}
+}
+
+// closeUnanswered closes the connection if a cancelled request has not been
+// answered, so the SDK, which waits for the response to every request before
+// closing a session, does not wait forever on a server that heeded the
+// cancellation.
+func (t *timingTransport) closeUnanswered() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.cancelled {
+		if c.Answered.IsZero() && t.conn != nil {
+			t.conn.Close()
+			return
+		}
+	}
+}
+
+// completed reports whether resp is a successful result rather than a
+// JSON-RPC or tool error.
+func completed(resp *jsonrpc.Response) bool {
+	if resp.Error != nil {
+		return false
+	}
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	return json.Unmarshal(resp.Result, &result) == nil && !result.IsError
+}
+
+// Cancellations returns the requests of the session the client cancelled,
+// in order.
+func (s *Session) Cancellations() []Cancellation {
+	t := s.conn.timing
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Cancellation, len(t.cancelled))
+	for i, c := range t.cancelled {
+		out[i] = *c
+	}
+	return out
+}
+
+// Ping checks that the server still answers requests in the session.
+func (s *Session) Ping(ctx context.Context) error {
+	return s.conn.session.Ping(ctx, nil)
+}
+
+// ServerPID returns the process ID of a stdio server, which leads the
+// process group of any children it starts, or 0 for a server reached over
+// the network.
+func (s *Session) ServerPID() int {
+	if s.conn.stdio == nil || s.conn.stdio.cmd.Process == nil {
+		return 0
+	}
+	return s.conn.stdio.cmd.Process.Pid
+}
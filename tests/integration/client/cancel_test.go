@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type waitArgs struct {
+	IgnoreCancel bool `json:"ignore_cancel,omitempty"`
+}
+
+func TestCallToolContextCancels(t *testing.T) {
+	// Over SSE a late answer arrives on the session's stream.
+	sse, _ := serveHTTP(t)
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, tc := range []struct {
+		name      string
+		args      waitArgs
+		completed bool
+	}{
+		{"honored", waitArgs{}, false},
+		{"ignored", waitArgs{IgnoreCancel: true}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			before := len(s.Cancellations())
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := s.CallToolContext(ctx, "wait", tc.args)
+			if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("CallToolContext() = %v, want ErrCancelled and the deadline", err)
+			}
+			// The ignoring server answers 200ms into the call.
+			time.Sleep(300 * time.Millisecond)
+			cancellations := s.Cancellations()[before:]
+			if len(cancellations) != 1 {
+				t.Fatalf("Cancellations() = %v, want the call", cancellations)
+			}
+			if c := cancellations[0]; c.Completed != tc.completed {
+				t.Errorf("cancellation = %s, want completed %v", c, tc.completed)
+			}
+			// The session outlives a cancelled call.
+			if err := s.Ping(context.Background()); err != nil {
+				t.Errorf("Ping() after the cancellation = %v", err)
+			}
+		})
+	}
+	if pid := s.ServerPID(); pid != 0 {
+		t.Errorf("ServerPID() over HTTP = %d, want 0", pid)
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ErrNoStructuredContent is returned by DecodeStructured for a result without
+// structuredContent.
+var ErrNoStructuredContent = errors.New("tool result has no structuredContent")
+
+// DecodeStructured decodes the result's structuredContent into v, as
+// json.Unmarshal would.
+func (r *Result) DecodeStructured(v any) error {
+	if r.Structured == nil {
+		return ErrNoStructuredContent
+	}
+	data, err := json.Marshal(r.Structured)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Images returns the image content blocks of the result, in order.
+func (r *Result) Images() []*mcp.ImageContent {
+	return blocks[*mcp.ImageContent](r)
+}
+
+// Audio returns the audio content blocks of the result, in order.
+func (r *Result) Audio() []*mcp.AudioContent {
+	return blocks[*mcp.AudioContent](r)
+}
+
+// Links returns the resource_link content blocks of the result, in order.
+func (r *Result) Links() []*mcp.ResourceLink {
+	return blocks[*mcp.ResourceLink](r)
+}
+
+// Embedded returns the resources embedded in the result, in order.
+func (r *Result) Embedded() []*mcp.ResourceContents {
+	var out []*mcp.ResourceContents
+	for _, e := range blocks[*mcp.EmbeddedResource](r) {
+		if e.Resource != nil {
+			out = append(out, e.Resource)
+		}
+	}
+	return out
+}
+
+// ContentTypes returns the type of each content block of the result, e.g.
+// text or image, for describing a result that lacks an expected block.
+func (r *Result) ContentTypes() []string {
+	types := make([]string, len(r.Content))
+	for i, c := range r.Content {
+		switch c.(type) {
+		case *mcp.TextContent:
+			types[i] = "text"
+		case *mcp.ImageContent:
+			types[i] = "image"
+		case *mcp.AudioContent:
+			types[i] = "audio"
+		case *mcp.ResourceLink:
+			types[i] = "resource_link"
+		case *mcp.EmbeddedResource:
+			types[i] = "resource"
+		default:
+			types[i] = "unknown"
+		}
+	}
+	return types
+}
+
+func blocks[T mcp.Content](r *Result) []T {
+	var out []T
+	for _, c := range r.Content {
+		if b, ok := c.(T); ok {
+			out = append(out, b)
+		}
+	}
+	return out
+}
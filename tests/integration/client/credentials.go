@@ -0,0 +1,103 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// impersonationURL is the IAM Credentials endpoint an impersonated
+// credentials file exchanges its source credentials at.
+const impersonationURL = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken"
+
+// serverEnv returns the environment a stdio server of toolCall is started
+// with: the harness's own, then toolCall.Env, then the variables that make
+// both gcloud and Google client libraries impersonate
+// toolCall.ImpersonateServiceAccount. cleanup removes any credentials file
+// written for the call.
+func serverEnv(toolCall ToolCall) (env []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if len(toolCall.Env) == 0 && toolCall.ImpersonateServiceAccount == "" {
+		return nil, cleanup, nil
+	}
+	env = append(os.Environ(), toolCall.Env...)
+	sa := toolCall.ImpersonateServiceAccount
+	if sa == "" {
+		return env, cleanup, nil
+	}
+
+	env = append(env, "CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT="+sa)
+	path, explicit := adcPath(env)
+	source, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		// On GCE and Cloud Build the ambient credentials come from the
+		// metadata server, which a credentials file cannot refer to; only
+		// gcloud impersonates then.
+		return env, cleanup, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("impersonating %s needs application default credentials to impersonate from: %w", sa, err)
+	}
+	file, err := impersonatedCredentials(source, sa)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp("", "impersonated-*.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.Remove(f.Name()) }
+	if _, err := f.Write(file); err != nil {
+		f.Close()
+		cleanup()
+		return nil, nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return append(env, "GOOGLE_APPLICATION_CREDENTIALS="+f.Name()), cleanup, nil
+}
+
+// impersonatedCredentials returns an impersonated_service_account
+// credentials file that uses the source credentials to act as sa.
+func impersonatedCredentials(source []byte, sa string) ([]byte, error) {
+	if !json.Valid(source) {
+		return nil, fmt.Errorf("application default credentials are not valid JSON")
+	}
+	return json.MarshalIndent(map[string]any{
+		"type":                              "impersonated_service_account",
+		"service_account_impersonation_url": fmt.Sprintf(impersonationURL, sa),
+		"source_credentials":                json.RawMessage(source),
+		"delegates":                         []string{},
+	}, "", "  ")
+}
+
+// adcPath returns where the application default credentials of env live:
+// GOOGLE_APPLICATION_CREDENTIALS if set (explicit), else the file `gcloud
+// auth application-default login` writes in the gcloud configuration
+// directory.
+func adcPath(env []string) (path string, explicit bool) {
+	lookup := func(name string) string {
+		// Later entries win, as they do for exec.Cmd.
+		value := ""
+		for _, kv := range env {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+				value = v
+			}
+		}
+		return value
+	}
+	if path := lookup("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path, true
+	}
+	dir := lookup("CLOUDSDK_CONFIG")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config", "gcloud")
+	}
+	return filepath.Join(dir, "application_default_credentials.json"), false
+}
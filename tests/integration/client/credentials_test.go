@@ -0,0 +1,134 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdioCallEnv(t *testing.T) {
+	call := stdioCall(t, "")
+	call.Env = []string{"GOOGLE_APPLICATION_CREDENTIALS=/keys/low-privilege.json"}
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, "ok /keys/low-privilege.json") {
+		t.Errorf("server did not see the injected environment: %s", result.Output)
+	}
+}
+
+func TestStdioCallDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLIENT_TEST_TOOL_CWD", "1")
+	call := stdioCall(t, "")
+	call.Dir = dir
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, "ok "+dir+`"`) {
+		t.Errorf("server did not run in %s: %s", dir, result.Output)
+	}
+}
+
+func TestStdioCallImpersonation(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "adc.json")
+	os.WriteFile(source, []byte(`{"type": "authorized_user", "client_id": "id", "refresh_token": "token"}`), 0o600)
+	call := stdioCall(t, "")
+	call.Env = []string{"GOOGLE_APPLICATION_CREDENTIALS=" + source}
+	call.ImpersonateServiceAccount = "denied@test-project.iam.gserviceaccount.com"
+
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(result.Output), &out); err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(out.Content[0].Text)
+	if len(fields) != 3 || fields[2] != call.ImpersonateServiceAccount {
+		t.Fatalf("server saw %q, want a credentials file and the impersonated account", out.Content[0].Text)
+	}
+	if fields[1] == source {
+		t.Error("server got the source credentials instead of an impersonated credentials file")
+	}
+	if _, err := os.Stat(fields[1]); !os.IsNotExist(err) {
+		t.Errorf("impersonated credentials file %s was not removed after the call: %v", fields[1], err)
+	}
+}
+
+func TestImpersonatedCredentials(t *testing.T) {
+	data, err := impersonatedCredentials([]byte(`{"type": "authorized_user"}`), "sa@p.iam.gserviceaccount.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Type   string `json:"type"`
+		URL    string `json:"service_account_impersonation_url"`
+		Source struct {
+			Type string `json:"type"`
+		} `json:"source_credentials"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "impersonated_service_account" || got.Source.Type != "authorized_user" ||
+		got.URL != "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/sa@p.iam.gserviceaccount.com:generateAccessToken" {
+		t.Errorf("impersonatedCredentials() = %s", data)
+	}
+	if _, err := impersonatedCredentials([]byte("not json"), "sa"); err == nil {
+		t.Error("impersonatedCredentials accepted invalid source credentials")
+	}
+}
+
+func TestADCPath(t *testing.T) {
+	if got, explicit := adcPath([]string{"GOOGLE_APPLICATION_CREDENTIALS=/a", "GOOGLE_APPLICATION_CREDENTIALS=/b"}); got != "/b" || !explicit {
+		t.Errorf("adcPath() = %q, %v, want the last entry", got, explicit)
+	}
+	if got, explicit := adcPath([]string{"CLOUDSDK_CONFIG=/cfg"}); got != filepath.Join("/cfg", "application_default_credentials.json") || explicit {
+		t.Errorf("adcPath() = %q, %v", got, explicit)
+	}
+}
+
+func TestImpersonationWithoutADCFile(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	env, cleanup, err := serverEnv(ToolCall{ImpersonateServiceAccount: "sa@p.iam.gserviceaccount.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if got := env[len(env)-1]; got != "CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT=sa@p.iam.gserviceaccount.com" {
+		t.Errorf("last env entry = %q, want only gcloud impersonation", got)
+	}
+}
+
+func TestImpersonatedCredentialsRemovedOnFailedConnect(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	source := filepath.Join(t.TempDir(), "adc.json")
+	os.WriteFile(source, []byte(`{"type": "authorized_user", "client_id": "id", "refresh_token": "token"}`), 0o600)
+	for name, mutate := range map[string]func(*ToolCall){
+		"invalid roots":    func(c *ToolCall) { c.Roots = []string{"https://example.com"} },
+		"server not found": func(c *ToolCall) { c.ServerCmd = []string{filepath.Join(tmp, "missing-server")} },
+	} {
+		call := stdioCall(t, "")
+		call.Env = []string{"GOOGLE_APPLICATION_CREDENTIALS=" + source}
+		call.ImpersonateServiceAccount = "denied@test-project.iam.gserviceaccount.com"
+		mutate(&call)
+		if _, err := InvokeMCPTool(call); err == nil {
+			t.Fatalf("%s: InvokeMCPTool succeeded", name)
+		}
+		if left, _ := filepath.Glob(filepath.Join(tmp, "impersonated-*.json")); len(left) > 0 {
+			t.Errorf("%s: credentials files left behind: %q", name, left)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Elicitation actions, as defined by the protocol.
+const (
+	ElicitAccept  = "accept"
+	ElicitDecline = "decline"
+	ElicitCancel  = "cancel"
+)
+
+// ElicitationRule answers the elicitation requests whose message matches
+// Pattern.
+type ElicitationRule struct {
+	Pattern *regexp.Regexp
+	// Action is ElicitAccept, ElicitDecline or ElicitCancel.
+	Action string
+	// Content is the form data submitted with ElicitAccept. It must match the
+	// schema the server requested.
+	Content map[string]any
+}
+
+// Elicited is an elicitation request an ElicitationScript answered or
+// refused.
+type Elicited struct {
+	Message string
+	// Schema is the schema the server requested, if any.
+	Schema any
+	// Rule is the index of the matching rule, or -1 if none matched and the
+	// request was refused.
+	Rule   int
+	Action string
+}
+
+// ElicitationScript answers a server's elicitation/create requests, such as
+// a confirmation before a destructive command, the way a user would, so the
+// confirmation flow can be tested without anyone at the keyboard. The first
+// rule whose pattern matches the message answers; a message matching no rule
+// is refused with an error, so a changed prompt fails the test instead of
+// hanging or being answered arbitrarily. Setting it on a ToolCall makes the
+// client advertise the elicitation capability. It is safe for concurrent
+// use.
+type ElicitationScript struct {
+	Rules []ElicitationRule
+
+	mu       sync.Mutex
+	elicited []Elicited
+}
+
+// ApproveElicitations returns a script that accepts every request with no
+// form data, as a user confirming every prompt would.
+func ApproveElicitations() *ElicitationScript {
+	return &ElicitationScript{Rules: []ElicitationRule{{Pattern: regexp.MustCompile(``), Action: ElicitAccept}}}
+}
+
+// DenyElicitations returns a script that declines every request.
+func DenyElicitations() *ElicitationScript {
+	return &ElicitationScript{Rules: []ElicitationRule{{Pattern: regexp.MustCompile(``), Action: ElicitDecline}}}
+}
+
+// Uniform returns the action the script gives every request, if it answers
+// them all the same way without form data, as ApproveElicitations and
+// DenyElicitations do.
+func (s *ElicitationScript) Uniform() (action string, ok bool) {
+	if len(s.Rules) == 0 {
+		return "", false
+	}
+	first := s.Rules[0]
+	if first.Pattern.String() != "" || first.Content != nil {
+		return "", false
+	}
+	return first.Action, true
+}
+
+// Elicited returns the requests the script has seen, in arrival order.
+func (s *ElicitationScript) Elicited() []Elicited {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Elicited(nil), s.elicited...)
+}
+
+func (s *ElicitationScript) elicit(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, rule := range s.Rules {
+		if !rule.Pattern.MatchString(req.Params.Message) {
+			continue
+		}
+		s.elicited = append(s.elicited, Elicited{Message: req.Params.Message, Schema: req.Params.RequestedSchema, Rule: i, Action: rule.Action})
+		result := &mcp.ElicitResult{Action: rule.Action}
+		if rule.Action == ElicitAccept {
+			result.Content = rule.Content
+		}
+		return result, nil
+	}
+	s.elicited = append(s.elicited, Elicited{Message: req.Params.Message, Schema: req.Params.RequestedSchema, Rule: -1})
+	return nil, fmt.Errorf("no scripted elicitation answer matches message %q", req.Params.Message)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestElicitationScript(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	tests := []struct {
+		name   string
+		script *ElicitationScript
+		want   string
+	}{
+		{"approve", ApproveElicitations(), `"bucket deleted"`},
+		{"deny", DenyElicitations(), "bucket kept: decline"},
+		{"scripted", &ElicitationScript{Rules: []ElicitationRule{
+			{Pattern: regexp.MustCompile(`(?i)delete project`), Action: ElicitDecline},
+			{Pattern: regexp.MustCompile(`(?i)delete bucket gs://test-bucket`), Action: ElicitAccept, Content: map[string]any{"reason": "cleanup"}},
+		}}, "bucket deleted: cleanup"},
+		{"cancel", &ElicitationScript{Rules: []ElicitationRule{{Pattern: regexp.MustCompile(`.`), Action: ElicitCancel}}}, "bucket kept: cancel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "delete_bucket", ToolArgs: map[string]any{}, Elicitation: tt.script})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(result.Output, tt.want) {
+				t.Errorf("tool result does not contain %q:\n%s", tt.want, result.Output)
+			}
+			elicited := tt.script.Elicited()
+			if len(elicited) != 1 || !strings.HasPrefix(elicited[0].Message, "Delete bucket") || elicited[0].Schema == nil {
+				t.Errorf("Elicited() = %+v", elicited)
+			}
+		})
+	}
+}
+
+func TestElicitationScriptRefusesUnmatchedMessage(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	script := &ElicitationScript{Rules: []ElicitationRule{{Pattern: regexp.MustCompile(`^never$`), Action: ElicitAccept}}}
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "delete_bucket", ToolArgs: map[string]any{}, Elicitation: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(result.Output, "no scripted elicitation answer") {
+		t.Errorf("tool result = %s, want the refusal passed back through the server", result.Output)
+	}
+	if elicited := script.Elicited(); len(elicited) != 1 || elicited[0].Rule != -1 {
+		t.Errorf("Elicited() = %+v", elicited)
+	}
+}
+
+func TestElicitationContentMustMatchSchema(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	script := &ElicitationScript{Rules: []ElicitationRule{{Pattern: regexp.MustCompile(``), Action: ElicitAccept, Content: map[string]any{"reason": 42}}}}
+	// The client rejects scripted content the requested schema does not
+	// allow, so the server's tool fails.
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "delete_bucket", ToolArgs: map[string]any{}, Elicitation: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Errorf("tool accepted content that does not match its schema: %s", result.Output)
+	}
+}
+
+func TestNoElicitationWithoutScript(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "delete_bucket", ToolArgs: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Errorf("elicitation succeeded without a script: %s", result.Output)
+	}
+}
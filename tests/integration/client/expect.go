@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Errors returned when a call declared with ExpectError does not fail as
+// expected.
+var (
+	ErrUnexpectedSuccess = errors.New("tool call succeeded but was expected to fail")
+	ErrErrorMismatch     = errors.New("tool call failed with an unexpected error")
+)
+
+// ExpectedError declares that a tool call must fail, either with a JSON-RPC
+// error or with a result that has isError set.
+type ExpectedError struct {
+	// Message, if set, must occur in the JSON-RPC error message or in the text
+	// content of the isError result.
+	Message string
+	// ProtocolError requires a JSON-RPC error rather than an isError result.
+	ProtocolError bool
+}
+
+// check compares the outcome of tools/call against e. It returns the error
+// message the server produced when the expectation holds.
+func (e *ExpectedError) check(result *mcp.CallToolResult, callErr error) (string, error) {
+	var got string
+	switch {
+	case callErr != nil:
+		got = callErr.Error()
+	case result.IsError && !e.ProtocolError:
+		got = resultText(result)
+	case result.IsError:
+		return "", fmt.Errorf("%w: want a JSON-RPC error, got an isError result: %s", ErrErrorMismatch, resultText(result))
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnexpectedSuccess, resultText(result))
+	}
+	if e.Message != "" && !strings.Contains(got, e.Message) {
+		return "", fmt.Errorf("%w: want message containing %q, got %q", ErrErrorMismatch, e.Message, got)
+	}
+	return got, nil
+}
+
+// resultText concatenates the text content blocks of result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func textResult(text string, isError bool) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}, IsError: isError}
+}
+
+func TestExpectedErrorCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  ExpectedError
+		result  *mcp.CallToolResult
+		callErr error
+		wantErr error
+	}{
+		{"isError result", ExpectedError{Message: "denied"}, textResult("Execution denied", true), nil, nil},
+		{"protocol error", ExpectedError{Message: "unknown tool"}, nil, errors.New(`unknown tool "x"`), nil},
+		{"any error", ExpectedError{}, textResult("boom", true), nil, nil},
+		{"success", ExpectedError{}, textResult("ok", false), nil, ErrUnexpectedSuccess},
+		{"wrong message", ExpectedError{Message: "denied"}, textResult("quota exceeded", true), nil, ErrErrorMismatch},
+		{"want protocol error", ExpectedError{ProtocolError: true}, textResult("boom", true), nil, ErrErrorMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.expect.check(tt.result, tt.callErr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("check() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recorded := `{"content":[{"type":"text","text":"Execution denied"}],"isError":true}`
+	denied := ToolCall{ToolName: "run_gcloud_command", ExpectError: &ExpectedError{Message: "denied"}}
+	result, err := Replay(denied, recorded, nil)
+	if err != nil || result.ErrorMessage != "Execution denied" || !result.IsError {
+		t.Errorf("Replay(isError result) = %+v, %v", result, err)
+	}
+	if _, err := Replay(denied, `{"content":[{"type":"text","text":"ok"}]}`, nil); !errors.Is(err, ErrUnexpectedSuccess) {
+		t.Errorf("Replay(success) = %v, want %v", err, ErrUnexpectedSuccess)
+	}
+	if result, err := Replay(denied, "", errors.New("permission denied")); err != nil || result.Output != "" {
+		t.Errorf("Replay(JSON-RPC error) = %+v, %v", result, err)
+	}
+	if _, err := Replay(ToolCall{ToolName: "x"}, "", errors.New("boom")); !errors.Is(err, ErrToolExecution) {
+		t.Errorf("Replay(unexpected error) = %v, want %v", err, ErrToolExecution)
+	}
+}
+
+func TestReplayTokenBudget(t *testing.T) {
+	recorded := `{"content":[{"type":"text","text":"0123456789abcdef"}]}`
+	if _, err := Replay(ToolCall{ToolName: "x", MaxTokens: 4}, recorded, nil); err != nil {
+		t.Errorf("Replay(within budget) = %v", err)
+	}
+	_, err := Replay(ToolCall{ToolName: "x", MaxTokens: 3}, recorded, nil)
+	if !errors.Is(err, ErrTokenBudget) || !strings.Contains(err.Error(), "~4 tokens (16 bytes)") {
+		t.Errorf("Replay(over budget) = %v, want %v", err, ErrTokenBudget)
+	}
+}
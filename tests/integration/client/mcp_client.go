@@ -3,50 +3,393 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/chaos"
+	"github.com/googleapis/gcloud-mcp/tests/integration/tokens"
+)
+
+// Errors wrapped by InvokeMCPTool, identifying the phase that failed.
+var (
+	ErrConnect       = errors.New("failed to connect")
+	ErrToolExecution = errors.New("tool execution failed")
+	ErrTokenBudget   = errors.New("tool result over its token budget")
+	ErrCancelled     = errors.New("tool call cancelled")
 )
 
 type ToolCall struct {
 	ServerCmd []string
-	ToolName  string
-	ToolArgs  any
+	// Endpoints lists the ways of reaching the server in order of preference;
+	// each is tried until one connects. Stdio endpoints launch ServerCmd.
+	// Empty means stdio only.
+	Endpoints []Endpoint
+	// Dial, if set, connects to the server in place of its endpoints, e.g. to
+	// an in-memory stand-in for it.
+	Dial     func() mcp.Transport
+	ToolName string
+	ToolArgs any
+	// Meta is sent as the request's _meta, e.g. a progressToken or a trace ID
+	// for correlating the call with server logs.
+	Meta map[string]any
+	// TerminateDuration bounds how long the server may take to exit after the
+	// session is closed before it is sent SIGTERM. Zero uses the SDK default.
+	TerminateDuration time.Duration
+	// ExpectError, if set, turns the call into a negative test: it succeeds
+	// only if the tool fails as described, and a successful tool result is
+	// reported as an error.
+	ExpectError *ExpectedError
+	// ValidateArgs checks ToolArgs against the tool's input schema, fetched
+	// with tools/list, before calling it and fails with ErrInvalidArgs on a
+	// mismatch.
+	ValidateArgs bool
+	// ProtocolVersion, if set, is the MCP protocol version requested in the
+	// initialize handshake instead of the latest, one of ProtocolVersions.
+	// The call fails with ErrProtocolVersion if the server answers with
+	// another.
+	ProtocolVersion string
+	// ValidateOutput checks the structuredContent of the tool's result
+	// against its output schema, fetched with tools/list, and fails with
+	// ErrInvalidOutput on a mismatch or when a tool declaring one returns
+	// none.
+	ValidateOutput bool
+	// StdoutNoise is fed to the client ahead of a stdio server's output, to
+	// simulate a server that prints banners or logs to stdout.
+	StdoutNoise []byte
+	// RecordStdoutPollution makes a stdio connection skip lines that are not
+	// JSON-RPC messages, recording them in Result.Pollution, instead of
+	// failing the call with a FramingError at the first one.
+	RecordStdoutPollution bool
+	// Env holds KEY=VALUE entries added to a stdio server's environment, e.g.
+	// GOOGLE_APPLICATION_CREDENTIALS or CLOUDSDK_* settings.
+	Env []string
+	// Dir is the working directory of a stdio server, e.g. one that reads a
+	// config file relative to it. Empty uses the harness's.
+	Dir string
+	// ImpersonateServiceAccount, if set, starts a stdio server acting as this
+	// service account: gcloud through CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT
+	// and client libraries through a temporary impersonated credentials file
+	// built from the caller's application default credentials. Without a
+	// credentials file to build from, only gcloud impersonates.
+	ImpersonateServiceAccount string
+	// OnNotification, if set, receives every progress and log notification
+	// the server sends while the session is open, as it arrives. Setting it
+	// also adds a progressToken to Meta unless Meta already has one, asking
+	// the server to report progress.
+	OnNotification func(Notification)
+	// LogLevel, if set, subscribes the session to the server's log messages
+	// at this level and above (debug, info, notice, warning, error, ...) with
+	// logging/setLevel before the tool is called. The call fails with
+	// ErrNoLogging if the server does not support logging.
+	LogLevel string
+	// Sampling, if set, answers the server's sampling requests from canned
+	// responses; without it the client does not offer sampling.
+	Sampling *SamplingScript
+	// Elicitation answers the server's elicitation requests. Without it the
+	// client does not offer elicitation.
+	Elicitation *ElicitationScript
+	// Roots are the directories, as paths or file:// URIs, the client offers
+	// the server as roots. Session.SetRoots changes them mid-session.
+	Roots []string
+	// Chaos, if set, injects the faults of an unreliable connection into the
+	// session, e.g. dropped messages or a connection killed mid-call.
+	Chaos *chaos.Policy
+	// OnFault, if set, is called for each fault Chaos injects.
+	OnFault func(chaos.Event)
+	// WireTrace, if set, records every JSON-RPC message of the session.
+	WireTrace *WireTrace
+	// Paging, if set, follows the tool's continuation hints to fetch every
+	// page of its result, up to a limit. Without it only the first page is
+	// fetched, and Result.Continuation tells whether there are more.
+	Paging *Paging
+	// MaxTokens, if set, fails the call with ErrTokenBudget if the tool
+	// result takes more than this many tokens of a model's context, as
+	// tokens.Measure estimates them. In a Session it applies to every call.
+	MaxTokens int
+	// TokenBudget, if set and MaxTokens is not, returns the MaxTokens of a
+	// call of the named tool, so each call of a Session gets its own tool's
+	// budget.
+	TokenBudget func(tool string) int
+	// Cacheable marks the call as idempotent and read-only, so Cache may
+	// answer it with the result of an identical earlier call.
+	Cacheable bool
+	// RateLimit, if set, throttles the call's requests along with every other
+	// call sharing it, and retries them while the server reports exhausted
+	// quota.
+	RateLimit *RateLimit
+	// Readiness, if set, retries the initialize handshake of a server that
+	// is not ready yet for its window before the call fails to connect.
+	Readiness *Readiness
+	// Guard, if set, is called with each tool call before it is made; an
+	// error fails the call unmade and unrecorded, e.g. one the run's budget
+	// for billable operations cannot afford.
+	Guard func(ToolCall) error
+	// Reconnect, if set, makes a Session whose server connection drops
+	// connect anew instead of failing its later calls. Single calls ignore it.
+	Reconnect *Reconnect
+	// Cache, if set, reuses the results of Cacheable calls for its TTL. A
+	// reused result has Cached set, and the call is not recorded in
+	// DefaultRecorder since it made no request.
+	Cache *ResultCache
 }
 
-func InvokeMCPTool(toolCall ToolCall) (string, error) {
-	if len(toolCall.ServerCmd) == 0 {
-		return "", fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+// Result is the outcome of a successful InvokeMCPTool call.
+type Result struct {
+	// Output is the tool result as indented JSON. It is empty when an expected
+	// JSON-RPC error was returned instead of a result.
+	Output string
+	// IsError mirrors the isError flag of the tool result.
+	IsError bool
+	// ErrorMessage is the failure the server reported for an ExpectError call.
+	ErrorMessage string
+	Metrics      Metrics
+	// Endpoint is the endpoint the call connected to.
+	Endpoint Endpoint
+	// Downgrades lists the preferred endpoints that failed to connect.
+	Downgrades []Downgrade
+	// Meta is the _meta of the tool result, if the server set one.
+	Meta map[string]any
+	// Structured is the structuredContent of the tool result decoded as a
+	// JSON object, or nil if it has none. With Paging it is the last page's.
+	Structured map[string]any
+	// Pollution lists the non-protocol lines a stdio server wrote, if
+	// RecordStdoutPollution was set.
+	Pollution []Pollution
+	// Notifications lists the progress, log and list_changed notifications
+	// the server sent, in arrival order.
+	Notifications []Notification
+	// Capabilities are the capabilities the server advertised.
+	Capabilities *mcp.ServerCapabilities
+	// ProtocolVersion is the protocol version the server negotiated.
+	ProtocolVersion string
+	// Content is every content block of the tool result, of every page
+	// fetched, in order.
+	Content []mcp.Content
+	// Pages is the number of pages of the result fetched, more than one with
+	// Paging.
+	Pages int
+	// Continuation is the cursor of the result's next page, if it has one
+	// that was not fetched: the result is incomplete.
+	Continuation string
+	// Cached is set on a result ToolCall.Cache reused instead of calling the
+	// tool.
+	Cached bool
+}
+
+// InvokeMCPTool starts the server, calls the tool and closes the session.
+// The call's Metrics are recorded in DefaultRecorder whether or not it fails;
+// Total does not include shutting the server down.
+func InvokeMCPTool(toolCall ToolCall) (out *Result, err error) {
+	if len(toolCall.ServerCmd) == 0 && len(toolCall.Endpoints) == 0 {
+		return nil, fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+	}
+	if toolCall.Cache.cacheable(toolCall) {
+		key, keyErr := cacheKey(toolCall)
+		if keyErr != nil {
+			return nil, fmt.Errorf("failed to encode tool arguments: %w", keyErr)
+		}
+		if cached, ok := toolCall.Cache.get(key); ok {
+			return cached, nil
+		}
+		defer func() {
+			if err == nil {
+				toolCall.Cache.put(key, out)
+			}
+		}()
+	}
+
+	if toolCall.Guard != nil {
+		if err := toolCall.Guard(toolCall); err != nil {
+			return nil, err
+		}
 	}
 
 	var (
-		ctx       = context.Background()
-		transport mcp.Transport
+		ctx        = context.Background()
+		start      = time.Now()
+		metrics    = Metrics{Server: serverName(toolCall), Tool: toolCall.ToolName, Started: start}
+		downgrades []Downgrade
+		conn       *connection
 	)
+	defer func() {
+		// Runs after the session is closed, so output written during
+		// shutdown is included.
+		var (
+			pollution     []Pollution
+			notifications []Notification
+			stderr        string
+		)
+		if conn != nil {
+			notifications = conn.notices.notifications()
+			if conn.stdio != nil {
+				pollution = conn.stdio.recordedPollution()
+				stderr = conn.stdio.stderr.String()
+			}
+		}
+		var output string
+		if out != nil {
+			out.Pollution, out.Notifications = pollution, notifications
+			output = out.Output + out.ErrorMessage
+		}
+		DefaultRecorder.record(Invocation{Call: toolCall, Metrics: metrics, Downgrades: downgrades, Pollution: pollution, Notifications: notifications, Stderr: stderr, Output: output, Err: err})
+	}()
 
-	cmd := exec.Command(toolCall.ServerCmd[0], toolCall.ServerCmd[1:]...)
-	transport = &mcp.CommandTransport{Command: cmd}
-	client := mcp.NewClient(&mcp.Implementation{Name: "mcp-client", Version: "v1.0.0"}, nil)
-	cs, err := client.Connect(ctx, transport, nil)
+	conn, err = connect(ctx, toolCall)
+	metrics.Connect = time.Since(start)
+	metrics.Total = metrics.Connect
+	if conn != nil {
+		metrics.Startup = conn.startup
+		metrics.ReadinessRetries = conn.readinessRetries
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to connect: %w", err)
+		metrics.Failed = true
+		return nil, err
 	}
+	cs, transport := conn.session, conn.timing
+	downgrades = conn.downgrades
 	defer cs.Close()
 
+	result := &Result{Endpoint: conn.endpoint, Downgrades: conn.downgrades}
+	if init := cs.InitializeResult(); init != nil {
+		result.Capabilities, result.ProtocolVersion = init.Capabilities, init.ProtocolVersion
+	}
+	if toolCall.LogLevel != "" {
+		if err := setLogLevel(ctx, cs, toolCall.LogLevel); err != nil {
+			metrics.Failed = true
+			metrics.Total = time.Since(start)
+			return nil, err
+		}
+	}
 	if toolCall.ToolName != "" {
-		result, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		if toolCall.ValidateArgs {
+			if err := validateArgs(ctx, cs, toolCall.ToolName, toolCall.ToolArgs); err != nil {
+				metrics.Failed = true
+				metrics.Total = time.Since(start)
+				return nil, err
+			}
+		}
+		meta := toolCall.Meta
+		if toolCall.OnNotification != nil {
+			meta = withProgressToken(meta)
+		}
+		callResult, err := callTool(ctx, cs, toolCall.RateLimit, &mcp.CallToolParams{
+			Meta:      mcp.Meta(meta),
 			Name:      toolCall.ToolName,
 			Arguments: toolCall.ToolArgs,
-		})
-		if err != nil {
-			return "", fmt.Errorf("tool execution failed: %w", err)
+		}, transport.mark, &metrics)
+		metrics.FirstResponse = transport.sinceMark()
+		if err == nil && toolCall.Paging != nil {
+			callResult, result.Pages, err = fetchPages(ctx, cs, toolCall, meta, callResult, toolCall.Paging, &metrics)
+		}
+		metrics.Total = time.Since(start)
+		if callResult != nil {
+			metrics.Output = tokens.Measure(callResult)
+		}
+		if framingErr := conn.framingError(); err != nil && framingErr != nil {
+			metrics.Failed = true
+			return nil, fmt.Errorf("%w: %w", ErrToolExecution, framingErr)
+		}
+		if err := evaluate(toolCall, callResult, err, result); err != nil {
+			metrics.Failed = true
+			return nil, err
 		}
-		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if toolCall.ValidateOutput {
+			if err := validateOutput(ctx, cs, toolCall.ToolName, callResult); err != nil {
+				metrics.Failed = true
+				return nil, err
+			}
+		}
+	}
+	result.Metrics = metrics
+	return result, nil
+}
+
+// Replay evaluates a recorded tools/call response the way InvokeMCPTool
+// would for toolCall, without contacting a server or recording anything.
+// output is a Result.Output; when it is empty, callErr is replayed as the
+// JSON-RPC error the server returned.
+func Replay(toolCall ToolCall, output string, callErr error) (*Result, error) {
+	var callResult *mcp.CallToolResult
+	if output != "" {
+		callResult = new(mcp.CallToolResult)
+		if err := json.Unmarshal([]byte(output), callResult); err != nil {
+			return nil, fmt.Errorf("failed to parse recorded tool result: %w", err)
+		}
+		callErr = nil
+	}
+	result := &Result{Endpoint: Endpoint{Transport: "replay"}}
+	if err := evaluate(toolCall, callResult, callErr, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TokenLimit returns the most tokens the result of the call of ToolName may
+// take: MaxTokens, or else what TokenBudget returns for the tool, or 0 for no
+// limit.
+func (c ToolCall) TokenLimit() int {
+	if c.MaxTokens > 0 || c.TokenBudget == nil {
+		return c.MaxTokens
+	}
+	return c.TokenBudget(c.ToolName)
+}
+
+// evaluate checks the tools/call response against toolCall's expectations
+// and fills in result.
+func evaluate(toolCall ToolCall, callResult *mcp.CallToolResult, callErr error, result *Result) error {
+	if toolCall.ExpectError != nil {
+		msg, err := toolCall.ExpectError.check(callResult, callErr)
 		if err != nil {
-			return "", fmt.Errorf("failed to format tool result: %w", err)
+			return err
 		}
-		return string(resultJSON), nil
+		result.ErrorMessage = msg
+		if callResult == nil {
+			return nil
+		}
+	} else if callErr != nil {
+		return fmt.Errorf("%w: %w", ErrToolExecution, callErr)
+	}
+	if size, limit := tokens.Measure(callResult), toolCall.TokenLimit(); limit > 0 && size.Tokens > limit {
+		return fmt.Errorf("%w: %s returned ~%d tokens (%d bytes), over its budget of %d", ErrTokenBudget, toolCall.ToolName, size.Tokens, size.Bytes, limit)
+	}
+	result.IsError = callResult.IsError
+	result.Meta = callResult.Meta
+	// structuredContent that is not an object is left to ValidateOutput.
+	result.Structured, _ = asMap(callResult.StructuredContent)
+	result.Content = callResult.Content
+	result.Continuation, _ = NextPage(callResult)
+	result.Pages = max(result.Pages, 1)
+	resultJSON, err := json.MarshalIndent(callResult, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format tool result: %w", err)
 	}
-	return "", nil
+	result.Output = string(resultJSON)
+	return nil
+}
+
+// commandTransport returns a stdio transport that launches the server of
+// toolCall.
+func commandTransport(toolCall ToolCall) (*stdioTransport, error) {
+	env, cleanup, err := serverEnv(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(toolCall.ServerCmd[0], toolCall.ServerCmd[1:]...)
+	cmd.Env = env
+	cmd.Dir = toolCall.Dir
+	return &stdioTransport{
+		cmd:       cmd,
+		terminate: toolCall.TerminateDuration,
+		noise:     toolCall.StdoutNoise,
+		cleanup:   cleanup,
+		lenient:   toolCall.RecordStdoutPollution,
+	}, nil
+}
+
+func newClient(opts *mcp.ClientOptions) *mcp.Client {
+	return mcp.NewClient(&mcp.Implementation{Name: "mcp-client", Version: "v1.0.0"}, opts)
 }
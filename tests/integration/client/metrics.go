@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/tokens"
+)
+
+// Metrics holds the timings of a single InvokeMCPTool call.
+type Metrics struct {
+	Server string
+	Tool   string
+	// Started is when the invocation began.
+	Started time.Time
+	// Connect covers spawning the server and completing the initialize handshake.
+	Connect time.Duration
+	// Startup is the part of Connect from spawning a stdio server to
+	// completing the initialize handshake, without failed attempts at
+	// preferred endpoints. It is zero for a server reached over the network
+	// and for calls in a session after its first, unless they reconnected.
+	Startup time.Duration
+	// FirstResponse is the time from sending tools/call until the first message
+	// (a notification or the result) arrives from the server.
+	FirstResponse time.Duration
+	// Total is the wall time of the whole invocation, including Connect.
+	Total time.Duration
+	// Output is the size of the tool result, if it returned one.
+	Output tokens.Size
+	// Failed reports whether the invocation returned an error.
+	Failed bool
+	// Throttled is how long the call waited for its server's RateLimit and
+	// backed off from exhausted quota; it is included in Total.
+	Throttled time.Duration
+	// QuotaRetries counts the times the call was retried after the server
+	// reported exhausted quota.
+	QuotaRetries int
+	// ReadinessRetries counts the handshakes retried before the server was
+	// ready; like Startup, it is only set on a session's first call and on
+	// a call that reconnected.
+	ReadinessRetries int
+	// Reconnected is why the call's session reconnected before making it:
+	// how its previous connection dropped. It is empty if it did not.
+	Reconnected string
+}
+
+// Invocation is a recorded InvokeMCPTool call.
+type Invocation struct {
+	Call    ToolCall
+	Metrics Metrics
+	// Downgrades lists the endpoints the call fell back from.
+	Downgrades []Downgrade
+	// Pollution lists the non-protocol lines a stdio server wrote.
+	Pollution []Pollution
+	// Notifications lists the progress, log and list_changed notifications the
+	// server sent.
+	Notifications []Notification
+	// Stderr is the end of what a stdio server wrote to stderr up to its
+	// shutdown. A session's is on its last call.
+	Stderr string
+	// Output is the call's raw result: its Output, or the ErrorMessage of an
+	// ExpectError call. DropOutputs releases it once it is no longer needed.
+	Output string
+	// Err is the error the call returned, if any.
+	Err error
+}
+
+// Recorder collects every invocation. It is safe for concurrent use.
+type Recorder struct {
+	mu          sync.Mutex
+	invocations []Invocation
+}
+
+// DefaultRecorder receives every InvokeMCPTool call.
+var DefaultRecorder = &Recorder{}
+
+// record appends inv and returns its index.
+func (r *Recorder) record(inv Invocation) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invocations = append(r.invocations, inv)
+	return len(r.invocations) - 1
+}
+
+// addShutdown appends pollution to the invocation at index i and sets its
+// stderr, both of which a session only knows in full once it is closed.
+func (r *Recorder) addShutdown(i int, pollution []Pollution, stderr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invocations[i].Pollution = append(r.invocations[i].Pollution, pollution...)
+	r.invocations[i].Stderr = stderr
+}
+
+// Invocations returns a copy of everything recorded so far, in call order.
+func (r *Recorder) Invocations() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Invocation(nil), r.invocations...)
+}
+
+// DropOutputs clears the Output of the invocations from index from on, so a
+// long run does not hold every tool result until it ends.
+func (r *Recorder) DropOutputs(from int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := from; i < len(r.invocations); i++ {
+		r.invocations[i].Output = ""
+	}
+}
+
+// Calls returns the Metrics of everything recorded so far.
+func (r *Recorder) Calls() []Metrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]Metrics, len(r.invocations))
+	for i, inv := range r.invocations {
+		calls[i] = inv.Metrics
+	}
+	return calls
+}
+
+// timingTransport timestamps the first message read after mark is called.
+type timingTransport struct {
+	mcp.Transport
+
+	mu        sync.Mutex
+	markedAt  time.Time
+	firstRead time.Time
+	// cancelled lists the requests the client cancelled, in order.
+	cancelled []*Cancellation
+	// conn is the connection made, which Session.Close closes directly if
+	// a cancelled request is unanswered.
+	conn mcp.Connection
+}
+
+func (t *timingTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.conn = conn
+	t.mu.Unlock()
+	return &timingConn{Connection: conn, t: t}, nil
+}
+
+// mark starts measuring time to the next incoming message.
+func (t *timingTransport) mark() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.markedAt = time.Now()
+	t.firstRead = time.Time{}
+}
+
+// sinceMark returns the delay between mark and the first message read after
+// it, or zero if nothing has arrived.
+func (t *timingTransport) sinceMark() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.firstRead.IsZero() {
+		return 0
+	}
+	return t.firstRead.Sub(t.markedAt)
+}
+
+type timingConn struct {
+	mcp.Connection
+	t *timingTransport
+}
+
+func (c *timingConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	msg, err := c.Connection.Read(ctx)
+	if err == nil {
+		c.t.mu.Lock()
+		if !c.t.markedAt.IsZero() && c.t.firstRead.IsZero() {
+			c.t.firstRead = time.Now()
+		}
+		c.t.mu.Unlock()
+		if resp, ok := msg.(*jsonrpc.Response); ok {
+			c.t.sawResponse(resp)
+		}
+	}
+	return msg, err
+}
+
+func (c *timingConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if req, ok := msg.(*jsonrpc.Request); ok && req.Method == methodCancelled {
+		c.t.sawCancel(req)
+	}
+	return c.Connection.Write(ctx, msg)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Notification kinds.
+const (
+	NotificationProgress = "progress"
+	NotificationLog      = "log"
+	// NotificationListChanged is a tools, prompts or resources list_changed
+	// notification; Message names the list.
+	NotificationListChanged = "list_changed"
+	// NotificationStep is a sub-step the test itself reported rather than a
+	// server: Message names the step and Progress is its percentage. Server
+	// is empty.
+	NotificationStep = "step"
+)
+
+// ErrNoLogging is returned for a ToolCall with a LogLevel when the server does
+// not advertise the logging capability.
+var ErrNoLogging = errors.New("server does not advertise the logging capability")
+
+// Notification is a progress, log or list_changed notification a server sent
+// while a session was open, or a step a test reported.
+type Notification struct {
+	At     time.Time `json:"at"`
+	Server string    `json:"server"`
+	Kind   string    `json:"kind"`
+	// Progress and Total are set for progress notifications; Total is zero
+	// when unknown.
+	Progress float64 `json:"progress,omitempty"`
+	Total    float64 `json:"total,omitempty"`
+	// Token is the progressToken a progress notification carries, which
+	// must be the one the call sent.
+	Token string `json:"token,omitempty"`
+	// Level and Logger are set for log notifications.
+	Level  string `json:"level,omitempty"`
+	Logger string `json:"logger,omitempty"`
+	// Message is the progress message, the logged data or the changed list.
+	Message string `json:"message,omitempty"`
+}
+
+func (n Notification) String() string {
+	switch n.Kind {
+	case NotificationProgress:
+		s := fmt.Sprintf("progress %g", n.Progress)
+		if n.Total != 0 {
+			s = fmt.Sprintf("progress %g/%g", n.Progress, n.Total)
+		}
+		if n.Message != "" {
+			s += ": " + n.Message
+		}
+		return s
+	case NotificationListChanged:
+		return n.Message + "/list_changed"
+	case NotificationStep:
+		return fmt.Sprintf("step %g%%: %s", n.Progress, n.Message)
+	default:
+		s := "log " + n.Level
+		if n.Logger != "" {
+			s += " [" + n.Logger + "]"
+		}
+		return s + ": " + n.Message
+	}
+}
+
+// progressTokens numbers the progress tokens InvokeMCPTool generates.
+var progressTokens atomic.Int64
+
+// notificationSink collects the notifications of one connection and passes
+// each to the call's callback as it arrives.
+type notificationSink struct {
+	server  string
+	forward func(Notification)
+
+	mu   sync.Mutex
+	list []Notification
+}
+
+func (s *notificationSink) add(n Notification) {
+	n.At, n.Server = time.Now(), s.server
+	s.mu.Lock()
+	s.list = append(s.list, n)
+	s.mu.Unlock()
+	if s.forward != nil {
+		s.forward(n)
+	}
+}
+
+func (s *notificationSink) notifications() []Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Notification(nil), s.list...)
+}
+
+// options returns client options that route notifications into s.
+func (s *notificationSink) options() *mcp.ClientOptions {
+	return &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			p := req.Params
+			n := Notification{Kind: NotificationProgress, Progress: p.Progress, Total: p.Total, Message: p.Message}
+			if p.ProgressToken != nil {
+				n.Token = fmt.Sprint(p.ProgressToken)
+			}
+			s.add(n)
+		},
+		LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
+			p := req.Params
+			s.add(Notification{Kind: NotificationLog, Level: string(p.Level), Logger: p.Logger, Message: logData(p.Data)})
+		},
+		ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
+			s.add(Notification{Kind: NotificationListChanged, Message: "tools"})
+		},
+		PromptListChangedHandler: func(context.Context, *mcp.PromptListChangedRequest) {
+			s.add(Notification{Kind: NotificationListChanged, Message: "prompts"})
+		},
+		ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+			s.add(Notification{Kind: NotificationListChanged, Message: "resources"})
+		},
+	}
+}
+
+// setLogLevel subscribes cs to the server's log messages at level and above.
+func setLogLevel(ctx context.Context, cs *mcp.ClientSession, level string) error {
+	if init := cs.InitializeResult(); init == nil || init.Capabilities == nil || init.Capabilities.Logging == nil {
+		return ErrNoLogging
+	}
+	if err := cs.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: mcp.LoggingLevel(level)}); err != nil {
+		return fmt.Errorf("logging/setLevel %s: %w", level, err)
+	}
+	return nil
+}
+
+// logData renders the data of a log notification, which may be any JSON
+// value, as text.
+func logData(data any) string {
+	if s, ok := data.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprint(data)
+	}
+	return string(b)
+}
+
+// withProgressToken returns meta with a generated progressToken added, unless
+// it already has one, so the server knows to report progress.
+func withProgressToken(meta map[string]any) map[string]any {
+	if _, ok := meta["progressToken"]; ok {
+		return meta
+	}
+	out := maps.Clone(meta)
+	if out == nil {
+		out = make(map[string]any)
+	}
+	out["progressToken"] = fmt.Sprintf("integration-%d", progressTokens.Add(1))
+	return out
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgressNotifications(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	result, err := InvokeMCPTool(ToolCall{
+		Endpoints: []Endpoint{streamable},
+		ToolName:  "deploy",
+		ToolArgs:  map[string]any{},
+		OnNotification: func(n Notification) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, n.String())
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"progress 1/2: building", "progress 2/2: rolling out"}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(received) != fmt.Sprint(want) {
+		t.Errorf("callback received %q, want %q", received, want)
+	}
+	if len(result.Notifications) != 2 || result.Notifications[0].Kind != NotificationProgress || result.Notifications[0].At.IsZero() {
+		t.Errorf("Result.Notifications = %+v", result.Notifications)
+	}
+	if token := result.Notifications[0].Token; !strings.HasPrefix(token, "integration-") {
+		t.Errorf("progress notification carries token %q, want the generated one", token)
+	}
+}
+
+func TestNoProgressTokenWithoutCallback(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "deploy", ToolArgs: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Notifications) != 0 {
+		t.Errorf("server reported progress without a token: %+v", result.Notifications)
+	}
+}
+
+func TestWithProgressToken(t *testing.T) {
+	meta := map[string]any{"progressToken": "mine"}
+	if got := withProgressToken(meta); got["progressToken"] != "mine" {
+		t.Errorf("caller's token replaced: %v", got)
+	}
+	trace := map[string]any{"trace": "x"}
+	got := withProgressToken(trace)
+	if got["progressToken"] == nil || got["trace"] != "x" {
+		t.Errorf("withProgressToken = %v", got)
+	}
+	if _, ok := trace["progressToken"]; ok {
+		t.Error("caller's map was modified")
+	}
+}
+
+func TestNotificationString(t *testing.T) {
+	tests := map[string]Notification{
+		"progress 3":                  {Kind: NotificationProgress, Progress: 3},
+		"progress 1/4: uploading":     {Kind: NotificationProgress, Progress: 1, Total: 4, Message: "uploading"},
+		"log warning [gcloud]: quota": {Kind: NotificationLog, Level: "warning", Logger: "gcloud", Message: "quota"},
+	}
+	for want, n := range tests {
+		if got := n.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+	if got := logData(map[string]any{"a": 1}); got != `{"a":1}` {
+		t.Errorf("logData = %s", got)
+	}
+}
+
+func TestLogAndListChangedNotifications(t *testing.T) {
+	// The SSE transport delivers notifications unrelated to a request on the
+	// same stream as the result, so they arrive before the call returns.
+	sse, _ := serveHTTP(t)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{sse}, ToolName: "enable_extension", ToolArgs: map[string]any{}, LogLevel: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range result.Notifications {
+		got = append(got, n.String())
+	}
+	want := []string{"log info [extensions]: enabling beta tools", "tools/list_changed"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("notifications = %q, want %q", got, want)
+	}
+	if c := result.Capabilities; c == nil || c.Tools == nil || !c.Tools.ListChanged || c.Logging == nil {
+		t.Errorf("capabilities = %+v, want tools.listChanged and logging", c)
+	}
+}
+
+func TestNoLogsWithoutLogLevel(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{sse}, ToolName: "enable_extension", ToolArgs: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range result.Notifications {
+		if n.Kind == NotificationLog {
+			t.Errorf("got a log message without subscribing: %s", n)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// continuationHints are the keys tools return the cursor of their next page
+// under, in structuredContent or _meta, each with the argument the cursor
+// is passed back in.
+var continuationHints = []struct{ key, arg string }{
+	{"nextPageToken", "pageToken"},
+	{"next_page_token", "page_token"},
+	{"nextCursor", "cursor"},
+}
+
+// NextPage returns the continuation hint of a tool result: the cursor of its
+// next page and the argument to pass it in, or "" if it is the last page.
+func NextPage(result *mcp.CallToolResult) (cursor, arg string) {
+	structured, _ := asMap(result.StructuredContent)
+	for _, fields := range []map[string]any{structured, result.Meta} {
+		for _, h := range continuationHints {
+			if s, ok := fields[h.key].(string); ok && s != "" {
+				return s, h.arg
+			}
+		}
+	}
+	return "", ""
+}
+
+// Paging makes InvokeMCPTool follow a tool's continuation hints, calling the
+// tool again with each cursor NextPage finds and merging the pages.
+type Paging struct {
+	// MaxPages bounds the pages fetched; a result with more is marked
+	// truncated. Zero allows 10.
+	MaxPages int
+}
+
+// fetchPages calls the tool for each page after first, as p allows, and
+// returns the merged result, whose structuredContent and _meta are those of
+// the last page fetched, and the number of pages. Each page is throttled
+// by the call's RateLimit like the first.
+func fetchPages(ctx context.Context, cs *mcp.ClientSession, toolCall ToolCall, meta map[string]any, first *mcp.CallToolResult, p *Paging, metrics *Metrics) (*mcp.CallToolResult, int, error) {
+	merged, pages := first, 1
+	cursor, arg := NextPage(first)
+	limit := p.MaxPages
+	if limit == 0 {
+		limit = 10
+	}
+	for cursor != "" && pages < limit && !merged.IsError {
+		args, err := asMap(toolCall.ToolArgs)
+		if err != nil {
+			return nil, pages, fmt.Errorf("cannot pass a page cursor in arguments %T: %w", toolCall.ToolArgs, err)
+		}
+		args = maps.Clone(args)
+		if args == nil {
+			args = map[string]any{}
+		}
+		args[arg] = cursor
+		next, err := callTool(ctx, cs, toolCall.RateLimit, &mcp.CallToolParams{Meta: mcp.Meta(meta), Name: toolCall.ToolName, Arguments: args}, nil, metrics)
+		if err != nil {
+			return nil, pages, fmt.Errorf("fetching page %d: %w", pages+1, err)
+		}
+		pages++
+		merged = &mcp.CallToolResult{
+			Meta:              next.Meta,
+			Content:           append(merged.Content, next.Content...),
+			StructuredContent: next.StructuredContent,
+			IsError:           next.IsError,
+		}
+		cursor, arg = NextPage(next)
+	}
+	return merged, pages, nil
+}
+
+// asMap converts a JSON object, e.g. tool arguments or structured content,
+// to a map.
+func asMap(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if m, ok := v.(map[string]any); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Text returns the text of every text content block of the result, in
+// order, joined by newlines.
+func (r *Result) Text() string {
+	var texts []string
+	for _, c := range r.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			texts = append(texts, t.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type listLogsArgs struct {
+	Filter    string `json:"filter"`
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+func TestNextPage(t *testing.T) {
+	for _, tc := range []struct {
+		result      *mcp.CallToolResult
+		cursor, arg string
+	}{
+		{&mcp.CallToolResult{StructuredContent: map[string]any{"nextPageToken": "a"}}, "a", "pageToken"},
+		{&mcp.CallToolResult{StructuredContent: struct {
+			Next string `json:"next_page_token"`
+		}{"b"}}, "b", "page_token"},
+		{&mcp.CallToolResult{Meta: mcp.Meta{"nextCursor": "c"}}, "c", "cursor"},
+		{&mcp.CallToolResult{StructuredContent: map[string]any{"nextPageToken": ""}}, "", ""},
+		{&mcp.CallToolResult{}, "", ""},
+	} {
+		if cursor, arg := NextPage(tc.result); cursor != tc.cursor || arg != tc.arg {
+			t.Errorf("NextPage(%+v) = %q, %q, want %q, %q", tc.result, cursor, arg, tc.cursor, tc.arg)
+		}
+	}
+}
+
+func TestPaging(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	args := map[string]any{"filter": "severity>=ERROR"}
+	call := ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "list_logs", ToolArgs: args}
+
+	first, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Pages != 1 || first.Continuation != "p2" || first.Text() != "entry 1\nentry 2" {
+		t.Errorf("first page: %d pages, continuation %q, text %q", first.Pages, first.Continuation, first.Text())
+	}
+
+	call.Paging = &Paging{}
+	all, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Pages != 3 || all.Continuation != "" || len(all.Content) != 6 || all.Text() != "entry 1\nentry 2\nentry 3\nentry 4\nentry 5\nentry 6" {
+		t.Errorf("all pages: %d pages, continuation %q, text %q", all.Pages, all.Continuation, all.Text())
+	}
+	if _, ok := args["pageToken"]; ok {
+		t.Error("paging changed the caller's arguments")
+	}
+
+	call.Paging = &Paging{MaxPages: 2}
+	some, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if some.Pages != 2 || some.Continuation != "p3" || len(some.Content) != 4 {
+		t.Errorf("two pages: %d pages, continuation %q, %d blocks", some.Pages, some.Continuation, len(some.Content))
+	}
+}
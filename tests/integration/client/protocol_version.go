@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ProtocolVersions are the MCP protocol versions the client can request,
+// newest first: those the SDK speaks.
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// ErrProtocolVersion is returned when a server answers a pinned
+// ToolCall.ProtocolVersion with another version.
+var ErrProtocolVersion = errors.New("server did not accept the requested protocol version")
+
+// ValidProtocolVersion reports a version the client cannot request.
+func ValidProtocolVersion(version string) error {
+	if !slices.Contains(ProtocolVersions, version) {
+		return fmt.Errorf("unsupported protocol version %q; want one of %v", version, ProtocolVersions)
+	}
+	return nil
+}
+
+// versionTransport requests version in the initialize handshake instead of
+// the SDK's latest.
+type versionTransport struct {
+	mcp.Transport
+	version string
+}
+
+func (t *versionTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &versionConn{Connection: conn, version: t.version}, nil
+}
+
+type versionConn struct {
+	mcp.Connection
+	version string
+}
+
+func (c *versionConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if req, ok := msg.(*jsonrpc.Request); ok && req.Method == "initialize" {
+		var params map[string]any
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return fmt.Errorf("failed to pin the protocol version: %w", err)
+		}
+		params["protocolVersion"] = c.version
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to pin the protocol version: %w", err)
+		}
+		pinned := *req
+		pinned.Params = data
+		msg = &pinned
+	}
+	return c.Connection.Write(ctx, msg)
+}
+
+// checkProtocolVersion fails a connection whose server answered a pinned
+// version with another, as a server dropping support for it does.
+func checkProtocolVersion(toolCall ToolCall, cs *mcp.ClientSession) error {
+	if toolCall.ProtocolVersion == "" {
+		return nil
+	}
+	init := cs.InitializeResult()
+	if init == nil || init.ProtocolVersion == toolCall.ProtocolVersion {
+		return nil
+	}
+	return fmt.Errorf("%w: asked for %s, %s answered with %s", ErrProtocolVersion, toolCall.ProtocolVersion, serverName(toolCall), init.ProtocolVersion)
+}
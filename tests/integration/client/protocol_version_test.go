@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeServer answers every request on one end of an in-memory transport with
+// an initialize result for protocol version answer, sending the version each
+// request asked for to requested.
+func fakeServer(t *testing.T, answer string, requested chan<- string) mcp.Transport {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	conn, err := serverTransport.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		for {
+			msg, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			req, ok := msg.(*jsonrpc.Request)
+			if !ok || !req.IsCall() {
+				continue
+			}
+			var params struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			}
+			json.Unmarshal(req.Params, &params)
+			requested <- params.ProtocolVersion
+			result := `{"protocolVersion": "` + answer + `", "capabilities": {}, "serverInfo": {"name": "old-server", "version": "1"}}`
+			conn.Write(ctx, &jsonrpc.Response{ID: req.ID, Result: json.RawMessage(result)})
+		}
+	}()
+	return clientTransport
+}
+
+func TestPinnedProtocolVersion(t *testing.T) {
+	call := ToolCall{ServerCmd: []string{"old-mcp"}, ProtocolVersion: "2024-11-05"}
+	for _, tt := range []struct {
+		answer  string
+		wantErr bool
+	}{
+		{"2024-11-05", false},
+		{"2025-06-18", true},
+	} {
+		requested := make(chan string, 1)
+		transport := &versionTransport{Transport: fakeServer(t, tt.answer, requested), version: call.ProtocolVersion}
+		cs, err := newClient(nil).Connect(context.Background(), transport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := <-requested; got != call.ProtocolVersion {
+			t.Errorf("initialize asked for %q, want %q", got, call.ProtocolVersion)
+		}
+		err = checkProtocolVersion(call, cs)
+		if tt.wantErr != errors.Is(err, ErrProtocolVersion) {
+			t.Errorf("server answering %s: checkProtocolVersion = %v, wantErr %v", tt.answer, err, tt.wantErr)
+		}
+		cs.Close()
+	}
+}
+
+func TestStdioCallProtocolVersion(t *testing.T) {
+	for _, version := range ProtocolVersions {
+		call := stdioCall(t, "")
+		call.ProtocolVersion = version
+		result, err := InvokeMCPTool(call)
+		if err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+		if result.ProtocolVersion != version {
+			t.Errorf("negotiated %s, want %s", result.ProtocolVersion, version)
+		}
+	}
+	if err := ValidProtocolVersion("2023-01-01"); err == nil {
+		t.Error("ValidProtocolVersion accepted an unknown version")
+	}
+}
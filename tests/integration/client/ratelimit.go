@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Defaults of a RateLimit's backoff.
+const (
+	DefaultQuotaRetries   = 3
+	DefaultInitialBackoff = time.Second
+	maxBackoff            = 30 * time.Second
+)
+
+// exhaustedQuota matches the errors of a call rejected for exceeding an API
+// quota or rate limit: gRPC's RESOURCE_EXHAUSTED, HTTP 429 and the messages
+// Google APIs return with them.
+var exhaustedQuota = regexp.MustCompile(`RESOURCE_EXHAUSTED|[Qq]uota exceeded|rateLimitExceeded|Too Many Requests|\b429\b`)
+
+// RateLimit throttles the tool calls to one server, so tests do not trip the
+// API quotas of the shared test project, and retries calls the server
+// rejected for exhausted quota. One RateLimit is shared by every call to its
+// server; it is safe for concurrent use, and a nil *RateLimit does nothing.
+type RateLimit struct {
+	// RequestsPerSecond spaces the calls' requests at least 1/RequestsPerSecond
+	// apart. Zero does not limit the rate.
+	RequestsPerSecond float64 `yaml:"requests_per_second,omitempty" json:"requests_per_second,omitempty"`
+	// MaxInFlight caps how many calls may wait for their result at once.
+	// Zero does not limit them.
+	MaxInFlight int `yaml:"max_in_flight,omitempty" json:"max_in_flight,omitempty"`
+	// QuotaRetries is how many times a call rejected with RESOURCE_EXHAUSTED
+	// or HTTP 429 is retried, waiting InitialBackoff and then twice as long
+	// each time, up to 30s. Zero uses DefaultQuotaRetries; negative never
+	// retries.
+	QuotaRetries int `yaml:"quota_retries,omitempty" json:"quota_retries,omitempty"`
+	// InitialBackoff is the wait before the first retry. Zero uses
+	// DefaultInitialBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty" json:"initial_backoff,omitempty"`
+
+	// sleep waits for d unless ctx ends first; nil sleeps with a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	next     time.Time
+	inFlight chan struct{}
+}
+
+// Validate reports settings that cannot be honored.
+func (r *RateLimit) Validate() error {
+	if r.RequestsPerSecond < 0 || r.MaxInFlight < 0 || r.InitialBackoff < 0 {
+		return errors.New("rate limit settings must not be negative")
+	}
+	return nil
+}
+
+func (r *RateLimit) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	if r.sleep != nil {
+		return r.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire waits for a free in-flight slot and the call's turn under the
+// rate, returning how long it waited and the function releasing the slot.
+func (r *RateLimit) acquire(ctx context.Context) (release func(), waited time.Duration, err error) {
+	start := time.Now()
+	release = func() {}
+	r.mu.Lock()
+	if r.MaxInFlight > 0 && r.inFlight == nil {
+		r.inFlight = make(chan struct{}, r.MaxInFlight)
+	}
+	slots := r.inFlight
+	r.mu.Unlock()
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			release = func() { <-slots }
+		case <-ctx.Done():
+			return nil, time.Since(start), ctx.Err()
+		}
+	}
+	if r.RequestsPerSecond > 0 {
+		r.mu.Lock()
+		now := time.Now()
+		turn := r.next
+		if turn.Before(now) {
+			turn = now
+		}
+		r.next = turn.Add(time.Duration(float64(time.Second) / r.RequestsPerSecond))
+		r.mu.Unlock()
+		if err := r.wait(ctx, turn.Sub(now)); err != nil {
+			release()
+			return nil, time.Since(start), err
+		}
+	}
+	return release, time.Since(start), nil
+}
+
+// exhausted reports whether a tools/call outcome is a rejection for
+// exhausted quota.
+func exhausted(res *mcp.CallToolResult, err error) bool {
+	if err != nil {
+		return exhaustedQuota.MatchString(err.Error())
+	}
+	if res == nil || !res.IsError {
+		return false
+	}
+	for _, c := range res.Content {
+		if t, ok := c.(*mcp.TextContent); ok && exhaustedQuota.MatchString(t.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// callTool sends params in cs under limit, calling mark, if set, right
+// before each request goes out. It records in metrics how long the call
+// waited for the limit and backed off, and how often it was retried for
+// exhausted quota. The last outcome is returned once the retries run out.
+func callTool(ctx context.Context, cs *mcp.ClientSession, limit *RateLimit, params *mcp.CallToolParams, mark func(), metrics *Metrics) (*mcp.CallToolResult, error) {
+	send := func() (*mcp.CallToolResult, error) {
+		if mark != nil {
+			mark()
+		}
+		return cs.CallTool(ctx, params)
+	}
+	if limit == nil {
+		return send()
+	}
+	retries := limit.QuotaRetries
+	if retries == 0 {
+		retries = DefaultQuotaRetries
+	}
+	backoff := limit.InitialBackoff
+	if backoff == 0 {
+		backoff = DefaultInitialBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		release, waited, err := limit.acquire(ctx)
+		metrics.Throttled += waited
+		if err != nil {
+			return nil, fmt.Errorf("waiting for the rate limit: %w", err)
+		}
+		res, err := send()
+		release()
+		if attempt >= retries || !exhausted(res, err) {
+			return res, err
+		}
+		metrics.QuotaRetries++
+		metrics.Throttled += backoff
+		if err := limit.wait(ctx, backoff); err != nil {
+			return nil, fmt.Errorf("backing off from exhausted quota: %w", err)
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
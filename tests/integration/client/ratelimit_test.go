@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeSleep records the waits of limit instead of sleeping.
+func fakeSleep(limit *RateLimit) *[]time.Duration {
+	var (
+		mu    sync.Mutex
+		waits []time.Duration
+	)
+	limit.sleep = func(_ context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		waits = append(waits, d)
+		return nil
+	}
+	return &waits
+}
+
+func TestRateLimitSpacesRequests(t *testing.T) {
+	limit := &RateLimit{RequestsPerSecond: 10}
+	waits := fakeSleep(limit)
+	for range 3 {
+		release, _, err := limit.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// The first request goes out at once; the sleeps are faked, so each
+	// later one waits for a further 100ms slot.
+	if len(*waits) != 2 || (*waits)[0] <= 0 || (*waits)[1] <= (*waits)[0] || (*waits)[1] > 200*time.Millisecond {
+		t.Errorf("waits = %v, want two growing waits up to 200ms", *waits)
+	}
+}
+
+func TestRateLimitMaxInFlight(t *testing.T) {
+	limit := &RateLimit{MaxInFlight: 1}
+	release, _, err := limit.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := limit.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second acquire = %v, want it to wait for the first call", err)
+	}
+	release()
+	if release, _, err := limit.acquire(context.Background()); err != nil {
+		t.Errorf("acquire after release = %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	for _, tc := range []struct {
+		res  *mcp.CallToolResult
+		err  error
+		want bool
+	}{
+		{nil, errors.New("calling tool: RESOURCE_EXHAUSTED: too many requests"), true},
+		{nil, errors.New("googleapi: Error 429: rateLimitExceeded"), true},
+		{&mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "ERROR: Quota exceeded for quota metric"}}}, nil, true},
+		{&mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "Quota exceeded"}}}, nil, false},
+		{&mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "PERMISSION_DENIED"}}}, nil, false},
+		{nil, errors.New("instance 4290 not found"), false},
+	} {
+		if got := exhausted(tc.res, tc.err); got != tc.want {
+			t.Errorf("exhausted(%v, %v) = %t, want %t", tc.res, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRateLimitRetriesExhaustedQuota(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	limit := &RateLimit{InitialBackoff: time.Second}
+	waits := fakeSleep(limit)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "create_instance", RateLimit: limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text() != "created" || result.Metrics.QuotaRetries != 2 {
+		t.Errorf("result %q after %d retries, want created after 2", result.Text(), result.Metrics.QuotaRetries)
+	}
+	if len(*waits) != 2 || (*waits)[0] != time.Second || (*waits)[1] != 2*time.Second {
+		t.Errorf("backoffs = %v, want [1s 2s]", *waits)
+	}
+	if result.Metrics.Throttled < 3*time.Second {
+		t.Errorf("Throttled = %v, want at least the 3s of backoff", result.Metrics.Throttled)
+	}
+
+	// Once the retries run out, the rejection is the call's outcome.
+	_, streamable = serveHTTP(t)
+	limit = &RateLimit{QuotaRetries: -1}
+	fakeSleep(limit)
+	result, err = InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "create_instance", RateLimit: limit})
+	if err != nil || !result.IsError || result.Metrics.QuotaRetries != 0 {
+		t.Errorf("call without retries = %+v, %v, want the quota error", result, err)
+	}
+}
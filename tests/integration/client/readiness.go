@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of a Readiness's backoff.
+const (
+	DefaultReadinessBackoff = 250 * time.Millisecond
+	maxReadinessBackoff     = 5 * time.Second
+)
+
+// Readiness retries the initialize handshake of a server that is not ready
+// yet, such as one npx is still installing on a cold start, instead of
+// failing the call on its first connection error. Each retry launches the
+// server anew. Only the warm-up is retried: once the server has connected,
+// or the window has expired, in the run, later connection failures are not,
+// since a server that has been up fails to connect because it is broken
+// rather than still starting. It is separate from a RateLimit's quota
+// retries, which repeat tool calls on a server that is up; a nil *Readiness
+// never retries. It is safe for concurrent use.
+type Readiness struct {
+	// Window is how long after the first attempt handshakes are retried; the
+	// server is unreachable once it has passed.
+	Window time.Duration `yaml:"window" json:"window"`
+	// InitialBackoff is the wait before the first retry, doubling each time
+	// up to 5s. Zero uses DefaultReadinessBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty" json:"initial_backoff,omitempty"`
+
+	// sleep waits for d unless ctx ends first; nil sleeps with a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+	// warm is set once a handshake succeeded or the window expired, after
+	// which handshakes are no longer retried.
+	warm atomic.Bool
+}
+
+// Validate reports settings that cannot be honored.
+func (r *Readiness) Validate() error {
+	if r.Window <= 0 || r.InitialBackoff < 0 {
+		return errors.New("readiness needs a positive window and a backoff that is not negative")
+	}
+	return nil
+}
+
+// permanentError marks a connection failure retrying cannot fix, such as a
+// misconfigured endpoint or corrupt stdio framing.
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// dialReady calls dial until it connects, retrying failures while r's window
+// lasts unless the server has warmed up already, and returns the connection
+// with its startup measured from the first attempt, so a slow warm-up still
+// counts against a startup SLO.
+func (r *Readiness) dialReady(ctx context.Context, dial func() (*connection, error)) (*connection, error) {
+	start := time.Now()
+	backoff := DefaultReadinessBackoff
+	if r != nil && r.InitialBackoff > 0 {
+		backoff = r.InitialBackoff
+	}
+	for retries := 0; ; retries++ {
+		c, err := dial()
+		if err == nil {
+			if c.stdio != nil && retries > 0 {
+				c.startup = time.Since(start)
+			}
+			c.readinessRetries = retries
+			if r != nil {
+				r.warm.Store(true)
+			}
+			return c, nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return nil, permanent.error
+		}
+		if r == nil || r.warm.Load() || ctx.Err() != nil || time.Since(start)+backoff > r.Window {
+			if r != nil && ctx.Err() == nil {
+				r.warm.Store(true)
+			}
+			if retries > 0 {
+				err = fmt.Errorf("not ready after %d attempts in %v: %w", retries+1, time.Since(start).Round(time.Millisecond), err)
+			}
+			return nil, err
+		}
+		if err := r.wait(ctx, backoff); err != nil {
+			return nil, fmt.Errorf("waiting for the server to be ready: %w", err)
+		}
+		backoff = min(2*backoff, maxReadinessBackoff)
+	}
+}
+
+func (r *Readiness) wait(ctx context.Context, d time.Duration) error {
+	if r.sleep != nil {
+		return r.sleep(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
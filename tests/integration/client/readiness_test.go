@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// coldStartCall is a stdio call whose first coldStarts launches exit before
+// the handshake. It returns the call and a func counting the launches.
+func coldStartCall(t *testing.T, coldStarts int) (ToolCall, func() int) {
+	path := filepath.Join(t.TempDir(), "launches")
+	t.Setenv(coldStartsEnv, path)
+	t.Setenv(coldStartsCountEnv, strconv.Itoa(coldStarts))
+	launches := func() int {
+		data, _ := os.ReadFile(path)
+		return len(data)
+	}
+	return stdioCall(t, ""), launches
+}
+
+func TestReadinessRetriesColdStarts(t *testing.T) {
+	call, launches := coldStartCall(t, 2)
+	call.Readiness = &Readiness{Window: 30 * time.Second, InitialBackoff: time.Millisecond}
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metrics.ReadinessRetries != 2 || launches() != 3 {
+		t.Errorf("ReadinessRetries = %d after %d launches, want 2 after 3", result.Metrics.ReadinessRetries, launches())
+	}
+	if result.Metrics.Startup <= 0 {
+		t.Errorf("Startup = %v", result.Metrics.Startup)
+	}
+}
+
+func TestReadinessSessionReportsRetriesOnFirstCall(t *testing.T) {
+	call, _ := coldStartCall(t, 1)
+	call.Readiness = &Readiness{Window: 30 * time.Second, InitialBackoff: time.Millisecond}
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i, want := range []int{1, 0} {
+		result, err := s.CallTool(call.ToolName, call.ToolArgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Metrics.ReadinessRetries != want {
+			t.Errorf("call %d: ReadinessRetries = %d, want %d", i+1, result.Metrics.ReadinessRetries, want)
+		}
+	}
+}
+
+func TestWithoutReadinessAColdStartFails(t *testing.T) {
+	call, launches := coldStartCall(t, 1)
+	if _, err := InvokeMCPTool(call); !errors.Is(err, ErrConnect) || launches() != 1 {
+		t.Errorf("InvokeMCPTool = %v after %d launches, want ErrConnect after 1", err, launches())
+	}
+}
+
+func TestReadinessWindowExpires(t *testing.T) {
+	var slept []time.Duration
+	r := &Readiness{Window: 100 * time.Millisecond, InitialBackoff: time.Millisecond, sleep: func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}}
+	attempts := 0
+	start := time.Now()
+	_, err := r.dialReady(context.Background(), func() (*connection, error) {
+		attempts++
+		if attempts == 4 {
+			// The backoffs are stubbed, so advance the clock the window
+			// is read from instead.
+			time.Sleep(time.Until(start.Add(r.Window)))
+		}
+		return nil, errors.New("connection closed")
+	})
+	if err == nil || !strings.Contains(err.Error(), "not ready after 4 attempts") || !strings.Contains(err.Error(), "connection closed") {
+		t.Errorf("dialReady = %v", err)
+	}
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if len(slept) != len(want) || slept[0] != want[0] || slept[1] != want[1] || slept[2] != want[2] {
+		t.Errorf("backoffs = %v, want %v", slept, want)
+	}
+}
+
+func TestReadinessRetriesOnlyTheWarmUp(t *testing.T) {
+	call, launches := coldStartCall(t, 1)
+	call.Readiness = &Readiness{Window: 30 * time.Second, InitialBackoff: time.Millisecond}
+	if _, err := InvokeMCPTool(call); err != nil {
+		t.Fatal(err)
+	}
+	// A server that was up once and then fails to connect has broken, so
+	// the failure is not retried.
+	attempts := 0
+	_, err := call.Readiness.dialReady(context.Background(), func() (*connection, error) {
+		attempts++
+		return nil, errors.New("connection closed")
+	})
+	if err == nil || attempts != 1 || launches() != 2 {
+		t.Errorf("dialReady after a warm-up = %v after %d attempts, want a failure after 1", err, attempts)
+	}
+
+	// Nor is a server that never came up within the window retried again.
+	r := &Readiness{Window: time.Millisecond, InitialBackoff: time.Millisecond}
+	failing := func() (*connection, error) { attempts++; return nil, errors.New("connection closed") }
+	r.dialReady(context.Background(), failing)
+	attempts = 0
+	if _, err := r.dialReady(context.Background(), failing); err == nil || attempts != 1 {
+		t.Errorf("dialReady after the window expired = %v after %d attempts, want a failure after 1", err, attempts)
+	}
+}
+
+func TestReadinessDoesNotRetryPermanentErrors(t *testing.T) {
+	r := &Readiness{Window: time.Minute, sleep: func(context.Context, time.Duration) error { return nil }}
+	attempts := 0
+	cause := errors.New("corrupt stdio framing")
+	_, err := r.dialReady(context.Background(), func() (*connection, error) {
+		attempts++
+		return nil, permanentError{cause}
+	})
+	if err != cause || attempts != 1 {
+		t.Errorf("dialReady = %v after %d attempts, want the cause after 1", err, attempts)
+	}
+}
+
+func TestReadinessValidate(t *testing.T) {
+	for _, r := range []*Readiness{{}, {Window: -time.Second}, {Window: time.Second, InitialBackoff: -1}} {
+		if err := r.Validate(); err == nil {
+			t.Errorf("%+v.Validate() succeeded", r)
+		}
+	}
+	if err := (&Readiness{Window: time.Second}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// DefaultMaxReconnects is how often a Session reconnects unless its
+// Reconnect sets Max.
+const DefaultMaxReconnects = 3
+
+// ErrDisconnected is returned by a Session call whose server connection
+// dropped, e.g. because the stdio server exited, and was not restored.
+var ErrDisconnected = errors.New("server connection dropped")
+
+// Reconnect restores a Session whose connection drops, e.g. because its
+// server crashed, instead of failing every later call in it. The session
+// connects anew to the server of its ToolCall, with its current roots and
+// log level; state the server kept in the old session is lost. A nil
+// *Reconnect never reconnects.
+type Reconnect struct {
+	// Max bounds the reconnections over the session's life, so a server
+	// that crashes on every call still fails. Zero uses
+	// DefaultMaxReconnects.
+	Max int
+	// RetryInFlight also repeats, once, a call that was in flight when the
+	// connection dropped. The server may have acted on it before dropping,
+	// so set it only for sessions whose calls are idempotent; without it the
+	// call fails with ErrDisconnected and the next one reconnects.
+	RetryInFlight bool
+}
+
+func (r *Reconnect) max() int {
+	if r.Max > 0 {
+		return r.Max
+	}
+	return DefaultMaxReconnects
+}
+
+// dropped reports whether err, returned by a request in the session, means
+// the connection is gone.
+func dropped(err error) bool {
+	return errors.Is(err, mcp.ErrConnectionClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// disconnected reports whether the session's connection is known to have
+// dropped: a call failed because of it, or its stdio server closed stdout.
+func (s *Session) disconnected() bool {
+	return s.drop != nil || s.conn.stdio != nil && s.conn.stdio.exited()
+}
+
+// dropCause describes why the session's connection dropped.
+func (s *Session) dropCause() string {
+	if s.drop != nil {
+		return s.drop.Error()
+	}
+	return "the server exited"
+}
+
+// dropError is the error of a call to tool made on the dropped connection,
+// with the last line of the stdio server's stderr, which usually says why it
+// exited.
+func (s *Session) dropError(tool string) error {
+	var err error
+	if s.drop != nil {
+		err = fmt.Errorf("%w: %s, calling %s: %w", ErrDisconnected, serverName(s.toolCall), tool, s.drop)
+	} else {
+		err = fmt.Errorf("%w: %s, calling %s: %s", ErrDisconnected, serverName(s.toolCall), tool, s.dropCause())
+	}
+	if s.conn.stdio != nil {
+		lines := strings.Split(strings.TrimSpace(s.conn.stdio.stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			err = fmt.Errorf("%w; stderr: %s", err, last)
+		}
+	}
+	return err
+}
+
+// restore reconnects a session whose connection dropped before a call to
+// tool and returns why it dropped, or "" if it had not. Without Reconnect,
+// or if reconnecting fails, it returns an error wrapping ErrDisconnected.
+func (s *Session) restore(ctx context.Context, tool string) (string, error) {
+	if !s.disconnected() {
+		return "", nil
+	}
+	if s.toolCall.Reconnect == nil {
+		return "", s.dropError(tool)
+	}
+	cause, dropErr := s.dropCause(), s.dropError(tool)
+	if err := s.reconnect(ctx); err != nil {
+		return "", fmt.Errorf("%w; reconnecting: %w", dropErr, err)
+	}
+	return cause, nil
+}
+
+// reconnect replaces the session's dropped connection with a new one. The
+// old server's late output goes to the last call made on it, as Close does.
+// Once connecting fails, the session stops trying.
+func (s *Session) reconnect(ctx context.Context) error {
+	if s.reconnects >= s.toolCall.Reconnect.max() {
+		return fmt.Errorf("gave up after %d reconnections", s.reconnects)
+	}
+	s.reconnects++
+	s.closeConn()
+	conn, err := connect(ctx, s.toolCall)
+	if err != nil {
+		s.reconnects = s.toolCall.Reconnect.max()
+		return err
+	}
+	if s.toolCall.LogLevel != "" {
+		if err := setLogLevel(ctx, conn.session, s.toolCall.LogLevel); err != nil {
+			conn.session.Close()
+			s.reconnects = s.toolCall.Reconnect.max()
+			return err
+		}
+	}
+	s.earlier = append(s.earlier, s.conn.notices.notifications()...)
+	s.conn, s.connClosed, s.pollution, s.drop = conn, false, 0, nil
+	return nil
+}
+
+// retryInFlight reports whether a call that failed with err should be made
+// again on a new connection.
+func (s *Session) retryInFlight(err error) bool {
+	r := s.toolCall.Reconnect
+	return errors.Is(err, ErrDisconnected) && s.drop != nil && r != nil && r.RetryInFlight && s.reconnects < r.max()
+}
+
+// KillServer kills a stdio server's process group, as a crash would, and
+// waits until its stdout has closed, so the session's next call finds the
+// connection dropped. It is for testing how a session recovers.
+func (s *Session) KillServer() error {
+	if s.conn.stdio == nil || s.conn.stdio.cmd.Process == nil {
+		return errors.New("the session has no stdio server to kill")
+	}
+	if err := subprocess.Default.Kill(s.conn.stdio.cmd); err != nil {
+		return err
+	}
+	select {
+	case <-s.conn.stdio.eof:
+		return nil
+	case <-time.After(defaultTerminateDuration):
+		return fmt.Errorf("%s kept its stdout open after being killed", serverName(s.toolCall))
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSessionReconnect(t *testing.T) {
+	call := stdioCall(t, "")
+	call.Reconnect = &Reconnect{Max: 1}
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	before := len(DefaultRecorder.Invocations())
+	if _, err := s.CallTool(call.ToolName, call.ToolArgs); err != nil {
+		t.Fatal(err)
+	}
+	first := s.ServerPID()
+	if err := s.KillServer(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.CallTool(call.ToolName, call.ToolArgs)
+	if err != nil {
+		t.Fatalf("call after the server was killed = %v, want it to reconnect", err)
+	}
+	if s.ServerPID() == first || result.Metrics.Reconnected == "" || result.Metrics.Startup <= 0 {
+		t.Errorf("call after the server was killed: PID %d (killed %d), Metrics %+v, want a new server's startup", s.ServerPID(), first, result.Metrics)
+	}
+	// The killed server's stderr stays with the last call made on it.
+	if invocations := DefaultRecorder.Invocations()[before:]; !strings.Contains(invocations[0].Stderr, "running gcloud version") {
+		t.Errorf("first call's Stderr = %q, want the killed server's", invocations[0].Stderr)
+	}
+
+	// Max bounds the reconnections.
+	if err := s.KillServer(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CallTool(call.ToolName, call.ToolArgs); !errors.Is(err, ErrDisconnected) || !strings.Contains(err.Error(), "gave up") {
+		t.Errorf("call after the second kill = %v, want ErrDisconnected after giving up", err)
+	}
+}
+
+func TestSessionWithoutReconnect(t *testing.T) {
+	call := stdioCall(t, "")
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.KillServer(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if _, err := s.CallTool(call.ToolName, call.ToolArgs); !errors.Is(err, ErrDisconnected) {
+			t.Errorf("call %d after the server was killed = %v, want ErrDisconnected", i+1, err)
+		}
+	}
+}
+
+func TestSessionCallInFlight(t *testing.T) {
+	for _, retry := range []bool{false, true} {
+		call := stdioCall(t, "")
+		call.Reconnect = &Reconnect{RetryInFlight: retry}
+		s, err := OpenSession(call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := len(DefaultRecorder.Invocations())
+		_, err = s.CallTool("crash", nil)
+		if !errors.Is(err, ErrDisconnected) || !strings.Contains(err.Error(), "panic: crashed mid-call") {
+			t.Errorf("RetryInFlight %v: crashing call = %v, want ErrDisconnected with the server's stderr", retry, err)
+		}
+		// A retried call is made, and recorded, once more.
+		want := 1
+		if retry {
+			want = 2
+		}
+		if got := len(DefaultRecorder.Invocations()) - before; got != want {
+			t.Errorf("RetryInFlight %v: recorded %d calls, want %d", retry, got, want)
+		}
+		if _, err := s.CallTool(call.ToolName, call.ToolArgs); err != nil {
+			t.Errorf("RetryInFlight %v: call after the crash = %v, want it to reconnect", retry, err)
+		}
+		s.Close()
+	}
+}
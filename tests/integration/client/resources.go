@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ResourceLinks returns the resource_link content blocks of a tool result, in
+// order.
+func ResourceLinks(result *Result) ([]*mcp.ResourceLink, error) {
+	if result.Output == "" {
+		return nil, nil
+	}
+	var callResult mcp.CallToolResult
+	if err := json.Unmarshal([]byte(result.Output), &callResult); err != nil {
+		return nil, fmt.Errorf("failed to parse tool result: %w", err)
+	}
+	var links []*mcp.ResourceLink
+	for _, c := range callResult.Content {
+		if link, ok := c.(*mcp.ResourceLink); ok {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+// ReadResource reads a resource with resources/read in the session, e.g. to
+// follow a resource link a tool returned.
+func (s *Session) ReadResource(uri string) (*mcp.ReadResourceResult, error) {
+	res, err := s.conn.session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+	if framingErr := s.conn.framingError(); err != nil && framingErr != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, framingErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestSessionFollowsResourceLinks(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	result, err := s.CallTool("find_object", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, err := ResourceLinks(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].URI != "gs://test-bucket/notes.txt" || links[0].MIMEType != "text/plain" {
+		t.Fatalf("ResourceLinks = %+v, want the notes.txt link", links)
+	}
+	res, err := s.ReadResource(links[0].URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Contents) != 1 || res.Contents[0].Text != "remember the milk" {
+		t.Errorf("ReadResource = %+v", res.Contents)
+	}
+
+	if _, err := s.ReadResource("gs://test-bucket/missing.txt"); err == nil {
+		t.Error("reading a missing resource succeeded")
+	}
+}
+
+func TestResourceLinksWithoutLinks(t *testing.T) {
+	links, err := ResourceLinks(&Result{Output: `{"content":[{"type":"text","text":"ok"}]}`})
+	if err != nil || len(links) != 0 {
+		t.Errorf("ResourceLinks = %+v, %v, want none", links, err)
+	}
+	if _, err := ResourceLinks(&Result{Output: "not json"}); err == nil {
+		t.Error("ResourceLinks accepted output that is not a tool result")
+	}
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ResultCache reuses the results of idempotent, read-only tool calls for a
+// while, so tests repeating the same call do not each start the server and
+// spend API quota on it. Entries are keyed by server, endpoints, tool,
+// arguments and _meta in canonical form, environment, working directory,
+// impersonated service account and log level. It is safe for concurrent use; a nil
+// *ResultCache caches nothing.
+type ResultCache struct {
+	// TTL is how long a result is reused.
+	TTL time.Duration
+	// Now returns the current time; nil uses time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResult
+	hits    int
+	misses  int
+}
+
+type cachedResult struct {
+	result *Result
+	stored time.Time
+}
+
+// NewResultCache returns a cache reusing results for ttl.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{TTL: ttl}
+}
+
+// Stats returns how many lookups the cache answered and how many it missed.
+func (c *ResultCache) Stats() (hits, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *ResultCache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// cacheable reports whether toolCall may be answered from c: it must be
+// marked Cacheable and expect no error, and c must be set.
+func (c *ResultCache) cacheable(toolCall ToolCall) bool {
+	return c != nil && c.TTL > 0 && toolCall.Cacheable && toolCall.ExpectError == nil
+}
+
+// cacheKey identifies the calls that return the same result. Arguments and
+// _meta are compared as JSON with sorted keys, so their order and Go types do
+// not matter. Calls over different endpoints differ, so -differential still
+// compares transports. The environment is compared without CLOUDSDK_CONFIG,
+// which names the fresh gcloud configuration of each test rather than
+// another configuration, and _meta without its progressToken, which is new
+// for every call.
+func cacheKey(toolCall ToolCall) (string, error) {
+	args, err := canonicalJSON(toolCall.ToolArgs)
+	if err != nil {
+		return "", err
+	}
+	meta := maps.Clone(toolCall.Meta)
+	delete(meta, "progressToken")
+	if len(meta) == 0 {
+		meta = nil
+	}
+	metaJSON, err := canonicalJSON(meta)
+	if err != nil {
+		return "", err
+	}
+	endpoints := make([]string, len(toolCall.Endpoints))
+	for i, e := range toolCall.Endpoints {
+		endpoints[i] = e.String()
+	}
+	env := slices.DeleteFunc(slices.Clone(toolCall.Env), func(kv string) bool {
+		return strings.HasPrefix(kv, "CLOUDSDK_CONFIG=")
+	})
+	return strings.Join([]string{
+		strings.Join(toolCall.ServerCmd, " "), strings.Join(endpoints, " "),
+		toolCall.ToolName, args, metaJSON, strings.Join(env, "\x01"), toolCall.Dir,
+		toolCall.ImpersonateServiceAccount, toolCall.LogLevel,
+	}, "\x00"), nil
+}
+
+// canonicalJSON returns v as JSON with the keys of its objects sorted.
+func canonicalJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", err
+	}
+	// Marshaling a map sorts its keys.
+	if data, err = json.Marshal(generic); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// get returns a copy of the fresh result cached under key, marked Cached, so
+// a test changing its result does not change the next test's.
+func (c *ResultCache) get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().Sub(e.stored) > c.TTL {
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	r := copyResult(e.result)
+	r.Cached = true
+	return r, true
+}
+
+// put caches result under key unless it is a tool error.
+func (c *ResultCache) put(key string, result *Result) {
+	if result.IsError {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedResult)
+	}
+	c.entries[key] = cachedResult{result: copyResult(result), stored: c.now()}
+}
+
+// copyResult returns a deep copy of r, sharing nothing a caller could change
+// with it.
+func copyResult(r *Result) *Result {
+	c := *r
+	c.Downgrades = slices.Clone(r.Downgrades)
+	c.Meta = copyJSON(r.Meta)
+	c.Structured = copyJSON(r.Structured)
+	c.Pollution = slices.Clone(r.Pollution)
+	c.Notifications = slices.Clone(r.Notifications)
+	if r.Capabilities != nil {
+		// Capabilities are compared, not changed, so a copy of the struct
+		// is enough.
+		capabilities := *r.Capabilities
+		c.Capabilities = &capabilities
+	}
+	if r.Content != nil {
+		c.Content = make([]mcp.Content, len(r.Content))
+		for i, content := range r.Content {
+			c.Content[i] = copyContent(content)
+		}
+	}
+	return &c
+}
+
+// copyJSON returns a deep copy of m, a decoded JSON object.
+func copyJSON(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = copyJSONValue(v)
+	}
+	return c
+}
+
+func copyJSONValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyJSON(v)
+	case []any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = copyJSONValue(e)
+		}
+		return c
+	}
+	return v
+}
+
+// copyContent returns a copy of a content block with its own data.
+func copyContent(content mcp.Content) mcp.Content {
+	switch content := content.(type) {
+	case *mcp.TextContent:
+		c := *content
+		c.Meta = copyJSON(content.Meta)
+		return &c
+	case *mcp.ImageContent:
+		c := *content
+		c.Meta = copyJSON(content.Meta)
+		c.Data = slices.Clone(content.Data)
+		return &c
+	case *mcp.AudioContent:
+		c := *content
+		c.Meta = copyJSON(content.Meta)
+		c.Data = slices.Clone(content.Data)
+		return &c
+	case *mcp.ResourceLink:
+		c := *content
+		c.Meta = copyJSON(content.Meta)
+		return &c
+	case *mcp.EmbeddedResource:
+		c := *content
+		c.Meta = copyJSON(content.Meta)
+		if content.Resource != nil {
+			resource := *content.Resource
+			resource.Meta = copyJSON(content.Resource.Meta)
+			resource.Blob = slices.Clone(content.Resource.Blob)
+			c.Resource = &resource
+		}
+		return &c
+	}
+	return content
+}
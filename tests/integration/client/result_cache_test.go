@@ -0,0 +1,98 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestResultCache(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	cache := &ResultCache{TTL: time.Minute, Now: func() time.Time { return now }}
+	call := ToolCall{
+		Endpoints: []Endpoint{streamable},
+		ToolName:  "run_gcloud_command",
+		ToolArgs:  map[string]any{"args": []any{"config", "list"}},
+		Cacheable: true,
+		Cache:     cache,
+	}
+	calls := func() int { return len(DefaultRecorder.Invocations()) }
+
+	before := calls()
+	first, err := InvokeMCPTool(call)
+	if err != nil || first.Cached {
+		t.Fatalf("first call = %+v, %v", first, err)
+	}
+	// The same arguments as different Go values hit the cache.
+	call.ToolArgs = gcloudArgs{Args: []string{"config", "list"}}
+	second, err := InvokeMCPTool(call)
+	if err != nil || !second.Cached || second.Text() != "ok" {
+		t.Errorf("second call = %+v, %v, want the cached result", second, err)
+	}
+	if n := calls() - before; n != 1 {
+		t.Errorf("%d calls were made, want 1", n)
+	}
+
+	for name, change := range map[string]func(*ToolCall){
+		"other arguments":    func(c *ToolCall) { c.ToolArgs = map[string]any{"args": []string{"config", "get-value", "project"}} },
+		"not cacheable":      func(c *ToolCall) { c.Cacheable = false },
+		"expecting an error": func(c *ToolCall) { c.ExpectError = &ExpectedError{} },
+		"impersonating":      func(c *ToolCall) { c.ImpersonateServiceAccount = "sa@p.iam.gserviceaccount.com" },
+		"another endpoint":   func(c *ToolCall) { c.Endpoints = []Endpoint{{Transport: TransportSSE, URL: c.Endpoints[0].URL}} },
+		"other environment":  func(c *ToolCall) { c.Env = []string{"CLOUDSDK_CORE_PROJECT=other"} },
+		"another directory":  func(c *ToolCall) { c.Dir = "/tmp" },
+		"other _meta":        func(c *ToolCall) { c.Meta = map[string]any{"trace": "x"} },
+	} {
+		changed := call
+		change(&changed)
+		if key, _ := cacheKey(changed); !cache.cacheable(changed) || key != mustKey(t, call) {
+			continue
+		}
+		t.Errorf("%s: the call would be answered from the cache", name)
+	}
+
+	// Each test's own gcloud configuration and each call's progress token
+	// do not make calls differ.
+	same := call
+	same.Env = []string{"CLOUDSDK_CONFIG=/tmp/other-test"}
+	same.Meta = map[string]any{"progressToken": "integration-7"}
+	if mustKey(t, same) != mustKey(t, call) {
+		t.Error("calls differing only in CLOUDSDK_CONFIG and progressToken have different keys")
+	}
+
+	// A test changing a cached result does not change the next one's.
+	second.Content[0].(*mcp.TextContent).Text = "changed"
+	if third, err := InvokeMCPTool(call); err != nil || third.Text() != "ok" {
+		t.Errorf("call after changing a cached result = %+v, %v, want the original result", third, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	before = calls()
+	if third, err := InvokeMCPTool(call); err != nil || third.Cached || calls()-before != 1 {
+		t.Errorf("call after the TTL = %+v, %v, want a new call", third, err)
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses, want 2, 2", hits, misses)
+	}
+}
+
+func mustKey(t *testing.T, call ToolCall) string {
+	t.Helper()
+	key, err := cacheKey(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestNilResultCache(t *testing.T) {
+	var c *ResultCache
+	if c.cacheable(ToolCall{Cacheable: true}) {
+		t.Error("a nil cache caches")
+	}
+	if hits, misses := c.Stats(); hits+misses != 0 {
+		t.Errorf("Stats() = %d, %d", hits, misses)
+	}
+}
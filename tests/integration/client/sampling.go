@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ScriptedModel is the model name sampling results from a SamplingScript
+// report unless the rule names one.
+const ScriptedModel = "scripted"
+
+// SamplingRule answers the sampling requests whose prompt matches Pattern.
+type SamplingRule struct {
+	Pattern *regexp.Regexp
+	// Response is the text returned as the assistant message.
+	Response string
+	// Model is reported as the model that produced Response. Defaults to
+	// ScriptedModel.
+	Model string
+}
+
+// SampledMessage is a sampling request a SamplingScript answered or refused.
+type SampledMessage struct {
+	Prompt string
+	// Rule is the index of the matching rule, or -1 if none matched and the
+	// request was refused.
+	Rule     int
+	Response string
+}
+
+// SamplingScript answers a server's sampling/createMessage requests with
+// canned responses, so tools that sample can be tested deterministically.
+// The first rule whose pattern matches the prompt (the text of every message,
+// one per line) answers; a prompt matching no rule is refused with an error.
+// Setting it on a ToolCall makes the client advertise the sampling
+// capability. It is safe for concurrent use.
+type SamplingScript struct {
+	Rules []SamplingRule
+
+	mu      sync.Mutex
+	sampled []SampledMessage
+}
+
+// Sampled returns the requests the script has seen, in arrival order.
+func (s *SamplingScript) Sampled() []SampledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SampledMessage(nil), s.sampled...)
+}
+
+func (s *SamplingScript) createMessage(_ context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	prompt := samplingPrompt(req.Params)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, rule := range s.Rules {
+		if !rule.Pattern.MatchString(prompt) {
+			continue
+		}
+		s.sampled = append(s.sampled, SampledMessage{Prompt: prompt, Rule: i, Response: rule.Response})
+		model := rule.Model
+		if model == "" {
+			model = ScriptedModel
+		}
+		return &mcp.CreateMessageResult{
+			Content:    &mcp.TextContent{Text: rule.Response},
+			Model:      model,
+			Role:       "assistant",
+			StopReason: "endTurn",
+		}, nil
+	}
+	s.sampled = append(s.sampled, SampledMessage{Prompt: prompt, Rule: -1})
+	return nil, fmt.Errorf("no scripted sampling response matches prompt %q", prompt)
+}
+
+// samplingPrompt joins the text of the request's messages, one per line.
+func samplingPrompt(params *mcp.CreateMessageParams) string {
+	var lines []string
+	for _, m := range params.Messages {
+		if t, ok := m.Content.(*mcp.TextContent); ok {
+			lines = append(lines, t.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSamplingScript(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	script := &SamplingScript{Rules: []SamplingRule{
+		{Pattern: regexp.MustCompile(`(?i)deploy`), Response: "unused"},
+		{Pattern: regexp.MustCompile(`quota exceeded`), Response: "The project ran out of quota."},
+	}}
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "summarize_logs", ToolArgs: map[string]any{}, Sampling: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, "The project ran out of quota.") {
+		t.Errorf("tool result does not carry the scripted response:\n%s", result.Output)
+	}
+	sampled := script.Sampled()
+	if len(sampled) != 1 || sampled[0].Rule != 1 || sampled[0].Prompt != "Summarize: ERROR quota exceeded" {
+		t.Errorf("Sampled() = %+v", sampled)
+	}
+}
+
+func TestSamplingScriptRefusesUnmatchedPrompt(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	script := &SamplingScript{Rules: []SamplingRule{{Pattern: regexp.MustCompile(`^never$`), Response: "x"}}}
+	// The server's tool fails with the client's refusal.
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "summarize_logs", ToolArgs: map[string]any{}, Sampling: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(result.Output, "no scripted sampling response") {
+		t.Errorf("tool result = %s, want the refusal passed back through the server", result.Output)
+	}
+	if sampled := script.Sampled(); len(sampled) != 1 || sampled[0].Rule != -1 {
+		t.Errorf("Sampled() = %+v", sampled)
+	}
+}
+
+func TestNoSamplingWithoutScript(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "summarize_logs", ToolArgs: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Errorf("sampling succeeded without a script: %s", result.Output)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ErrInvalidArgs is returned when ToolArgs do not satisfy the input schema
+// the server advertises for the tool.
+var ErrInvalidArgs = errors.New("tool arguments do not match the server's input schema")
+
+// ErrInvalidOutput is returned when the structuredContent of a tool result
+// does not satisfy the output schema the server advertises for the tool.
+var ErrInvalidOutput = errors.New("tool result does not match the server's output schema")
+
+// listedTool looks up toolName via tools/list, returning nil if the server
+// does not list it.
+func listedTool(ctx context.Context, cs *mcp.ClientSession, toolName string) (*mcp.Tool, error) {
+	for t, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		if t.Name == toolName {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// validateArgs looks up toolName via tools/list and validates args against
+// its input schema.
+func validateArgs(ctx context.Context, cs *mcp.ClientSession, toolName string, args any) error {
+	tool, err := listedTool(ctx, cs, toolName)
+	if err != nil {
+		return err
+	}
+	if tool == nil {
+		return fmt.Errorf("%w: server does not list a tool named %q", ErrInvalidArgs, toolName)
+	}
+
+	resolved, err := resolveSchema(tool.InputSchema)
+	if err != nil {
+		return fmt.Errorf("failed to resolve input schema of %q: %w", toolName, err)
+	}
+	instance, err := toJSONValue(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	if instance == nil {
+		// A call without arguments is sent as an empty object.
+		instance = map[string]any{}
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArgs, toolName, err)
+	}
+	return nil
+}
+
+// validateOutput looks up toolName via tools/list and validates the
+// structuredContent of its result against its output schema. A tool that
+// declares one must return structuredContent unless the call failed; one
+// that does not may return any.
+func validateOutput(ctx context.Context, cs *mcp.ClientSession, toolName string, result *mcp.CallToolResult) error {
+	if result == nil || result.IsError {
+		return nil
+	}
+	tool, err := listedTool(ctx, cs, toolName)
+	if err != nil {
+		return err
+	}
+	if tool == nil {
+		return fmt.Errorf("%w: server does not list a tool named %q", ErrInvalidOutput, toolName)
+	}
+	if tool.OutputSchema == nil {
+		return nil
+	}
+	if result.StructuredContent == nil {
+		return fmt.Errorf("%w: %s declares an output schema but returned no structuredContent", ErrInvalidOutput, toolName)
+	}
+	resolved, err := resolveSchema(tool.OutputSchema)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output schema of %q: %w", toolName, err)
+	}
+	instance, err := toJSONValue(result.StructuredContent)
+	if err != nil {
+		return fmt.Errorf("failed to encode structuredContent: %w", err)
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidOutput, toolName, err)
+	}
+	return nil
+}
+
+// resolveSchema converts a schema as decoded from the wire into a resolved
+// jsonschema. The $schema keyword is dropped: servers built with zod emit
+// draft-07, whose keywords we validate with 2020-12 semantics.
+func resolveSchema(raw any) (*jsonschema.Resolved, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "$schema")
+	if data, err = json.Marshal(m); err != nil {
+		return nil, err
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return schema.Resolve(nil)
+}
+
+// toJSONValue round-trips v through JSON so it can be validated the same way
+// the server will see it.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type gcloudArgs struct {
+	Args []string `json:"args"`
+}
+
+// sumOutput is the structured result of the in-memory server's sum tool.
+type sumOutput struct {
+	Sum int `json:"sum"`
+}
+
+// connectInMemory starts a server with a run_gcloud_command-shaped tool and a
+// sum tool declaring an output schema, and returns a client session connected to it.
+func connectInMemory(t *testing.T) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "run_gcloud_command"}, func(context.Context, *mcp.CallToolRequest, gcloudArgs) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "sum"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, sumOutput, error) {
+		return nil, sumOutput{}, nil
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func TestValidateArgs(t *testing.T) {
+	cs := connectInMemory(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    any
+		wantErr bool
+	}{
+		{"valid", "run_gcloud_command", map[string]any{"args": []string{"config", "list"}}, false},
+		{"wrong type", "run_gcloud_command", map[string]any{"args": "config list"}, true},
+		{"unknown tool", "does_not_exist", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArgs(ctx, cs, tt.tool, tt.args)
+			if tt.wantErr != errors.Is(err, ErrInvalidArgs) {
+				t.Errorf("validateArgs() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	cs := connectInMemory(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		tool    string
+		result  *mcp.CallToolResult
+		wantErr bool
+	}{
+		{"valid", "sum", &mcp.CallToolResult{StructuredContent: map[string]any{"sum": 42}}, false},
+		{"wrong type", "sum", &mcp.CallToolResult{StructuredContent: map[string]any{"sum": "42"}}, true},
+		{"missing", "sum", &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "42"}}}, true},
+		{"failed call", "sum", &mcp.CallToolResult{IsError: true}, false},
+		{"no output schema", "run_gcloud_command", &mcp.CallToolResult{StructuredContent: map[string]any{"any": true}}, false},
+		{"unknown tool", "does_not_exist", &mcp.CallToolResult{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOutput(ctx, cs, tt.tool, tt.result)
+			if tt.wantErr != errors.Is(err, ErrInvalidOutput) {
+				t.Errorf("validateOutput() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplayDecodesStructuredContent(t *testing.T) {
+	result, err := Replay(ToolCall{ToolName: "sum"}, `{"content": [], "structuredContent": {"sum": 42}}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Structured["sum"]; got != float64(42) {
+		t.Errorf("Structured = %v, want sum 42", result.Structured)
+	}
+	var sum sumOutput
+	if err := result.DecodeStructured(&sum); err != nil || sum.Sum != 42 {
+		t.Errorf("DecodeStructured = %+v, %v, want sum 42", sum, err)
+	}
+	if err := (&Result{}).DecodeStructured(&sum); !errors.Is(err, ErrNoStructuredContent) {
+		t.Errorf("DecodeStructured without structuredContent = %v", err)
+	}
+}
+
+func TestResolveSchemaIgnoresDraft07(t *testing.T) {
+	raw := map[string]any{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"required":   []any{"args"},
+		"properties": map[string]any{"args": map[string]any{"type": "array"}},
+	}
+	resolved, err := resolveSchema(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resolved.Validate(map[string]any{}); err == nil {
+		t.Error("expected missing required property to fail validation")
+	}
+}
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/tokens"
+)
+
+// Session is an open connection to a server, for tests that make several
+// requests in one session, e.g. to change the roots between tool calls.
+type Session struct {
+	toolCall ToolCall
+	conn     *connection
+	// pollution is how many of the stdio server's non-protocol lines have
+	// been attributed to a call.
+	pollution int
+	// last is the DefaultRecorder index of the last call, or -1.
+	last int
+	// drop is the error that showed the connection had dropped, if a call
+	// failed because of it.
+	drop error
+	// connClosed reports whether conn has been closed.
+	connClosed bool
+	// reconnects counts the connections replaced.
+	reconnects int
+	// earlier holds the notifications of the replaced connections.
+	earlier []Notification
+}
+
+// OpenSession connects to the server of toolCall the way InvokeMCPTool does.
+// ToolName and ToolArgs are ignored; the other settings apply to every call
+// made in the session. The caller closes the session.
+func OpenSession(toolCall ToolCall) (*Session, error) {
+	if len(toolCall.ServerCmd) == 0 && len(toolCall.Endpoints) == 0 {
+		return nil, fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+	}
+	ctx := context.Background()
+	conn, err := connect(ctx, toolCall)
+	if err != nil {
+		return nil, err
+	}
+	if toolCall.LogLevel != "" {
+		if err := setLogLevel(ctx, conn.session, toolCall.LogLevel); err != nil {
+			conn.session.Close()
+			return nil, err
+		}
+	}
+	return &Session{toolCall: toolCall, conn: conn, last: -1}, nil
+}
+
+// CallTool calls a tool in the session, applying the session's ExpectError
+// and Meta. Like InvokeMCPTool, it records the call in DefaultRecorder with
+// the non-protocol stdout lines written since the previous call; Connect is
+// zero since the session was already open.
+func (s *Session) CallTool(name string, args any) (*Result, error) {
+	return s.CallToolContext(context.Background(), name, args)
+}
+
+// CallToolContext is CallTool, cancelling the call when ctx is done: the
+// client sends the server notifications/cancelled for the request and
+// returns an error wrapping ErrCancelled and ctx.Err() without waiting for a
+// response. Cancellations reports whether the server answered anyway.
+//
+// A call made once the connection has dropped, e.g. because the server
+// crashed, fails with an error wrapping ErrDisconnected, or first
+// reconnects if the session's ToolCall sets Reconnect.
+func (s *Session) CallToolContext(ctx context.Context, name string, args any) (*Result, error) {
+	out, err := s.callTool(ctx, name, args)
+	if s.retryInFlight(err) {
+		return s.callTool(ctx, name, args)
+	}
+	return out, err
+}
+
+// callTool makes and records a single call of CallToolContext.
+func (s *Session) callTool(ctx context.Context, name string, args any) (out *Result, err error) {
+	call := s.toolCall
+	call.ToolName, call.ToolArgs = name, args
+	if call.Guard != nil {
+		if err := call.Guard(call); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	metrics := Metrics{Server: serverName(call), Tool: name, Started: start}
+	reconnected, restoreErr := s.restore(ctx, name)
+	if s.last < 0 || reconnected != "" {
+		// The first call on a connection reports the server's startup.
+		metrics.Startup = s.conn.startup
+		metrics.ReadinessRetries = s.conn.readinessRetries
+	}
+	metrics.Reconnected = reconnected
+	before := len(s.conn.notices.notifications())
+	defer func() {
+		metrics.Total = time.Since(start)
+		metrics.Failed = err != nil
+		notifications := s.conn.notices.notifications()[before:]
+		pollution := s.newPollution()
+		var output string
+		if out != nil {
+			out.Metrics, out.Pollution, out.Notifications = metrics, pollution, notifications
+			output = out.Output + out.ErrorMessage
+		}
+		s.last = DefaultRecorder.record(Invocation{Call: call, Metrics: metrics, Pollution: pollution, Notifications: notifications, Output: output, Err: err})
+	}()
+	if restoreErr != nil {
+		return nil, restoreErr
+	}
+
+	if call.ValidateArgs {
+		if err := validateArgs(ctx, s.conn.session, name, args); err != nil {
+			return nil, err
+		}
+	}
+	meta := call.Meta
+	if call.OnNotification != nil {
+		meta = withProgressToken(meta)
+	}
+	callResult, err := callTool(ctx, s.conn.session, call.RateLimit, &mcp.CallToolParams{Meta: mcp.Meta(meta), Name: name, Arguments: args}, s.conn.timing.mark, &metrics)
+	metrics.FirstResponse = s.conn.timing.sinceMark()
+	if callResult != nil {
+		metrics.Output = tokens.Measure(callResult)
+	}
+	if framingErr := s.conn.framingError(); err != nil && framingErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrToolExecution, framingErr)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCancelled, name, ctx.Err())
+	}
+	if err != nil && dropped(err) {
+		s.drop = err
+		return nil, s.dropError(name)
+	}
+	result := &Result{Endpoint: s.conn.endpoint}
+	if init := s.conn.session.InitializeResult(); init != nil {
+		result.Capabilities, result.ProtocolVersion = init.Capabilities, init.ProtocolVersion
+	}
+	if err := evaluate(call, callResult, err, result); err != nil {
+		return nil, err
+	}
+	if call.ValidateOutput {
+		if err := validateOutput(ctx, s.conn.session, name, callResult); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// SetRoots replaces the session's roots, which notifies the server with
+// roots/list_changed: once for removing the old roots and once for adding
+// the new ones.
+func (s *Session) SetRoots(roots ...string) error {
+	next, err := fileRoots(roots)
+	if err != nil {
+		return err
+	}
+	var current []string
+	for _, r := range s.toolCall.Roots {
+		if uri, err := rootURI(r); err == nil {
+			current = append(current, uri)
+		}
+	}
+	s.conn.client.RemoveRoots(current...)
+	s.conn.client.AddRoots(next...)
+	s.toolCall.Roots = roots
+	return nil
+}
+
+// RootsListed returns how many times the server has listed the session's
+// roots on its current connection.
+func (s *Session) RootsListed() int {
+	return int(s.conn.rootsListed.Load())
+}
+
+// Notifications returns every notification the server sent in the session,
+// over every connection if it reconnected.
+func (s *Session) Notifications() []Notification {
+	return append(slices.Clone(s.earlier), s.conn.notices.notifications()...)
+}
+
+// Close ends the session and stops a stdio server. Non-protocol lines
+// written after the last call, including during shutdown, are added to that
+// call's Invocation with the server's stderr, or recorded as an Invocation of
+// their own if the session made no calls.
+func (s *Session) Close() error {
+	return s.closeConn()
+}
+
+// closeConn closes the session's connection the way Close describes, once.
+func (s *Session) closeConn() error {
+	if s.connClosed {
+		return nil
+	}
+	s.connClosed = true
+	s.conn.timing.closeUnanswered()
+	err := s.conn.session.Close()
+	pollution := s.newPollution()
+	var stderr string
+	if s.conn.stdio != nil {
+		stderr = s.conn.stdio.stderr.String()
+	}
+	switch {
+	case s.last >= 0:
+		DefaultRecorder.addShutdown(s.last, pollution, stderr)
+	case len(pollution) > 0:
+		DefaultRecorder.record(Invocation{Call: s.toolCall, Metrics: Metrics{Server: serverName(s.toolCall)}, Pollution: pollution, Stderr: stderr})
+	}
+	return err
+}
+
+// newPollution returns the stdio server's non-protocol lines not yet
+// attributed to a call.
+func (s *Session) newPollution() []Pollution {
+	if s.conn.stdio == nil {
+		return nil
+	}
+	all := s.conn.stdio.recordedPollution()
+	pollution := all[s.pollution:]
+	s.pollution = len(all)
+	return pollution
+}
+
+// fileRoots converts paths and file:// URIs to roots named after their last
+// path element.
+func fileRoots(roots []string) ([]*mcp.Root, error) {
+	out := make([]*mcp.Root, len(roots))
+	for i, r := range roots {
+		uri, err := rootURI(r)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = &mcp.Root{URI: uri, Name: filepath.Base(strings.TrimPrefix(uri, "file://"))}
+	}
+	return out, nil
+}
+
+// rootURI returns root as a file:// URI, making paths absolute.
+func rootURI(root string) (string, error) {
+	if strings.HasPrefix(root, "file://") {
+		return root, nil
+	}
+	if strings.Contains(root, "://") {
+		return "", fmt.Errorf("root %q is not a file:// URI", root)
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
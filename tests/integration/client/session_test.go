@@ -0,0 +1,175 @@
+package client
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionRootsListChanged(t *testing.T) {
+	// Over SSE, roots/list_changed reaches the server on the same stream as
+	// the tool calls.
+	sse, _ := serveHTTP(t)
+	first, second := t.TempDir(), t.TempDir()
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}, Roots: []string{first}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	result, err := s.CallTool("list_roots", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstURI, _ := rootURI(first)
+	if !strings.Contains(result.Output, "changes=0") || !strings.Contains(result.Output, filepath.Base(first)+" "+firstURI) {
+		t.Fatalf("roots before the change:\n%s", result.Output)
+	}
+
+	if err := s.SetRoots(second); err != nil {
+		t.Fatal(err)
+	}
+	secondURI, _ := rootURI(second)
+	// The server handles the notification asynchronously, so poll for it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result, err = s.CallTool("list_roots", map[string]any{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(result.Output, "changes=0") || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(result.Output, "changes=0") {
+		t.Errorf("server saw no roots/list_changed:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, firstURI) || !strings.Contains(result.Output, secondURI) {
+		t.Errorf("roots after the change:\n%s", result.Output)
+	}
+	if n := s.RootsListed(); n < 2 {
+		t.Errorf("RootsListed = %d, want one per list_roots call", n)
+	}
+}
+
+func TestRootURI(t *testing.T) {
+	if got, err := rootURI("file:///workspace"); err != nil || got != "file:///workspace" {
+		t.Errorf(`rootURI("file:///workspace") = %q, %v`, got, err)
+	}
+	if got, err := rootURI("/tmp/a b"); err != nil || got != "file:///tmp/a%20b" {
+		t.Errorf(`rootURI("/tmp/a b") = %q, %v`, got, err)
+	}
+	if _, err := rootURI("https://example.com"); err == nil {
+		t.Error("rootURI accepted an https URI")
+	}
+}
+
+func TestSessionRecordsPollution(t *testing.T) {
+	call := stdioCall(t, "Listening on stdio\n")
+	t.Setenv("CLIENT_TEST_TOOL_NOISE", "running gcloud version")
+	call.RecordStdoutPollution = true
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := len(DefaultRecorder.Invocations())
+	// Each call is charged with the lines written since the previous one.
+	for i, want := range []int{2, 1} {
+		result, err := s.CallTool(call.ToolName, call.ToolArgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Pollution) != want || result.Pollution[len(result.Pollution)-1].Content != "running gcloud version" {
+			t.Errorf("call %d: Pollution = %+v, want %d lines ending with the tool's output", i+1, result.Pollution, want)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	invocations := DefaultRecorder.Invocations()[before:]
+	if len(invocations) != 2 || len(invocations[0].Pollution) != 2 || len(invocations[1].Pollution) != 1 {
+		t.Errorf("recorded invocations = %+v", invocations)
+	}
+}
+
+func TestSessionTool(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if tool, err := s.Tool("deploy"); err != nil || tool.Name != "deploy" {
+		t.Errorf("Tool(deploy) = %v, %v", tool, err)
+	}
+	if _, err := s.Tool("missing"); err == nil {
+		t.Error("Tool(missing) succeeded")
+	}
+}
+
+func TestSessionTokenBudgetPerTool(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	budgets := map[string]int{"deploy": 1}
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}, TokenBudget: func(tool string) int { return budgets[tool] }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.CallTool("deploy", map[string]any{}); !errors.Is(err, ErrTokenBudget) {
+		t.Errorf("deploy = %v, want %v", err, ErrTokenBudget)
+	}
+	// The budget of one tool does not apply to another's calls.
+	if _, err := s.CallTool("run_gcloud_command", gcloudArgs{Args: []string{"version"}}); err != nil {
+		t.Errorf("run_gcloud_command = %v, want no budget", err)
+	}
+}
+
+func TestRecorderOutputs(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	before := len(DefaultRecorder.Invocations())
+	result, err := s.CallTool("deploy", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultRecorder.Invocations()[before].Output; got == "" || got != result.Output {
+		t.Errorf("recorded Output = %q, want the result's %q", got, result.Output)
+	}
+	DefaultRecorder.DropOutputs(before)
+	if got := DefaultRecorder.Invocations()[before].Output; got != "" {
+		t.Errorf("Output after DropOutputs = %q", got)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	refused := errors.New("refused")
+	guard := func(call ToolCall) error {
+		if call.ToolName == "deploy" {
+			return refused
+		}
+		return nil
+	}
+	s, err := OpenSession(ToolCall{Endpoints: []Endpoint{sse}, Guard: guard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	before := len(DefaultRecorder.Invocations())
+	if _, err := s.CallTool("deploy", map[string]any{}); err != refused {
+		t.Errorf("session call = %v, want the guard's refusal", err)
+	}
+	if _, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{sse}, ToolName: "deploy", ToolArgs: map[string]any{}, Guard: guard}); err != refused {
+		t.Errorf("InvokeMCPTool = %v, want the guard's refusal", err)
+	}
+	if n := len(DefaultRecorder.Invocations()) - before; n != 0 {
+		t.Errorf("recorded %d refused calls, want none", n)
+	}
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// ErrFraming is wrapped by errors reporting that a server's stdout is not a
+// stream of newline-delimited JSON-RPC messages, usually because the server
+// logs to stdout.
+var ErrFraming = errors.New("corrupt stdio framing")
+
+// defaultTerminateDuration matches the SDK's CommandTransport.
+const defaultTerminateDuration = 5 * time.Second
+
+// maxFrameExcerpt bounds the offending bytes kept in a FramingError.
+const maxFrameExcerpt = 200
+
+// FramingError points at the first bytes of a server's stdout that are not a
+// JSON-RPC message.
+type FramingError struct {
+	// Offset is the byte offset of the offending line in the stream.
+	Offset int64
+	// Line is the 1-based line number of the offending line.
+	Line int
+	// Frame holds the start of the offending line.
+	Frame []byte
+	Err   error
+}
+
+func (e *FramingError) Error() string {
+	return fmt.Sprintf("%v at byte %d (line %d) of the server's stdout: %v: %q", ErrFraming, e.Offset, e.Line, e.Err, e.Frame)
+}
+
+func (e *FramingError) Unwrap() []error { return []error{ErrFraming, e.Err} }
+
+// Pollution is a line of non-protocol output a stdio server wrote to stdout.
+type Pollution struct {
+	Offset int64 `json:"offset"`
+	Line   int   `json:"line"`
+	// Content holds the start of the line.
+	Content string `json:"content"`
+	// During names the last request the client had sent when the line
+	// arrived, e.g. "tools/call run_gcloud_command", or is empty if it came
+	// before the first.
+	During string `json:"during,omitempty"`
+}
+
+func (p Pollution) String() string {
+	during := "before the first request"
+	if p.During != "" {
+		during = "during " + p.During
+	}
+	return fmt.Sprintf("line %d (byte %d) %s: %q", p.Line, p.Offset, during, p.Content)
+}
+
+// stdioTransport launches a server and speaks newline-delimited JSON-RPC over
+// its stdin and stdout. Unlike mcp.CommandTransport it validates every line
+// itself, so corrupt output is reported with its position.
+type stdioTransport struct {
+	cmd       *exec.Cmd
+	terminate time.Duration
+	// noise is fed to the reader ahead of the server's own output.
+	noise []byte
+	// cleanup runs once the server has been shut down.
+	cleanup func()
+	// lenient skips non-protocol lines, recording them as pollution, instead
+	// of failing with a FramingError.
+	lenient bool
+
+	// stderr keeps the end of what the server wrote to stderr.
+	stderr tailBuffer
+	// eof is closed once the server's stdout has closed, as it does when the
+	// server exits.
+	eof chan struct{}
+
+	mu        sync.Mutex
+	framing   *FramingError
+	pollution []Pollution
+	// during describes the last request written.
+	during string
+}
+
+// maxStderr bounds the stderr kept of each server launch. The end is kept,
+// since that is where a server that failed says why.
+const maxStderr = 32 << 10
+
+// tailBuffer keeps the last maxStderr bytes written to it. It is safe for
+// concurrent use.
+type tailBuffer struct {
+	mu      sync.Mutex
+	buf     []byte
+	dropped int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - maxStderr; over > 0 {
+		b.buf = slices.Delete(b.buf, 0, over)
+		b.dropped += over
+	}
+	return len(p), nil
+}
+
+// String returns the bytes kept, noting how many were dropped before them.
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.dropped > 0 {
+		return fmt.Sprintf("[%d earlier bytes dropped]\n%s", b.dropped, b.buf)
+	}
+	return string(b.buf)
+}
+
+// recordedPollution returns the non-protocol lines skipped in lenient mode.
+func (t *stdioTransport) recordedPollution() []Pollution {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.pollution)
+}
+
+// framingError returns the first framing error seen on the stream, or nil.
+func (t *stdioTransport) framingError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.framing == nil {
+		return nil
+	}
+	return t.framing
+}
+
+// exited reports whether the server's stdout has closed.
+func (t *stdioTransport) exited() bool {
+	select {
+	case <-t.eof:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *stdioTransport) Connect(context.Context) (mcp.Connection, error) {
+	// Until the server has started, nothing else removes the credentials
+	// file, so every early return cleans up.
+	stdout, err := t.cmd.StdoutPipe()
+	if err != nil {
+		t.cleanup()
+		return nil, err
+	}
+	stdin, err := t.cmd.StdinPipe()
+	if err != nil {
+		t.cleanup()
+		return nil, err
+	}
+	if t.cmd.Stderr == nil {
+		t.cmd.Stderr = &t.stderr
+	}
+	if err := subprocess.Default.Start(t.cmd); err != nil {
+		t.cleanup()
+		return nil, err
+	}
+	t.eof = make(chan struct{})
+	c := &stdioConn{
+		t:        t,
+		stdin:    stdin,
+		incoming: make(chan readResult),
+		closed:   make(chan struct{}),
+	}
+	go c.readLoop(io.MultiReader(bytes.NewReader(t.noise), stdout))
+	return c, nil
+}
+
+type readResult struct {
+	msg jsonrpc.Message
+	err error
+}
+
+type stdioConn struct {
+	t     *stdioTransport
+	stdin io.WriteCloser
+
+	incoming  chan readResult
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	writeMu sync.Mutex
+}
+
+func (c *stdioConn) readLoop(r io.Reader) {
+	var (
+		br     = bufio.NewReader(r)
+		offset int64
+		line   int
+	)
+	for {
+		frame, err := br.ReadBytes('\n')
+		line++
+		start := offset
+		offset += int64(len(frame))
+
+		var res readResult
+		trimmed := bytes.TrimSpace(frame)
+		switch {
+		case err == io.EOF && len(trimmed) > 0:
+			res.err = c.fail(start, line, trimmed, errors.New("stream ended inside a message"))
+		case err != nil:
+			res.err = err
+			close(c.t.eof)
+		case len(trimmed) == 0:
+			continue
+		default:
+			msg, decodeErr := jsonrpc.DecodeMessage(trimmed)
+			if decodeErr != nil && c.t.lenient {
+				c.pollute(start, line, trimmed)
+				continue
+			}
+			if decodeErr != nil {
+				res.err = c.fail(start, line, trimmed, decodeErr)
+			} else {
+				res.msg = msg
+			}
+		}
+		select {
+		case c.incoming <- res:
+		case <-c.closed:
+			return
+		}
+		if res.err != nil {
+			return
+		}
+	}
+}
+
+func (c *stdioConn) fail(offset int64, line int, frame []byte, err error) error {
+	if len(frame) > maxFrameExcerpt {
+		frame = frame[:maxFrameExcerpt]
+	}
+	fe := &FramingError{Offset: offset, Line: line, Frame: bytes.Clone(frame), Err: err}
+	c.t.mu.Lock()
+	if c.t.framing == nil {
+		c.t.framing = fe
+	}
+	c.t.mu.Unlock()
+	return fe
+}
+
+func (c *stdioConn) pollute(offset int64, line int, content []byte) {
+	if len(content) > maxFrameExcerpt {
+		content = content[:maxFrameExcerpt]
+	}
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	c.t.pollution = append(c.t.pollution, Pollution{Offset: offset, Line: line, Content: string(content), During: c.t.during})
+}
+
+func (c *stdioConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-c.incoming:
+		return res.msg, res.err
+	case <-c.closed:
+		return nil, io.EOF
+	}
+}
+
+func (c *stdioConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	if req, ok := msg.(*jsonrpc.Request); ok && req.IsCall() {
+		c.t.mu.Lock()
+		c.t.during = describeRequest(req)
+		c.t.mu.Unlock()
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// Close shuts the server down the way the MCP spec asks of stdio clients:
+// close its stdin, then send SIGTERM and finally SIGKILL if it does not exit
+// within the terminate duration.
+func (c *stdioConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.closeErr = c.shutdown()
+		c.t.cleanup()
+	})
+	return c.closeErr
+}
+
+func (c *stdioConn) shutdown() error {
+	if err := c.stdin.Close(); err != nil {
+		return fmt.Errorf("closing stdin: %v", err)
+	}
+	td := c.t.terminate
+	if td <= 0 {
+		td = defaultTerminateDuration
+	}
+	done := make(chan error, 1)
+	go func() { done <- subprocess.Default.Wait(c.t.cmd) }()
+	wait := func() (error, bool) {
+		select {
+		case err := <-done:
+			return err, true
+		case <-time.After(td):
+			return nil, false
+		}
+	}
+	if err, ok := wait(); ok {
+		return err
+	}
+	if err := c.t.cmd.Process.Signal(syscall.SIGTERM); err == nil {
+		if err, ok := wait(); ok {
+			return err
+		}
+	}
+	if err := c.t.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	if err, ok := wait(); ok {
+		return err
+	}
+	return fmt.Errorf("unresponsive subprocess")
+}
+
+func (c *stdioConn) SessionID() string { return "" }
+
+// describeRequest names a request for Pollution.During, adding the tool name
+// to tools/call.
+func describeRequest(req *jsonrpc.Request) string {
+	if req.Method != "tools/call" {
+		return req.Method
+	}
+	var params struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(req.Params, &params) != nil || params.Name == "" {
+		return req.Method
+	}
+	return req.Method + " " + params.Name
+}
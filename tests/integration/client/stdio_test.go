@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+)
+
+// The test binary doubles as a stdio server when this variable is set.
+const stdioServerEnv = "CLIENT_TEST_STDIO_SERVER"
+
+func TestMain(m *testing.M) {
+	if banner, ok := os.LookupEnv(stdioServerEnv); ok {
+		serveStdio(banner)
+		return
+	}
+	// Most tests serve over SSE and streamable HTTP.
+	if err := features.Default.Parse(FeatureNetworkTransports, "client tests"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// coldStartsEnv names a file counting the server's launches; while it holds
+// fewer than coldStartsCountEnv, a launch exits before the handshake, as npx
+// can on a cold start.
+const (
+	coldStartsEnv      = "CLIENT_TEST_COLD_STARTS_FILE"
+	coldStartsCountEnv = "CLIENT_TEST_COLD_STARTS"
+)
+
+// serveStdio runs a run_gcloud_command-shaped server on stdin and stdout,
+// printing banner to stdout first as a misbehaving server would. The tool
+// answers with the credentials it would use, or its working directory if
+// CLIENT_TEST_TOOL_CWD is set.
+func serveStdio(banner string) {
+	if path := os.Getenv(coldStartsEnv); path != "" {
+		launches, _ := os.ReadFile(path)
+		os.WriteFile(path, append(launches, '.'), 0o644)
+		if want, _ := strconv.Atoi(os.Getenv(coldStartsCountEnv)); len(launches) < want {
+			fmt.Fprintln(os.Stderr, "npm ERR! network timeout")
+			os.Exit(1)
+		}
+	}
+	fmt.Print(banner)
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "run_gcloud_command"}, func(context.Context, *mcp.CallToolRequest, gcloudArgs) (*mcp.CallToolResult, any, error) {
+		if noise := os.Getenv("CLIENT_TEST_TOOL_NOISE"); noise != "" {
+			fmt.Println(noise)
+		}
+		fmt.Fprintln(os.Stderr, "running gcloud version")
+		text := "ok " + os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") + " " + os.Getenv("CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT")
+		if os.Getenv("CLIENT_TEST_TOOL_CWD") != "" {
+			wd, _ := os.Getwd()
+			text = "ok " + wd
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "crash"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		fmt.Fprintln(os.Stderr, "panic: crashed mid-call")
+		os.Exit(2)
+		return nil, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "hang"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		// Never answer, not even once cancelled, as a server that heeds
+		// notifications/cancelled does not.
+		select {}
+	})
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		os.Exit(1)
+	}
+}
+
+// stdioCall returns a call to the test binary acting as a server that prints
+// banner before its first message.
+func stdioCall(t *testing.T, banner string) ToolCall {
+	t.Helper()
+	t.Setenv(stdioServerEnv, banner)
+	return ToolCall{
+		ServerCmd: []string{os.Args[0]},
+		ToolName:  "run_gcloud_command",
+		ToolArgs:  gcloudArgs{Args: []string{"version"}},
+	}
+}
+
+func TestStdioCall(t *testing.T) {
+	result, err := InvokeMCPTool(stdioCall(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, `"ok `) {
+		t.Errorf("Output = %s", result.Output)
+	}
+	if m := result.Metrics; m.Startup <= 0 || m.Startup > m.Connect {
+		t.Errorf("Startup = %s, want a part of Connect %s", m.Startup, m.Connect)
+	}
+}
+
+func TestStdioFramingErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner string
+		noise  string
+		want   FramingError
+	}{
+		{
+			name:   "server banner",
+			banner: "gcloud-mcp starting\n",
+			want:   FramingError{Offset: 0, Line: 1, Frame: []byte("gcloud-mcp starting")},
+		},
+		{
+			name:  "injected log lines",
+			noise: "\nINFO ready\n",
+			want:  FramingError{Offset: 1, Line: 2, Frame: []byte("INFO ready")},
+		},
+		{
+			name:  "partial frame",
+			noise: `{"jsonrpc":"2.0","id":` + "\n",
+			want:  FramingError{Offset: 0, Line: 1, Frame: []byte(`{"jsonrpc":"2.0","id":`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := stdioCall(t, tt.banner)
+			call.StdoutNoise = []byte(tt.noise)
+			_, err := InvokeMCPTool(call)
+			if !errors.Is(err, ErrFraming) || !errors.Is(err, ErrConnect) {
+				t.Fatalf("InvokeMCPTool() = %v, want a framing error while connecting", err)
+			}
+			var fe *FramingError
+			if !errors.As(err, &fe) {
+				t.Fatalf("%v is not a *FramingError", err)
+			}
+			if fe.Offset != tt.want.Offset || fe.Line != tt.want.Line || string(fe.Frame) != string(tt.want.Frame) {
+				t.Errorf("FramingError = {Offset: %d, Line: %d, Frame: %q}, want {%d, %d, %q}",
+					fe.Offset, fe.Line, fe.Frame, tt.want.Offset, tt.want.Line, tt.want.Frame)
+			}
+		})
+	}
+}
+
+func TestStdioRecordsPollution(t *testing.T) {
+	call := stdioCall(t, "Listening on stdio\n")
+	t.Setenv("CLIENT_TEST_TOOL_NOISE", "running gcloud version")
+	call.RecordStdoutPollution = true
+
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pollution{
+		{Offset: 0, Line: 1, Content: "Listening on stdio"},
+		{Content: "running gcloud version", During: "tools/call run_gcloud_command"},
+	}
+	if len(result.Pollution) != len(want) {
+		t.Fatalf("Pollution = %+v, want %d lines", result.Pollution, len(want))
+	}
+	first, second := result.Pollution[0], result.Pollution[1]
+	// The banner races with the initialize request, so either During is right.
+	if first.Offset != 0 || first.Line != 1 || first.Content != want[0].Content || (first.During != "" && first.During != "initialize") {
+		t.Errorf("Pollution[0] = %+v, want %+v", first, want[0])
+	}
+	if second.Content != want[1].Content || second.During != want[1].During || second.Line < 3 {
+		t.Errorf("Pollution[1] = %+v, want %+v", second, want[1])
+	}
+	invocations := DefaultRecorder.Invocations()
+	if got := invocations[len(invocations)-1].Pollution; len(got) != 2 {
+		t.Errorf("recorded pollution = %+v", got)
+	}
+}
+
+func TestStdioCapturesStderr(t *testing.T) {
+	call := stdioCall(t, "")
+	if _, err := InvokeMCPTool(call); err != nil {
+		t.Fatal(err)
+	}
+	invocations := DefaultRecorder.Invocations()
+	if got := invocations[len(invocations)-1].Stderr; got != "running gcloud version\n" {
+		t.Errorf("recorded stderr = %q", got)
+	}
+
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := s.CallTool(call.ToolName, call.ToolArgs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+	invocations = DefaultRecorder.Invocations()
+	if got := invocations[len(invocations)-1].Stderr; got != strings.Repeat("running gcloud version\n", 2) {
+		t.Errorf("session's recorded stderr = %q, want both calls' on the last", got)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	var b tailBuffer
+	b.Write([]byte("head\n"))
+	b.Write([]byte(strings.Repeat("x", maxStderr-1) + "\n"))
+	if got := b.String(); !strings.HasPrefix(got, "[5 earlier bytes dropped]\nxx") || !strings.HasSuffix(got, "x\n") {
+		t.Errorf("String() = %.40q…, want the head dropped", got)
+	}
+}
+
+func TestSessionCloseAfterUnansweredCancel(t *testing.T) {
+	call := stdioCall(t, "")
+	call.TerminateDuration = 100 * time.Millisecond
+	s, err := OpenSession(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ServerPID() == 0 {
+		t.Error("ServerPID() = 0 for a stdio server")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.CallToolContext(ctx, "hang", struct{}{}); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("CallToolContext() = %v, want ErrCancelled", err)
+	}
+	// The SDK alone would wait for the answer forever.
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() hung on the unanswered cancelled call")
+	}
+	if c := s.Cancellations(); len(c) != 1 || !c[0].Answered.IsZero() {
+		t.Errorf("Cancellations() = %v, want the unanswered call", c)
+	}
+}
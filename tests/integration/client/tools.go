@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ListTools starts the server of toolCall, pages through tools/list and closes
+// the session. Only ServerCmd, Endpoints, Env and TerminateDuration are used.
+func ListTools(toolCall ToolCall) ([]*mcp.Tool, error) {
+	_, tools, err := DescribeServer(toolCall)
+	return tools, err
+}
+
+// DescribeServer is ListTools that also returns the name and version the
+// server reported during initialization.
+func DescribeServer(toolCall ToolCall) (*mcp.Implementation, []*mcp.Tool, error) {
+	if len(toolCall.ServerCmd) == 0 && len(toolCall.Endpoints) == 0 {
+		return nil, nil, fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+	}
+	ctx := context.Background()
+	conn, err := connect(ctx, toolCall)
+	if err != nil {
+		return nil, nil, err
+	}
+	cs := conn.session
+	defer cs.Close()
+
+	var tools []*mcp.Tool
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		tools = append(tools, tool)
+	}
+	var info *mcp.Implementation
+	if init := cs.InitializeResult(); init != nil {
+		info = init.ServerInfo
+	}
+	return info, tools, nil
+}
+
+// Tool returns the tool named name that the session's server lists, or an
+// error if it lists none.
+func (s *Session) Tool(name string) (*mcp.Tool, error) {
+	for tool, err := range s.conn.session.Tools(context.Background(), nil) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		if tool.Name == name {
+			return tool, nil
+		}
+	}
+	return nil, fmt.Errorf("the server lists no tool %s", name)
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/chaos"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+)
+
+// Transport kinds an Endpoint can use.
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+	// TransportHTTP is the streamable HTTP transport.
+	TransportHTTP = "http"
+)
+
+// Endpoint is one way of reaching a server.
+type Endpoint struct {
+	Transport string `json:"transport"`
+	// URL is the address of an sse or http endpoint. Stdio endpoints launch
+	// the call's ServerCmd.
+	URL string `json:"url,omitempty"`
+}
+
+func (e Endpoint) String() string {
+	if e.URL == "" {
+		return e.Transport
+	}
+	return e.Transport + " " + e.URL
+}
+
+// Flag returns e in the TRANSPORT[=URL] form ParseEndpoint reads.
+func (e Endpoint) Flag() string {
+	if e.URL == "" {
+		return e.Transport
+	}
+	return e.Transport + "=" + e.URL
+}
+
+// ParseEndpoint parses an endpoint written as TRANSPORT[=URL], e.g. stdio or
+// sse=http://localhost:8080/sse.
+func ParseEndpoint(s string) (Endpoint, error) {
+	transport, url, _ := strings.Cut(s, "=")
+	e := Endpoint{Transport: transport, URL: url}
+	switch {
+	case transport == TransportStdio && url != "":
+		return Endpoint{}, fmt.Errorf("endpoint %q: stdio takes no URL", s)
+	case (transport == TransportSSE || transport == TransportHTTP) && url == "":
+		return Endpoint{}, fmt.Errorf("endpoint %q: %s needs a URL", s, transport)
+	case transport != TransportStdio && transport != TransportSSE && transport != TransportHTTP:
+		return Endpoint{}, fmt.Errorf("endpoint %q: unknown transport %q", s, transport)
+	}
+	return e, nil
+}
+
+// Downgrade records that a preferred endpoint could not be connected to and
+// a later one was used instead.
+type Downgrade struct {
+	From Endpoint `json:"from"`
+	To   Endpoint `json:"to"`
+	Err  string   `json:"error"`
+}
+
+// FeatureNetworkTransports enables the SSE and streamable HTTP transports.
+// While it is off, connecting over them fails, so a server falls back to its
+// next endpoint.
+const FeatureNetworkTransports = "network-transports"
+
+func init() {
+	features.Register(features.Flag{
+		Name:        FeatureNetworkTransports,
+		Description: "connect to servers over their sse and http endpoints, not only stdio",
+	})
+}
+
+// endpoints returns the endpoints of toolCall in order of preference.
+func endpoints(toolCall ToolCall) []Endpoint {
+	if len(toolCall.Endpoints) == 0 {
+		return []Endpoint{{Transport: TransportStdio}}
+	}
+	return toolCall.Endpoints
+}
+
+// serverName identifies the server of toolCall in metrics.
+func serverName(toolCall ToolCall) string {
+	if len(toolCall.ServerCmd) > 0 {
+		return filepath.Base(toolCall.ServerCmd[0])
+	}
+	for _, e := range toolCall.Endpoints {
+		if e.URL != "" {
+			return e.URL
+		}
+	}
+	return ""
+}
+
+func transportFor(toolCall ToolCall, e Endpoint) (mcp.Transport, error) {
+	if toolCall.Dial != nil {
+		return toolCall.Dial(), nil
+	}
+	switch e.Transport {
+	case TransportStdio:
+		if len(toolCall.ServerCmd) == 0 {
+			return nil, fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+		}
+		return commandTransport(toolCall)
+	case TransportSSE, TransportHTTP:
+		if !features.Enabled(FeatureNetworkTransports) {
+			return nil, fmt.Errorf("the %s transport is experimental; enable feature %s to use it", e.Transport, FeatureNetworkTransports)
+		}
+		if e.Transport == TransportSSE {
+			return &mcp.SSEClientTransport{Endpoint: e.URL}, nil
+		}
+		return &mcp.StreamableClientTransport{Endpoint: e.URL}, nil
+	}
+	return nil, fmt.Errorf("unknown transport %q", e.Transport)
+}
+
+// connection is a session opened by connect.
+type connection struct {
+	client     *mcp.Client
+	session    *mcp.ClientSession
+	timing     *timingTransport
+	stdio      *stdioTransport
+	endpoint   Endpoint
+	downgrades []Downgrade
+	notices    *notificationSink
+	// startup is how long a stdio server took from being spawned to
+	// completing the handshake, from the first spawn if it was retried.
+	startup time.Duration
+	// readinessRetries counts the handshakes retried before the server was
+	// ready.
+	readinessRetries int
+	// rootsListed counts the server's roots/list requests.
+	rootsListed atomic.Int64
+}
+
+// framingError returns the framing error seen on a stdio connection, which
+// explains the otherwise opaque failures it causes in the SDK.
+func (c *connection) framingError() error {
+	if c.stdio == nil {
+		return nil
+	}
+	return c.stdio.framingError()
+}
+
+// dial opens a session over e. A failure retrying cannot fix is a
+// permanentError.
+func dial(ctx context.Context, toolCall ToolCall, e Endpoint, roots []*mcp.Root) (*connection, error) {
+	t, err := transportFor(toolCall, e)
+	if err != nil {
+		return nil, permanentError{err}
+	}
+	var transport mcp.Transport = t
+	if toolCall.WireTrace != nil {
+		transport = &wireTapTransport{Transport: transport, trace: toolCall.WireTrace, server: serverName(toolCall), endpoint: e}
+	}
+	if toolCall.ProtocolVersion != "" {
+		// Outside the tap, so the trace shows the version requested.
+		transport = &versionTransport{Transport: transport, version: toolCall.ProtocolVersion}
+	}
+	if toolCall.Chaos != nil {
+		// Outside the tap, so the trace shows what really crossed the wire.
+		transport = &chaos.Transport{Transport: transport, Policy: toolCall.Chaos, OnFault: toolCall.OnFault}
+	}
+	c := &connection{
+		timing:   &timingTransport{Transport: transport},
+		endpoint: e,
+		notices:  &notificationSink{server: serverName(toolCall), forward: toolCall.OnNotification},
+	}
+	c.stdio, _ = t.(*stdioTransport)
+	opts := c.notices.options()
+	if toolCall.Sampling != nil {
+		opts.CreateMessageHandler = toolCall.Sampling.createMessage
+	}
+	if toolCall.Elicitation != nil {
+		opts.ElicitationHandler = toolCall.Elicitation.elicit
+	}
+	c.client = newClient(opts)
+	c.client.AddRoots(roots...)
+	c.client.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "roots/list" {
+				c.rootsListed.Add(1)
+			}
+			return next(ctx, method, req)
+		}
+	})
+	// A stdio transport spawns the server on Connect.
+	spawned := time.Now()
+	c.session, err = c.client.Connect(ctx, c.timing, nil)
+	if c.stdio != nil {
+		c.startup = time.Since(spawned)
+	}
+	if err != nil {
+		if framingErr := c.framingError(); framingErr != nil {
+			return nil, permanentError{framingErr}
+		}
+		return nil, err
+	}
+	if err := checkProtocolVersion(toolCall, c.session); err != nil {
+		c.session.Close()
+		return nil, permanentError{err}
+	}
+	return c, nil
+}
+
+// connect tries the endpoints of toolCall in order and returns a session on
+// the first that connects, recording every fallback on the way. The error
+// wraps ErrConnect and the last endpoint's failure.
+func connect(ctx context.Context, toolCall ToolCall) (*connection, error) {
+	// Roots are checked before any transport, which may own a credentials
+	// file, is built.
+	roots, err := fileRoots(toolCall.Roots)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		candidates = endpoints(toolCall)
+		failed     []Downgrade
+		lastErr    error
+	)
+	for _, e := range candidates {
+		c, err := toolCall.Readiness.dialReady(ctx, func() (*connection, error) {
+			return dial(ctx, toolCall, e, roots)
+		})
+		if err != nil {
+			if len(candidates) > 1 {
+				err = fmt.Errorf("%s: %w", e, err)
+			}
+			failed, lastErr = append(failed, Downgrade{From: e, Err: err.Error()}), err
+			continue
+		}
+		for _, d := range failed {
+			d.To = e
+			c.downgrades = append(c.downgrades, d)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: %w", ErrConnect, lastErr)
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+)
+
+// serveHTTP serves a server with a run_gcloud_command-shaped tool over both
+// network transports and returns the sse and http endpoints.
+func serveHTTP(t *testing.T) (sse, streamable Endpoint) {
+	t.Helper()
+	var rootsChanged atomic.Int32
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "v0.0.1"}, &mcp.ServerOptions{
+		RootsListChangedHandler: func(context.Context, *mcp.RootsListChangedRequest) { rootsChanged.Add(1) },
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "run_gcloud_command"}, func(_ context.Context, req *mcp.CallToolRequest, _ gcloudArgs) (*mcp.CallToolResult, any, error) {
+		// Echo the request's _meta so callers can check it arrived.
+		return &mcp.CallToolResult{Meta: req.Params.Meta, Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "deploy"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		// Report progress the way a long-running deploy would, if asked to.
+		if token := req.Params.GetProgressToken(); token != nil {
+			for i, step := range []string{"building", "rolling out"} {
+				req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{ProgressToken: token, Progress: float64(i + 1), Total: 2, Message: step})
+			}
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "deployed"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "enable_extension"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		// Adding a tool notifies every session that the tool list changed.
+		req.Session.Log(ctx, &mcp.LoggingMessageParams{Level: "info", Logger: "extensions", Data: "enabling beta tools"})
+		req.Session.Log(ctx, &mcp.LoggingMessageParams{Level: "debug", Data: "below the subscribed level"})
+		server.AddTool(&mcp.Tool{Name: "beta_tool", InputSchema: &jsonschema.Schema{Type: "object"}}, func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{}, nil
+		})
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "enabled"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "summarize_logs"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		// Ask the client's model, as a server summarizing log entries would.
+		res, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			MaxTokens: 100,
+			Messages:  []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "Summarize: ERROR quota exceeded"}}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{res.Content}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "delete_bucket"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		// Confirm with the user first, as a server guarding a destructive
+		// command would.
+		res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+			Message: "Delete bucket gs://test-bucket? This cannot be undone.",
+			RequestedSchema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+				"reason": {Type: "string"},
+			}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		text := "bucket kept: " + res.Action
+		if res.Action == "accept" {
+			text = "bucket deleted"
+			if reason, ok := res.Content["reason"].(string); ok {
+				text += ": " + reason
+			}
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "list_roots"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		// Report the client's roots as a server scoping file access would see
+		// them, after any roots/list_changed notifications so far.
+		res, err := req.Session.ListRoots(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines := []string{fmt.Sprintf("changes=%d", rootsChanged.Load())}
+		for _, r := range res.Roots {
+			lines = append(lines, r.Name+" "+r.URI)
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.Join(lines, "\n")}}}, nil, nil
+	})
+	server.AddResource(&mcp.Resource{URI: "gs://test-bucket/notes.txt", Name: "notes.txt", MIMEType: "text/plain"}, func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: req.Params.URI, MIMEType: "text/plain", Text: "remember the milk"}}}, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "find_object"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		// Point at the object instead of inlining it, as a storage server
+		// might for large objects.
+		return &mcp.CallToolResult{Content: []mcp.Content{
+			&mcp.TextContent{Text: "found 1 object"},
+			&mcp.ResourceLink{URI: "gs://test-bucket/notes.txt", Name: "notes.txt", MIMEType: "text/plain"},
+		}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "list_logs"}, func(_ context.Context, _ *mcp.CallToolRequest, args listLogsArgs) (*mcp.CallToolResult, any, error) {
+		// Serve three pages of two entries, as a logging API would.
+		page := map[string]int{"": 1, "p2": 2, "p3": 3}[args.PageToken]
+		next := map[string]any{}
+		if page < 3 {
+			next["nextPageToken"] = fmt.Sprintf("p%d", page+1)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("entry %d", 2*page-1)},
+				&mcp.TextContent{Text: fmt.Sprintf("entry %d", 2*page)},
+			},
+			StructuredContent: next,
+		}, nil, nil
+	})
+	var quotaRejections atomic.Int32
+	mcp.AddTool(server, &mcp.Tool{Name: "create_instance"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		// Reject the first two calls, as Compute Engine does while the
+		// project's quota is exhausted.
+		if quotaRejections.Add(1) <= 2 {
+			return nil, nil, errors.New("RESOURCE_EXHAUSTED: Quota exceeded for quota metric 'Queries'")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "created"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "wait"}, func(ctx context.Context, _ *mcp.CallToolRequest, args waitArgs) (*mcp.CallToolResult, any, error) {
+		// Work until cancelled, or, like a server that ignores
+		// cancellation, finish the work and answer anyway.
+		if args.IgnoreCancel {
+			time.Sleep(200 * time.Millisecond)
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+		}
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	})
+	getServer := func(*http.Request) *mcp.Server { return server }
+	mux := http.NewServeMux()
+	mux.Handle("/sse", mcp.NewSSEHandler(getServer, nil))
+	mux.Handle("/mcp", mcp.NewStreamableHTTPHandler(getServer, nil))
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return Endpoint{Transport: TransportSSE, URL: ts.URL + "/sse"}, Endpoint{Transport: TransportHTTP, URL: ts.URL + "/mcp"}
+}
+
+func TestInvokeOverNetworkTransports(t *testing.T) {
+	sse, streamable := serveHTTP(t)
+	for _, e := range []Endpoint{sse, streamable} {
+		t.Run(e.Transport, func(t *testing.T) {
+			result, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{e}, ToolName: "run_gcloud_command", ToolArgs: gcloudArgs{Args: []string{"version"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Endpoint != e || len(result.Downgrades) != 0 {
+				t.Errorf("connected to %v with downgrades %v", result.Endpoint, result.Downgrades)
+			}
+			if result.Metrics.Startup != 0 {
+				t.Errorf("Startup = %s over the network, want 0", result.Metrics.Startup)
+			}
+		})
+	}
+}
+
+func TestFallbackRecordsDowngrade(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	preferred := Endpoint{Transport: TransportHTTP, URL: closed.URL + "/mcp"}
+
+	call := ToolCall{Endpoints: []Endpoint{preferred, streamable}, ToolName: "run_gcloud_command", ToolArgs: gcloudArgs{Args: []string{"version"}}}
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Endpoint != streamable {
+		t.Errorf("connected to %v, want %v", result.Endpoint, streamable)
+	}
+	if len(result.Downgrades) != 1 || result.Downgrades[0].From != preferred || result.Downgrades[0].To != streamable {
+		t.Errorf("Downgrades = %+v", result.Downgrades)
+	}
+	invocations := DefaultRecorder.Invocations()
+	if last := invocations[len(invocations)-1]; len(last.Downgrades) != 1 {
+		t.Errorf("recorded downgrades = %+v", last.Downgrades)
+	}
+
+	call.Endpoints = call.Endpoints[:1]
+	if _, err := InvokeMCPTool(call); !errors.Is(err, ErrConnect) {
+		t.Errorf("InvokeMCPTool() without fallback = %v, want ErrConnect", err)
+	}
+}
+
+func TestMetaPropagation(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	meta := map[string]any{"progressToken": "call-1", "io.example/traceId": "4bf92f3577b34da6"}
+	result, err := InvokeMCPTool(ToolCall{
+		Endpoints: []Endpoint{streamable},
+		ToolName:  "run_gcloud_command",
+		ToolArgs:  gcloudArgs{Args: []string{"version"}},
+		Meta:      meta,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range meta {
+		if got := result.Meta[k]; got != want {
+			t.Errorf("echoed _meta[%q] = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestNetworkTransportsFeature(t *testing.T) {
+	sse, _ := serveHTTP(t)
+	if err := features.Default.Parse("-"+FeatureNetworkTransports, t.Name()); err != nil {
+		t.Fatal(err)
+	}
+	defer features.Default.Parse(FeatureNetworkTransports, "client tests")
+
+	// Without the feature the server falls back to stdio.
+	call := stdioCall(t, "")
+	call.Endpoints = []Endpoint{sse, {Transport: TransportStdio}}
+	result, err := InvokeMCPTool(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Endpoint.Transport != TransportStdio {
+		t.Errorf("call used %s, want the stdio fallback", result.Endpoint)
+	}
+	if _, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{sse}, ToolName: "run_gcloud_command", ToolArgs: map[string]any{}}); err == nil ||
+		!strings.Contains(err.Error(), "enable feature "+FeatureNetworkTransports) {
+		t.Errorf("SSE-only call with the feature off = %v, want an error naming the feature", err)
+	}
+}
+
+func TestDial(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "run_gcloud_command"}, func(context.Context, *mcp.CallToolRequest, gcloudArgs) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "in memory"}}}, nil, nil
+	})
+	dial := func() mcp.Transport {
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		if _, err := server.Connect(context.Background(), serverTransport, nil); err != nil {
+			t.Fatal(err)
+		}
+		return clientTransport
+	}
+	// The endpoints are not tried: no server listens there.
+	result, err := InvokeMCPTool(ToolCall{
+		Endpoints: []Endpoint{{Transport: TransportHTTP, URL: "http://127.0.0.1:1/mcp"}},
+		Dial:      dial,
+		ToolName:  "run_gcloud_command",
+		ToolArgs:  gcloudArgs{Args: []string{"version"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Text(); got != "in memory" {
+		t.Errorf("result = %q, want the in-memory server's", got)
+	}
+}
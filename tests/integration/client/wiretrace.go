@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Directions of a WireRecord.
+const (
+	WireSent     = "sent"
+	WireReceived = "received"
+)
+
+// WireRecord is one JSON-RPC message of a session, as written to a
+// WireTrace.
+type WireRecord struct {
+	At        time.Time `json:"at"`
+	Server    string    `json:"server"`
+	Direction string    `json:"direction"`
+	// Endpoint is the endpoint the message went over, e.g. stdio.
+	Endpoint string          `json:"endpoint"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// WireTrace writes every message of the sessions it is set on to a writer,
+// one WireRecord per line. It is safe for concurrent sessions.
+type WireTrace struct {
+	// Redact, if set, rewrites each message before it is written, e.g. to
+	// mask secrets.
+	Redact func([]byte) []byte
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewWireTrace returns a trace writing to w.
+func NewWireTrace(w io.Writer) *WireTrace {
+	return &WireTrace{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the trace, which does not fail the
+// sessions.
+func (t *WireTrace) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *WireTrace) record(server string, e Endpoint, direction string, msg jsonrpc.Message) {
+	data, err := jsonrpc.EncodeMessage(msg)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err == nil && t.Redact != nil {
+		data = t.Redact(data)
+	}
+	if err == nil {
+		err = t.enc.Encode(WireRecord{At: time.Now(), Server: server, Direction: direction, Endpoint: e.String(), Message: data})
+	}
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+}
+
+// wireTapTransport records the messages of its connections on a WireTrace.
+type wireTapTransport struct {
+	mcp.Transport
+	trace    *WireTrace
+	server   string
+	endpoint Endpoint
+}
+
+func (t *wireTapTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wireTapConn{Connection: conn, t: t}, nil
+}
+
+type wireTapConn struct {
+	mcp.Connection
+	t *wireTapTransport
+}
+
+func (c *wireTapConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	msg, err := c.Connection.Read(ctx)
+	if err == nil {
+		c.t.trace.record(c.t.server, c.t.endpoint, WireReceived, msg)
+	}
+	return msg, err
+}
+
+func (c *wireTapConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	err := c.Connection.Write(ctx, msg)
+	if err == nil {
+		c.t.trace.record(c.t.server, c.t.endpoint, WireSent, msg)
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWireTrace(t *testing.T) {
+	_, streamable := serveHTTP(t)
+	var out strings.Builder
+	trace := NewWireTrace(&out)
+	if _, err := InvokeMCPTool(ToolCall{Endpoints: []Endpoint{streamable}, ToolName: "deploy", ToolArgs: map[string]any{}, WireTrace: trace}); err != nil {
+		t.Fatal(err)
+	}
+	if err := trace.Err(); err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	lines := bufio.NewScanner(strings.NewReader(out.String()))
+	for lines.Scan() {
+		var r WireRecord
+		if err := json.Unmarshal(lines.Bytes(), &r); err != nil {
+			t.Fatalf("trace line %q: %v", lines.Text(), err)
+		}
+		if r.At.IsZero() || r.Endpoint != streamable.String() || r.Server != streamable.URL {
+			t.Errorf("record = %+v", r)
+		}
+		var msg struct {
+			Method string `json:"method"`
+		}
+		json.Unmarshal(r.Message, &msg)
+		if msg.Method != "" {
+			methods = append(methods, r.Direction+" "+msg.Method)
+		}
+	}
+	for _, want := range []string{"sent initialize", "sent notifications/initialized", "sent tools/call"} {
+		if !strings.Contains(strings.Join(methods, "\n"), want) {
+			t.Errorf("trace lacks %q; has %q", want, methods)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// runCompare implements `compare -tool NAME [-args JSON] -a "CMD" -b "CMD"`,
+// making the same tool call against two server commands, e.g. a released
+// and a locally built server, and printing the diff of their normalized
+// results and their latencies. Server release validation uses it.
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	tool := fs.String("tool", "", "name of the tool to call")
+	toolArgs := fs.String("args", "{}", "tool arguments as a JSON object")
+	aCmd := fs.String("a", "", "command starting the first server, split on spaces, e.g. the released build")
+	bCmd := fs.String("b", "", "command starting the second server, split on spaces, e.g. the local build")
+	repeat := fs.Int("repeat", 1, "call each server this many times, alternating between them, for steadier latencies")
+	volatileFields := fs.String("volatile-fields", "", "comma-separated result fields to ignore in addition to the defaults")
+	var env stringList
+	fs.Var(&env, "env", "KEY=VALUE added to both servers' environment (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	a, b := strings.Fields(*aCmd), strings.Fields(*bCmd)
+	if *tool == "" || len(a) == 0 || len(b) == 0 || *repeat < 1 || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, `usage: integration-test compare -tool NAME [-args JSON] [-repeat N] -a "<server command>" -b "<server command>"`)
+		return exitUsage
+	}
+	var parsedArgs map[string]any
+	if err := json.Unmarshal([]byte(*toolArgs), &parsedArgs); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -args: %v\n", err)
+		return exitUsage
+	}
+	if err := features.Default.Parse(os.Getenv(features.EnvVar), features.EnvVar); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	fields := slices.Clone(differential.DefaultVolatileFields)
+	if *volatileFields != "" {
+		fields = append(fields, strings.Split(*volatileFields, ",")...)
+	}
+	n := differential.New(fields)
+
+	sides := []struct {
+		label string
+		cmd   []string
+		first any
+	}{{label: "a", cmd: a}, {label: "b", cmd: b}}
+	var calls []client.Metrics
+	for i := range *repeat {
+		for s := range sides {
+			side := &sides[s]
+			result, err := client.InvokeMCPTool(client.ToolCall{
+				ServerCmd: side.cmd,
+				ToolName:  *tool,
+				ToolArgs:  parsedArgs,
+				Env:       env,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %s (%s): %v\n", side.label, strings.Join(side.cmd, " "), err)
+				return exitFail
+			}
+			// The two servers may share an executable name; label their
+			// latencies by side instead.
+			m := result.Metrics
+			m.Server = side.label
+			calls = append(calls, m)
+			if i == 0 {
+				side.first = n.Normalize(result.Output + result.ErrorMessage)
+			}
+		}
+	}
+
+	fmt.Printf("a: %s\nb: %s\n\n", *aCmd, *bCmd)
+	if err := report.WriteLatencyTable(os.Stdout, report.SummarizeLatency(calls)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	fmt.Println()
+	diff, err := differential.Diff("a", "b", sides[0].first, sides[1].first)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	if diff != "" {
+		fmt.Print(diff)
+		fmt.Fprintf(os.Stderr, "❌ %s returns different results\n", *tool)
+		return exitFail
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s returns the same result from both servers\n", *tool)
+	return exitPass
+}
@@ -0,0 +1,192 @@
+// Package compat turns a run's results into a compatibility report per
+// server release: the conformance, contract and smoke tests that covered the
+// server and its latency against the objectives, with a verdict, as a
+// versioned JSON document to publish alongside the release as evidence of
+// what it was tested with.
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// SchemaVersion is the version of the Report format. It changes when a
+// field is removed or changes meaning, not when one is added.
+const SchemaVersion = 1
+
+// Verdicts of a Report.
+const (
+	// Compatible means every test of the server passed and every objective
+	// was met.
+	Compatible = "compatible"
+	// Incompatible means a test failed or an objective was missed.
+	Incompatible = "incompatible"
+	// Untested means no test of the server ran.
+	Untested = "untested"
+)
+
+// Report is the tested compatibility of one server release.
+type Report struct {
+	SchemaVersion int    `json:"schema_version"`
+	Server        string `json:"server"`
+	// Package and Version identify the release, from the run's fingerprint
+	// of the server. Version is "unknown" without one.
+	Package   string    `json:"package,omitempty"`
+	Version   string    `json:"version"`
+	Generated time.Time `json:"generated"`
+	Run       Run       `json:"run"`
+	Verdict   string    `json:"verdict"`
+	// Conformance holds the protocol conformance, protocol version and fuzz
+	// tests, Contract the tool catalog tests and Smoke the other tests that
+	// called the server.
+	Conformance []Check     `json:"conformance,omitempty"`
+	Contract    []Check     `json:"contract,omitempty"`
+	Smoke       []Check     `json:"smoke,omitempty"`
+	SLO         []Objective `json:"slo,omitempty"`
+}
+
+// Run identifies the run a Report was built from.
+type Run struct {
+	Started  time.Time         `json:"started"`
+	Seed     int64             `json:"seed"`
+	Harness  string            `json:"harness,omitempty"`
+	Platform string            `json:"platform,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// Check is the outcome of one test.
+type Check struct {
+	Test   string        `json:"test"`
+	Status report.Status `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// Failed reports whether the test counts against the release. A flaky test
+// passed in the end; a quarantined one still failed.
+func (c Check) Failed() bool {
+	return c.Status == report.StatusFailed || c.Status == report.StatusQuarantined
+}
+
+// Objective is the latency of one tool against its objective.
+type Objective struct {
+	Tool  string        `json:"tool"`
+	Calls int           `json:"calls"`
+	P95   time.Duration `json:"p95_ns"`
+	// Target is the p95 the tool must stay within; zero records the latency
+	// without an objective.
+	Target time.Duration `json:"target_ns,omitempty"`
+	Met    bool          `json:"met"`
+}
+
+// Options configure Build.
+type Options struct {
+	// Servers are the registered servers to report on, by name.
+	Servers []string
+	// Executables maps the executable a server's tool calls are recorded
+	// under in the run's latency to its name.
+	Executables map[string]string
+	// P95 is the latency objective of every tool; zero sets none.
+	P95 time.Duration
+	// Now is the report's generation time. Defaults to time.Now.
+	Now time.Time
+}
+
+// Build returns a report for each of opts.Servers from run.
+func Build(run *report.Run, opts Options) []Report {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var reports []Report
+	for _, server := range opts.Servers {
+		r := Report{
+			SchemaVersion: SchemaVersion,
+			Server:        server,
+			Version:       "unknown",
+			Generated:     now.UTC(),
+			Run:           Run{Started: run.Started, Seed: run.Seed, Harness: run.Harness, Platform: run.Platform, Labels: run.Labels},
+		}
+		for _, fp := range run.Fingerprints {
+			if fp.Server == server && fp.Version != "" {
+				r.Package, r.Version = fp.Package, fp.Version
+			}
+		}
+		for _, t := range run.Tests {
+			if t.Status == report.StatusSkipped {
+				continue
+			}
+			c := Check{Test: t.ID, Status: t.Status, Reason: t.Reason, Error: t.Error}
+			switch {
+			case t.ID == "conformance-"+server || t.ID == "fuzz-"+server || t.ID == "protocol-versions-"+server:
+				r.Conformance = append(r.Conformance, c)
+			case t.ID == "tool-catalog-"+server:
+				r.Contract = append(r.Contract, c)
+			case slices.Contains(t.Servers, server):
+				r.Smoke = append(r.Smoke, c)
+			}
+		}
+		for _, l := range run.Latency {
+			if name, ok := opts.Executables[l.Server]; !ok || name != server {
+				continue
+			}
+			r.SLO = append(r.SLO, Objective{Tool: l.Tool, Calls: l.Calls, P95: l.P95, Target: opts.P95, Met: opts.P95 == 0 || l.P95 <= opts.P95})
+		}
+		r.Verdict = r.verdict()
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+func (r *Report) verdict() string {
+	checks := slices.Concat(r.Conformance, r.Contract, r.Smoke)
+	if len(checks) == 0 {
+		return Untested
+	}
+	for _, c := range checks {
+		if c.Failed() {
+			return Incompatible
+		}
+	}
+	for _, o := range r.SLO {
+		if !o.Met {
+			return Incompatible
+		}
+	}
+	return Compatible
+}
+
+// FileName is the name Write gives r: <server>-<version>.json, with any
+// character unsafe in a file name replaced.
+func (r *Report) FileName() string {
+	safe := strings.Map(func(c rune) rune {
+		if c == '/' || c == '\\' || c == ':' || c == ' ' {
+			return '_'
+		}
+		return c
+	}, r.Server+"-"+r.Version)
+	return safe + ".json"
+}
+
+// Write writes r to dir as FileName and returns its path.
+func (r *Report) Write(dir string) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.FileName())
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write compatibility report: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,102 @@
+package compat
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func run() *report.Run {
+	return &report.Run{
+		Started: time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC),
+		Seed:    7,
+		Harness: "v1.4.0",
+		Tests: []report.TestResult{
+			{ID: "conformance-gcloud", Status: report.StatusPassed},
+			{ID: "fuzz-gcloud", Status: report.StatusFlaky, Reason: report.ReasonHang},
+			{ID: "tool-catalog-gcloud", Status: report.StatusPassed},
+			{ID: "gcloud-tool-call", Status: report.StatusPassed, Servers: []string{"gcloud"}},
+			{ID: "gcloud-iam-denied", Status: report.StatusSkipped, Servers: []string{"gcloud"}},
+			{ID: "conformance-storage", Status: report.StatusPassed},
+			{ID: "storage-resource-link", Status: report.StatusQuarantined, Reason: report.ReasonToolError, Servers: []string{"storage", "gcloud"}},
+			{ID: "example-echo", Status: report.StatusPassed},
+		},
+		Latency: []report.ToolLatency{
+			{Server: "gcloud-mcp", Tool: "run_gcloud_command", Calls: 3, P95: 2 * time.Second},
+			{Server: "storage-mcp", Tool: "list_objects", Calls: 1, P95: 9 * time.Second},
+		},
+		Fingerprints: []fingerprint.Fingerprint{{Server: "gcloud", Package: "@google-cloud/gcloud-mcp", Version: "0.5.1"}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	reports := Build(run(), Options{
+		Servers:     []string{"gcloud", "storage", "observability"},
+		Executables: map[string]string{"gcloud-mcp": "gcloud", "storage-mcp": "storage"},
+		P95:         5 * time.Second,
+	})
+	if len(reports) != 3 {
+		t.Fatalf("got %d reports", len(reports))
+	}
+	gcloud, storage, observability := reports[0], reports[1], reports[2]
+
+	if gcloud.Version != "0.5.1" || gcloud.Package != "@google-cloud/gcloud-mcp" || gcloud.Run.Seed != 7 {
+		t.Errorf("gcloud identity = %+v", gcloud)
+	}
+	if len(gcloud.Conformance) != 2 || len(gcloud.Contract) != 1 || len(gcloud.Smoke) != 2 {
+		t.Errorf("gcloud checks = %+v / %+v / %+v", gcloud.Conformance, gcloud.Contract, gcloud.Smoke)
+	}
+	// The quarantined test that also used gcloud counts against it.
+	if gcloud.Verdict != Incompatible {
+		t.Errorf("gcloud verdict = %s", gcloud.Verdict)
+	}
+	if len(gcloud.SLO) != 1 || !gcloud.SLO[0].Met || gcloud.SLO[0].Target != 5*time.Second {
+		t.Errorf("gcloud SLO = %+v", gcloud.SLO)
+	}
+
+	if storage.Version != "unknown" || storage.Verdict != Incompatible || len(storage.SLO) != 1 || storage.SLO[0].Met {
+		t.Errorf("storage = %+v", storage)
+	}
+	if observability.Verdict != Untested {
+		t.Errorf("observability verdict = %s", observability.Verdict)
+	}
+}
+
+func TestVerdictCompatible(t *testing.T) {
+	r := run()
+	r.Tests[6].Status = report.StatusPassed
+	reports := Build(r, Options{Servers: []string{"gcloud"}, Executables: map[string]string{"gcloud-mcp": "gcloud"}})
+	if v := reports[0].Verdict; v != Compatible {
+		t.Errorf("verdict = %s, want %s", v, Compatible)
+	}
+	// Without an objective the latency is recorded but always met.
+	if o := reports[0].SLO; len(o) != 1 || !o[0].Met || o[0].Target != 0 {
+		t.Errorf("SLO = %+v", o)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := Report{SchemaVersion: SchemaVersion, Server: "gcloud", Version: "1.0.0 rc/1", Verdict: Compatible}
+	path, err := r.Write(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["schema_version"] != float64(1) || got["verdict"] != "compatible" {
+		t.Errorf("report = %s", data)
+	}
+	if want := "gcloud-1.0.0_rc_1.json"; r.FileName() != want {
+		t.Errorf("FileName = %q, want %q", r.FileName(), want)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/compat"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// runCompatReport implements `compat-report [-out DIR] [-server NAME]...
+// [-slo-p95 D] <results.json>`, writing a compatibility report for each
+// registered server release the run tested.
+func runCompatReport(args []string) int {
+	fs := flag.NewFlagSet("compat-report", flag.ContinueOnError)
+	outDir := fs.String("out", "compat", "directory the <server>-<version>.json reports are written to")
+	var only stringList
+	fs.Var(&only, "server", "report only on this registered server (repeatable; default all)")
+	p95 := fs.Duration("slo-p95", 0, "latency objective: the p95 every tool of the server must stay within (0 for none)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test compat-report [-out DIR] [-server NAME]... [-slo-p95 D] <results.json>")
+		return exitUsage
+	}
+	results, err := report.ReadJSON(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	opts := compat.Options{Executables: map[string]string{}, P95: *p95}
+	for _, s := range serverRegistry.All() {
+		opts.Executables[filepath.Base(s.Bin())] = s.Name
+		if len(only) == 0 || slices.Contains(only, s.Name) {
+			opts.Servers = append(opts.Servers, s.Name)
+		}
+	}
+	for _, name := range only {
+		if !slices.Contains(opts.Servers, name) {
+			fmt.Fprintf(os.Stderr, "unknown server %q\n", name)
+			return exitUsage
+		}
+	}
+	for _, r := range compat.Build(results, opts) {
+		path, err := r.Write(*outDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFail
+		}
+		logger.Printf("📜 %s %s: %s (%d conformance, %d contract, %d smoke tests) in %s\n",
+			r.Server, r.Version, r.Verdict, len(r.Conformance), len(r.Contract), len(r.Smoke), path)
+	}
+	return exitPass
+}
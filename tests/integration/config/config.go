@@ -0,0 +1,63 @@
+// Package config reads the harness's own configuration files, such as the
+// quarantine list and the feature flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Read returns the contents of the file at path. A missing file is not an
+// error when optional is set, so a checkout that needs no configuration needs
+// no file: Read then returns nil data, which callers treat as empty.
+func Read(path string, optional bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Expand returns value with its references resolved: ${NAME} is replaced by
+// the environment variable NAME and ${file:PATH} by the contents of the file
+// at PATH without its trailing newline, such as a mounted secret. secrets
+// lists the values read from files, which callers mask wherever they would
+// print them. An unset variable or unreadable file is an error, so a missing
+// secret is reported instead of starting a server without it.
+func Expand(value string) (expanded string, secrets []string, err error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), secrets, nil
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated reference in %q", value)
+		}
+		b.WriteString(value[:start])
+		ref := value[start+2 : start+end]
+		value = value[start+end+1:]
+		if path, ok := strings.CutPrefix(ref, "file:"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", nil, err
+			}
+			secret := strings.TrimRight(string(data), "\r\n")
+			secrets = append(secrets, secret)
+			b.WriteString(secret)
+			continue
+		}
+		if ref == "" {
+			return "", nil, errors.New("empty reference ${}")
+		}
+		v, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", nil, fmt.Errorf("$%s is not set", ref)
+		}
+		b.WriteString(v)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.yaml")
+	if data, err := Read(missing, true); data != nil || err != nil {
+		t.Errorf("Read(missing, optional) = %q, %v, want nil, nil", data, err)
+	}
+	if _, err := Read(missing, false); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Read(missing, required) = %v, want ErrNotExist", err)
+	}
+	path := filepath.Join(dir, "present.yaml")
+	os.WriteFile(path, []byte("a: 1\n"), 0o644)
+	if data, err := Read(path, true); string(data) != "a: 1\n" || err != nil {
+		t.Errorf("Read(present) = %q, %v", data, err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(secret, []byte("s3cret\n"), 0o600)
+	t.Setenv("CONFIG_TEST_PROJECT", "my-project")
+	got, secrets, err := Expand("projects/${CONFIG_TEST_PROJECT}?key=${file:" + secret + "}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "projects/my-project?key=s3cret"; got != want {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+	if len(secrets) != 1 || secrets[0] != "s3cret" {
+		t.Errorf("secrets = %q, want the file's contents only", secrets)
+	}
+	if got, _, err := Expand("plain $HOME"); got != "plain $HOME" || err != nil {
+		t.Errorf("Expand(plain) = %q, %v, want it unchanged", got, err)
+	}
+	for _, value := range []string{"${CONFIG_TEST_UNSET}", "${file:" + secret + ".missing}", "${CONFIG_TEST_PROJECT", "${}"} {
+		if _, _, err := Expand(value); err == nil {
+			t.Errorf("Expand(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package conformance runs protocol-level checks against any MCP server that
+// speaks stdio, independently of a client library: the handshake with each
+// protocol version, handling of unknown methods and malformed params,
+// cancellation, and graceful shutdown. Run it against every server before
+// onboarding it; the tests it needs are only the server's command.
+//
+// Every check starts a fresh server process so one check's failure cannot
+// leave the next with a broken session.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// ProtocolVersions are the MCP protocol revisions the handshake checks
+// request, newest first.
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes the checks expect.
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInvalidRequest = -32600
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultShutdownGrace = 5 * time.Second
+)
+
+var versionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Check is one conformance check.
+type Check struct {
+	Name string
+	// Describe says what the check requires of the server.
+	Describe string
+	run      func(ctx context.Context, c *conn) error
+}
+
+// Checks are all conformance checks, in the order Run runs them.
+var Checks = buildChecks()
+
+// Result is the outcome of one check. A check fails if the server breaks the
+// protocol; lesser deviations, such as answering with the wrong JSON-RPC
+// error code, are warnings.
+type Result struct {
+	Check    string        `json:"check"`
+	Error    string        `json:"error,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Passed reports whether the check passed.
+func (r Result) Passed() bool {
+	return r.Error == ""
+}
+
+func (r Result) String() string {
+	if r.Passed() {
+		return r.Check + ": ok"
+	}
+	return r.Check + ": " + r.Error
+}
+
+// Suite runs the checks against one server.
+type Suite struct {
+	// Command launches the server over stdio.
+	Command []string
+	// Env is added to the server's environment.
+	Env []string
+	// Timeout bounds each check; it defaults to 10 seconds.
+	Timeout time.Duration
+	// ShutdownGrace is how long the server may take to exit once its stdin
+	// is closed; it defaults to 5 seconds.
+	ShutdownGrace time.Duration
+	// Only, if set, selects checks by name.
+	Only []string
+}
+
+// Run runs every selected check and returns their results in order.
+func (s *Suite) Run(ctx context.Context) []Result {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	var results []Result
+	for _, check := range Checks {
+		if len(s.Only) > 0 && !slices.Contains(s.Only, check.Name) {
+			continue
+		}
+		start := time.Now()
+		warnings, err := s.runCheck(ctx, check, timeout)
+		r := Result{Check: check.Name, Warnings: warnings, Duration: time.Since(start)}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (s *Suite) runCheck(ctx context.Context, check Check, timeout time.Duration) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	c, err := dial(s.Command, s.Env)
+	if err != nil {
+		return nil, err
+	}
+	c.grace = s.ShutdownGrace
+	if c.grace <= 0 {
+		c.grace = defaultShutdownGrace
+	}
+	err = check.run(ctx, c)
+	if check.Name != "graceful-shutdown" {
+		c.shutdown(c.grace)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return c.warnings, err
+}
+
+// Failed returns the failed results.
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if !r.Passed() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+func buildChecks() []Check {
+	var checks []Check
+	for _, v := range ProtocolVersions {
+		checks = append(checks, Check{
+			Name:     "handshake-" + v,
+			Describe: "answers initialize for protocol " + v + " with a version it supports, and ping afterwards",
+			run:      func(ctx context.Context, c *conn) error { return checkHandshake(ctx, c, v) },
+		})
+	}
+	return append(checks,
+		Check{
+			Name:     "handshake-unsupported-version",
+			Describe: "answers initialize for an unknown protocol version with one it supports, or an error",
+			run:      checkUnsupportedVersion,
+		},
+		Check{
+			Name:     "unknown-method",
+			Describe: "rejects an unknown method with a JSON-RPC error, ideally -32601, and keeps serving",
+			run:      checkUnknownMethod,
+		},
+		Check{
+			Name:     "malformed-params",
+			Describe: "rejects tools/call with malformed params with a JSON-RPC error, ideally -32602, and keeps serving",
+			run:      checkMalformedParams,
+		},
+		Check{
+			Name:     "cancellation",
+			Describe: "accepts notifications/cancelled for in-flight and finished requests and keeps serving",
+			run:      checkCancellation,
+		},
+		Check{
+			Name:     "graceful-shutdown",
+			Describe: "exits by itself soon after its stdin is closed",
+			run:      checkShutdown,
+		},
+	)
+}
+
+func checkHandshake(ctx context.Context, c *conn, version string) error {
+	result, err := c.initialize(ctx, version)
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	if !versionPattern.MatchString(result.ProtocolVersion) {
+		return fmt.Errorf("initialize returned protocol version %q, not a YYYY-MM-DD revision", result.ProtocolVersion)
+	}
+	// A server that supports the requested version must answer with it;
+	// one that does not answers with another it supports. Any known newer
+	// version in answer to an older request means it ignored the request.
+	if result.ProtocolVersion != version && slices.Index(ProtocolVersions, result.ProtocolVersion) >= 0 &&
+		slices.Index(ProtocolVersions, result.ProtocolVersion) < slices.Index(ProtocolVersions, version) {
+		return fmt.Errorf("initialize for %s returned the newer %s; a server must answer with the requested version if it supports it, or an older one", version, result.ProtocolVersion)
+	}
+	if result.ServerInfo == nil || result.ServerInfo.Name == "" {
+		return fmt.Errorf("initialize result has no serverInfo.name")
+	}
+	if len(result.Capabilities) == 0 || result.Capabilities[0] != '{' {
+		return fmt.Errorf("initialize result has no capabilities object")
+	}
+	return initializedPing(ctx, c)
+}
+
+// initializedPing completes the handshake and checks the session works.
+func initializedPing(ctx context.Context, c *conn) error {
+	if err := c.send(nil, "notifications/initialized", map[string]any{}); err != nil {
+		return err
+	}
+	resp, err := c.request(ctx, "ping", nil)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("ping failed: %w", resp.Error)
+	}
+	return nil
+}
+
+func checkUnsupportedVersion(ctx context.Context, c *conn) error {
+	const unknown = "1999-01-01"
+	result, err := c.initialize(ctx, unknown)
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("initialize failed without a JSON-RPC error: %w", err)
+	}
+	if result.ProtocolVersion == unknown {
+		return fmt.Errorf("initialize accepted the made-up protocol version %s", unknown)
+	}
+	return nil
+}
+
+func (c *conn) handshake(ctx context.Context) error {
+	if _, err := c.initialize(ctx, ProtocolVersions[0]); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	return initializedPing(ctx, c)
+}
+
+func checkUnknownMethod(ctx context.Context, c *conn) error {
+	if err := c.handshake(ctx); err != nil {
+		return err
+	}
+	resp, err := c.request(ctx, "conformance/no-such-method", map[string]any{})
+	if err != nil {
+		return err
+	}
+	if resp.Error == nil {
+		return fmt.Errorf("unknown method returned a result: %s", resp.Result)
+	}
+	if resp.Error.Code != codeMethodNotFound {
+		c.warnf("unknown method returned error %d, want %d (method not found): %s", resp.Error.Code, codeMethodNotFound, resp.Error.Message)
+	}
+	return pingAfter(ctx, c, "an unknown method")
+}
+
+func checkMalformedParams(ctx context.Context, c *conn) error {
+	if err := c.handshake(ctx); err != nil {
+		return err
+	}
+	for _, params := range []any{"not an object", []any{1, 2}, map[string]any{"name": 42}} {
+		resp, err := c.request(ctx, "tools/call", params)
+		if err != nil {
+			return fmt.Errorf("tools/call with params %v: %w", describe(params), err)
+		}
+		if resp.Error == nil {
+			return fmt.Errorf("tools/call with params %v returned a result instead of an error: %s", describe(params), resp.Result)
+		}
+		if resp.Error.Code != codeInvalidParams && resp.Error.Code != codeInvalidRequest {
+			c.warnf("tools/call with params %v returned error %d, want %d (invalid params): %s", describe(params), resp.Error.Code, codeInvalidParams, resp.Error.Message)
+		}
+	}
+	return pingAfter(ctx, c, "malformed params")
+}
+
+func checkCancellation(ctx context.Context, c *conn) error {
+	if err := c.handshake(ctx); err != nil {
+		return err
+	}
+	// Cancel a request as soon as it is sent; the server may still answer
+	// it.
+	c.nextID++
+	inFlight := c.nextID
+	if err := c.send(inFlight, "tools/list", map[string]any{}); err != nil {
+		return err
+	}
+	if err := c.send(nil, "notifications/cancelled", map[string]any{"requestId": inFlight, "reason": "conformance check"}); err != nil {
+		return err
+	}
+	// And one the server has long finished.
+	if err := c.send(nil, "notifications/cancelled", map[string]any{"requestId": 1, "reason": "conformance check"}); err != nil {
+		return err
+	}
+	return pingAfter(ctx, c, "cancellation")
+}
+
+// pingAfter checks that the server still answers after what.
+func pingAfter(ctx context.Context, c *conn, what string) error {
+	resp, err := c.request(ctx, "ping", nil)
+	if err != nil {
+		return fmt.Errorf("server stopped answering after %s: %w", what, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("ping after %s failed: %w", what, resp.Error)
+	}
+	return nil
+}
+
+func checkShutdown(ctx context.Context, c *conn) error {
+	if err := c.handshake(ctx); err != nil {
+		c.shutdown(0)
+		return err
+	}
+	exited, err := c.shutdown(c.grace)
+	if !exited {
+		return fmt.Errorf("server was still running %s after its stdin was closed", c.grace)
+	}
+	if err != nil {
+		return fmt.Errorf("server exited with an error after its stdin was closed: %w", err)
+	}
+	return nil
+}
+
+// describe returns params as the JSON the check sent.
+func describe(params any) string {
+	data, _ := json.Marshal(params)
+	return string(data)
+}
@@ -0,0 +1,111 @@
+package conformance
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fuzz"
+)
+
+// The test binary doubles as a server under test.
+const serveEnv = "CONFORMANCE_TEST_SERVE"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(serveEnv) {
+	case "example":
+		if err := exampleserver.Run(context.Background()); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "panic":
+		// Like a server that indexes its arguments unchecked.
+		server := mcp.NewServer(&mcp.Implementation{Name: "panicky"}, nil)
+		mcp.AddTool(server, &mcp.Tool{Name: "run"}, func(_ context.Context, _ *mcp.CallToolRequest, args struct {
+			Args []string `json:"args,omitempty"`
+		}) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: args.Args[0]}}}, nil, nil
+		})
+		server.Run(context.Background(), &mcp.StdioTransport{})
+		os.Exit(0)
+	case "hang":
+		// Reads nothing and never exits.
+		time.Sleep(time.Hour)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func suite(t *testing.T, mode string) *Suite {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Suite{Command: []string{exe}, Env: []string{serveEnv + "=" + mode}, Timeout: 5 * time.Second}
+}
+
+func TestExampleServerConforms(t *testing.T) {
+	results := suite(t, "example").Run(context.Background())
+	if len(results) != len(Checks) {
+		t.Fatalf("got %d results, want one per check", len(results))
+	}
+	for _, r := range Failed(results) {
+		t.Errorf("%s", r)
+	}
+}
+
+func TestUnresponsiveServerFails(t *testing.T) {
+	s := suite(t, "hang")
+	s.Timeout, s.ShutdownGrace = 200*time.Millisecond, 100*time.Millisecond
+	s.Only = []string{"unknown-method", "graceful-shutdown"}
+	results := s.Run(context.Background())
+	if len(results) != 2 || results[0].Passed() || results[1].Passed() {
+		t.Fatalf("results = %v, want both checks to fail", results)
+	}
+}
+
+func TestFuzzExampleServer(t *testing.T) {
+	cases, err := fuzz.Cases(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"text": map[string]any{"type": "string"}},
+		"required":   []any{"text"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := suite(t, "example").Fuzz(context.Background(), "echo", cases)
+	if len(results) != len(cases) {
+		t.Fatalf("got %d results, want one per case", len(results))
+	}
+	for _, r := range results {
+		if r.Failed() {
+			t.Errorf("%s", r)
+		}
+	}
+}
+
+func TestFuzzFindsPanic(t *testing.T) {
+	cases, err := fuzz.Cases(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"args": map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := suite(t, "panic").Fuzz(context.Background(), "run", cases)
+	if len(results) != len(cases) {
+		t.Fatalf("got %d results, want one per case", len(results))
+	}
+	if r := results[0]; r.Case != "empty arguments" || r.Outcome != Crashed || !strings.Contains(r.Detail, "panic:") {
+		t.Errorf("first result = %s, want a crash with the panic", r)
+	}
+	// Each crash restarts the server, so later cases still run.
+	if r := results[2]; r.Case != "string arguments" || r.Outcome != Rejected {
+		t.Errorf("third result = %s, want a rejection", r)
+	}
+}
@@ -0,0 +1,224 @@
+package conformance
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// maxLine bounds a JSON-RPC line read from the server.
+const maxLine = 16 << 20
+
+// maxStderr bounds the server's stderr kept to explain a crash.
+const maxStderr = 4 << 10
+
+// errExited reports that the server exited while a check was talking to it.
+var errExited = errors.New("server exited")
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// message is any JSON-RPC message the server sends.
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+}
+
+// conn is a raw newline-delimited JSON-RPC connection to a server process,
+// which lets the checks send what a well-behaved client library never would.
+type conn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan []byte
+	exited chan struct{}
+	// waitErr is the outcome of the process, set before exited is closed.
+	waitErr error
+	nextID  int
+	// grace is how long the server may take to exit when shut down.
+	grace time.Duration
+	// warnings collects the check's deviations that do not fail it.
+	warnings []string
+	// stderr keeps the end of the server's stderr.
+	stderr tail
+}
+
+// tail is a writer that keeps the last maxStderr bytes written to it.
+type tail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - maxStderr; over > 0 {
+		t.buf = t.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (t *tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
+
+func (c *conn) warnf(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// dial starts command with env added to the harness's environment.
+func dial(command, env []string) (*conn, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{cmd: cmd, stdin: stdin, lines: make(chan []byte, 16), exited: make(chan struct{})}
+	cmd.Stderr = &c.stderr
+	if err := subprocess.Default.Start(cmd); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64<<10), maxLine)
+		for scanner.Scan() {
+			c.lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+		close(c.lines)
+		c.waitErr = subprocess.Default.Wait(cmd)
+		close(c.exited)
+	}()
+	return c, nil
+}
+
+// send writes one JSON-RPC message. A nil id makes it a notification.
+func (c *conn) send(id any, method string, params any) error {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// request sends a request and returns its response. Notifications and
+// requests from the server in the meantime are skipped.
+func (c *conn) request(ctx context.Context, method string, params any) (*message, error) {
+	c.nextID++
+	id := c.nextID
+	if err := c.send(id, method, params); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	for {
+		msg, err := c.read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("no response to %s: %w", method, err)
+		}
+		if msg.Method == "" && string(msg.ID) == strconv.Itoa(id) {
+			return msg, nil
+		}
+	}
+}
+
+// read returns the next JSON-RPC message, skipping lines that are not one.
+func (c *conn) read(ctx context.Context) (*message, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case line, ok := <-c.lines:
+			if !ok {
+				return nil, errExited
+			}
+			var msg message
+			if json.Unmarshal(line, &msg) == nil {
+				return &msg, nil
+			}
+		}
+	}
+}
+
+// initialize performs the handshake, requesting protocol version, and returns
+// the server's initialize result.
+func (c *conn) initialize(ctx context.Context, version string) (*initializeResult, error) {
+	resp, err := c.request(ctx, "initialize", map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "mcp-conformance", "version": "v1"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	var result initializeResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid initialize result: %w", err)
+	}
+	return &result, nil
+}
+
+type initializeResult struct {
+	ProtocolVersion string          `json:"protocolVersion"`
+	Capabilities    json.RawMessage `json:"capabilities"`
+	ServerInfo      *struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"serverInfo"`
+}
+
+// shutdown closes the server's stdin, as the stdio transport specifies, and
+// waits up to grace for it to exit before killing it. It reports whether the
+// server exited by itself.
+func (c *conn) shutdown(grace time.Duration) (bool, error) {
+	c.stdin.Close()
+	// Drain the output so the reader can reach the exit.
+	go func() {
+		for range c.lines {
+		}
+	}()
+	select {
+	case <-c.exited:
+		return true, c.waitErr
+	case <-time.After(grace):
+		// Wait kills the rest of the process group.
+		c.cmd.Process.Kill()
+		<-c.exited
+		return false, nil
+	}
+}
@@ -0,0 +1,149 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/fuzz"
+)
+
+// Outcome is how a server answered a fuzz case.
+type Outcome string
+
+const (
+	// Rejected is a JSON-RPC error or a result with isError set.
+	Rejected Outcome = "rejected"
+	// Accepted is a successful result.
+	Accepted Outcome = "accepted"
+	// Crashed means the server exited before answering.
+	Crashed Outcome = "crashed"
+	// Hung means the server did not answer within the suite's timeout.
+	Hung Outcome = "hung"
+)
+
+// FuzzResult is the outcome of one fuzz case. A case fails if the server
+// crashed or hung; accepting arguments that violate the schema is a warning.
+type FuzzResult struct {
+	Tool     string        `json:"tool"`
+	Case     string        `json:"case"`
+	Outcome  Outcome       `json:"outcome"`
+	Detail   string        `json:"detail,omitempty"`
+	Warning  string        `json:"warning,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Failed reports whether the server crashed or hung on the case.
+func (r FuzzResult) Failed() bool {
+	return r.Outcome == Crashed || r.Outcome == Hung
+}
+
+func (r FuzzResult) String() string {
+	s := fmt.Sprintf("%s with %s: %s", r.Tool, r.Case, r.Outcome)
+	if r.Detail != "" {
+		s += " (" + r.Detail + ")"
+	}
+	return s
+}
+
+// Fuzz calls tool with every case, over raw JSON-RPC so that arguments a
+// client library would refuse to send still reach the server. Cases share a
+// server process until it crashes or hangs; the next case starts a fresh one.
+func (s *Suite) Fuzz(ctx context.Context, tool string, cases []fuzz.Case) []FuzzResult {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	var c *conn
+	defer func() {
+		if c != nil {
+			c.shutdown(0)
+		}
+	}()
+	var results []FuzzResult
+	for _, fc := range cases {
+		start := time.Now()
+		r := FuzzResult{Tool: tool, Case: fc.Name}
+		caseCtx, cancel := context.WithTimeout(ctx, timeout)
+		if c == nil {
+			var err error
+			if c, err = s.dialSession(caseCtx); err != nil {
+				// A server that cannot even start is not fuzzed further.
+				cancel()
+				r.Outcome, r.Detail = Crashed, err.Error()
+				return append(results, r)
+			}
+		}
+		resp, err := c.request(caseCtx, "tools/call", map[string]any{"name": tool, "arguments": fc.Args})
+		cancel()
+		switch {
+		case errors.Is(err, errExited):
+			r.Outcome, r.Detail = Crashed, exitDetail(c)
+		case errors.Is(err, context.DeadlineExceeded):
+			r.Outcome, r.Detail = Hung, fmt.Sprintf("no answer after %s", timeout)
+		case err != nil:
+			r.Outcome, r.Detail = Crashed, err.Error()
+		case resp.Error != nil:
+			r.Outcome, r.Detail = Rejected, resp.Error.Error()
+		case isError(resp.Result):
+			r.Outcome = Rejected
+		default:
+			r.Outcome = Accepted
+			if fc.Invalid {
+				r.Warning = "accepted arguments that violate its input schema"
+			}
+		}
+		if r.Failed() {
+			c.shutdown(0)
+			c = nil
+		}
+		r.Duration = time.Since(start)
+		results = append(results, r)
+	}
+	return results
+}
+
+// dialSession starts the server and completes the handshake.
+func (s *Suite) dialSession(ctx context.Context) (*conn, error) {
+	c, err := dial(s.Command, s.Env)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.handshake(ctx); err != nil {
+		c.shutdown(0)
+		return nil, err
+	}
+	return c, nil
+}
+
+// exitDetail describes how the server exited, with the line of its stderr
+// most likely to say why: a Go panic if there was one, else the last.
+func exitDetail(c *conn) string {
+	<-c.exited
+	detail := "server exited"
+	if c.waitErr != nil {
+		detail += ": " + c.waitErr.Error()
+	}
+	lines := strings.Split(c.stderr.String(), "\n")
+	last := lines[len(lines)-1]
+	for _, line := range lines {
+		if strings.HasPrefix(line, "panic:") {
+			last = line
+			break
+		}
+	}
+	if last != "" {
+		detail += "; stderr: " + last
+	}
+	return detail
+}
+
+func isError(result json.RawMessage) bool {
+	var r struct {
+		IsError bool `json:"isError"`
+	}
+	return json.Unmarshal(result, &r) == nil && r.IsError
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/conformance"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The conformance-* tests run the protocol conformance checks against every
+// registered server and the example server, so a newly registered server is
+// checked before any of its own tests are written.
+
+// conformanceTests returns a conformance-<name> test for every registered
+// server and one for the example server.
+func conformanceTests() []testCase {
+	tests := []testCase{{
+		id:   "conformance-example",
+		tags: smoke,
+		run:  func(t *testContext) error { return testConformance(t, "example server", exampleServerCmd()) },
+	}}
+	for _, s := range serverRegistry.All() {
+		tests = append(tests, testCase{
+			id:       "conformance-" + s.Name,
+			requires: s.Command[:1],
+			run:      func(t *testContext) error { return testConformance(t, s.Name, s.Command) },
+		})
+	}
+	return tests
+}
+
+func testConformance(t *testContext, server string, command []string) error {
+	logger.Printf("🚀 Starting %s protocol conformance test...\n", server)
+	suite := &conformance.Suite{Command: command, Env: t.Env()}
+	results := suite.Run(context.Background())
+	logConformance(results)
+	failed := conformance.Failed(results)
+	if len(failed) == 0 {
+		logger.Printf("✅ Assertion passed: %s passed all %d conformance checks\n", server, len(results))
+		return nil
+	}
+	summary := make([]string, len(failed))
+	for i, r := range failed {
+		summary[i] = r.String()
+	}
+	return report.Fail(report.ReasonProtocol, "%s failed %d of %d conformance checks: %s", server, len(failed), len(results), strings.Join(summary, "; "))
+}
+
+func logConformance(results []conformance.Result) {
+	for _, r := range results {
+		if r.Passed() {
+			logger.Printf("  ✅ %s (%s)\n", r.Check, r.Duration.Round(time.Millisecond))
+		} else {
+			logf(slog.LevelError, "  ❌ %s\n", r)
+		}
+		for _, w := range r.Warnings {
+			logf(slog.LevelWarn, "  ⚠️  %s: %s\n", r.Check, w)
+		}
+	}
+}
+
+// runConformance implements `conformance [-check NAME]... [-timeout D] --
+// <server command...>`, running the protocol conformance checks against a
+// server that is not registered yet. It exits 1 if a check fails.
+func runConformance(args []string) int {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	var checks stringList
+	fs.Var(&checks, "check", "run only this check (repeatable); -list shows them")
+	list := fs.Bool("list", false, "list the checks and exit")
+	timeout := fs.Duration("timeout", 10*time.Second, "time limit for each check")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *list {
+		for _, c := range conformance.Checks {
+			fmt.Printf("%-30s %s\n", c.Name, c.Describe)
+		}
+		return exitPass
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test conformance [-check NAME]... [-timeout D] -- <server command...>")
+		return exitUsage
+	}
+	for _, name := range checks {
+		if !slices.ContainsFunc(conformance.Checks, func(c conformance.Check) bool { return c.Name == name }) {
+			fmt.Fprintf(os.Stderr, "unknown check %q; see -list\n", name)
+			return exitUsage
+		}
+	}
+	suite := &conformance.Suite{Command: fs.Args(), Timeout: *timeout, Only: checks}
+	results := suite.Run(context.Background())
+	logConformance(results)
+	if failed := conformance.Failed(results); len(failed) > 0 {
+		logf(slog.LevelError, "❌ %d of %d conformance checks failed\n", len(failed), len(results))
+		return exitFail
+	}
+	logger.Printf("✅ All %d conformance checks passed\n", len(results))
+	return exitPass
+}
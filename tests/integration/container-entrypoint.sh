@@ -0,0 +1,15 @@
+#!/bin/sh
+# Copies the host credentials mounted read-only under /run/credentials into
+# place, since gcloud and the Gemini CLI write next to them, then runs the
+# harness.
+set -e
+if [ -d /run/credentials/gcloud ]; then
+  mkdir -p /root/.config/gcloud
+  cp -R /run/credentials/gcloud/. /root/.config/gcloud/
+fi
+for f in oauth_creds.json google_accounts.json; do
+  if [ -f "/run/credentials/gemini/$f" ]; then
+    cp "/run/credentials/gemini/$f" /root/.gemini/
+  fi
+done
+exec integration-test "$@"
@@ -0,0 +1,206 @@
+// Package container runs the suite inside an image with pinned versions of
+// node, the Gemini CLI, the gcloud SDK and the MCP servers, so a run does not
+// depend on what the host happens to have installed.
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// EnvVar is set inside the container, where -in-container is ignored so the
+// harness runs the tests instead of starting another container.
+const EnvVar = "INTEGRATION_IN_CONTAINER"
+
+// Inside reports whether the harness is running inside the suite's
+// container.
+func Inside() bool {
+	return os.Getenv(EnvVar) != ""
+}
+
+// CredentialsDir is where the host's credentials are mounted, read-only; the
+// image's entrypoint copies them into place so tools can write next to them.
+const CredentialsDir = "/run/credentials"
+
+// Pins are the versions of the tools built into the image.
+type Pins struct {
+	Node      string `yaml:"node"`
+	GeminiCLI string `yaml:"gemini_cli"`
+	GcloudSDK string `yaml:"gcloud_sdk"`
+}
+
+// Load reads a pins file. Every version is required and must be exact, since
+// a dist-tag would let two builds of the same pins differ.
+func Load(path string) (*Pins, error) {
+	data, err := config.Read(path, false)
+	if err != nil {
+		return nil, err
+	}
+	var p Pins
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse container pins %s: %w", path, err)
+	}
+	for _, v := range []struct{ key, version string }{
+		{"node", p.Node},
+		{"gemini_cli", p.GeminiCLI},
+		{"gcloud_sdk", p.GcloudSDK},
+	} {
+		if !exact(v.version) {
+			return nil, fmt.Errorf("%s: %s needs an exact version such as 1.2.3, not %q", path, v.key, v.version)
+		}
+	}
+	return &p, nil
+}
+
+// exact reports whether v is a version number rather than a range or tag.
+func exact(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, r := range v {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// Build describes the image to build.
+type Build struct {
+	Pins Pins
+	// Servers are the npm install arguments of the MCP servers, pinned to
+	// the versions they resolved to, e.g. @google-cloud/gcloud-mcp@1.2.3.
+	Servers []string
+	// Context is the build context, which holds the Dockerfile and the
+	// harness's source.
+	Context string
+	// HarnessVersion, if set, is the release the harness in the image reports,
+	// that of the harness building it, so the image's runs are checked for
+	// updates as the host's would be.
+	HarnessVersion string
+}
+
+// Tag returns the image's tag, which is derived from the pinned versions, so
+// images of different pins coexist and the log tells them apart.
+func (b *Build) Tag() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "node=%s\ngemini_cli=%s\ngcloud_sdk=%s\n", b.Pins.Node, b.Pins.GeminiCLI, b.Pins.GcloudSDK)
+	for _, s := range b.Servers {
+		fmt.Fprintln(h, s)
+	}
+	return "integration-test:" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Args returns the arguments of `docker build` for the image. The Dockerfile
+// receives the pins as build arguments; the image's layers are cached, so
+// rebuilding unchanged pins only rebuilds the harness.
+func (b *Build) Args() []string {
+	return []string{
+		"build",
+		"--tag", b.Tag(),
+		"--build-arg", "NODE_VERSION=" + b.Pins.Node,
+		"--build-arg", "GEMINI_CLI_VERSION=" + b.Pins.GeminiCLI,
+		"--build-arg", "GCLOUD_SDK_VERSION=" + b.Pins.GcloudSDK,
+		"--build-arg", "MCP_SERVERS=" + strings.Join(b.Servers, " "),
+		"--build-arg", "HARNESS_VERSION=" + b.HarnessVersion,
+		b.Context,
+	}
+}
+
+// Mount is a host path made available in the container.
+type Mount struct {
+	Source, Target string
+	ReadOnly       bool
+}
+
+func (m Mount) String() string {
+	s := "type=bind,source=" + m.Source + ",target=" + m.Target
+	if m.ReadOnly {
+		s += ",readonly"
+	}
+	return s
+}
+
+// PassThrough are the host variables every run passes into the container
+// when they are set: the project and the Gemini CLI's authentication.
+var PassThrough = []string{
+	"GOOGLE_CLOUD_PROJECT",
+	"CLOUDSDK_CORE_PROJECT",
+	"GOOGLE_CLOUD_LOCATION",
+	"GOOGLE_GENAI_USE_VERTEXAI",
+	"GEMINI_API_KEY",
+	"GOOGLE_API_KEY",
+}
+
+// Credentials returns the mounts and variables that hand the host's
+// credentials to the container: the gcloud configuration directory, the
+// Gemini CLI's OAuth credentials and the application default credentials
+// file, each if it exists. getenv and home are the host's.
+func Credentials(getenv func(string) string, home string) ([]Mount, []string) {
+	var mounts []Mount
+	var env []string
+	add := func(source, name string) bool {
+		if source == "" {
+			return false
+		}
+		if _, err := os.Stat(source); err != nil {
+			return false
+		}
+		mounts = append(mounts, Mount{Source: source, Target: CredentialsDir + "/" + name, ReadOnly: true})
+		return true
+	}
+	gcloud := getenv("CLOUDSDK_CONFIG")
+	if gcloud == "" && home != "" {
+		gcloud = filepath.Join(home, ".config", "gcloud")
+	}
+	add(gcloud, "gcloud")
+	if home != "" {
+		add(filepath.Join(home, ".gemini"), "gemini")
+	}
+	if add(getenv("GOOGLE_APPLICATION_CREDENTIALS"), "application_default_credentials.json") {
+		env = append(env, "GOOGLE_APPLICATION_CREDENTIALS="+CredentialsDir+"/application_default_credentials.json")
+	}
+	return mounts, env
+}
+
+// Run describes a run of the harness in the container.
+type Run struct {
+	Image string
+	// WorkDir is mounted at the same path and is the working directory, so
+	// relative and absolute paths below it, such as -results, mean the same
+	// inside and out.
+	WorkDir string
+	Mounts  []Mount
+	// Env are NAME=VALUE settings and NAME pass-throughs of host variables.
+	Env []string
+	// Terminal allocates a TTY, for a run from an interactive shell.
+	Terminal bool
+	// HarnessArgs are the harness's arguments.
+	HarnessArgs []string
+}
+
+// Args returns the arguments of `docker run` for the run.
+func (r *Run) Args() []string {
+	args := []string{"run", "--rm", "--init", "--interactive"}
+	if r.Terminal {
+		args = append(args, "--tty")
+	}
+	args = append(args, "--mount", Mount{Source: r.WorkDir, Target: r.WorkDir}.String(), "--workdir", r.WorkDir)
+	for _, m := range r.Mounts {
+		args = append(args, "--mount", m.String())
+	}
+	args = append(args, "--env", EnvVar+"=1")
+	for _, e := range r.Env {
+		args = append(args, "--env", e)
+	}
+	args = append(args, r.Image)
+	return append(args, r.HarnessArgs...)
+}
@@ -0,0 +1,119 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "container.yaml")
+	os.WriteFile(path, []byte("node: 20.18.0\ngemini_cli: 0.9.0\ngcloud_sdk: 540.0.0\n"), 0o644)
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *p != (Pins{Node: "20.18.0", GeminiCLI: "0.9.0", GcloudSDK: "540.0.0"}) {
+		t.Errorf("Load() = %+v", p)
+	}
+
+	for name, content := range map[string]string{
+		"tag":     "node: 20.18.0\ngemini_cli: latest\ngcloud_sdk: 540.0.0\n",
+		"missing": "node: 20.18.0\ngemini_cli: 0.9.0\n",
+		"range":   "node: ^20\ngemini_cli: 0.9.0\ngcloud_sdk: 540.0.0\n",
+	} {
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: Load() succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "none.yaml")); err == nil {
+		t.Error("Load(missing file) succeeded")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	b := &Build{
+		Pins:    Pins{Node: "20.18.0", GeminiCLI: "0.9.0", GcloudSDK: "540.0.0"},
+		Servers: []string{"@google-cloud/gcloud-mcp@1.2.3", "@google-cloud/storage-mcp@0.4.0"},
+		Context: ".",
+		// The version is the harness's, not the image's contents, so it is
+		// not part of the tag.
+		HarnessVersion: "v1.2.0",
+	}
+	tag := b.Tag()
+	if !strings.HasPrefix(tag, "integration-test:") || len(tag) != len("integration-test:")+12 {
+		t.Errorf("Tag() = %s", tag)
+	}
+	bumped := *b
+	bumped.Servers = []string{"@google-cloud/gcloud-mcp@1.2.4", "@google-cloud/storage-mcp@0.4.0"}
+	if bumped.Tag() == tag {
+		t.Error("a server version bump kept the tag")
+	}
+	args := strings.Join(b.Args(), " ")
+	for _, want := range []string{
+		"build --tag " + tag,
+		"--build-arg NODE_VERSION=20.18.0",
+		"--build-arg GEMINI_CLI_VERSION=0.9.0",
+		"--build-arg GCLOUD_SDK_VERSION=540.0.0",
+		"--build-arg MCP_SERVERS=@google-cloud/gcloud-mcp@1.2.3 @google-cloud/storage-mcp@0.4.0",
+		"--build-arg HARNESS_VERSION=v1.2.0",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Args() = %s, missing %q", args, want)
+		}
+	}
+	if a := b.Args(); a[len(a)-1] != "." {
+		t.Errorf("Args() ends with %q, want the context", a[len(a)-1])
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	home := t.TempDir()
+	os.MkdirAll(filepath.Join(home, ".config", "gcloud"), 0o755)
+	adc := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(adc, []byte("{}"), 0o600)
+	env := map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": adc}
+
+	mounts, vars := Credentials(func(k string) string { return env[k] }, home)
+	want := []Mount{
+		{Source: filepath.Join(home, ".config", "gcloud"), Target: "/run/credentials/gcloud", ReadOnly: true},
+		{Source: adc, Target: "/run/credentials/application_default_credentials.json", ReadOnly: true},
+	}
+	if !slices.Equal(mounts, want) {
+		t.Errorf("mounts = %+v, want %+v", mounts, want)
+	}
+	if !slices.Equal(vars, []string{"GOOGLE_APPLICATION_CREDENTIALS=/run/credentials/application_default_credentials.json"}) {
+		t.Errorf("env = %q", vars)
+	}
+
+	// CLOUDSDK_CONFIG overrides the default directory, and a missing one is
+	// not mounted.
+	env = map[string]string{"CLOUDSDK_CONFIG": filepath.Join(home, "nowhere")}
+	if mounts, vars := Credentials(func(k string) string { return env[k] }, home); len(mounts) != 0 || len(vars) != 0 {
+		t.Errorf("Credentials() = %+v, %q, want nothing", mounts, vars)
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	r := &Run{
+		Image:       "integration-test:abc",
+		WorkDir:     "/src/tests/integration",
+		Mounts:      []Mount{{Source: "/home/me/.config/gcloud", Target: "/run/credentials/gcloud", ReadOnly: true}},
+		Env:         []string{"GOOGLE_CLOUD_PROJECT", "A=b"},
+		Terminal:    true,
+		HarnessArgs: []string{"-only", "gcloud-tool-call", "-in-container"},
+	}
+	got := strings.Join(r.Args(), " ")
+	want := "run --rm --init --interactive --tty" +
+		" --mount type=bind,source=/src/tests/integration,target=/src/tests/integration --workdir /src/tests/integration" +
+		" --mount type=bind,source=/home/me/.config/gcloud,target=/run/credentials/gcloud,readonly" +
+		" --env " + EnvVar + "=1 --env GOOGLE_CLOUD_PROJECT --env A=b" +
+		" integration-test:abc -only gcloud-tool-call -in-container"
+	if got != want {
+		t.Errorf("Args() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+// Package coverage checks that a run executed enough of each suite's
+// registered tests to mean something, so a run whose tests were mostly
+// filtered out or skipped is not reported as green.
+package coverage
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"slices"
+)
+
+// Policy declares a suite and the share of its tests a run must execute.
+type Policy struct {
+	Suite string
+	// Tests are test ID globs, matched with path.Match.
+	Tests []string
+	// MinExecuted is the minimum number of the suite's tests that must run.
+	MinExecuted int
+	// MinPercent is the minimum percentage, 0-100, of the suite's registered
+	// tests that must run. Both minimums apply.
+	MinPercent float64
+}
+
+// Members returns the IDs of registered that belong to the suite.
+func (p Policy) Members(registered []string) []string {
+	var ids []string
+	for _, id := range registered {
+		if slices.ContainsFunc(p.Tests, func(pattern string) bool {
+			ok, _ := path.Match(pattern, id)
+			return ok
+		}) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Required returns how many of n registered tests the policy requires to run.
+func (p Policy) Required(n int) int {
+	required := int(math.Ceil(p.MinPercent / 100 * float64(n)))
+	return min(n, max(required, p.MinExecuted))
+}
+
+// Result is the coverage of one suite in a run.
+type Result struct {
+	Suite      string `json:"suite"`
+	Registered int    `json:"registered"`
+	Executed   int    `json:"executed"`
+	Required   int    `json:"required"`
+	// NotExecuted lists the suite's tests that were filtered out or skipped.
+	NotExecuted []string `json:"not_executed,omitempty"`
+}
+
+// Met reports whether the suite executed enough tests.
+func (r Result) Met() bool {
+	return r.Executed >= r.Required
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("suite %s executed %d of %d tests, %d required", r.Suite, r.Executed, r.Registered, r.Required)
+}
+
+// Evaluate returns the coverage of every policy, given all registered test
+// IDs and whether each one executed, i.e. ran to a pass or failure rather
+// than being skipped or filtered out.
+func Evaluate(policies []Policy, registered []string, executed func(id string) bool) []Result {
+	results := make([]Result, len(policies))
+	for i, p := range policies {
+		members := p.Members(registered)
+		r := Result{Suite: p.Suite, Registered: len(members), Required: p.Required(len(members))}
+		for _, id := range members {
+			if executed(id) {
+				r.Executed++
+			} else {
+				r.NotExecuted = append(r.NotExecuted, id)
+			}
+		}
+		results[i] = r
+	}
+	return results
+}
+
+// Unmet returns the results that fall short of their policy.
+func Unmet(results []Result) []Result {
+	var out []Result
+	for _, r := range results {
+		if !r.Met() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
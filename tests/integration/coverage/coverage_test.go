@@ -0,0 +1,55 @@
+package coverage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		n      int
+		want   int
+	}{
+		{Policy{MinPercent: 50}, 5, 3},
+		{Policy{MinPercent: 100}, 4, 4},
+		{Policy{MinExecuted: 2, MinPercent: 10}, 10, 2},
+		// A suite cannot require more tests than it has.
+		{Policy{MinExecuted: 3}, 2, 2},
+		{Policy{}, 7, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Required(tt.n); got != tt.want {
+			t.Errorf("%+v.Required(%d) = %d, want %d", tt.policy, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	registered := []string{"gcloud-tool-call", "gcloud-iam-denied", "gcloud-denied-command", "tool-catalog-gcloud", "transport-parity"}
+	policies := []Policy{
+		{Suite: "gcloud", Tests: []string{"gcloud-*"}, MinPercent: 100},
+		{Suite: "catalog", Tests: []string{"tool-catalog-*"}, MinExecuted: 1},
+		{Suite: "protocol", Tests: []string{"transport-parity", "stdio-*"}, MinExecuted: 1},
+	}
+	ran := []string{"gcloud-tool-call", "gcloud-denied-command", "tool-catalog-gcloud"}
+	results := Evaluate(policies, registered, func(id string) bool { return slices.Contains(ran, id) })
+
+	gcloud := results[0]
+	if gcloud.Registered != 3 || gcloud.Executed != 2 || gcloud.Required != 3 || gcloud.Met() {
+		t.Errorf("gcloud = %+v, want 2 of 3 executed and unmet", gcloud)
+	}
+	if !slices.Equal(gcloud.NotExecuted, []string{"gcloud-iam-denied"}) {
+		t.Errorf("NotExecuted = %q", gcloud.NotExecuted)
+	}
+	if !results[1].Met() {
+		t.Errorf("catalog = %+v, want met", results[1])
+	}
+	unmet := Unmet(results)
+	if len(unmet) != 2 || unmet[0].Suite != "gcloud" || unmet[1].Suite != "protocol" {
+		t.Errorf("Unmet = %+v", unmet)
+	}
+	if got, want := gcloud.String(), "suite gcloud executed 2 of 3 tests, 3 required"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
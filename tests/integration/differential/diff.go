@@ -0,0 +1,56 @@
+package differential
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Diff returns a unified diff between the indented JSON of two normalized
+// results, labeled aName and bName, or "" if they are equal.
+func Diff(aName, bName string, a, b any) (string, error) {
+	aText, err := indent(a)
+	if err != nil {
+		return "", err
+	}
+	bText, err := indent(b)
+	if err != nil {
+		return "", err
+	}
+	return report.UnifiedDiff(aName, bName, aText, bText), nil
+}
+
+// Consistent checks that the results of repeating one call, e.g. a read-only
+// tool call, are equal once normalized by n. It returns a Failure with
+// ReasonInconsistent and the diff from the first result to the first that
+// differs, naming the call what.
+func (n *Normalizer) Consistent(what string, results []string) error {
+	if len(results) < 2 {
+		return nil
+	}
+	first := n.Normalize(results[0])
+	for i, r := range results[1:] {
+		message := fmt.Sprintf("assertion failed: %s returned a different result on call %d of %d than on the first", what, i+2, len(results))
+		if err := report.Compare(message, first, n.Normalize(r)); err != nil {
+			if m := report.MismatchOf(err); m != nil {
+				return &report.Failure{Reason: report.ReasonInconsistent, Err: m}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// indent encodes v as indented JSON with a trailing newline; a string, such
+// as a normalized result that was not JSON, is used as-is.
+func indent(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s + "\n", nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,52 @@
+package differential
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestDiff(t *testing.T) {
+	n := New(DefaultVolatileFields)
+	a := n.Normalize(`{"name":"b","etag":"1","size":10}`)
+	b := n.Normalize(`{"name":"b","etag":"2","size":12}`)
+	diff, err := Diff("released", "local", a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--- released", "+++ local", `-  "size": 10`, `+  "size": 12`} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff lacks %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, `-  "etag"`) {
+		t.Errorf("diff includes a volatile field:\n%s", diff)
+	}
+
+	same, err := Diff("released", "local", a, n.Normalize(`{"size":10,"etag":"3","name":"b"}`))
+	if err != nil || same != "" {
+		t.Errorf("Diff() of equal results = %q, %v", same, err)
+	}
+	if text, _ := Diff("a", "b", "plain", "plain"); text != "" {
+		t.Errorf("Diff() of equal text = %q", text)
+	}
+}
+
+func TestConsistent(t *testing.T) {
+	n := New(DefaultVolatileFields)
+	same := []string{`{"items":[1,2],"etag":"1"}`, `{"etag":"2","items":[1,2]}`, `{"items":[1,2],"etag":"3"}`}
+	if err := n.Consistent("list", same); err != nil {
+		t.Errorf("Consistent() of results differing in volatile fields = %v", err)
+	}
+	if err := n.Consistent("list", same[:1]); err != nil {
+		t.Errorf("Consistent() of one result = %v", err)
+	}
+	err := n.Consistent("list", append(same, `{"items":[2,1],"etag":"4"}`))
+	if report.ReasonOf(err) != report.ReasonInconsistent || !strings.Contains(err.Error(), "call 4 of 4") {
+		t.Fatalf("Consistent() of reordered items = %v, want an inconsistent_result failure on call 4", err)
+	}
+	if m := report.MismatchOf(err); m == nil || !strings.Contains(m.Diff, "-    1,") {
+		t.Errorf("mismatch = %+v, want a diff of the items", m)
+	}
+}
@@ -0,0 +1,79 @@
+// Package differential normalizes tool results so that the same call made
+// over different transports can be compared, ignoring fields that differ
+// from one call to the next.
+package differential
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces every volatile value.
+const Placeholder = "*volatile*"
+
+// DefaultVolatileFields are object keys whose values change between calls.
+var DefaultVolatileFields = []string{
+	"etag", "requestId", "traceId", "timestamp", "time", "timeCreated",
+	"updated", "createTime", "updateTime", "generation", "metageneration",
+}
+
+// timestamp matches RFC 3339 timestamps, which are volatile wherever they
+// appear.
+var timestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// Normalizer rewrites volatile parts of a tool result.
+type Normalizer struct {
+	fields map[string]bool
+}
+
+// New returns a Normalizer treating the given keys, compared
+// case-insensitively, as volatile.
+func New(fields []string) *Normalizer {
+	n := &Normalizer{fields: make(map[string]bool)}
+	for _, f := range fields {
+		n.fields[strings.ToLower(f)] = true
+	}
+	return n
+}
+
+// Normalize parses text as JSON and returns it with volatile values replaced
+// by Placeholder. String values that hold JSON themselves, such as the text
+// content of a tool result, are normalized recursively. Text that is not
+// JSON is returned with its timestamps replaced.
+func (n *Normalizer) Normalize(text string) any {
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return timestamp.ReplaceAllString(text, Placeholder)
+	}
+	return n.value(v)
+}
+
+func (n *Normalizer) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if n.fields[strings.ToLower(k)] {
+				v[k] = Placeholder
+				continue
+			}
+			v[k] = n.value(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = n.value(child)
+		}
+		return v
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var inner any
+			if json.Unmarshal([]byte(trimmed), &inner) == nil {
+				return n.value(inner)
+			}
+		}
+		return timestamp.ReplaceAllString(v, Placeholder)
+	}
+	return v
+}
@@ -0,0 +1,41 @@
+package differential
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	n := New(DefaultVolatileFields)
+	a := `{"content":[{"type":"text","text":"{\"name\":\"b\",\"ETag\":\"CAE=\",\"created\":\"2026-10-14T10:00:00.123Z\"}"}],"requestId":"1"}`
+	b := `{"content":[{"type":"text","text":"{\"name\":\"b\",\"ETag\":\"CAI=\",\"created\":\"2026-10-14T10:00:05Z\"}"}],"requestId":"2"}`
+	got, want := encode(t, n.Normalize(a)), encode(t, n.Normalize(b))
+	if got != want {
+		t.Errorf("normalized results differ:\n%s\n%s", got, want)
+	}
+	wantText := `{"content":[{"text":{"ETag":"*volatile*","created":"*volatile*","name":"b"},"type":"text"}],"requestId":"*volatile*"}`
+	if got != wantText {
+		t.Errorf("Normalize() = %s, want %s", got, wantText)
+	}
+
+	c := `{"content":[{"type":"text","text":"{\"name\":\"c\"}"}]}`
+	if encode(t, n.Normalize(a)) == encode(t, n.Normalize(c)) {
+		t.Error("results with different names normalized to the same value")
+	}
+}
+
+func TestNormalizePlainText(t *testing.T) {
+	n := New(nil)
+	if got := n.Normalize("done at 2026-10-14 10:00:00"); got != "done at "+Placeholder {
+		t.Errorf("Normalize() = %q", got)
+	}
+}
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
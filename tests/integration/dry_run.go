@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/redact"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// errDryRun refuses the commands a test would run in a dry run.
+var errDryRun = errors.New("not executed in a dry run")
+
+// standIn, if set, is the in-memory server a dry run connects each call to
+// in place of the call's own.
+var standIn func(call client.ToolCall) mcp.Transport
+
+// secretName matches the names of environment variables whose values a dry
+// run does not print.
+var secretName = regexp.MustCompile(`(?i)token|secret|password|passwd|api_?key|private_?key|credential`)
+
+// redactEnv returns env with the values of secret-looking variables replaced
+// and the secrets the redactor knows masked.
+func redactEnv(env []string) []string {
+	out := make([]string, len(env))
+	for i, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if secretName.MatchString(name) {
+			kv = name + "=" + redact.Mask
+		}
+		out[i] = redactor.String(kv)
+	}
+	return out
+}
+
+// dryRun prints the plan of a run of tests with opts: the configuration it
+// resolved to and, for each test, what it requires and the commands and tool
+// calls it makes, with the harness defaults applied. Every process a test
+// would start is refused, and every call goes to an in-memory stand-in that
+// lists it and returns an empty result, so nothing reaches GCP. A test runs
+// until a result it checks comes back empty; what it would call after that
+// depends on real results and is not listed.
+func dryRun(w io.Writer, tests []testCase, opts runOptions) int {
+	seed := opts.seed
+	if seed == 0 {
+		fmt.Fprintln(w, "🧪 Dry run without -seed: a real run draws a fresh seed, so random arguments will differ")
+	} else {
+		fmt.Fprintf(w, "🧪 Dry run with seed %d\n", seed)
+	}
+	if opts.gcloudSandbox != nil {
+		fmt.Fprintf(w, "   each test gets a fresh gcloud configuration for project %s, or for the configured one if it checks the project\n", opts.gcloudSandbox.Project)
+	}
+	current := platform.Current()
+
+	console := logger.Writer()
+	defer logger.SetOutput(console)
+	defer func() { subprocess.Default.Refuse, toolCallHook, standIn = nil, nil, nil }()
+	for _, tc := range tests {
+		fmt.Fprintf(w, "\n📝 %s\n", tc.id)
+		if tc.suite != nil {
+			fmt.Fprintf(w, "   suite:    %s (its setup is not run)\n", tc.suite.name)
+		}
+		if tags := testTags(tc); len(tags) > 0 {
+			fmt.Fprintf(w, "   tags:     %s\n", strings.Join(tags, ", "))
+		}
+		if entry := opts.quarantine.Lookup(tc.id); entry != nil {
+			fmt.Fprintf(w, "   quarantined until %s: %s\n", entry.Expires.UTC().Format(time.DateOnly), entry.Reason)
+		}
+		if !tc.platforms.Allows(current) {
+			fmt.Fprintf(w, "   skipped: runs only on %s, not %s\n", tc.platforms, current)
+			continue
+		}
+		for _, bin := range tc.requires {
+			path, err := exec.LookPath(bin)
+			if err != nil {
+				path = "missing, so the run would fail its requirements check"
+			}
+			fmt.Fprintf(w, "   requires: %s (%s)\n", bin, path)
+		}
+		for _, step := range tc.steps {
+			fmt.Fprintf(w, "   step:     %s\n", step)
+		}
+		// A test that carries on after a failed call, such as a conformance
+		// test, would list the same command for each check.
+		var planned []string
+		plan := func(line string) {
+			if !slices.Contains(planned, line) {
+				planned = append(planned, line)
+				fmt.Fprintln(w, line)
+			}
+		}
+		refused := false
+		subprocess.Default.Refuse = func(cmd *exec.Cmd) error {
+			refused = true
+			line := "   runs:     " + strings.Join(cmd.Args, " ")
+			if env := newEnv(redactEnv(cmd.Env)); len(env) > 0 {
+				line += " with " + strings.Join(env, " ")
+			}
+			plan(line)
+			return errDryRun
+		}
+		standIn = func(call client.ToolCall) mcp.Transport { return dryRunServer(call, plan) }
+		// The stand-in lists each call, so neither -differential nor -oracle
+		// repeats it.
+		toolCallHook = func(call client.ToolCall, _ func(client.ToolCall) (*client.Result, error)) (*client.Result, error) {
+			return client.InvokeMCPTool(call)
+		}
+		logger.SetOutput(io.Discard)
+		err := planTest(tc, seed)
+		logger.SetOutput(console)
+		switch {
+		case err != nil && refused:
+			fmt.Fprintln(w, "   (what follows depends on the command's result)")
+		case err != nil && len(planned) > 0:
+			first, _, _ := strings.Cut(err.Error(), "\n")
+			fmt.Fprintf(w, "   stops on the stand-in's empty result: %s\n   (what follows depends on real results)\n", first)
+		case err != nil:
+			fmt.Fprintf(w, "   stops before calling anything: %v\n", err)
+		}
+	}
+	return exitPass
+}
+
+// planTest runs tc against the caller's stand-ins until it returns.
+func planTest(tc testCase, seed int64) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked: %v", r)
+		}
+	}()
+	steps := &progress{test: tc.id}
+	t := &testContext{id: tc.id, board: blackboard.New(), rand: testRand(seed, tc.id), progress: steps}
+	return tc.run(t)
+}
+
+// dryRunServer starts an in-memory server for call's session that passes
+// each tools/call and tools/list request to plan instead of answering it, and
+// returns the client's end of the connection. Every call gets an empty
+// result, and the server has no tools.
+func dryRunServer(call client.ToolCall, plan func(line string)) mcp.Transport {
+	server := mcp.NewServer(&mcp.Implementation{Name: "dry-run", Version: "v0.0.1"}, &mcp.ServerOptions{HasTools: true})
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			switch method {
+			case "tools/list":
+				plan("   lists:    tools of " + strings.Join(call.ServerCmd, " "))
+			case "tools/call":
+				params, _ := req.GetParams().(*mcp.CallToolParamsRaw)
+				if params == nil {
+					break
+				}
+				call.ToolName, call.ToolArgs = params.Name, params.Arguments
+				plan("   calls:    " + describeCall(call))
+				return &mcp.CallToolResult{Content: []mcp.Content{}}, nil
+			}
+			return next(ctx, method, req)
+		}
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(context.Background(), serverTransport, nil); err != nil {
+		// The client's connect then fails on the closed pipe and reports it.
+		logf(slog.LevelError, "❌ could not start the dry run's stand-in for %s: %v\n", strings.Join(call.ServerCmd, " "), err)
+	}
+	return clientTransport
+}
+
+// newEnv returns the entries of env the harness adds to its own environment.
+func newEnv(env []string) []string {
+	own := map[string]bool{}
+	for _, kv := range redactEnv(os.Environ()) {
+		own[kv] = true
+	}
+	var out []string
+	for _, kv := range env {
+		if !own[kv] {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// describeCall returns the server, tool, arguments and settings of call.
+func describeCall(call client.ToolCall) string {
+	args, err := json.Marshal(call.ToolArgs)
+	if err != nil {
+		args = []byte(fmt.Sprint(call.ToolArgs))
+	}
+	parts := []string{strings.Join(call.ServerCmd, " "), call.ToolName, string(args)}
+	if len(call.Endpoints) > 0 {
+		endpoints := make([]string, len(call.Endpoints))
+		for i, e := range call.Endpoints {
+			endpoints[i] = e.String()
+		}
+		parts = append(parts, "over "+strings.Join(endpoints, ", then "))
+	}
+	if env := redactEnv(call.Env); len(env) > 0 {
+		parts = append(parts, "env "+strings.Join(env, " "))
+	}
+	if call.Dir != "" {
+		parts = append(parts, "in "+call.Dir)
+	}
+	if call.ImpersonateServiceAccount != "" {
+		parts = append(parts, "as "+call.ImpersonateServiceAccount)
+	}
+	if call.ExpectError != nil {
+		parts = append(parts, fmt.Sprintf("expecting an error containing %q", call.ExpectError.Message))
+	}
+	if limit := call.TokenLimit(); limit > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d tokens", limit))
+	}
+	return strings.Join(parts, " ")
+}
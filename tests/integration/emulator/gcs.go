@@ -0,0 +1,159 @@
+// Package emulator runs local stand-ins for Google Cloud services, so tests
+// of the servers built on them run without credentials or a real project.
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// GCSHostEnv is the variable Cloud Storage client libraries send their
+// requests to instead of storage.googleapis.com.
+const GCSHostEnv = "STORAGE_EMULATOR_HOST"
+
+// DefaultGCSBin is the fake-gcs-server executable StartGCS runs.
+const DefaultGCSBin = "fake-gcs-server"
+
+// readyTimeout bounds how long StartGCS waits for the emulator to answer.
+const readyTimeout = 30 * time.Second
+
+// GCS is a Cloud Storage emulator speaking the JSON API, either one StartGCS
+// started or one already running at Host.
+type GCS struct {
+	// Host is the emulator's base URL, e.g. http://127.0.0.1:4443.
+	Host string
+
+	procs *subprocess.Manager
+	cmd   *exec.Cmd
+}
+
+// StartGCS starts fake-gcs-server from bin with an in-memory backend on a free
+// local port, tracked by procs, and waits until it answers. Its output goes to
+// log.
+func StartGCS(ctx context.Context, bin string, procs *subprocess.Manager, log io.Writer) (*GCS, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	cmd := exec.Command(bin,
+		"-scheme", "http",
+		"-host", "127.0.0.1",
+		"-port", strconv.Itoa(port),
+		"-backend", "memory",
+		"-public-host", addr,
+		"-external-url", "http://"+addr,
+	)
+	cmd.Stdout, cmd.Stderr = log, log
+	if err := procs.Start(cmd); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", bin, err)
+	}
+	g := &GCS{Host: "http://" + addr, procs: procs, cmd: cmd}
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	if err := g.WaitReady(ctx); err != nil {
+		g.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// freePort returns a local TCP port no one listens on.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Env returns the environment that points a server's Cloud Storage client at
+// the emulator.
+func (g *GCS) Env() []string {
+	return []string{GCSHostEnv + "=" + g.Host}
+}
+
+// WaitReady polls the emulator until it lists buckets or ctx ends.
+func (g *GCS) WaitReady(ctx context.Context) error {
+	for {
+		err := g.do(ctx, http.MethodGet, "/storage/v1/b", "", nil)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("storage emulator at %s is not answering: %w", g.Host, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// CreateBucket creates bucket, which may exist already.
+func (g *GCS) CreateBucket(ctx context.Context, bucket string) error {
+	body, err := json.Marshal(map[string]string{"name": bucket})
+	if err != nil {
+		return err
+	}
+	err = g.do(ctx, http.MethodPost, "/storage/v1/b", "application/json", body)
+	if errors.Is(err, errConflict) {
+		return nil
+	}
+	return err
+}
+
+// Upload writes an object, replacing any of the same name.
+func (g *GCS) Upload(ctx context.Context, bucket, object, contentType string, data []byte) error {
+	path := "/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o?uploadType=media&name=" + url.QueryEscape(object)
+	return g.do(ctx, http.MethodPost, path, contentType, data)
+}
+
+// Close stops the emulator if StartGCS started it.
+func (g *GCS) Close() error {
+	if g.cmd == nil {
+		return nil
+	}
+	g.cmd.Process.Kill()
+	// The emulator exits with the signal, which is no error of its own.
+	g.procs.Wait(g.cmd)
+	g.cmd = nil
+	return nil
+}
+
+// errConflict is a 409 response, e.g. to creating a bucket that exists.
+var errConflict = errors.New("conflict")
+
+func (g *GCS) do(ctx context.Context, method, path, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, g.Host+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return errConflict
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package emulator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// fakeGCS answers like fake-gcs-server, recording the objects uploaded.
+type fakeGCS struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string]string
+	ready   time.Time
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case time.Now().Before(f.ready):
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b":
+		w.Write([]byte(`{"kind":"storage#buckets"}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/storage/v1/b":
+		body, _ := io.ReadAll(r.Body)
+		if f.buckets[string(body)] {
+			http.Error(w, "exists", http.StatusConflict)
+			return
+		}
+		f.buckets[string(body)] = true
+	case r.Method == http.MethodPost && r.URL.Query().Get("uploadType") == "media":
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path+"/"+r.URL.Query().Get("name")] = r.Header.Get("Content-Type") + ":" + string(body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGCS(t *testing.T) {
+	fake := &fakeGCS{buckets: map[string]bool{}, objects: map[string]string{}, ready: time.Now().Add(200 * time.Millisecond)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	g := &GCS{Host: srv.URL}
+	ctx := context.Background()
+
+	if err := g.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := g.CreateBucket(ctx, "test-bucket"); err != nil {
+			t.Fatalf("CreateBucket() = %v; an existing bucket is not an error", err)
+		}
+	}
+	if err := g.Upload(ctx, "test-bucket", "dir/hello world.txt", "text/plain", []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.objects["/upload/storage/v1/b/test-bucket/o/dir/hello world.txt"]; got != "text/plain:hi" {
+		t.Errorf("uploaded %q, objects %v", got, fake.objects)
+	}
+	if err := g.Upload(ctx, "test-bucket", "x", "text/plain", nil); err != nil {
+		t.Fatal(err)
+	}
+	if env := g.Env(); len(env) != 1 || env[0] != "STORAGE_EMULATOR_HOST="+srv.URL {
+		t.Errorf("Env() = %q", env)
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Close() of an emulator it did not start = %v", err)
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := (&GCS{Host: srv.URL}).WaitReady(ctx); err == nil {
+		t.Error("WaitReady() of an emulator that never answers succeeded")
+	}
+}
+
+func TestStartGCSMissingBinary(t *testing.T) {
+	if _, err := StartGCS(context.Background(), "no-such-fake-gcs-server", &subprocess.Manager{}, io.Discard); err == nil {
+		t.Error("StartGCS() of a missing binary succeeded")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/emulator"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// emulatorBucket is the bucket -use-emulators creates for the storage tests
+// unless -storage-bucket names another.
+const emulatorBucket = "emulator-test-bucket"
+
+// emulatorObjects are the objects the emulator's test bucket starts with.
+var emulatorObjects = []struct{ name, contentType, content string }{
+	{"hello.txt", "text/plain", "hello from the storage emulator\n"},
+	{"data/numbers.json", "application/json", `{"numbers":[1,2,3]}` + "\n"},
+}
+
+var (
+	// storageEmulator, with -use-emulators, is the Cloud Storage emulator
+	// storage-mcp's calls are pointed at.
+	storageEmulator *emulator.GCS
+
+	// services tracks the processes that live for the whole run, such as
+	// emulators, which the per-test check for leaked servers must not kill.
+	services = &subprocess.Manager{}
+)
+
+// startEmulators points storage-mcp at a Cloud Storage emulator seeded with
+// the test bucket: the one at $STORAGE_EMULATOR_HOST if set, else a
+// fake-gcs-server it starts. The returned function stops what it started.
+func startEmulators() (func(), error) {
+	ctx := context.Background()
+	if host := os.Getenv(emulator.GCSHostEnv); host != "" {
+		storageEmulator = &emulator.GCS{Host: host}
+		if err := storageEmulator.WaitReady(ctx); err != nil {
+			return nil, err
+		}
+		logger.Printf("🧪 Using the storage emulator at %s\n", host)
+	} else {
+		if _, err := exec.LookPath(emulator.DefaultGCSBin); err != nil {
+			return nil, fmt.Errorf("-use-emulators runs %s: %w; install it with `go install github.com/fsouza/fake-gcs-server@latest` or set $%s", emulator.DefaultGCSBin, err, emulator.GCSHostEnv)
+		}
+		g, err := emulator.StartGCS(ctx, emulator.DefaultGCSBin, services, emulatorLog{})
+		if err != nil {
+			return nil, err
+		}
+		storageEmulator = g
+		logger.Printf("🧪 Started the storage emulator at %s\n", g.Host)
+	}
+	if storageBucket == "" {
+		storageBucket = emulatorBucket
+	}
+	stop := func() {
+		storageEmulator.Close()
+		storageEmulator = nil
+	}
+	if err := storageEmulator.CreateBucket(ctx, storageBucket); err != nil {
+		stop()
+		return nil, fmt.Errorf("creating gs://%s in the storage emulator: %w", storageBucket, err)
+	}
+	for _, o := range emulatorObjects {
+		if err := storageEmulator.Upload(ctx, storageBucket, o.name, o.contentType, []byte(o.content)); err != nil {
+			stop()
+			return nil, fmt.Errorf("seeding gs://%s in the storage emulator: %w", storageBucket, err)
+		}
+	}
+	return stop, nil
+}
+
+// emulatorLog logs an emulator's output, which is of interest only when
+// debugging it, at debug level.
+type emulatorLog struct{}
+
+func (emulatorLog) Write(p []byte) (int, error) {
+	logf(slog.LevelDebug, "🐞 %s", p)
+	return len(p), nil
+}
+
+// emulatorEnv returns the environment that points the server started by
+// serverCmd at its emulator, if -use-emulators runs one for it.
+func emulatorEnv(serverCmd []string) []string {
+	if storageEmulator == nil || len(serverCmd) == 0 || filepath.Base(serverCmd[0]) != storageServer.Bin() {
+		return nil
+	}
+	// The emulator takes any project and needs no credentials.
+	return append(storageEmulator.Env(), "GOOGLE_CLOUD_PROJECT="+testProject)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/lease"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/projects"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Modes of -ephemeral-project.
+const (
+	ephemeralCreate = "create"
+	ephemeralPool   = "pool"
+)
+
+// provisionTimeout bounds creating or leasing a project, and deleting or
+// releasing it.
+const provisionTimeout = 5 * time.Minute
+
+// ephemeralProject confines the tests tagged destructive to a project of
+// their own, so the shared test project only ever sees tests that read it.
+// The project is created, or leased from the pool, before the first such
+// test, and deleted or released once the tests are done. A nil
+// *ephemeralProject runs every test in the test project.
+type ephemeralProject struct {
+	// Exactly one of provisioner and pool is set, by the mode.
+	provisioner *projects.Provisioner
+	pool        *projects.Pool
+
+	once  sync.Once
+	id    string
+	lease *lease.Lease
+	err   error
+	// down makes teardown run once, from the run's end or the watchdog's.
+	down sync.Once
+}
+
+// newEphemeralProject returns the ephemeralProject of -ephemeral-project
+// mode, or nil if mode is empty.
+func newEphemeralProject(mode, parent, billingAccount, pool, bucket string) (*ephemeralProject, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case ephemeralCreate:
+		if parent == "" {
+			return nil, fmt.Errorf("-ephemeral-project=%s needs -project-parent", mode)
+		}
+		return &ephemeralProject{provisioner: &projects.Provisioner{Parent: parent, BillingAccount: billingAccount}}, nil
+	case ephemeralPool:
+		var ids []string
+		for _, id := range strings.Split(pool, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 || bucket == "" {
+			return nil, fmt.Errorf("-ephemeral-project=%s needs -project-pool and -lease-bucket", mode)
+		}
+		if slices.Contains(ids, testProject) {
+			return nil, fmt.Errorf("-project-pool must not include the shared test project %s", testProject)
+		}
+		return &ephemeralProject{pool: &projects.Pool{Projects: ids, Locker: &lease.Locker{Bucket: bucket, Holder: runHolder()}}}, nil
+	}
+	return nil, fmt.Errorf("-ephemeral-project must be %s or %s, not %q", ephemeralCreate, ephemeralPool, mode)
+}
+
+// projectFor returns the project tc runs in: the ephemeral one if tc is
+// tagged destructive, provisioned on first use, or else the test project.
+// Once provisioning failed, it fails every destructive test rather than
+// running it in the test project.
+func (e *ephemeralProject) projectFor(tc testCase) (string, error) {
+	if e == nil || !slices.Contains(testTags(tc), tagDestructive) {
+		return testProject, nil
+	}
+	e.once.Do(e.provision)
+	if e.err != nil {
+		return "", report.Fail(report.ReasonPrerequisite, "no ephemeral project for destructive test %s: %v", tc.id, e.err)
+	}
+	return e.id, nil
+}
+
+func (e *ephemeralProject) provision() {
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+	if e.pool != nil {
+		e.id, e.lease, e.err = e.pool.Lease(ctx)
+		if e.err == nil {
+			logger.Printf("🏗️  Leased project %s from the pool for the destructive tests, until %s\n", e.id, e.lease.Expires.Format(time.RFC3339))
+		}
+		return
+	}
+	id := projects.NewID(runID, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+	logger.Printf("🏗️  Creating project %s for the destructive tests...\n", id)
+	if e.err = e.provisioner.Create(ctx, id); e.err != nil {
+		return
+	}
+	e.id = id
+}
+
+// teardown deletes the created project, or empties the leased one of test
+// resources and releases it. It does nothing if no destructive test ran, and
+// after the first call.
+func (e *ephemeralProject) teardown() {
+	if e == nil {
+		return
+	}
+	e.down.Do(e.release)
+}
+
+func (e *ephemeralProject) release() {
+	// A project still being provisioned is waited for, so it is deleted too,
+	// and no test provisions one after the teardown.
+	e.once.Do(func() { e.err = errors.New("the run has ended") })
+	if e.id == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+	if e.pool == nil {
+		if err := e.provisioner.Delete(ctx, e.id); err != nil {
+			logf(slog.LevelError, "❌ could not delete project %s: %v\n", e.id, err)
+			return
+		}
+		logger.Printf("🧹 Deleted project %s\n", e.id)
+		return
+	}
+	// The next run to lease the project finds it as it was created.
+	sweeper := &orphans.Sweeper{Project: e.id}
+	left, err := sweeper.Find(ctx, 0, time.Now())
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not list the test resources left in %s: %v\n", e.id, err)
+	}
+	for _, r := range left {
+		if err := sweeper.Delete(ctx, r); err != nil {
+			logf(slog.LevelError, "❌ could not delete %s left in %s: %v\n", r, e.id, err)
+		}
+	}
+	if err := e.lease.Release(ctx); err != nil {
+		logf(slog.LevelError, "❌ could not return project %s to the pool: %v\n", e.id, err)
+		return
+	}
+	logger.Printf("🧹 Returned project %s to the pool\n", e.id)
+}
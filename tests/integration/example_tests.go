@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The example-* tests exercise the harness against the embedded example
+// server, so they run anywhere, without GCP access or installed servers.
+
+// exampleServerCmd returns the command that serves the example server: this
+// binary's example-server subcommand.
+func exampleServerCmd() []string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	return []string{exe, "example-server"}
+}
+
+// runExampleServer implements `example-server`, serving the example server on
+// stdin and stdout.
+func runExampleServer(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test example-server")
+		return exitUsage
+	}
+	if err := exampleserver.Run(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	return exitPass
+}
+
+func testExampleEcho(t *testContext) error {
+	logger.Println("🚀 Starting example server echo test...")
+	text := fmt.Sprintf("hello %x", t.rand.Uint32())
+	result, err := t.invokeTool(client.ToolCall{
+		ServerCmd: exampleServerCmd(),
+		ToolName:  "echo",
+		ToolArgs:  exampleserver.EchoArgs{Text: text},
+	})
+	if err != nil {
+		return fmt.Errorf("error calling echo: %w", err)
+	}
+	got, err := resultText(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: echo returned different text", text, got); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: echo returned %q\n", got)
+	return nil
+}
+
+func testExampleAdd(t *testContext) error {
+	logger.Println("🚀 Starting example server structured content test...")
+	result, err := t.invokeTool(client.ToolCall{
+		ServerCmd:      exampleServerCmd(),
+		ToolName:       "add",
+		ToolArgs:       exampleserver.AddArgs{A: 2, B: 40},
+		ValidateOutput: true,
+	})
+	if err != nil {
+		return fmt.Errorf("error calling add: %w", err)
+	}
+	// The text content must carry the same sum, for clients that ignore
+	// structuredContent.
+	text, err := resultText(result)
+	if err != nil {
+		return err
+	}
+	var sum, mirrored exampleserver.Sum
+	if err := json.Unmarshal([]byte(text), &mirrored); err != nil {
+		return report.Fail(report.ReasonParse, "error parsing add text content: %v\nText: %s", err, text)
+	}
+	if err := result.DecodeStructured(&sum); err != nil {
+		return report.Fail(report.ReasonParse, "error decoding add structuredContent: %v\nOutput: %s", err, result.Output)
+	}
+	if err := report.Compare("assertion failed: add returned a wrong sum", exampleserver.Sum{Sum: 42}, sum); err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: add text content differs from its structuredContent", sum, mirrored); err != nil {
+		return err
+	}
+	logger.Println("✅ Assertion passed: add returned {\"sum\": 42}")
+	return nil
+}
+
+func testExampleCountdown(t *testContext) error {
+	logger.Println("🚀 Starting example server progress test...")
+	result, err := t.invokeTool(client.ToolCall{
+		ServerCmd: exampleServerCmd(),
+		ToolName:  "countdown",
+		ToolArgs:  exampleserver.CountdownArgs{From: 3},
+	})
+	if err != nil {
+		return fmt.Errorf("error calling countdown: %w", err)
+	}
+	got, err := resultText(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: countdown returned different text", "liftoff", got); err != nil {
+		return err
+	}
+	// Notifications still in flight when the session closes are lost, so
+	// only the order of those that arrived is checked.
+	last := 0.0
+	for _, n := range result.Notifications {
+		if n.Kind != client.NotificationProgress {
+			continue
+		}
+		if n.Progress <= last || n.Total != 3 {
+			return report.Fail(report.ReasonAssertion, "assertion failed: countdown reported %s after progress %g", n, last)
+		}
+		last = n.Progress
+	}
+	logger.Printf("✅ Assertion passed: countdown reported progress up to %g/3 and lifted off\n", last)
+	return nil
+}
+
+func testExampleNotifications(t *testContext) error {
+	logger.Println("🚀 Starting example server logging and list_changed notification test...")
+	session, err := t.openSession(client.ToolCall{ServerCmd: exampleServerCmd(), LogLevel: "debug"})
+	if err != nil {
+		return fmt.Errorf("opening session subscribed to debug logs failed: %w", err)
+	}
+	defer session.Close()
+	if _, err := session.CallTool("unlock", struct{}{}); err != nil {
+		return fmt.Errorf("error calling unlock: %w", err)
+	}
+	// The server sends both notifications before its result, but the client
+	// may handle them after it.
+	var changed, logged bool
+	for deadline := time.Now().Add(5 * time.Second); !(changed && logged) && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		for _, n := range session.Notifications() {
+			changed = changed || n.Kind == client.NotificationListChanged && n.Message == "tools"
+			logged = logged || n.Kind == client.NotificationLog && n.Level == "debug"
+		}
+	}
+	if !changed {
+		return report.Fail(report.ReasonAssertion, "assertion failed: unlock added a tool without a tools list_changed notification; got %v", session.Notifications())
+	}
+	if !logged {
+		return report.Fail(report.ReasonAssertion, "assertion failed: the session subscribed at debug got no debug log from unlock; got %v", session.Notifications())
+	}
+	if _, err := session.Tool(exampleserver.SecretTool); err != nil {
+		return report.Fail(report.ReasonAssertion, "assertion failed: tools/list after list_changed: %v", err)
+	}
+	result, err := session.CallTool(exampleserver.SecretTool, struct{}{})
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", exampleserver.SecretTool, err)
+	}
+	got, err := resultText(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: the unlocked tool returned different text", exampleserver.Secret, got); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: unlock logged at debug, announced tools/list_changed and added %s\n", exampleserver.SecretTool)
+	return nil
+}
+
+func testExampleChart(t *testContext) error {
+	logger.Println("🚀 Starting example server image content test...")
+	result, err := t.invokeTool(client.ToolCall{
+		ServerCmd: exampleServerCmd(),
+		ToolName:  "chart",
+		ToolArgs:  exampleserver.ChartArgs{Values: []float64{0.25, 0.5, 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("error calling chart: %w", err)
+	}
+	if result.IsError {
+		return report.Fail(report.ReasonToolError, "tool failed: %s", result.Output)
+	}
+	img, err := report.AssertHasImage(result, "image/png")
+	if err != nil {
+		return err
+	}
+	data, err := report.AssertHasEmbeddedResource(result, exampleserver.ChartDataURI)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: chart embedded different data", "index,value\n0,0.25\n1,0.5\n2,1\n", data.Text); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: chart returned a %d-byte PNG and its data\n", len(img.Data))
+	return nil
+}
+
+// resultText returns the text of every text content block of a successful
+// tool result, warning if the tool has pages that were not fetched.
+func resultText(result *client.Result) (string, error) {
+	if result.IsError {
+		return "", report.Fail(report.ReasonToolError, "tool failed: %s", result.Output)
+	}
+	if len(result.Content) == 0 {
+		return "", report.Fail(report.ReasonParse, "MCP output content is empty")
+	}
+	if result.Continuation != "" {
+		logf(slog.LevelWarn, "⚠️  The result has more pages than were fetched (next: %q); set ToolCall.Paging to fetch them\n", result.Continuation)
+	}
+	return result.Text(), nil
+}
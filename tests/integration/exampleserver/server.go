@@ -0,0 +1,185 @@
+// Package exampleserver is a small MCP server with toy tools that need no
+// cloud access. The harness serves it with `integration-test example-server`
+// so the docs, the example-* tests and newcomers can exercise every part of
+// the harness without a GCP project.
+package exampleserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os/exec"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Name is the implementation name the server reports.
+const Name = "example-server"
+
+// The note the find_note tool links to.
+const (
+	NoteURI  = "example://notes/welcome.txt"
+	NoteText = "Welcome to the example server.\n"
+)
+
+// The tool the unlock tool adds, and the text it returns.
+const (
+	SecretTool = "secret"
+	Secret     = "open sesame"
+)
+
+// The data the chart tool embeds alongside its image.
+const ChartDataURI = "example://charts/data.csv"
+
+// ChartArgs are the arguments of the chart tool.
+type ChartArgs struct {
+	Values []float64 `json:"values" jsonschema:"the bar heights, each between 0 and 1"`
+}
+
+// EchoArgs are the arguments of the echo tool.
+type EchoArgs struct {
+	Text string `json:"text" jsonschema:"the text to return"`
+}
+
+// AddArgs are the arguments of the add tool.
+type AddArgs struct {
+	A float64 `json:"a" jsonschema:"the first addend"`
+	B float64 `json:"b" jsonschema:"the second addend"`
+}
+
+// Sum is the structured result of the add tool.
+type Sum struct {
+	Sum float64 `json:"sum"`
+}
+
+// CountdownArgs are the arguments of the countdown tool.
+type CountdownArgs struct {
+	From int `json:"from" jsonschema:"the number to count down from, at most 10"`
+}
+
+// WaitArgs are the arguments of the wait tool.
+type WaitArgs struct {
+	Seconds int `json:"seconds" jsonschema:"how long to wait, at most 600"`
+}
+
+// New returns the example server with its tools:
+//
+//	echo       returns its text argument
+//	add        returns the sum of a and b as structured content
+//	countdown  reports a progress notification per step, then "liftoff"
+//	find_note  returns a resource_link to NoteURI, which resources/read serves
+//	chart      returns a PNG bar chart of values and their CSV as an embedded resource
+//	wait       runs `sleep seconds`, as gcloud-mcp runs gcloud, and kills it if the call is cancelled
+//	unlock     adds the secret tool, which announces a tools list_changed, and logs it at debug
+func New() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: Name, Version: "v0.1.0"}, nil)
+	readOnly := &mcp.ToolAnnotations{ReadOnlyHint: true}
+
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Returns the given text.", Annotations: readOnly},
+		func(_ context.Context, _ *mcp.CallToolRequest, args EchoArgs) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: args.Text}}}, nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "add", Description: "Adds two numbers.", Annotations: readOnly},
+		func(_ context.Context, _ *mcp.CallToolRequest, args AddArgs) (*mcp.CallToolResult, Sum, error) {
+			return nil, Sum{Sum: args.A + args.B}, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "countdown", Description: "Counts down to zero, reporting progress.", Annotations: readOnly},
+		func(ctx context.Context, req *mcp.CallToolRequest, args CountdownArgs) (*mcp.CallToolResult, any, error) {
+			if args.From < 0 || args.From > 10 {
+				return nil, nil, fmt.Errorf("from must be between 0 and 10, got %d", args.From)
+			}
+			if token := req.Params.GetProgressToken(); token != nil {
+				for i := range args.From {
+					req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+						ProgressToken: token,
+						Progress:      float64(i + 1),
+						Total:         float64(args.From),
+						Message:       fmt.Sprint(args.From - i),
+					})
+				}
+			}
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "liftoff"}}}, nil, nil
+		})
+	server.AddResource(&mcp.Resource{URI: NoteURI, Name: "welcome.txt", MIMEType: "text/plain"},
+		func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: req.Params.URI, MIMEType: "text/plain", Text: NoteText}}}, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "find_note", Description: "Links to the welcome note.", Annotations: readOnly},
+		func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{
+				&mcp.ResourceLink{URI: NoteURI, Name: "welcome.txt", MIMEType: "text/plain"},
+			}}, nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "chart", Description: "Draws a bar chart of the given values.", Annotations: readOnly},
+		func(_ context.Context, _ *mcp.CallToolRequest, args ChartArgs) (*mcp.CallToolResult, any, error) {
+			img, err := chart(args.Values)
+			if err != nil {
+				return nil, nil, err
+			}
+			var csv strings.Builder
+			csv.WriteString("index,value\n")
+			for i, v := range args.Values {
+				fmt.Fprintf(&csv, "%d,%g\n", i, v)
+			}
+			return &mcp.CallToolResult{Content: []mcp.Content{
+				&mcp.ImageContent{MIMEType: "image/png", Data: img},
+				&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: ChartDataURI, MIMEType: "text/csv", Text: csv.String()}},
+			}}, nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "wait", Description: "Waits for the given number of seconds.", Annotations: readOnly},
+		func(ctx context.Context, _ *mcp.CallToolRequest, args WaitArgs) (*mcp.CallToolResult, any, error) {
+			if args.Seconds < 0 || args.Seconds > 600 {
+				return nil, nil, fmt.Errorf("seconds must be between 0 and 600, got %d", args.Seconds)
+			}
+			// Cancelling the call cancels ctx, which kills sleep.
+			if err := exec.CommandContext(ctx, "sleep", fmt.Sprint(args.Seconds)).Run(); err != nil {
+				return nil, nil, fmt.Errorf("waiting: %w", err)
+			}
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "unlock", Description: "Adds the secret tool."},
+		func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+			// Adding a tool sends tools/list_changed to every session.
+			mcp.AddTool(server, &mcp.Tool{Name: SecretTool, Description: "Returns the secret.", Annotations: readOnly},
+				func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+					return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: Secret}}}, nil, nil
+				})
+			req.Session.Log(ctx, &mcp.LoggingMessageParams{Level: "debug", Logger: Name, Data: "unlocked " + SecretTool})
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "unlocked"}}}, nil, nil
+		})
+	return server
+}
+
+// chart draws values as 8-pixel-wide bars of a 32-pixel-high PNG.
+func chart(values []float64) ([]byte, error) {
+	const width, height = 8, 32
+	if len(values) == 0 || len(values) > 50 {
+		return nil, fmt.Errorf("values must have between 1 and 50 entries, got %d", len(values))
+	}
+	img := image.NewGray(image.Rect(0, 0, width*len(values), height))
+	for i, v := range values {
+		if v < 0 || v > 1 {
+			return nil, fmt.Errorf("values[%d] must be between 0 and 1, got %g", i, v)
+		}
+		for y := height - int(v*height); y < height; y++ {
+			for x := i * width; x < (i+1)*width-1; x++ {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Run serves the example server on stdin and stdout until the client
+// disconnects.
+func Run(ctx context.Context) error {
+	return New().Run(ctx, &mcp.StdioTransport{})
+}
@@ -0,0 +1,99 @@
+package exampleserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connect returns a client session with the example server over in-memory
+// transports, sending progress messages to progress.
+func connect(t *testing.T, progress chan<- string) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := New().Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	c := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			progress <- req.Params.Message
+		},
+	})
+	cs, err := c.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func TestTools(t *testing.T) {
+	progress := make(chan string, 10)
+	cs := connect(t, progress)
+	ctx := context.Background()
+
+	tests := []struct {
+		tool string
+		args any
+		want string
+	}{
+		{"echo", EchoArgs{Text: "hello"}, `"text":"hello"`},
+		{"add", AddArgs{A: 2, B: 40}, `"structuredContent":{"sum":42}`},
+		{"countdown", CountdownArgs{From: 3}, `"text":"liftoff"`},
+		{"find_note", struct{}{}, `"uri":"` + NoteURI + `"`},
+		{"chart", ChartArgs{Values: []float64{0.5, 1}}, `"uri":"` + ChartDataURI + `"`},
+		{"wait", WaitArgs{Seconds: 0}, `"text":"done"`},
+		{"unlock", struct{}{}, `"text":"unlocked"`},
+		{SecretTool, struct{}{}, `"text":"` + Secret + `"`},
+	}
+	for _, tt := range tests {
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: tt.tool, Arguments: tt.args, Meta: mcp.Meta{"progressToken": tt.tool}})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.tool, err)
+		}
+		out, _ := json.Marshal(res)
+		if res.IsError || !strings.Contains(string(out), tt.want) {
+			t.Errorf("%s = %s, want it to contain %s", tt.tool, out, tt.want)
+		}
+	}
+	// Notifications may arrive after the result.
+	var got []string
+	for len(got) < 3 {
+		select {
+		case m := <-progress:
+			got = append(got, m)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("countdown progress = %q, want 3 messages", got)
+		}
+	}
+	if strings.Join(got, ",") != "3,2,1" {
+		t.Errorf("countdown progress = %q, want 3, 2, 1", got)
+	}
+}
+
+func TestReadNote(t *testing.T) {
+	cs := connect(t, make(chan string, 10))
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: NoteURI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Contents) != 1 || res.Contents[0].Text != NoteText {
+		t.Errorf("ReadResource = %+v", res.Contents)
+	}
+}
+
+func TestCountdownRejectsLongCounts(t *testing.T) {
+	cs := connect(t, make(chan string, 10))
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "countdown", Arguments: CountdownArgs{From: 11}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Errorf("countdown from 11 succeeded: %+v", res)
+	}
+}
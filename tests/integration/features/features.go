@@ -0,0 +1,168 @@
+// Package features gates experimental harness behavior behind named flags,
+// so a large subsystem can be merged dark and enabled per environment before
+// it is on by default.
+//
+// Packages register their flags at init time and query them with Enabled;
+// the runner enables flags from a YAML file and the INTEGRATION_FEATURES
+// environment variable before any test runs, and records the active ones in
+// the run results.
+package features
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// EnvVar is the environment variable holding a comma-separated list of flags
+// to enable. A name prefixed with "-" disables the flag instead.
+const EnvVar = "INTEGRATION_FEATURES"
+
+// Flag is a registered feature flag.
+type Flag struct {
+	Name        string
+	Description string
+}
+
+// Active is a flag enabled for the run and where it was enabled.
+type Active struct {
+	Name string `json:"name"`
+	// Source is the file or environment variable that enabled the flag.
+	Source string `json:"source"`
+}
+
+// Set is a registry of flags and their state. It is safe for concurrent use.
+type Set struct {
+	mu      sync.Mutex
+	flags   []Flag
+	enabled map[string]string // name to source
+}
+
+// Default is the set Register, Enabled and the runner use.
+var Default = &Set{}
+
+// Register adds flags to the default set.
+func Register(flags ...Flag) { Default.Register(flags...) }
+
+// Enabled reports whether the flag is enabled in the default set.
+func Enabled(name string) bool { return Default.Enabled(name) }
+
+// Register adds flags, which start disabled. Registering a name twice
+// panics.
+func (s *Set) Register(flags ...Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range flags {
+		if s.known(f.Name) {
+			panic("features: flag " + f.Name + " registered twice")
+		}
+		s.flags = append(s.flags, f)
+	}
+}
+
+// Enabled reports whether the flag is enabled. Querying an unregistered
+// flag panics, as it means the flag's name is misspelt.
+func (s *Set) Enabled(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known(name) {
+		panic("features: unknown flag " + name)
+	}
+	_, ok := s.enabled[name]
+	return ok
+}
+
+// Flags returns the registered flags sorted by name.
+func (s *Set) Flags() []Flag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flags := slices.Clone(s.flags)
+	slices.SortFunc(flags, func(a, b Flag) int { return strings.Compare(a.Name, b.Name) })
+	return flags
+}
+
+// Active returns the enabled flags sorted by name.
+func (s *Set) Active() []Active {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Active
+	for name, source := range s.enabled {
+		out = append(out, Active{Name: name, Source: source})
+	}
+	slices.SortFunc(out, func(a, b Active) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// set enables or disables a flag on behalf of source.
+func (s *Set) set(name string, on bool, source string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known(name) {
+		return fmt.Errorf("%s: unknown feature flag %q", source, name)
+	}
+	if !on {
+		delete(s.enabled, name)
+		return nil
+	}
+	if s.enabled == nil {
+		s.enabled = make(map[string]string)
+	}
+	s.enabled[name] = source
+	return nil
+}
+
+func (s *Set) known(name string) bool {
+	return slices.ContainsFunc(s.flags, func(f Flag) bool { return f.Name == name })
+}
+
+// file is the format of a feature flag file.
+type file struct {
+	Features map[string]bool `yaml:"features"`
+}
+
+// Load applies a flag file, which maps flag names to whether they are enabled
+// under a "features" key. The file may be missing if optional is set (see
+// config.Read).
+func (s *Set) Load(path string, optional bool) error {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return err
+	}
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to parse feature flag file %s: %w", path, err)
+	}
+	names := make([]string, 0, len(f.Features))
+	for name := range f.Features {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := s.set(name, f.Features[name], path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse applies a comma-separated list of flags to enable, as found in
+// EnvVar, on behalf of source. Names prefixed with "-" are disabled, so the
+// environment can turn off a flag a file enabled.
+func (s *Set) Parse(list, source string) error {
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		on := !strings.HasPrefix(name, "-")
+		if err := s.set(strings.TrimPrefix(name, "-"), on, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newSet() *Set {
+	s := &Set{}
+	s.Register(Flag{Name: "chaos"}, Flag{Name: "http-transport"}, Flag{Name: "e2e"})
+	return s
+}
+
+func TestLoadAndParse(t *testing.T) {
+	s := newSet()
+	path := filepath.Join(t.TempDir(), "features.yaml")
+	if err := os.WriteFile(path, []byte("features:\n  chaos: true\n  http-transport: true\n  e2e: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(path, false); err != nil {
+		t.Fatal(err)
+	}
+	// The environment is applied after the file and overrides it.
+	if err := s.Parse("e2e, -chaos", EnvVar); err != nil {
+		t.Fatal(err)
+	}
+	want := []Active{{Name: "e2e", Source: EnvVar}, {Name: "http-transport", Source: path}}
+	if got := s.Active(); !slices.Equal(got, want) {
+		t.Errorf("Active() = %+v, want %+v", got, want)
+	}
+	if s.Enabled("chaos") || !s.Enabled("e2e") {
+		t.Errorf("Enabled(chaos) = %v, Enabled(e2e) = %v", s.Enabled("chaos"), s.Enabled("e2e"))
+	}
+}
+
+func TestUnknownFlags(t *testing.T) {
+	s := newSet()
+	if err := s.Parse("chaoss", EnvVar); err == nil || !strings.Contains(err.Error(), `unknown feature flag "chaoss"`) {
+		t.Errorf("Parse(chaoss) = %v, want an unknown flag error", err)
+	}
+	if err := s.Load(filepath.Join(t.TempDir(), "missing.yaml"), true); err != nil {
+		t.Errorf("Load of a missing optional file = %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Enabled of an unregistered flag did not panic")
+		}
+	}()
+	s.Enabled("chaoss")
+}
@@ -0,0 +1,294 @@
+// Package fingerprint records what each server under test actually ran as:
+// the executable its command resolved to, the npm package and version behind
+// it and a hash of the dependency lockfile of its install. Comparing a run's
+// fingerprints with the previous run's tells a server that changed because
+// its configuration did from one that drifted by itself, e.g. a `latest`
+// install picking up a new release or a transitive dependency.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Fingerprint is the runtime environment of one server.
+type Fingerprint struct {
+	Server string `json:"server"`
+	// Path is the executable the server's command resolved to, following
+	// symlinks.
+	Path string `json:"path,omitempty"`
+	// Package and Version identify the npm package providing it, if any.
+	Package string `json:"package,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Lockfile is the SHA-256 of the install's dependency lockfile, if it
+	// has one.
+	Lockfile string `json:"lockfile,omitempty"`
+	// Config is the SHA-256 of the server's configuration in the harness:
+	// whatever a deliberate change to how it is installed or launched
+	// touches.
+	Config string `json:"config"`
+}
+
+// Change is one field of a fingerprint that differs between runs.
+type Change struct {
+	Field string `json:"field"`
+	Was   string `json:"was"`
+	Now   string `json:"now"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s → %s", c.Field, short(c.Was), short(c.Now))
+}
+
+// short abbreviates hashes.
+func short(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	if len(s) == sha256.Size*2 {
+		return s[:12]
+	}
+	return s
+}
+
+// Drift is a server whose environment changed while its configuration did
+// not.
+type Drift struct {
+	Server  string   `json:"server"`
+	Changes []Change `json:"changes"`
+}
+
+func (d Drift) String() string {
+	changes := make([]string, len(d.Changes))
+	for i, c := range d.Changes {
+		changes[i] = c.String()
+	}
+	return fmt.Sprintf("%s environment changed without a config change: %s", d.Server, strings.Join(changes, ", "))
+}
+
+// npxCache is npm's cache of npx installs; a var for tests.
+var npxCache = func() string {
+	if dir := os.Getenv("npm_config_cache"); dir != "" {
+		return filepath.Join(dir, "_npx")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".npm", "_npx")
+}
+
+// lockfiles are the files an npm install records its dependency tree in, in
+// order of preference.
+var lockfiles = []string{"npm-shrinkwrap.json", "package-lock.json", filepath.Join("node_modules", ".package-lock.json")}
+
+// Take fingerprints server, launched by command, whose configuration is
+// config, e.g. its registration and manifest entry. The parts of the
+// environment it cannot resolve are left empty.
+func Take(server string, command []string, config any) (Fingerprint, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("failed to encode the configuration of %s: %w", server, err)
+	}
+	fp := Fingerprint{Server: server, Config: hash(data)}
+	if len(command) == 0 {
+		return fp, nil
+	}
+	if path, err := exec.LookPath(command[0]); err == nil {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		fp.Path = path
+	}
+	var root string
+	if filepath.Base(command[0]) == "npx" {
+		root = npxInstall(npxPackage(command[1:]))
+	} else if fp.Path != "" {
+		root = packageRoot(filepath.Dir(fp.Path))
+	}
+	if root == "" {
+		return fp, nil
+	}
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil && json.Unmarshal(data, &pkg) == nil {
+		fp.Package, fp.Version = pkg.Name, pkg.Version
+	}
+	fp.Lockfile = lockfileHash(root)
+	return fp, nil
+}
+
+// packageRoot returns the nearest directory at or above dir with a
+// package.json, or "".
+func packageRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// npxPackage returns the package an npx command line runs: the first
+// argument that is not a flag, without a version suffix.
+func npxPackage(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-p" || arg == "--package":
+			if i+1 < len(args) {
+				return stripVersion(args[i+1])
+			}
+		case strings.HasPrefix(arg, "--package="):
+			return stripVersion(strings.TrimPrefix(arg, "--package="))
+		case !strings.HasPrefix(arg, "-"):
+			return stripVersion(arg)
+		}
+	}
+	return ""
+}
+
+// stripVersion turns @scope/name@1.2.3 into @scope/name.
+func stripVersion(spec string) string {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// npxInstall returns the directory of the most recently updated npx install
+// of pkg, or "".
+func npxInstall(pkg string) string {
+	cache := npxCache()
+	if pkg == "" || cache == "" {
+		return ""
+	}
+	entries, err := os.ReadDir(cache)
+	if err != nil {
+		return ""
+	}
+	var best string
+	var bestTime int64
+	for _, e := range entries {
+		dir := filepath.Join(cache, e.Name(), "node_modules", filepath.FromSlash(pkg))
+		info, err := os.Stat(filepath.Join(dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		if t := info.ModTime().UnixNano(); best == "" || t > bestTime {
+			best, bestTime = dir, t
+		}
+	}
+	return best
+}
+
+// lockfileHash hashes the lockfile of the install containing root: its own,
+// or that of the npx install or global node_modules it sits in.
+func lockfileHash(root string) string {
+	for dir := root; ; {
+		for _, name := range lockfiles {
+			if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+				return hash(data)
+			}
+		}
+		// Climb out of node_modules/<pkg> or node_modules/@scope/<pkg>.
+		parent := filepath.Dir(dir)
+		if filepath.Base(parent) != "node_modules" {
+			parent = filepath.Dir(parent)
+		}
+		if filepath.Base(parent) != "node_modules" {
+			return ""
+		}
+		dir = filepath.Dir(parent)
+	}
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Compare returns the servers of current whose fingerprint differs from the
+// one in previous with the same configuration. A server that is new, or
+// whose configuration changed, is not drift.
+func Compare(previous, current []Fingerprint) []Drift {
+	var drift []Drift
+	for _, now := range current {
+		i := slices.IndexFunc(previous, func(f Fingerprint) bool { return f.Server == now.Server })
+		if i < 0 || previous[i].Config != now.Config {
+			continue
+		}
+		was := previous[i]
+		var changes []Change
+		for _, f := range []struct{ name, was, now string }{
+			{"path", was.Path, now.Path},
+			{"package", was.Package, now.Package},
+			{"version", was.Version, now.Version},
+			{"lockfile", was.Lockfile, now.Lockfile},
+		} {
+			if f.was != f.now {
+				changes = append(changes, Change{Field: f.name, Was: f.was, Now: f.now})
+			}
+		}
+		if len(changes) > 0 {
+			drift = append(drift, Drift{Server: now.Server, Changes: changes})
+		}
+	}
+	return drift
+}
+
+// Load reads the fingerprints Save wrote. A missing file holds none.
+func Load(path string) ([]Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fps []Fingerprint
+	if err := json.Unmarshal(data, &fps); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprints %s: %w", path, err)
+	}
+	return fps, nil
+}
+
+// Save writes fps to path, replacing the earlier fingerprints of the same
+// servers and keeping the others, so a run of some servers does not forget
+// the rest.
+func Save(path string, fps []Fingerprint) error {
+	previous, err := Load(path)
+	if err != nil {
+		return err
+	}
+	merged := slices.Clone(fps)
+	for _, p := range previous {
+		if !slices.ContainsFunc(fps, func(f Fingerprint) bool { return f.Server == p.Server }) {
+			merged = append(merged, p)
+		}
+	}
+	slices.SortFunc(merged, func(a, b Fingerprint) int { return strings.Compare(a.Server, b.Server) })
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
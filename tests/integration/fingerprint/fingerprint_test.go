@@ -0,0 +1,125 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTakeGlobalInstall(t *testing.T) {
+	prefix := t.TempDir()
+	pkg := filepath.Join(prefix, "lib", "node_modules", "@google-cloud", "gcloud-mcp")
+	writeFile(t, filepath.Join(pkg, "package.json"), `{"name": "@google-cloud/gcloud-mcp", "version": "0.3.1"}`)
+	writeFile(t, filepath.Join(pkg, "node_modules", ".package-lock.json"), `{"lockfileVersion": 3}`)
+	writeFile(t, filepath.Join(pkg, "dist", "bin.js"), "#!/bin/sh\n")
+	bin := filepath.Join(prefix, "bin")
+	os.MkdirAll(bin, 0o755)
+	if err := os.Symlink(filepath.Join(pkg, "dist", "bin.js"), filepath.Join(bin, "gcloud-mcp")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	fp, err := Take("gcloud", []string{"gcloud-mcp"}, map[string]string{"version": "latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Fingerprint{
+		Server:   "gcloud",
+		Path:     filepath.Join(pkg, "dist", "bin.js"),
+		Package:  "@google-cloud/gcloud-mcp",
+		Version:  "0.3.1",
+		Lockfile: hash([]byte(`{"lockfileVersion": 3}`)),
+		Config:   hash([]byte(`{"version":"latest"}`)),
+	}
+	if resolved, err := filepath.EvalSymlinks(want.Path); err == nil {
+		want.Path = resolved
+	}
+	if fp != want {
+		t.Errorf("Take = %+v\nwant %+v", fp, want)
+	}
+}
+
+func TestTakeNpx(t *testing.T) {
+	cache := t.TempDir()
+	defer func(saved func() string) { npxCache = saved }(npxCache)
+	npxCache = func() string { return cache }
+	install := filepath.Join(cache, "0123abcd")
+	writeFile(t, filepath.Join(install, "package-lock.json"), `{"packages": {}}`)
+	writeFile(t, filepath.Join(install, "node_modules", "@google-cloud", "storage-mcp", "package.json"), `{"name": "@google-cloud/storage-mcp", "version": "1.0.0"}`)
+
+	fp, _ := Take("storage", []string{"npx", "-y", "@google-cloud/storage-mcp@latest"}, nil)
+	if fp.Package != "@google-cloud/storage-mcp" || fp.Version != "1.0.0" || fp.Lockfile != hash([]byte(`{"packages": {}}`)) {
+		t.Errorf("Take = %+v", fp)
+	}
+}
+
+func TestTakeUnresolved(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	fp, err := Take("gcloud", []string{"gcloud-mcp"}, nil)
+	if err != nil || fp.Path != "" || fp.Version != "" || fp.Config == "" {
+		t.Errorf("Take = %+v, %v", fp, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	previous := []Fingerprint{
+		{Server: "gcloud", Version: "0.3.0", Lockfile: "a", Config: "c1"},
+		{Server: "storage", Version: "1.0.0", Config: "c2"},
+		{Server: "observability", Version: "1.0.0", Config: "c3"},
+	}
+	current := []Fingerprint{
+		{Server: "gcloud", Version: "0.3.1", Lockfile: "b", Config: "c1"},
+		// A deliberate change: the configuration changed too.
+		{Server: "storage", Version: "2.0.0", Config: "c2'"},
+		{Server: "observability", Version: "1.0.0", Config: "c3"},
+		{Server: "new", Version: "1.0.0", Config: "c4"},
+	}
+	want := []Drift{{Server: "gcloud", Changes: []Change{{"version", "0.3.0", "0.3.1"}, {"lockfile", "a", "b"}}}}
+	got := Compare(previous, current)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Compare = %+v, want %+v", got, want)
+	}
+	if s := got[0].String(); s != "gcloud environment changed without a config change: version 0.3.0 → 0.3.1, lockfile a → b" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestSaveKeepsOtherServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "fingerprints.json")
+	if fps, err := Load(path); err != nil || fps != nil {
+		t.Fatalf("Load(missing) = %v, %v", fps, err)
+	}
+	if err := Save(path, []Fingerprint{{Server: "gcloud", Version: "1"}, {Server: "storage", Version: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, []Fingerprint{{Server: "gcloud", Version: "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	fps, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Fingerprint{{Server: "gcloud", Version: "2"}, {Server: "storage", Version: "1"}}
+	if !reflect.DeepEqual(fps, want) {
+		t.Errorf("Load = %+v, want %+v", fps, want)
+	}
+}
+
+func TestChangeAbbreviatesHashes(t *testing.T) {
+	c := Change{Field: "lockfile", Was: "", Now: strings.Repeat("ab", 32)}
+	if got := c.String(); got != "lockfile (none) → abababababab" {
+		t.Errorf("String = %q", got)
+	}
+}
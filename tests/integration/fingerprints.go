@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/registry"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// defaultFingerprintFile is where fingerprintServers keeps the previous
+// run's fingerprints, or "" without a user cache directory.
+func defaultFingerprintFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gcloud-mcp-integration", "fingerprints.json")
+}
+
+// serverConfig is what a deliberate change to how a server is installed or
+// launched touches: its registration and its manifest entry.
+type serverConfig struct {
+	Registration registry.Server   `json:"registration"`
+	Manifest     *bootstrap.Server `json:"manifest,omitempty"`
+}
+
+// fingerprintServers records on results the fingerprint of every registered
+// server and, if path is set, the drift since the fingerprints in path, which
+// it then replaces. Failing to read or write path is logged and otherwise
+// ignored.
+func fingerprintServers(results *report.Run, path string) {
+	for _, s := range serverRegistry.All() {
+		config := serverConfig{Registration: *s}
+		for i, m := range servers.Servers {
+			if m.Name == s.Name {
+				config.Manifest = &servers.Servers[i]
+			}
+		}
+		fp, err := fingerprint.Take(s.Name, s.Command, config)
+		if err != nil {
+			logf(slog.LevelWarn, "⚠️  could not fingerprint %s: %v\n", s.Name, err)
+			continue
+		}
+		results.Fingerprints = append(results.Fingerprints, fp)
+	}
+	if path == "" {
+		return
+	}
+	previous, err := fingerprint.Load(path)
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not read the previous fingerprints: %v\n", err)
+	}
+	results.Drift = fingerprint.Compare(previous, results.Fingerprints)
+	if err := fingerprint.Save(path, results.Fingerprints); err != nil {
+		logf(slog.LevelWarn, "⚠️  could not save the fingerprints: %v\n", err)
+	}
+}
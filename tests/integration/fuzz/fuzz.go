@@ -0,0 +1,237 @@
+// Package fuzz generates boundary and malformed arguments for a tool from the
+// input schema it lists: wrong types, missing required properties, huge
+// strings and arrays, extreme numbers and arguments that are not an object at
+// all. A server must answer each with a result or a structured error; one that
+// crashes or hangs on them has a bug a careless client will eventually find.
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Sizes of the oversized values.
+const (
+	HugeString = 1 << 20
+	HugeArray  = 10000
+	DeepNest   = 512
+)
+
+// Case is one set of arguments to call a tool with.
+type Case struct {
+	// Name says what the case varies, e.g. "missing text".
+	Name string
+	// Args is the arguments value, which need not be an object.
+	Args json.RawMessage
+	// Invalid is set if Args violate the schema, so a correct server must
+	// reject them.
+	Invalid bool
+}
+
+// schema is the part of a JSON schema the generator understands.
+type schema struct {
+	Type                 any                `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Enum                 []any              `json:"enum"`
+	Default              any                `json:"default"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties any                `json:"additionalProperties"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MaxLength            *int               `json:"maxLength"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+// kind returns the schema's type, the first non-null one of a list.
+func (s *schema) kind() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if v, ok := v.(string); ok && v != "null" {
+				return v
+			}
+		}
+	}
+	if s.Properties != nil {
+		return "object"
+	}
+	return ""
+}
+
+func (s *schema) closed() bool {
+	return s.AdditionalProperties == false
+}
+
+// Cases returns the cases for a tool with inputSchema, as decoded from
+// tools/list. Each varies one thing of a minimal valid set of arguments, so
+// a rejection can be told apart from the server rejecting everything.
+func Cases(inputSchema any) ([]Case, error) {
+	data, err := json.Marshal(inputSchema)
+	if err != nil {
+		return nil, err
+	}
+	var root schema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse input schema: %w", err)
+	}
+	base := root.minimal()
+	var cases []Case
+	add := func(name string, args any, invalid bool) {
+		data, err := json.Marshal(args)
+		if err != nil {
+			panic(fmt.Sprintf("fuzz: case %s: %v", name, err))
+		}
+		cases = append(cases, Case{Name: name, Args: data, Invalid: invalid})
+	}
+
+	add("empty arguments", map[string]any{}, len(root.Required) > 0)
+	add("null arguments", nil, len(root.Required) > 0)
+	add("string arguments", "fuzz", true)
+	add("array arguments", []any{base}, true)
+	add("number arguments", 42, true)
+
+	names := make([]string, 0, len(root.Properties))
+	for name := range root.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range root.Required {
+		if _, ok := root.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		prop := root.Properties[name]
+		if prop == nil {
+			prop = &schema{}
+		}
+		set := func(v any) map[string]any { return with(base, name, v) }
+		if slices.Contains(root.Required, name) {
+			args := maps.Clone(base)
+			delete(args, name)
+			add("missing "+name, args, true)
+		}
+		add("null "+name, set(nil), !nullable(prop))
+		if v, ok := wrongType(prop); ok {
+			add("wrong type "+name, set(v), true)
+		}
+		switch prop.kind() {
+		case "string":
+			add("huge "+name, set(strings.Repeat("x", HugeString)), prop.MaxLength != nil && *prop.MaxLength < HugeString || len(prop.Enum) > 0)
+			add("control characters in "+name, set("\x00\x1b[2J\u202e\ufffd"), len(prop.Enum) > 0)
+			if len(prop.Enum) > 0 {
+				add("value outside the enum of "+name, set("fuzz-not-in-enum"), true)
+			}
+		case "number", "integer":
+			for _, v := range []float64{1e308, -1e308} {
+				invalid := prop.Minimum != nil && v < *prop.Minimum || prop.Maximum != nil && v > *prop.Maximum || len(prop.Enum) > 0
+				add(fmt.Sprintf("%s = %g", name, v), set(v), invalid)
+			}
+			if prop.kind() == "integer" {
+				add("fractional "+name, set(0.5), true)
+			}
+		case "array":
+			items := make([]any, HugeArray)
+			for i := range items {
+				items[i] = prop.Items.value()
+			}
+			add("huge "+name, set(items), prop.MaxItems != nil && *prop.MaxItems < HugeArray)
+		}
+	}
+
+	add("unknown property", with(base, "fuzz_unknown", true), root.closed())
+	add("deeply nested property", with(base, "fuzz_nested", nested(DeepNest)), root.closed())
+	return cases, nil
+}
+
+// minimal returns the simplest value that satisfies s, as far as the
+// generator understands it: an object holds only the required properties.
+func (s *schema) minimal() map[string]any {
+	args := map[string]any{}
+	if s == nil {
+		return args
+	}
+	for _, name := range s.Required {
+		args[name] = s.Properties[name].value()
+	}
+	return args
+}
+
+// value returns a valid value for s.
+func (s *schema) value() any {
+	if s == nil {
+		return "fuzz"
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+	if s.Default != nil {
+		return s.Default
+	}
+	switch s.kind() {
+	case "object":
+		return s.minimal()
+	case "array":
+		return []any{}
+	case "boolean":
+		return false
+	case "number", "integer":
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		if s.Maximum != nil && *s.Maximum < 0 {
+			return *s.Maximum
+		}
+		return 0
+	case "null":
+		return nil
+	}
+	return "fuzz"
+}
+
+// wrongType returns a value of a type s does not allow.
+func wrongType(s *schema) (any, bool) {
+	switch s.kind() {
+	case "string":
+		return 42, true
+	case "number", "integer":
+		return "42", true
+	case "boolean":
+		return "true", true
+	case "array":
+		return "fuzz", true
+	case "object":
+		return []any{"fuzz"}, true
+	}
+	// An untyped property accepts anything.
+	return nil, false
+}
+
+func nullable(s *schema) bool {
+	if t, ok := s.Type.([]any); ok {
+		return slices.Contains(t, any("null"))
+	}
+	return s.kind() == "" || s.kind() == "null"
+}
+
+// nested returns arrays nested depth deep.
+func nested(depth int) any {
+	var v any = []any{}
+	for range depth - 1 {
+		v = []any{v}
+	}
+	return v
+}
+
+// with returns a copy of args with name set to v.
+func with(args map[string]any, name string, v any) map[string]any {
+	out := maps.Clone(args)
+	out[name] = v
+	return out
+}
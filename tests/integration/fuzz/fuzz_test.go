@@ -0,0 +1,95 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func casesByName(t *testing.T, schema string) map[string]Case {
+	t.Helper()
+	var decoded any
+	if err := json.Unmarshal([]byte(schema), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	cases, err := Cases(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]Case{}
+	for _, c := range cases {
+		if _, dup := byName[c.Name]; dup {
+			t.Errorf("duplicate case %q", c.Name)
+		}
+		byName[c.Name] = c
+	}
+	return byName
+}
+
+func TestCases(t *testing.T) {
+	cases := casesByName(t, `{
+		"type": "object",
+		"properties": {
+			"text": {"type": "string"},
+			"count": {"type": "integer", "minimum": 1, "maximum": 10},
+			"mode": {"type": "string", "enum": ["fast", "slow"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 5}
+		},
+		"required": ["text", "count"],
+		"additionalProperties": false
+	}`)
+
+	for _, tt := range []struct {
+		name    string
+		args    string
+		invalid bool
+	}{
+		{"empty arguments", `{}`, true},
+		{"null arguments", `null`, true},
+		{"string arguments", `"fuzz"`, true},
+		{"missing text", `{"count":1}`, true},
+		{"missing count", `{"text":"fuzz"}`, true},
+		{"wrong type count", `{"count":"42","text":"fuzz"}`, true},
+		{"wrong type text", `{"count":1,"text":42}`, true},
+		{"fractional count", `{"count":0.5,"text":"fuzz"}`, true},
+		{"count = 1e+308", `{"count":1e+308,"text":"fuzz"}`, true},
+		{"value outside the enum of mode", `{"count":1,"mode":"fuzz-not-in-enum","text":"fuzz"}`, true},
+		{"control characters in text", "{\"count\":1,\"text\":\"\\u0000\\u001b[2J\u202e\ufffd\"}", false},
+		{"unknown property", `{"count":1,"fuzz_unknown":true,"text":"fuzz"}`, true},
+	} {
+		c, ok := cases[tt.name]
+		if !ok {
+			t.Errorf("no case %q", tt.name)
+			continue
+		}
+		if string(c.Args) != tt.args || c.Invalid != tt.invalid {
+			t.Errorf("%s: args %s, invalid %v; want %s, %v", tt.name, c.Args, c.Invalid, tt.args, tt.invalid)
+		}
+	}
+	if c := cases["huge text"]; len(c.Args) < HugeString || c.Invalid {
+		t.Errorf("huge text: %d bytes, invalid %v", len(c.Args), c.Invalid)
+	}
+	if c := cases["huge tags"]; !c.Invalid {
+		t.Error("huge tags is valid despite maxItems")
+	}
+}
+
+func TestCasesOpenSchema(t *testing.T) {
+	// Without required properties or additionalProperties: false, the
+	// server may accept most cases.
+	cases := casesByName(t, `{"type": "object", "properties": {"args": {"type": ["array", "null"]}}}`)
+	for name, invalid := range map[string]bool{
+		"empty arguments":        false,
+		"null arguments":         false,
+		"null args":              false,
+		"wrong type args":        true,
+		"unknown property":       false,
+		"deeply nested property": false,
+	} {
+		if c, ok := cases[name]; !ok || c.Invalid != invalid {
+			t.Errorf("%s: present %v, invalid %v; want invalid %v", name, ok, c.Invalid, invalid)
+		}
+	}
+	if _, ok := cases["missing args"]; ok {
+		t.Error("optional args has a missing case")
+	}
+}
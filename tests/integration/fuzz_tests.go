@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/conformance"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fuzz"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/safety"
+)
+
+// The fuzz-* tests call every tool of a server with arguments generated from
+// its input schema to be wrong or extreme, and fail if the server crashes or
+// hangs instead of answering. Only fuzz-example runs by default; the
+// registered servers' tests need -fuzz.
+
+// fuzzMode enables the fuzz tests of the registered servers.
+var fuzzMode bool
+
+// fuzzTests returns a fuzz-<name> test for every registered server and one
+// for the example server.
+func fuzzTests() []testCase {
+	tests := []testCase{{
+		id:  "fuzz-example",
+		run: func(t *testContext) error { return testFuzz(t, "example server", exampleServerCmd()) },
+	}}
+	for _, s := range serverRegistry.All() {
+		tests = append(tests, testCase{
+			id:       "fuzz-" + s.Name,
+			requires: s.Command[:1],
+			tags:     slow,
+			run: func(t *testContext) error {
+				if !fuzzMode {
+					return report.Skip("fuzzing %s is off; run with -fuzz", s.Name)
+				}
+				return testFuzz(t, s.Name, s.Command)
+			},
+		})
+	}
+	return tests
+}
+
+func testFuzz(t *testContext, server string, command []string) error {
+	logger.Printf("🚀 Starting %s argument fuzzing test...\n", server)
+	tools, err := t.listTools(command)
+	if err != nil {
+		return fmt.Errorf("error listing tools: %w", err)
+	}
+	results, err := fuzzTools(&conformance.Suite{Command: command, Env: t.Env()}, tools, nil)
+	if err != nil {
+		return report.Fail(report.ReasonParse, "%v", err)
+	}
+	logFuzz(results)
+	if failed := fuzzFailures(results); len(failed) > 0 {
+		return report.Fail(report.ReasonFuzzCrash, "%s crashed or hung on %d of %d fuzz cases: %s", server, len(failed), len(results), strings.Join(failed, "; "))
+	}
+	logger.Printf("✅ Assertion passed: %s answered all %d fuzz cases\n", server, len(results))
+	return nil
+}
+
+// fuzzTools fuzzes each of tools, or only those named in only. A tool that
+// looks destructive gets only the cases that violate its schema: a server
+// that validates its arguments rejects them before doing anything, whereas
+// a valid case might really run.
+func fuzzTools(suite *conformance.Suite, tools []*mcp.Tool, only []string) ([]conformance.FuzzResult, error) {
+	var results []conformance.FuzzResult
+	for _, t := range tools {
+		if len(only) > 0 && !slices.Contains(only, t.Name) {
+			continue
+		}
+		cases, err := fuzz.Cases(t.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", t.Name, err)
+		}
+		if reasons := safety.Classify(t); len(reasons) > 0 {
+			cases = slices.DeleteFunc(cases, func(c fuzz.Case) bool { return !c.Invalid })
+			logger.Printf("🛡️  %s looks destructive (%s); fuzzing it only with invalid arguments\n", t.Name, strings.Join(reasons, ", "))
+		}
+		results = append(results, suite.Fuzz(context.Background(), t.Name, cases)...)
+	}
+	return results, nil
+}
+
+// fuzzFailures describes the cases the server crashed or hung on.
+func fuzzFailures(results []conformance.FuzzResult) []string {
+	var failed []string
+	for _, r := range results {
+		if r.Failed() {
+			failed = append(failed, r.String())
+		}
+	}
+	return failed
+}
+
+func logFuzz(results []conformance.FuzzResult) {
+	for _, r := range results {
+		switch {
+		case r.Failed():
+			logf(slog.LevelError, "  ❌ %s\n", r)
+		case r.Warning != "":
+			logf(slog.LevelWarn, "  ⚠️  %s with %s: %s\n", r.Tool, r.Case, r.Warning)
+		default:
+			logger.Printf("  ✅ %s with %s: %s (%s)\n", r.Tool, r.Case, r.Outcome, r.Duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// runFuzz implements `fuzz [-tool NAME]... [-timeout D] -- <server
+// command...>`, fuzzing the tools of a server that is not registered yet. It
+// exits 1 if the server crashes or hangs on a case.
+func runFuzz(args []string) int {
+	fs := flag.NewFlagSet("fuzz", flag.ContinueOnError)
+	var only stringList
+	fs.Var(&only, "tool", "fuzz only this tool (repeatable)")
+	timeout := fs.Duration("timeout", 10*time.Second, "time limit for the server to answer each case")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test fuzz [-tool NAME]... [-timeout D] -- <server command...>")
+		return exitUsage
+	}
+	tools, err := client.ListTools(client.ToolCall{ServerCmd: fs.Args()})
+	if err != nil {
+		logf(slog.LevelError, "❌ error listing tools: %v\n", err)
+		return exitFail
+	}
+	for _, name := range only {
+		if !slices.ContainsFunc(tools, func(t *mcp.Tool) bool { return t.Name == name }) {
+			fmt.Fprintf(os.Stderr, "the server lists no tool %q\n", name)
+			return exitUsage
+		}
+	}
+	results, err := fuzzTools(&conformance.Suite{Command: fs.Args(), Timeout: *timeout}, tools, only)
+	if err != nil {
+		logf(slog.LevelError, "❌ %v\n", err)
+		return exitFail
+	}
+	logFuzz(results)
+	if failed := fuzzFailures(results); len(failed) > 0 {
+		logf(slog.LevelError, "❌ The server crashed or hung on %d of %d fuzz cases\n", len(failed), len(results))
+		return exitFail
+	}
+	logger.Printf("✅ The server answered all %d fuzz cases\n", len(results))
+	return exitPass
+}
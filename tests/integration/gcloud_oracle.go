@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/oracle"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// gcloudOracleTimeout bounds each direct gcloud command -oracle runs.
+const gcloudOracleTimeout = 2 * time.Minute
+
+// gcloudOracle, if set, makes invokeTool check every read-only, JSON-formatted
+// run_gcloud_command call against running its gcloud command directly,
+// comparing the results normalized by it.
+var gcloudOracle *differential.Normalizer
+
+// checkGcloudOracle runs the gcloud command of call, if it has an oracle, and
+// fails with ReasonOracleDiff unless its output, normalized, equals the
+// tool's.
+func checkGcloudOracle(call client.ToolCall, result *client.Result) error {
+	if call.ToolName != oracle.ToolName || len(call.ServerCmd) == 0 ||
+		registeredName(filepath.Base(call.ServerCmd[0])) != gcloudServer.Name ||
+		call.ExpectError != nil || result.IsError {
+		return nil
+	}
+	args, ok := oracle.Args(call.ToolArgs)
+	if !ok {
+		return nil
+	}
+	command := "gcloud " + strings.Join(args, " ")
+	ctx, cancel := context.WithTimeout(runCtx, gcloudOracleTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gcloud", args...)
+	// gcloud sees what the server's gcloud would: the test's sandbox and
+	// impersonation.
+	cmd.Env = slices.Concat(os.Environ(), call.Env)
+	if call.ImpersonateServiceAccount != "" {
+		cmd.Env = append(cmd.Env, "CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT="+call.ImpersonateServiceAccount)
+	}
+	var stdout bytes.Buffer
+	var stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := subprocess.Default.Run(cmd); err != nil {
+		return report.Fail(report.ReasonOracleDiff, "%s succeeded through %s but failed when run directly: %v\nStderr:\n%s", oracle.ToolName, command, err, stderr.String())
+	}
+	message := fmt.Sprintf("assertion failed: %s returned different output than %s", oracle.ToolName, command)
+	want := gcloudOracle.Normalize(stdout.String())
+	got := gcloudOracle.Normalize(oracle.Stdout(result.Text()))
+	if err := report.Compare(message, want, got); err != nil {
+		return &report.Failure{Reason: report.ReasonOracleDiff, Err: report.MismatchOf(err)}
+	}
+	logger.Printf("🔮 %s output matches %s\n", oracle.ToolName, command)
+	return nil
+}
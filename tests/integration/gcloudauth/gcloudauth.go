@@ -0,0 +1,33 @@
+// Package gcloudauth gets the OAuth access tokens the harness calls Google
+// Cloud APIs with from the gcloud CLI, so its own calls run as the account
+// the servers' gcloud commands do.
+package gcloudauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// AccessToken returns an access token of gcloud's active account, as
+// `gcloud auth print-access-token` prints it. It is the default Token of the
+// harness's API clients.
+func AccessToken(ctx context.Context) (string, error) {
+	return Print(ctx, "auth", "print-access-token")
+}
+
+// Print runs gcloud with args, a print-access-token command, and returns the
+// token it prints. A failure carries what gcloud wrote to stderr.
+func Print(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "gcloud", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,34 @@
+package gcloudauth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeGcloud puts a gcloud on PATH that runs script.
+func fakeGcloud(t *testing.T, script string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gcloud"), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestAccessToken(t *testing.T) {
+	fakeGcloud(t, `[ "$*" = "auth print-access-token" ] && echo ya29.token`)
+	token, err := AccessToken(context.Background())
+	if err != nil || token != "ya29.token" {
+		t.Errorf("AccessToken() = %q, %v, want the printed token", token, err)
+	}
+}
+
+func TestPrintCarriesStderr(t *testing.T) {
+	fakeGcloud(t, `echo "You do not currently have an active account selected." >&2; exit 1`)
+	_, err := Print(context.Background(), "auth", "print-access-token", "a@example.com")
+	if err == nil || !strings.Contains(err.Error(), "do not currently have an active account") {
+		t.Errorf("Print() = %v, want gcloud's stderr", err)
+	}
+}
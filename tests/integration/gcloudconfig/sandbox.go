@@ -0,0 +1,191 @@
+// Package gcloudconfig creates throwaway gcloud configuration directories, so
+// a test's servers neither see nor change the developer's global gcloud
+// configuration.
+package gcloudconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvVar is the environment variable that points gcloud at a configuration
+// directory.
+const EnvVar = "CLOUDSDK_CONFIG"
+
+// credentialFiles are the entries of a configuration directory that hold the
+// logged-in accounts. They are copied into a sandbox so it stays logged in.
+var credentialFiles = []string{
+	"credentials.db",
+	"access_tokens.db",
+	"application_default_credentials.json",
+	"legacy_credentials",
+}
+
+// Options describe the configuration a sandbox starts with.
+type Options struct {
+	// Project is set as core/project. Empty keeps the active project of
+	// Source, if it has one.
+	Project string
+	// Account is set as core/account. Empty keeps the active account of
+	// Source, if it has one.
+	Account string
+	// Source is the configuration directory credentials are copied from.
+	// Empty means the one gcloud itself would use.
+	Source string
+}
+
+// Sandbox is a temporary gcloud configuration directory.
+type Sandbox struct {
+	Dir string
+}
+
+// New creates a sandbox with a single, active "default" configuration set up
+// from opts. The caller removes it with Remove.
+func New(opts Options) (*Sandbox, error) {
+	source := opts.Source
+	if source == "" {
+		source = DefaultDir()
+	}
+	project := opts.Project
+	if project == "" {
+		project = ActiveProject(source)
+	}
+	account := opts.Account
+	if account == "" {
+		account = ActiveAccount(source)
+	}
+
+	dir, err := os.MkdirTemp("", "gcloud-config-")
+	if err != nil {
+		return nil, err
+	}
+	s := &Sandbox{Dir: dir}
+	if err := s.populate(source, project, account); err != nil {
+		s.Remove()
+		return nil, fmt.Errorf("failed to set up gcloud configuration sandbox: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Sandbox) populate(source, project, account string) error {
+	for _, name := range credentialFiles {
+		err := copyTree(filepath.Join(source, name), filepath.Join(s.Dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(s.Dir, "configurations"), 0o700); err != nil {
+		return err
+	}
+	var config strings.Builder
+	config.WriteString("[core]\n")
+	if project != "" {
+		fmt.Fprintf(&config, "project = %s\n", project)
+	}
+	if account != "" {
+		fmt.Fprintf(&config, "account = %s\n", account)
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, "configurations", "config_default"), []byte(config.String()), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, "active_config"), []byte("default"), 0o600)
+}
+
+// Env returns the environment entries that point gcloud at s.
+func (s *Sandbox) Env() []string {
+	return []string{EnvVar + "=" + s.Dir}
+}
+
+// Remove deletes s and everything gcloud wrote into it.
+func (s *Sandbox) Remove() error {
+	return os.RemoveAll(s.Dir)
+}
+
+// DefaultDir returns the configuration directory gcloud uses for the current
+// environment.
+func DefaultDir() string {
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud")
+}
+
+// ActiveAccount returns core/account of the active configuration in dir, or
+// "" if it has none.
+func ActiveAccount(dir string) string {
+	return activeCore(dir, "account")
+}
+
+// ActiveProject returns core/project of the active configuration in dir, or
+// "" if it has none.
+func ActiveProject(dir string) string {
+	return activeCore(dir, "project")
+}
+
+// activeCore returns property of the core section of the active
+// configuration in dir, or "" if it is not set.
+func activeCore(dir, property string) string {
+	name := "default"
+	if data, err := os.ReadFile(filepath.Join(dir, "active_config")); err == nil && strings.TrimSpace(string(data)) != "" {
+		name = strings.TrimSpace(string(data))
+	}
+	f, err := os.Open(filepath.Join(dir, "configurations", "config_"+name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section == "core" && strings.TrimSpace(key) == property {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// copyTree copies the file or directory src to dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,111 @@
+package gcloudconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestNew(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "active_config"), "work\n")
+	writeFile(t, filepath.Join(source, "configurations", "config_work"), "[core]\naccount = dev@example.com\nproject = prod-project\n")
+	writeFile(t, filepath.Join(source, "credentials.db"), "creds")
+	writeFile(t, filepath.Join(source, "legacy_credentials", "dev@example.com", "adc.json"), "{}")
+
+	s, err := New(Options{Project: "gcloud-mcp-testing", Source: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Remove()
+
+	config := readFile(t, filepath.Join(s.Dir, "configurations", "config_default"))
+	if want := "[core]\nproject = gcloud-mcp-testing\naccount = dev@example.com\n"; config != want {
+		t.Errorf("config_default = %q, want %q", config, want)
+	}
+	if got := readFile(t, filepath.Join(s.Dir, "active_config")); got != "default" {
+		t.Errorf("active_config = %q, want default", got)
+	}
+	if got := readFile(t, filepath.Join(s.Dir, "credentials.db")); got != "creds" {
+		t.Errorf("credentials.db = %q, want a copy of the source", got)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "legacy_credentials", "dev@example.com", "adc.json")); err != nil {
+		t.Errorf("legacy credentials not copied: %v", err)
+	}
+	if env := s.Env(); len(env) != 1 || env[0] != "CLOUDSDK_CONFIG="+s.Dir {
+		t.Errorf("Env() = %v", env)
+	}
+
+	// Changes inside the sandbox must not reach the source.
+	writeFile(t, filepath.Join(s.Dir, "configurations", "config_default"), "[core]\nproject = other\n")
+	if got := readFile(t, filepath.Join(source, "configurations", "config_work")); !strings.Contains(got, "prod-project") {
+		t.Errorf("source configuration changed: %q", got)
+	}
+
+	if err := s.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.Dir); !os.IsNotExist(err) {
+		t.Errorf("sandbox still exists after Remove: %v", err)
+	}
+}
+
+func TestNewExplicitAccountAndEmptySource(t *testing.T) {
+	s, err := New(Options{Project: "p", Account: "ci@p.iam.gserviceaccount.com", Source: filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Remove()
+	if got := ActiveAccount(s.Dir); got != "ci@p.iam.gserviceaccount.com" {
+		t.Errorf("ActiveAccount = %q", got)
+	}
+}
+
+func TestActiveAccount(t *testing.T) {
+	dir := t.TempDir()
+	if got := ActiveAccount(dir); got != "" {
+		t.Errorf("ActiveAccount of an empty directory = %q", got)
+	}
+	// Without active_config gcloud uses the default configuration; an
+	// account key outside [core] does not count.
+	writeFile(t, filepath.Join(dir, "configurations", "config_default"), "[auth]\naccount = wrong\n[core]\naccount=a@example.com\n")
+	if got := ActiveAccount(dir); got != "a@example.com" {
+		t.Errorf("ActiveAccount = %q, want a@example.com", got)
+	}
+}
+
+func TestNewKeepsSourceProject(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "configurations", "config_default"), "[core]\nproject = dev-project\naccount = dev@example.com\n")
+	s, err := New(Options{Source: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Remove()
+	if got := ActiveProject(s.Dir); got != "dev-project" {
+		t.Errorf("ActiveProject = %q, want the source's dev-project", got)
+	}
+	if got := ActiveProject(t.TempDir()); got != "" {
+		t.Errorf("ActiveProject of an empty directory = %q", got)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/geminicli"
+	"github.com/googleapis/gcloud-mcp/tests/integration/geminiconfig"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// geminiPromptTimeout bounds a single non-interactive Gemini CLI run, which
+// includes model round trips and every tool call it makes.
+const geminiPromptTimeout = 3 * time.Minute
+
+// geminiOutput is the part of `gemini -p ... --output-format json` the tests
+// read.
+type geminiOutput struct {
+	Response string `json:"response"`
+	Stats    struct {
+		Tools struct {
+			TotalCalls int `json:"totalCalls"`
+			ByName     map[string]struct {
+				Count int `json:"count"`
+			} `json:"byName"`
+		} `json:"tools"`
+	} `json:"stats"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// toolCalls returns how often the model called tool, whether or not the CLI
+// prefixed it with its server name.
+func (o *geminiOutput) toolCalls(tool string) int {
+	n := 0
+	for name, stats := range o.Stats.Tools.ByName {
+		if name == tool || strings.HasSuffix(name, "__"+tool) {
+			n += stats.Count
+		}
+	}
+	return n
+}
+
+// gemini-mcp-list lists the servers again while some are still starting, up
+// to geminiListAttempts times.
+const (
+	geminiListAttempts = 5
+	geminiListInterval = 2 * time.Second
+)
+
+// listGeminiServers returns the servers `gemini mcp list` shows and its
+// output. It asks for JSON, which the CLI may not support yet, and parses
+// the text output with format otherwise. Either way, each server's scope and
+// trust come from the settings files that configure it.
+func listGeminiServers(t *testContext, format *geminicli.ListFormat) ([]geminicli.Server, []byte, error) {
+	env := slices.Concat(os.Environ(), geminiEnv, t.Env())
+	cmd := exec.Command("gemini", "mcp", "list", "--format", "json")
+	cmd.Env = env
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if subprocess.Default.Run(cmd) == nil {
+		if listed, err := geminicli.ParseJSON(stdout.Bytes()); err == nil {
+			logger.Println("Command output:")
+			logger.Println(stdout.String())
+			return listed, stdout.Bytes(), configureListed(listed, env)
+		}
+	}
+
+	cmd = exec.Command("gemini", "mcp", "list")
+	cmd.Env = env
+	var combined bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &combined, &combined
+	err := subprocess.Default.Run(cmd)
+	output := combined.Bytes()
+	if err != nil {
+		return nil, nil, report.Fail(report.ReasonCommand, "error executing command: %v\nOutput:\n%s", err, output)
+	}
+	logger.Println("Command output:")
+	logger.Println(string(output))
+	listed := format.Parse(output)
+	if len(listed) == 0 {
+		return nil, nil, report.Fail(report.ReasonGeminiVersion, "%v: gemini mcp list printed no server line in the format known for versions %s; its output format may have changed:\n%s",
+			geminicli.ErrUnsupported, format.Versions(), output)
+	}
+	return listed, output, configureListed(listed, env)
+}
+
+// configureListed sets the scope and trust of the listed servers to those of
+// the user or project settings.json of a gemini run with env. A server
+// neither configures, e.g. one of an extension, has neither.
+func configureListed(listed []geminicli.Server, env []string) error {
+	userDir, err := geminiconfig.UserDir(env)
+	if err != nil {
+		return report.Fail(report.ReasonPrerequisite, "no Gemini CLI user settings directory: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	configured, err := geminiconfig.ReadServers(userDir, filepath.Join(wd, geminiconfig.DirName))
+	if err != nil {
+		return report.Fail(report.ReasonParse, "error reading the Gemini CLI settings: %v", err)
+	}
+	for i, l := range listed {
+		listed[i].Scope, listed[i].Trust = "", nil
+		if c, ok := configured[l.Name]; ok {
+			listed[i].Scope, listed[i].Trust = c.Scope, &c.Trust
+		}
+	}
+	return nil
+}
+
+// runGeminiPrompt runs prompt through the Gemini CLI with tool calls
+// auto-approved and only the given MCP servers enabled.
+func runGeminiPrompt(t *testContext, prompt string, mcpServers ...string) (*geminiOutput, error) {
+	ctx, cancel := context.WithTimeout(runCtx, geminiPromptTimeout)
+	defer cancel()
+	args := []string{"-p", prompt, "--output-format", "json", "--yolo"}
+	if len(mcpServers) > 0 {
+		args = append(args, "--allowed-mcp-server-names", strings.Join(mcpServers, ","))
+	}
+	cmd := exec.CommandContext(ctx, "gemini", args...)
+	cmd.Env = slices.Concat(os.Environ(), geminiEnv, t.Env())
+	var stdout bytes.Buffer
+	var stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := subprocess.Default.Run(cmd); err != nil {
+		return nil, report.Fail(report.ReasonCommand, "error executing gemini -p: %v\nStderr:\n%s\nOutput:\n%s", err, stderr.String(), stdout.Bytes())
+	}
+
+	var out geminiOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, report.Fail(report.ReasonParse, "error parsing gemini JSON output: %v\nOutput: %s", err, stdout.Bytes())
+	}
+	if out.Error != nil {
+		return nil, report.Fail(report.ReasonCommand, "gemini reported an error: %s", out.Error.Message)
+	}
+	return &out, nil
+}
+
+// testGeminiPromptProject asks the model for the active project so that it has
+// to go through gcloud-mcp, then checks both the tool call and the answer.
+func testGeminiPromptProject(t *testContext) error {
+	logger.Println("🚀 Starting Gemini CLI prompt integration test...")
+
+	// Prefer the project gcloud-tool-call saw, so a wrong project is reported
+	// by that test rather than here.
+	project, err := blackboard.Get(t.board, projectIDKey)
+	if errors.Is(err, blackboard.ErrNotPublished) {
+		project = testProject
+	} else if err != nil {
+		return err
+	}
+
+	out, err := runGeminiPrompt(t, "Use the run_gcloud_command tool to find the active gcloud project "+
+		"(for example with `config get-value project`) and answer with just the project ID.", "gcloud")
+	if err != nil {
+		return err
+	}
+	logger.Printf("Model response: %s\n", out.Response)
+
+	if n := out.toolCalls("run_gcloud_command"); n == 0 {
+		called := slices.Sorted(maps.Keys(out.Stats.Tools.ByName))
+		return &report.Failure{Reason: report.ReasonAssertion, Err: &report.Mismatch{
+			Message:  "assertion failed: the model answered without calling run_gcloud_command",
+			Expected: "run_gcloud_command",
+			Actual:   fmt.Sprintf("tools called: %v", called),
+		}}
+	}
+	logger.Println("✅ Assertion passed: The model called run_gcloud_command")
+
+	if !strings.Contains(out.Response, project) {
+		return &report.Failure{Reason: report.ReasonAssertion, Err: &report.Mismatch{
+			Message:  "assertion failed: the model's answer does not contain the active project",
+			Expected: project,
+			Actual:   out.Response,
+		}}
+	}
+	logger.Printf("✅ Assertion passed: The answer names project %s\n", project)
+	return nil
+}
+
+var geminiVersion struct {
+	once    sync.Once
+	version geminicli.Version
+	err     error
+}
+
+// detectGeminiVersion returns the version of the installed Gemini CLI,
+// asking it once per run.
+func detectGeminiVersion(t *testContext) (geminicli.Version, error) {
+	geminiVersion.once.Do(func() {
+		cmd := exec.Command("gemini", "--version")
+		cmd.Env = slices.Concat(os.Environ(), geminiEnv, t.Env())
+		var out bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := subprocess.Default.Run(cmd); err != nil {
+			geminiVersion.err = report.Fail(report.ReasonCommand, "error executing gemini --version: %v\nOutput:\n%s", err, out.Bytes())
+			return
+		}
+		if geminiVersion.version, geminiVersion.err = geminicli.ParseVersion(out.Bytes()); geminiVersion.err != nil {
+			geminiVersion.err = report.Fail(report.ReasonGeminiVersion, "%v", geminiVersion.err)
+		}
+	})
+	return geminiVersion.version, geminiVersion.err
+}
@@ -0,0 +1,176 @@
+// Package geminicli reads the output of the Gemini CLI commands the tests
+// run. Its formats are not a stable interface, so every format is tied to
+// the CLI versions it was verified against, and a version outside them is
+// reported as unsupported rather than as output that fails to match.
+package geminicli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a Gemini CLI release, as `gemini --version` prints it.
+type Version struct {
+	Major, Minor, Patch int
+	// Pre is the prerelease suffix, e.g. nightly.20251014, if any.
+	Pre string
+}
+
+// versionText matches a semantic version in `gemini --version` output.
+var versionText = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.\-]+))?`)
+
+// ParseVersion returns the version `gemini --version` printed.
+func ParseVersion(out []byte) (Version, error) {
+	m := versionText.FindSubmatch(out)
+	if m == nil {
+		return Version{}, fmt.Errorf("no version in gemini --version output %q", strings.TrimSpace(string(out)))
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(string(m[1]))
+	v.Minor, _ = strconv.Atoi(string(m[2]))
+	v.Patch, _ = strconv.Atoi(string(m[3]))
+	v.Pre = string(m[4])
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Less reports whether v precedes o. A prerelease precedes its release;
+// prereleases of the same release are ordered as text.
+func (v Version) Less(o Version) bool {
+	switch {
+	case v.Major != o.Major:
+		return v.Major < o.Major
+	case v.Minor != o.Minor:
+		return v.Minor < o.Minor
+	case v.Patch != o.Patch:
+		return v.Patch < o.Patch
+	case v.Pre == "" || o.Pre == "":
+		return v.Pre != "" && o.Pre == ""
+	}
+	return v.Pre < o.Pre
+}
+
+// Server is a server listed by `gemini mcp list`.
+type Server struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Transport is stdio, sse or http.
+	Transport string `json:"transport"`
+	// Status is one of the Status constants, or what the CLI printed if it
+	// is none of them.
+	Status string `json:"status"`
+	// Scope is the settings scope the server is configured in, user or
+	// project, and Trust whether its tool calls skip confirmation. Only the
+	// JSON listing may report them; they are empty in one parsed from text.
+	Scope string `json:"scope,omitempty"`
+	Trust *bool  `json:"trust,omitempty"`
+}
+
+// Statuses of a listed server.
+const (
+	StatusConnected    = "Connected"
+	StatusDisconnected = "Disconnected"
+	// StatusConnecting and StatusStarting are servers whose handshake has
+	// not finished yet.
+	StatusConnecting = "Connecting"
+	StatusStarting   = "Starting"
+)
+
+// Pending reports whether s is still connecting, so listing again later may
+// find it connected.
+func (s Server) Pending() bool {
+	return s.Status == StatusConnecting || s.Status == StatusStarting
+}
+
+// ParseJSON returns the servers of `gemini mcp list --format json` output
+// in the order listed, with their statuses capitalized like the text
+// format's. JSON is self-describing, so unlike the text formats it is not
+// tied to CLI versions.
+func ParseJSON(out []byte) ([]Server, error) {
+	var servers []Server
+	if err := json.Unmarshal(bytes.TrimSpace(out), &servers); err != nil {
+		return nil, fmt.Errorf("parsing gemini mcp list --format json output: %w", err)
+	}
+	for i, s := range servers {
+		if s.Name == "" {
+			return nil, fmt.Errorf("gemini mcp list --format json listed server %d without a name", i+1)
+		}
+		for _, status := range []string{StatusConnected, StatusDisconnected, StatusConnecting, StatusStarting} {
+			if strings.EqualFold(s.Status, status) {
+				servers[i].Status = status
+			}
+		}
+	}
+	return servers, nil
+}
+
+// ListFormat is a layout of `gemini mcp list` output and the CLI versions
+// known to print it.
+type ListFormat struct {
+	// Since is the first version printing the format and Before the first
+	// that is not known to; a zero Before is not known yet.
+	Since, Before Version
+	// line matches a server, with its name, command, transport and status
+	// as groups.
+	line *regexp.Regexp
+}
+
+// ListFormats are the `gemini mcp list` formats of the supported CLI
+// versions, in release order. Supporting a new version means checking its
+// output and extending or adding a format.
+var ListFormats = []ListFormat{
+	{
+		// ✓ gcloud: npx -y gcloud-mcp (stdio) - Connected
+		Since:  Version{Minor: 1},
+		Before: Version{Major: 1},
+		line:   regexp.MustCompile(`(?m)^\s*\S*\s*([\w.-]+): (.+?)\s+\((stdio|sse|http)\) - (\w[\w ]*?)\s*$`),
+	},
+}
+
+// ErrUnsupported is returned for CLI versions no known format covers.
+var ErrUnsupported = errors.New("unsupported gemini version")
+
+// ListFormatFor returns the `gemini mcp list` format of version v.
+func ListFormatFor(v Version) (*ListFormat, error) {
+	for i := range ListFormats {
+		f := &ListFormats[i]
+		if !v.Less(f.Since) && (f.Before == Version{} || v.Less(f.Before)) {
+			return f, nil
+		}
+	}
+	var known []string
+	for _, f := range ListFormats {
+		known = append(known, f.Versions())
+	}
+	return nil, fmt.Errorf("%w %s: the tests know the gemini mcp list output of versions %s; install one of them or add the new format to geminicli", ErrUnsupported, v, strings.Join(known, ", "))
+}
+
+// Parse returns the servers of `gemini mcp list` output in the order
+// listed.
+func (f *ListFormat) Parse(out []byte) []Server {
+	var servers []Server
+	for _, m := range f.line.FindAllSubmatch(out, -1) {
+		servers = append(servers, Server{Name: string(m[1]), Command: string(m[2]), Transport: string(m[3]), Status: string(m[4])})
+	}
+	return servers
+}
+
+// Versions describes the CLI versions f covers, e.g. "0.1.0 up to 1.0.0".
+func (f *ListFormat) Versions() string {
+	if f.Before == (Version{}) {
+		return f.Since.String() + " and later"
+	}
+	return f.Since.String() + " up to " + f.Before.String()
+}
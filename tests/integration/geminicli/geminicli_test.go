@@ -0,0 +1,86 @@
+package geminicli
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for out, want := range map[string]Version{
+		"0.9.0\n":                              {Minor: 9},
+		"gemini 0.10.2-nightly.20251014.abc\n": {Minor: 10, Patch: 2, Pre: "nightly.20251014.abc"},
+	} {
+		if got, err := ParseVersion([]byte(out)); err != nil || got != want {
+			t.Errorf("ParseVersion(%q) = %v, %v, want %v", out, got, err, want)
+		}
+	}
+	if _, err := ParseVersion([]byte("command not found")); err == nil {
+		t.Error("ParseVersion accepted output without a version")
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	ordered := []Version{{Minor: 1}, {Minor: 9, Pre: "nightly.1"}, {Minor: 9, Pre: "nightly.2"}, {Minor: 9}, {Minor: 10}, {Major: 1}}
+	for i := range ordered {
+		for j := range ordered {
+			if got := ordered[i].Less(ordered[j]); got != (i < j) {
+				t.Errorf("%s.Less(%s) = %t", ordered[i], ordered[j], got)
+			}
+		}
+	}
+}
+
+func TestListFormat(t *testing.T) {
+	f, err := ListFormatFor(Version{Minor: 9, Pre: "nightly.20251014"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := "Configured MCP servers:\n\n" +
+		"✓ gcloud: npx -y gcloud-mcp  (stdio) - Connected\n" +
+		"✗ storage: npx -y storage-mcp --port 8080 (sse) - Disconnected\n"
+	want := []Server{
+		{Name: "gcloud", Command: "npx -y gcloud-mcp", Transport: "stdio", Status: "Connected"},
+		{Name: "storage", Command: "npx -y storage-mcp --port 8080", Transport: "sse", Status: "Disconnected"},
+	}
+	if got := f.Parse([]byte(out)); !slices.Equal(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+
+	for _, v := range []Version{{Major: 1}, {Patch: 9}} {
+		if _, err := ListFormatFor(v); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("ListFormatFor(%s) = %v, want ErrUnsupported", v, err)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	out := `[
+  {"name": "gcloud", "command": "npx -y gcloud-mcp", "transport": "stdio", "status": "connected", "scope": "user", "trust": false},
+  {"name": "storage", "command": "npx -y storage-mcp", "transport": "stdio", "status": "STARTING", "scope": "project"},
+  {"name": "custom", "transport": "http", "status": "errored"}
+]
+`
+	got, err := ParseJSON([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("ParseJSON() = %+v, want 3 servers", got)
+	}
+	if g := got[0]; g.Status != StatusConnected || g.Scope != "user" || g.Trust == nil || *g.Trust || g.Pending() {
+		t.Errorf("gcloud = %+v", g)
+	}
+	if g := got[1]; g.Status != StatusStarting || g.Trust != nil || !g.Pending() {
+		t.Errorf("storage = %+v", g)
+	}
+	if g := got[2]; g.Status != "errored" || g.Pending() {
+		t.Errorf("custom = %+v", g)
+	}
+
+	for _, bad := range []string{"Configured MCP servers:\n", `[{"command":"npx -y x"}]`} {
+		if _, err := ParseJSON([]byte(bad)); err == nil {
+			t.Errorf("ParseJSON(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package geminiconfig generates and validates the MCP server section of the
+// Gemini CLI's settings.json, so a run can use a configuration of its own
+// instead of whatever the host user has set up.
+package geminiconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+)
+
+// EnvVar is the environment variable that points the Gemini CLI at a
+// settings directory.
+const EnvVar = "GEMINI_CONFIG_DIR"
+
+// FileName is the settings file inside the settings directory.
+const FileName = "settings.json"
+
+// MCPServer is one entry of the mcpServers map in settings.json.
+type MCPServer struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Cwd     string            `json:"cwd,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	HTTPURL string            `json:"httpUrl,omitempty"`
+	// Timeout is in milliseconds.
+	Timeout int  `json:"timeout,omitempty"`
+	Trust   bool `json:"trust,omitempty"`
+}
+
+// Settings is the part of settings.json the harness manages.
+type Settings struct {
+	MCPServers map[string]MCPServer `json:"mcpServers"`
+}
+
+// DirName is the settings directory in the user's home and in a project.
+const DirName = ".gemini"
+
+// UserDir returns the user settings directory of a Gemini CLI run with env:
+// $GEMINI_CONFIG_DIR if env sets it, else ~/.gemini. Later entries of env
+// win, as with exec.Cmd.
+func UserDir(env []string) (string, error) {
+	dir, home := "", ""
+	for _, e := range env {
+		if v, ok := strings.CutPrefix(e, EnvVar+"="); ok {
+			dir = v
+		} else if v, ok := strings.CutPrefix(e, "HOME="); ok {
+			home = v
+		}
+	}
+	if dir != "" {
+		return dir, nil
+	}
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(home, DirName), nil
+}
+
+// Configured is a server as the settings files configure it.
+type Configured struct {
+	// Scope is the settings scope the server comes from, bootstrap.ScopeUser
+	// or bootstrap.ScopeProject.
+	Scope string
+	Trust bool
+}
+
+// ReadServers returns the MCP servers the user settings in userDir and the
+// project settings in projectDir configure, by name. A server in both is the
+// project's, as the CLI lets project settings override the user's. A missing
+// settings file configures no servers.
+func ReadServers(userDir, projectDir string) (map[string]Configured, error) {
+	servers := make(map[string]Configured)
+	for _, scope := range []struct{ name, dir string }{{bootstrap.ScopeUser, userDir}, {bootstrap.ScopeProject, projectDir}} {
+		data, err := os.ReadFile(filepath.Join(scope.dir, FileName))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var s Settings
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("%s is not valid JSON: %w", filepath.Join(scope.dir, FileName), err)
+		}
+		for name, server := range s.MCPServers {
+			servers[name] = Configured{Scope: scope.name, Trust: server.Trust}
+		}
+	}
+	return servers, nil
+}
+
+// FromManifest returns settings that start every server of m the way
+// `integration-test setup` registers it.
+func FromManifest(m *bootstrap.Manifest) *Settings {
+	s := &Settings{MCPServers: make(map[string]MCPServer)}
+	for _, server := range m.Servers {
+		cmd := server.GeminiCommand()
+		s.MCPServers[server.Name] = MCPServer{Command: cmd[0], Args: cmd[1:], Trust: server.Trust}
+	}
+	return s
+}
+
+// Marshal returns s as indented JSON.
+func (s *Settings) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Write writes s to dir/settings.json, creating dir if needed.
+func Write(dir string, s *Settings) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, FileName), data, 0o644)
+}
+
+// WriteTemp writes s to a new temporary settings directory and returns the
+// directory and the environment entry selecting it. The caller removes dir.
+func WriteTemp(s *Settings) (dir, env string, err error) {
+	dir, err = os.MkdirTemp("", "gemini-config-")
+	if err != nil {
+		return "", "", err
+	}
+	if err := Write(dir, s); err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	return dir, EnvVar + "=" + dir, nil
+}
+
+// Validate checks the settings file at path: it must parse, every server must
+// have exactly one of command, url and httpUrl, and every server of want must
+// be present with the same command and arguments. Settings outside
+// mcpServers are ignored. It returns one message per problem found.
+func Validate(path string, want *Settings) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var got Settings
+	if err := json.Unmarshal(data, &got); err != nil {
+		return []string{fmt.Sprintf("%s is not valid JSON: %v", path, err)}, nil
+	}
+
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(got.MCPServers)) {
+		s := got.MCPServers[name]
+		n := 0
+		for _, v := range []string{s.Command, s.URL, s.HTTPURL} {
+			if v != "" {
+				n++
+			}
+		}
+		if n != 1 {
+			problems = append(problems, fmt.Sprintf("server %q must set exactly one of command, url and httpUrl", name))
+		}
+	}
+
+	if want == nil {
+		return problems, nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(want.MCPServers)) {
+		w := want.MCPServers[name]
+		g, ok := got.MCPServers[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("server %q is not configured", name))
+		case g.Command != w.Command || !slices.Equal(g.Args, w.Args) || g.URL != w.URL || g.HTTPURL != w.HTTPURL:
+			problems = append(problems, fmt.Sprintf("server %q runs %s, want %s", name, describe(g), describe(w)))
+		}
+	}
+	return problems, nil
+}
+
+func describe(s MCPServer) string {
+	switch {
+	case s.HTTPURL != "":
+		return s.HTTPURL
+	case s.URL != "":
+		return s.URL
+	}
+	return fmt.Sprintf("%q", append([]string{s.Command}, s.Args...))
+}
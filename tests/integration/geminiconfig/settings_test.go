@@ -0,0 +1,113 @@
+package geminiconfig
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+)
+
+var manifest = &bootstrap.Manifest{Servers: []bootstrap.Server{
+	{Name: "gcloud", Package: "@google-cloud/gcloud-mcp", Bin: "gcloud-mcp"},
+	{Name: "storage", Package: "@google-cloud/storage-mcp", Bin: "storage-mcp"},
+}}
+
+func TestWriteTempValidates(t *testing.T) {
+	want := FromManifest(manifest)
+	dir, env, err := WriteTemp(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if env != EnvVar+"="+dir {
+		t.Errorf("env = %q", env)
+	}
+	problems, err := Validate(filepath.Join(dir, FileName), want)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Validate() = %q, %v", problems, err)
+	}
+	if got := want.MCPServers["gcloud"]; got.Command != "npx" || !slices.Equal(got.Args, []string{"-y", "gcloud-mcp"}) {
+		t.Errorf("gcloud entry = %+v", got)
+	}
+}
+
+func TestValidateProblems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte(`{
+  "theme": "dark",
+  "mcpServers": {
+    "gcloud": {"command": "node", "args": ["dist/bundle.js"]},
+    "broken": {"command": "x", "httpUrl": "http://localhost:8080/mcp"}
+  }
+}`), 0o644)
+	problems, err := Validate(path, FromManifest(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`server "broken" must set exactly one of command, url and httpUrl`,
+		`server "gcloud" runs`,
+		`server "storage" is not configured`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Validate() = %q", problems)
+	}
+	for i := range want {
+		if !strings.HasPrefix(problems[i], want[i]) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], want[i])
+		}
+	}
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte(`{"mcpServers": `), 0o644)
+	if problems, err := Validate(path, nil); err != nil || len(problems) != 1 {
+		t.Errorf("Validate() = %q, %v", problems, err)
+	}
+}
+
+func TestReadServers(t *testing.T) {
+	user, project := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(user, FileName), []byte(`{"mcpServers": {"gcloud": {"command": "npx", "trust": true}, "storage": {"command": "npx"}}}`), 0o644)
+	os.WriteFile(filepath.Join(project, FileName), []byte(`{"mcpServers": {"storage": {"command": "npx", "trust": true}}}`), 0o644)
+	got, err := ReadServers(user, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Configured{
+		"gcloud":  {Scope: bootstrap.ScopeUser, Trust: true},
+		"storage": {Scope: bootstrap.ScopeProject, Trust: true},
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("ReadServers() = %+v, want %+v", got, want)
+	}
+
+	if got, err := ReadServers(t.TempDir(), t.TempDir()); err != nil || len(got) != 0 {
+		t.Errorf("ReadServers() without settings = %+v, %v, want none", got, err)
+	}
+	os.WriteFile(filepath.Join(project, FileName), []byte(`{`), 0o644)
+	if _, err := ReadServers(user, project); err == nil {
+		t.Error("ReadServers() of invalid JSON succeeded")
+	}
+}
+
+func TestUserDir(t *testing.T) {
+	tests := []struct {
+		env  []string
+		want string
+	}{
+		{[]string{"HOME=/home/a"}, filepath.Join("/home/a", DirName)},
+		{[]string{"HOME=/home/a", EnvVar + "=/tmp/config"}, "/tmp/config"},
+		{[]string{EnvVar + "=/tmp/config", "HOME=/home/a", EnvVar + "="}, filepath.Join("/home/a", DirName)},
+	}
+	for _, tt := range tests {
+		if got, err := UserDir(tt.env); err != nil || got != tt.want {
+			t.Errorf("UserDir(%q) = %q, %v, want %q", tt.env, got, err, tt.want)
+		}
+	}
+}
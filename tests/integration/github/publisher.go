@@ -0,0 +1,328 @@
+// Package github posts the summary of an integration run to a pull request,
+// as a comment that later runs edit in place and a commit status, so a PR's
+// author sees what broke without opening the CI job. It speaks the REST API
+// directly, so any CI system with a token can publish.
+package github
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+const defaultEndpoint = "https://api.github.com"
+
+// Marker starts every comment Comment renders; the publisher edits the
+// PR's comment with it instead of adding one per run.
+const Marker = "<!-- integration-test-summary -->"
+
+// StatusContext names the commit status the publisher sets.
+const StatusContext = "integration-test"
+
+// Limits of the comment, which GitHub caps at 65536 characters.
+const (
+	maxListed    = 20
+	maxSlowest   = 5
+	maxErrorLine = 200
+)
+
+// Comment renders the Markdown summary of run: the counts, the tests that
+// failed with their reason and error, the flaky and quarantined tests, the
+// slowest tools by P95 and, if set, a link to the run's artifacts.
+func Comment(run *report.Run, artifactsURL string) string {
+	var b strings.Builder
+	passed, failed, skipped := run.Counts()
+	flaky, quarantined := run.Flaky(), run.Quarantined()
+	b.WriteString(Marker + "\n")
+	switch {
+	case run.Interrupted != "":
+		fmt.Fprintf(&b, "### ⚠️ Integration tests interrupted by %s\n\n", run.Interrupted)
+	case failed > 0:
+		b.WriteString("### ❌ Integration tests failed\n\n")
+	default:
+		b.WriteString("### ✅ Integration tests passed\n\n")
+	}
+	fmt.Fprintf(&b, "**%d passed**, **%d failed**, %d skipped", passed, failed, skipped)
+	if len(flaky) > 0 {
+		fmt.Fprintf(&b, ", %d flaky", len(flaky))
+	}
+	if len(quarantined) > 0 {
+		fmt.Fprintf(&b, ", %d quarantined", len(quarantined))
+	}
+	fmt.Fprintf(&b, " in %s.\n", run.Duration.Round(time.Second))
+
+	var broken []report.TestResult
+	for _, t := range run.Tests {
+		switch t.Status {
+		case report.StatusPassed, report.StatusSkipped, report.StatusFlaky, report.StatusQuarantined:
+		default:
+			broken = append(broken, t)
+		}
+	}
+	if len(broken) > 0 {
+		b.WriteString("\n| Failed test | Reason | Error |\n| --- | --- | --- |\n")
+		for _, t := range listed(broken) {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", t.ID, cmp.Or(t.Reason, "-"), cell(t.Error))
+		}
+		more(&b, len(broken))
+	}
+	for _, group := range []struct {
+		title string
+		tests []report.TestResult
+	}{{"Flaky (passed on retry)", flaky}, {"Quarantined", quarantined}} {
+		if len(group.tests) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s: ", group.title)
+		for i, t := range listed(group.tests) {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "`%s`", t.ID)
+		}
+		b.WriteString("\n")
+		more(&b, len(group.tests))
+	}
+
+	if slowest := Slowest(run.Latency, maxSlowest); len(slowest) > 0 {
+		b.WriteString("\n<details><summary>Slowest tools</summary>\n\n| Server | Tool | Calls | Avg | P95 |\n| --- | --- | --- | --- | --- |\n")
+		for _, l := range slowest {
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %s | %s |\n", l.Server, l.Tool, l.Calls, round(l.Avg), round(l.P95))
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	var footer []string
+	if artifactsURL != "" {
+		footer = append(footer, fmt.Sprintf("[Artifacts](%s)", artifactsURL))
+	}
+	if run.Commit != "" {
+		footer = append(footer, "commit "+short(run.Commit))
+	}
+	if run.Harness != "" {
+		footer = append(footer, "harness "+run.Harness)
+	}
+	footer = append(footer, fmt.Sprintf("seed %d", run.Seed))
+	fmt.Fprintf(&b, "\n<sub>%s</sub>\n", strings.Join(footer, " · "))
+	return b.String()
+}
+
+// Slowest returns up to n rows of latency with the highest P95, slowest
+// first.
+func Slowest(latency []report.ToolLatency, n int) []report.ToolLatency {
+	rows := slices.Clone(latency)
+	slices.SortStableFunc(rows, func(a, b report.ToolLatency) int { return cmp.Compare(b.P95, a.P95) })
+	return rows[:min(n, len(rows))]
+}
+
+func listed(tests []report.TestResult) []report.TestResult {
+	return tests[:min(maxListed, len(tests))]
+}
+
+func more(b *strings.Builder, n int) {
+	if n > maxListed {
+		fmt.Fprintf(b, "\n…and %d more.\n", n-maxListed)
+	}
+}
+
+// cell returns the first line of text, shortened and escaped for a table
+// cell.
+func cell(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if line == "" {
+		return "-"
+	}
+	if r := []rune(line); len(r) > maxErrorLine {
+		line = string(r[:maxErrorLine]) + "…"
+	}
+	return strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(line)
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+func short(sha string) string {
+	return sha[:min(12, len(sha))]
+}
+
+// repoName matches OWNER/REPO.
+var repoName = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// ValidRepo checks that repo is OWNER/REPO.
+func ValidRepo(repo string) error {
+	if !repoName.MatchString(repo) {
+		return fmt.Errorf("invalid GitHub repository %q; want OWNER/REPO", repo)
+	}
+	return nil
+}
+
+// pullRef matches the refs GitHub Actions checks pull requests out at.
+var pullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// PullRequest returns the number of the pull request ref names, such as
+// $GITHUB_REF in a pull_request workflow, or 0.
+func PullRequest(ref string) int {
+	m := pullRef.FindStringSubmatch(ref)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// Publisher posts run summaries to a pull request.
+type Publisher struct {
+	// Repo is OWNER/REPO.
+	Repo string
+	// PR is the pull request number.
+	PR int
+	// Token is a token that may write the repository's pull requests and
+	// statuses, such as $GITHUB_TOKEN.
+	Token string
+	// ArtifactsURL, if set, is linked from the comment and the status,
+	// e.g. the CI job's page.
+	ArtifactsURL string
+	// Endpoint overrides the REST API base URL, e.g. for GitHub Enterprise.
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// Publish comments the summary of run on the pull request, editing the
+// comment of an earlier run if there is one, and sets the StatusContext
+// status of the pull request's head commit. That is the commit the PR shows
+// checks for; run.Commit is often the merge commit CI checked out instead.
+func (p *Publisher) Publish(ctx context.Context, run *report.Run) error {
+	if err := ValidRepo(p.Repo); err != nil {
+		return err
+	}
+	if p.PR <= 0 {
+		return fmt.Errorf("no pull request of %s to comment on", p.Repo)
+	}
+	if p.Token == "" {
+		return fmt.Errorf("no token to comment on %s#%d with", p.Repo, p.PR)
+	}
+	body := map[string]string{"body": Comment(run, p.ArtifactsURL)}
+	id, err := p.findComment(ctx)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		err = p.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", p.Repo, id), body, nil)
+	} else {
+		err = p.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", p.Repo, p.PR), body, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to comment on %s#%d: %w", p.Repo, p.PR, err)
+	}
+	var pull struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := p.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", p.Repo, p.PR), nil, &pull); err != nil {
+		return fmt.Errorf("failed to look up the head of %s#%d: %w", p.Repo, p.PR, err)
+	}
+	if pull.Head.SHA == "" {
+		return fmt.Errorf("%s#%d has no head commit to set the status of", p.Repo, p.PR)
+	}
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", p.Repo, pull.Head.SHA), status(run, p.ArtifactsURL), nil); err != nil {
+		return fmt.Errorf("failed to set the status of %s: %w", short(pull.Head.SHA), err)
+	}
+	return nil
+}
+
+// findComment returns the ID of the pull request's comment that starts with
+// Marker, or 0.
+func (p *Publisher) findComment(ctx context.Context) (int64, error) {
+	const perPage = 100
+	for page := 1; ; page++ {
+		var comments []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", p.Repo, p.PR, perPage, page)
+		if err := p.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return 0, fmt.Errorf("failed to list the comments of %s#%d: %w", p.Repo, p.PR, err)
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.Body, Marker) {
+				return c.ID, nil
+			}
+		}
+		if len(comments) < perPage {
+			return 0, nil
+		}
+	}
+}
+
+// status returns the commit status request for run.
+func status(run *report.Run, targetURL string) map[string]string {
+	passed, failed, _ := run.Counts()
+	s := map[string]string{
+		"state":       "success",
+		"context":     StatusContext,
+		"description": fmt.Sprintf("%d passed, %d failed", passed, failed),
+	}
+	if failed > 0 || run.Interrupted != "" {
+		s["state"] = "failure"
+	}
+	if targetURL != "" {
+		s["target_url"] = targetURL
+	}
+	return s
+}
+
+// do sends a request with the JSON of in, if set, and decodes the response
+// into out, if set.
+func (p *Publisher) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(cmp.Or(p.Endpoint, defaultEndpoint), "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
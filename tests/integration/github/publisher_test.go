@@ -0,0 +1,171 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func testRun() *report.Run {
+	return &report.Run{
+		Duration: 90 * time.Second,
+		Seed:     7,
+		Commit:   "0123456789abcdef",
+		Harness:  "v1.2.0",
+		Tests: []report.TestResult{
+			{ID: "a", Status: report.StatusPassed},
+			{ID: "b", Status: report.StatusFailed, Reason: report.ReasonAssertion, Error: "want 1 | got 2\nmore detail"},
+			{ID: "c", Status: report.StatusFlaky, Reason: report.ReasonHang},
+			{ID: "d", Status: report.StatusSkipped},
+		},
+		Latency: []report.ToolLatency{
+			{Server: "gcloud", Tool: "fast", Calls: 3, Avg: time.Millisecond, P95: 2 * time.Millisecond},
+			{Server: "gcloud", Tool: "slow", Calls: 1, Avg: 2 * time.Second, P95: 2 * time.Second},
+		},
+	}
+}
+
+func TestComment(t *testing.T) {
+	got := Comment(testRun(), "https://ci.example/run/1")
+	for _, want := range []string{
+		Marker + "\n",
+		"❌ Integration tests failed",
+		"**1 passed**, **1 failed**, 1 skipped, 1 flaky in 1m30s.",
+		"| `b` | assertion_failed | want 1 \\| got 2 |",
+		"Flaky (passed on retry): `c`",
+		"| gcloud | `slow` | 1 | 2s | 2s |\n| gcloud | `fast` |",
+		"[Artifacts](https://ci.example/run/1) · commit 0123456789ab · harness v1.2.0 · seed 7",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comment lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "more detail") {
+		t.Errorf("comment has the error's later lines:\n%s", got)
+	}
+}
+
+func TestCommentListsAtMostMaxListed(t *testing.T) {
+	run := &report.Run{}
+	for i := range maxListed + 3 {
+		run.Tests = append(run.Tests, report.TestResult{ID: fmt.Sprint("t", i), Status: report.StatusFailed})
+	}
+	got := Comment(run, "")
+	if n := strings.Count(got, "| `t"); n != maxListed {
+		t.Errorf("comment lists %d tests, want %d", n, maxListed)
+	}
+	if !strings.Contains(got, "…and 3 more.") {
+		t.Errorf("comment does not count the unlisted tests:\n%s", got)
+	}
+}
+
+func TestPullRequest(t *testing.T) {
+	for ref, want := range map[string]int{"refs/pull/42/merge": 42, "refs/pull/7/head": 7, "refs/heads/main": 0, "": 0} {
+		if got := PullRequest(ref); got != want {
+			t.Errorf("PullRequest(%q) = %d, want %d", ref, got, want)
+		}
+	}
+}
+
+// fakeGitHub serves the endpoints Publish uses, with comments as the pull
+// request's existing comments, and records the requests it gets.
+func fakeGitHub(t *testing.T, comments []map[string]any) (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		var body map[string]string
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		line := r.Method + " " + r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/pulls/"):
+			requests = append(requests, line)
+			w.Write([]byte(`{"head":{"sha":"fedcba9876543210"}}`))
+			return
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(comments)
+		case strings.Contains(r.URL.Path, "/statuses/"):
+			line += " " + body["state"] + " " + body["description"]
+		default:
+			if !strings.HasPrefix(body["body"], Marker) {
+				t.Errorf("%s body = %q", line, body["body"])
+			}
+		}
+		requests = append(requests, line)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPublish(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		comments []map[string]any
+		want     string
+	}{
+		{"new", []map[string]any{{"id": 1, "body": "LGTM"}}, "POST /repos/o/r/issues/5/comments"},
+		{"update", []map[string]any{{"id": 1, "body": "LGTM"}, {"id": 9, "body": Marker + "\nold"}}, "PATCH /repos/o/r/issues/comments/9"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := fakeGitHub(t, tt.comments)
+			p := &Publisher{Repo: "o/r", PR: 5, Token: "tok", Endpoint: srv.URL}
+			if err := p.Publish(context.Background(), testRun()); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{
+				"GET /repos/o/r/issues/5/comments",
+				tt.want,
+				"GET /repos/o/r/pulls/5",
+				"POST /repos/o/r/statuses/fedcba9876543210 failure 1 passed, 1 failed",
+			}
+			if strings.Join(*requests, "\n") != strings.Join(want, "\n") {
+				t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(*requests, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPublishSetsTheStatusOfTheHeadWithoutACommit(t *testing.T) {
+	srv, requests := fakeGitHub(t, nil)
+	run := testRun()
+	run.Commit = ""
+	p := &Publisher{Repo: "o/r", PR: 5, Token: "tok", Endpoint: srv.URL}
+	if err := p.Publish(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*requests)[len(*requests)-1]; !strings.HasPrefix(got, "POST /repos/o/r/statuses/fedcba9876543210 ") {
+		t.Errorf("last request = %q, want the status of the pull request's head", got)
+	}
+}
+
+func TestPublishErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+	for _, tt := range []struct {
+		p    Publisher
+		want string
+	}{
+		{Publisher{Repo: "o", PR: 5, Token: "tok"}, "want OWNER/REPO"},
+		{Publisher{Repo: "o/r", Token: "tok"}, "no pull request"},
+		{Publisher{Repo: "o/r", PR: 5}, "no token"},
+		{Publisher{Repo: "o/r", PR: 5, Token: "tok", Endpoint: srv.URL}, "403 Forbidden"},
+	} {
+		err := tt.p.Publish(context.Background(), testRun())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Publish(%+v) = %v, want an error containing %q", tt.p, err, tt.want)
+		}
+	}
+}
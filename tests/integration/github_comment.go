@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/github"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// githubFlags select the pull request a run's summary is posted to. They
+// default to the GitHub Actions context, so a pull_request workflow needs no
+// flags beyond -github-comment.
+type githubFlags struct {
+	repo, artifactsURL string
+	pr                 int
+}
+
+func (g *githubFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.repo, "github-repo", os.Getenv("GITHUB_REPOSITORY"), "OWNER/REPO of the pull request to comment on")
+	fs.IntVar(&g.pr, "github-pr", github.PullRequest(os.Getenv("GITHUB_REF")), "number of the pull request to comment on (default: from $GITHUB_REF)")
+	fs.StringVar(&g.artifactsURL, "artifacts-url", actionsRunURL(), "URL of the run's artifacts, linked from the pull request comment and status (default: the GitHub Actions run)")
+}
+
+// publisher returns the publisher of the selected pull request, authorized
+// by $GITHUB_TOKEN, or an error if the pull request or token is missing.
+func (g *githubFlags) publisher() (*github.Publisher, error) {
+	if err := github.ValidRepo(g.repo); err != nil {
+		return nil, err
+	}
+	if g.pr <= 0 {
+		return nil, fmt.Errorf("-github-pr is required outside a pull_request workflow")
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("$GITHUB_TOKEN must be set to comment on %s#%d", g.repo, g.pr)
+	}
+	return &github.Publisher{Repo: g.repo, PR: g.pr, Token: token, ArtifactsURL: g.artifactsURL, Endpoint: os.Getenv("GITHUB_API_URL")}, nil
+}
+
+// githubSink returns p as a reporting sink.
+func githubSink(p *github.Publisher) reportSink {
+	return reportSink{
+		name:      "GitHub",
+		publish:   p.Publish,
+		published: fmt.Sprintf("💬 Commented the run summary on %s#%d", p.Repo, p.PR),
+	}
+}
+
+// actionsRunURL returns the page of the current GitHub Actions run, whose
+// artifacts section holds what the job uploaded, or "" outside Actions.
+func actionsRunURL() string {
+	server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+}
+
+// runGitHubComment implements `github-comment [-github-repo OWNER/REPO]
+// [-github-pr N] [-artifacts-url URL] <results.json>`, posting the summary of
+// a finished run, e.g. one merged from shards, to a pull request.
+func runGitHubComment(args []string) int {
+	fs := flag.NewFlagSet("github-comment", flag.ContinueOnError)
+	var gh githubFlags
+	gh.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test github-comment [-github-repo OWNER/REPO] [-github-pr N] [-artifacts-url URL] <results.json>")
+		return exitUsage
+	}
+	publisher, err := gh.publisher()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	results, err := report.ReadJSON(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := publisher.Publish(ctx, results); err != nil {
+		logf(slog.LevelError, "❌ %v\n", err)
+		return exitFail
+	}
+	logger.Println(githubSink(publisher).published)
+	return exitPass
+}
@@ -1,12 +1,13 @@
-module integration
+module github.com/googleapis/gcloud-mcp/tests/integration
 
 go 1.23.0
 
 toolchain go1.24.4
 
-require github.com/modelcontextprotocol/go-sdk v1.0.0
-
 require (
-	github.com/google/jsonschema-go v0.3.0 // indirect
-	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	github.com/google/jsonschema-go v0.3.0
+	github.com/modelcontextprotocol/go-sdk v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
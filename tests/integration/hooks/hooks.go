@@ -0,0 +1,174 @@
+// Package hooks runs site-specific integrations at points of a run's
+// lifecycle, such as filing a ticket for a failed test or warming a cache
+// before the first one, without forking the harness.
+//
+// A hook is either a command from the hooks file, which receives the event
+// as JSON on stdin, or a Go function a site's build registers with Register
+// from an init function in a file of its own.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// Event is a point of the run's lifecycle.
+type Event string
+
+const (
+	// PreRun fires once the tests are selected, before the first one runs.
+	PreRun Event = "pre-run"
+	// PostRun fires after the run, before the reports are written.
+	PostRun Event = "post-run"
+	// OnFailure fires once for every failed test, after the run.
+	OnFailure Event = "on-failure"
+)
+
+var events = []Event{PreRun, PostRun, OnFailure}
+
+// DefaultTimeout bounds a hook without a timeout of its own.
+const DefaultTimeout = time.Minute
+
+// Hook is a command run at an event.
+type Hook struct {
+	On      Event    `yaml:"on"`
+	Command []string `yaml:"command"`
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Required makes the hook's failure fail the run. Other hooks only
+	// warn.
+	Required bool `yaml:"required,omitempty"`
+}
+
+func (h *Hook) String() string {
+	return fmt.Sprintf("%s hook %s", h.On, strings.Join(h.Command, " "))
+}
+
+// Config is the parsed hooks file.
+type Config struct {
+	Hooks []Hook `yaml:"hooks"`
+}
+
+// Load reads a hooks file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*Config, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse hooks file %s: %w", path, err)
+	}
+	for i, h := range c.Hooks {
+		if !validEvent(h.On) {
+			return nil, fmt.Errorf("%s: hook %d: unknown event %q; want %s, %s or %s", path, i+1, h.On, PreRun, PostRun, OnFailure)
+		}
+		if len(h.Command) == 0 {
+			return nil, fmt.Errorf("%s: hook %d needs a command", path, i+1)
+		}
+	}
+	return &c, nil
+}
+
+func validEvent(e Event) bool {
+	for _, v := range events {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Func is a hook compiled into the harness. payload is the event's JSON.
+type Func func(ctx context.Context, event Event, payload []byte) error
+
+type registered struct {
+	on   Event
+	name string
+	f    Func
+}
+
+var funcs []registered
+
+// Register adds f, named name in logs, to the hooks of event on. Call it from
+// an init function; Go hooks run before the hooks file's commands and their
+// failures fail the run.
+func Register(on Event, name string, f Func) {
+	if !validEvent(on) {
+		panic(fmt.Sprintf("hooks: unknown event %q", on))
+	}
+	funcs = append(funcs, registered{on, name, f})
+}
+
+// Fire runs every hook of event with payload, encoded as JSON, on stdin,
+// writing their output to out. It returns the failures of required hooks;
+// the others are written to out as warnings. A nil Config runs only the
+// registered Go hooks.
+func (c *Config) Fire(ctx context.Context, event Event, payload any, out io.Writer) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode the %s event: %w", event, err)
+	}
+	var errs []error
+	for _, r := range funcs {
+		if r.on != event {
+			continue
+		}
+		if err := r.f(ctx, event, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %s failed: %w", event, r.name, err))
+		}
+	}
+	if c == nil {
+		return errors.Join(errs...)
+	}
+	for i := range c.Hooks {
+		h := &c.Hooks[i]
+		if h.On != event {
+			continue
+		}
+		err := h.run(ctx, data, out)
+		switch {
+		case err == nil:
+		case h.Required:
+			errs = append(errs, err)
+		default:
+			fmt.Fprintf(out, "⚠️  %v\n", err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Hook) run(ctx context.Context, payload []byte, out io.Writer) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(), "MCP_IT_EVENT="+string(h.On))
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout, cmd.Stderr = out, out
+	err := subprocess.Default.Run(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %s", h, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", h, err)
+	}
+	return nil
+}
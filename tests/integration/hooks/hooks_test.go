@@ -0,0 +1,87 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	os.WriteFile(path, []byte(`hooks:
+  - on: on-failure
+    command: [./file-ticket.sh, --queue, mcp]
+    timeout: 30s
+    required: true
+`), 0o644)
+	c, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Hooks) != 1 || c.Hooks[0].On != OnFailure || c.Hooks[0].Timeout != 30*time.Second || !c.Hooks[0].Required {
+		t.Errorf("Load = %+v", c.Hooks)
+	}
+	if c, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), true); err != nil || len(c.Hooks) != 0 {
+		t.Errorf("Load(missing, optional) = %+v, %v", c, err)
+	}
+
+	for _, bad := range []string{"hooks: [{on: pre-test, command: [x]}]", "hooks: [{on: pre-run}]"} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := Load(path, false); err == nil {
+			t.Errorf("Load(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestFire(t *testing.T) {
+	dir := t.TempDir()
+	got := filepath.Join(dir, "payload.json")
+	c := &Config{Hooks: []Hook{
+		{On: PostRun, Command: []string{"sh", "-c", `cat > ` + got + `; echo "ran $MCP_IT_EVENT"`}},
+		{On: PreRun, Command: []string{"false"}},
+		{On: PostRun, Command: []string{"false"}},
+	}}
+	var out strings.Builder
+	if err := c.Fire(context.Background(), PostRun, map[string]int{"failed": 2}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(got); string(data) != `{"failed":2}` {
+		t.Errorf("hook read %q from stdin", data)
+	}
+	if !strings.Contains(out.String(), "ran post-run\n") || !strings.Contains(out.String(), "⚠️  post-run hook false failed") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	c.Hooks[2].Required = true
+	if err := c.Fire(context.Background(), PostRun, nil, &out); err == nil || !strings.Contains(err.Error(), "post-run hook false failed") {
+		t.Errorf("Fire with a failing required hook = %v", err)
+	}
+}
+
+func TestFireTimeout(t *testing.T) {
+	c := &Config{Hooks: []Hook{{On: PreRun, Command: []string{"sleep", "10"}, Timeout: 50 * time.Millisecond, Required: true}}}
+	if err := c.Fire(context.Background(), PreRun, nil, &strings.Builder{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Fire = %v, want a timeout", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func(saved []registered) { funcs = saved }(funcs)
+	var payload string
+	Register(OnFailure, "ticket", func(_ context.Context, _ Event, data []byte) error {
+		payload = string(data)
+		return errors.New("tracker down")
+	})
+	var nilConfig *Config
+	err := nilConfig.Fire(context.Background(), OnFailure, map[string]string{"test": "a"}, &strings.Builder{})
+	if payload != `{"test":"a"}` || err == nil || !strings.Contains(err.Error(), "on-failure hook ticket failed: tracker down") {
+		t.Errorf("payload %q, err %v", payload, err)
+	}
+	if err := nilConfig.Fire(context.Background(), PreRun, nil, &strings.Builder{}); err != nil {
+		t.Errorf("Fire(pre-run) = %v, want no hooks", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package impact maps changed files to the integration tests they can affect,
+// so CI for a pull request only runs the relevant part of the suite.
+package impact
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule selects tests when any changed file matches one of its paths.
+type Rule struct {
+	// Paths are globs relative to the repository root. "*" matches within a
+	// path segment and "**" across segments.
+	Paths []string `yaml:"paths"`
+	// Tests are test ID globs, matched with path.Match.
+	Tests []string `yaml:"tests"`
+}
+
+// Mapping is the contents of an impact mapping file.
+type Mapping struct {
+	Rules []Rule `yaml:"rules"`
+	// Unmatched decides what a changed file matching no rule selects: "all"
+	// (the default, so unknown changes are never silently untested) or
+	// "none".
+	Unmatched string `yaml:"unmatched"`
+}
+
+// Load reads a YAML mapping file.
+func Load(file string) (*Mapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m Mapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse impact mapping %s: %w", file, err)
+	}
+	switch m.Unmatched {
+	case "":
+		m.Unmatched = "all"
+	case "all", "none":
+	default:
+		return nil, fmt.Errorf("impact mapping %s: unmatched must be \"all\" or \"none\", got %q", file, m.Unmatched)
+	}
+	for _, r := range m.Rules {
+		for _, p := range r.Tests {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("impact mapping %s: bad test pattern %q: %w", file, p, err)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// Selection is the outcome of Select.
+type Selection struct {
+	// Tests are the selected test IDs, in registration order.
+	Tests []string
+	// Unmatched lists changed files no rule covered.
+	Unmatched []string
+}
+
+// Select returns the tests among testIDs affected by changedFiles.
+func (m *Mapping) Select(changedFiles, testIDs []string) Selection {
+	var sel Selection
+	patterns := map[string]bool{}
+	for _, file := range changedFiles {
+		file = strings.TrimPrefix(path.Clean(strings.TrimSpace(file)), "./")
+		if file == "." {
+			continue
+		}
+		matched := false
+		for _, r := range m.Rules {
+			if slices.ContainsFunc(r.Paths, func(glob string) bool { return matchPath(glob, file) }) {
+				matched = true
+				for _, t := range r.Tests {
+					patterns[t] = true
+				}
+			}
+		}
+		if !matched {
+			sel.Unmatched = append(sel.Unmatched, file)
+		}
+	}
+	if len(sel.Unmatched) > 0 && m.Unmatched == "all" {
+		patterns["*"] = true
+	}
+
+	for _, id := range testIDs {
+		for p := range patterns {
+			if ok, _ := path.Match(p, id); ok {
+				sel.Tests = append(sel.Tests, id)
+				break
+			}
+		}
+	}
+	return sel
+}
+
+// RunFilter returns the -run expression selecting exactly ids.
+func RunFilter(ids []string) string {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = regexp.QuoteMeta(id)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// matchPath reports whether file matches glob, where "**" spans directories.
+func matchPath(glob, file string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				// "**/" also matches zero directories.
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	ok, _ := regexp.MatchString(re.String(), file)
+	return ok
+}
@@ -0,0 +1,82 @@
+package impact
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		glob, file string
+		want       bool
+	}{
+		{"packages/gcloud-mcp/**", "packages/gcloud-mcp/src/index.ts", true},
+		{"packages/gcloud-mcp/**", "packages/gcloud-mcp-extra/x.ts", false},
+		{"packages/*/package.json", "packages/storage-mcp/package.json", true},
+		{"packages/*/package.json", "packages/storage-mcp/src/package.json", false},
+		{"**/*.md", "README.md", true},
+		{"**/*.md", "doc/denylist.md", true},
+		{"package?.json", "packages.json", true},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.glob, tt.file); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.glob, tt.file, got, tt.want)
+		}
+	}
+}
+
+var testIDs = []string{"gemini-mcp-list", "gcloud-tool-call", "gcloud-denied-command", "tool-catalog-gcloud", "tool-catalog-storage"}
+
+func TestSelect(t *testing.T) {
+	m := &Mapping{Unmatched: "none", Rules: []Rule{
+		{Paths: []string{"packages/gcloud-mcp/**"}, Tests: []string{"gcloud-*", "tool-catalog-gcloud"}},
+		{Paths: []string{"packages/storage-mcp/**"}, Tests: []string{"tool-catalog-storage"}},
+		{Paths: []string{"**/*.md"}},
+	}}
+
+	sel := m.Select([]string{"./packages/gcloud-mcp/src/denylist.ts", "README.md", "LICENSE"}, testIDs)
+	if want := []string{"gcloud-tool-call", "gcloud-denied-command", "tool-catalog-gcloud"}; !slices.Equal(sel.Tests, want) {
+		t.Errorf("Tests = %q, want %q", sel.Tests, want)
+	}
+	if !slices.Equal(sel.Unmatched, []string{"LICENSE"}) {
+		t.Errorf("Unmatched = %q", sel.Unmatched)
+	}
+
+	m.Unmatched = "all"
+	if sel := m.Select([]string{"LICENSE"}, testIDs); len(sel.Tests) != len(testIDs) {
+		t.Errorf("unmatched file with unmatched=all selected %q", sel.Tests)
+	}
+	if sel := m.Select([]string{"README.md"}, testIDs); len(sel.Tests) != 0 {
+		t.Errorf("docs-only change selected %q", sel.Tests)
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	re := regexp.MustCompile(RunFilter([]string{"gcloud-tool-call", "a.b"}))
+	for id, want := range map[string]bool{"gcloud-tool-call": true, "a.b": true, "axb": false, "gcloud-tool-call-2": false} {
+		if re.MatchString(id) != want {
+			t.Errorf("filter matches %q = %v, want %v", id, !want, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "impact.yaml")
+	os.WriteFile(file, []byte("rules:\n  - paths: [\"a/**\"]\n    tests: [\"x\"]\n"), 0o644)
+	m, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Unmatched != "all" || len(m.Rules) != 1 {
+		t.Errorf("Load() = %+v", m)
+	}
+
+	os.WriteFile(file, []byte("unmatched: some\n"), 0o644)
+	if _, err := Load(file); err == nil {
+		t.Error("expected error for bad unmatched value")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+	"github.com/googleapis/gcloud-mcp/tests/integration/container"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+)
+
+// defaultContainerPins is the file of the versions built into the image of
+// -in-container.
+const defaultContainerPins = "container.yaml"
+
+// containerOptions are the -container-* flags.
+type containerOptions struct {
+	image, pins, runtime, manifest string
+	// lookupCacheTTL is how long the servers' resolved versions are reused.
+	lookupCacheTTL time.Duration
+}
+
+// runInContainer runs the harness with args in the suite's container and
+// returns its exit code. Unless opts.image names a prebuilt image, which the
+// runtime pulls if missing, it first builds one from the Dockerfile in the
+// working directory with the versions of the pins file and of the manifest's
+// servers, pinned to what they resolve to now.
+func runInContainer(args []string, opts containerOptions) int {
+	if _, err := exec.LookPath(opts.runtime); err != nil {
+		fmt.Fprintf(os.Stderr, "-in-container runs the suite with %s: %v\n", opts.runtime, err)
+		return exitUsage
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	image := opts.image
+	if image == "" {
+		if _, err := os.Stat("Dockerfile"); err != nil {
+			fmt.Fprintln(os.Stderr, "-in-container builds the image from ./Dockerfile; run it from the harness's directory or pass -container-image")
+			return exitUsage
+		}
+		pins, err := container.Load(opts.pins)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		manifest, err := bootstrap.Load(opts.manifest)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		build, err := containerBuild(pins, manifest, opts.lookupCacheTTL)
+		if err != nil {
+			logf(slog.LevelError, "❌ %v\n", err)
+			return exitFail
+		}
+		image = build.Tag()
+		logger.Printf("🐳 Building %s: node %s, Gemini CLI %s, gcloud SDK %s, %s\n",
+			image, build.Pins.Node, build.Pins.GeminiCLI, build.Pins.GcloudSDK, strings.Join(build.Servers, ", "))
+		cmd := exec.Command(opts.runtime, build.Args()...)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			logf(slog.LevelError, "❌ Building the container image failed: %v\n", err)
+			return exitFail
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	mounts, env := container.Credentials(os.Getenv, home)
+	// The checkout's .git is usually above the mounted directory.
+	if sha := detectCommit(); sha != "" {
+		env = append(env, "COMMIT_SHA="+sha)
+	}
+	passThrough := append(slices.Clone(container.PassThrough), features.EnvVar, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	for _, s := range serverRegistry.All() {
+		passThrough = append(passThrough, s.Env...)
+	}
+	// A variable the credentials set, such as GOOGLE_APPLICATION_CREDENTIALS,
+	// points into the container and must not be replaced by the host's.
+	for _, name := range passThrough {
+		if !slices.ContainsFunc(env, func(e string) bool { return e == name || strings.HasPrefix(e, name+"=") }) {
+			env = append(env, name)
+		}
+	}
+	r := &container.Run{
+		Image:       image,
+		WorkDir:     wd,
+		Mounts:      mounts,
+		Env:         env,
+		Terminal:    isTerminal(os.Stdin) && isTerminal(os.Stdout),
+		HarnessArgs: args,
+	}
+	logger.Printf("🐳 Running the suite in %s\n", image)
+	cmd := exec.Command(opts.runtime, r.Args()...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return exitPass
+	// The runtime exits with 125 to 127 when it cannot start the container;
+	// anything else is the harness's own exit code.
+	case errors.As(err, &exit) && exit.ExitCode() < 125:
+		return exit.ExitCode()
+	default:
+		logf(slog.LevelError, "❌ Running the container failed: %v\n", err)
+		return exitFail
+	}
+}
+
+// containerBuild returns the image build of pins and the exact versions the
+// servers of manifest resolve to now.
+func containerBuild(pins *container.Pins, manifest *bootstrap.Manifest, lookupCacheTTL time.Duration) (*container.Build, error) {
+	b := &bootstrap.Bootstrapper{Cache: cache.Default(lookupCacheTTL)}
+	build := &container.Build{Pins: *pins, Context: ".", HarnessVersion: version}
+	for i := range manifest.Servers {
+		s := &manifest.Servers[i]
+		version, err := b.Resolve(context.Background(), s)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", s.Spec(), err)
+		}
+		build.Servers = append(build.Servers, s.Package+"@"+version)
+	}
+	return build, nil
+}
+
+// isTerminal reports whether f looks like a terminal rather than a pipe or
+// file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
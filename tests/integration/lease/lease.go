@@ -0,0 +1,251 @@
+// Package lease takes exclusive, expiring leases on named resources, such as
+// a test project, so concurrent runs do not use one at the same time.
+//
+// A lease is an object in a Cloud Storage bucket, created only if it does not
+// exist yet, which makes creating it atomic. The object records its holder and
+// when the lease expires; a lease its holder never released, e.g. because the
+// run was killed, is taken over once it has expired.
+package lease
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+)
+
+// DefaultTTL is how long a lease lasts unless the Locker sets TTL. It is
+// longer than a full run, so only an abandoned lease expires.
+const DefaultTTL = 3 * time.Hour
+
+const defaultStorage = "https://storage.googleapis.com"
+
+// objectPrefix starts the names of the lock objects in the bucket.
+const objectPrefix = "leases/"
+
+// ErrHeld is wrapped by the error of Acquire when another holder has the
+// lease.
+var ErrHeld = errors.New("lease is held")
+
+// Locker takes leases through lock objects in a bucket.
+type Locker struct {
+	Bucket string
+	// Holder identifies the run taking leases, e.g. by host and run ID, to
+	// whoever finds a lease taken.
+	Holder string
+	// TTL is how long a lease lasts. Defaults to DefaultTTL.
+	TTL time.Duration
+	// Token returns an access token. Defaults to gcloudauth.AccessToken.
+	Token func(context.Context) (string, error)
+	// Storage overrides the Cloud Storage base URL.
+	Storage    string
+	HTTPClient *http.Client
+	// now overrides the clock in tests.
+	now func() time.Time
+}
+
+// Lease is a lease a Locker holds.
+type Lease struct {
+	Name    string
+	Expires time.Time
+
+	locker     *Locker
+	generation string
+}
+
+// record is the content of a lock object.
+type record struct {
+	Holder   string    `json:"holder"`
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Acquire takes the lease on name. If another holder has it and it has not
+// expired, it returns an error wrapping ErrHeld that says who holds it.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	token, err := l.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get an access token: %w", err)
+	}
+	// Each round either creates the object or removes an expired one; two
+	// rounds are only exceeded when another run took the lease over first.
+	for range 2 {
+		now := l.clock()
+		rec := record{Holder: l.Holder, Acquired: now, Expires: now.Add(l.ttl())}
+		generation, status, err := l.create(ctx, token, name, rec)
+		if err == nil {
+			return &Lease{Name: name, Expires: rec.Expires, locker: l, generation: generation}, nil
+		}
+		if status != http.StatusPreconditionFailed {
+			return nil, fmt.Errorf("failed to take the lease on %s: %w", name, err)
+		}
+		held, generation, status, err := l.read(ctx, token, name)
+		if status == http.StatusNotFound {
+			// Released since; try again.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the lease on %s: %w", name, err)
+		}
+		if now.Before(held.Expires) {
+			return nil, fmt.Errorf("%w: %s by %s until %s", ErrHeld, name, held.Holder, held.Expires.Format(time.RFC3339))
+		}
+		// Remove the expired lease only if it is still the one read, so two
+		// runs taking it over do not both succeed.
+		status, err = l.remove(ctx, token, name, generation)
+		if err != nil && status != http.StatusNotFound && status != http.StatusPreconditionFailed {
+			return nil, fmt.Errorf("failed to take over the expired lease on %s: %w", name, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s was taken over by another run", ErrHeld, name)
+}
+
+// Wait takes the lease on name like Acquire, but while another holder has
+// it, it tries again every poll until ctx is done, calling held with the
+// holder's error before each wait.
+func (l *Locker) Wait(ctx context.Context, name string, poll time.Duration, held func(error)) (*Lease, error) {
+	for {
+		le, err := l.Acquire(ctx, name)
+		if !errors.Is(err, ErrHeld) {
+			return le, err
+		}
+		held(err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting: %w", err)
+		case <-time.After(poll):
+		}
+	}
+}
+
+// Release gives the lease up. It fails if the lease expired and another
+// holder took it over meanwhile.
+func (le *Lease) Release(ctx context.Context) error {
+	l := le.locker
+	token, err := l.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get an access token: %w", err)
+	}
+	status, err := l.remove(ctx, token, le.Name, le.generation)
+	if status == http.StatusNotFound || status == http.StatusPreconditionFailed {
+		return fmt.Errorf("lease on %s expired at %s and was taken over", le.Name, le.Expires.Format(time.RFC3339))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release the lease on %s: %w", le.Name, err)
+	}
+	return nil
+}
+
+func (l *Locker) object(name string) string {
+	return objectPrefix + name
+}
+
+// create creates the lock object of name unless it exists and returns its
+// generation.
+func (l *Locker) create(ctx context.Context, token, name string, rec record) (string, int, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", 0, err
+	}
+	u := l.storage() + "/upload/storage/v1/b/" + url.PathEscape(l.Bucket) + "/o?uploadType=media&ifGenerationMatch=0&name=" + url.QueryEscape(l.object(name))
+	body, _, status, err := l.do(ctx, http.MethodPost, u, token, data)
+	if err != nil {
+		return "", status, err
+	}
+	var created struct {
+		Generation string `json:"generation"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", status, err
+	}
+	return created.Generation, status, nil
+}
+
+// read returns the record of the lock object of name and its generation.
+func (l *Locker) read(ctx context.Context, token, name string) (record, string, int, error) {
+	u := l.storage() + "/storage/v1/b/" + url.PathEscape(l.Bucket) + "/o/" + url.PathEscape(l.object(name)) + "?alt=media"
+	body, header, status, err := l.do(ctx, http.MethodGet, u, token, nil)
+	if err != nil {
+		return record{}, "", status, err
+	}
+	var rec record
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return record{}, "", status, fmt.Errorf("lock object is not a lease: %w", err)
+	}
+	return rec, header.Get("X-Goog-Generation"), status, nil
+}
+
+// remove deletes the lock object of name if it is still at generation.
+func (l *Locker) remove(ctx context.Context, token, name, generation string) (int, error) {
+	u := l.storage() + "/storage/v1/b/" + url.PathEscape(l.Bucket) + "/o/" + url.PathEscape(l.object(name)) + "?ifGenerationMatch=" + url.QueryEscape(generation)
+	_, _, status, err := l.do(ctx, http.MethodDelete, u, token, nil)
+	return status, err
+}
+
+func (l *Locker) do(ctx context.Context, method, u, token string, data []byte) ([]byte, http.Header, int, error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := l.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, resp.StatusCode, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, nil, resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(body[:min(len(body), 4096)])))
+	}
+	return body, resp.Header, resp.StatusCode, nil
+}
+
+func (l *Locker) ttl() time.Duration {
+	if l.TTL > 0 {
+		return l.TTL
+	}
+	return DefaultTTL
+}
+
+func (l *Locker) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+func (l *Locker) storage() string {
+	if l.Storage != "" {
+		return l.Storage
+	}
+	return defaultStorage
+}
+
+func (l *Locker) token(ctx context.Context) (string, error) {
+	if l.Token != nil {
+		return l.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
@@ -0,0 +1,148 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBucket serves the object create, read and delete calls of a bucket,
+// with their generation preconditions.
+type fakeBucket struct {
+	mu         sync.Mutex
+	objects    map[string]string
+	generation map[string]int
+	next       int
+}
+
+func newFakeBucket() *fakeBucket {
+	return &fakeBucket{objects: map[string]string{}, generation: map[string]int{}}
+}
+
+func (f *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthenticated", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/locks/o":
+		name := r.URL.Query().Get("name")
+		if r.URL.Query().Get("ifGenerationMatch") != "0" {
+			http.Error(w, "unconditional write", http.StatusBadRequest)
+			return
+		}
+		if _, ok := f.objects[name]; ok {
+			http.Error(w, "exists", http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.next++
+		f.objects[name], f.generation[name] = string(body), f.next
+		w.Write([]byte(`{"generation": "` + strconv.Itoa(f.next) + `"}`))
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/locks/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/locks/o/")
+		content, ok := f.objects[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Header().Set("X-Goog-Generation", strconv.Itoa(f.generation[name]))
+			w.Write([]byte(content))
+			return
+		}
+		if r.URL.Query().Get("ifGenerationMatch") != strconv.Itoa(f.generation[name]) {
+			http.Error(w, "generation changed", http.StatusPreconditionFailed)
+			return
+		}
+		delete(f.objects, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	bucket := newFakeBucket()
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	locker := func(holder string) *Locker {
+		return &Locker{
+			Bucket:  "locks",
+			Holder:  holder,
+			TTL:     time.Hour,
+			Token:   func(context.Context) (string, error) { return "token", nil },
+			Storage: srv.URL,
+			now:     func() time.Time { return now },
+		}
+	}
+	ctx := context.Background()
+	a, b := locker("run-a"), locker("run-b")
+
+	lease, err := a.Acquire(ctx, "pool-1")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, ok := bucket.objects["leases/pool-1"]; !ok {
+		t.Fatalf("no lock object in %v", bucket.objects)
+	}
+	if _, err := b.Acquire(ctx, "pool-1"); !errors.Is(err, ErrHeld) || !strings.Contains(err.Error(), "run-a") {
+		t.Errorf("Acquire of a held lease = %v, want ErrHeld naming run-a", err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := b.Acquire(ctx, "pool-1"); err != nil {
+		t.Errorf("Acquire of a released lease: %v", err)
+	}
+
+	// A lease run-a never released is taken over once expired, and run-a
+	// then learns it lost it.
+	abandoned, err := a.Acquire(ctx, "pool-2")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := b.Acquire(ctx, "pool-2"); err != nil {
+		t.Errorf("Acquire of an expired lease: %v", err)
+	}
+	if err := abandoned.Release(ctx); err == nil || !strings.Contains(err.Error(), "taken over") {
+		t.Errorf("Release of a lease taken over = %v", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	srv := httptest.NewServer(newFakeBucket())
+	defer srv.Close()
+	locker := func(holder string) *Locker {
+		return &Locker{Bucket: "locks", Holder: holder, Token: func(context.Context) (string, error) { return "token", nil }, Storage: srv.URL}
+	}
+	ctx := context.Background()
+	first, err := locker("run-a").Acquire(ctx, "project")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	waits := 0
+	second, err := locker("run-b").Wait(ctx, "project", time.Millisecond, func(err error) {
+		if waits++; waits == 2 {
+			first.Release(ctx)
+		}
+	})
+	if err != nil || waits != 2 {
+		t.Fatalf("Wait = %v after %d waits, want the lease after 2", err, waits)
+	}
+	waiting, stop := context.WithCancel(ctx)
+	if _, err := locker("run-c").Wait(waiting, "project", time.Hour, func(error) { stop() }); !errors.Is(err, ErrHeld) {
+		t.Errorf("Wait given up = %v, want ErrHeld", err)
+	}
+	second.Release(ctx)
+}
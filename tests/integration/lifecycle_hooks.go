@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/hooks"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/shard"
+)
+
+// defaultHooksFile is the -hooks default, which may be absent.
+const defaultHooksFile = "hooks.yaml"
+
+// preRunEvent is the pre-run payload: what is about to run.
+type preRunEvent struct {
+	Event hooks.Event `json:"event"`
+	Tests []string    `json:"tests"`
+	// Seed is the -seed; zero means the run picks one.
+	Seed     int64             `json:"seed"`
+	Platform string            `json:"platform"`
+	Harness  string            `json:"harness"`
+	Shard    *shard.Spec       `json:"shard,omitempty"`
+	Notes    []report.Note     `json:"notes,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// postRunEvent is the post-run payload: the run's full results.
+type postRunEvent struct {
+	Event hooks.Event `json:"event"`
+	*report.Run
+}
+
+// failureEvent is the on-failure payload: one failed test and the run it
+// failed in.
+type failureEvent struct {
+	Event    hooks.Event       `json:"event"`
+	Test     report.TestResult `json:"test"`
+	Started  time.Time         `json:"started"`
+	Seed     int64             `json:"seed"`
+	Platform string            `json:"platform"`
+	Harness  string            `json:"harness"`
+	Shard    *shard.Spec       `json:"shard,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// firePostRun fires on-failure for every failed test of results, then
+// post-run. It reports whether every required hook succeeded.
+func firePostRun(c *hooks.Config, results *report.Run) bool {
+	ok := true
+	for _, tr := range results.Failures() {
+		event := failureEvent{
+			Event: hooks.OnFailure, Test: tr, Started: results.Started, Seed: results.Seed,
+			Platform: results.Platform, Harness: results.Harness, Shard: results.Shard, Labels: results.Labels,
+		}
+		ok = fireHooks(c, hooks.OnFailure, event) && ok
+	}
+	return fireHooks(c, hooks.PostRun, postRunEvent{hooks.PostRun, results}) && ok
+}
+
+// fireHooks fires event and logs the failures of its required hooks.
+func fireHooks(c *hooks.Config, event hooks.Event, payload any) bool {
+	if err := c.Fire(context.Background(), event, payload, logger.Writer()); err != nil {
+		logf(slog.LevelError, "❌ %v\n", err)
+		return false
+	}
+	return true
+}
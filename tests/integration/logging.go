@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log formats of -log-format.
+const (
+	// logHuman prints the messages as written, for a terminal.
+	logHuman = "human"
+	// logText and logJSON print slog records, with the level and the
+	// running test as attributes, for CI to scrape.
+	logText = "text"
+	logJSON = "json"
+)
+
+var (
+	// logLevel is the least severe level logged; -log-level sets it and
+	// -fast raises it to error.
+	logLevel = new(slog.LevelVar)
+	// summaryOut receives the end-of-run summary, which is not a log record
+	// and is printed as is in every format. -fast discards it.
+	summaryOut io.Writer = os.Stdout
+)
+
+// logWriter is the output of logger. It turns every line written to it
+// into a slog record, at info level unless written with logf, and scoped to
+// the test running when it was written, so the harness's messages can be
+// filtered by level and emitted as JSON. Secrets are masked before anything
+// is written.
+type logWriter struct {
+	mu      sync.Mutex
+	handler slog.Handler
+}
+
+// newLogWriter returns a writer logging to w in format.
+func newLogWriter(w io.Writer, format string) (*logWriter, error) {
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var h slog.Handler
+	switch format {
+	case logHuman:
+		h = &humanHandler{w: w, level: logLevel}
+	case logText:
+		h = slog.NewTextHandler(w, opts)
+	case logJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q; want human, text or json", format)
+	}
+	return &logWriter{handler: h}, nil
+}
+
+// leveledWriter is implemented by the outputs of logger that keep the level
+// logf writes a message at.
+type leveledWriter interface {
+	WriteLevel(level slog.Level, p []byte) (int, error)
+}
+
+// logf logs a message at level, formatted as logger.Printf formats it.
+// logger itself logs at info.
+func logf(level slog.Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	w := logger.Writer()
+	if lw, ok := w.(leveledWriter); ok {
+		lw.WriteLevel(level, []byte(msg))
+		return
+	}
+	w.Write([]byte(msg))
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel logs every line of p at level, so a message's continuation
+// lines, e.g. a diff, go with it.
+func (w *logWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.handler.Enabled(context.Background(), level) {
+		return len(p), nil
+	}
+	var test string
+	if live := liveProgress.Load(); live != nil {
+		test = live.test
+	}
+	lines := strings.Split(strings.TrimSuffix(redactor.String(string(p)), "\n"), "\n")
+	_, human := w.handler.(*humanHandler)
+	for _, line := range lines {
+		if line == "" && !human {
+			continue
+		}
+		r := slog.NewRecord(time.Now(), level, line, 0)
+		if test != "" {
+			r.AddAttrs(slog.String("test", test))
+		}
+		if err := w.handler.Handle(context.Background(), r); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// teeLog writes the log to out and, whatever its level, to capture, e.g. the
+// log of the running test.
+type teeLog struct {
+	mu      sync.Mutex
+	out     io.Writer
+	capture io.Writer
+}
+
+func (t *teeLog) Write(p []byte) (int, error) {
+	return t.WriteLevel(slog.LevelInfo, p)
+}
+
+func (t *teeLog) WriteLevel(level slog.Level, p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var err error
+	if lw, ok := t.out.(leveledWriter); ok {
+		_, err = lw.WriteLevel(level, p)
+	} else {
+		_, err = t.out.Write(p)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return t.capture.Write(p)
+}
+
+// humanHandler prints each record's message alone, as the harness always
+// has.
+type humanHandler struct {
+	w     io.Writer
+	level slog.Leveler
+}
+
+func (h *humanHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *humanHandler) Handle(_ context.Context, r slog.Record) error {
+	_, err := fmt.Fprintln(h.w, r.Message)
+	return err
+}
+
+func (h *humanHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *humanHandler) WithGroup(string) slog.Handler      { return h }
+
+// setUpLogging points logger at stdout in format, logging level and up.
+func setUpLogging(format, level string) error {
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid -log-level %q; want debug, info, warn or error", level)
+	}
+	w, err := newLogWriter(os.Stdout, format)
+	if err != nil {
+		return err
+	}
+	logger.SetOutput(w)
+	return nil
+}
@@ -1,113 +1,1280 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"integration/client"
+	"io"
+	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/artifacts"
+	"github.com/googleapis/gcloud-mcp/tests/integration/bigquery"
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+	"github.com/googleapis/gcloud-mcp/tests/integration/chaos"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+	"github.com/googleapis/gcloud-mcp/tests/integration/container"
+	"github.com/googleapis/gcloud-mcp/tests/integration/coverage"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/emulator"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudconfig"
+	"github.com/googleapis/gcloud-mcp/tests/integration/geminiconfig"
+	"github.com/googleapis/gcloud-mcp/tests/integration/github"
+	"github.com/googleapis/gcloud-mcp/tests/integration/hooks"
+	"github.com/googleapis/gcloud-mcp/tests/integration/impact"
+	"github.com/googleapis/gcloud-mcp/tests/integration/lease"
+	"github.com/googleapis/gcloud-mcp/tests/integration/monitoring"
+	"github.com/googleapis/gcloud-mcp/tests/integration/notify"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/preflight"
+	"github.com/googleapis/gcloud-mcp/tests/integration/pubsub"
+	"github.com/googleapis/gcloud-mcp/tests/integration/quarantine"
+	"github.com/googleapis/gcloud-mcp/tests/integration/redact"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/safety"
+	"github.com/googleapis/gcloud-mcp/tests/integration/selfupdate"
+	"github.com/googleapis/gcloud-mcp/tests/integration/shard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+	"github.com/googleapis/gcloud-mcp/tests/integration/tokens"
+	"github.com/googleapis/gcloud-mcp/tests/integration/tracing"
+)
+
+// Exit codes. exitSkip is the code `git bisect run` treats as "this revision
+// cannot be tested", so a missing prerequisite never marks a commit bad.
+const (
+	exitPass  = 0
+	exitFail  = 1
+	exitUsage = 2
+	exitSkip  = 125
+)
+
+const (
+	defaultQuarantineFile = "quarantine.yaml"
+	defaultFeaturesFile   = "features.yaml"
+	defaultManifestFile   = "servers.yaml"
+	defaultTokenBudgets   = "token_budgets.yaml"
+	defaultBillingBudget  = "billing_budget.yaml"
 )
 
-func testGeminiMcpList() error {
-	fmt.Println("🚀 Starting gcloud-mcp integration test...")
+var (
+	// runCtx is cancelled when a signal stops the harness, ending what waits
+	// on it, such as a Gemini CLI prompt.
+	runCtx, cancelRun = context.WithCancel(context.Background())
+
+	logger = log.New(&logWriter{handler: &humanHandler{w: os.Stdout, level: logLevel}}, "", 0)
+
+	// callDefaults holds harness-wide ToolCall settings applied by invokeTool.
+	callDefaults client.ToolCall
+
+	// servers is the manifest of the servers under test. invokeTool takes
+	// their endpoints from it.
+	servers *bootstrap.Manifest
+
+	// differentialNormalizer, if set, makes invokeTool repeat every call over
+	// each of the server's endpoints and compare the normalized results.
+	differentialNormalizer *differential.Normalizer
+
+	// resultCache, if set, answers repeated Cacheable calls; invokeTool sets
+	// each call's Cache to it.
+	resultCache *client.ResultCache
+
+	// tokenBudgets caps the results of the tools it lists; invokeTool and
+	// openSession look up each call's budget in it.
+	tokenBudgets *tokens.Budgets
+
+	// billingBudget caps the run's billable operations; withDefaults makes
+	// every call charge it.
+	billingBudget *billing.Budget
+
+	// geminiEnv is added to the environment of every gemini command, e.g. to
+	// select a generated settings directory.
+	geminiEnv []string
+)
+
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "summarize":
+			return runSummarize(args[1:])
+		case "merge":
+			return runMerge(args[1:])
+		case "annotate":
+			return runAnnotate(args[1:])
+		case "github-comment":
+			return runGitHubComment(args[1:])
+		case "triage":
+			return runTriage(args[1:])
+		case "compat-report":
+			return runCompatReport(args[1:])
+		case "matrix":
+			return runMatrix(args[1:])
+		case "version-matrix":
+			return runVersionMatrix(args[1:])
+		case "conformance":
+			return runConformance(args[1:])
+		case "fuzz":
+			return runFuzz(args[1:])
+		case "call":
+			return runCall(args[1:])
+		case "compare":
+			return runCompare(args[1:])
+		case "impacted":
+			return runImpacted(args[1:])
+		case "setup":
+			return runSetup(args[1:])
+		case "gemini-config":
+			return runGeminiConfig(args[1:])
+		case "preflight":
+			return runPreflight(args[1:])
+		case "safety-inventory":
+			return runSafetyInventory(args[1:])
+		case "version":
+			return runVersion(args[1:])
+		case "example-server":
+			return runExampleServer(args[1:])
+		}
+	}
 
-	cmd := exec.Command("gemini", "mcp", "list")
-	output, err := cmd.CombinedOutput()
+	fs := flag.NewFlagSet("integration-test", flag.ContinueOnError)
+	only := fs.String("only", "", "run only the test with this ID")
+	runPattern := fs.String("run", "", "run only tests whose ID matches this regular expression")
+	tags := fs.String("tags", "", "comma-separated tags; run only tests with any of them, e.g. smoke")
+	skipTags := fs.String("skip-tags", "", "comma-separated tags; do not run tests with any of them, e.g. slow,destructive")
+	logFormat := fs.String("log-format", logHuman, "log format: human (the messages as written), text (slog key=value records) or json (slog JSON records)")
+	logLevelName := fs.String("log-level", "info", "least severe level logged: debug, info, warn or error")
+	fast := fs.Bool("fast", false, "with -only: skip unrelated checks, silence progress output and shut servers down immediately (for git bisect run)")
+	resultsPath := fs.String("results", "", "write the run results as JSON to this file")
+	junitPath := fs.String("junit", "", "write a JUnit XML report to this file")
+	htmlPath := fs.String("html", "", "write a self-contained HTML report, with each test's timeline, servers' stderr and wire trace, to this file")
+	artifactsDir := fs.String("artifacts", "", "directory for per-test artifacts such as repro scripts and output of failed tests")
+	artifactBudget := artifacts.Budget{PerTest: 64 << 20, PerRun: 1 << 30, Policy: artifacts.Compress, Keep: []string{"repro.sh"}}
+	traceWire := fs.Bool("wire-trace", false, "with -artifacts: record every JSON-RPC message of each test's tool calls in <artifacts>/<testID>/wire.jsonl")
+	resourceInterval := fs.Duration("resource-interval", 200*time.Millisecond, "how often to sample the memory and CPU of each test's servers, on Linux (0 to not sample them)")
+	fs.Var(&artifactBudget.PerTest, "artifact-budget", "with -artifacts: shrink a test's artifacts once they exceed this size (0 for no limit)")
+	fs.Var(&artifactBudget.PerRun, "artifact-run-budget", "with -artifacts: shrink the oldest tests' artifacts once all of them exceed this size (0 for no limit)")
+	fs.Var(&artifactBudget.Policy, "artifact-policy", "how -artifact-budget shrinks artifacts: compress (gzip the largest files) or trim (cut out their middle)")
+	exportMonitoring := fs.Bool("export-monitoring", false, "push pass/fail counts and tool latencies to Cloud Monitoring after the run")
+	monitoringProject := fs.String("monitoring-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "project that receives the custom metrics")
+	bigqueryTable := fs.String("bigquery-table", "", "append a row per test to this BigQuery table, PROJECT.DATASET.TABLE, after the run")
+	fs.StringVar(&runID, "run-id", "", "ID of the run in the names of the resources its tests create, its leases and its results, up to 12 lowercase letters and digits, e.g. the CI run number (default: random)")
+	projectLeaseBucket := fs.String("project-lease-bucket", "", "take a lease on the test project in this Cloud Storage bucket before the tests, so concurrent runs take turns")
+	leaseWait := fs.Duration("lease-wait", 30*time.Minute, "with -project-lease-bucket: how long to wait for another run to release the test project")
+	commit := fs.String("commit", "", "SHA of the checkout under test, recorded in the results (default: $COMMIT_SHA, $GITHUB_SHA, $CI_COMMIT_SHA or git rev-parse HEAD)")
+	notifyWebhook := fs.String("notify-webhook", os.Getenv("NOTIFY_WEBHOOK_URL"), "Slack or Google Chat incoming webhook to post a summary of the run to if it fails (default: $NOTIFY_WEBHOOK_URL)")
+	githubComment := fs.Bool("github-comment", false, "comment the run's summary on a pull request, editing the comment of an earlier run, and set its commit's status; needs $GITHUB_TOKEN")
+	var gh githubFlags
+	gh.register(fs)
+	pubsubTopic := fs.String("pubsub-topic", "", "publish a JSON summary of the run to this Pub/Sub topic, projects/PROJECT/topics/TOPIC, after the run")
+	otlpEndpoint := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector to export spans of the run's tests and tool calls to, e.g. "+tracing.GoogleEndpoint+" for Cloud Trace")
+	traceProject := fs.String("trace-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "with -otlp-endpoint "+tracing.GoogleEndpoint+": project that receives the spans")
+	seed := fs.Int64("seed", 0, "seed for the tests' random sources (0 picks one and records it in the results)")
+	strictOrder := fs.String("strict-order", "", "replay the tests of this results file one at a time in their recorded start order, with its seed")
+	hermeticGemini := fs.Bool("hermetic-gemini", false, "run gemini with a settings directory generated from -manifest instead of the host user's configuration")
+	manifestPath := fs.String("manifest", defaultManifestFile, "YAML manifest of the servers under test and their endpoints")
+	fs.StringVar(&lowPrivilegeSA, "low-privilege-sa", lowPrivilegeSA, "service account without access to the test project, impersonated by IAM denial tests")
+	fs.StringVar(&storageBucket, "storage-bucket", storageBucket, "bucket whose objects storage-resource-link follows resource links to; with -use-emulators, the emulator bucket by default")
+	useEmulators := fs.Bool("use-emulators", false, "point storage-mcp at a local Cloud Storage emulator seeded with the test bucket instead of real GCP: $"+emulator.GCSHostEnv+" if set, else a fake-gcs-server it starts")
+	dryRunMode := fs.Bool("dry-run", false, "print the resolved configuration and each selected test's requirements, server commands and tool calls, without running anything")
+	runPreflightChecks := fs.Bool("preflight", false, "check credentials, project access and required APIs before running any test")
+	differentialMode := fs.Bool("differential", false, "repeat every tool call over each endpoint of its server and fail if the results differ")
+	volatileFields := fs.String("volatile-fields", "", "comma-separated result keys, in addition to the defaults, that -differential and -oracle ignore")
+	oracleMode := fs.Bool("oracle", false, "also run the gcloud command of every read-only, --format=json run_gcloud_command call directly and fail if its output differs from the tool's")
+	scenarioPath := fs.String("scenarios", defaultScenarioFile, "YAML file of multi-step tool workflows, each run as a scenario-<name> test")
+	policyPath := fs.String("gcloud-policies", defaultPolicyFile, "YAML file of gcloud-mcp allowlist and denylist configurations and the commands each must allow or deny, each run as a gcloud-policy-<name> test")
+	resultCacheTTL := fs.Duration("result-cache-ttl", 0, "reuse the results of idempotent read-only tool calls the tests mark cacheable for this long (0 to always call)")
+	redactionPath := fs.String("redaction-rules", defaultRedactionRules, "YAML file of patterns and field names, in addition to the built-in ones, whose values are masked in logs, traces and reports")
+	tokenBudgetsPath := fs.String("token-budgets", defaultTokenBudgets, "YAML file of the most tokens each tool's result may take")
+	billingBudgetPath := fs.String("billing-budget", defaultBillingBudget, "YAML file of the billable operations, such as storage writes, and how many of each a run may make before it is aborted")
+	quarantinePath := fs.String("quarantine", defaultQuarantineFile, "YAML file of quarantined tests whose failures do not fail the run until they expire")
+	hooksPath := fs.String("hooks", defaultHooksFile, "YAML file of commands to run before the tests, after them and for each failed test, with the event as JSON on stdin")
+	quarantineWarnDays := fs.Int("quarantine-warn-days", 14, "warn about quarantine entries expiring within this many days")
+	fs.BoolVar(&updateSnapshots, "update-snapshots", false, "rewrite the tool catalog snapshots from the live servers instead of diffing against them")
+	fs.StringVar(&snapshotDir, "snapshot-dir", snapshotDir, "directory of the tool catalog snapshots")
+	fs.BoolVar(&callDefaults.RecordStdoutPollution, "detect-stdout-pollution", false, "fail tests whose stdio servers write anything but JSON-RPC to stdout, listing every offending line")
+	chaosPolicy := fs.String("chaos", "", "inject faults into every tool call's connection, e.g. latency=200ms,drop=0.05,duplicate=0.1,truncate=0.01,kill-after=3,seed=7")
+	fs.BoolVar(&fuzzMode, "fuzz", false, "run the fuzz-* tests of the registered servers, which call every tool with malformed and extreme arguments")
+	mutate := fs.Bool("mutate", false, "self-test: replay each passing test against mutated copies of its tool responses and fail it if any mutant still passes")
+	gcloudSandbox := fs.Bool("gcloud-sandbox", true, "give each test a fresh gcloud configuration directory set to the test project instead of the global one")
+	gcloudAccount := fs.String("gcloud-account", "", "with -gcloud-sandbox: account to set in each test's gcloud configuration (default: the active account)")
+	ephemeralMode := fs.String("ephemeral-project", "", "run the tests tagged destructive in a project of their own instead of the test project: create to create one for the run and delete it after, pool to lease one of -project-pool (needs -gcloud-sandbox)")
+	projectParent := fs.String("project-parent", "", "with -ephemeral-project=create: folder or organization to create the project in, e.g. folders/123")
+	billingAccount := fs.String("billing-account", "", "with -ephemeral-project=create: billing account ID to link the project to")
+	projectPool := fs.String("project-pool", "", "with -ephemeral-project=pool: comma-separated IDs of the projects to lease one of")
+	leaseBucket := fs.String("lease-bucket", "", "with -ephemeral-project=pool: Cloud Storage bucket holding the pool's lease objects")
+	featuresPath := fs.String("features", defaultFeaturesFile, "YAML file of experimental feature flags to enable; "+features.EnvVar+" overrides it")
+	minCoverage := fs.Bool("min-coverage", true, "fail the run if it executed fewer of a suite's tests than the suite requires (not checked with -only, shards, -tags or -skip-tags)")
+	updateCheck := fs.Bool("update-check", true, "warn in the summary if a newer harness release is available")
+	releaseManifest := fs.String("release-manifest", selfupdate.DefaultManifest, "gs:// or https:// URL of the latest release manifest -update-check reads")
+	sweep := fs.Bool("sweep-orphans", true, "after the tests, list the test resources earlier runs left in the test project")
+	orphanAge := fs.Duration("orphan-age", 6*time.Hour, "with -sweep-orphans: report test resources older than this")
+	cleanupOrphans := fs.Bool("cleanup-orphans", false, "with -sweep-orphans: delete the orphaned test resources found")
+	fingerprintFile := fs.String("fingerprints", defaultFingerprintFile(), "file of the previous run's server fingerprints, to report servers whose environment changed without a config change (empty to skip the comparison)")
+	lookupCacheTTL := fs.Duration("lookup-cache-ttl", cache.DefaultTTL, "reuse external lookups, such as the release manifest, for this long (0 to always repeat them)")
+	prewarm := fs.Int("prewarm", 4, "set up this many suites with slow setup at a time before the first test (0 to set each up with its first test)")
+	heartbeat := fs.Duration("heartbeat", time.Minute, "print which test is running and its last reported step this often (0 to never)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "end a run still going after this long, dumping goroutines, running servers and partial results (0 for no limit)")
+	retries := fs.Int("retries", 0, "rerun a failing test up to this many times; one that then passes is reported as flaky")
+	failOnFlaky := fs.Bool("fail-on-flaky", false, "with -retries: fail the run if any test is flaky")
+	shardIndex := fs.Int("shard-index", 0, "with -shard-count: run only the tests of this shard, from 0")
+	shardCount := fs.Int("shard-count", 1, "split the selected tests into this many shards by test ID; combine the shards' -results with merge")
+	inContainer := fs.Bool("in-container", false, "run the suite in a container image with the pinned node, Gemini CLI, gcloud SDK and MCP server versions, with the host's credentials mounted")
+	containerOpts := containerOptions{manifest: defaultManifestFile}
+	fs.StringVar(&containerOpts.image, "container-image", "", "with -in-container: prebuilt image to run instead of building one from ./Dockerfile")
+	fs.StringVar(&containerOpts.pins, "container-pins", defaultContainerPins, "with -in-container: YAML file of the node, Gemini CLI and gcloud SDK versions to build into the image")
+	fs.StringVar(&containerOpts.runtime, "container-runtime", "docker", "with -in-container: container runtime to build and run the image with, e.g. podman")
+	var annotations annotationFlags
+	annotations.register(fs)
+	fs.BoolVar(&callDefaults.ValidateArgs, "validate-args", false, "validate every call's arguments against the tool's input schema before sending it")
+	fs.BoolVar(&callDefaults.ValidateOutput, "validate-output", false, "validate every result's structuredContent against the tool's output schema")
+	fs.StringVar(&callDefaults.ProtocolVersion, "protocol-version", "", "MCP protocol version every call requests in initialize instead of the latest, failing calls whose server answers with another")
+	reconnects := fs.Int("reconnect", 0, "reconnect a session up to this many times when its server connection drops, e.g. because the server crashed, instead of failing its later calls")
+	protocolVersions := fs.String("protocol-versions", strings.Join(geminiProtocolVersions, ","), "comma-separated MCP protocol versions the protocol-versions-* tests expect every server to accept")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if callDefaults.ProtocolVersion != "" {
+		if err := client.ValidProtocolVersion(callDefaults.ProtocolVersion); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -protocol-version: %v\n", err)
+			return exitUsage
+		}
+	}
+	if runID == "" {
+		runID = newRunID()
+	} else if !validRunID.MatchString(runID) {
+		fmt.Fprintf(os.Stderr, "invalid -run-id %q: must be up to 12 lowercase letters and digits\n", runID)
+		return exitUsage
+	}
+	resourceNames.RunID = runID
+	if *reconnects < 0 {
+		fmt.Fprintln(os.Stderr, "invalid -reconnect: must not be negative")
+		return exitUsage
+	}
+	if *reconnects > 0 {
+		callDefaults.Reconnect = &client.Reconnect{Max: *reconnects}
+	}
+	versions, err := parseProtocolVersions(*protocolVersions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -protocol-versions: %v\n", err)
+		return exitUsage
+	}
+	geminiProtocolVersions = versions
+	if err := setUpLogging(*logFormat, *logLevelName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *fast {
+		logLevel.Set(slog.LevelError)
+		summaryOut = io.Discard
+	}
+	if *inContainer && !container.Inside() {
+		containerOpts.manifest, containerOpts.lookupCacheTTL = *manifestPath, *lookupCacheTTL
+		return runInContainer(args, containerOpts)
+	}
+	if err := registerScenarios(*scenarioPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if err := registerPolicies(*policyPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	for _, tc := range testCases {
+		if err := tc.platforms.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "test %s: %v\n", tc.id, err)
+			return exitUsage
+		}
+	}
+	if *fast && *only == "" {
+		fmt.Fprintln(os.Stderr, "-fast requires -only <testID>")
+		return exitUsage
+	}
+	if *traceWire && *artifactsDir == "" {
+		fmt.Fprintln(os.Stderr, "-wire-trace requires -artifacts")
+		return exitUsage
+	}
+	if *retries < 0 {
+		fmt.Fprintln(os.Stderr, "-retries must not be negative")
+		return exitUsage
+	}
+	notes, labels, err := annotations.parse()
 	if err != nil {
-		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, string(output))
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *pubsubTopic != "" {
+		if err := pubsub.ValidTopic(*pubsubTopic); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	var ghPublisher *github.Publisher
+	if *githubComment {
+		if ghPublisher, err = gh.publisher(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	var bqTable bigquery.Table
+	if *bigqueryTable != "" {
+		if bqTable, err = bigquery.ParseTable(*bigqueryTable); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	if *commit == "" {
+		*commit = detectCommit()
+	}
+	if redactor, err = redact.Load(*redactionPath, *redactionPath == defaultRedactionRules); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *chaosPolicy != "" {
+		if callDefaults.Chaos, err = chaos.Parse(*chaosPolicy); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		logger.Printf("🌪️  Injecting chaos into tool calls: %s\n", callDefaults.Chaos)
 	}
 
-	fmt.Println("Command output:")
-	fmt.Println(string(output))
+	tests := testCases
+	if *strictOrder != "" {
+		if *only != "" {
+			fmt.Fprintln(os.Stderr, "-strict-order cannot be combined with -only")
+			return exitUsage
+		}
+		previous, err := report.ReadJSON(*strictOrder)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		if tests, err = orderFromReport(previous); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		if *seed == 0 {
+			*seed = previous.Seed
+		}
+	}
+	if *runPattern != "" {
+		if *only != "" || *strictOrder != "" {
+			fmt.Fprintln(os.Stderr, "-run cannot be combined with -only or -strict-order")
+			return exitUsage
+		}
+		re, err := regexp.Compile(*runPattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -run pattern: %v\n", err)
+			return exitUsage
+		}
+		tests = slices.DeleteFunc(slices.Clone(tests), func(tc testCase) bool { return !re.MatchString(tc.id) })
+		if len(tests) == 0 {
+			fmt.Fprintf(os.Stderr, "-run %q matches no tests; available: %s\n", *runPattern, strings.Join(testIDs(), ", "))
+			return exitUsage
+		}
+	}
+	tagged, err := parseTagFilter(*tags, *skipTags, testCases)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if tagged.active() {
+		if *only != "" || *strictOrder != "" {
+			fmt.Fprintln(os.Stderr, "-tags and -skip-tags cannot be combined with -only or -strict-order")
+			return exitUsage
+		}
+		tests = slices.DeleteFunc(slices.Clone(tests), func(tc testCase) bool { return !tagged.selects(tc) })
+		if len(tests) == 0 {
+			fmt.Fprintf(os.Stderr, "no tests are %s\n", tagged)
+			return exitUsage
+		}
+		logger.Printf("🏷️  Running the %d tests %s\n", len(tests), tagged)
+	}
+	if *only != "" {
+		tc, ok := findTest(*only)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown test ID %q; available: %s\n", *only, strings.Join(testIDs(), ", "))
+			return exitUsage
+		}
+		tests = []testCase{tc}
+	}
+	var shardSpec *shard.Spec
+	if *shardCount != 1 || *shardIndex != 0 {
+		if *only != "" {
+			fmt.Fprintln(os.Stderr, "-shard-index and -shard-count cannot be combined with -only")
+			return exitUsage
+		}
+		spec := shard.Spec{Index: *shardIndex, Count: *shardCount}
+		if err := spec.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		tests = slices.DeleteFunc(slices.Clone(tests), func(tc testCase) bool { return !spec.Contains(tc.id) })
+		shardSpec = &spec
+		// Shards run at the same time, so one lease would serialize them;
+		// their run IDs already keep their resources apart.
+		if *projectLeaseBucket != "" {
+			fmt.Fprintln(os.Stderr, "-project-lease-bucket cannot be combined with -shard-index and -shard-count")
+			return exitUsage
+		}
+		logger.Printf("🧩 Running shard %s: %d of the selected tests\n", spec, len(tests))
+	}
+	if *fast {
+		callDefaults.TerminateDuration = 100 * time.Millisecond
+	}
 
-	expectedMCPServers := map[string]string{
-		"gcloud":        "gcloud-mcp",
-		"observability": "observability-mcp",
-		"storage":       "storage-mcp",
+	if err := features.Default.Load(*featuresPath, *featuresPath == defaultFeaturesFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if err := features.Default.Parse(os.Getenv(features.EnvVar), features.EnvVar); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	for _, f := range features.Default.Active() {
+		logger.Printf("🚩 Feature %s enabled by %s\n", f.Name, f.Source)
 	}
 
-	for serverName, binCommand := range expectedMCPServers {
-		expectedRegexMatch := fmt.Sprintf(".*%s.*: npx -y %s .*\\(stdio\\) - Connected", serverName, binCommand)
-		matched, err := regexp.MatchString(expectedRegexMatch, string(output))
+	if servers, err = bootstrap.Load(*manifestPath); err != nil {
+		if *manifestPath != defaultManifestFile || !errors.Is(err, os.ErrNotExist) || *hermeticGemini {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		servers = &bootstrap.Manifest{}
+	}
+	// The secrets servers read from files are masked like those the rules
+	// match. Unresolvable env fails the tests needing the server instead.
+	for i := range servers.Servers {
+		_, secrets, _ := servers.Servers[i].Environ()
+		redactor = redactor.WithSecrets(secrets...)
+	}
+	if *differentialMode && !features.Enabled(client.FeatureNetworkTransports) {
+		fmt.Fprintf(os.Stderr, "-differential compares network transports; enable feature %s\n", client.FeatureNetworkTransports)
+		return exitUsage
+	}
+	if *oracleMode {
+		if _, err := exec.LookPath("gcloud"); err != nil {
+			fmt.Fprintf(os.Stderr, "-oracle runs gcloud directly: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *differentialMode || *oracleMode {
+		fields := slices.Clone(differential.DefaultVolatileFields)
+		if *volatileFields != "" {
+			fields = append(fields, strings.Split(*volatileFields, ",")...)
+		}
+		if *differentialMode {
+			differentialNormalizer = differential.New(fields)
+		}
+		if *oracleMode {
+			gcloudOracle = differential.New(fields)
+		}
+	}
+	if *hermeticGemini {
+		dir, env, err := geminiconfig.WriteTemp(geminiconfig.FromManifest(servers))
 		if err != nil {
-			return fmt.Errorf("error compiling regex: %v", err)
+			logf(slog.LevelError, "❌ error writing Gemini CLI settings: %v\n", err)
+			return exitFail
 		}
-		if !matched {
-			return fmt.Errorf("assertion failed: output did not contain the connected %s server line. Expected regex: %s, Output: %s", serverName, expectedRegexMatch, string(output))
+		defer os.RemoveAll(dir)
+		geminiEnv = append(geminiEnv, env)
+		logger.Printf("🔧 Using generated Gemini CLI settings in %s\n", dir)
+	}
+
+	// Outside the sandbox the servers run as the active account.
+	preflightAccount := ""
+	if *gcloudSandbox {
+		preflightAccount = *gcloudAccount
+	}
+	if *runPreflightChecks && !*dryRunMode && !preflightPassed(preflightAccount) {
+		return exitFail
+	}
+	if err := checkRequirements(tests); err != nil && !*dryRunMode {
+		logf(slog.LevelError, "❌ %v\n", err)
+		if *fast {
+			return exitSkip
 		}
-		fmt.Printf("✅ Assertion passed: Output regex matched the connected %s server line.\n", serverName)
+		return exitFail
 	}
-	return nil
+
+	opts := runOptions{stopOnFailure: *fast, artifactsDir: *artifactsDir, artifactBudget: artifactBudget, seed: *seed, mutate: *mutate, retries: *retries, wireTrace: *traceWire, resourceInterval: *resourceInterval}
+	if *gcloudSandbox {
+		opts.gcloudSandbox = &gcloudconfig.Options{Project: testProject, Account: *gcloudAccount}
+	}
+	if opts.ephemeral, err = newEphemeralProject(*ephemeralMode, *projectParent, *billingAccount, *projectPool, *leaseBucket); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// Only a sandbox points the servers at another project.
+	if opts.ephemeral != nil && !*gcloudSandbox {
+		fmt.Fprintln(os.Stderr, "-ephemeral-project needs -gcloud-sandbox")
+		return exitUsage
+	}
+	onWatchdogExit(opts.ephemeral.teardown)
+	// A bisect needs the raw outcome, so -fast ignores quarantines.
+	if !*fast {
+		list, err := quarantine.Load(*quarantinePath, *quarantinePath == defaultQuarantineFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		for _, id := range list.Unknown(testIDs()) {
+			logf(slog.LevelWarn, "⚠️  %s quarantines unknown test %q; remove the entry\n", *quarantinePath, id)
+		}
+		opts.quarantine = list
+		opts.quarantineWarning = time.Duration(*quarantineWarnDays) * 24 * time.Hour
+	}
+	if *resultCacheTTL > 0 {
+		resultCache = client.NewResultCache(*resultCacheTTL)
+	}
+	if tokenBudgets, err = tokens.Load(*tokenBudgetsPath, *tokenBudgetsPath == defaultTokenBudgets); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	billable, err := billing.Load(*billingBudgetPath, *billingBudgetPath == defaultBillingBudget)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if len(billable.Operations) > 0 {
+		billingBudget = billing.New(billable)
+		opts.billing = billingBudget
+	}
+	lifecycle, err := hooks.Load(*hooksPath, *hooksPath == defaultHooksFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *dryRunMode {
+		return dryRun(redactor.Writer(os.Stdout), tests, opts)
+	}
+	if *projectLeaseBucket != "" {
+		ttl := lease.DefaultTTL
+		if *timeout > 0 {
+			ttl = *timeout + *leaseWait
+		}
+		l, err := leaseProject(*projectLeaseBucket, *leaseWait, ttl)
+		if err != nil {
+			logf(slog.LevelError, "❌ could not lease the test project: %v\n", err)
+			return exitFail
+		}
+		// The watchdog's os.Exit skips the defer.
+		release := sync.OnceFunc(func() { releaseProject(l) })
+		onWatchdogExit(release)
+		defer release()
+	}
+	if *useEmulators {
+		stop, err := startEmulators()
+		if err != nil {
+			logf(slog.LevelError, "❌ %v\n", err)
+			return exitFail
+		}
+		defer stop()
+	}
+	preRun := preRunEvent{
+		Event: hooks.PreRun, Seed: *seed, Platform: platform.Current().String(), Harness: harnessVersion(),
+		Shard: shardSpec, Notes: notes, Labels: labels,
+	}
+	for _, tc := range tests {
+		preRun.Tests = append(preRun.Tests, tc.id)
+	}
+	if !fireHooks(lifecycle, hooks.PreRun, preRun) {
+		return exitFail
+	}
+
+	opts.watchdog = startWatchdog(*timeout, *artifactsDir, func(partial *report.Run) {
+		partial.Features = features.Default.Active()
+		partial.Harness = harnessVersion()
+		partial.RunID = runID
+		partial.Commit = *commit
+		partial.Shard = shardSpec
+		partial.Annotate(notes, labels)
+		redactValue(partial)
+		if err := report.WriteText(os.Stderr, partial); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ error writing summary: %v\n", err)
+		}
+		writeReports(partial, *resultsPath, *junitPath, *htmlPath)
+		// A run the watchdog ends never reaches the sinks below, and it
+		// exits 1, so it is just the kind the team needs to hear about.
+		if *notifyWebhook != "" {
+			publishSinks(partial, []reportSink{webhookSink(*notifyWebhook, gh.artifactsURL)})
+		}
+	})
+	var otlpHeaders map[string]string
+	if *otlpEndpoint != "" {
+		if otlpHeaders, err = tracing.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		runTrace = tracing.New()
+	}
+	opts.prewarm = *prewarm
+	if *timeout > 0 {
+		opts.deadline = time.Now().Add(*timeout)
+	}
+	stopHeartbeat := startHeartbeat(*heartbeat)
+	results := runTests(tests, opts)
+	stopHeartbeat()
+	if err := billingBudget.Exceeded(); err != nil {
+		logger.Printf("💸 Aborted the run: %v. Raise the limit in %s if the tests need more.\n", err, *billingBudgetPath)
+	} else if len(results.Billing) > 0 {
+		logger.Printf("💸 Billable operations: %s\n", joinUsage(results.Billing))
+	}
+	if hits, misses := resultCache.Stats(); hits > 0 {
+		logger.Printf("♻️  Reused cached results for %d of %d cacheable tool calls\n", hits, hits+misses)
+	}
+	opts.watchdog.stop()
+	results.Features = features.Default.Active()
+	results.Harness = harnessVersion()
+	results.RunID = runID
+	results.Commit = *commit
+	results.Shard = shardSpec
+	results.Annotate(notes, labels)
+	redactValue(results)
+	code := exitPass
+	if _, failed, _ := results.Counts(); failed > 0 || *failOnFlaky && len(results.Flaky()) > 0 || billingBudget.Exceeded() != nil {
+		code = exitFail
+	}
+	if *fast {
+		return code
+	}
+	// A shard or a tag selection covers part of each suite by design; merge
+	// checks a sharded run's whole, and the full run a tag selection's.
+	if *minCoverage && *only == "" && shardSpec == nil && !tagged.active() {
+		results.Coverage = coverage.Evaluate(suites, platformTestIDs(platform.Current()), results.Executed)
+	}
+	// Shards share the project, so only the first sweeps it for earlier
+	// runs' resources; each cleans up its own.
+	sweepEarlier := *sweep && (shardSpec == nil || shardSpec.Index == 0)
+	if sweepEarlier || len(resourceNames.Names()) > 0 {
+		sweepOrphans(results, &orphans.Sweeper{Project: testProject}, sweepEarlier, *orphanAge, *cleanupOrphans)
+	}
+	if sweepEarlier && opts.ephemeral != nil && opts.ephemeral.provisioner != nil {
+		sweepProjects(results, opts.ephemeral.provisioner, *orphanAge, *cleanupOrphans)
+	}
+	fingerprintServers(results, *fingerprintFile)
+	if *updateCheck {
+		checkForUpdate(results, &selfupdate.Checker{Manifest: *releaseManifest, Cache: cache.Default(*lookupCacheTTL)})
+	}
+
+	// Sinks publish before the summary and the local reports are written, so
+	// those record any degradation.
+	var sinks []reportSink
+	if *exportMonitoring {
+		exporter := &monitoring.Exporter{Project: *monitoringProject}
+		sinks = append(sinks, reportSink{
+			name:      "Cloud Monitoring",
+			publish:   exporter.Export,
+			published: fmt.Sprintf("📈 Exported run metrics to Cloud Monitoring project %s", *monitoringProject),
+		})
+	}
+	if *bigqueryTable != "" {
+		exporter := &bigquery.Exporter{Table: bqTable}
+		sinks = append(sinks, reportSink{
+			name:      "BigQuery",
+			publish:   exporter.Export,
+			published: fmt.Sprintf("🗄️  Appended %d test results to BigQuery table %s", len(results.Tests), bqTable),
+		})
+	}
+	if *pubsubTopic != "" {
+		publisher := &pubsub.Publisher{Topic: *pubsubTopic}
+		if host := os.Getenv("PUBSUB_EMULATOR_HOST"); host != "" {
+			// The emulator needs no credentials.
+			publisher.Endpoint = "http://" + host + "/v1"
+			publisher.Token = func(context.Context) (string, error) { return "", nil }
+		}
+		sinks = append(sinks, reportSink{
+			name:      "Pub/Sub",
+			publish:   publisher.Publish,
+			published: fmt.Sprintf("📨 Published the run summary to %s", *pubsubTopic),
+		})
+	}
+	if ghPublisher != nil {
+		sinks = append(sinks, githubSink(ghPublisher))
+	}
+	if *notifyWebhook != "" && notify.Failed(results) {
+		sinks = append(sinks, webhookSink(*notifyWebhook, gh.artifactsURL))
+	}
+	if runTrace != nil {
+		results.TraceID = runTrace.ID.String()
+		sinks = append(sinks, otlpSink(&tracing.Exporter{Endpoint: *otlpEndpoint, Headers: otlpHeaders, Project: *traceProject}))
+	}
+	publishSinks(results, sinks)
+	if !firePostRun(lifecycle, results) {
+		code = exitFail
+	}
+
+	if err := report.WriteText(summaryOut, results); err != nil {
+		logf(slog.LevelError, "❌ error writing summary: %v\n", err)
+	}
+	for _, r := range coverage.Unmet(results.Coverage) {
+		logf(slog.LevelError, "❌ Coverage: %s; not executed: %s\n", r, strings.Join(r.NotExecuted, ", "))
+		code = exitFail
+	}
+	writeReports(results, *resultsPath, *junitPath, *htmlPath)
+	return code
 }
 
-func testCallGcloudMCPTool() error {
-	fmt.Println("🚀 Starting gcloud-mcp tool call integration test...")
-	gcloudToolCall := client.ToolCall{
-		ServerCmd: []string{"gcloud-mcp"},
-		ToolName:  "run_gcloud_command",
-		ToolArgs: map[string]any{
-			"args": []string{"config", "list", "--format=json"},
-		},
+// writeReports writes the results file, JUnit report and HTML report of run,
+// if their paths are set.
+func writeReports(run *report.Run, resultsPath, junitPath, htmlPath string) {
+	if resultsPath != "" {
+		if err := report.WriteJSON(resultsPath, run); err != nil {
+			logf(slog.LevelError, "❌ error writing results file: %v\n", err)
+		}
+	}
+	if junitPath != "" {
+		if err := report.WriteJUnit(junitPath, run); err != nil {
+			logf(slog.LevelError, "❌ error writing JUnit report: %v\n", err)
+		}
+	}
+	if htmlPath != "" {
+		if err := report.WriteHTML(htmlPath, run); err != nil {
+			logf(slog.LevelError, "❌ error writing HTML report: %v\n", err)
+		}
+	}
+}
+
+// runSummarize implements `summarize [-for-llm] [-budget N] <results.json>`.
+func runSummarize(args []string) int {
+	fs := flag.NewFlagSet("summarize", flag.ContinueOnError)
+	forLLM := fs.Bool("for-llm", false, "write a compact digest for automated triage agents")
+	budget := fs.Int("budget", report.DefaultTokenBudget, "approximate token budget for -for-llm")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test summarize [-for-llm] [-budget N] <results.json>")
+		return exitUsage
 	}
 
-	output, err := client.InvokeMCPTool(gcloudToolCall)
+	results, err := report.ReadJSON(fs.Arg(0))
 	if err != nil {
-		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, string(output))
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	if *forLLM {
+		err = report.WriteLLMSummary(os.Stdout, results, *budget)
+	} else {
+		err = report.WriteText(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	return exitPass
+}
+
+// runMerge implements `merge [-results FILE] [-junit FILE] [-html FILE] [-min-coverage]
+// <shard results.json...>`, combining the results files of every shard of a
+// sharded run into one and printing its summary. It exits 1 if a test failed
+// or, with -min-coverage, a suite's coverage is unmet.
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	resultsPath := fs.String("results", "", "write the merged results as JSON to this file")
+	junitPath := fs.String("junit", "", "write a JUnit XML report of the merged results to this file")
+	htmlPath := fs.String("html", "", "write an HTML report of the merged results to this file")
+	minCoverage := fs.Bool("min-coverage", true, "fail if the merged run executed fewer of a suite's tests than the suite requires")
+	failOnFlaky := fs.Bool("fail-on-flaky", false, "fail if any test of the merged run is flaky")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test merge [-results FILE] [-junit FILE] [-html FILE] <shard results.json...>")
+		return exitUsage
+	}
+
+	var shards []*report.Run
+	for _, path := range fs.Args() {
+		run, err := report.ReadJSON(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		shards = append(shards, run)
+	}
+	results, err := report.Merge(shards)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFail
+	}
+	for _, s := range shards[1:] {
+		if s.Seed != results.Seed {
+			logf(slog.LevelWarn, "⚠️  shard %s ran with seed %d, not %d; -strict-order replays use %d\n", s.Shard, s.Seed, results.Seed, results.Seed)
+		}
+	}
+
+	code := exitPass
+	if _, failed, _ := results.Counts(); failed > 0 || *failOnFlaky && len(results.Flaky()) > 0 {
+		code = exitFail
+	}
+	if *minCoverage {
+		ids := testIDs()
+		if p, err := platform.Parse(results.Platform); err == nil {
+			ids = platformTestIDs(p)
+		}
+		results.Coverage = coverage.Evaluate(suites, ids, results.Executed)
+	}
+	if err := report.WriteText(os.Stdout, results); err != nil {
+		logf(slog.LevelError, "❌ error writing summary: %v\n", err)
+	}
+	for _, r := range coverage.Unmet(results.Coverage) {
+		logf(slog.LevelError, "❌ Coverage: %s; not executed: %s\n", r, strings.Join(r.NotExecuted, ", "))
+		code = exitFail
+	}
+	writeReports(results, *resultsPath, *junitPath, *htmlPath)
+	return code
+}
+
+// runMatrix implements `matrix [-fleet os/arch,...] <results.json...>`,
+// printing which tests passed, failed or were skipped on which platform
+// across the results files of a fleet of runners. It exits 1 if a platform of
+// the fleet has no results.
+func runMatrix(args []string) int {
+	fs := flag.NewFlagSet("matrix", flag.ContinueOnError)
+	fleet := fs.String("fleet", "", "comma-separated os/arch platforms of the CI runners, each expected to report results")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test matrix [-fleet os/arch,...] <results.json...>")
+		return exitUsage
+	}
+	var platforms []string
+	if *fleet != "" {
+		for _, p := range strings.Split(*fleet, ",") {
+			if _, err := platform.Parse(p); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitUsage
+			}
+			platforms = append(platforms, p)
+		}
+	}
+	var runs []*report.Run
+	for _, path := range fs.Args() {
+		run, err := report.ReadJSON(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		if run.Platform == "" {
+			fmt.Fprintf(os.Stderr, "%s does not record the platform it ran on\n", path)
+			return exitUsage
+		}
+		runs = append(runs, run)
+	}
+	m := report.NewMatrix(runs, platforms)
+	if err := m.Write(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	if len(m.Missing) > 0 {
+		return exitFail
+	}
+	return exitPass
+}
+
+// runImpacted implements `impacted [-mapping FILE] [-files FILE|-] [-format
+// flag|ids] [changed files...]`, printing the selection of tests affected by
+// the changed files.
+func runImpacted(args []string) int {
+	fs := flag.NewFlagSet("impacted", flag.ContinueOnError)
+	mappingPath := fs.String("mapping", "impact.yaml", "YAML file mapping changed paths to test IDs")
+	filesPath := fs.String("files", "", "file with one changed path per line, or - for stdin")
+	format := fs.String("format", "flag", "output format: flag (a -run=... argument) or ids (one test ID per line)")
+	scenarioPath := fs.String("scenarios", defaultScenarioFile, "YAML file of the scenario-* tests to select from")
+	policyPath := fs.String("gcloud-policies", defaultPolicyFile, "YAML file of the gcloud-policy-* tests to select from")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := registerScenarios(*scenarioPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if err := registerPolicies(*policyPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	changed := fs.Args()
+	if *filesPath != "" {
+		var data []byte
+		var err error
+		if *filesPath == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(*filesPath)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		changed = append(changed, strings.Fields(string(data))...)
+	}
+	if len(changed) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test impacted [-mapping FILE] [-files FILE|-] [-format flag|ids] [changed files...]")
+		return exitUsage
+	}
+
+	mapping, err := impact.Load(*mappingPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	sel := mapping.Select(changed, testIDs())
+	for _, file := range sel.Unmatched {
+		fmt.Fprintf(os.Stderr, "note: %s matches no rule in %s (unmatched: %s)\n", file, *mappingPath, mapping.Unmatched)
+	}
+	if len(sel.Tests) == 0 {
+		fmt.Fprintln(os.Stderr, "no tests are affected by the changed files")
+		return exitPass
+	}
+	switch *format {
+	case "flag":
+		fmt.Printf("-run=%s\n", impact.RunFilter(sel.Tests))
+	case "ids":
+		fmt.Println(strings.Join(sel.Tests, "\n"))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown -format %q\n", *format)
+		return exitUsage
 	}
-	type mcpOutput struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
+	return exitPass
+}
+
+// runSetup implements `setup [-manifest FILE] [-prefix DIR] [-scope SCOPE]
+// [-skip-install]`, preparing a machine to run the suite.
+func runSetup(args []string) int {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", defaultManifestFile, "YAML manifest of the servers to install")
+	b := &bootstrap.Bootstrapper{Log: os.Stdout}
+	fs.StringVar(&b.Prefix, "prefix", "", "npm install prefix; its bin directory must be on PATH for the tests")
+	fs.StringVar(&b.Scope, "scope", "user", "Gemini CLI settings scope to add missing servers to (user or project)")
+	fs.BoolVar(&b.SkipInstall, "skip-install", false, "only verify and register servers that are already installed, e.g. with npm link")
+	lookupCacheTTL := fs.Duration("lookup-cache-ttl", cache.DefaultTTL, "reuse npm version resolutions for this long (0 to always repeat them)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
 	}
+	b.Cache = cache.Default(*lookupCacheTTL)
 
-	var parsedOutput mcpOutput
-	if err := json.Unmarshal([]byte(output), &parsedOutput); err != nil {
-		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+	manifest, err := bootstrap.Load(*manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
 	}
+	if err := b.Bootstrap(context.Background(), manifest); err != nil {
+		logf(slog.LevelError, "❌ %v\n", err)
+		return exitFail
+	}
+	logger.Println("✅ Servers installed and registered")
+	return exitPass
+}
 
-	if len(parsedOutput.Content) == 0 {
-		return fmt.Errorf("MCP output content is empty")
+// runGeminiConfig implements `gemini-config [-manifest FILE] [-write DIR |
+// -validate FILE]`. Without -write or -validate it prints the generated
+// settings.
+func runGeminiConfig(args []string) int {
+	fs := flag.NewFlagSet("gemini-config", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", defaultManifestFile, "YAML manifest of the servers to configure")
+	writeDir := fs.String("write", "", "write settings.json into this directory")
+	validatePath := fs.String("validate", "", "check this settings.json against the manifest instead of generating one")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *writeDir != "" && *validatePath != "" {
+		fmt.Fprintln(os.Stderr, "-write cannot be combined with -validate")
+		return exitUsage
 	}
 
-	// Look for STDERR in the output and truncate the string before this keyword if found.
-	parsedText := parsedOutput.Content[0].Text
-	stderrIndex := strings.Index(parsedText, "STDERR")
-	if stderrIndex != -1 {
-		parsedText = parsedText[:stderrIndex]
+	manifest, err := bootstrap.Load(*manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
 	}
+	settings := geminiconfig.FromManifest(manifest)
+	switch {
+	case *validatePath != "":
+		problems, err := geminiconfig.Validate(*validatePath, settings)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFail
+		}
+		for _, p := range problems {
+			logf(slog.LevelError, "❌ %s\n", p)
+		}
+		if len(problems) > 0 {
+			return exitFail
+		}
+		logger.Printf("✅ %s configures every server in %s\n", *validatePath, *manifestPath)
+	case *writeDir != "":
+		if err := geminiconfig.Write(*writeDir, settings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFail
+		}
+		fmt.Printf("export %s=%s\n", geminiconfig.EnvVar, *writeDir)
+	default:
+		data, err := settings.Marshal()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFail
+		}
+		os.Stdout.Write(data)
+	}
+	return exitPass
+}
 
-	type gcloudConfig struct {
-		Core struct {
-			Project string `json:"project"`
-		} `json:"core"`
+// runPreflight implements `preflight`, running only the preflight checks.
+func runPreflight(args []string) int {
+	fs := flag.NewFlagSet("preflight", flag.ContinueOnError)
+	account := fs.String("gcloud-account", "", "gcloud account the servers run as (default: the active account)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
 	}
-	var config gcloudConfig
-	if err := json.Unmarshal([]byte(parsedText), &config); err != nil {
-		return fmt.Errorf("error parsing gcloud config from MCP output: %v\nOutput: %s", err, parsedText)
+	if !preflightPassed(*account) {
+		return exitFail
 	}
+	return exitPass
+}
 
-	if config.Core.Project == "gcloud-mcp-testing" {
-		fmt.Printf("✅ Assertion passed: Tool call was successful\n")
-		return nil
+// preflightPassed runs the preflight checks against the test project as the
+// gcloud account the servers run as, printing each result with its
+// remediation hint.
+func preflightPassed(account string) bool {
+	checker := &preflight.Checker{Project: testProject, Account: account}
+	results := checker.Run(context.Background())
+	for _, r := range results {
+		if r.Err == nil {
+			logger.Printf("✅ Preflight: %s\n", r.Check)
+			continue
+		}
+		logf(slog.LevelError, "❌ Preflight: %s: %v\n   💡 %s\n", r.Check, r.Err, r.Hint)
+	}
+	return len(preflight.Failed(results)) == 0
+}
+
+// runSafetyInventory implements `safety-inventory [-manifest FILE] [-out DIR]`,
+// printing a Markdown review of every potentially destructive tool of the
+// manifest's servers and saving one JSON inventory per server version.
+func runSafetyInventory(args []string) int {
+	fs := flag.NewFlagSet("safety-inventory", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", defaultManifestFile, "YAML manifest of the servers to review")
+	outDir := fs.String("out", "", "directory receiving <server>@<version>.json per server, replacing the inventory of the same version")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	manifest, err := bootstrap.Load(*manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
 	}
 
-	return fmt.Errorf("assertion failed: Tool call was not successful. Tool call content: %s", output)
+	code := exitPass
+	var inventories []*safety.Inventory
+	for _, s := range manifest.Servers {
+		env, _, err := s.Environ()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", s.Name, err)
+			code = exitFail
+			continue
+		}
+		info, tools, err := client.DescribeServer(client.ToolCall{ServerCmd: []string{s.Bin}, Endpoints: s.Endpoints, Env: env, Dir: s.Dir})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", s.Name, err)
+			code = exitFail
+			continue
+		}
+		version := s.Spec()
+		if info != nil && info.Version != "" {
+			version = info.Version
+		}
+		inv := safety.Build(s.Name, version, tools, time.Now())
+		inventories = append(inventories, inv)
+		if *outDir == "" {
+			continue
+		}
+		path, err := safety.Save(*outDir, inv)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", s.Name, err)
+			code = exitFail
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "📦 Wrote %s\n", path)
+	}
+	if err := safety.WriteMarkdown(os.Stdout, inventories); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	return code
 }
 
-func run() int {
-	if err := testGeminiMcpList(); err != nil {
-		fmt.Printf("❌ %v\n", err)
-		return 1
+// runCall implements `call -tool NAME [-args JSON] -- <server command...>`,
+// invoking a single tool and printing its result. Repro scripts use it.
+// Feature flags come from the environment only.
+func runCall(args []string) int {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	tool := fs.String("tool", "", "name of the tool to call")
+	toolArgs := fs.String("args", "{}", "tool arguments as a JSON object")
+	expectError := fs.Bool("expect-error", false, "the call must fail; a successful result is an error")
+	expectMessage := fs.String("expect-message", "", "with -expect-error: text the failure must contain")
+	validate := fs.Bool("validate-args", false, "validate -args against the tool's input schema before calling it")
+	validateOutput := fs.Bool("validate-output", false, "validate the result's structuredContent against the tool's output schema")
+	protocolVersion := fs.String("protocol-version", "", "MCP protocol version to request in initialize instead of the latest")
+	var env stringList
+	fs.Var(&env, "env", "KEY=VALUE added to the server's environment; VALUE may reference ${NAME} and ${file:PATH} (repeatable)")
+	dir := fs.String("dir", "", "working directory to start the server in")
+	impersonate := fs.String("impersonate", "", "start the server acting as this service account")
+	meta := fs.String("meta", "", "request _meta as a JSON object, e.g. a progressToken or trace ID")
+	logLevel := fs.String("log-level", "", "subscribe to the server's log messages at this level and print them to stderr")
+	elicit := fs.String("elicit", "", "answer the server's elicitation requests with accept, decline or cancel, printing each to stderr")
+	var endpointFlags stringList
+	fs.Var(&endpointFlags, "endpoint", "TRANSPORT[=URL] to reach the server at, in order of preference (repeatable; default stdio)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
 	}
-	if err := testCallGcloudMCPTool(); err != nil {
-		fmt.Printf("❌ %v\n", err)
-		return 1
+	if err := features.Default.Parse(os.Getenv(features.EnvVar), features.EnvVar); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
 	}
-	return 0
+	var endpoints []client.Endpoint
+	for _, f := range endpointFlags {
+		e, err := client.ParseEndpoint(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -endpoint: %v\n", err)
+			return exitUsage
+		}
+		endpoints = append(endpoints, e)
+	}
+	if *tool == "" || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test call -tool NAME [-args JSON] -- <server command...>")
+		return exitUsage
+	}
+
+	if *protocolVersion != "" {
+		if err := client.ValidProtocolVersion(*protocolVersion); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -protocol-version: %v\n", err)
+			return exitUsage
+		}
+	}
+	var parsedArgs map[string]any
+	if err := json.Unmarshal([]byte(*toolArgs), &parsedArgs); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -args: %v\n", err)
+		return exitUsage
+	}
+	for i, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		expanded, _, err := config.Expand(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -env %s: %v\n", name, err)
+			return exitUsage
+		}
+		env[i] = name + "=" + expanded
+	}
+	var parsedMeta map[string]any
+	if *meta != "" {
+		if err := json.Unmarshal([]byte(*meta), &parsedMeta); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -meta: %v\n", err)
+			return exitUsage
+		}
+	}
+	call := client.ToolCall{
+		ServerCmd:       fs.Args(),
+		Endpoints:       endpoints,
+		ToolName:        *tool,
+		ToolArgs:        parsedArgs,
+		Meta:            parsedMeta,
+		LogLevel:        *logLevel,
+		ValidateArgs:    *validate,
+		ValidateOutput:  *validateOutput,
+		ProtocolVersion: *protocolVersion,
+		Env:             env,
+		Dir:             *dir,
+
+		ImpersonateServiceAccount: *impersonate,
+	}
+	if *expectError {
+		call.ExpectError = &client.ExpectedError{Message: *expectMessage}
+	}
+	switch *elicit {
+	case "":
+	case client.ElicitAccept:
+		call.Elicitation = client.ApproveElicitations()
+	case client.ElicitDecline:
+		call.Elicitation = client.DenyElicitations()
+	case client.ElicitCancel:
+		call.Elicitation = &client.ElicitationScript{Rules: []client.ElicitationRule{{Pattern: regexp.MustCompile(``), Action: client.ElicitCancel}}}
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -elicit %q: want accept, decline or cancel\n", *elicit)
+		return exitUsage
+	}
+	if *logLevel != "" {
+		call.OnNotification = func(n client.Notification) { fmt.Fprintf(os.Stderr, "📣 %s\n", n) }
+	}
+	result, err := client.InvokeMCPTool(call)
+	if call.Elicitation != nil {
+		for _, e := range call.Elicitation.Elicited() {
+			fmt.Fprintf(os.Stderr, "🙋 %s: %s\n", e.Action, e.Message)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFail
+	}
+	if result.Output == "" {
+		fmt.Println(result.ErrorMessage)
+		return exitPass
+	}
+	fmt.Println(result.Output)
+	return exitPass
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
 }
 
 func main() {
-	os.Exit(run())
+	killChildrenOnSignal()
+	os.Exit(run(os.Args[1:]))
+}
+
+// killChildrenOnSignal kills every child process group when the harness is
+// interrupted or terminated. The children run in process groups of their
+// own, so a Ctrl-C in the terminal no longer reaches them. During a run,
+// the results so far are written first.
+func killChildrenOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		// A second signal stops the harness at once.
+		signal.Stop(signals)
+		if w := activeWatchdog.Load(); w != nil {
+			// During a run, this writes its results and exits.
+			w.interrupt(sig)
+		}
+		cancelRun()
+		for _, p := range slices.Concat(subprocess.Default.Shutdown(), services.Shutdown()) {
+			fmt.Fprintf(os.Stderr, "🧹 Killed %s\n", p.Label())
+		}
+		fmt.Fprintf(os.Stderr, "❌ Stopped by %v\n", sig)
+		os.Exit(exitFail)
+	}()
 }
@@ -0,0 +1,196 @@
+// Package monitoring pushes the outcome of an integration run to Cloud
+// Monitoring as custom metrics, so nightly failures and latency regressions
+// can be alerted on directly.
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+const (
+	defaultEndpoint = "https://monitoring.googleapis.com/v3"
+	metricPrefix    = "custom.googleapis.com/mcp_integration/"
+	// maxSeriesPerRequest is the Cloud Monitoring limit for timeSeries.create.
+	maxSeriesPerRequest = 200
+)
+
+// Exporter writes Run data to a Cloud Monitoring project.
+type Exporter struct {
+	Project string
+	// Endpoint overrides the Cloud Monitoring API base URL.
+	Endpoint string
+	// Token returns an OAuth access token. Defaults to gcloudauth.AccessToken.
+	Token      func(context.Context) (string, error)
+	HTTPClient *http.Client
+}
+
+// Export writes the following metrics, all gauges on the global resource:
+//
+//	tests_passed, tests_failed     count of tests in the run
+//	test_passed{test_id}           1 if the test passed, 0 otherwise
+//	tool_latency_ms{server,tool,statistic}  min/avg/p95 total call duration
+//
+// Every point is stamped with the end time of the run.
+func (e *Exporter) Export(ctx context.Context, run *report.Run) error {
+	if e.Project == "" {
+		return fmt.Errorf("no project configured for Cloud Monitoring export")
+	}
+	token, err := e.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	series := e.timeSeries(run)
+	for start := 0; start < len(series); start += maxSeriesPerRequest {
+		end := min(start+maxSeriesPerRequest, len(series))
+		if err := e.create(ctx, token, series[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) timeSeries(run *report.Run) []timeSeries {
+	at := run.Started.Add(run.Duration).UTC().Format(time.RFC3339Nano)
+	resource := monitoredResource{Type: "global", Labels: map[string]string{"project_id": e.Project}}
+	// The run's labels go on every series so trends can be split by them; a
+	// series' own labels take precedence.
+	gauge := func(metric string, labels map[string]string, v value) timeSeries {
+		if len(run.Labels) > 0 {
+			labels = maps.Clone(labels)
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			for k, l := range run.Labels {
+				if _, ok := labels[k]; !ok {
+					labels[k] = l
+				}
+			}
+		}
+		return timeSeries{
+			Metric:     metricType{Type: metricPrefix + metric, Labels: labels},
+			Resource:   resource,
+			MetricKind: "GAUGE",
+			Points:     []point{{Interval: interval{EndTime: at}, Value: v}},
+		}
+	}
+
+	var passed, failed int64
+	var series []timeSeries
+	for _, t := range run.Tests {
+		var v int64
+		switch t.Status {
+		case report.StatusSkipped, report.StatusQuarantined:
+			continue
+		case report.StatusPassed, report.StatusFlaky:
+			v = 1
+			passed++
+		default:
+			failed++
+		}
+		series = append(series, gauge("test_passed", map[string]string{"test_id": t.ID}, int64Value(v)))
+	}
+	series = append(series,
+		gauge("tests_passed", nil, int64Value(passed)),
+		gauge("tests_failed", nil, int64Value(failed)),
+	)
+
+	for _, l := range run.Latency {
+		for stat, d := range map[string]time.Duration{"min": l.Min, "avg": l.Avg, "p95": l.P95} {
+			labels := map[string]string{"server": l.Server, "tool": l.Tool, "statistic": stat}
+			series = append(series, gauge("tool_latency_ms", labels, doubleValue(float64(d)/float64(time.Millisecond))))
+		}
+	}
+	return series
+}
+
+func (e *Exporter) create(ctx context.Context, token string, series []timeSeries) error {
+	body, err := json.Marshal(map[string]any{"timeSeries": series})
+	if err != nil {
+		return err
+	}
+	endpoint := e.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	url := fmt.Sprintf("%s/projects/%s/timeSeries", endpoint, e.Project)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := e.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to write time series: %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+func (e *Exporter) token(ctx context.Context) (string, error) {
+	if e.Token != nil {
+		return e.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
+
+type timeSeries struct {
+	Metric     metricType        `json:"metric"`
+	Resource   monitoredResource `json:"resource"`
+	MetricKind string            `json:"metricKind"`
+	Points     []point           `json:"points"`
+}
+
+type metricType struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type monitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+}
+
+type point struct {
+	Interval interval `json:"interval"`
+	Value    value    `json:"value"`
+}
+
+type interval struct {
+	EndTime string `json:"endTime"`
+}
+
+type value struct {
+	// Int64Value is a string in the API's JSON mapping.
+	Int64Value  *string  `json:"int64Value,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func int64Value(v int64) value {
+	s := fmt.Sprint(v)
+	return value{Int64Value: &s}
+}
+
+func doubleValue(v float64) value {
+	return value{DoubleValue: &v}
+}
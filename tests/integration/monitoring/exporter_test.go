@@ -0,0 +1,85 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestExport(t *testing.T) {
+	var got struct {
+		TimeSeries []timeSeries `json:"timeSeries"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/test-project/timeSeries" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	e := &Exporter{
+		Project:  "test-project",
+		Endpoint: srv.URL,
+		Token:    func(context.Context) (string, error) { return "tok", nil },
+	}
+	run := &report.Run{
+		Started: time.Unix(0, 0),
+		Tests:   []report.TestResult{{ID: "a", Status: report.StatusPassed}, {ID: "b", Status: report.StatusFailed}},
+		Latency: []report.ToolLatency{{Server: "gcloud-mcp", Tool: "run_gcloud_command", P95: 1500 * time.Millisecond}},
+		Labels:  map[string]string{"backend": "canary", "tool": "shadowed"},
+	}
+	if err := e.Export(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+
+	byType := map[string][]timeSeries{}
+	for _, s := range got.TimeSeries {
+		byType[s.Metric.Type] = append(byType[s.Metric.Type], s)
+	}
+	if n := byType[metricPrefix+"test_passed"]; len(n) != 2 {
+		t.Errorf("got %d test_passed series, want 2", len(n))
+	}
+	if s := byType[metricPrefix+"tests_failed"]; len(s) != 1 || *s[0].Points[0].Value.Int64Value != "1" {
+		t.Errorf("unexpected tests_failed series: %+v", s)
+	}
+	for _, s := range got.TimeSeries {
+		if s.Metric.Labels["backend"] != "canary" {
+			t.Errorf("%s series labels = %v, want the run's backend label", s.Metric.Type, s.Metric.Labels)
+		}
+	}
+	for _, s := range byType[metricPrefix+"tool_latency_ms"] {
+		if s.Metric.Labels["tool"] != "run_gcloud_command" {
+			t.Errorf("tool label = %q, want the series' own", s.Metric.Labels["tool"])
+		}
+		if s.Metric.Labels["statistic"] == "p95" && *s.Points[0].Value.DoubleValue != 1500 {
+			t.Errorf("p95 = %v, want 1500", *s.Points[0].Value.DoubleValue)
+		}
+	}
+}
+
+func TestExportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	e := &Exporter{
+		Project:  "p",
+		Endpoint: srv.URL,
+		Token:    func(context.Context) (string, error) { return "tok", nil },
+	}
+	if err := e.Export(context.Background(), &report.Run{Tests: []report.TestResult{{ID: "a", Status: report.StatusFailed}}}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
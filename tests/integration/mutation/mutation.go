@@ -0,0 +1,180 @@
+// Package mutation derives corrupted variants of recorded tool responses. A
+// test replayed against a mutant that still passes does not really check the
+// part of the response the mutant changed.
+package mutation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Response is a recorded tools/call response.
+type Response struct {
+	// Output is the tool result as JSON, or empty for a JSON-RPC error.
+	Output string
+	// Error is the JSON-RPC error message when Output is empty.
+	Error string
+}
+
+// Mutant is a named corruption of a Response.
+type Mutant struct {
+	Name     string
+	Response Response
+}
+
+// successOutput is the result an error response is mutated into.
+const successOutput = `{"content":[{"type":"text","text":"mutated"}]}`
+
+// Mutants returns up to max mutants of r, the structural ones first. Output
+// that is not a JSON object yields the error mutants only.
+func Mutants(r Response, max int) []Mutant {
+	if r.Output == "" {
+		return limit([]Mutant{
+			{"error becomes success", Response{Output: successOutput}},
+			{"error message emptied", Response{}},
+		}, max)
+	}
+	var result map[string]any
+	if err := json.Unmarshal([]byte(r.Output), &result); err != nil {
+		return nil
+	}
+
+	var out []Mutant
+	add := func(name string, mutate func(result map[string]any)) {
+		m := deepCopy(result).(map[string]any)
+		mutate(m)
+		data, err := json.Marshal(m)
+		if err == nil {
+			out = append(out, Mutant{Name: name, Response: Response{Output: string(data)}})
+		}
+	}
+
+	isError, _ := result["isError"].(bool)
+	add("isError flipped", func(m map[string]any) { m["isError"] = !isError })
+	content, _ := result["content"].([]any)
+	if len(content) > 0 {
+		add("content dropped", func(m map[string]any) { m["content"] = []any{} })
+	}
+	for i, c := range content {
+		block, _ := c.(map[string]any)
+		text, ok := block["text"].(string)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("content[%d].text", i)
+		add(path+" emptied", func(m map[string]any) { textBlock(m, i)["text"] = "" })
+		var parsed any
+		if json.Unmarshal([]byte(text), &parsed) != nil {
+			add(path+" replaced", func(m map[string]any) { textBlock(m, i)["text"] = "mutated" })
+			continue
+		}
+		// Text holding JSON is mutated leaf by leaf, as tests parse it.
+		for _, leaf := range leaves(parsed, path) {
+			add(leaf.name, func(m map[string]any) {
+				changed, _ := json.Marshal(leaf.mutate(deepCopy(parsed)))
+				textBlock(m, i)["text"] = string(changed)
+			})
+		}
+	}
+	if structured, ok := result["structuredContent"]; ok {
+		add("structuredContent dropped", func(m map[string]any) { delete(m, "structuredContent") })
+		for _, leaf := range leaves(structured, "structuredContent") {
+			add(leaf.name, func(m map[string]any) { m["structuredContent"] = leaf.mutate(m["structuredContent"]) })
+		}
+	}
+	return limit(out, max)
+}
+
+func textBlock(result map[string]any, i int) map[string]any {
+	return result["content"].([]any)[i].(map[string]any)
+}
+
+// leaf is a mutation of one scalar or array inside a JSON value.
+type leaf struct {
+	name string
+	// mutate returns the value with the leaf changed; it may modify v.
+	mutate func(v any) any
+}
+
+// leaves returns a mutation for every scalar in v, and an emptying mutation
+// for every non-empty array, in path order.
+func leaves(v any, path string) []leaf {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		var out []leaf
+		for _, k := range keys {
+			for _, l := range leaves(v[k], path+"."+k) {
+				out = append(out, leaf{l.name, func(x any) any {
+					m := x.(map[string]any)
+					m[k] = l.mutate(m[k])
+					return m
+				}})
+			}
+		}
+		return out
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		out := []leaf{{path + " emptied", func(any) any { return []any{} }}}
+		for i := range v {
+			for _, l := range leaves(v[i], path+"["+strconv.Itoa(i)+"]") {
+				out = append(out, leaf{l.name, func(x any) any {
+					a := x.([]any)
+					a[i] = l.mutate(a[i])
+					return a
+				}})
+			}
+		}
+		return out
+	case string:
+		return []leaf{{path + " changed", func(any) any { return mutateString(v) }}}
+	case float64:
+		return []leaf{{path + " changed", func(any) any { return v + 1 }}}
+	case bool:
+		return []leaf{{path + " flipped", func(any) any { return !v }}}
+	default:
+		return []leaf{{path + " set", func(any) any { return "mutated" }}}
+	}
+}
+
+func mutateString(s string) string {
+	if s == "" {
+		return "mutated"
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "-mutated"
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[k] = deepCopy(x)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, x := range v {
+			a[i] = deepCopy(x)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+func limit(mutants []Mutant, max int) []Mutant {
+	if max > 0 && len(mutants) > max {
+		return mutants[:max]
+	}
+	return mutants
+}
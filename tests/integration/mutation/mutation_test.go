@@ -0,0 +1,76 @@
+package mutation
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func names(mutants []Mutant) []string {
+	out := make([]string, len(mutants))
+	for i, m := range mutants {
+		out[i] = m.Name
+	}
+	return out
+}
+
+func TestMutantsOfJSONText(t *testing.T) {
+	r := Response{Output: `{"content":[{"type":"text","text":"{\"core\":{\"project\":\"p\",\"disable\":true},\"ids\":[1]}"}]}`}
+	got := Mutants(r, 0)
+	want := []string{
+		"isError flipped",
+		"content dropped",
+		"content[0].text emptied",
+		"content[0].text.core.disable flipped",
+		"content[0].text.core.project changed",
+		"content[0].text.ids emptied",
+		"content[0].text.ids[0] changed",
+	}
+	if strings.Join(names(got), "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Mutants = %q, want %q", names(got), want)
+	}
+	var result struct {
+		Content []struct{ Text string } `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(got[4].Response.Output), &result); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"core":{"disable":true,"project":"P-mutated"},"ids":[1]}`; result.Content[0].Text != want {
+		t.Errorf("project mutant text = %s, want %s", result.Content[0].Text, want)
+	}
+	// Mutants are independent copies.
+	if !strings.Contains(got[5].Response.Output, `\"project\":\"p\"`) {
+		t.Errorf("mutants share state: %s", got[5].Response.Output)
+	}
+}
+
+func TestMutantsOfPlainTextAndStructuredContent(t *testing.T) {
+	r := Response{Output: `{"content":[{"type":"text","text":"Execution denied"}],"isError":true,"structuredContent":{"ok":false}}`}
+	want := []string{
+		"isError flipped",
+		"content dropped",
+		"content[0].text emptied",
+		"content[0].text replaced",
+		"structuredContent dropped",
+		"structuredContent.ok flipped",
+	}
+	if got := names(Mutants(r, 0)); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Mutants = %q, want %q", got, want)
+	}
+	if got := Mutants(r, 2); len(got) != 2 {
+		t.Errorf("max not applied: %q", names(got))
+	}
+	if !strings.Contains(Mutants(r, 1)[0].Response.Output, `"isError":false`) {
+		t.Errorf("isError not flipped: %s", Mutants(r, 1)[0].Response.Output)
+	}
+}
+
+func TestMutantsOfError(t *testing.T) {
+	got := Mutants(Response{Error: "unknown tool"}, 0)
+	if len(got) != 2 || got[0].Response.Output == "" || got[1].Response != (Response{}) {
+		t.Errorf("Mutants(error) = %+v", got)
+	}
+	if got := Mutants(Response{Output: "not json"}, 0); got != nil {
+		t.Errorf("Mutants(invalid) = %+v", got)
+	}
+}
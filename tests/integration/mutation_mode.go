@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/mutation"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// maxMutantsPerCall bounds the mutants a test is replayed against per
+// recorded tool call.
+const maxMutantsPerCall = 20
+
+// toolCallHook, if set, handles every invokeTool call in place of invoke,
+// which makes it against the server.
+var toolCallHook func(call client.ToolCall, invoke func(client.ToolCall) (*client.Result, error)) (*client.Result, error)
+
+// recordedCall is a tool call made by a test and the server's response.
+type recordedCall struct {
+	call     client.ToolCall
+	response mutation.Response
+	// err is the error the call failed with, replayed as is.
+	err error
+}
+
+// recordingHook returns a toolCallHook that makes each call and appends it
+// with its response to calls.
+func recordingHook(calls *[]recordedCall) func(client.ToolCall, func(client.ToolCall) (*client.Result, error)) (*client.Result, error) {
+	return func(call client.ToolCall, invoke func(client.ToolCall) (*client.Result, error)) (*client.Result, error) {
+		result, err := invoke(call)
+		rec := recordedCall{call: call, err: err}
+		if result != nil {
+			rec.response = mutation.Response{Output: result.Output, Error: result.ErrorMessage}
+		}
+		*calls = append(*calls, rec)
+		return result, err
+	}
+}
+
+// replayHook returns a toolCallHook that answers the calls of a rerun test
+// from recorded, substituting mutant for the response to call target.
+func replayHook(recorded []recordedCall, target int, mutant mutation.Response) func(client.ToolCall, func(client.ToolCall) (*client.Result, error)) (*client.Result, error) {
+	next := 0
+	return func(call client.ToolCall, _ func(client.ToolCall) (*client.Result, error)) (*client.Result, error) {
+		i := next
+		next++
+		if i >= len(recorded) {
+			return nil, fmt.Errorf("replay made call %d, but only %d were recorded", i+1, len(recorded))
+		}
+		if recorded[i].err != nil {
+			return nil, recorded[i].err
+		}
+		response := recorded[i].response
+		if i == target {
+			response = mutant
+		}
+		var callErr error
+		if response.Output == "" {
+			callErr = errors.New(response.Error)
+		}
+		return client.Replay(call, response.Output, callErr)
+	}
+}
+
+// mutationTest reruns tc once per mutant of each recorded response, starting
+// from board as it was before tc first ran, with the other responses replayed
+// unchanged. A mutant is killed if the rerun fails. It records the outcome on
+// result and returns a ReasonMutantSurvived failure if any mutant survived.
+func mutationTest(tc testCase, seed int64, board *blackboard.Board, recorded []recordedCall, result *report.TestResult) error {
+	out := logger.Writer()
+	logger.SetOutput(io.Discard)
+	defer func() {
+		toolCallHook = nil
+		logger.SetOutput(out)
+	}()
+
+	m := &report.Mutation{}
+	for i, rec := range recorded {
+		if rec.err != nil {
+			continue
+		}
+		for _, mutant := range mutation.Mutants(rec.response, maxMutantsPerCall) {
+			m.Mutants++
+			t := &testContext{id: tc.id, board: board.Clone(), rand: testRand(seed, tc.id)}
+			err := tc.suite.each(t, func() error {
+				toolCallHook = replayHook(recorded, i, mutant.Response)
+				defer func() { toolCallHook = nil }()
+				return tc.run(t)
+			})
+			if err != nil && !report.IsSkip(err) {
+				m.Killed++
+				continue
+			}
+			m.Survived = append(m.Survived, fmt.Sprintf("call %d (%s): %s", i+1, rec.call.ToolName, mutant.Name))
+		}
+	}
+	result.Mutation = m
+	logger.SetOutput(out)
+	logger.Printf("🧬 %s detected %d/%d mutated responses\n", tc.id, m.Killed, m.Mutants)
+	if len(m.Survived) == 0 {
+		return nil
+	}
+	return report.Fail(report.ReasonMutantSurvived, "%s still passes with %d of %d mutated responses, so it does not check them:\n  %s",
+		tc.id, len(m.Survived), m.Mutants, strings.Join(m.Survived, "\n  "))
+}
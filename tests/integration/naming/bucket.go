@@ -0,0 +1,34 @@
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bucketName matches the characters and shape Cloud Storage allows in a
+// bucket name; lengths are checked separately.
+var bucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*[a-z0-9]$`)
+
+// ValidBucketName applies Cloud Storage's bucket naming rules to name.
+func ValidBucketName(name string) error {
+	limit := 63
+	if strings.Contains(name, ".") {
+		// Dotted names may be longer, but each component may not.
+		limit = 222
+		for _, part := range strings.Split(name, ".") {
+			if len(part) == 0 || len(part) > 63 {
+				return fmt.Errorf("dot-separated components must be 1 to 63 characters")
+			}
+		}
+	}
+	switch {
+	case len(name) < 3 || len(name) > limit:
+		return fmt.Errorf("must be 3 to %d characters", limit)
+	case !bucketName.MatchString(name):
+		return fmt.Errorf("must be lowercase letters, digits, dashes, underscores and dots, starting and ending with a letter or digit")
+	case strings.HasPrefix(name, "goog"):
+		return fmt.Errorf("must not start with goog")
+	}
+	return nil
+}
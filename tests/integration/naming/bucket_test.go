@@ -0,0 +1,40 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidBucketName(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		valid bool
+	}{
+		{"mcp-it-1a2b3c4d-1-storage-roundtrip", true},
+		{"my_bucket.example.com", true},
+		{"abc", true},
+		{"ab", false},
+		{strings.Repeat("a", 64), false},
+		{strings.Repeat("a", 63) + "." + strings.Repeat("b", 63), true},
+		{strings.Repeat("a", 64) + ".com", false},
+		{"a..b", false},
+		{"My-Bucket", false},
+		{"-bucket", false},
+		{"bucket-", false},
+		{"google-bucket", false},
+		{"gs://bucket", false},
+	} {
+		if err := ValidBucketName(tt.name); (err == nil) != tt.valid {
+			t.Errorf("ValidBucketName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestNamesAreBucketNames(t *testing.T) {
+	n := &Namer{RunID: "1a2b3c4d"}
+	for _, testID := range []string{"storage-roundtrip", "scenario-Storage_Roundtrip", strings.Repeat("x", 80)} {
+		if name := n.Name(testID); ValidBucketName(name) != nil {
+			t.Errorf("Name(%q) = %s, not a valid bucket name: %v", testID, name, ValidBucketName(name))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package naming generates the names of the GCP resources tests create, so
+// that every one is scoped to its run, valid for any resource kind, and
+// known to the harness afterwards.
+//
+// A Namer's names are orphans.Prefix, the run ID, a counter and the test ID,
+// e.g. mcp-it-1a2b3c4d-3-storage-upload: RFC 1035 labels, which bucket and
+// log sink names accept, the kinds orphans.Sweeper cleans up. The counter
+// makes them unique within the run, and the run ID across runs. The Namer
+// records which test asked for each name, so the harness can delete what a
+// test's own cleanup missed as soon as the run ends, instead of leaving it
+// for a later sweep. ValidBucketName checks a name a server returned against
+// Cloud Storage's own rules.
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+)
+
+// MaxLen is the longest name a Namer returns, the length of an RFC 1035
+// label.
+const MaxLen = 63
+
+var unsafe = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Generated is a name a Namer returned and the test it returned it to.
+type Generated struct {
+	Name string `json:"name"`
+	Test string `json:"test"`
+}
+
+// Namer generates and records the resource names of one run. It is safe for
+// concurrent use.
+type Namer struct {
+	// RunID is the run's ID, up to 12 lowercase letters and digits.
+	RunID string
+
+	mu    sync.Mutex
+	names []Generated
+}
+
+// Name returns a new name for a resource the test testID creates.
+func (n *Namer) Name(testID string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	base := fmt.Sprintf("%s%s-%d-", orphans.Prefix, n.RunID, len(n.names)+1)
+	test := strings.Trim(unsafe.ReplaceAllString(strings.ToLower(testID), "-"), "-")
+	name := strings.TrimRight((base + test)[:min(len(base)+len(test), MaxLen)], "-")
+	n.names = append(n.names, Generated{Name: name, Test: testID})
+	return name
+}
+
+// Names returns every name generated so far, in order.
+func (n *Namer) Names() []Generated {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Generated(nil), n.names...)
+}
+
+// Owner returns the test a name was generated for, or "" if the Namer did
+// not generate it.
+func (n *Namer) Owner(name string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, g := range n.names {
+		if g.Name == name {
+			return g.Test
+		}
+	}
+	return ""
+}
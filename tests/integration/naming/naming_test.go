@@ -0,0 +1,40 @@
+package naming
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var rfc1035 = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+func TestName(t *testing.T) {
+	n := &Namer{RunID: "1a2b3c4d"}
+	tests := []struct {
+		testID, want string
+	}{
+		{"storage-upload", "mcp-it-1a2b3c4d-1-storage-upload"},
+		{"scenario-Storage_Roundtrip", "mcp-it-1a2b3c4d-2-scenario-storage-roundtrip"},
+		{"", "mcp-it-1a2b3c4d-3"},
+		// Cut to 63 characters, without ending in the dash the cut exposed.
+		{"a-" + strings.Repeat("b", 42) + "-c", "mcp-it-1a2b3c4d-4-a-" + strings.Repeat("b", 42)},
+	}
+	for _, tt := range tests {
+		got := n.Name(tt.testID)
+		if got != tt.want {
+			t.Errorf("Name(%q) = %s, want %s", tt.testID, got, tt.want)
+		}
+		if !rfc1035.MatchString(got) || len(got) > MaxLen {
+			t.Errorf("Name(%q) = %s, not an RFC 1035 label", tt.testID, got)
+		}
+	}
+	if got := n.Owner("mcp-it-1a2b3c4d-2-scenario-storage-roundtrip"); got != "scenario-Storage_Roundtrip" {
+		t.Errorf("Owner = %q, want the test the name was generated for", got)
+	}
+	if got := n.Owner("mcp-it-other"); got != "" {
+		t.Errorf("Owner of a foreign name = %q, want none", got)
+	}
+	if got := n.Names(); len(got) != len(tests) || got[0] != (Generated{Name: tests[0].want, Test: tests[0].testID}) {
+		t.Errorf("Names = %v, want the %d names in order", got, len(tests))
+	}
+}
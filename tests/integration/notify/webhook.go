@@ -0,0 +1,139 @@
+// Package notify posts a summary of a failed integration run to a chat
+// webhook, so a broken scheduled run reaches the team instead of waiting for
+// someone to check CI.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Limits of the message, which chat clients collapse once it is long.
+const (
+	maxListed    = 10
+	maxErrorLine = 150
+)
+
+// Failed reports whether run is worth notifying about: a test failed or the
+// run was interrupted.
+func Failed(run *report.Run) bool {
+	_, failed, _ := run.Counts()
+	return failed > 0 || run.Interrupted != ""
+}
+
+// Message renders the failure summary of run: the counts, each failed test
+// with its reason and the first line of its error, the labels and a link to
+// runURL, if set. It uses the markup Slack and Google Chat share: *bold*,
+// `code` and <url|text> links.
+func Message(run *report.Run, runURL string) string {
+	var b strings.Builder
+	passed, failed, _ := run.Counts()
+	if run.Interrupted != "" {
+		fmt.Fprintf(&b, "⚠️ *MCP integration run interrupted by %s*", escape(run.Interrupted))
+	} else {
+		b.WriteString("❌ *MCP integration tests failed*")
+	}
+	fmt.Fprintf(&b, ": %d failed, %d passed", failed, passed)
+	if runURL != "" {
+		fmt.Fprintf(&b, " (<%s|view run>)", runURL)
+	}
+	b.WriteString("\n")
+	var broken []report.TestResult
+	for _, t := range run.Tests {
+		switch t.Status {
+		case report.StatusPassed, report.StatusSkipped, report.StatusFlaky, report.StatusQuarantined:
+		default:
+			broken = append(broken, t)
+		}
+	}
+	for _, t := range broken[:min(maxListed, len(broken))] {
+		fmt.Fprintf(&b, "• `%s`", t.ID)
+		if t.Reason != "" {
+			fmt.Fprintf(&b, " [%s]", t.Reason)
+		}
+		if line := firstLine(t.Error); line != "" {
+			b.WriteString(": " + escape(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(broken) > maxListed {
+		fmt.Fprintf(&b, "…and %d more\n", len(broken)-maxListed)
+	}
+	var footer []string
+	for _, k := range slices.Sorted(maps.Keys(run.Labels)) {
+		footer = append(footer, escape(k+"="+run.Labels[k]))
+	}
+	if run.Commit != "" {
+		footer = append(footer, "commit "+run.Commit[:min(12, len(run.Commit))])
+	}
+	if len(footer) > 0 {
+		b.WriteString(strings.Join(footer, " · ") + "\n")
+	}
+	return b.String()
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if r := []rune(line); len(r) > maxErrorLine {
+		line = string(r[:maxErrorLine]) + "…"
+	}
+	return line
+}
+
+// escape escapes the characters Slack and Google Chat read as markup.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// Webhook posts failure summaries to a Slack or Google Chat incoming
+// webhook, both of which take a JSON {"text": ...} message.
+type Webhook struct {
+	// URL is the webhook's URL. It is a credential: errors never include it.
+	URL string
+	// RunURL, if set, is linked from the message, e.g. the CI job's page.
+	RunURL     string
+	HTTPClient *http.Client
+}
+
+// Notify posts the Message of run to the webhook.
+func (w *Webhook) Notify(ctx context.Context, run *report.Run) error {
+	body, err := json.Marshal(map[string]string{"text": Message(run, w.RunURL)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// A *url.Error names the URL, and with it the webhook's secret.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to the webhook of %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to post to the webhook of %s: %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
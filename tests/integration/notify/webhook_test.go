@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func testRun() *report.Run {
+	return &report.Run{
+		Commit: "0123456789abcdef",
+		Labels: map[string]string{"schedule": "nightly", "backend": "canary"},
+		Tests: []report.TestResult{
+			{ID: "a", Status: report.StatusPassed},
+			{ID: "b", Status: report.StatusFailed, Reason: report.ReasonAssertion, Error: "want <1>\nmore detail"},
+			{ID: "c", Status: report.StatusFlaky},
+		},
+	}
+}
+
+func TestFailed(t *testing.T) {
+	run := testRun()
+	if !Failed(run) {
+		t.Error("Failed = false for a run with a failed test")
+	}
+	run.Tests = run.Tests[:1]
+	if Failed(run) {
+		t.Error("Failed = true for a passing run")
+	}
+	run.Interrupted = "interrupt"
+	if !Failed(run) {
+		t.Error("Failed = false for an interrupted run")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	got := Message(testRun(), "https://ci.example/run/1")
+	want := "❌ *MCP integration tests failed*: 1 failed, 1 passed (<https://ci.example/run/1|view run>)\n" +
+		"• `b` [assertion_failed]: want &lt;1&gt;\n" +
+		"backend=canary · schedule=nightly · commit 0123456789ab\n"
+	if got != want {
+		t.Errorf("Message =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMessageListsAtMostMaxListed(t *testing.T) {
+	run := &report.Run{}
+	for i := range maxListed + 2 {
+		run.Tests = append(run.Tests, report.TestResult{ID: fmt.Sprint("t", i), Status: report.StatusFailed})
+	}
+	got := Message(run, "")
+	if n := strings.Count(got, "• "); n != maxListed {
+		t.Errorf("message lists %d tests, want %d", n, maxListed)
+	}
+	if !strings.HasSuffix(got, "…and 2 more\n") {
+		t.Errorf("message does not count the unlisted tests:\n%s", got)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/T/B/secret" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	w := &Webhook{URL: srv.URL + "/services/T/B/secret"}
+	if err := w.Notify(context.Background(), testRun()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got["text"], "❌ *MCP integration tests failed*") {
+		t.Errorf("text = %q", got["text"])
+	}
+}
+
+func TestNotifyErrorsOmitTheURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{srv.URL + "/services/T/B/secret", "403 Forbidden: invalid_token"},
+		{closed.URL + "/services/T/B/secret", "connection refused"},
+		{"://secret", "invalid webhook URL"},
+	} {
+		err := (&Webhook{URL: tt.url}).Notify(context.Background(), testRun())
+		if err == nil || !strings.Contains(err.Error(), tt.want) || strings.Contains(err.Error(), "secret") {
+			t.Errorf("Notify(%q) = %v, want an error containing %q but not the URL", tt.url, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package oracle decides which gcloud-mcp calls can be checked against
+// running the same gcloud command directly, and extracts the part of a tool
+// result to compare with gcloud's own output.
+package oracle
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ToolName is the gcloud-mcp tool whose calls have an oracle.
+const ToolName = "run_gcloud_command"
+
+// readOnlyVerbs are the gcloud command verbs that change nothing, so running
+// the command a second time is safe and should return what the tool did.
+var readOnlyVerbs = []string{"describe", "get-iam-policy", "get-value", "list"}
+
+// writeVerbs are the common gcloud command verbs that change something. With
+// readOnlyVerbs they mark where a command's groups end, so a positional
+// argument after the verb is not taken for one.
+var writeVerbs = []string{
+	"add", "add-iam-policy-binding", "cancel", "cp", "create", "delete",
+	"deploy", "disable", "enable", "export", "import", "mv", "patch",
+	"publish", "remove", "remove-iam-policy-binding", "reset", "resize",
+	"restore", "rm", "rsync", "run", "scp", "set", "set-iam-policy", "ssh",
+	"start", "stop", "submit", "undelete", "unset", "update", "write",
+}
+
+// groupName matches the words of a gcloud command path: its groups and verb.
+var groupName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Args returns the gcloud arguments of a run_gcloud_command call with
+// arguments toolArgs, and whether the call has an oracle: its command must be
+// read-only and ask for --format=json, whose output can be compared
+// structurally.
+func Args(toolArgs any) ([]string, bool) {
+	args := ToolArgs(toolArgs)
+	if len(args) == 0 {
+		return nil, false
+	}
+	jsonFormat := false
+	for i, a := range args {
+		if a == "--format=json" || a == "--format" && i+1 < len(args) && args[i+1] == "json" {
+			jsonFormat = true
+		}
+	}
+	return args, jsonFormat && ReadOnly(args)
+}
+
+// ReadOnly reports whether the gcloud command line args runs a read-only
+// command, which changes nothing, so running it again is safe and should
+// return the same.
+func ReadOnly(args []string) bool {
+	return slices.Contains(readOnlyVerbs, Verb(args))
+}
+
+// Verb returns the verb of the gcloud command line args: the first word of
+// its Command, after the command groups, that is one of readOnlyVerbs or
+// writeVerbs, e.g. create for storage buckets create list. It returns "" if
+// a word that cannot name a group, such as gs://b, comes first.
+func Verb(args []string) string {
+	for _, w := range Command(args) {
+		if slices.Contains(readOnlyVerbs, w) || slices.Contains(writeVerbs, w) {
+			return w
+		}
+		if !groupName.MatchString(w) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// ToolArgs returns the gcloud arguments of a run_gcloud_command call's
+// arguments toolArgs, or nil if it has none.
+func ToolArgs(toolArgs any) []string {
+	data, err := json.Marshal(toolArgs)
+	if err != nil {
+		return nil
+	}
+	var parsed struct {
+		Args []string `json:"args"`
+	}
+	if json.Unmarshal(data, &parsed) != nil {
+		return nil
+	}
+	return parsed.Args
+}
+
+// boolFlags are the gcloud flags that take no value, so the word after one
+// is not its value. A --no- flag never takes one either.
+var boolFlags = []string{
+	"--all", "--async", "--available", "--dry-run", "--enabled", "--force",
+	"--help", "--log-http", "--quiet", "--recursive", "--user-output-enabled",
+	"-h", "-q", "-r", "-R",
+}
+
+// Command returns the words of the gcloud command line args that are not
+// flags or flag values: the command and its positional arguments, e.g.
+// [storage cp a gs://b] for --project p storage cp -r a gs://b. A flag takes
+// its value after an = or in the next word, unless it is one of boolFlags, a
+// --no- flag or followed by another flag.
+func Command(args []string) []string {
+	var words []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			words = append(words, a)
+			continue
+		}
+		if strings.Contains(a, "=") || slices.Contains(boolFlags, a) || strings.HasPrefix(a, "--no-") {
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+		}
+	}
+	return words
+}
+
+// Stdout returns the command output in a run_gcloud_command result's text,
+// without the STDERR section gcloud-mcp appends when the command wrote to
+// stderr.
+func Stdout(text string) string {
+	if i := strings.Index(text, "STDERR"); i != -1 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
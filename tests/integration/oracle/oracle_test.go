@@ -0,0 +1,103 @@
+package oracle
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		toolArgs any
+		want     bool
+	}{
+		{map[string]any{"args": []string{"config", "list", "--format=json"}}, true},
+		{map[string]any{"args": []any{"projects", "describe", "p", "--format", "json"}}, true},
+		{map[string]any{"args": []string{"storage", "buckets", "list", "--project", "p"}}, false},
+		{map[string]any{"args": []string{"storage", "buckets", "create", "gs://b", "--format=json"}}, false},
+		// A flag value named like a verb does not make the command read-only.
+		{map[string]any{"args": []string{"storage", "buckets", "create", "--filter=list", "--format=json"}}, false},
+		{map[string]any{"args": []string{}}, false},
+		{map[string]any{"command": "config list"}, false},
+		{struct{ Args []string }{[]string{"config", "list", "--format=json"}}, true},
+	}
+	for _, tt := range tests {
+		args, ok := Args(tt.toolArgs)
+		if ok != tt.want {
+			t.Errorf("Args(%v) = %q, %v, want %v", tt.toolArgs, args, ok, tt.want)
+		}
+	}
+	args, _ := Args(map[string]any{"args": []string{"config", "list", "--format=json"}})
+	if !slices.Equal(args, []string{"config", "list", "--format=json"}) {
+		t.Errorf("Args() = %q", args)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		toolArgs any
+		want     bool
+	}{
+		{map[string]any{"args": []string{"config", "list"}}, true},
+		{map[string]any{"args": []any{"--project", "p", "storage", "buckets", "describe", "gs://b"}}, true},
+		{map[string]any{"args": []string{"storage", "buckets", "create", "gs://b"}}, false},
+		// A positional argument named like a verb is not the command's verb.
+		{map[string]any{"args": []string{"storage", "buckets", "create", "list"}}, false},
+		{map[string]any{"args": []string{"compute", "instances", "delete", "describe", "--zone", "us-central1-a"}}, false},
+		{map[string]any{"args": []string{"storage", "cp", "gs://b/list", "list"}}, false},
+		{map[string]any{"command": "config list"}, false},
+	}
+	for _, tt := range tests {
+		if got := ReadOnly(ToolArgs(tt.toolArgs)); got != tt.want {
+			t.Errorf("ReadOnly(ToolArgs(%v)) = %v, want %v", tt.toolArgs, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		args, want []string
+	}{
+		{[]string{"storage", "cp", "a", "gs://b"}, []string{"storage", "cp", "a", "gs://b"}},
+		// A flag value before the command is not taken for its first word.
+		{[]string{"--project", "p", "storage", "buckets", "create", "gs://b"}, []string{"storage", "buckets", "create", "gs://b"}},
+		{[]string{"--quiet", "storage", "cp", "-r", "a", "gs://b"}, []string{"storage", "cp", "a", "gs://b"}},
+		{[]string{"logging", "write", "log", "hello", "--severity=INFO", "--no-user-output-enabled", "x"}, []string{"logging", "write", "log", "hello", "x"}},
+		{[]string{"config", "list", "--verbosity", "--format=json"}, []string{"config", "list"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := Command(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("Command(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestVerb(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"config", "get-value", "project"}, "get-value"},
+		{[]string{"--project", "p", "logging", "sinks", "list"}, "list"},
+		{[]string{"pubsub", "topics", "publish", "list", "--message=hi"}, "publish"},
+		// A word that cannot name a group ends the command path, so a later
+		// argument is not taken for its verb.
+		{[]string{"storage", "gs://b", "list"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := Verb(tt.args); got != tt.want {
+			t.Errorf("Verb(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestStdout(t *testing.T) {
+	text := "{\"core\": {\"project\": \"p\"}}\n\nSTDERR:\nYour active configuration is: [default]\n"
+	if got := Stdout(text); got != `{"core": {"project": "p"}}` {
+		t.Errorf("Stdout() = %q", got)
+	}
+	if got := Stdout(" []\n"); got != "[]" {
+		t.Errorf("Stdout() = %q", got)
+	}
+}
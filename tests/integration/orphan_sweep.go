@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/naming"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/projects"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// orphanSweepTimeout bounds the listing and deletion of leftover resources.
+const orphanSweepTimeout = 2 * time.Minute
+
+// resourceNames names the resources the run's tests create; see
+// testContext.ResourceName.
+var resourceNames = &naming.Namer{}
+
+// sweepOrphans deletes the resources the run's tests created under names from
+// resourceNames that are still in the test project, which a test's own
+// cleanup missed, and records them on results. With earlier set, it also
+// records the test resources older than olderThan that earlier runs left,
+// deleting them if cleanup is set. A failed sweep is logged and otherwise
+// ignored.
+func sweepOrphans(results *report.Run, s *orphans.Sweeper, earlier bool, olderThan time.Duration, cleanup bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), orphanSweepTimeout)
+	defer cancel()
+	now := time.Now()
+	found, err := s.Find(ctx, 0, now)
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not sweep %s for orphaned test resources: %v\n", s.Project, err)
+		return
+	}
+	for _, r := range found {
+		orphan := report.Orphan{Resource: r, Test: resourceNames.Owner(r.Name)}
+		if orphan.Test == "" && (!earlier || now.Sub(r.Created) <= olderThan) {
+			continue
+		}
+		if orphan.Test != "" || cleanup {
+			if err := s.Delete(ctx, r); err != nil {
+				orphan.Error = err.Error()
+				logf(slog.LevelError, "❌ could not delete orphaned %s: %v\n", r, err)
+			} else {
+				orphan.Deleted = true
+				logger.Printf("🧹 Deleted orphaned %s\n", r)
+			}
+		}
+		results.Orphans = append(results.Orphans, orphan)
+	}
+}
+
+// sweepProjects records the projects labelled projects.Label older than
+// olderThan under p's parent, which runs killed before their teardown left,
+// deleting them if cleanup is set. They stay linked to billing until then.
+func sweepProjects(results *report.Run, p *projects.Provisioner, olderThan time.Duration, cleanup bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), orphanSweepTimeout)
+	defer cancel()
+	found, err := p.Find(ctx, olderThan, time.Now())
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not sweep %s for orphaned test projects: %v\n", p.Parent, err)
+		return
+	}
+	for _, r := range found {
+		orphan := report.Orphan{Resource: r}
+		if cleanup {
+			if err := p.Delete(ctx, r.Name); err != nil {
+				orphan.Error = err.Error()
+				logf(slog.LevelError, "❌ could not delete orphaned %s: %v\n", r, err)
+			} else {
+				orphan.Deleted = true
+				logger.Printf("🧹 Deleted orphaned %s\n", r)
+			}
+		}
+		results.Orphans = append(results.Orphans, orphan)
+	}
+}
@@ -0,0 +1,217 @@
+// Package orphans finds the GCP resources tests create and failed runs leave
+// behind in the test project, and optionally deletes them.
+//
+// A test that creates a resource names it with a naming.Namer, so its name
+// starts with Prefix. A sweep lists the buckets and log sinks of the project
+// with that prefix and reports those older than a threshold: younger ones
+// may belong to a run still in progress.
+package orphans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+)
+
+// Prefix starts the name of every resource a test creates.
+const Prefix = "mcp-it-"
+
+const (
+	defaultStorage = "https://storage.googleapis.com/storage/v1"
+	defaultLogging = "https://logging.googleapis.com/v2"
+)
+
+// Kinds of resources a sweep covers.
+const (
+	KindBucket  = "bucket"
+	KindLogSink = "log sink"
+	// KindProject is a project -ephemeral-project created, which
+	// projects.Provisioner finds and deletes rather than a Sweeper.
+	KindProject = "project"
+)
+
+// Resource is a test resource found in the project.
+type Resource struct {
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+func (r Resource) String() string {
+	return r.Kind + " " + r.Name
+}
+
+// Sweeper lists and deletes the test resources of a project.
+type Sweeper struct {
+	Project string
+	// Token returns an access token. Defaults to gcloudauth.AccessToken.
+	Token func(context.Context) (string, error)
+	// Storage and Logging override the API base URLs.
+	Storage    string
+	Logging    string
+	HTTPClient *http.Client
+}
+
+// Find returns the test resources of the project created more than
+// olderThan before now, or all of them if olderThan is zero, buckets first.
+func (s *Sweeper) Find(ctx context.Context, olderThan time.Duration, now time.Time) ([]Resource, error) {
+	token, err := s.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get an access token: %w", err)
+	}
+	var found []Resource
+	buckets, err := s.list(ctx, token, s.storage()+"/b?project="+url.QueryEscape(s.Project)+"&prefix="+Prefix, "items", "timeCreated")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	for _, b := range buckets {
+		found = append(found, Resource{Kind: KindBucket, Name: b.Name, Created: b.Created})
+	}
+	sinks, err := s.list(ctx, token, s.logging()+"/projects/"+s.Project+"/sinks", "sinks", "createTime")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log sinks: %w", err)
+	}
+	for _, sink := range sinks {
+		if strings.HasPrefix(sink.Name, Prefix) {
+			found = append(found, Resource{Kind: KindLogSink, Name: sink.Name, Created: sink.Created})
+		}
+	}
+	var old []Resource
+	for _, r := range found {
+		if olderThan <= 0 || now.Sub(r.Created) > olderThan {
+			old = append(old, r)
+		}
+	}
+	return old, nil
+}
+
+// Delete deletes r, emptying a bucket first.
+func (s *Sweeper) Delete(ctx context.Context, r Resource) error {
+	token, err := s.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get an access token: %w", err)
+	}
+	switch r.Kind {
+	case KindBucket:
+		bucket := s.storage() + "/b/" + url.PathEscape(r.Name)
+		objects, err := s.list(ctx, token, bucket+"/o", "items", "timeCreated")
+		if err != nil {
+			return fmt.Errorf("failed to list the objects of %s: %w", r, err)
+		}
+		for _, o := range objects {
+			if err := s.delete(ctx, token, bucket+"/o/"+url.PathEscape(o.Name)); err != nil {
+				return fmt.Errorf("failed to delete gs://%s/%s: %w", r.Name, o.Name, err)
+			}
+		}
+		return s.delete(ctx, token, bucket)
+	case KindLogSink:
+		return s.delete(ctx, token, s.logging()+"/projects/"+s.Project+"/sinks/"+url.PathEscape(r.Name))
+	}
+	return fmt.Errorf("cannot delete a %s", r.Kind)
+}
+
+type listed struct {
+	Name    string
+	Created time.Time
+}
+
+// list fetches every page of a list call and returns the name and creation
+// time of the items under itemsKey.
+func (s *Sweeper) list(ctx context.Context, token, listURL, itemsKey, createdKey string) ([]listed, error) {
+	var out []listed
+	pageToken := ""
+	for {
+		u := listURL
+		if pageToken != "" {
+			sep := "?"
+			if strings.Contains(u, "?") {
+				sep = "&"
+			}
+			u += sep + "pageToken=" + url.QueryEscape(pageToken)
+		}
+		body, err := s.do(ctx, http.MethodGet, u, token)
+		if err != nil {
+			return nil, err
+		}
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		var items []map[string]any
+		if raw, ok := page[itemsKey]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, err
+			}
+		}
+		for _, item := range items {
+			name, _ := item["name"].(string)
+			created, _ := time.Parse(time.RFC3339, fmt.Sprint(item[createdKey]))
+			out = append(out, listed{name, created})
+		}
+		pageToken = ""
+		if raw, ok := page["nextPageToken"]; ok {
+			json.Unmarshal(raw, &pageToken)
+		}
+		if pageToken == "" {
+			return out, nil
+		}
+	}
+}
+
+func (s *Sweeper) delete(ctx context.Context, token, u string) error {
+	_, err := s.do(ctx, http.MethodDelete, u, token)
+	return err
+}
+
+func (s *Sweeper) do(ctx context.Context, method, u, token string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(body[:min(len(body), 4096)])))
+	}
+	return body, nil
+}
+
+func (s *Sweeper) storage() string {
+	if s.Storage != "" {
+		return s.Storage
+	}
+	return defaultStorage
+}
+
+func (s *Sweeper) logging() string {
+	if s.Logging != "" {
+		return s.Logging
+	}
+	return defaultLogging
+}
+
+func (s *Sweeper) token(ctx context.Context) (string, error) {
+	if s.Token != nil {
+		return s.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
@@ -0,0 +1,97 @@
+package orphans
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProject serves the bucket, object and sink APIs of one project.
+type fakeProject struct {
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (f *fakeProject) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthenticated", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		f.mu.Lock()
+		f.deleted = append(f.deleted, r.URL.Path)
+		f.mu.Unlock()
+		return
+	}
+	switch r.URL.Path {
+	case "/storage/v1/b":
+		if r.URL.Query().Get("prefix") != Prefix || r.URL.Query().Get("project") != "p" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			w.Write([]byte(`{"items": [{"name": "mcp-it-old", "timeCreated": "2026-09-01T00:00:00Z"}], "nextPageToken": "2"}`))
+			return
+		}
+		w.Write([]byte(`{"items": [{"name": "mcp-it-new", "timeCreated": "2026-10-01T11:00:00Z"}]}`))
+	case "/storage/v1/b/mcp-it-old/o":
+		w.Write([]byte(`{"items": [{"name": "a/b.txt"}]}`))
+	case "/v2/projects/p/sinks":
+		w.Write([]byte(`{"sinks": [{"name": "audit", "createTime": "2025-01-01T00:00:00Z"}, {"name": "mcp-it-sink", "createTime": "2026-09-30T00:00:00Z"}]}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	f := &fakeProject{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	s := &Sweeper{
+		Project: "p",
+		Token:   func(context.Context) (string, error) { return "token", nil },
+		Storage: srv.URL + "/storage/v1",
+		Logging: srv.URL + "/v2",
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	found, err := s.Find(context.Background(), 6*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range found {
+		names = append(names, r.String())
+	}
+	if want := []string{"bucket mcp-it-old", "log sink mcp-it-sink"}; !slices.Equal(names, want) {
+		t.Fatalf("Find = %q, want %q", names, want)
+	}
+	// Without an age, the young resources of runs in progress too.
+	if all, err := s.Find(context.Background(), 0, now); err != nil || len(all) != 3 {
+		t.Errorf("Find of all = %v, %v, want all 3 resources", all, err)
+	}
+	for _, r := range found {
+		if err := s.Delete(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"/storage/v1/b/mcp-it-old/o/a/b.txt", "/storage/v1/b/mcp-it-old", "/v2/projects/p/sinks/mcp-it-sink"}
+	if !slices.Equal(f.deleted, want) {
+		t.Errorf("deleted %q, want %q", f.deleted, want)
+	}
+}
+
+func TestFindReportsAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	s := &Sweeper{Project: "p", Token: func(context.Context) (string, error) { return "token", nil }, Storage: srv.URL, Logging: srv.URL}
+	if _, err := s.Find(context.Background(), time.Hour, time.Now()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Find = %v, want the 403", err)
+	}
+}
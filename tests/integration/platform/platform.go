@@ -0,0 +1,68 @@
+// Package platform describes the operating systems and architectures a test
+// supports, so a test for a server that only ships for some platforms is
+// skipped, and reported as such, everywhere else.
+package platform
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Platform is an operating system and architecture, as in GOOS and GOARCH.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// Current is the platform the harness runs on.
+func Current() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// Parse parses an os/arch platform, as recorded in results files.
+func Parse(s string) (Platform, error) {
+	os, arch, ok := strings.Cut(s, "/")
+	if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+		return Platform{}, fmt.Errorf("invalid platform %q; want os/arch", s)
+	}
+	return Platform{OS: os, Arch: arch}, nil
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// Constraint lists the platforms a test supports as os/arch patterns, such as
+// "linux/amd64", "linux" (every architecture) or "*/arm64". An empty
+// constraint supports every platform.
+type Constraint []string
+
+// Validate checks the syntax of every pattern.
+func (c Constraint) Validate() error {
+	for _, pattern := range c {
+		os, arch, _ := strings.Cut(pattern, "/")
+		if os == "" || strings.Count(pattern, "/") > 1 || strings.Contains(pattern, "/") && arch == "" {
+			return fmt.Errorf("invalid platform %q; want os, os/arch or */arch", pattern)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the constraint supports p.
+func (c Constraint) Allows(p Platform) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, pattern := range c {
+		os, arch, ok := strings.Cut(pattern, "/")
+		if (os == "*" || os == p.OS) && (!ok || arch == "*" || arch == p.Arch) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) String() string {
+	return strings.Join(c, ", ")
+}
@@ -0,0 +1,48 @@
+package platform
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	linuxAMD := Platform{OS: "linux", Arch: "amd64"}
+	darwinARM := Platform{OS: "darwin", Arch: "arm64"}
+	for _, tc := range []struct {
+		c             Constraint
+		linux, darwin bool
+	}{
+		{nil, true, true},
+		{Constraint{"linux/amd64"}, true, false},
+		{Constraint{"linux"}, true, false},
+		{Constraint{"*/arm64"}, false, true},
+		{Constraint{"windows", "darwin/arm64"}, false, true},
+		{Constraint{"linux/arm64"}, false, false},
+	} {
+		if got := tc.c.Allows(linuxAMD); got != tc.linux {
+			t.Errorf("%v.Allows(%s) = %v", tc.c, linuxAMD, got)
+		}
+		if got := tc.c.Allows(darwinARM); got != tc.darwin {
+			t.Errorf("%v.Allows(%s) = %v", tc.c, darwinARM, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if p, err := Parse("linux/amd64"); err != nil || p != (Platform{OS: "linux", Arch: "amd64"}) {
+		t.Errorf("Parse = %v, %v", p, err)
+	}
+	for _, bad := range []string{"linux", "linux/", "/amd64", "a/b/c"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Constraint{"linux/amd64", "darwin", "*/arm64"}).Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []string{"", "linux/", "/amd64", "linux/amd64/v2"} {
+		if err := (Constraint{bad}).Validate(); err == nil {
+			t.Errorf("Validate(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package policy declares the access control policies gcloud-mcp is checked
+// against: for each allowlist or denylist configuration, a table of gcloud
+// commands run_gcloud_command must let through or reject.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// DeniedPrefix starts every error gcloud-mcp returns for a command its
+// access control list rejects, with or without a suggested alternative.
+const DeniedPrefix = "Execution denied"
+
+// What a Command expects of the policy.
+const (
+	Allowed = "allowed"
+	Denied  = "denied"
+)
+
+// File is the parsed policy table.
+type File struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// Policy is one access control configuration, run as the test
+// gcloud-policy-<name> against a gcloud-mcp started with it.
+type Policy struct {
+	Name string `yaml:"name"`
+	// Allow and Deny are the lists of gcloud-mcp's --config file, of which
+	// it accepts at most one. Its built-in denylist applies either way.
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+	// Commands are checked in order.
+	Commands []Command `yaml:"commands"`
+}
+
+// Command is a run_gcloud_command call and what the policy must do with it.
+type Command struct {
+	// Args are the tool's args, in which ${project} is the test project.
+	Args []string `yaml:"args"`
+	// Expect is Allowed, for a command that must run and succeed, or
+	// Denied, for one that must be rejected without running.
+	Expect string `yaml:"expect"`
+}
+
+func (c Command) String() string {
+	return "gcloud " + strings.Join(c.Args, " ")
+}
+
+// Expand returns c's args with ${project} replaced by project.
+func (c Command) Expand(project string) []string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = strings.ReplaceAll(a, "${project}", project)
+	}
+	return args
+}
+
+// Check returns nil if a call of c that returned text, as a tool error if
+// isError is set, met its expectation. exitStatus is gcloud's exit status, as
+// ExitStatus reads it, or -1 if the server did not report one. A denied
+// command that ran, or an allowed one that was denied, fails with
+// ReasonPolicy; an allowed command that ran and failed fails with
+// ReasonToolError. gcloud-mcp returns a failed gcloud command as a successful
+// result with a STDERR section, so an allowed command also fails if gcloud
+// exited non-zero or wrote an ERROR line there.
+func (c Command) Check(isError bool, text string, exitStatus int) error {
+	denied := isError && strings.HasPrefix(strings.TrimSpace(text), DeniedPrefix)
+	switch {
+	case c.Expect == Denied && !isError:
+		return report.Fail(report.ReasonPolicy, "%s ran instead of being denied: %s", c, firstLine(text))
+	case c.Expect == Denied && !denied:
+		return report.Fail(report.ReasonPolicy, "%s failed with %q instead of a policy denial", c, firstLine(text))
+	case c.Expect == Allowed && denied:
+		return report.Fail(report.ReasonPolicy, "%s was denied: %s", c, firstLine(text))
+	case c.Expect == Allowed && isError:
+		return report.Fail(report.ReasonToolError, "%s was allowed but failed: %s", c, firstLine(text))
+	case c.Expect == Allowed && exitStatus > 0:
+		return report.Fail(report.ReasonToolError, "%s was allowed but gcloud exited with status %d: %s", c, exitStatus, firstLine(stderr(text)))
+	case c.Expect == Allowed && gcloudError(text) != "":
+		return report.Fail(report.ReasonToolError, "%s was allowed but failed: %s", c, gcloudError(text))
+	}
+	return nil
+}
+
+// exitedPrefix starts the message of the progress notification gcloud-mcp
+// sends when gcloud exits, followed by its exit status.
+const exitedPrefix = "gcloud exited with status "
+
+// ExitStatus returns the exit status in message, the message of a progress
+// notification gcloud-mcp sent for a call, and whether message reports one.
+func ExitStatus(message string) (int, bool) {
+	status, ok := strings.CutPrefix(message, exitedPrefix)
+	if !ok {
+		return 0, false
+	}
+	code, err := strconv.Atoi(status)
+	return code, err == nil
+}
+
+// stderr returns the STDERR section gcloud-mcp appends to a
+// run_gcloud_command result's text when gcloud wrote to stderr or exited
+// non-zero, or "" if it has none.
+func stderr(text string) string {
+	_, section, _ := strings.Cut(text, "\nSTDERR:\n")
+	return section
+}
+
+// gcloudError returns the first line of text's STDERR section that reports a
+// gcloud error, or "" if none does.
+func gcloudError(text string) string {
+	for _, line := range strings.Split(stderr(text), "\n") {
+		if strings.HasPrefix(line, "ERROR:") {
+			return line
+		}
+	}
+	return ""
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// Config returns the JSON of the --config file that configures p, or nil if
+// p only relies on the built-in denylist.
+func (p Policy) Config() ([]byte, error) {
+	if p.Allow == nil && p.Deny == nil {
+		return nil, nil
+	}
+	return json.Marshal(struct {
+		Allow []string `json:"allow,omitempty"`
+		Deny  []string `json:"deny,omitempty"`
+	}{p.Allow, p.Deny})
+}
+
+// validName matches the names a policy's test ID can carry.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Load reads a policy file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*File, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse policies %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, p := range f.Policies {
+		if !validName.MatchString(p.Name) {
+			return nil, fmt.Errorf("%s: policy name %q must be lowercase letters, digits and dashes", path, p.Name)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s: policy %s is declared more than once", path, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Allow != nil && p.Deny != nil {
+			return nil, fmt.Errorf("%s: policy %s sets both allow and deny, which gcloud-mcp rejects", path, p.Name)
+		}
+		if len(p.Commands) == 0 {
+			return nil, fmt.Errorf("%s: policy %s has no commands", path, p.Name)
+		}
+		for i, c := range p.Commands {
+			if len(c.Args) == 0 {
+				return nil, fmt.Errorf("%s: policy %s command %d has no args", path, p.Name, i+1)
+			}
+			if c.Expect != Allowed && c.Expect != Denied {
+				return nil, fmt.Errorf("%s: policy %s command %d (%s) expects %q; want %s or %s", path, p.Name, i+1, c, c.Expect, Allowed, Denied)
+			}
+		}
+	}
+	return &f, nil
+}
@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if f, err := Load(filepath.Join(dir, "missing.yaml"), true); err != nil || len(f.Policies) != 0 {
+		t.Errorf("Load(missing) = %v, %v", f, err)
+	}
+	for body, want := range map[string]string{
+		"policies:\n  - {name: Bad, commands: [{args: [info], expect: allowed}]}\n":                                                           "lowercase",
+		"policies:\n  - {name: a, commands: [{args: [info], expect: allowed}]}\n  - {name: a, commands: [{args: [info], expect: allowed}]}\n": "more than once",
+		"policies:\n  - {name: a, allow: [info], deny: [meta], commands: [{args: [info], expect: allowed}]}\n":                                "both allow and deny",
+		"policies:\n  - {name: a}\n":                                            "no commands",
+		"policies:\n  - {name: a, commands: [{expect: denied}]}\n":              "no args",
+		"policies:\n  - {name: a, commands: [{args: [info], expect: maybe}]}\n": `expects "maybe"`,
+	} {
+		path := filepath.Join(dir, "policies.yaml")
+		os.WriteFile(path, []byte(body), 0o644)
+		if _, err := Load(path, false); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) = %v, want an error containing %q", body, err, want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	denied := Command{Args: []string{"projects", "delete", "p"}, Expect: Denied}
+	allowed := Command{Args: []string{"config", "list"}, Expect: Allowed}
+	const denial = "Execution denied: This command is on the access control's denylist.\n* Do not attempt to run this command again"
+	for _, tt := range []struct {
+		c       Command
+		isError bool
+		text    string
+		status  int
+		reason  string
+	}{
+		{denied, true, denial, -1, ""},
+		{denied, false, "Deleted [p].", 0, report.ReasonPolicy},
+		{denied, true, "ERROR: (gcloud.projects.delete) NOT_FOUND", -1, report.ReasonPolicy},
+		{allowed, false, "[core]\nproject = p", 0, ""},
+		// Output on stderr alone is no failure: gcloud writes notices there.
+		{allowed, false, "[core]\nproject = p\n\nSTDERR:\nYour active configuration is: [default]\n", 0, ""},
+		{allowed, true, denial, -1, report.ReasonPolicy},
+		{allowed, true, "Failed to parse the input command.", -1, report.ReasonToolError},
+		// gcloud-mcp returns a failed gcloud command as a successful result.
+		{allowed, false, "\nSTDERR:\nERROR: (gcloud.config.list) unrecognized arguments: --bogus\n", 2, report.ReasonToolError},
+		{allowed, false, "\nSTDERR:\nWARNING: a warning\nERROR: (gcloud.projects.describe) NOT_FOUND\n", -1, report.ReasonToolError},
+	} {
+		err := tt.c.Check(tt.isError, tt.text, tt.status)
+		if (err == nil) != (tt.reason == "") || err != nil && report.ReasonOf(err) != tt.reason {
+			t.Errorf("%s.Check(%v, %q, %d) = %v, want reason %q", tt.c, tt.isError, tt.text, tt.status, err, tt.reason)
+		}
+		if err != nil && strings.Contains(err.Error(), "Do not attempt") {
+			t.Errorf("%s.Check() error %q includes more than the first line", tt.c, err)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	if code, ok := ExitStatus("gcloud exited with status 2"); code != 2 || !ok {
+		t.Errorf("ExitStatus() = %d, %v, want 2, true", code, ok)
+	}
+	for _, message := range []string{"Running gcloud config list", "gcloud exited with status ?", ""} {
+		if _, ok := ExitStatus(message); ok {
+			t.Errorf("ExitStatus(%q) reports a status", message)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	if data, err := (Policy{Name: "default"}).Config(); data != nil || err != nil {
+		t.Errorf("Config() of a policy without lists = %s, %v", data, err)
+	}
+	data, err := Policy{Deny: []string{"projects delete"}}.Config()
+	if err != nil || string(data) != `{"deny":["projects delete"]}` {
+		t.Errorf("Config() = %s, %v", data, err)
+	}
+	if got := (Command{Args: []string{"projects", "describe", "${project}"}}).Expand("p1"); strings.Join(got, " ") != "projects describe p1" {
+		t.Errorf("Expand() = %v", got)
+	}
+}
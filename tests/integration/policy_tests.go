@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/policy"
+)
+
+// defaultPolicyFile declares the gcloud-policy-* tests; it may be absent.
+const defaultPolicyFile = "gcloud_policies.yaml"
+
+// registerPolicies adds a gcloud-policy-<name> test for each policy of the
+// file at path, which may be missing if it is the default one, to testCases.
+func registerPolicies(path string) error {
+	f, err := policy.Load(path, path == defaultPolicyFile)
+	if err != nil {
+		return err
+	}
+	var tests []testCase
+	for _, p := range f.Policies {
+		tc := testCase{id: "gcloud-policy-" + p.Name, requires: gcloudServer.Command[:1]}
+		for _, c := range p.Commands {
+			tc.steps = append(tc.steps, fmt.Sprintf("%s: %s", c.Expect, c))
+		}
+		tc.run = func(t *testContext) error {
+			return runPolicy(t, &p)
+		}
+		tests = append(tests, gcloudSuite.add(tc))
+	}
+	testCases = append(slices.Clip(testCases), tests...)
+	return nil
+}
+
+// runPolicy starts gcloud-mcp with p's access control configuration and
+// checks each of its commands in one session. The commands a policy must deny
+// target resources that do not exist, so a policy that lets one through
+// changes nothing.
+func runPolicy(t *testContext, p *policy.Policy) error {
+	logger.Printf("🚀 Starting gcloud-mcp %s policy test (%d commands)...\n", p.Name, len(p.Commands))
+	serverCmd := gcloudServer.Command
+	config, err := p.Config()
+	if err != nil {
+		return err
+	}
+	if config != nil {
+		dir, err := os.MkdirTemp("", t.id+"-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		// gcloud-mcp only accepts an absolute --config path.
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, config, 0o644); err != nil {
+			return err
+		}
+		serverCmd = slices.Concat(serverCmd, []string{"--config", path})
+	}
+	session, err := t.openSession(client.ToolCall{ServerCmd: serverCmd})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	for i, c := range p.Commands {
+		t.Progress(c.String(), 100*i/len(p.Commands))
+		result, err := session.CallTool("run_gcloud_command", map[string]any{"args": c.Expand(testProject)})
+		if err != nil {
+			return fmt.Errorf("error calling run_gcloud_command with %s: %w", c, err)
+		}
+		if err := c.Check(result.IsError, result.Text(), exitStatus(result)); err != nil {
+			return err
+		}
+		logger.Printf("🛡️  %s was %s\n", c, c.Expect)
+	}
+	logger.Printf("✅ Assertion passed: the %s policy allowed and denied all %d commands as expected\n", p.Name, len(p.Commands))
+	return nil
+}
+
+// exitStatus returns the exit status of gcloud the last progress
+// notification of a run_gcloud_command result reports, or -1 if none does.
+func exitStatus(result *client.Result) int {
+	for _, n := range slices.Backward(result.Notifications) {
+		if code, ok := policy.ExitStatus(n.Message); n.Kind == client.NotificationProgress && ok {
+			return code
+		}
+	}
+	return -1
+}
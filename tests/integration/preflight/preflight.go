@@ -0,0 +1,192 @@
+// Package preflight checks that the environment can run the suite at all —
+// credentials, project access and enabled APIs — so a misconfigured machine
+// fails up front with a remediation hint instead of deep inside a tool call.
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+)
+
+const (
+	defaultResourceManager = "https://cloudresourcemanager.googleapis.com/v1"
+	defaultServiceUsage    = "https://serviceusage.googleapis.com/v1"
+)
+
+// DefaultAPIs are the services the servers under test call.
+var DefaultAPIs = []string{"storage.googleapis.com", "logging.googleapis.com", "monitoring.googleapis.com"}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check string
+	// Err is nil if the check passed.
+	Err error
+	// Hint tells the user how to fix a failed check.
+	Hint string
+}
+
+// Checker runs the preflight checks against a project.
+type Checker struct {
+	Project string
+	// Account is the gcloud account the servers run as. Defaults to the
+	// active account.
+	Account string
+	// APIs must be enabled on Project. Defaults to DefaultAPIs.
+	APIs []string
+	// ResourceManager and ServiceUsage override the API base URLs.
+	ResourceManager string
+	ServiceUsage    string
+	// ADCToken returns an access token for the application default
+	// credentials. Defaults to `gcloud auth application-default
+	// print-access-token`.
+	ADCToken func(context.Context) (string, error)
+	// AccountToken returns an access token for Account, which the project
+	// and API checks use, since gcloud-mcp runs gcloud as that account.
+	// Defaults to `gcloud auth print-access-token`.
+	AccountToken func(ctx context.Context, account string) (string, error)
+	HTTPClient   *http.Client
+}
+
+// Run performs every check and returns their results in order. The project
+// and API checks are not run without a token for the gcloud account.
+func (c *Checker) Run(ctx context.Context) []Result {
+	adc := Result{Check: "application default credentials"}
+	if _, err := c.adcToken(ctx); err != nil {
+		adc.Err = err
+		adc.Hint = "run `gcloud auth application-default login`, or set GOOGLE_APPLICATION_CREDENTIALS to a service account key file"
+	}
+	results := []Result{adc}
+
+	account := Result{Check: "active gcloud account"}
+	if c.Account != "" {
+		account.Check = "gcloud account " + c.Account
+	}
+	token, err := c.accountToken(ctx)
+	if err != nil {
+		account.Err = err
+		account.Hint = "run `gcloud auth login`, or `gcloud config set account` to an account that is already logged in"
+		return append(results, account)
+	}
+	results = append(results, account)
+
+	if c.Project == "" {
+		return append(results, Result{
+			Check: "test project",
+			Err:   errors.New("no project configured"),
+			Hint:  "configure the project the tests run against",
+		})
+	}
+	access := Result{Check: "access to project " + c.Project}
+	if err := c.get(ctx, token, fmt.Sprintf("%s/projects/%s", c.endpoint(c.ResourceManager, defaultResourceManager), c.Project), nil); err != nil {
+		access.Err = err
+		access.Hint = fmt.Sprintf("grant the gcloud account at least roles/viewer on %s, or switch to an account that has it", c.Project)
+		// Without access the API checks would only repeat the same error.
+		return append(results, access)
+	}
+	results = append(results, access)
+
+	apis := c.APIs
+	if apis == nil {
+		apis = DefaultAPIs
+	}
+	for _, api := range apis {
+		r := Result{Check: "API " + api}
+		var service struct {
+			State string `json:"state"`
+		}
+		url := fmt.Sprintf("%s/projects/%s/services/%s", c.endpoint(c.ServiceUsage, defaultServiceUsage), c.Project, api)
+		if err := c.get(ctx, token, url, &service); err != nil {
+			r.Err = err
+			r.Hint = fmt.Sprintf("grant the gcloud account serviceusage.services.get on %s, or check the API by hand", c.Project)
+		} else if service.State != "ENABLED" {
+			r.Err = fmt.Errorf("%s is %s", api, strings.ToLower(service.State))
+			r.Hint = fmt.Sprintf("run `gcloud services enable %s --project %s`", api, c.Project)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Failed returns the failed results.
+func Failed(results []Result) []Result {
+	var out []Result
+	for _, r := range results {
+		if r.Err != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (c *Checker) endpoint(override, def string) string {
+	if override != "" {
+		return override
+	}
+	return def
+}
+
+func (c *Checker) get(ctx context.Context, token, url string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, apiMessage(body))
+	}
+	if into == nil {
+		return nil
+	}
+	return json.Unmarshal(body, into)
+}
+
+// apiMessage extracts error.message from a Google API error body.
+func apiMessage(body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+func (c *Checker) adcToken(ctx context.Context) (string, error) {
+	if c.ADCToken != nil {
+		return c.ADCToken(ctx)
+	}
+	return gcloudauth.Print(ctx, "auth", "application-default", "print-access-token")
+}
+
+func (c *Checker) accountToken(ctx context.Context) (string, error) {
+	if c.AccountToken != nil {
+		return c.AccountToken(ctx, c.Account)
+	}
+	args := []string{"auth", "print-access-token"}
+	if c.Account != "" {
+		args = append(args, c.Account)
+	}
+	return gcloudauth.Print(ctx, args...)
+}
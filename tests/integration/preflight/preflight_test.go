@@ -0,0 +1,99 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func fakeAPIs(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects/test-project", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"projectId": "test-project"}`))
+	})
+	mux.HandleFunc("/projects/no-access", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": {"code": 403, "message": "The caller does not have permission"}}`))
+	})
+	mux.HandleFunc("/projects/test-project/services/", func(w http.ResponseWriter, r *http.Request) {
+		state := "ENABLED"
+		if strings.HasSuffix(r.URL.Path, "/logging.googleapis.com") {
+			state = "DISABLED"
+		}
+		w.Write([]byte(`{"state": "` + state + `"}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func checker(ts *httptest.Server, project string) *Checker {
+	return &Checker{
+		Project:         project,
+		ResourceManager: ts.URL,
+		ServiceUsage:    ts.URL,
+		ADCToken:        func(context.Context) (string, error) { return "adc-token", nil },
+		AccountToken:    func(context.Context, string) (string, error) { return "token", nil },
+	}
+}
+
+func TestRun(t *testing.T) {
+	results := checker(fakeAPIs(t), "test-project").Run(context.Background())
+	if len(results) != 6 {
+		t.Fatalf("got %d results, want 6: %+v", len(results), results)
+	}
+	failed := Failed(results)
+	if len(failed) != 1 || failed[0].Check != "API logging.googleapis.com" {
+		t.Fatalf("Failed() = %+v", failed)
+	}
+	if want := "gcloud services enable logging.googleapis.com --project test-project"; !strings.Contains(failed[0].Hint, want) {
+		t.Errorf("Hint = %q, want it to mention %q", failed[0].Hint, want)
+	}
+}
+
+func TestRunNoAccess(t *testing.T) {
+	results := checker(fakeAPIs(t), "no-access").Run(context.Background())
+	failed := Failed(results)
+	if len(results) != 3 || len(failed) != 1 || !strings.Contains(failed[0].Err.Error(), "does not have permission") {
+		t.Errorf("Run() = %+v", results)
+	}
+}
+
+func TestRunNoCredentials(t *testing.T) {
+	c := &Checker{
+		Project:      "p",
+		ADCToken:     func(context.Context) (string, error) { return "", errors.New("no credentials") },
+		AccountToken: func(context.Context, string) (string, error) { return "", errors.New("no account") },
+	}
+	results := c.Run(context.Background())
+	if len(results) != 2 || len(Failed(results)) != 2 || !strings.Contains(results[0].Hint, "application-default login") || !strings.Contains(results[1].Hint, "gcloud auth login") {
+		t.Errorf("Run() = %+v", results)
+	}
+}
+
+func TestRunChecksProjectAsGcloudAccount(t *testing.T) {
+	// Access is checked with the gcloud account's token even when the
+	// application default credentials are missing.
+	c := checker(fakeAPIs(t), "test-project")
+	c.Account = "tester@example.com"
+	c.ADCToken = func(context.Context) (string, error) { return "", errors.New("no credentials") }
+	var asked string
+	c.AccountToken = func(_ context.Context, account string) (string, error) {
+		asked = account
+		return "token", nil
+	}
+	results := c.Run(context.Background())
+	if asked != c.Account {
+		t.Errorf("token requested for %q, want %q", asked, c.Account)
+	}
+	if len(results) != 6 || results[1].Check != "gcloud account tester@example.com" || results[2].Err != nil {
+		t.Errorf("Run() = %+v", results)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// progress holds the steps a running test reported with Progress.
+type progress struct {
+	test    string
+	started time.Time
+
+	mu    sync.Mutex
+	steps []client.Notification
+}
+
+// liveProgress is the progress of the running test, or nil between tests.
+// The heartbeat and the watchdog report it.
+var liveProgress atomic.Pointer[progress]
+
+// Progress reports that the test reached step, pct percent of the way
+// through, so a long test shows where it is rather than appearing hung. The
+// step is printed, shown by the heartbeat and the watchdog, and recorded in
+// the test's timeline. It is safe to call from several goroutines.
+func (t *testContext) Progress(step string, pct int) {
+	pct = min(max(pct, 0), 100)
+	t.progress.mu.Lock()
+	t.progress.steps = append(t.progress.steps, client.Notification{At: time.Now(), Kind: client.NotificationStep, Progress: float64(pct), Message: step})
+	t.progress.mu.Unlock()
+	logger.Printf("⏳ %s: %s (%d%%)\n", t.id, step, pct)
+}
+
+// recorded returns the steps reported so far.
+func (p *progress) recorded() []client.Notification {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.steps)
+}
+
+// status describes how long the test has been running and its last step.
+func (p *progress) status() string {
+	s := fmt.Sprintf("%s has been running for %s", p.test, time.Since(p.started).Round(time.Second))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.steps) == 0 {
+		return s + " without reporting a step"
+	}
+	last := p.steps[len(p.steps)-1]
+	return fmt.Sprintf("%s; last step %q (%g%%) %s ago", s, last.Message, last.Progress, time.Since(last.At).Round(time.Second))
+}
+
+// startHeartbeat prints the running test's status every interval until the
+// returned function is called. A non-positive interval prints nothing.
+func startHeartbeat(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if p := liveProgress.Load(); p != nil {
+					logger.Printf("💓 %s\n", p.status())
+				}
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/lease"
+)
+
+// runID identifies the run in the names of the resources its tests create,
+// in its leases and in its results, so runs sharing the test project tell
+// their resources apart. -run-id sets it, e.g. to the CI run number; it
+// defaults to a random one.
+var runID string
+
+// validRunID keeps a run ID usable inside any resource name: bucket, sink
+// and project names all take lowercase letters and digits.
+var validRunID = regexp.MustCompile(`^[a-z0-9]{1,12}$`)
+
+// newRunID returns a random run ID.
+func newRunID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// leasePoll is how often a run waiting for the test project checks whether
+// it is free.
+const leasePoll = 30 * time.Second
+
+// leaseProject takes the lease on the test project in bucket, waiting up to
+// wait while another run holds it. The lease lasts ttl, past which a run that
+// was killed holding it no longer blocks others.
+func leaseProject(bucket string, wait, ttl time.Duration) (*lease.Lease, error) {
+	locker := &lease.Locker{Bucket: bucket, Holder: runHolder(), TTL: ttl}
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	l, err := locker.Wait(ctx, testProject, leasePoll, func(err error) {
+		logger.Printf("⏳ Waiting for the test project: %v\n", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("🔒 Leased %s for run %s until %s\n", testProject, runID, l.Expires.Format(time.RFC3339))
+	return l, nil
+}
+
+// releaseProject gives the test project's lease up. A failure is logged:
+// the lease then expires on its own.
+func releaseProject(l *lease.Lease) {
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+	if err := l.Release(ctx); err != nil {
+		logf(slog.LevelWarn, "⚠️  could not release the lease on %s: %v\n", testProject, err)
+		return
+	}
+	logger.Printf("🔓 Released %s\n", testProject)
+}
+
+// runHolder identifies this run in the leases it takes.
+func runHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("run %s on %s (pid %d)", runID, host, os.Getpid())
+}
@@ -0,0 +1,305 @@
+// Package projects provides the throwaway GCP projects destructive tests run
+// in, so they cannot damage the shared test project: a Provisioner creates a
+// project for one run and deletes it afterwards, and a Pool leases one of a
+// set of projects created in advance, which is faster and needs no
+// permission to create projects or link billing.
+package projects
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+	"github.com/googleapis/gcloud-mcp/tests/integration/lease"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+)
+
+const (
+	defaultResourceManager = "https://cloudresourcemanager.googleapis.com/v3"
+	defaultBilling         = "https://cloudbilling.googleapis.com/v1"
+	defaultServiceUsage    = "https://serviceusage.googleapis.com/v1"
+)
+
+// DefaultAPIs are the APIs a created project enables unless the Provisioner
+// sets APIs: those the registered servers call.
+var DefaultAPIs = []string{
+	"storage.googleapis.com",
+	"logging.googleapis.com",
+	"monitoring.googleapis.com",
+	"pubsub.googleapis.com",
+	"bigquery.googleapis.com",
+}
+
+// Label marks the projects a Provisioner creates, so one a killed run left
+// behind is told apart from the organization's other projects.
+const Label = "mcp-it"
+
+const defaultPollInterval = 2 * time.Second
+
+// maxIDLen is the longest project ID.
+const maxIDLen = 30
+
+// NewID returns the ID of a new project for the run runID: orphans.Prefix,
+// the run ID and as many random hex digits, up to 16, as fit the 30
+// characters a project ID may have. runID must be at most 12 characters.
+func NewID(runID string, r *rand.Rand) string {
+	id := orphans.Prefix + runID + "-"
+	return id + fmt.Sprintf("%016x", r.Uint64())[:min(16, maxIDLen-len(id))]
+}
+
+// Provisioner creates and deletes projects.
+type Provisioner struct {
+	// Parent is the folder or organization new projects go in, e.g.
+	// folders/123.
+	Parent string
+	// BillingAccount is the ID of the billing account new projects are
+	// linked to, e.g. 0X0X0X-0X0X0X-0X0X0X.
+	BillingAccount string
+	// APIs are enabled in new projects. Defaults to DefaultAPIs.
+	APIs []string
+	// Token returns an access token. Defaults to gcloudauth.AccessToken.
+	Token func(context.Context) (string, error)
+	// ResourceManager, Billing and ServiceUsage override the API base URLs.
+	ResourceManager string
+	Billing         string
+	ServiceUsage    string
+	HTTPClient      *http.Client
+	// PollInterval is how often a long-running operation is checked.
+	// Defaults to 2s.
+	PollInterval time.Duration
+}
+
+// operation is a long-running operation of Resource Manager or Service
+// Usage.
+type operation struct {
+	Name  string `json:"name"`
+	Done  bool   `json:"done"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Create creates the project id under Parent, links it to BillingAccount and
+// enables its APIs. If linking or enabling fails, it deletes the project
+// again.
+func (p *Provisioner) Create(ctx context.Context, id string) error {
+	token, err := p.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get an access token: %w", err)
+	}
+	project := map[string]any{"projectId": id, "parent": p.Parent, "displayName": id, "labels": map[string]string{Label: "ephemeral"}}
+	if err := p.run(ctx, token, p.resourceManager(), p.resourceManager()+"/projects", project); err != nil {
+		return fmt.Errorf("failed to create project %s: %w", id, err)
+	}
+	if err := p.setUp(ctx, token, id); err != nil {
+		if _, delErr := p.do(ctx, http.MethodDelete, p.resourceManager()+"/projects/"+id, token, nil); delErr != nil {
+			return fmt.Errorf("%w; the project was left behind: %w", err, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// setUp links the new project id to BillingAccount and enables its APIs.
+func (p *Provisioner) setUp(ctx context.Context, token, id string) error {
+	if p.BillingAccount != "" {
+		billing := map[string]string{"billingAccountName": "billingAccounts/" + p.BillingAccount}
+		if _, err := p.do(ctx, http.MethodPut, p.billing()+"/projects/"+id+"/billingInfo", token, billing); err != nil {
+			return fmt.Errorf("failed to link project %s to billing account %s: %w", id, p.BillingAccount, err)
+		}
+	}
+	apis := p.APIs
+	if apis == nil {
+		apis = DefaultAPIs
+	}
+	if len(apis) > 0 {
+		enable := map[string][]string{"serviceIds": apis}
+		if err := p.run(ctx, token, p.serviceUsage(), p.serviceUsage()+"/projects/"+id+"/services:batchEnable", enable); err != nil {
+			return fmt.Errorf("failed to enable APIs in project %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Delete deletes the project id. Its resources go with it; the project
+// itself stays recoverable for 30 days, as any deleted project does.
+func (p *Provisioner) Delete(ctx context.Context, id string) error {
+	token, err := p.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get an access token: %w", err)
+	}
+	if _, err := p.do(ctx, http.MethodDelete, p.resourceManager()+"/projects/"+id, token, nil); err != nil {
+		return fmt.Errorf("failed to delete project %s: %w", id, err)
+	}
+	return nil
+}
+
+// Find returns the active projects under Parent labelled Label, which runs
+// that could not delete theirs left behind, created more than olderThan
+// before now, or all of them if olderThan is zero.
+func (p *Provisioner) Find(ctx context.Context, olderThan time.Duration, now time.Time) ([]orphans.Resource, error) {
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get an access token: %w", err)
+	}
+	query := url.QueryEscape(fmt.Sprintf("parent:%s labels.%s:* state:ACTIVE", p.Parent, Label))
+	var found []orphans.Resource
+	pageToken := ""
+	for {
+		u := p.resourceManager() + "/projects:search?query=" + query
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		data, err := p.do(ctx, http.MethodGet, u, token, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for %s projects: %w", Label, err)
+		}
+		var page struct {
+			Projects []struct {
+				ProjectID  string    `json:"projectId"`
+				CreateTime time.Time `json:"createTime"`
+			} `json:"projects"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		for _, project := range page.Projects {
+			if olderThan <= 0 || now.Sub(project.CreateTime) > olderThan {
+				found = append(found, orphans.Resource{Kind: orphans.KindProject, Name: project.ProjectID, Created: project.CreateTime})
+			}
+		}
+		if pageToken = page.NextPageToken; pageToken == "" {
+			return found, nil
+		}
+	}
+}
+
+// run posts body to u, which starts a long-running operation of the API at
+// base, and waits until the operation is done.
+func (p *Provisioner) run(ctx context.Context, token, base, u string, body any) error {
+	data, err := p.do(ctx, http.MethodPost, u, token, body)
+	for err == nil {
+		var op operation
+		if err := json.Unmarshal(data, &op); err != nil {
+			return err
+		}
+		if op.Done {
+			if op.Error != nil {
+				return errors.New(op.Error.Message)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("operation %s did not finish: %w", op.Name, ctx.Err())
+		case <-time.After(p.pollInterval()):
+		}
+		data, err = p.do(ctx, http.MethodGet, base+"/"+op.Name, token, nil)
+	}
+	return err
+}
+
+func (p *Provisioner) do(ctx context.Context, method, u, token string, body any) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(data[:min(len(data), 4096)])))
+	}
+	return data, nil
+}
+
+func (p *Provisioner) resourceManager() string {
+	if p.ResourceManager != "" {
+		return p.ResourceManager
+	}
+	return defaultResourceManager
+}
+
+func (p *Provisioner) billing() string {
+	if p.Billing != "" {
+		return p.Billing
+	}
+	return defaultBilling
+}
+
+func (p *Provisioner) serviceUsage() string {
+	if p.ServiceUsage != "" {
+		return p.ServiceUsage
+	}
+	return defaultServiceUsage
+}
+
+func (p *Provisioner) pollInterval() time.Duration {
+	if p.PollInterval > 0 {
+		return p.PollInterval
+	}
+	return defaultPollInterval
+}
+
+func (p *Provisioner) token(ctx context.Context) (string, error) {
+	if p.Token != nil {
+		return p.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
+
+// Pool leases projects created in advance, one run at a time each.
+type Pool struct {
+	Projects []string
+	Locker   *lease.Locker
+}
+
+// Lease leases the first project of the pool no other run holds. If every
+// one is held, it returns an error wrapping lease.ErrHeld.
+func (p *Pool) Lease(ctx context.Context) (string, *lease.Lease, error) {
+	var held []string
+	for _, project := range p.Projects {
+		l, err := p.Locker.Acquire(ctx, project)
+		if errors.Is(err, lease.ErrHeld) {
+			held = append(held, err.Error())
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return project, l, nil
+	}
+	return "", nil, fmt.Errorf("every project of the pool is leased: %s: %w", strings.Join(held, "; "), lease.ErrHeld)
+}
@@ -0,0 +1,156 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+)
+
+func TestNewID(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	valid := regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	for _, runID := range []string{"7", "1a2b3c4d", "123456789012"} {
+		if id := NewID(runID, r); !valid.MatchString(id) || !strings.HasPrefix(id, "mcp-it-"+runID+"-") {
+			t.Errorf("NewID(%s) = %s, not a valid project ID of the run", runID, id)
+		}
+	}
+}
+
+// fakeCloud serves the project, billing and service APIs, finishing each
+// operation on its second poll.
+type fakeCloud struct {
+	mu    sync.Mutex
+	calls []string
+	polls map[string]int
+}
+
+func (f *fakeCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthenticated", http.StatusUnauthorized)
+		return
+	}
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	var body map[string]any
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "POST /v3/projects":
+		if body["parent"] != "folders/1" {
+			http.Error(w, "bad project", http.StatusBadRequest)
+			return
+		}
+		if body["projectId"] == "mcp-it-denied" {
+			w.Write([]byte(`{"name": "operations/create", "done": true}`))
+			return
+		}
+		w.Write([]byte(`{"name": "operations/create", "done": false}`))
+	case "GET /v3/operations/create", "GET /v1/operations/enable":
+		f.polls[r.URL.Path]++
+		// Strip the API version, /v1/ or /v3/.
+		json.NewEncoder(w).Encode(map[string]any{"name": r.URL.Path[len("/v1/"):], "done": f.polls[r.URL.Path] > 1})
+	case "PUT /v1/projects/mcp-it-x/billingInfo":
+		if body["billingAccountName"] != "billingAccounts/AB-CD" {
+			http.Error(w, "bad account", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{}`))
+	case "POST /v1/projects/mcp-it-x/services:batchEnable":
+		w.Write([]byte(`{"name": "operations/enable"}`))
+	case "DELETE /v3/projects/mcp-it-x", "DELETE /v3/projects/mcp-it-denied":
+		w.Write([]byte(`{"name": "operations/delete", "done": true}`))
+	case "GET /v3/projects:search":
+		if r.URL.Query().Get("query") != "parent:folders/1 labels.mcp-it:* state:ACTIVE" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			w.Write([]byte(`{"projects": [{"projectId": "mcp-it-old", "createTime": "2026-09-01T00:00:00Z"}], "nextPageToken": "2"}`))
+			return
+		}
+		w.Write([]byte(`{"projects": [{"projectId": "mcp-it-new", "createTime": "2026-10-01T11:00:00Z"}]}`))
+	case "POST /v1/projects/mcp-it-denied/services:batchEnable":
+		w.Write([]byte(`{"name": "operations/denied", "done": true, "error": {"message": "permission denied"}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestProvisioner(t *testing.T) {
+	cloud := &fakeCloud{polls: map[string]int{}}
+	srv := httptest.NewServer(cloud)
+	defer srv.Close()
+	p := &Provisioner{
+		Parent:          "folders/1",
+		BillingAccount:  "AB-CD",
+		APIs:            []string{"storage.googleapis.com"},
+		Token:           func(context.Context) (string, error) { return "token", nil },
+		ResourceManager: srv.URL + "/v3",
+		Billing:         srv.URL + "/v1",
+		ServiceUsage:    srv.URL + "/v1",
+		PollInterval:    1,
+	}
+	ctx := context.Background()
+	if err := p.Create(ctx, "mcp-it-x"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := p.Delete(ctx, "mcp-it-x"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	want := []string{
+		"POST /v3/projects",
+		"GET /v3/operations/create",
+		"GET /v3/operations/create",
+		"PUT /v1/projects/mcp-it-x/billingInfo",
+		"POST /v1/projects/mcp-it-x/services:batchEnable",
+		"GET /v1/operations/enable",
+		"GET /v1/operations/enable",
+		"DELETE /v3/projects/mcp-it-x",
+	}
+	if !slices.Equal(cloud.calls, want) {
+		t.Errorf("calls = %q, want %q", cloud.calls, want)
+	}
+
+	// A project whose APIs could not be enabled is deleted again.
+	cloud.calls = nil
+	p.BillingAccount = ""
+	if err := p.Create(ctx, "mcp-it-denied"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Create of a project whose APIs cannot be enabled = %v, want permission denied", err)
+	}
+	if last := cloud.calls[len(cloud.calls)-1]; last != "DELETE /v3/projects/mcp-it-denied" {
+		t.Errorf("last call = %s, want the project deleted", last)
+	}
+}
+
+func TestFind(t *testing.T) {
+	srv := httptest.NewServer(&fakeCloud{polls: map[string]int{}})
+	defer srv.Close()
+	p := &Provisioner{
+		Parent:          "folders/1",
+		Token:           func(context.Context) (string, error) { return "token", nil },
+		ResourceManager: srv.URL + "/v3",
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	found, err := p.Find(context.Background(), 24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Name != "mcp-it-old" || found[0].Kind != orphans.KindProject {
+		t.Errorf("Find = %v, want only the project older than a day", found)
+	}
+	if all, err := p.Find(context.Background(), 0, now); err != nil || len(all) != 2 {
+		t.Errorf("Find with no age = %v, %v, want both pages", all, err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The protocol-versions-* tests handshake with every registered server and
+// the example server at each protocol version the Gemini CLI still requests,
+// so a server release dropping one fails here before it strands the users of
+// an older CLI.
+
+// geminiProtocolVersions are the protocol versions the Gemini CLI releases
+// in use request in initialize, newest first; -protocol-versions replaces
+// them. The CLI bundles the TypeScript MCP SDK, @modelcontextprotocol/sdk,
+// whose client requests the SDK's LATEST_PROTOCOL_VERSION: 2025-06-18 since
+// SDK 1.13.0, and 2025-03-26 from 1.10.0 up to 1.12, which the earliest CLI
+// releases bundled. The list is pinned rather than taken from
+// client.ProtocolVersions, which is what the Go SDK speaks. When a CLI
+// release bundles an SDK with a newer LATEST_PROTOCOL_VERSION, add it once
+// the Go SDK can request it; drop a version only once no supported release
+// asks for it.
+var geminiProtocolVersions = []string{"2025-06-18", "2025-03-26"}
+
+// parseProtocolVersions parses the comma-separated versions of
+// -protocol-versions.
+func parseProtocolVersions(list string) ([]string, error) {
+	var versions []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if err := client.ValidProtocolVersion(v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no protocol versions given")
+	}
+	return versions, nil
+}
+
+// protocolVersionTests returns a protocol-versions-<name> test for every
+// registered server and one for the example server.
+func protocolVersionTests() []testCase {
+	tests := []testCase{{
+		id:   "protocol-versions-example",
+		tags: smoke,
+		run:  func(t *testContext) error { return testProtocolVersions(t, "example server", exampleServerCmd()) },
+	}}
+	for _, s := range serverRegistry.All() {
+		tests = append(tests, testCase{
+			id:       "protocol-versions-" + s.Name,
+			requires: s.Command[:1],
+			run:      func(t *testContext) error { return testProtocolVersions(t, s.Name, s.Command) },
+		})
+	}
+	return tests
+}
+
+func testProtocolVersions(t *testContext, server string, command []string) error {
+	logger.Printf("🚀 Starting %s protocol version test...\n", server)
+	var dropped []string
+	for _, version := range geminiProtocolVersions {
+		// Listing the tools uses the session past the handshake.
+		_, err := client.ListTools(t.withDefaults(client.ToolCall{ServerCmd: command, ProtocolVersion: version}))
+		switch {
+		case errors.Is(err, client.ErrProtocolVersion):
+			logf(slog.LevelError, "  ❌ %s: %v\n", version, err)
+			dropped = append(dropped, version)
+		case err != nil:
+			return fmt.Errorf("error connecting at protocol version %s: %w", version, err)
+		default:
+			logger.Printf("  ✅ %s\n", version)
+		}
+	}
+	if len(dropped) > 0 {
+		return report.Fail(report.ReasonProtocol, "%s no longer accepts protocol versions the Gemini CLI still requests: %s", server, strings.Join(dropped, ", "))
+	}
+	logger.Printf("✅ Assertion passed: %s accepts protocol versions %s\n", server, strings.Join(geminiProtocolVersions, ", "))
+	return nil
+}
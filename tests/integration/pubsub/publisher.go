@@ -0,0 +1,174 @@
+// Package pubsub publishes a summary of each integration run to a Pub/Sub
+// topic, so dashboards and alerting pipelines can consume the results without
+// parsing CI artifacts.
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+const defaultEndpoint = "https://pubsub.googleapis.com/v1"
+
+// SchemaVersion is the version of Summary; it is incremented on changes
+// that break consumers, e.g. a renamed field, and not when fields are added.
+const SchemaVersion = 1
+
+// Summary is the message published for a run, as JSON.
+type Summary struct {
+	SchemaVersion int       `json:"schema_version"`
+	Started       time.Time `json:"started"`
+	DurationMS    int64     `json:"duration_ms"`
+	// Status is failed if any test failed, else passed.
+	Status      string            `json:"status"`
+	Passed      int               `json:"passed"`
+	Failed      int               `json:"failed"`
+	Skipped     int               `json:"skipped"`
+	Flaky       int               `json:"flaky"`
+	Quarantined int               `json:"quarantined"`
+	Seed        int64             `json:"seed"`
+	Harness     string            `json:"harness,omitempty"`
+	Platform    string            `json:"platform,omitempty"`
+	Shard       string            `json:"shard,omitempty"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Tests       []Test            `json:"tests"`
+}
+
+// Test is the outcome of one test in a Summary.
+type Test struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Summarize returns the Summary of run.
+func Summarize(run *report.Run) *Summary {
+	passed, failed, skipped := run.Counts()
+	s := &Summary{
+		SchemaVersion: SchemaVersion,
+		Started:       run.Started,
+		DurationMS:    run.Duration.Milliseconds(),
+		Status:        "passed",
+		Passed:        passed,
+		Failed:        failed,
+		Skipped:       skipped,
+		Flaky:         len(run.Flaky()),
+		Quarantined:   len(run.Quarantined()),
+		Seed:          run.Seed,
+		Harness:       run.Harness,
+		Platform:      run.Platform,
+		TraceID:       run.TraceID,
+		Labels:        run.Labels,
+		Tests:         make([]Test, 0, len(run.Tests)),
+	}
+	if failed > 0 {
+		s.Status = "failed"
+	}
+	if run.Shard != nil {
+		s.Shard = run.Shard.String()
+	}
+	for _, t := range run.Tests {
+		s.Tests = append(s.Tests, Test{ID: t.ID, Status: string(t.Status), Reason: t.Reason, DurationMS: t.Duration.Milliseconds()})
+	}
+	return s
+}
+
+// topicName matches a full topic name, projects/PROJECT/topics/TOPIC.
+var topicName = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// ValidTopic checks that topic is a full topic name.
+func ValidTopic(topic string) error {
+	if !topicName.MatchString(topic) {
+		return fmt.Errorf("invalid Pub/Sub topic %q; want projects/PROJECT/topics/TOPIC", topic)
+	}
+	return nil
+}
+
+// Publisher publishes run summaries to a topic.
+type Publisher struct {
+	// Topic is the full topic name, projects/PROJECT/topics/TOPIC.
+	Topic string
+	// Endpoint overrides the Pub/Sub API base URL, e.g. for the emulator.
+	Endpoint string
+	// Token returns an OAuth access token. Defaults to gcloudauth.AccessToken.
+	Token      func(context.Context) (string, error)
+	HTTPClient *http.Client
+}
+
+// Publish publishes the Summary of run as one message. Its attributes,
+// which subscriptions can filter on, are the status, harness version and the
+// run's labels prefixed with label_.
+func (p *Publisher) Publish(ctx context.Context, run *report.Run) error {
+	if err := ValidTopic(p.Topic); err != nil {
+		return err
+	}
+	summary := Summarize(run)
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	attrs := map[string]string{"status": summary.Status, "schema_version": fmt.Sprint(SchemaVersion)}
+	if run.Harness != "" {
+		attrs["harness"] = run.Harness
+	}
+	for k, v := range run.Labels {
+		attrs["label_"+k] = v
+	}
+	// encoding/json encodes the []byte data as base64, as the API expects.
+	body, err := json.Marshal(map[string]any{"messages": []message{{Data: data, Attributes: attrs}}})
+	if err != nil {
+		return err
+	}
+	token, err := p.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/"+p.Topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", p.Topic, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to publish to %s: %s: %s", p.Topic, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+type message struct {
+	Data       []byte            `json:"data"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func (p *Publisher) token(ctx context.Context) (string, error) {
+	if p.Token != nil {
+		return p.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
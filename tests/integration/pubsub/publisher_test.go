@@ -0,0 +1,92 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/shard"
+)
+
+func TestPublish(t *testing.T) {
+	var got struct {
+		Messages []message `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/p/topics/results:publish" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer srv.Close()
+
+	p := &Publisher{
+		Topic:    "projects/p/topics/results",
+		Endpoint: srv.URL,
+		Token:    func(context.Context) (string, error) { return "tok", nil },
+	}
+	run := &report.Run{
+		Started:  time.Unix(100, 0).UTC(),
+		Duration: 90 * time.Second,
+		Seed:     7,
+		Harness:  "v1.2.0",
+		Shard:    &shard.Spec{Index: 1, Count: 3},
+		Labels:   map[string]string{"backend": "canary"},
+		Tests: []report.TestResult{
+			{ID: "a", Status: report.StatusPassed, Duration: 1500 * time.Millisecond},
+			{ID: "b", Status: report.StatusFailed, Reason: report.ReasonAssertion},
+			{ID: "c", Status: report.StatusFlaky},
+		},
+	}
+	if err := p.Publish(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 1 {
+		t.Fatalf("published %d messages, want 1", len(got.Messages))
+	}
+	m := got.Messages[0]
+	want := map[string]string{"status": "failed", "schema_version": "1", "harness": "v1.2.0", "label_backend": "canary"}
+	for k, v := range want {
+		if m.Attributes[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, m.Attributes[k], v)
+		}
+	}
+	var s Summary
+	if err := json.Unmarshal(m.Data, &s); err != nil {
+		t.Fatalf("data is not a summary: %v\n%s", err, m.Data)
+	}
+	if s.Status != "failed" || s.Passed != 1 || s.Failed != 1 || s.Flaky != 1 || s.DurationMS != 90000 || s.Shard != "1/3" || s.Seed != 7 {
+		t.Errorf("summary = %+v", s)
+	}
+	if len(s.Tests) != 3 || s.Tests[0] != (Test{ID: "a", Status: "passed", DurationMS: 1500}) || s.Tests[1].Reason != report.ReasonAssertion {
+		t.Errorf("tests = %+v", s.Tests)
+	}
+}
+
+func TestPublishErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "topic not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	token := func(context.Context) (string, error) { return "tok", nil }
+
+	p := &Publisher{Topic: "projects/p/topics/missing", Endpoint: srv.URL, Token: token}
+	if err := p.Publish(context.Background(), &report.Run{}); err == nil || !strings.Contains(err.Error(), "topic not found") {
+		t.Errorf("Publish() = %v, want the API's error", err)
+	}
+	p.Topic = "results"
+	if err := p.Publish(context.Background(), &report.Run{}); err == nil {
+		t.Error("Publish() to a short topic name succeeded")
+	}
+}
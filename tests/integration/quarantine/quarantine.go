@@ -0,0 +1,120 @@
+// Package quarantine loads the list of tests that are known to fail and may
+// not fail the run until their entry expires.
+//
+// Every entry names an owner and an expiry date, so a quarantine is a
+// deadline rather than a permanent skip: the runner warns as the date
+// approaches and, once it has passed, counts the test's failures again.
+package quarantine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// Entry quarantines one test.
+type Entry struct {
+	Test   string `yaml:"test" json:"test"`
+	Owner  string `yaml:"owner" json:"owner"`
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	// Issue links the bug tracking the fix.
+	Issue string `yaml:"issue,omitempty" json:"issue,omitempty"`
+	// Expires is the last day, in UTC, on which the quarantine applies.
+	Expires time.Time `yaml:"expires" json:"expires"`
+}
+
+// State is where an entry stands relative to its expiry date.
+type State int
+
+const (
+	Active State = iota
+	// Expiring entries still apply but expire within the warning window.
+	Expiring
+	// Expired entries no longer shield the test's failures.
+	Expired
+)
+
+// State reports the entry's state at now, treating entries that expire within
+// warnWithin as Expiring.
+func (e *Entry) State(now time.Time, warnWithin time.Duration) State {
+	end := e.end()
+	switch {
+	case !now.Before(end):
+		return Expired
+	case end.Sub(now) <= warnWithin:
+		return Expiring
+	}
+	return Active
+}
+
+// end is the first instant after the expiry day.
+func (e *Entry) end() time.Time {
+	y, m, d := e.Expires.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("%s (owner %s, expires %s)", e.Test, e.Owner, e.Expires.UTC().Format(time.DateOnly))
+}
+
+// List is the parsed quarantine file.
+type List struct {
+	Entries []Entry `yaml:"quarantine"`
+}
+
+// Load reads a quarantine file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*List, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var l List
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine file %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i, e := range l.Entries {
+		switch {
+		case e.Test == "":
+			return nil, fmt.Errorf("%s: entry %d has no test", path, i+1)
+		case e.Owner == "":
+			return nil, fmt.Errorf("%s: quarantine of %s has no owner", path, e.Test)
+		case e.Expires.IsZero():
+			return nil, fmt.Errorf("%s: quarantine of %s has no expires date", path, e.Test)
+		case seen[e.Test]:
+			return nil, fmt.Errorf("%s: %s is quarantined twice", path, e.Test)
+		}
+		seen[e.Test] = true
+	}
+	return &l, nil
+}
+
+// Lookup returns the entry for the test, or nil if it is not quarantined.
+func (l *List) Lookup(test string) *Entry {
+	if l == nil {
+		return nil
+	}
+	for i := range l.Entries {
+		if l.Entries[i].Test == test {
+			return &l.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Unknown returns the tests named by entries that are not in known, which
+// usually means a test was renamed or removed without cleaning up.
+func (l *List) Unknown(known []string) []string {
+	var out []string
+	for _, e := range l.Entries {
+		if !slices.Contains(known, e.Test) {
+			out = append(out, e.Test)
+		}
+	}
+	return out
+}
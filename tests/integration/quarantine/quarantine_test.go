@@ -0,0 +1,90 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quarantine.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, `
+quarantine:
+  - test: gcloud-tool-call
+    owner: alice
+    reason: flaky project lookup
+    expires: 2026-11-01
+`)
+	l, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := l.Lookup("gcloud-tool-call")
+	if e == nil || e.Owner != "alice" || !e.Expires.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Lookup() = %+v", e)
+	}
+	if l.Lookup("other") != nil {
+		t.Error("Lookup(other) != nil")
+	}
+	if got := l.Unknown([]string{"other"}); !slices.Equal(got, []string{"gcloud-tool-call"}) {
+		t.Errorf("Unknown() = %v", got)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "none.yaml")
+	if l, err := Load(path, true); err != nil || len(l.Entries) != 0 {
+		t.Errorf("Load(optional) = %+v, %v", l, err)
+	}
+	if _, err := Load(path, false); err == nil {
+		t.Error("Load(required) of a missing file succeeded")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no owner":   "quarantine: [{test: a, expires: 2026-11-01}]",
+		"no expires": "quarantine: [{test: a, owner: bob}]",
+		"twice":      "quarantine: [{test: a, owner: bob, expires: 2026-11-01}, {test: a, owner: bob, expires: 2026-11-01}]",
+		"no test":    "quarantine: [{owner: bob, expires: 2026-11-01}]",
+	}
+	for name, content := range tests {
+		if _, err := Load(writeFile(t, content), false); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	e := &Entry{Test: "a", Owner: "bob", Expires: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)}
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		now  time.Time
+		want State
+	}{
+		{time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), Active},
+		{time.Date(2026, 10, 28, 12, 0, 0, 0, time.UTC), Expiring},
+		// The expiry day itself is still covered.
+		{time.Date(2026, 11, 1, 23, 59, 0, 0, time.UTC), Expiring},
+		{time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC), Expired},
+	}
+	for _, tt := range tests {
+		if got := e.State(tt.now, week); got != tt.want {
+			t.Errorf("State(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+	if s := e.String(); !strings.Contains(s, "2026-11-01") || !strings.Contains(s, "bob") {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The example-reconnect test kills the server between two calls in a
+// session, as a crash would, and checks that the session reconnects to a new
+// server for the next call rather than failing it, until it runs out of
+// reconnections.
+
+func testExampleReconnect(t *testContext) error {
+	logger.Println("🚀 Starting example server reconnect test...")
+	session, err := t.openSession(client.ToolCall{ServerCmd: exampleServerCmd(), Reconnect: &client.Reconnect{Max: 1}})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	args := exampleserver.AddArgs{A: 2, B: 40}
+	if _, err := session.CallTool("add", args); err != nil {
+		return fmt.Errorf("error calling add: %w", err)
+	}
+	killed := session.ServerPID()
+	if err := session.KillServer(); err != nil {
+		return fmt.Errorf("error killing the server: %w", err)
+	}
+	result, err := session.CallTool("add", args)
+	if err != nil {
+		return fmt.Errorf("error calling add after the server was killed: %w", err)
+	}
+	if result.Metrics.Reconnected == "" || session.ServerPID() == killed {
+		return report.Fail(report.ReasonAssertion, "assertion failed: the call after the server was killed did not reconnect to a new server")
+	}
+	var sum exampleserver.Sum
+	if err := result.DecodeStructured(&sum); err != nil {
+		return report.Fail(report.ReasonParse, "error decoding add structuredContent: %v\nOutput: %s", err, result.Output)
+	}
+	if err := report.Compare("assertion failed: add returned a wrong sum after reconnecting", exampleserver.Sum{Sum: 42}, sum); err != nil {
+		return err
+	}
+
+	// With its one reconnection spent, the session fails the next call
+	// plainly instead of with whatever the dead connection returns.
+	if err := session.KillServer(); err != nil {
+		return fmt.Errorf("error killing the server again: %w", err)
+	}
+	if _, err := session.CallTool("add", args); !errors.Is(err, client.ErrDisconnected) {
+		return report.Fail(report.ReasonAssertion, "assertion failed: call after the server was killed again returned %v, want a dropped connection", err)
+	}
+	logger.Println("✅ Assertion passed: the session reconnected once the server was killed, and gave up after its last reconnection")
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package redact masks secrets, such as access tokens, signed URL signatures
+// and service account emails, in what the harness writes: its console
+// output, trace files and reports. Tool outputs carry them, and the
+// harness's artifacts are shared more widely than the test project's
+// credentials.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// Built-in rules, which every Redactor applies.
+var (
+	builtinPatterns = []string{
+		// OAuth 2.0 access tokens, as gcloud auth print-access-token prints.
+		`ya29\.[0-9A-Za-z_\-]+`,
+		// JWTs, e.g. ID tokens.
+		`eyJ[0-9A-Za-z_\-]+\.eyJ[0-9A-Za-z_\-]+\.[0-9A-Za-z_\-]+`,
+		`(?i)\bbearer\s+([0-9A-Za-z._~+/\-]+=*)`,
+		// Google API keys.
+		`AIza[0-9A-Za-z_\-]{35}`,
+		// The signatures and credentials of signed URLs.
+		`(?i)[?&](?:x-goog-signature|x-goog-credential|x-amz-signature|signature|sig)=([^&\s"'<>]+)`,
+		// Service account emails keep their domain, so they still read as
+		// service accounts.
+		`([a-z][a-z0-9\-]*@[a-z][a-z0-9\-]*)\.iam\.gserviceaccount\.com`,
+		`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	}
+	builtinFields = []string{
+		"access_token", "refresh_token", "id_token", "token",
+		"client_secret", "secret", "password", "private_key", "private_key_id",
+		"api_key", "apikey",
+	}
+)
+
+// Rules are the redactions a rules file adds to the built-in ones.
+type Rules struct {
+	// Patterns are regular expressions of secrets. A pattern with capture
+	// groups masks only the text of its groups, e.g. a query parameter's
+	// value but not its name.
+	Patterns []string `yaml:"patterns,omitempty"`
+	// Fields are names whose values are masked wherever they appear as a
+	// JSON key, a YAML key or a key=value pair, matched case-insensitively.
+	Fields []string `yaml:"fields,omitempty"`
+}
+
+// Redactor masks the secrets its rules match. A nil *Redactor masks
+// nothing.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	// field matches a field and its value, whose text is one of its groups.
+	field  *regexp.Regexp
+	fields map[string]bool
+}
+
+// New returns a Redactor applying the built-in rules and extra.
+func New(extra Rules) (*Redactor, error) {
+	r := &Redactor{fields: map[string]bool{}}
+	for _, p := range append(builtinPatterns, extra.Patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	var names []string
+	for _, f := range append(builtinFields, extra.Fields...) {
+		if f == "" {
+			return nil, fmt.Errorf("empty redaction field name")
+		}
+		r.fields[strings.ToLower(f)] = true
+		names = append(names, regexp.QuoteMeta(f))
+	}
+	r.field = regexp.MustCompile(`(?i)["']?\b(?:` + strings.Join(names, "|") + `)\b["']?\s*[:=]\s*(?:"([^"]*)"|'([^']*)'|([^\s"',&;}\]]+))`)
+	return r, nil
+}
+
+// Load returns a Redactor applying the built-in rules and those of the
+// rules file at path, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*Redactor, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction rules %s: %w", path, err)
+	}
+	r, err := New(rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// WithSecrets returns a Redactor applying r's rules that also masks each of
+// secrets verbatim, such as a credential the harness read from a file.
+func (r *Redactor) WithSecrets(secrets ...string) *Redactor {
+	if r == nil {
+		// The built-in rules always compile.
+		r, _ = New(Rules{})
+	}
+	out := *r
+	out.patterns = slices.Clone(r.patterns)
+	for _, secret := range secrets {
+		if secret != "" {
+			out.patterns = append(out.patterns, regexp.MustCompile(regexp.QuoteMeta(secret)))
+		}
+	}
+	return &out
+}
+
+// String returns s with its secrets masked.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	// Fields first, so their values are masked whole even where a pattern
+	// would mask part of them.
+	s = mask(s, r.field)
+	for _, re := range r.patterns {
+		s = mask(s, re)
+	}
+	return s
+}
+
+// mask replaces the text of re's groups in each match, or the whole match
+// if re has none.
+func mask(s string, re *regexp.Regexp) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		spans := m[:2]
+		if re.NumSubexp() > 0 {
+			spans = m[2:]
+		}
+		for i := 0; i < len(spans); i += 2 {
+			start, end := spans[i], spans[i+1]
+			if start < last || start == end {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// jsonString matches a JSON string literal.
+var jsonString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// JSON returns the JSON document data with its secrets masked. Each string
+// is masked as text, so JSON embedded in tool output is masked too, and the
+// string values of fields are masked whole; the document stays valid JSON.
+func (r *Redactor) JSON(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+	var out bytes.Buffer
+	last, maskNext := 0, false
+	for _, m := range jsonString.FindAllIndex(data, -1) {
+		start, end := m[0], m[1]
+		between := bytes.TrimSpace(data[last:start])
+		out.Write(data[last:start])
+		last = end
+		var s string
+		if err := json.Unmarshal(data[start:end], &s); err != nil {
+			out.Write(data[start:end])
+			maskNext = false
+			continue
+		}
+		isValue := bytes.Equal(between, []byte(":"))
+		if maskNext && isValue {
+			out.WriteString(`"` + Mask + `"`)
+			maskNext = false
+			continue
+		}
+		isKey := bytes.HasPrefix(bytes.TrimSpace(data[end:]), []byte(":"))
+		maskNext = isKey && r.fields[strings.ToLower(s)]
+		if masked := r.String(s); masked != s {
+			encoded, _ := json.Marshal(masked)
+			out.Write(encoded)
+			continue
+		}
+		out.Write(data[start:end])
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+// Writer returns a writer masking the secrets of each write before passing
+// it to w. Secrets split across writes are not masked, so w should be
+// written whole messages, as logger writes them.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
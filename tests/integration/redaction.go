@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/redact"
+)
+
+// defaultRedactionRules is the rules file -redaction-rules reads by default.
+const defaultRedactionRules = "redaction.yaml"
+
+// redactor masks secrets in the console output, trace files and reports.
+// It applies the built-in rules until -redaction-rules is loaded.
+var redactor = mustRedactor()
+
+func mustRedactor() *redact.Redactor {
+	r, err := redact.New(redact.Rules{})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// redactValue masks the secrets of *v, a value that round-trips through
+// JSON such as a test result, in every string it holds.
+func redactValue[T any](v *T) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not mask secrets in %T: %v\n", *v, err)
+		return
+	}
+	var masked T
+	if err := json.Unmarshal(redactor.JSON(data), &masked); err != nil {
+		logf(slog.LevelWarn, "⚠️  could not mask secrets in %T: %v\n", *v, err)
+		return
+	}
+	*v = masked
+}
@@ -0,0 +1,106 @@
+// Package registry declares the MCP servers under test and what the tests
+// expect of each: how to launch it, how Gemini CLI connects to it, the tools
+// it must list and the environment it needs. The gemini mcp list assertions,
+// the tool-call tests and the tool catalog tests all read the registry, so
+// adding a server is one registration.
+package registry
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Server is a registered MCP server.
+type Server struct {
+	// Name is the server's name in the Gemini CLI settings and in the
+	// servers.yaml manifest.
+	Name string
+	// Command launches the server over stdio. Its first element is the
+	// executable tests require on PATH.
+	Command []string
+	// Transport is how Gemini CLI connects to the server, as `gemini mcp
+	// list` shows it.
+	Transport string
+	// Tools are tools the server must list. It may list others.
+	Tools []string
+	// Env lists environment variables that must be set for the server to
+	// work, e.g. a project ID.
+	Env []string
+}
+
+// Bin returns the server's executable.
+func (s *Server) Bin() string {
+	return s.Command[0]
+}
+
+// MissingEnv returns the variables of s.Env that are unset or empty.
+func (s *Server) MissingEnv() []string {
+	var missing []string
+	for _, name := range s.Env {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// MissingTools returns the tools of s.Tools that are not in listed.
+func (s *Server) MissingTools(listed []string) []string {
+	var missing []string
+	for _, tool := range s.Tools {
+		if !slices.Contains(listed, tool) {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// ServerRegistry is a set of servers with unique names and executables.
+type ServerRegistry struct {
+	servers []*Server
+}
+
+// Register adds s and returns it for the tests to refer to. It panics if s
+// is incomplete or its name or executable is already registered, since the
+// registry is built at init time.
+func (r *ServerRegistry) Register(s Server) *Server {
+	if s.Name == "" || len(s.Command) == 0 || s.Transport == "" {
+		panic(fmt.Sprintf("registry: server %q needs a name, command and transport", s.Name))
+	}
+	for _, other := range r.servers {
+		if other.Name == s.Name || other.Bin() == s.Bin() {
+			panic(fmt.Sprintf("registry: server %s (%s) is already registered", s.Name, strings.Join(s.Command, " ")))
+		}
+	}
+	r.servers = append(r.servers, &s)
+	return &s
+}
+
+// All returns the registered servers sorted by name.
+func (r *ServerRegistry) All() []*Server {
+	all := slices.Clone(r.servers)
+	slices.SortFunc(all, func(a, b *Server) int { return strings.Compare(a.Name, b.Name) })
+	return all
+}
+
+// Lookup returns the server named name, or nil.
+func (r *ServerRegistry) Lookup(name string) *Server {
+	for _, s := range r.servers {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// ByBin returns the server launched by executable bin, or nil.
+func (r *ServerRegistry) ByBin(bin string) *Server {
+	for _, s := range r.servers {
+		if s.Bin() == bin {
+			return s
+		}
+	}
+	return nil
+}
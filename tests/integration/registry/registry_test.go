@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var r ServerRegistry
+	storage := r.Register(Server{Name: "storage", Command: []string{"storage-mcp"}, Transport: "stdio", Tools: []string{"list_objects", "read_object_content"}})
+	r.Register(Server{Name: "gcloud", Command: []string{"gcloud-mcp"}, Transport: "stdio"})
+
+	if got := r.All(); len(got) != 2 || got[0].Name != "gcloud" || got[1] != storage {
+		t.Errorf("All = %v, want gcloud then storage", got)
+	}
+	if r.Lookup("storage") != storage || r.ByBin("storage-mcp") != storage || r.Lookup("bigquery") != nil {
+		t.Error("Lookup or ByBin returned the wrong server")
+	}
+	if got := storage.MissingTools([]string{"list_objects", "write_object"}); !slices.Equal(got, []string{"read_object_content"}) {
+		t.Errorf("MissingTools = %v", got)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	var r ServerRegistry
+	r.Register(Server{Name: "gcloud", Command: []string{"gcloud-mcp"}, Transport: "stdio"})
+	for _, s := range []Server{
+		{Name: "gcloud", Command: []string{"other-mcp"}, Transport: "stdio"},
+		{Name: "other", Command: []string{"gcloud-mcp"}, Transport: "stdio"},
+		{Name: "incomplete"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%+v) did not panic", s)
+				}
+			}()
+			r.Register(s)
+		}()
+	}
+}
+
+func TestMissingEnv(t *testing.T) {
+	t.Setenv("REGISTRY_TEST_SET", "x")
+	t.Setenv("REGISTRY_TEST_EMPTY", "")
+	s := &Server{Env: []string{"REGISTRY_TEST_SET", "REGISTRY_TEST_EMPTY"}}
+	if got := s.MissingEnv(); !slices.Equal(got, []string{"REGISTRY_TEST_EMPTY"}) {
+		t.Errorf("MissingEnv = %v", got)
+	}
+}
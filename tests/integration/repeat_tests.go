@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/oracle"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// checkRepeatable fails with ReasonPrerequisite unless calling the tool name
+// with args again cannot change what it returns: the session's server must
+// annotate the tool read-only or idempotent, or, for run_gcloud_command,
+// whose calls may run any command, args must run a read-only one.
+func checkRepeatable(session *client.Session, name string, args any) error {
+	if name == oracle.ToolName {
+		if !oracle.ReadOnly(oracle.ToolArgs(args)) {
+			return report.Fail(report.ReasonPrerequisite, "%s %q is not a read-only gcloud command, so repeating it proves nothing", name, oracle.ToolArgs(args))
+		}
+		return nil
+	}
+	tool, err := session.Tool(name)
+	if err != nil {
+		return fmt.Errorf("error looking up %s: %w", name, err)
+	}
+	if a := tool.Annotations; a == nil || !a.ReadOnlyHint && !a.IdempotentHint {
+		return report.Fail(report.ReasonPrerequisite, "%s is not annotated read-only or idempotent, so repeating it proves nothing", name)
+	}
+	return nil
+}
+
+// invokeRepeated makes call times in a row in one session and fails with
+// ReasonInconsistent unless every result equals the first once
+// volatileFields and differential.DefaultVolatileFields are ignored. The tool
+// must be read-only or idempotent. It returns the first result.
+func invokeRepeated(t *testContext, call client.ToolCall, times int, volatileFields ...string) (*client.Result, error) {
+	session, err := t.openSession(call)
+	if err != nil {
+		return nil, fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	if err := checkRepeatable(session, call.ToolName, call.ToolArgs); err != nil {
+		return nil, err
+	}
+	var (
+		first   *client.Result
+		outputs []string
+	)
+	for i := range times {
+		result, err := session.CallTool(call.ToolName, call.ToolArgs)
+		if err != nil {
+			return nil, fmt.Errorf("call %d of %d: %w", i+1, times, err)
+		}
+		if first == nil {
+			first = result
+		}
+		outputs = append(outputs, result.Output+result.ErrorMessage)
+	}
+	n := differential.New(slices.Concat(differential.DefaultVolatileFields, volatileFields))
+	if err := n.Consistent(call.ToolName, outputs); err != nil {
+		return nil, err
+	}
+	logger.Printf("🔁 %s returned the same result %d times in a row\n", call.ToolName, times)
+	return first, nil
+}
+
+func testExampleRepeat(t *testContext) error {
+	logger.Println("🚀 Starting example server repeat-call consistency test...")
+	result, err := invokeRepeated(t, client.ToolCall{
+		ServerCmd: exampleServerCmd(),
+		ToolName:  "add",
+		ToolArgs:  exampleserver.AddArgs{A: 2, B: 40},
+	}, 5)
+	if err != nil {
+		return fmt.Errorf("error calling add: %w", err)
+	}
+	text, err := resultText(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: add returned a wrong sum", `{"sum":42}`, text); err != nil {
+		return err
+	}
+	logger.Println("✅ Assertion passed: add returned {\"sum\": 42} on all 5 calls")
+	return nil
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Note is a freeform remark a person attached to a run, such as "ran
+// against the canary backend", either when starting it or afterwards with
+// the annotate command.
+type Note struct {
+	Text   string    `json:"text"`
+	Author string    `json:"author,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func (n Note) String() string {
+	if n.Author == "" {
+		return n.Text
+	}
+	return fmt.Sprintf("%s (%s)", n.Text, n.Author)
+}
+
+// labelKey is the syntax of a label key, which is also valid as a Cloud
+// Monitoring and JUnit property name.
+var labelKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ParseLabel parses a key=value label.
+func ParseLabel(s string) (key, value string, err error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid label %q; want key=value", s)
+	}
+	if !labelKey.MatchString(key) {
+		return "", "", fmt.Errorf("invalid label key %q; want lowercase letters, digits and underscores, starting with a letter", key)
+	}
+	return key, value, nil
+}
+
+// Annotate adds notes and labels to run. A label replaces an earlier value
+// for its key.
+func (r *Run) Annotate(notes []Note, labels map[string]string) {
+	r.Notes = append(r.Notes, notes...)
+	if len(labels) > 0 && r.Labels == nil {
+		r.Labels = map[string]string{}
+	}
+	maps.Copy(r.Labels, labels)
+}
+
+// LabelList returns the labels of run as sorted key=value pairs.
+func (r *Run) LabelList() []string {
+	var list []string
+	for _, k := range slices.Sorted(maps.Keys(r.Labels)) {
+		list = append(list, k+"="+r.Labels[k])
+	}
+	return list
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLabel(t *testing.T) {
+	if k, v, err := ParseLabel("backend=canary=eu"); err != nil || k != "backend" || v != "canary=eu" {
+		t.Errorf("ParseLabel = %q, %q, %v", k, v, err)
+	}
+	for _, bad := range []string{"backend", "Backend=x", "1a=x", "=x"} {
+		if _, _, err := ParseLabel(bad); err == nil {
+			t.Errorf("ParseLabel(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	run := &Run{}
+	run.Annotate([]Note{{Text: "ran against the canary backend", Author: "ana"}}, map[string]string{"backend": "canary", "region": "eu"})
+	run.Annotate([]Note{{Text: "canary was down"}}, map[string]string{"backend": "prod"})
+	if len(run.Notes) != 2 {
+		t.Errorf("notes = %+v, want both", run.Notes)
+	}
+	if got := strings.Join(run.LabelList(), ","); got != "backend=prod,region=eu" {
+		t.Errorf("labels = %s, want the later backend", got)
+	}
+
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  🏷️  backend=prod, region=eu\n",
+		"  📝 ran against the canary backend (ana)\n  📝 canary was down\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := WriteJUnit(path, run); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<property name="label.backend" value="prod"></property>`,
+		`<property name="note" value="ran against the canary backend (ana)"></property>`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JUnit report missing %q:\n%s", want, data)
+		}
+	}
+}
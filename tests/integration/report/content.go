@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"image"
+	"slices"
+	"strings"
+
+	// Decoders of the image formats AssertHasImage recognizes.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// decodable are the image formats AssertHasImage decodes.
+var decodable = []string{"gif", "jpeg", "png"}
+
+// AssertHasImage returns the first image block of result with mimeType, or
+// of any type if mimeType is empty, failing with ReasonAssertion if there
+// is none, it has no data or, for a GIF, JPEG or PNG, its data does not
+// decode as an image of the type it claims.
+func AssertHasImage(result *client.Result, mimeType string) (*mcp.ImageContent, error) {
+	for _, img := range result.Images() {
+		if mimeType != "" && img.MIMEType != mimeType {
+			continue
+		}
+		want := strings.TrimPrefix(img.MIMEType, "image/")
+		if !slices.Contains(decodable, want) {
+			if len(img.Data) == 0 {
+				return nil, Fail(ReasonAssertion, "assertion failed: %s image has no data", img.MIMEType)
+			}
+			return img, nil
+		}
+		_, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+		if err != nil {
+			return nil, Fail(ReasonAssertion, "assertion failed: %s image does not decode: %v", img.MIMEType, err)
+		}
+		if format != want {
+			return nil, Fail(ReasonAssertion, "assertion failed: image claims to be %s but is %s", img.MIMEType, format)
+		}
+		return img, nil
+	}
+	return nil, missing(result, "image", mimeType)
+}
+
+// AssertHasAudio returns the first audio block of result with mimeType, or
+// of any audio type if mimeType is empty, failing with ReasonAssertion if
+// there is none or it has no data.
+func AssertHasAudio(result *client.Result, mimeType string) (*mcp.AudioContent, error) {
+	for _, a := range result.Audio() {
+		if mimeType != "" && a.MIMEType != mimeType {
+			continue
+		}
+		if !strings.HasPrefix(a.MIMEType, "audio/") || len(a.Data) == 0 {
+			return nil, Fail(ReasonAssertion, "assertion failed: audio block of type %q has %d bytes of data", a.MIMEType, len(a.Data))
+		}
+		return a, nil
+	}
+	return nil, missing(result, "audio", mimeType)
+}
+
+// AssertHasEmbeddedResource returns the resource with uri embedded in
+// result, failing with ReasonAssertion if there is none or it is empty.
+func AssertHasEmbeddedResource(result *client.Result, uri string) (*mcp.ResourceContents, error) {
+	for _, r := range result.Embedded() {
+		if r.URI != uri {
+			continue
+		}
+		if r.Text == "" && len(r.Blob) == 0 {
+			return nil, Fail(ReasonAssertion, "assertion failed: embedded resource %s is empty", uri)
+		}
+		return r, nil
+	}
+	return nil, missing(result, "embedded resource", uri)
+}
+
+// AssertHasResourceLink returns the resource_link to uri in result, failing
+// with ReasonAssertion if there is none.
+func AssertHasResourceLink(result *client.Result, uri string) (*mcp.ResourceLink, error) {
+	for _, l := range result.Links() {
+		if l.URI == uri {
+			return l, nil
+		}
+	}
+	return nil, missing(result, "resource_link", uri)
+}
+
+func missing(result *client.Result, kind, detail string) error {
+	if detail != "" {
+		kind += " " + detail
+	}
+	types := strings.Join(result.ContentTypes(), ", ")
+	if types == "" {
+		types = "none"
+	}
+	return Fail(ReasonAssertion, "assertion failed: result has no %s; its content blocks: %s", kind, types)
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+func pngData(t *testing.T) []byte {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestAssertHasImage(t *testing.T) {
+	result := &client.Result{Content: []mcp.Content{
+		&mcp.TextContent{Text: "chart"},
+		&mcp.ImageContent{MIMEType: "image/svg+xml", Data: []byte("<svg/>")},
+		&mcp.ImageContent{MIMEType: "image/png", Data: pngData(t)},
+	}}
+	if img, err := AssertHasImage(result, "image/png"); err != nil || img.MIMEType != "image/png" {
+		t.Errorf("AssertHasImage(png) = %v, %v", img, err)
+	}
+	// Formats it cannot decode only need data.
+	if img, err := AssertHasImage(result, ""); err != nil || img.MIMEType != "image/svg+xml" {
+		t.Errorf("AssertHasImage(any) = %v, %v", img, err)
+	}
+	_, err := AssertHasImage(result, "image/jpeg")
+	if ReasonOf(err) != ReasonAssertion || !strings.Contains(err.Error(), "its content blocks: text, image, image") {
+		t.Errorf("AssertHasImage(jpeg) = %v", err)
+	}
+	mislabeled := &client.Result{Content: []mcp.Content{&mcp.ImageContent{MIMEType: "image/gif", Data: pngData(t)}}}
+	if _, err := AssertHasImage(mislabeled, "image/gif"); err == nil || !strings.Contains(err.Error(), "claims to be image/gif but is png") {
+		t.Errorf("AssertHasImage(mislabeled) = %v", err)
+	}
+	corrupt := &client.Result{Content: []mcp.Content{&mcp.ImageContent{MIMEType: "image/png", Data: []byte("not a png")}}}
+	if _, err := AssertHasImage(corrupt, ""); err == nil {
+		t.Error("AssertHasImage accepted a corrupt PNG")
+	}
+}
+
+func TestAssertHasAudio(t *testing.T) {
+	result := &client.Result{Content: []mcp.Content{&mcp.AudioContent{MIMEType: "audio/wav", Data: []byte("RIFF")}}}
+	if _, err := AssertHasAudio(result, "audio/wav"); err != nil {
+		t.Errorf("AssertHasAudio = %v", err)
+	}
+	if _, err := AssertHasAudio(result, "audio/mpeg"); err == nil {
+		t.Error("AssertHasAudio found an mpeg block")
+	}
+	empty := &client.Result{Content: []mcp.Content{&mcp.AudioContent{MIMEType: "audio/wav"}}}
+	if _, err := AssertHasAudio(empty, ""); err == nil {
+		t.Error("AssertHasAudio accepted an empty block")
+	}
+}
+
+func TestAssertHasResources(t *testing.T) {
+	result := &client.Result{Content: []mcp.Content{
+		&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "example://data.csv", Text: "a,b\n"}},
+		&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "example://empty"}},
+		&mcp.ResourceLink{URI: "gs://bucket/object"},
+	}}
+	if r, err := AssertHasEmbeddedResource(result, "example://data.csv"); err != nil || r.Text != "a,b\n" {
+		t.Errorf("AssertHasEmbeddedResource = %v, %v", r, err)
+	}
+	if _, err := AssertHasEmbeddedResource(result, "example://empty"); err == nil {
+		t.Error("AssertHasEmbeddedResource accepted an empty resource")
+	}
+	if _, err := AssertHasResourceLink(result, "gs://bucket/object"); err != nil {
+		t.Errorf("AssertHasResourceLink = %v", err)
+	}
+	_, err := AssertHasResourceLink(&client.Result{}, "gs://bucket/other")
+	if err == nil || !strings.Contains(err.Error(), "no resource_link gs://bucket/other; its content blocks: none") {
+		t.Errorf("AssertHasResourceLink(empty) = %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxDiffCells bounds the size of the LCS table; larger inputs are reported
+// as a single replaced hunk.
+const maxDiffCells = 4_000_000
+
+// Mismatch is an assertion failure that carries what was expected and what
+// was observed, so reports can render a diff instead of the raw output.
+type Mismatch struct {
+	Message  string `json:"message"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	// Diff is a unified diff from Expected to Actual. It is empty when the
+	// values are not comparable line by line, such as a pattern and a text.
+	Diff string `json:"diff,omitempty"`
+}
+
+func (m *Mismatch) Error() string { return m.Message }
+
+// Compare returns nil if expected and actual encode to the same JSON, and
+// otherwise a Failure with ReasonAssertion wrapping a Mismatch with their
+// indented JSON and its unified diff. Strings are compared as-is.
+func Compare(message string, expected, actual any) error {
+	want, err := display(expected)
+	if err != nil {
+		return fmt.Errorf("encoding expected value: %w", err)
+	}
+	got, err := display(actual)
+	if err != nil {
+		return fmt.Errorf("encoding actual value: %w", err)
+	}
+	if want == got {
+		return nil
+	}
+	return &Failure{Reason: ReasonAssertion, Err: &Mismatch{
+		Message:  message,
+		Expected: want,
+		Actual:   got,
+		Diff:     UnifiedDiff("expected", "actual", want, got),
+	}}
+}
+
+// MismatchOf returns the first Mismatch in err's chain, or nil.
+func MismatchOf(err error) *Mismatch {
+	var m *Mismatch
+	if errors.As(err, &m) {
+		return m
+	}
+	return nil
+}
+
+func display(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.RawMessage:
+		return indentJSON(v)
+	case []byte:
+		return indentJSON(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return indentJSON(data)
+}
+
+// indentJSON re-encodes data with sorted keys and two-space indentation.
+func indentJSON(data []byte) (string, error) {
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(generic, "", "  ")
+	return string(out), err
+}
+
+// UnifiedDiff returns a unified diff of a and b with three lines of context,
+// or "" if they are equal.
+func UnifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	if !slices.ContainsFunc(ops, func(op diffOp) bool { return op.kind != ' ' }) {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	const context = 3
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Grow the hunk until there are more than 2*context equal lines
+		// between changes.
+		start := max(0, i-context)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		writeHunk(&out, ops[start:end])
+		i = end
+	}
+	return out.String()
+}
+
+type diffOp struct {
+	kind       byte // ' ', '-' or '+'
+	line       string
+	aLine, bLn int // 1-based line numbers in a and b before this op
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp) {
+	var aCount, bCount int
+	for _, op := range ops {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", ops[0].aLine, aCount, ops[0].bLn, bCount)
+	for _, op := range ops {
+		fmt.Fprintf(out, "%c%s\n", op.kind, op.line)
+	}
+}
+
+// diffLines computes a line diff via the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for i, l := range a {
+			ops = append(ops, diffOp{kind: '-', line: l, aLine: i + 1, bLn: 1})
+		}
+		for j, l := range b {
+			ops = append(ops, diffOp{kind: '+', line: l, aLine: len(a) + 1, bLn: j + 1})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i], aLine: i + 1, bLn: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: a[i], aLine: i + 1, bLn: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j], aLine: i + 1, bLn: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nx\nh\ni\nj\n"
+	b := "a\nb\nc\nD\ne\nf\ng\nx\nh\ni\nj\nk\n"
+	want := `--- expected
++++ actual
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -9,3 +9,4 @@
+ h
+ i
+ j
++k
+`
+	if got := UnifiedDiff("expected", "actual", a, b); got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := UnifiedDiff("x", "y", a, a); got != "" {
+		t.Errorf("equal inputs produced diff:\n%s", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if err := Compare("same", map[string]any{"a": 1}, []byte(`{"a":1}`)); err != nil {
+		t.Errorf("Compare() of equal values = %v", err)
+	}
+
+	err := Compare("project mismatch", map[string]any{"core": map[string]any{"project": "want"}}, []byte(`{"core":{"project":"got"}}`))
+	if ReasonOf(err) != ReasonAssertion {
+		t.Errorf("ReasonOf() = %q", ReasonOf(err))
+	}
+	m := MismatchOf(err)
+	if m == nil {
+		t.Fatalf("Compare() = %v, want a Mismatch", err)
+	}
+	if err.Error() != "project mismatch" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !strings.Contains(m.Diff, `-    "project": "want"`) || !strings.Contains(m.Diff, `+    "project": "got"`) {
+		t.Errorf("unexpected diff:\n%s", m.Diff)
+	}
+	if MismatchOf(errors.New("plain")) != nil {
+		t.Error("MismatchOf found a mismatch in a plain error")
+	}
+}
@@ -0,0 +1,228 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxEmbeddedTrace bounds the bytes of each wire trace embedded in the HTML
+// report; the whole trace stays linked.
+const maxEmbeddedTrace = 256 << 10
+
+// htmlTemplate renders a self-contained page: styles are inline and details
+// are expanded with <details>, so the file works as a downloaded artifact.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"round": round,
+	"time":  func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>MCP integration run {{time .Run.Started}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 0.8em; text-align: left; vertical-align: top; }
+tr.test { border-top: 1px solid #ddd; }
+pre { background: #f6f6f6; padding: 0.6em; overflow-x: auto; max-height: 40em; }
+summary { cursor: pointer; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .flaky, .quarantined { color: #9a6700; } .skipped { color: #666; }
+.meta td:first-child { color: #666; }
+</style>
+</head>
+<body>
+<h1>MCP integration run</h1>
+<p>
+<span class="passed">{{.Passed}} passed</span>,
+<span class="failed">{{.Failed}} failed</span>,
+<span class="skipped">{{.Skipped}} skipped</span>{{if .Quarantined}},
+<span class="quarantined">{{.Quarantined}} quarantined</span>{{end}}{{if .Flaky}},
+<span class="flaky">{{.Flaky}} flaky</span>{{end}}
+in {{round .Run.Duration}}
+</p>
+{{with .Run.Interrupted}}<p class="failed">Interrupted by {{.}}; the results are partial.</p>{{end}}
+<table class="meta">
+<tr><td>Started</td><td>{{time .Run.Started}}</td></tr>
+<tr><td>Seed</td><td>{{.Run.Seed}}</td></tr>
+{{with .Run.RunID}}<tr><td>Run ID</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.Shard}}<tr><td>Shard</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.Platform}}<tr><td>Platform</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.Harness}}<tr><td>Harness</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.Commit}}<tr><td>Commit</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.TraceID}}<tr><td>Trace</td><td>{{.}}</td></tr>{{end}}
+{{with .Run.LabelList}}<tr><td>Labels</td><td>{{range .}}{{.}}<br>{{end}}</td></tr>{{end}}
+{{with .Run.Notes}}<tr><td>Notes</td><td>{{range .}}{{.}}<br>{{end}}</td></tr>{{end}}
+</table>
+
+<h2>Tests</h2>
+<table>
+<tr><th>Test</th><th>Status</th><th>Duration</th><th>Reason</th></tr>
+{{range .Tests}}<tr class="test">
+<td><a href="#{{.ID}}">{{.ID}}</a></td><td class="{{.Status}}">{{.Status}}</td><td>{{round .Duration}}</td><td>{{.Reason}}</td>
+</tr>
+{{end}}</table>
+
+{{range $t := .Tests}}
+<h3 id="{{.ID}}" class="{{.Status}}">{{.ID}}: {{.Status}}{{with .Reason}} [{{.}}]{{end}}</h3>
+{{with .Error}}<pre>{{.}}</pre>{{end}}
+{{with .Mismatch}}{{with .Diff}}<details open><summary>Diff</summary><pre>{{.}}</pre></details>{{end}}{{end}}
+{{range .FailedAttempts}}<details><summary>Failed attempt [{{.Reason}}] after {{round .Duration}}</summary><pre>{{.Error}}</pre></details>{{end}}
+{{with .Timeline}}<details{{if $t.Open}} open{{end}}><summary>Timeline</summary><table>
+{{range .}}<tr><td>+{{.Offset}}</td><td>{{.Server}}</td><td>{{.Text}}</td></tr>
+{{end}}</table></details>{{end}}
+{{range .Stderr}}<details><summary>{{.Server}} stderr</summary><pre>{{.Text}}</pre></details>{{end}}
+{{with .Trace}}<details><summary>Wire trace <a href="{{.Href}}">{{.Href}}</a>{{if .Truncated}} (first {{.Shown}} bytes){{end}}</summary><pre>{{.Text}}</pre></details>{{end}}
+{{with .Artifacts}}<p>Artifacts: {{range $i, $a := .}}{{if $i}}, {{end}}<a href="{{$a.Href}}">{{$a.Name}}</a>{{end}}</p>{{end}}
+{{with .Repro}}<p>Rerun: <code>{{.}}</code>{{with $t.ReproScript}}, or replay its calls with <code>{{.}}</code>{{end}}</p>{{end}}
+{{with .Log}}<details><summary>Log</summary><pre>{{.}}</pre></details>{{end}}
+{{end}}
+
+{{range .Tables}}<h2>{{.Title}}</h2>
+<pre>{{.Text}}</pre>
+{{end}}
+</body>
+</html>
+`))
+
+type htmlReport struct {
+	Run                                         *Run
+	Passed, Failed, Skipped, Quarantined, Flaky int
+	Tests                                       []htmlTest
+	Tables                                      []htmlTable
+}
+
+type htmlTest struct {
+	TestResult
+	Timeline  []htmlEvent
+	Trace     *htmlTrace
+	Artifacts []htmlArtifact
+}
+
+// Open reports whether the test's timeline starts expanded, as it does for
+// tests that did not pass.
+func (t htmlTest) Open() bool {
+	return t.Status != StatusPassed && t.Status != StatusSkipped
+}
+
+type htmlEvent struct {
+	Offset time.Duration
+	Server string
+	Text   string
+}
+
+type htmlTrace struct {
+	Href      string
+	Text      string
+	Truncated bool
+	Shown     int
+}
+
+type htmlArtifact struct {
+	Name, Href string
+}
+
+type htmlTable struct {
+	Title, Text string
+}
+
+// WriteHTML writes run to path as a single HTML page: the summary with the
+// run's labels and notes, every test with its error, diff, timeline, its
+// servers' stderr, links to its artifacts and its log, and the startup,
+// resource and latency tables.
+// Wire traces are linked relative to path and their start is embedded, so
+// the page alone is enough to triage most failures.
+func WriteHTML(path string, run *Run) error {
+	passed, failed, skipped := run.Counts()
+	data := htmlReport{
+		Run:         run,
+		Passed:      passed,
+		Failed:      failed,
+		Skipped:     skipped,
+		Quarantined: len(run.Quarantined()),
+		Flaky:       len(run.Flaky()),
+	}
+	for _, t := range run.Tests {
+		ht := htmlTest{TestResult: t}
+		for _, n := range t.Timeline {
+			ht.Timeline = append(ht.Timeline, htmlEvent{Offset: round(n.At.Sub(t.Started)), Server: n.Server, Text: n.String()})
+		}
+		for _, a := range t.Artifacts {
+			ht.Artifacts = append(ht.Artifacts, htmlArtifact{Name: filepath.Base(a), Href: relativeHref(filepath.Dir(path), a)})
+		}
+		if t.WireTrace != "" {
+			trace, err := embedTrace(filepath.Dir(path), t.WireTrace)
+			if err != nil {
+				return err
+			}
+			ht.Trace = trace
+		}
+		data.Tests = append(data.Tests, ht)
+	}
+	for _, table := range []struct {
+		title string
+		empty bool
+		write func(io.Writer) error
+	}{
+		{"Server startup", len(run.Startup) == 0, func(w io.Writer) error { return WriteStartupTable(w, run.Startup) }},
+		{"Server resources", len(run.Resources) == 0, func(w io.Writer) error { return WriteResourceTable(w, run.Resources) }},
+		{"Tool call latency", len(run.Latency) == 0, func(w io.Writer) error { return WriteLatencyTable(w, run.Latency) }},
+	} {
+		if table.empty {
+			continue
+		}
+		var b strings.Builder
+		if err := table.write(&b); err != nil {
+			return err
+		}
+		data.Tables = append(data.Tables, htmlTable{Title: table.title, Text: b.String()})
+	}
+	var out bytes.Buffer
+	if err := htmlTemplate.Execute(&out, data); err != nil {
+		return fmt.Errorf("rendering HTML report: %w", err)
+	}
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// relativeHref returns the link to path from a page in dir.
+func relativeHref(dir, path string) string {
+	href := path
+	if abs, err := filepath.Abs(path); err == nil {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			if rel, err := filepath.Rel(absDir, abs); err == nil {
+				href = rel
+			}
+		}
+	}
+	return filepath.ToSlash(href)
+}
+
+// embedTrace reads up to maxEmbeddedTrace bytes of the wire trace at path and
+// links it relative to dir, where the report is written. A trace that was
+// since removed is still linked.
+func embedTrace(dir, path string) (*htmlTrace, error) {
+	trace := &htmlTrace{Href: relativeHref(dir, path)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		trace.Text = "(the trace file no longer exists)"
+		return trace, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxEmbeddedTrace+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading wire trace %s: %w", path, err)
+	}
+	if len(data) > maxEmbeddedTrace {
+		data, trace.Truncated, trace.Shown = data[:maxEmbeddedTrace], true, maxEmbeddedTrace
+	}
+	trace.Text = string(data)
+	return trace, nil
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	tracePath := filepath.Join(dir, "traces", "bad.jsonl")
+	os.MkdirAll(filepath.Dir(tracePath), 0o755)
+	os.WriteFile(tracePath, []byte(`{"dir":"send","msg":{"method":"tools/call"}}`+"\n"), 0o644)
+	start := time.Unix(100, 0)
+	run := &Run{
+		Started:  start,
+		Duration: 2 * time.Second,
+		Seed:     7,
+		Labels:   map[string]string{"backend": "canary"},
+		Notes:    []Note{{Text: "nightly <rerun>", Author: "oncall"}},
+		Latency:  []ToolLatency{{Server: "gcloud-mcp", Tool: "run_gcloud_command", Calls: 1}},
+		Tests: []TestResult{
+			{ID: "ok", Status: StatusPassed, Duration: time.Second},
+			{
+				ID:        "bad",
+				Started:   start,
+				Status:    StatusFailed,
+				Reason:    ReasonAssertion,
+				Error:     "project mismatch",
+				Mismatch:  &Mismatch{Diff: "--- expected\n+++ actual\n-a\n+b\n"},
+				Timeline:  []client.Notification{{At: start.Add(1500 * time.Millisecond), Server: "gcloud-mcp", Kind: client.NotificationStep, Progress: 50, Message: "listing"}},
+				Stderr:    []ServerStderr{{Server: "gcloud-mcp", Text: "Error: quota exceeded"}},
+				WireTrace: tracePath,
+				Artifacts: []string{filepath.Join(dir, "bad", "tool-outputs.jsonl")},
+				Repro:     "integration-test -only bad -fast",
+			},
+		},
+	}
+	path := filepath.Join(dir, "report.html")
+	if err := WriteHTML(path, run); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		`<span class="passed">1 passed</span>`,
+		`<span class="failed">1 failed</span>`,
+		`backend=canary`,
+		`nightly &lt;rerun&gt; (oncall)`,
+		`<h3 id="bad" class="failed">bad: failed [assertion_failed]</h3>`,
+		"<pre>--- expected\n&#43;&#43;&#43; actual\n-a\n&#43;b\n</pre>",
+		`<details open><summary>Timeline</summary>`,
+		`<td>+1.5s</td><td>gcloud-mcp</td><td>step 50%: listing</td>`,
+		`<summary>gcloud-mcp stderr</summary><pre>Error: quota exceeded</pre>`,
+		`<a href="traces/bad.jsonl">traces/bad.jsonl</a>`,
+		`{&#34;dir&#34;:&#34;send&#34;`,
+		`<p>Artifacts: <a href="bad/tool-outputs.jsonl">tool-outputs.jsonl</a></p>`,
+		`<h2>Tool call latency</h2>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if strings.Contains(got, "Server startup") {
+		t.Error("report has an empty startup table")
+	}
+}
+
+func TestEmbedTraceTruncates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.jsonl")
+	os.WriteFile(path, []byte(strings.Repeat("x", maxEmbeddedTrace+10)), 0o644)
+	trace, err := embedTrace(dir, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !trace.Truncated || len(trace.Text) != maxEmbeddedTrace || trace.Href != "big.jsonl" {
+		t.Errorf("trace = {Href: %s, Truncated: %v, %d bytes}", trace.Href, trace.Truncated, len(trace.Text))
+	}
+	if trace, err := embedTrace(dir, filepath.Join(dir, "gone.jsonl")); err != nil || !strings.Contains(trace.Text, "no longer exists") {
+		t.Errorf("embedTrace(missing) = %+v, %v", trace, err)
+	}
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string `xml:"name,attr"`
+	Tests     int    `xml:"tests,attr"`
+	Failures  int    `xml:"failures,attr"`
+	Skipped   int    `xml:"skipped,attr"`
+	Time      string `xml:"time,attr"`
+	Timestamp string `xml:"timestamp,attr"`
+	// Properties carry the run's labels and notes, which CI dashboards show
+	// with the suite.
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	// FlakyFailures and RerunFailures follow the Maven Surefire convention
+	// for the failed attempts of a retried test that eventually passed or
+	// never did.
+	FlakyFailures []junitFailure `xml:"flakyFailure,omitempty"`
+	RerunFailures []junitFailure `xml:"rerunFailure,omitempty"`
+	SystemOut     string         `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes run to path as a JUnit XML report. A failure's body holds
+// the full error followed by the assertion diff, if any. Flaky tests are
+// reported as passed.
+func WriteJUnit(path string, run *Run) error {
+	_, failed, skipped := run.Counts()
+	suite := junitTestSuite{
+		Name:      "mcp-integration",
+		Tests:     len(run.Tests),
+		Failures:  failed,
+		Skipped:   skipped + len(run.Quarantined()),
+		Time:      seconds(run.Duration.Seconds()),
+		Timestamp: run.Started.UTC().Format("2006-01-02T15:04:05"),
+	}
+	for _, l := range run.LabelList() {
+		name, value, _ := strings.Cut(l, "=")
+		suite.Properties = append(suite.Properties, junitProperty{Name: "label." + name, Value: value})
+	}
+	for _, n := range run.Notes {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "note", Value: n.String()})
+	}
+	for _, t := range run.Tests {
+		c := junitTestCase{
+			Name:      t.ID,
+			ClassName: "integration",
+			Time:      seconds(t.Duration.Seconds()),
+			SystemOut: t.Log + attachments(t.Artifacts),
+		}
+		var attempts []junitFailure
+		for _, a := range t.FailedAttempts {
+			attempts = append(attempts, junitFailure{Message: firstLine(a.Error), Type: a.Reason, Body: a.Error})
+		}
+		switch t.Status {
+		case StatusFlaky:
+			c.FlakyFailures = attempts
+		case StatusFailed:
+			c.RerunFailures = attempts
+			var body strings.Builder
+			body.WriteString(t.Error)
+			if t.Mismatch != nil && t.Mismatch.Diff != "" {
+				body.WriteString("\n\n")
+				body.WriteString(t.Mismatch.Diff)
+			}
+			if t.Repro != "" {
+				fmt.Fprintf(&body, "\nRepro: %s\n", t.Repro)
+			}
+			c.Failure = &junitFailure{Message: firstLine(t.Error), Type: t.Reason, Body: body.String()}
+		case StatusSkipped:
+			c.Skipped = &junitSkipped{Message: t.Error}
+		case StatusQuarantined:
+			c.Skipped = &junitSkipped{Message: fmt.Sprintf("quarantined %s: %s", t.Quarantine, firstLine(t.Error))}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0o644)
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// attachments returns the [[ATTACHMENT|path]] lines CI systems such as
+// Jenkins and GitLab read from a test case's system-out to attach its files.
+func attachments(paths []string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		fmt.Fprintf(&b, "\n[[ATTACHMENT|%s]]", p)
+	}
+	return b.String()
+}
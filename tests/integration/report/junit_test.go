@@ -0,0 +1,74 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	run := &Run{
+		Started:  time.Unix(0, 0),
+		Duration: 1500 * time.Millisecond,
+		Tests: []TestResult{
+			{ID: "ok", Status: StatusPassed, Duration: time.Second},
+			{ID: "skip", Status: StatusSkipped, Error: "skipped: no snapshot"},
+			{
+				ID:        "bad",
+				Status:    StatusFailed,
+				Reason:    ReasonAssertion,
+				Error:     "project mismatch",
+				Mismatch:  &Mismatch{Diff: "--- expected\n+++ actual\n-a\n+b\n"},
+				Log:       "❌ project mismatch\n",
+				Artifacts: []string{"/artifacts/bad/stderr-gcloud-mcp.log"},
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := WriteJUnit(path, run); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		`<testsuite name="mcp-integration" tests="3" failures="1" skipped="1" time="1.500"`,
+		`<failure message="project mismatch" type="assertion_failed">project mismatch&#xA;&#xA;--- expected&#xA;+++ actual&#xA;-a&#xA;+b&#xA;</failure>`,
+		`<skipped message="skipped: no snapshot"></skipped>`,
+		"<system-out>❌ project mismatch&#xA;&#xA;[[ATTACHMENT|/artifacts/bad/stderr-gcloud-mcp.log]]</system-out>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteJUnitRetries(t *testing.T) {
+	failed := []Attempt{{Reason: ReasonConnect, Error: "connection reset"}}
+	run := &Run{Tests: []TestResult{
+		{ID: "flaky", Status: StatusFlaky, Reason: ReasonConnect, Error: "connection reset", FailedAttempts: failed},
+		{ID: "broken", Status: StatusFailed, Reason: ReasonConnect, Error: "connection reset", FailedAttempts: failed},
+	}}
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := WriteJUnit(path, run); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		`tests="2" failures="1" skipped="0"`,
+		`<testcase name="flaky" classname="integration" time="0.000">` + "\n" + `      <flakyFailure message="connection reset" type="connect_failed">connection reset</flakyFailure>`,
+		`<rerunFailure message="connection reset" type="connect_failed">connection reset</rerunFailure>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %s:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package report renders the end-of-run summary of the integration suite.
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// ToolLatency aggregates the timings of every call to one tool on one server.
+type ToolLatency struct {
+	Server string        `json:"server"`
+	Tool   string        `json:"tool"`
+	Calls  int           `json:"calls"`
+	Min    time.Duration `json:"min_ns"`
+	Avg    time.Duration `json:"avg_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	// Connect and FirstResponse are averages over all calls.
+	Connect       time.Duration `json:"connect_ns"`
+	FirstResponse time.Duration `json:"first_response_ns"`
+	// MaxTokens is the largest result of any call, in estimated tokens.
+	MaxTokens int `json:"max_output_tokens,omitempty"`
+}
+
+// SummarizeLatency groups calls by server and tool and computes min/avg/p95
+// of their total durations. Rows are sorted by server, then tool.
+func SummarizeLatency(calls []client.Metrics) []ToolLatency {
+	type key struct{ server, tool string }
+	groups := map[key][]client.Metrics{}
+	for _, c := range calls {
+		k := key{c.Server, c.Tool}
+		groups[k] = append(groups[k], c)
+	}
+
+	rows := make([]ToolLatency, 0, len(groups))
+	for k, group := range groups {
+		totals := make([]time.Duration, len(group))
+		var (
+			sum, connect, first time.Duration
+			maxTokens           int
+		)
+		for i, c := range group {
+			totals[i] = c.Total
+			maxTokens = max(maxTokens, c.Output.Tokens)
+			sum += c.Total
+			connect += c.Connect
+			first += c.FirstResponse
+		}
+		slices.Sort(totals)
+		n := time.Duration(len(group))
+		rows = append(rows, ToolLatency{
+			Server:        k.server,
+			Tool:          k.tool,
+			Calls:         len(group),
+			Min:           totals[0],
+			Avg:           sum / n,
+			P95:           percentile(totals, 95),
+			Connect:       connect / n,
+			FirstResponse: first / n,
+			MaxTokens:     maxTokens,
+		})
+	}
+	sortLatency(rows)
+	return rows
+}
+
+// sortLatency sorts rows by server, then tool.
+func sortLatency(rows []ToolLatency) {
+	slices.SortFunc(rows, func(a, b ToolLatency) int {
+		if a.Server != b.Server {
+			if a.Server < b.Server {
+				return -1
+			}
+			return 1
+		}
+		if a.Tool < b.Tool {
+			return -1
+		}
+		if a.Tool > b.Tool {
+			return 1
+		}
+		return 0
+	})
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteLatencyTable prints one row per server/tool with its timing summary
+// and largest result.
+func WriteLatencyTable(w io.Writer, rows []ToolLatency) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tTOOL\tCALLS\tMIN\tAVG\tP95\tAVG CONNECT\tAVG FIRST RESPONSE\tMAX TOKENS")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			r.Server, r.Tool, r.Calls, round(r.Min), round(r.Avg), round(r.P95), round(r.Connect), round(r.FirstResponse), r.MaxTokens)
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
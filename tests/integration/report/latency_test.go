@@ -0,0 +1,62 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/tokens"
+)
+
+func TestSummarizeLatency(t *testing.T) {
+	var calls []client.Metrics
+	for i := 1; i <= 20; i++ {
+		calls = append(calls, client.Metrics{
+			Server:  "gcloud-mcp",
+			Tool:    "run_gcloud_command",
+			Connect: time.Second,
+			Total:   time.Duration(i) * time.Second,
+			Output:  tokens.Size{Tokens: i * 100},
+		})
+	}
+	calls = append(calls, client.Metrics{Server: "a-mcp", Tool: "list", Total: time.Second})
+
+	rows := SummarizeLatency(calls)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Server != "a-mcp" {
+		t.Errorf("rows not sorted by server: %+v", rows)
+	}
+	got := rows[1]
+	if got.Calls != 20 || got.Min != time.Second || got.P95 != 19*time.Second {
+		t.Errorf("unexpected summary: %+v", got)
+	}
+	if want := 10500 * time.Millisecond; got.Avg != want {
+		t.Errorf("Avg = %s, want %s", got.Avg, want)
+	}
+	if got.Connect != time.Second {
+		t.Errorf("Connect = %s, want 1s", got.Connect)
+	}
+	if got.MaxTokens != 2000 {
+		t.Errorf("MaxTokens = %d, want 2000", got.MaxTokens)
+	}
+}
+
+func TestPercentileSingleValue(t *testing.T) {
+	if got := percentile([]time.Duration{time.Second}, 95); got != time.Second {
+		t.Errorf("percentile = %s, want 1s", got)
+	}
+}
+
+func TestWriteLatencyTable(t *testing.T) {
+	var b strings.Builder
+	rows := []ToolLatency{{Server: "gcloud-mcp", Tool: "run_gcloud_command", Calls: 1, Min: 1234567 * time.Microsecond}}
+	if err := WriteLatencyTable(&b, rows); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "run_gcloud_command") || !strings.Contains(b.String(), "1.235s") {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+}
@@ -0,0 +1,230 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/coverage"
+)
+
+// DefaultTokenBudget is the token budget used when none is given.
+const DefaultTokenBudget = 2000
+
+const (
+	maxErrorChars   = 300
+	maxExcerptLines = 5
+	maxExcerptChars = 200
+	maxDiffLines    = 20
+)
+
+// keyLinePattern selects log lines that are likely to explain a failure.
+var keyLinePattern = regexp.MustCompile(`(?i)error|fail|denied|exception|panic|stderr|timeout|❌`)
+
+// ApproxTokens estimates the token count of s at four bytes per token.
+func ApproxTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// WriteLLMSummary writes a compact, line-oriented digest of run meant to be
+// pasted into an agent's context. Failures come first with their reason,
+// error and repro command; assertion diffs, log excerpts and the list of
+// passing tests are only added while the estimated size stays within budget
+// tokens.
+func WriteLLMSummary(w io.Writer, run *Run, budget int) error {
+	if budget <= 0 {
+		budget = DefaultTokenBudget
+	}
+	var b summaryBuilder
+	b.budget = budget
+
+	passed, failed, skipped := run.Counts()
+	quarantined := run.Quarantined()
+	b.add(fmt.Sprintf("RUN total=%d passed=%d failed=%d skipped=%d quarantined=%d duration=%s\n", len(run.Tests), passed, failed, skipped, len(quarantined), round(run.Duration)))
+
+	if labels := run.LabelList(); len(labels) > 0 {
+		b.add(fmt.Sprintf("LABELS %s\n", strings.Join(labels, ",")))
+	}
+	for _, n := range run.Notes {
+		b.add(fmt.Sprintf("NOTE %s\n", truncate(oneLine(n.Text), maxErrorChars)))
+	}
+	if len(run.Features) > 0 {
+		names := make([]string, len(run.Features))
+		for i, f := range run.Features {
+			names[i] = f.Name
+		}
+		b.add(fmt.Sprintf("FEATURES %s\n", strings.Join(names, ",")))
+	}
+	if flaky := run.Flaky(); len(flaky) > 0 {
+		entries := make([]string, len(flaky))
+		for i, t := range flaky {
+			entries[i] = fmt.Sprintf("%s(%s)", t.ID, t.Reason)
+		}
+		b.add(fmt.Sprintf("FLAKY %s\n", strings.Join(entries, ",")))
+	}
+	for _, d := range run.Degraded {
+		b.add(fmt.Sprintf("DEGRADED %s: %s\n", d.Sink, firstLine(d.Error)))
+	}
+	if len(run.Orphans) > 0 {
+		names := make([]string, len(run.Orphans))
+		for i, o := range run.Orphans {
+			names[i] = o.Name
+		}
+		b.add(fmt.Sprintf("ORPHANS %s\n", strings.Join(names, ",")))
+	}
+	for _, d := range run.Drift {
+		changes := make([]string, len(d.Changes))
+		for i, c := range d.Changes {
+			changes[i] = c.String()
+		}
+		b.add(fmt.Sprintf("DRIFT %s: %s\n", d.Server, strings.Join(changes, ", ")))
+	}
+	if run.Upgrade != nil {
+		b.add(fmt.Sprintf("OUTDATED harness=%s latest=%s\n", run.Harness, run.Upgrade.Latest))
+	}
+	for _, c := range coverage.Unmet(run.Coverage) {
+		b.add(fmt.Sprintf("COVERAGE %s\n", c))
+	}
+
+	failures := run.Failures()
+	if len(failures) > 0 {
+		b.add("FAILURES\n")
+	}
+	// Essentials for every failure go in before any excerpt, so a long log
+	// never crowds out a later failure.
+	essentials := make([]string, len(failures))
+	for i, f := range failures {
+		var e strings.Builder
+		fmt.Fprintf(&e, "- id: %s\n  reason: %s\n  error: %s\n", f.ID, f.Reason, truncate(oneLine(f.Error), maxErrorChars))
+		if f.Repro != "" {
+			fmt.Fprintf(&e, "  repro: %s\n", f.Repro)
+		}
+		if f.ReproScript != "" {
+			fmt.Fprintf(&e, "  repro_script: %s\n", f.ReproScript)
+		}
+		essentials[i] = e.String()
+	}
+	omitted := 0
+	for i, e := range essentials {
+		if !b.add(e) {
+			omitted = len(failures) - i
+			break
+		}
+	}
+	if omitted > 0 {
+		b.force(fmt.Sprintf("OMITTED %d more failures (token budget %d)\n", omitted, budget))
+	}
+
+	if omitted == 0 {
+		for _, f := range failures {
+			if f.Mismatch == nil || f.Mismatch.Diff == "" {
+				continue
+			}
+			lines := strings.Split(strings.TrimSuffix(f.Mismatch.Diff, "\n"), "\n")
+			if len(lines) > maxDiffLines {
+				lines = append(lines[:maxDiffLines], "…")
+			}
+			var e strings.Builder
+			fmt.Fprintf(&e, "DIFF %s\n", f.ID)
+			for _, line := range lines {
+				fmt.Fprintf(&e, "  %s\n", truncate(line, maxExcerptChars))
+			}
+			b.add(e.String())
+		}
+		for _, f := range failures {
+			excerpt := keyLines(f.Log)
+			if len(excerpt) == 0 {
+				continue
+			}
+			var e strings.Builder
+			fmt.Fprintf(&e, "LOG %s\n", f.ID)
+			for _, line := range excerpt {
+				fmt.Fprintf(&e, "  | %s\n", line)
+			}
+			b.add(e.String())
+		}
+		if passed > 0 {
+			var ids []string
+			for _, t := range run.Tests {
+				if t.Status == StatusPassed {
+					ids = append(ids, t.ID)
+				}
+			}
+			b.add("PASSED " + strings.Join(ids, ",") + "\n")
+		}
+		if len(quarantined) > 0 {
+			ids := make([]string, len(quarantined))
+			for i, t := range quarantined {
+				ids[i] = t.ID
+			}
+			b.add("QUARANTINED " + strings.Join(ids, ",") + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// summaryBuilder accumulates sections while they fit in the token budget.
+type summaryBuilder struct {
+	strings.Builder
+	budget int
+}
+
+// add appends s if it fits in the remaining budget and reports whether it did.
+func (b *summaryBuilder) add(s string) bool {
+	if ApproxTokens(b.String()+s) > b.budget {
+		return false
+	}
+	b.WriteString(s)
+	return true
+}
+
+// force appends s regardless of the budget.
+func (b *summaryBuilder) force(s string) {
+	b.WriteString(s)
+}
+
+// keyLines picks the log lines most likely to explain a failure, falling back
+// to the last lines of the log.
+func keyLines(log string) []string {
+	lines := strings.Split(log, "\n")
+	var picked []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] || !keyLinePattern.MatchString(line) {
+			continue
+		}
+		seen[line] = true
+		picked = append(picked, truncate(line, maxExcerptChars))
+		if len(picked) == maxExcerptLines {
+			return picked
+		}
+	}
+	if len(picked) > 0 {
+		return picked
+	}
+	for i := len(lines) - 1; i >= 0 && len(picked) < 3; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			picked = append([]string{truncate(line, maxExcerptChars)}, picked...)
+		}
+	}
+	return picked
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
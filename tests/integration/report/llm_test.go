@@ -0,0 +1,76 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteLLMSummary(t *testing.T) {
+	run := &Run{Tests: []TestResult{
+		{ID: "ok", Status: StatusPassed},
+		{
+			ID:     "broken",
+			Status: StatusFailed,
+			Reason: ReasonToolError,
+			Error:  "tool execution failed:\n  permission denied",
+			Log:    "🚀 starting\nsome noise\nERROR: permission denied on project\n",
+			Repro:  "integration-test -only broken -fast",
+		},
+	}}
+
+	var b strings.Builder
+	if err := WriteLLMSummary(&b, run, 1000); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"RUN total=2 passed=1 failed=1 skipped=0",
+		"- id: broken\n  reason: tool_error\n  error: tool execution failed: permission denied\n",
+		"repro: integration-test -only broken -fast",
+		"LOG broken\n  | ERROR: permission denied on project\n",
+		"PASSED ok",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "some noise") {
+		t.Errorf("summary should only excerpt key lines:\n%s", got)
+	}
+}
+
+func TestWriteLLMSummaryBudget(t *testing.T) {
+	run := &Run{}
+	for i := range 50 {
+		run.Tests = append(run.Tests, TestResult{
+			ID:     fmt.Sprintf("test-%d", i),
+			Status: StatusFailed,
+			Reason: ReasonAssertion,
+			Error:  strings.Repeat("x", 500),
+			Log:    strings.Repeat("error line\n", 20),
+		})
+	}
+
+	var b strings.Builder
+	if err := WriteLLMSummary(&b, run, 300); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	if !strings.Contains(got, "OMITTED") {
+		t.Errorf("expected omitted marker:\n%s", got)
+	}
+	// Only the short OMITTED line may go over budget.
+	if tokens := ApproxTokens(got); tokens > 320 {
+		t.Errorf("summary is %d tokens, want about 300", tokens)
+	}
+	if strings.Contains(got, "LOG ") {
+		t.Errorf("log excerpts should be dropped before failures:\n%s", got)
+	}
+}
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	if got := truncate("ab❌cd", 3); got != "ab…" {
+		t.Errorf("truncate = %q", got)
+	}
+}
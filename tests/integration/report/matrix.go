@@ -0,0 +1,161 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+// Matrix is the outcome of every test in each of several runs, grouped into
+// columns: what a fleet of runners exercised on every platform, or a server
+// package's versions.
+type Matrix struct {
+	// Columns are the expected columns in the order given, then any others
+	// that reported, sorted.
+	Columns []string
+	// Tests are the rows, sorted.
+	Tests []string
+	// Missing lists the expected columns without results.
+	Missing []string
+	cells   map[[2]string]TestResult
+}
+
+// NewMatrix tabulates runs, each from one runner, by their Platform. fleet
+// names the platforms expected to report. Runs from the same platform are
+// combined, a failure outweighing a pass.
+func NewMatrix(runs []*Run, fleet []string) *Matrix {
+	return NewMatrixBy(runs, fleet, func(r *Run) string { return r.Platform })
+}
+
+// NewMatrixBy tabulates runs by the column each belongs to, as NewMatrix
+// does by platform. expected names the columns expected to report.
+func NewMatrixBy(runs []*Run, expected []string, column func(*Run) string) *Matrix {
+	m := &Matrix{cells: map[[2]string]TestResult{}}
+	var others []string
+	for _, run := range runs {
+		col := column(run)
+		if !slices.Contains(expected, col) && !slices.Contains(others, col) {
+			others = append(others, col)
+		}
+		for _, t := range run.Tests {
+			if !slices.Contains(m.Tests, t.ID) {
+				m.Tests = append(m.Tests, t.ID)
+			}
+			key := [2]string{t.ID, col}
+			if prev, ok := m.cells[key]; !ok || rank(t) > rank(prev) {
+				m.cells[key] = t
+			}
+		}
+	}
+	slices.Sort(others)
+	slices.Sort(m.Tests)
+	for _, c := range expected {
+		if !slices.ContainsFunc(runs, func(r *Run) bool { return column(r) == c }) {
+			m.Missing = append(m.Missing, c)
+		}
+	}
+	m.Columns = append(slices.Clone(expected), others...)
+	return m
+}
+
+// rank orders outcomes by how much they matter when combining runs.
+func rank(t TestResult) int {
+	switch {
+	case t.Status == StatusFailed:
+		return 4
+	case t.Status == StatusFlaky || t.Status == StatusQuarantined:
+		return 3
+	case t.Status == StatusPassed:
+		return 2
+	case t.Reason == ReasonPlatform:
+		return 0
+	}
+	return 1
+}
+
+// Cell returns the outcome of test id in column, if it reported one.
+func (m *Matrix) Cell(id, column string) (TestResult, bool) {
+	t, ok := m.cells[[2]string{id, column}]
+	return t, ok
+}
+
+// Regression is a test that passed in a matrix's baseline column but failed
+// in another.
+type Regression struct {
+	Test   string `json:"test"`
+	Column string `json:"column"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (r Regression) String() string {
+	if r.Error == "" {
+		return r.Test + " fails on " + r.Column
+	}
+	return fmt.Sprintf("%s fails on %s: %s", r.Test, r.Column, firstLine(r.Error))
+}
+
+// Regressions returns the tests that passed, possibly after retries, in
+// baseline and failed in another column, by test and then column.
+func (m *Matrix) Regressions(baseline string) []Regression {
+	var out []Regression
+	for _, id := range m.Tests {
+		base, ok := m.Cell(id, baseline)
+		if !ok || base.Status != StatusPassed && base.Status != StatusFlaky {
+			continue
+		}
+		for _, c := range m.Columns {
+			if t, ok := m.Cell(id, c); ok && c != baseline && t.Status == StatusFailed {
+				out = append(out, Regression{Test: id, Column: c, Error: t.Error})
+			}
+		}
+	}
+	return out
+}
+
+// Write prints the matrix as a table: ✅ passed, ❌ failed, 🎲 flaky, 🔒
+// quarantined, ⏭️ skipped, 🚫 not supported on the platform, and · for no
+// result, followed by the expected columns without results.
+func (m *Matrix) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "TEST")
+	for _, c := range m.Columns {
+		fmt.Fprintf(tw, "\t%s", c)
+	}
+	fmt.Fprintln(tw)
+	for _, id := range m.Tests {
+		fmt.Fprint(tw, id)
+		for _, c := range m.Columns {
+			fmt.Fprintf(tw, "\t%s", m.symbol(id, c))
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, c := range m.Missing {
+		fmt.Fprintf(w, "⚠️  no results from %s\n", c)
+	}
+	return nil
+}
+
+func (m *Matrix) symbol(id, column string) string {
+	t, ok := m.Cell(id, column)
+	switch {
+	case !ok:
+		return "·"
+	case t.Reason == ReasonPlatform:
+		return "🚫"
+	}
+	switch t.Status {
+	case StatusPassed:
+		return "✅"
+	case StatusFailed:
+		return "❌"
+	case StatusFlaky:
+		return "🎲"
+	case StatusQuarantined:
+		return "🔒"
+	}
+	return "⏭️"
+}
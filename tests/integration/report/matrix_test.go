@@ -0,0 +1,75 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	runs := []*Run{
+		{Platform: "linux/amd64", Tests: []TestResult{
+			{ID: "gcloud-tool-call", Status: StatusPassed},
+			{ID: "storage-list", Status: StatusPassed},
+		}},
+		// A second shard of the same platform.
+		{Platform: "linux/amd64", Tests: []TestResult{{ID: "gcloud-tool-call", Status: StatusFailed}}},
+		{Platform: "darwin/arm64", Tests: []TestResult{
+			{ID: "gcloud-tool-call", Status: StatusPassed},
+			{ID: "storage-list", Status: StatusSkipped, Reason: ReasonPlatform},
+		}},
+		{Platform: "freebsd/amd64", Tests: []TestResult{{ID: "gcloud-tool-call", Status: StatusSkipped}}},
+	}
+	m := NewMatrix(runs, []string{"linux/amd64", "darwin/arm64", "windows/amd64"})
+	if got := strings.Join(m.Columns, ","); got != "linux/amd64,darwin/arm64,windows/amd64,freebsd/amd64" {
+		t.Errorf("platforms = %s", got)
+	}
+	if c, _ := m.Cell("gcloud-tool-call", "linux/amd64"); c.Status != StatusFailed {
+		t.Errorf("combined linux cell = %s, want the failure", c.Status)
+	}
+	var b strings.Builder
+	if err := m.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"TEST              linux/amd64  darwin/arm64  windows/amd64  freebsd/amd64\n",
+		"gcloud-tool-call  ❌",
+		"storage-list      ✅",
+		"🚫",
+		"⚠️  no results from windows/amd64\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("matrix missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMatrixRegressions(t *testing.T) {
+	tagged := func(tag string, tests ...TestResult) *Run {
+		return &Run{Labels: map[string]string{"matrix_tag": tag}, Tests: tests}
+	}
+	runs := []*Run{
+		tagged("previous",
+			TestResult{ID: "gcloud-tool-call", Status: StatusPassed},
+			TestResult{ID: "gcloud-list-tools", Status: StatusFlaky},
+			TestResult{ID: "gcloud-negative", Status: StatusFailed},
+		),
+		tagged("latest",
+			TestResult{ID: "gcloud-tool-call", Status: StatusFailed, Error: "tool failed: boom\nmore"},
+			TestResult{ID: "gcloud-list-tools", Status: StatusPassed},
+			TestResult{ID: "gcloud-negative", Status: StatusFailed},
+		),
+		tagged("canary", TestResult{ID: "gcloud-list-tools", Status: StatusFailed}),
+	}
+	m := NewMatrixBy(runs, []string{"latest", "previous", "canary", "next"}, func(r *Run) string { return r.Labels["matrix_tag"] })
+	if got := strings.Join(m.Missing, ","); got != "next" {
+		t.Errorf("missing = %s, want next", got)
+	}
+	var got []string
+	for _, r := range m.Regressions("previous") {
+		got = append(got, r.String())
+	}
+	want := []string{"gcloud-list-tools fails on canary", "gcloud-tool-call fails on latest: tool failed: boom"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Regressions() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+)
+
+// Merge combines the results of every shard of one sharded run into a single
+// Run, as if it had run in one job. The shards must all be present, split
+// the run the same way and not repeat a test.
+//
+// Tests are ordered by start time and the run spans the earliest start to the
+// latest end. Latency rows for the same server and tool are combined; their
+// min and averages are exact, but P95 is the highest shard P95, an upper
+// bound. The seed is the first shard's. Notes are combined and a label takes
+// the value of the first shard that has it, as do a server's fingerprint and
+// drift. Billable operations add up, limits too: each shard has the whole
+// budget of its own, so a run split N ways may make N times the calls of one
+// that is not. The run ID is the one the shards share, or else their run IDs in
+// shard order, joined by commas. Coverage is left for the caller to evaluate
+// against the full registry.
+func Merge(shards []*Run) (*Run, error) {
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no results to merge")
+	}
+	count := 0
+	seen := map[int]bool{}
+	for i, s := range shards {
+		if s.Shard == nil {
+			return nil, fmt.Errorf("results %d are not from a sharded run", i+1)
+		}
+		if count == 0 {
+			count = s.Shard.Count
+		}
+		if s.Shard.Count != count {
+			return nil, fmt.Errorf("results %d are shard %s, but results 1 are from a run split %d ways", i+1, s.Shard, count)
+		}
+		if s.Platform != shards[0].Platform {
+			return nil, fmt.Errorf("results %d ran on %s, but results 1 on %s; tabulate runs on different platforms with matrix", i+1, s.Platform, shards[0].Platform)
+		}
+		if seen[s.Shard.Index] {
+			return nil, fmt.Errorf("shard %s appears twice", s.Shard)
+		}
+		seen[s.Shard.Index] = true
+	}
+	for index := range count {
+		if !seen[index] {
+			return nil, fmt.Errorf("missing shard %d/%d", index, count)
+		}
+	}
+
+	merged := &Run{Started: shards[0].Started, Seed: shards[0].Seed, Harness: shards[0].Harness, Commit: shards[0].Commit, Platform: shards[0].Platform}
+	var end time.Time
+	ids := map[string]bool{}
+	runIDs := make([]string, count)
+	for _, s := range shards {
+		runIDs[s.Shard.Index] = s.RunID
+		if s.Started.Before(merged.Started) {
+			merged.Started = s.Started
+		}
+		if e := s.Started.Add(s.Duration); e.After(end) {
+			end = e
+		}
+		for _, t := range s.Tests {
+			if ids[t.ID] {
+				return nil, fmt.Errorf("test %s ran in more than one shard", t.ID)
+			}
+			ids[t.ID] = true
+			merged.Tests = append(merged.Tests, t)
+		}
+		merged.Latency = mergeLatency(merged.Latency, s.Latency)
+		merged.Startup = mergeStartup(merged.Startup, s.Startup)
+		merged.Resources = resources.Merge(merged.Resources, s.Resources)
+		merged.Billing = mergeBilling(merged.Billing, s.Billing)
+		merged.Blackboard = append(merged.Blackboard, s.Blackboard...)
+		for _, f := range s.Features {
+			if !slices.ContainsFunc(merged.Features, func(a features.Active) bool { return a.Name == f.Name }) {
+				merged.Features = append(merged.Features, f)
+			}
+		}
+		merged.Degraded = append(merged.Degraded, s.Degraded...)
+		merged.Orphans = append(merged.Orphans, s.Orphans...)
+		for _, f := range s.Fingerprints {
+			if !slices.ContainsFunc(merged.Fingerprints, func(m fingerprint.Fingerprint) bool { return m.Server == f.Server }) {
+				merged.Fingerprints = append(merged.Fingerprints, f)
+			}
+		}
+		for _, d := range s.Drift {
+			if !slices.ContainsFunc(merged.Drift, func(m fingerprint.Drift) bool { return m.Server == d.Server }) {
+				merged.Drift = append(merged.Drift, d)
+			}
+		}
+		for _, n := range s.Notes {
+			if !slices.Contains(merged.Notes, n) {
+				merged.Notes = append(merged.Notes, n)
+			}
+		}
+		for k, v := range s.Labels {
+			if _, ok := merged.Labels[k]; !ok {
+				merged.Annotate(nil, map[string]string{k: v})
+			}
+		}
+		if merged.Upgrade == nil {
+			merged.Upgrade = s.Upgrade
+		}
+		if merged.Interrupted == "" {
+			merged.Interrupted = s.Interrupted
+		}
+	}
+	merged.RunID = strings.Join(slices.Compact(slices.DeleteFunc(runIDs, func(id string) bool { return id == "" })), ",")
+	merged.Duration = end.Sub(merged.Started)
+	slices.SortStableFunc(merged.Tests, func(a, b TestResult) int { return a.Started.Compare(b.Started) })
+	return merged, nil
+}
+
+// mergeBilling adds the calls and limits of more to usage, by operation.
+func mergeBilling(usage, more []billing.Usage) []billing.Usage {
+	for _, m := range more {
+		i := slices.IndexFunc(usage, func(u billing.Usage) bool { return u.Operation == m.Operation })
+		if i < 0 {
+			usage = append(usage, m)
+			continue
+		}
+		usage[i].Calls += m.Calls
+		usage[i].Limit += m.Limit
+	}
+	return usage
+}
+
+// mergeLatency adds the rows of more to rows.
+func mergeLatency(rows, more []ToolLatency) []ToolLatency {
+	for _, m := range more {
+		i := slices.IndexFunc(rows, func(r ToolLatency) bool { return r.Server == m.Server && r.Tool == m.Tool })
+		if i < 0 {
+			rows = append(rows, m)
+			continue
+		}
+		r := &rows[i]
+		weighted := func(a, b time.Duration) time.Duration {
+			return (a*time.Duration(r.Calls) + b*time.Duration(m.Calls)) / time.Duration(r.Calls+m.Calls)
+		}
+		r.Min = min(r.Min, m.Min)
+		r.Avg = weighted(r.Avg, m.Avg)
+		r.P95 = max(r.P95, m.P95)
+		r.Connect = weighted(r.Connect, m.Connect)
+		r.FirstResponse = weighted(r.FirstResponse, m.FirstResponse)
+		r.Calls += m.Calls
+	}
+	sortLatency(rows)
+	return rows
+}
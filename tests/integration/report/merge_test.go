@@ -0,0 +1,86 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/shard"
+)
+
+func TestMerge(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	shards := []*Run{
+		{
+			Started: start.Add(time.Second), Duration: 10 * time.Second, Seed: 7, Shard: &shard.Spec{Index: 1, Count: 2}, RunID: "b2",
+			Tests:        []TestResult{{ID: "b", Started: start.Add(2 * time.Second), Status: StatusFailed}},
+			Billing:      []billing.Usage{{Operation: "storage-writes", Calls: 3, Limit: 500}},
+			Fingerprints: []fingerprint.Fingerprint{{Server: "gcloud", Version: "0.3.1"}},
+			Latency:      []ToolLatency{{Server: "gcloud-mcp", Tool: "run", Calls: 3, Min: 2 * time.Millisecond, Avg: 4 * time.Millisecond, P95: 9 * time.Millisecond}},
+		},
+		{
+			Started: start, Duration: 5 * time.Second, Seed: 7, Shard: &shard.Spec{Index: 0, Count: 2}, RunID: "a1",
+			Tests:        []TestResult{{ID: "a", Started: start, Status: StatusPassed}},
+			Billing:      []billing.Usage{{Operation: "storage-writes", Calls: 2, Limit: 500}, {Operation: "log-ingestion", Calls: 1, Limit: 1000}},
+			Orphans:      []Orphan{{Resource: orphans.Resource{Kind: orphans.KindBucket, Name: "mcp-it-a-00000000"}}},
+			Fingerprints: []fingerprint.Fingerprint{{Server: "gcloud", Version: "0.3.0"}},
+			Latency:      []ToolLatency{{Server: "gcloud-mcp", Tool: "run", Calls: 1, Min: 8 * time.Millisecond, Avg: 8 * time.Millisecond, P95: 8 * time.Millisecond}},
+		},
+	}
+	merged, err := Merge(shards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Shard != nil || !merged.Started.Equal(start) || merged.Duration != 11*time.Second || merged.Seed != 7 {
+		t.Errorf("Merge = %+v", merged)
+	}
+	if len(merged.Tests) != 2 || merged.Tests[0].ID != "a" || merged.Tests[1].ID != "b" {
+		t.Errorf("merged tests = %+v, want a then b", merged.Tests)
+	}
+	if merged.RunID != "a1,b2" {
+		t.Errorf("merged run ID = %q, want the shards' in shard order", merged.RunID)
+	}
+	shards[0].RunID = "a1"
+	if same, err := Merge(shards); err != nil || same.RunID != "a1" {
+		t.Errorf("merged run ID of shards sharing one = %v, %v, want a1", same, err)
+	}
+	if len(merged.Orphans) != 1 {
+		t.Errorf("merged orphans = %+v, want shard 0's", merged.Orphans)
+	}
+	if len(merged.Fingerprints) != 1 || merged.Fingerprints[0].Version != "0.3.1" {
+		t.Errorf("merged fingerprints = %+v, want the first results'", merged.Fingerprints)
+	}
+	if got := merged.Billing; len(got) != 2 || got[0] != (billing.Usage{Operation: "storage-writes", Calls: 5, Limit: 1000}) || got[1].Calls != 1 {
+		t.Errorf("merged billing = %+v, want every shard's calls and budgets added up", got)
+	}
+	want := ToolLatency{Server: "gcloud-mcp", Tool: "run", Calls: 4, Min: 2 * time.Millisecond, Avg: 5 * time.Millisecond, P95: 9 * time.Millisecond}
+	if len(merged.Latency) != 1 || merged.Latency[0] != want {
+		t.Errorf("merged latency = %+v, want %+v", merged.Latency, want)
+	}
+}
+
+func TestMergeRejectsIncompleteShards(t *testing.T) {
+	spec := func(index, count int) *shard.Spec { return &shard.Spec{Index: index, Count: count} }
+	tests := []struct {
+		shards []*Run
+		want   string
+	}{
+		{[]*Run{{Shard: spec(0, 2)}}, "missing shard 1/2"},
+		{[]*Run{{Shard: spec(0, 2)}, {Shard: spec(0, 2)}}, "shard 0/2 appears twice"},
+		{[]*Run{{Shard: spec(0, 2)}, {Shard: spec(1, 3)}}, "split 2 ways"},
+		{[]*Run{{}}, "not from a sharded run"},
+		{[]*Run{{Shard: spec(0, 2), Platform: "linux/amd64"}, {Shard: spec(1, 2), Platform: "darwin/arm64"}}, "ran on darwin/arm64"},
+		{[]*Run{
+			{Shard: spec(0, 2), Tests: []TestResult{{ID: "a"}}},
+			{Shard: spec(1, 2), Tests: []TestResult{{ID: "a"}}},
+		}, "test a ran in more than one shard"},
+	}
+	for _, tt := range tests {
+		if _, err := Merge(tt.shards); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Merge() = %v, want an error containing %q", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+)
+
+// OverLimit is a server that used more memory or CPU during a test than the
+// manifest limits it to.
+type OverLimit struct {
+	Server string `json:"server"`
+	// Resource is "memory" or "CPU".
+	Resource string `json:"resource"`
+	Used     string `json:"used"`
+	Limit    string `json:"limit"`
+}
+
+func (o OverLimit) String() string {
+	return fmt.Sprintf("%s used %s of %s, over its %s limit", o.Server, o.Used, o.Resource, o.Limit)
+}
+
+// WriteResourceTable prints one row per server with the most memory and CPU
+// it used.
+func WriteResourceTable(w io.Writer, peaks []resources.Peak) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tPEAK RSS\tCPU\tPEAK CPU")
+	for _, p := range peaks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0f%%\n", p.Server, resources.FormatBytes(p.RSS), round(p.CPU), p.CPUPercent)
+	}
+	return tw.Flush()
+}
+
+// SummarizeResources combines the peaks of tests by server.
+func SummarizeResources(tests []TestResult) []resources.Peak {
+	var peaks []resources.Peak
+	for _, t := range tests {
+		peaks = resources.Merge(peaks, t.Resources)
+	}
+	return peaks
+}
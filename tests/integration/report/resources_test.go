@@ -0,0 +1,32 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+)
+
+func TestWriteTextResources(t *testing.T) {
+	run := &Run{
+		Tests: []TestResult{{
+			ID: "observability-large-query", Status: StatusFailed, Reason: ReasonResourceLimit,
+			OverLimits: []OverLimit{{Server: "observability-mcp", Resource: "memory", Used: "812.0 MiB", Limit: "512.0 MiB"}},
+		}},
+		Resources: []resources.Peak{{Server: "observability-mcp", RSS: 812 << 20, CPU: 3200 * time.Millisecond, CPUPercent: 97.4}},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"       🧠 observability-mcp used 812.0 MiB of memory, over its 512.0 MiB limit\n",
+		"🧠 Server resources:",
+		"observability-mcp  812.0 MiB  3.2s  97%",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/artifacts"
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/coverage"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/quarantine"
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+	"github.com/googleapis/gcloud-mcp/tests/integration/shard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// Status is the outcome of a single test.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+	// StatusQuarantined is a failure of a test with an unexpired quarantine
+	// entry; it does not fail the run.
+	StatusQuarantined Status = "quarantined"
+	// StatusFlaky is a test that failed and then passed when retried with
+	// -retries. It fails the run only with -fail-on-flaky.
+	StatusFlaky Status = "flaky"
+)
+
+// Reason codes classify why a test failed.
+const (
+	ReasonPrerequisite = "prerequisite_missing"
+	ReasonCommand      = "command_failed"
+	ReasonConnect      = "connect_failed"
+	ReasonToolError    = "tool_error"
+	ReasonParse        = "parse_error"
+	ReasonAssertion    = "assertion_failed"
+	ReasonUnexpected   = "unexpected_success"
+	ReasonSchema       = "schema_mismatch"
+	// ReasonFraming marks a stdio server whose output is not valid
+	// newline-delimited JSON-RPC.
+	ReasonFraming = "framing_error"
+	// ReasonPollution marks a test whose servers wrote non-protocol output
+	// to stdout, found with -detect-stdout-pollution.
+	ReasonPollution = "stdout_pollution"
+	// ReasonTransportDiff marks a call whose result depends on the transport
+	// it was made over.
+	ReasonTransportDiff = "transport_mismatch"
+	// ReasonOracleDiff marks a gcloud-mcp call whose result differs from
+	// running its gcloud command directly, found with -oracle.
+	ReasonOracleDiff = "oracle_mismatch"
+	// ReasonQuarantineExpired marks a quarantined test that still fails after
+	// its entry expired.
+	ReasonQuarantineExpired = "quarantine_expired"
+	// ReasonMutantSurvived marks a test that still passed when replayed
+	// against a mutated server response, i.e. one that does not check it.
+	ReasonMutantSurvived = "mutant_survived"
+	// ReasonHang marks the test a run was stuck in when the -timeout
+	// watchdog ended it.
+	ReasonHang = "hang"
+	// ReasonStartupSLO marks a test that launched a server slower than the
+	// startup SLO the manifest declares for it.
+	ReasonStartupSLO = "startup_slo"
+	// ReasonResourceLimit marks a test during which a server used more
+	// memory or CPU than the manifest limits it to.
+	ReasonResourceLimit = "resource_limit"
+	// ReasonAborted marks the test a run was in when a signal, such as a
+	// Ctrl-C, interrupted it.
+	ReasonAborted = "aborted"
+	// ReasonCancellation marks a server that kept working on a tool call
+	// after the client cancelled it.
+	ReasonCancellation = "cancellation_ignored"
+	// ReasonInconsistent marks a read-only tool call that returned different
+	// results, after normalization, when repeated.
+	ReasonInconsistent = "inconsistent_result"
+	// ReasonPolicy marks a gcloud-mcp command its access control policy ran
+	// though it should deny it, or denied though it should allow it.
+	ReasonPolicy = "policy_violation"
+	// ReasonProtocol marks a server that failed a protocol conformance
+	// check or answered a pinned protocol version with another.
+	ReasonProtocol = "protocol_violation"
+	// ReasonPlatform marks a skipped test whose platform constraint excludes
+	// the platform the run is on.
+	ReasonPlatform = "platform_unsupported"
+	// ReasonTokenBudget marks a tool result larger than its token budget.
+	ReasonTokenBudget = "token_budget"
+	// ReasonFuzzCrash marks a server that crashed or hung on fuzzed tool
+	// arguments instead of rejecting them.
+	ReasonFuzzCrash = "fuzz_crash"
+	// ReasonDisconnected marks a call made in a session whose server
+	// connection dropped, e.g. because the server crashed, and was not
+	// restored.
+	ReasonDisconnected = "server_disconnected"
+	// ReasonBilling marks the test whose tool call would have exceeded the
+	// run's budget for billable operations, and the tests the run then did
+	// not start.
+	ReasonBilling = "billing_budget"
+	// ReasonGeminiVersion marks a Gemini CLI whose output the tests do not
+	// know the format of.
+	ReasonGeminiVersion = "unsupported_gemini_version"
+	ReasonUnknown       = "error"
+)
+
+// Failure is an error annotated with a reason code.
+type Failure struct {
+	Reason string
+	Err    error
+}
+
+// Fail returns a Failure with the given reason and formatted message.
+func Fail(reason, format string, args ...any) error {
+	return &Failure{Reason: reason, Err: fmt.Errorf(format, args...)}
+}
+
+func (f *Failure) Error() string { return f.Err.Error() }
+func (f *Failure) Unwrap() error { return f.Err }
+
+// ReasonOf returns the reason code of the first Failure in err's chain,
+// falling back to the phase of a failed client call, or ReasonUnknown.
+func ReasonOf(err error) string {
+	var f *Failure
+	switch {
+	case errors.As(err, &f):
+		return f.Reason
+	case errors.Is(err, client.ErrInvalidArgs), errors.Is(err, client.ErrInvalidOutput):
+		return ReasonSchema
+	case errors.Is(err, client.ErrFraming):
+		return ReasonFraming
+	case errors.Is(err, client.ErrProtocolVersion):
+		return ReasonProtocol
+	case errors.Is(err, client.ErrDisconnected):
+		return ReasonDisconnected
+	case errors.Is(err, client.ErrConnect):
+		return ReasonConnect
+	case errors.Is(err, client.ErrToolExecution):
+		return ReasonToolError
+	case errors.Is(err, client.ErrUnexpectedSuccess):
+		return ReasonUnexpected
+	case errors.Is(err, client.ErrErrorMismatch):
+		return ReasonAssertion
+	case errors.Is(err, client.ErrTokenBudget):
+		return ReasonTokenBudget
+	case errors.Is(err, billing.ErrExceeded):
+		return ReasonBilling
+	}
+	return ReasonUnknown
+}
+
+// SkipError marks a test as skipped rather than failed.
+type SkipError struct {
+	Message string
+}
+
+// Skip returns a SkipError with the formatted message.
+func Skip(format string, args ...any) error {
+	return &SkipError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *SkipError) Error() string { return "skipped: " + s.Message }
+
+// IsSkip reports whether err marks a test as skipped.
+func IsSkip(err error) bool {
+	var s *SkipError
+	return errors.As(err, &s)
+}
+
+// TestResult is the recorded outcome of one test.
+type TestResult struct {
+	ID       string        `json:"id"`
+	Started  time.Time     `json:"started"`
+	Status   Status        `json:"status"`
+	Reason   string        `json:"reason,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	// Log is the progress output the test produced.
+	Log string `json:"log,omitempty"`
+	// Repro is a command line that reruns just this test.
+	Repro string `json:"repro,omitempty"`
+	// Mismatch holds the expected and actual values of a failed comparison.
+	Mismatch *Mismatch `json:"mismatch,omitempty"`
+	// ReproScript is the path of a generated script replaying the test's
+	// tool calls, if artifacts were enabled.
+	ReproScript string `json:"repro_script,omitempty"`
+	// ArtifactOverage records how the test's artifacts were shrunk to fit
+	// their budget, if they outgrew it.
+	ArtifactOverage *artifacts.Overage `json:"artifact_overage,omitempty"`
+	// WireTrace is the path of the JSONL trace of every JSON-RPC message of
+	// the failed test's tool calls, if -wire-trace recorded one.
+	WireTrace string `json:"wire_trace,omitempty"`
+	// Stderr holds what the failed test's stdio servers wrote to stderr,
+	// one entry per launch that wrote anything.
+	Stderr []ServerStderr `json:"stderr,omitempty"`
+	// Artifacts are the paths of the files saved to the test's artifacts
+	// directory, by the test itself or, for a failed test, by the runner:
+	// its servers' stderr, its raw tool outputs and its wire trace.
+	Artifacts []string `json:"artifacts,omitempty"`
+	// Servers lists the registered servers the test's tool calls reached.
+	Servers []string `json:"servers,omitempty"`
+	// Downgrades lists the tool calls that fell back from their preferred
+	// transport.
+	Downgrades []client.Downgrade `json:"downgrades,omitempty"`
+	// Leaked lists the child processes still running after the test, which
+	// the runner killed.
+	Leaked []subprocess.Process `json:"leaked,omitempty"`
+	// Pollution lists the non-protocol lines the test's stdio servers wrote.
+	Pollution []client.Pollution `json:"pollution,omitempty"`
+	// SlowStartups lists the server launches over their startup SLO.
+	SlowStartups []SlowStartup `json:"slow_startups,omitempty"`
+	// Resources is the most memory and CPU each server the test started
+	// used, and OverLimits the manifest limits they exceeded.
+	Resources  []resources.Peak `json:"resources,omitempty"`
+	OverLimits []OverLimit      `json:"over_limits,omitempty"`
+	// Timeline lists the progress and log notifications the test's servers
+	// sent and the steps the test reported, in arrival order.
+	Timeline []client.Notification `json:"timeline,omitempty"`
+	// Mutation is the outcome of replaying the test against mutated
+	// responses with -mutate.
+	Mutation *Mutation `json:"mutation,omitempty"`
+	// Quarantine is the test's quarantine entry, if it has one.
+	Quarantine *quarantine.Entry `json:"quarantine,omitempty"`
+	// FailedAttempts lists the attempts before the recorded one, all failed,
+	// if the test was retried. Reason and Error of a flaky test are those of
+	// the first.
+	FailedAttempts []Attempt `json:"failed_attempts,omitempty"`
+}
+
+// ServerStderr is the end of what one server launch wrote to stderr.
+type ServerStderr struct {
+	Server string `json:"server"`
+	Text   string `json:"text"`
+}
+
+// Attempt is a failed attempt at a test that was retried.
+type Attempt struct {
+	Reason   string        `json:"reason"`
+	Error    string        `json:"error"`
+	Duration time.Duration `json:"duration_ns"`
+	// WireTrace is the path of the attempt's own wire trace, with
+	// -wire-trace.
+	WireTrace string `json:"wire_trace,omitempty"`
+}
+
+// Mutation counts the mutants a test was replayed against and names those it
+// did not detect.
+type Mutation struct {
+	Mutants  int      `json:"mutants"`
+	Killed   int      `json:"killed"`
+	Survived []string `json:"survived,omitempty"`
+}
+
+// Published records a value a test put on the run's blackboard.
+type Published struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Publisher string    `json:"publisher"`
+	At        time.Time `json:"at"`
+}
+
+// Run is the full record of one suite run, as written to the results file.
+type Run struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	// Seed is the seed every test's random source was derived from.
+	Seed    int64         `json:"seed"`
+	Tests   []TestResult  `json:"tests"`
+	Latency []ToolLatency `json:"latency,omitempty"`
+	// Startup summarizes how long each server took to launch over stdio.
+	Startup []ServerStartup `json:"startup,omitempty"`
+	// Resources is the most memory and CPU each server used in any test,
+	// with the CPU time of all tests added up.
+	Resources []resources.Peak `json:"resources,omitempty"`
+	// Billing counts the billable operations the run made against its
+	// budget.
+	Billing []billing.Usage `json:"billing,omitempty"`
+	// Blackboard lists the values tests shared during the run.
+	Blackboard []Published `json:"blackboard,omitempty"`
+	// Coverage is the share of each suite the run executed.
+	Coverage []coverage.Result `json:"coverage,omitempty"`
+	// Features are the experimental feature flags enabled for the run.
+	Features []features.Active `json:"features,omitempty"`
+	// Degraded lists the optional reporting sinks the run could not publish
+	// to. They never affect the outcome.
+	Degraded []Degradation `json:"degraded,omitempty"`
+	// Platform is the os/arch the run was on.
+	Platform string `json:"platform,omitempty"`
+	// RunID names the run in the resources its tests created and in its
+	// leases. A merged run lists its shards' if they differ.
+	RunID string `json:"run_id,omitempty"`
+	// Harness is the version of the harness build that ran.
+	Harness string `json:"harness,omitempty"`
+	// Commit is the SHA of the checkout under test, if known.
+	Commit string `json:"commit,omitempty"`
+	// Interrupted is the signal that stopped the run before it finished, such
+	// as "interrupt"; the tests after the aborted one did not run.
+	Interrupted string `json:"interrupted,omitempty"`
+	// Upgrade is the newer harness release the update check found, if any.
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+	// Shard is the part of a sharded run these results cover; Merge combines
+	// the shards.
+	Shard *shard.Spec `json:"shard,omitempty"`
+	// ArtifactOverage records how the oldest tests' artifacts were shrunk to
+	// fit the run's budget, if they outgrew it.
+	ArtifactOverage *artifacts.Overage `json:"artifact_overage,omitempty"`
+	// Orphans lists the test resources the run's tests or earlier runs left
+	// in the test project, found by the sweep after the tests.
+	Orphans []Orphan `json:"orphans,omitempty"`
+	// Notes and Labels annotate the run for the people reading its results,
+	// e.g. with the backend it ran against.
+	Notes  []Note            `json:"notes,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Fingerprints record the runtime environment of each server, and Drift
+	// the servers whose environment changed since the previous run without
+	// a change to their configuration.
+	Fingerprints []fingerprint.Fingerprint `json:"fingerprints,omitempty"`
+	Drift        []fingerprint.Drift       `json:"drift,omitempty"`
+	// TraceID is the OpenTelemetry trace the run's spans were exported as.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// Orphan is a leftover test resource.
+type Orphan struct {
+	orphans.Resource
+	// Test is the test of this run that created the resource, which the
+	// sweep then deletes; empty for one an earlier run left.
+	Test string `json:"test,omitempty"`
+	// Deleted is set if the sweep deleted the resource with
+	// -cleanup-orphans; Error holds why it could not.
+	Deleted bool   `json:"deleted,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Upgrade is a harness release newer than the one that ran.
+type Upgrade struct {
+	Latest string `json:"latest"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// Degradation is an optional reporting sink that was unavailable, so the run
+// fell back to local-only reporting for it.
+type Degradation struct {
+	Sink  string `json:"sink"`
+	Error string `json:"error"`
+}
+
+// Executed reports whether test id ran to a pass or failure, rather than
+// being skipped or left out of the run.
+func (r *Run) Executed(id string) bool {
+	for _, t := range r.Tests {
+		if t.ID == id {
+			return t.Status != StatusSkipped
+		}
+	}
+	return false
+}
+
+// Counts returns the number of passed, failed and skipped tests. Quarantined
+// and flaky tests are in none of them; see Quarantined and Flaky.
+func (r *Run) Counts() (passed, failed, skipped int) {
+	for _, t := range r.Tests {
+		switch t.Status {
+		case StatusPassed:
+			passed++
+		case StatusSkipped:
+			skipped++
+		case StatusQuarantined, StatusFlaky:
+		default:
+			failed++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// Failures returns the tests that failed, in run order.
+func (r *Run) Failures() []TestResult {
+	var out []TestResult
+	for _, t := range r.Tests {
+		if t.Status == StatusFailed {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Quarantined returns the tests whose failure was excused by their
+// quarantine entry, in run order.
+func (r *Run) Quarantined() []TestResult {
+	var out []TestResult
+	for _, t := range r.Tests {
+		if t.Status == StatusQuarantined {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Flaky returns the tests that passed only when retried, in run order.
+func (r *Run) Flaky() []TestResult {
+	var out []TestResult
+	for _, t := range r.Tests {
+		if t.Status == StatusFlaky {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// WriteJSON writes run to path as indented JSON.
+func WriteJSON(path string, run *Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadJSON loads a results file written by WriteJSON.
+func ReadJSON(path string) (*Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse results file %s: %w", path, err)
+	}
+	return &run, nil
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+func TestReasonOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{Fail(ReasonParse, "bad json"), ReasonParse},
+		{fmt.Errorf("wrapped: %w", Fail(ReasonAssertion, "mismatch")), ReasonAssertion},
+		{fmt.Errorf("call: %w", fmt.Errorf("%w: eof", client.ErrConnect)), ReasonConnect},
+		{fmt.Errorf("%w: boom", client.ErrToolExecution), ReasonToolError},
+		{fmt.Errorf("%w: %w", client.ErrConnect, &client.FramingError{Err: fmt.Errorf("bad")}), ReasonFraming},
+		{fmt.Errorf("%w: eof; reconnecting: %w", client.ErrDisconnected, client.ErrConnect), ReasonDisconnected},
+		{fmt.Errorf("calling write_object: %w: over", billing.ErrExceeded), ReasonBilling},
+		{fmt.Errorf("plain"), ReasonUnknown},
+	}
+	for _, tt := range tests {
+		if got := ReasonOf(tt.err); got != tt.want {
+			t.Errorf("ReasonOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	run := &Run{Tests: []TestResult{{ID: "a", Status: StatusPassed}, {ID: "b", Status: StatusFailed, Reason: ReasonParse}}}
+	if err := WriteJSON(path, run); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed, failed, skipped := got.Counts(); passed != 1 || failed != 1 || skipped != 0 {
+		t.Errorf("Counts() = %d, %d, %d", passed, failed, skipped)
+	}
+	if f := got.Failures(); len(f) != 1 || f[0].Reason != ReasonParse {
+		t.Errorf("Failures() = %+v", f)
+	}
+}
+
+func TestCountsExcludeQuarantined(t *testing.T) {
+	run := &Run{Tests: []TestResult{
+		{ID: "a", Status: StatusPassed},
+		{ID: "b", Status: StatusQuarantined, Reason: ReasonAssertion},
+		{ID: "c", Status: StatusFailed, Reason: ReasonQuarantineExpired},
+	}}
+	if passed, failed, skipped := run.Counts(); passed != 1 || failed != 1 || skipped != 0 {
+		t.Errorf("Counts() = %d, %d, %d", passed, failed, skipped)
+	}
+	if q := run.Quarantined(); len(q) != 1 || q[0].ID != "b" {
+		t.Errorf("Quarantined() = %+v", q)
+	}
+	if f := run.Failures(); len(f) != 1 || f[0].ID != "c" {
+		t.Errorf("Failures() = %+v", f)
+	}
+}
+
+func TestFlakyCountsSeparately(t *testing.T) {
+	run := &Run{Tests: []TestResult{
+		{ID: "a", Status: StatusPassed},
+		{ID: "b", Status: StatusFlaky, FailedAttempts: []Attempt{{Reason: ReasonConnect}}},
+	}}
+	if passed, failed, skipped := run.Counts(); passed != 1 || failed != 0 || skipped != 0 {
+		t.Errorf("Counts() = %d, %d, %d", passed, failed, skipped)
+	}
+	if f := run.Flaky(); len(f) != 1 || f[0].ID != "b" {
+		t.Errorf("Flaky() = %+v", f)
+	}
+	if !run.Executed("b") {
+		t.Error("a flaky test was not executed")
+	}
+}
+
+func TestRunExecuted(t *testing.T) {
+	run := &Run{Tests: []TestResult{{ID: "a", Status: StatusPassed}, {ID: "b", Status: StatusSkipped}, {ID: "c", Status: StatusQuarantined}}}
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true, "filtered-out": false} {
+		if got := run.Executed(id); got != want {
+			t.Errorf("Executed(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
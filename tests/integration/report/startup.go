@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// SlowStartup is a server launch that took longer than its startup SLO.
+type SlowStartup struct {
+	Server  string        `json:"server"`
+	Startup time.Duration `json:"startup_ns"`
+	SLO     time.Duration `json:"slo_ns"`
+}
+
+func (s SlowStartup) String() string {
+	return fmt.Sprintf("%s started in %s, over its %s SLO", s.Server, round(s.Startup), s.SLO)
+}
+
+// ServerStartup summarizes the stdio launches of one server.
+type ServerStartup struct {
+	Server   string `json:"server"`
+	Launches int    `json:"launches"`
+	// Cold is the first launch, which may include e.g. npx fetching the
+	// package; a merged run keeps the slowest shard's.
+	Cold time.Duration `json:"cold_ns"`
+	Avg  time.Duration `json:"avg_ns"`
+	Max  time.Duration `json:"max_ns"`
+	// Retries counts the handshakes retried while the server was not ready,
+	// over all its launches.
+	Retries int `json:"readiness_retries,omitempty"`
+}
+
+// SummarizeStartup groups the calls that launched a server by server, in
+// call order, and sorts the rows by server.
+func SummarizeStartup(calls []client.Metrics) []ServerStartup {
+	var rows []ServerStartup
+	sums := map[string]time.Duration{}
+	for _, c := range calls {
+		if c.Startup == 0 {
+			continue
+		}
+		i := slices.IndexFunc(rows, func(r ServerStartup) bool { return r.Server == c.Server })
+		if i < 0 {
+			rows = append(rows, ServerStartup{Server: c.Server, Cold: c.Startup})
+			i = len(rows) - 1
+		}
+		r := &rows[i]
+		r.Launches++
+		r.Retries += c.ReadinessRetries
+		r.Max = max(r.Max, c.Startup)
+		sums[c.Server] += c.Startup
+		r.Avg = sums[c.Server] / time.Duration(r.Launches)
+	}
+	slices.SortFunc(rows, func(a, b ServerStartup) int { return strings.Compare(a.Server, b.Server) })
+	return rows
+}
+
+// mergeStartup adds the rows of more to rows.
+func mergeStartup(rows, more []ServerStartup) []ServerStartup {
+	for _, m := range more {
+		i := slices.IndexFunc(rows, func(r ServerStartup) bool { return r.Server == m.Server })
+		if i < 0 {
+			rows = append(rows, m)
+			continue
+		}
+		r := &rows[i]
+		r.Avg = (r.Avg*time.Duration(r.Launches) + m.Avg*time.Duration(m.Launches)) / time.Duration(r.Launches+m.Launches)
+		r.Launches += m.Launches
+		r.Retries += m.Retries
+		r.Cold = max(r.Cold, m.Cold)
+		r.Max = max(r.Max, m.Max)
+	}
+	slices.SortFunc(rows, func(a, b ServerStartup) int { return strings.Compare(a.Server, b.Server) })
+	return rows
+}
+
+// WriteStartupTable prints one row per server with its startup times.
+func WriteStartupTable(w io.Writer, rows []ServerStartup) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tLAUNCHES\tCOLD\tAVG\tMAX\tRETRIES")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\n", r.Server, r.Launches, round(r.Cold), round(r.Avg), round(r.Max), r.Retries)
+	}
+	return tw.Flush()
+}
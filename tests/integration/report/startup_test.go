@@ -0,0 +1,52 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+func TestSummarizeStartup(t *testing.T) {
+	calls := []client.Metrics{
+		{Server: "gcloud-mcp", Startup: 6 * time.Second, ReadinessRetries: 2},
+		{Server: "gcloud-mcp", Startup: time.Second},
+		// A later call in the same session launched nothing.
+		{Server: "gcloud-mcp"},
+		{Server: "a-mcp", Startup: 2 * time.Second},
+		{Server: "gcloud-mcp", Startup: 2 * time.Second},
+	}
+	rows := SummarizeStartup(calls)
+	want := []ServerStartup{
+		{Server: "a-mcp", Launches: 1, Cold: 2 * time.Second, Avg: 2 * time.Second, Max: 2 * time.Second},
+		{Server: "gcloud-mcp", Launches: 3, Cold: 6 * time.Second, Avg: 3 * time.Second, Max: 6 * time.Second, Retries: 2},
+	}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] {
+		t.Errorf("SummarizeStartup() = %+v, want %+v", rows, want)
+	}
+
+	merged := mergeStartup(rows, []ServerStartup{{Server: "gcloud-mcp", Launches: 1, Cold: 7 * time.Second, Avg: 7 * time.Second, Max: 7 * time.Second, Retries: 1}})
+	if g := merged[1]; g.Launches != 4 || g.Cold != 7*time.Second || g.Avg != 4*time.Second || g.Max != 7*time.Second || g.Retries != 3 {
+		t.Errorf("merged = %+v", g)
+	}
+}
+
+func TestWriteTextSlowStartup(t *testing.T) {
+	run := &Run{
+		Tests: []TestResult{{
+			ID: "gcloud-tool-call", Status: StatusPassed,
+			SlowStartups: []SlowStartup{{Server: "gcloud-mcp", Startup: 7200 * time.Millisecond, SLO: 5 * time.Second}},
+		}},
+		Startup: []ServerStartup{{Server: "gcloud-mcp", Launches: 1, Cold: 7200 * time.Millisecond, Avg: 7200 * time.Millisecond, Max: 7200 * time.Millisecond}},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"       🐢 gcloud-mcp started in 7.2s, over its 5s SLO\n", "🚀 Server startup:", "gcloud-mcp  1         7.2s"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+}
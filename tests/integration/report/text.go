@@ -0,0 +1,140 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText prints the human-readable end-of-run summary.
+func WriteText(w io.Writer, run *Run) error {
+	passed, failed, skipped := run.Counts()
+	fmt.Fprintf(w, "\n📋 %d passed, %d failed, %d skipped", passed, failed, skipped)
+	if q := len(run.Quarantined()); q > 0 {
+		fmt.Fprintf(w, ", %d quarantined", q)
+	}
+	if f := len(run.Flaky()); f > 0 {
+		fmt.Fprintf(w, ", %d flaky", f)
+	}
+	fmt.Fprintf(w, " in %s\n", round(run.Duration))
+	if run.Interrupted != "" {
+		fmt.Fprintf(w, "  ⛔ interrupted by %s; the results are partial\n", run.Interrupted)
+	}
+	if labels := run.LabelList(); len(labels) > 0 {
+		fmt.Fprintf(w, "  🏷️  %s\n", strings.Join(labels, ", "))
+	}
+	for _, n := range run.Notes {
+		fmt.Fprintf(w, "  📝 %s\n", n)
+	}
+	for _, t := range run.Tests {
+		switch t.Status {
+		case StatusPassed:
+			fmt.Fprintf(w, "  ✅ %s (%s)\n", t.ID, round(t.Duration))
+		case StatusSkipped:
+			if t.Reason == ReasonPlatform {
+				fmt.Fprintf(w, "  🚫 %s: %s\n", t.ID, strings.TrimPrefix(t.Error, "skipped: "))
+				break
+			}
+			fmt.Fprintf(w, "  ⏭️  %s: %s\n", t.ID, firstLine(t.Error))
+		case StatusFlaky:
+			fmt.Fprintf(w, "  🎲 %s (%s) [%s]: passed on attempt %d after: %s\n", t.ID, round(t.Duration), t.Reason, len(t.FailedAttempts)+1, firstLine(t.Error))
+		case StatusQuarantined:
+			fmt.Fprintf(w, "  🔒 %s (%s) [%s]: %s; owner %s, expires %s\n", t.ID, round(t.Duration), t.Reason, firstLine(t.Error),
+				t.Quarantine.Owner, t.Quarantine.Expires.UTC().Format(time.DateOnly))
+		default:
+			fmt.Fprintf(w, "  ❌ %s (%s) [%s]: %s", t.ID, round(t.Duration), t.Reason, firstLine(t.Error))
+			if n := len(t.FailedAttempts); n > 0 {
+				fmt.Fprintf(w, " (all %d attempts failed)", n+1)
+			}
+			fmt.Fprintln(w)
+		}
+		writeTimeline(w, t)
+		for _, p := range t.Leaked {
+			fmt.Fprintf(w, "       🧟 leaked %s\n", p.Label())
+		}
+		for _, s := range t.SlowStartups {
+			fmt.Fprintf(w, "       🐢 %s\n", s)
+		}
+		for _, o := range t.OverLimits {
+			fmt.Fprintf(w, "       🧠 %s\n", o)
+		}
+		if t.WireTrace != "" {
+			fmt.Fprintf(w, "       🔌 wire trace %s\n", t.WireTrace)
+		}
+		if len(t.Artifacts) > 0 {
+			fmt.Fprintf(w, "       📎 artifacts %s\n", strings.Join(t.Artifacts, ", "))
+		}
+		if o := t.ArtifactOverage; o != nil {
+			fmt.Fprintf(w, "       📦 artifacts were %s\n", o)
+		}
+	}
+	for _, d := range run.Degraded {
+		fmt.Fprintf(w, "  ⚠️  %s unavailable, reported locally only: %s\n", d.Sink, firstLine(d.Error))
+	}
+	if o := run.ArtifactOverage; o != nil {
+		fmt.Fprintf(w, "  📦 run artifacts were %s\n", o)
+	}
+	for _, o := range run.Orphans {
+		if o.Test != "" {
+			fmt.Fprintf(w, "  🧹 %s left behind by %s: ", o.Resource, o.Test)
+		} else {
+			fmt.Fprintf(w, "  🧹 orphaned %s, created %s: ", o.Resource, o.Created.UTC().Format(time.DateOnly))
+		}
+		switch {
+		case o.Deleted:
+			fmt.Fprintln(w, "deleted")
+		case o.Error != "":
+			fmt.Fprintf(w, "could not delete it: %s\n", firstLine(o.Error))
+		default:
+			fmt.Fprintln(w, "left in place; delete it with -cleanup-orphans")
+		}
+	}
+	for _, d := range run.Drift {
+		fmt.Fprintf(w, "  🧬 %s\n", d)
+	}
+	if u := run.Upgrade; u != nil {
+		fmt.Fprintf(w, "  ⬆️  harness %s is out of date; upgrade to %s before trusting mismatches", run.Harness, u.Latest)
+		if u.Notes != "" {
+			fmt.Fprintf(w, " (%s)", u.Notes)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(run.Startup) > 0 {
+		fmt.Fprintln(w, "\n🚀 Server startup:")
+		if err := WriteStartupTable(w, run.Startup); err != nil {
+			return err
+		}
+	}
+	if len(run.Resources) > 0 {
+		fmt.Fprintln(w, "\n🧠 Server resources:")
+		if err := WriteResourceTable(w, run.Resources); err != nil {
+			return err
+		}
+	}
+	if len(run.Latency) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\n⏱️  Tool call latency:")
+	return WriteLatencyTable(w, run.Latency)
+}
+
+// maxTimelineLines caps the notifications printed per test.
+const maxTimelineLines = 10
+
+// writeTimeline prints the notifications and steps of t with their offset
+// from the test's start.
+func writeTimeline(w io.Writer, t TestResult) {
+	for i, n := range t.Timeline {
+		if i == maxTimelineLines {
+			fmt.Fprintf(w, "       … %d more notifications\n", len(t.Timeline)-i)
+			return
+		}
+		fmt.Fprintf(w, "       +%-7s %s\n", round(n.At.Sub(t.Started)), strings.TrimSpace(n.Server+" "+firstLine(n.String())))
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
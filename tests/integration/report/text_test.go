@@ -0,0 +1,181 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/artifacts"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/fingerprint"
+	"github.com/googleapis/gcloud-mcp/tests/integration/orphans"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+func TestWriteTextTimeline(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	test := TestResult{ID: "deploy", Started: start, Status: StatusPassed, Duration: 3 * time.Second}
+	for i := range maxTimelineLines + 2 {
+		test.Timeline = append(test.Timeline, client.Notification{
+			At: start.Add(time.Duration(i+1) * 100 * time.Millisecond), Server: "gcloud-mcp",
+			Kind: client.NotificationProgress, Progress: float64(i + 1),
+		})
+	}
+	var b strings.Builder
+	if err := WriteText(&b, &Run{Tests: []TestResult{test}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  ✅ deploy (3s)\n       +100ms   gcloud-mcp progress 1\n",
+		"+1s      gcloud-mcp progress 10\n",
+		"… 2 more notifications\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "progress 11") {
+		t.Errorf("timeline not capped:\n%s", b.String())
+	}
+}
+
+func TestWriteTextTimelineSteps(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	test := TestResult{ID: "deploy", Started: start, Status: StatusPassed, Duration: 3 * time.Second, Timeline: []client.Notification{
+		{At: start.Add(time.Second), Kind: client.NotificationStep, Progress: 40, Message: "creating bucket"},
+		{At: start.Add(2 * time.Second), Server: "storage-mcp", Kind: client.NotificationProgress, Progress: 1},
+	}}
+	var b strings.Builder
+	if err := WriteText(&b, &Run{Tests: []TestResult{test}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "       +1s      step 40%: creating bucket\n       +2s      storage-mcp progress 1\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextDegraded(t *testing.T) {
+	run := &Run{
+		Tests:    []TestResult{{ID: "gcloud-tool-call", Status: StatusPassed}},
+		Degraded: []Degradation{{Sink: "Cloud Monitoring", Error: "failed to get access token: exit status 1\nstderr"}},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  ⚠️  Cloud Monitoring unavailable, reported locally only: failed to get access token: exit status 1\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextInterrupted(t *testing.T) {
+	run := &Run{
+		Interrupted: "interrupt",
+		Tests:       []TestResult{{ID: "gcloud-tool-call", Status: StatusFailed, Reason: ReasonAborted, Error: "still running when the run was interrupted"}},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"  ⛔ interrupted by interrupt; the results are partial\n", "[aborted]"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteTextUpgrade(t *testing.T) {
+	run := &Run{Harness: "v1.2.0", Upgrade: &Upgrade{Latest: "v1.4.0", Notes: "https://example.com/v1.4.0"}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  ⬆️  harness v1.2.0 is out of date; upgrade to v1.4.0 before trusting mismatches (https://example.com/v1.4.0)\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextArtifactOverage(t *testing.T) {
+	run := &Run{
+		Tests:           []TestResult{{ID: "chatty", Status: StatusFailed, ArtifactOverage: &artifacts.Overage{Budget: 1 << 20, Before: 3 << 20, After: 1 << 19, Compressed: []string{"chatty/output.log"}}}},
+		ArtifactOverage: &artifacts.Overage{Budget: 1 << 10, Before: 1 << 19, After: 1 << 19},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"       📦 artifacts were 3.0MiB, over the 1.0MiB budget; compressed chatty/output.log; now 512.0KiB\n",
+		"  📦 run artifacts were 512.0KiB, over the 1.0KiB budget; now 512.0KiB, still over\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteTextArtifacts(t *testing.T) {
+	run := &Run{Tests: []TestResult{{ID: "bad", Status: StatusFailed, Artifacts: []string{"a/bad/stderr-gcloud.log", "a/bad/tool-outputs.jsonl"}}}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "       📎 artifacts a/bad/stderr-gcloud.log, a/bad/tool-outputs.jsonl\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextLeaked(t *testing.T) {
+	run := &Run{Tests: []TestResult{{ID: "leaky", Status: StatusPassed, Leaked: []subprocess.Process{{PID: 42, Command: []string{"npx", "gcloud-mcp"}}}}}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "       🧟 leaked pid 42: npx gcloud-mcp\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextOrphans(t *testing.T) {
+	created := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	run := &Run{Orphans: []Orphan{
+		{Resource: orphans.Resource{Kind: orphans.KindBucket, Name: "mcp-it-a", Created: created}, Deleted: true},
+		{Resource: orphans.Resource{Kind: orphans.KindLogSink, Name: "mcp-it-b", Created: created}},
+		{Resource: orphans.Resource{Kind: orphans.KindBucket, Name: "mcp-it-7-1-storage-upload", Created: created}, Test: "storage-upload", Deleted: true},
+	}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  🧹 orphaned bucket mcp-it-a, created 2026-09-01: deleted\n",
+		"  🧹 orphaned log sink mcp-it-b, created 2026-09-01: left in place; delete it with -cleanup-orphans\n",
+		"  🧹 bucket mcp-it-7-1-storage-upload left behind by storage-upload: deleted\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteTextPlatformSkip(t *testing.T) {
+	run := &Run{Tests: []TestResult{{ID: "storage-list", Status: StatusSkipped, Reason: ReasonPlatform, Error: "skipped: runs only on linux/amd64, not darwin/arm64"}}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  🚫 storage-list: runs only on linux/amd64, not darwin/arm64\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
+
+func TestWriteTextDrift(t *testing.T) {
+	run := &Run{Drift: []fingerprint.Drift{{Server: "gcloud", Changes: []fingerprint.Change{{Field: "version", Was: "0.3.0", Now: "0.3.1"}}}}}
+	var b strings.Builder
+	if err := WriteText(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  🧬 gcloud environment changed without a config change: version 0.3.0 → 0.3.1\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("text report missing %q:\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,165 @@
+// Package repro writes standalone shell scripts that replay the tool calls of
+// a failed test through the harness's `call` subcommand.
+package repro
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+)
+
+// envPrefixes selects the harness environment variables that influence how
+// servers behave and are therefore baked into scripts.
+var envPrefixes = []string{"GOOGLE_CLOUD_", "CLOUDSDK_", "GOOGLE_APPLICATION_CREDENTIALS", "GOOGLE_GENAI_"}
+
+// maxFailureLines caps how much of the failure is copied into the header.
+const maxFailureLines = 20
+
+// Script returns a POSIX shell script that replays calls. If there are no
+// calls it falls back to rerunning the whole test with fallback.
+func Script(testID, failure string, calls []client.ToolCall, env []string, fallback string) (string, error) {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Reproduces the failure of integration test %s:\n", testID)
+	lines := strings.Split(strings.TrimSpace(failure), "\n")
+	if len(lines) > maxFailureLines {
+		lines = append(lines[:maxFailureLines], "...")
+	}
+	for _, line := range lines {
+		if r := []rune(line); len(r) > 200 {
+			line = string(r[:200]) + "..."
+		}
+		fmt.Fprintf(&b, "#   %s\n", line)
+	}
+	b.WriteString("#\n# Needs the integration-test binary on PATH, or $INTEGRATION_TEST set to it.\n")
+	network := false
+	for i, call := range calls {
+		// `call` can only answer every prompt the same way.
+		if call.Sampling != nil {
+			fmt.Fprintf(&b, "# Call %d answered sampling requests from a script, which cannot be replayed;\n# here the client does not offer sampling.\n", i+1)
+		}
+		if e := call.Elicitation; e != nil {
+			if _, ok := e.Uniform(); !ok {
+				fmt.Fprintf(&b, "# Call %d answered elicitation prompts from a script, which cannot be replayed;\n# here the client does not offer elicitation.\n", i+1)
+			}
+		}
+		for _, e := range call.Endpoints {
+			network = network || e.Transport != client.TransportStdio
+		}
+	}
+	b.WriteString("set -eu\n\n")
+
+	for _, kv := range selectEnv(env) {
+		name, value, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&b, "export %s=%s\n", name, Quote(value))
+	}
+	if network {
+		fmt.Fprintf(&b, "export %s=%s\n", features.EnvVar, client.FeatureNetworkTransports)
+	}
+	b.WriteString(`bin="${INTEGRATION_TEST:-integration-test}"` + "\n\n")
+
+	if len(calls) == 0 {
+		fmt.Fprintf(&b, "\"$bin\" %s\n", strings.TrimPrefix(fallback, "integration-test "))
+		return b.String(), nil
+	}
+	for i, call := range calls {
+		args, err := json.Marshal(call.ToolArgs)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode arguments of call %d: %w", i+1, err)
+		}
+		fmt.Fprintf(&b, "echo '--- call %d/%d: %s'\n", i+1, len(calls), call.ToolName)
+		fmt.Fprintf(&b, "\"$bin\" call -tool %s -args %s", Quote(call.ToolName), Quote(string(args)))
+		if len(call.Meta) > 0 {
+			meta, err := json.Marshal(call.Meta)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode _meta of call %d: %w", i+1, err)
+			}
+			fmt.Fprintf(&b, " -meta %s", Quote(string(meta)))
+		}
+		if call.LogLevel != "" {
+			fmt.Fprintf(&b, " -log-level %s", Quote(call.LogLevel))
+		}
+		if call.ValidateArgs {
+			b.WriteString(" -validate-args")
+		}
+		if call.ValidateOutput {
+			b.WriteString(" -validate-output")
+		}
+		if call.ProtocolVersion != "" {
+			fmt.Fprintf(&b, " -protocol-version %s", Quote(call.ProtocolVersion))
+		}
+		for _, e := range call.Endpoints {
+			fmt.Fprintf(&b, " -endpoint %s", Quote(e.Flag()))
+		}
+		if call.Elicitation != nil {
+			if action, ok := call.Elicitation.Uniform(); ok {
+				fmt.Fprintf(&b, " -elicit %s", Quote(action))
+			}
+		}
+		for _, kv := range call.Env {
+			fmt.Fprintf(&b, " -env %s", Quote(kv))
+		}
+		if call.Dir != "" {
+			fmt.Fprintf(&b, " -dir %s", Quote(call.Dir))
+		}
+		if call.ImpersonateServiceAccount != "" {
+			fmt.Fprintf(&b, " -impersonate %s", Quote(call.ImpersonateServiceAccount))
+		}
+		if e := call.ExpectError; e != nil {
+			b.WriteString(" -expect-error")
+			if e.Message != "" {
+				fmt.Fprintf(&b, " -expect-message %s", Quote(e.Message))
+			}
+		}
+		b.WriteString(" --")
+		for _, arg := range call.ServerCmd {
+			fmt.Fprintf(&b, " %s", Quote(arg))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// Write saves the script for testID as <dir>/<testID>/repro.sh and returns its
+// path.
+func Write(dir, testID, script string) (string, error) {
+	testDir := filepath.Join(dir, testID)
+	if err := os.MkdirAll(testDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(testDir, "repro.sh")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Quote returns s as a single-quoted shell word.
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@", r))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func selectEnv(env []string) []string {
+	var out []string
+	for _, kv := range env {
+		for _, prefix := range envPrefixes {
+			if strings.HasPrefix(kv, prefix) {
+				out = append(out, kv)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
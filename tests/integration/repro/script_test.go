@@ -0,0 +1,135 @@
+package repro
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"gcloud-mcp":     "gcloud-mcp",
+		"":               "''",
+		"a b":            "'a b'",
+		"it's":           `'it'\''s'`,
+		`{"args":["x"]}`: `'{"args":["x"]}'`,
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestScript(t *testing.T) {
+	calls := []client.ToolCall{{
+		ServerCmd: []string{"npx", "-y", "gcloud-mcp"},
+		ToolName:  "run_gcloud_command",
+		ToolArgs:  map[string]any{"args": []string{"config", "list"}},
+	}}
+	env := []string{"HOME=/root", "GOOGLE_CLOUD_PROJECT=gcloud-mcp-testing", "CLOUDSDK_CORE_ACCOUNT=a b"}
+
+	got, err := Script("gcloud-tool-call", "assertion failed", calls, env, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export CLOUDSDK_CORE_ACCOUNT='a b'\nexport GOOGLE_CLOUD_PROJECT=gcloud-mcp-testing\n",
+		`"$bin" call -tool run_gcloud_command -args '{"args":["config","list"]}' -- npx -y gcloud-mcp`,
+		"#   assertion failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "HOME=") {
+		t.Errorf("script leaks unrelated environment:\n%s", got)
+	}
+}
+
+func TestScriptFallback(t *testing.T) {
+	got, err := Script("gemini-mcp-list", "boom", nil, nil, "integration-test -only gemini-mcp-list -fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `"$bin" -only gemini-mcp-list -fast`) {
+		t.Errorf("unexpected fallback script:\n%s", got)
+	}
+}
+
+func TestWriteIsValidShell(t *testing.T) {
+	script, err := Script("t", "it's broken", []client.ToolCall{{ServerCmd: []string{"srv"}, ToolName: "x", ToolArgs: map[string]any{"q": "'; rm -rf /"}}}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := Write(t.TempDir(), "t", script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "repro.sh" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode()&0o100 == 0 {
+		t.Errorf("script is not executable: %v", err)
+	}
+	if out, err := exec.Command("sh", "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("sh -n: %v\n%s", err, out)
+	}
+}
+
+func TestScriptCredentials(t *testing.T) {
+	calls := []client.ToolCall{{
+		ServerCmd:                 []string{"gcloud-mcp"},
+		ToolName:                  "run_gcloud_command",
+		ToolArgs:                  map[string]any{"args": []string{"storage", "ls"}},
+		Env:                       []string{"CLOUDSDK_CORE_DISABLE_PROMPTS=1", "API_KEY=${file:/run/secrets/key}"},
+		Dir:                       "/srv/gcloud",
+		ImpersonateServiceAccount: "denied@p.iam.gserviceaccount.com",
+		Meta:                      map[string]any{"progressToken": "t1"},
+		LogLevel:                  "debug",
+	}}
+	got, err := Script("gcloud-iam-denied", "boom", calls, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `-meta '{"progressToken":"t1"}' -log-level debug -env CLOUDSDK_CORE_DISABLE_PROMPTS=1 -env 'API_KEY=${file:/run/secrets/key}' -dir /srv/gcloud -impersonate denied@p.iam.gserviceaccount.com --`; !strings.Contains(got, want) {
+		t.Errorf("script missing %q:\n%s", want, got)
+	}
+}
+
+func TestScriptEndpointsAndElicitation(t *testing.T) {
+	calls := []client.ToolCall{
+		{
+			ServerCmd:   []string{"storage-mcp"},
+			ToolName:    "delete_bucket",
+			Endpoints:   []client.Endpoint{{Transport: client.TransportSSE, URL: "http://localhost:8080/sse"}, {Transport: client.TransportStdio}},
+			Elicitation: client.DenyElicitations(),
+		},
+		{
+			ServerCmd:   []string{"storage-mcp"},
+			ToolName:    "delete_object",
+			Elicitation: &client.ElicitationScript{Rules: []client.ElicitationRule{{Pattern: regexp.MustCompile(`delete`), Action: client.ElicitAccept}}},
+		},
+	}
+	got, err := Script("storage-delete-declined", "boom", calls, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export INTEGRATION_FEATURES=network-transports\n",
+		"-endpoint sse=http://localhost:8080/sse -endpoint stdio -elicit decline --",
+		"# Call 2 answered elicitation prompts from a script, which cannot be replayed;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script missing %q:\n%s", want, got)
+		}
+	}
+	if second := got[strings.LastIndex(got, "call -tool delete_object"):]; strings.Contains(second, "-elicit") {
+		t.Errorf("scripted elicitation replayed as a uniform answer:\n%s", second)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/exampleserver"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The *-resource-link tests follow the resource_link content a tool returns
+// with resources/read in the same session, checking that every link a server
+// hands out resolves to the resource it names.
+
+// storageBucket is a bucket storage-mcp can read, whose objects the
+// storage-resource-link test follows links to. -use-emulators defaults it to
+// the emulator's seeded bucket.
+var storageBucket = os.Getenv("STORAGE_TEST_BUCKET")
+
+// maxFollowedLinks caps how many links of one result are read, so a large
+// bucket does not turn one test into hundreds of reads.
+const maxFollowedLinks = 3
+
+func testExampleResourceLink(t *testContext) error {
+	logger.Println("🚀 Starting example server resource link test...")
+	session, err := t.openSession(client.ToolCall{ServerCmd: exampleServerCmd()})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	result, err := session.CallTool("find_note", map[string]any{})
+	if err != nil {
+		return fmt.Errorf("error calling find_note: %w", err)
+	}
+	contents, err := followLinks(session, result)
+	if err != nil {
+		return err
+	}
+	if len(contents) != 1 {
+		return report.Fail(report.ReasonAssertion, "assertion failed: find_note returned %d resource links, want 1: %s", len(contents), result.Output)
+	}
+	if err := report.Compare("assertion failed: linked note has different text", exampleserver.NoteText, contents[0].Text); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: find_note linked to %s and resources/read returned it\n", contents[0].URI)
+	return nil
+}
+
+func testStorageResourceLink(t *testContext) error {
+	logger.Println("🚀 Starting storage-mcp resource link integration test...")
+	if storageBucket == "" {
+		return report.Skip("no test bucket; pass -use-emulators to use the emulator's, or set -storage-bucket or $STORAGE_TEST_BUCKET")
+	}
+	session, err := t.openSession(client.ToolCall{ServerCmd: storageServer.Command})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	t.Progress("listing objects", 0)
+	result, err := session.CallTool("list_objects", map[string]any{"bucket_name": storageBucket})
+	if err != nil {
+		return fmt.Errorf("error calling list_objects: %w", err)
+	}
+	if result.IsError {
+		return report.Fail(report.ReasonToolError, "list_objects failed: %s", result.Output)
+	}
+	links, err := client.ResourceLinks(result)
+	if err != nil {
+		return report.Fail(report.ReasonParse, "%v\nOutput: %s", err, result.Output)
+	}
+	var listed struct {
+		Objects []string `json:"objects"`
+	}
+	if err := json.Unmarshal([]byte(result.Text()), &listed); err != nil {
+		return report.Fail(report.ReasonParse, "list_objects did not return JSON: %v\nOutput: %s", err, result.Text())
+	}
+	if len(listed.Objects) == 0 {
+		return report.Skip("gs://%s has no objects to follow links to", storageBucket)
+	}
+	// storage-mcp links every object it lists.
+	if len(links) != len(listed.Objects) {
+		return report.Fail(report.ReasonAssertion, "assertion failed: list_objects listed %d objects of gs://%s but returned %d resource links", len(listed.Objects), storageBucket, len(links))
+	}
+	t.Progress(fmt.Sprintf("following %d resource links", len(links)), 20)
+	contents, err := followLinks(session, result)
+	if err != nil {
+		return err
+	}
+	// The linked content must match what the tool itself reads for the
+	// object.
+	for i, c := range contents {
+		t.Progress(fmt.Sprintf("reading object %d of %d", i+1, len(contents)), 40+60*i/len(contents))
+		object, ok := strings.CutPrefix(c.URI, "gcs://"+storageBucket+"/")
+		if !ok {
+			continue
+		}
+		read, err := session.CallTool("read_object_content", map[string]any{"bucket_name": storageBucket, "object_name": object})
+		if err != nil {
+			return fmt.Errorf("error calling read_object_content: %w", err)
+		}
+		text, err := resultText(read)
+		if err != nil {
+			return err
+		}
+		var want struct {
+			ContentType string `json:"content_type"`
+			Content     string `json:"content"`
+		}
+		if err := json.Unmarshal([]byte(text), &want); err != nil {
+			return report.Fail(report.ReasonParse, "error parsing read_object_content result: %v\nText: %s", err, text)
+		}
+		if err := report.Compare("assertion failed: resource "+c.URI+" differs from the object's content", want.Content, c.Text); err != nil {
+			return err
+		}
+		if c.MIMEType != "" && want.ContentType != "" && !strings.HasPrefix(want.ContentType, c.MIMEType) {
+			return report.Fail(report.ReasonAssertion, "assertion failed: resource %s has MIME type %s, the object %s", c.URI, c.MIMEType, want.ContentType)
+		}
+	}
+	logger.Printf("✅ Assertion passed: %d resource links from list_objects resolved to their objects\n", len(contents))
+	return nil
+}
+
+// followLinks reads up to maxFollowedLinks of the resource links in result
+// with resources/read in session and returns the contents, one per link. A
+// link fails the test if reading it fails, returns nothing, returns another
+// URI, or returns a MIME type other than the one the link declared.
+func followLinks(session *client.Session, result *client.Result) ([]*mcp.ResourceContents, error) {
+	if result.IsError {
+		return nil, report.Fail(report.ReasonToolError, "tool failed: %s", result.Output)
+	}
+	links, err := client.ResourceLinks(result)
+	if err != nil {
+		return nil, report.Fail(report.ReasonParse, "%v\nOutput: %s", err, result.Output)
+	}
+	if len(links) > maxFollowedLinks {
+		logger.Printf("ℹ️  Following the first %d of %d resource links\n", maxFollowedLinks, len(links))
+		links = links[:maxFollowedLinks]
+	}
+	var contents []*mcp.ResourceContents
+	for _, link := range links {
+		res, err := session.ReadResource(link.URI)
+		if err != nil {
+			return nil, report.Fail(report.ReasonAssertion, "assertion failed: resource link does not resolve: %v", err)
+		}
+		if len(res.Contents) == 0 {
+			return nil, report.Fail(report.ReasonAssertion, "assertion failed: resources/read returned no contents for %s", link.URI)
+		}
+		c := res.Contents[0]
+		if err := report.Compare("assertion failed: resources/read returned another resource than the link named", link.URI, c.URI); err != nil {
+			return nil, err
+		}
+		if link.MIMEType != "" && c.MIMEType != "" && link.MIMEType != c.MIMEType {
+			return nil, report.Fail(report.ReasonAssertion, "assertion failed: link to %s declares MIME type %s, resources/read returned %s", link.URI, link.MIMEType, c.MIMEType)
+		}
+		logger.Printf("🔗 Followed %s (%s, %d bytes)\n", link.URI, c.MIMEType, len(c.Text)+len(c.Blob))
+		contents = append(contents, c)
+	}
+	return contents, nil
+}
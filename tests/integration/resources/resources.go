@@ -0,0 +1,190 @@
+// Package resources samples the memory and CPU use of the servers the
+// harness runs, so a server that leaks memory or spins on a request fails
+// its test instead of slowly degrading Gemini CLI sessions.
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// ErrUnsupported is returned by GroupUsage where process groups cannot be
+// sampled.
+var ErrUnsupported = errors.New("sampling process resources is not supported on this platform")
+
+// Usage is what a process group has used at one point in time.
+type Usage struct {
+	// RSS is the resident memory of the group's processes, in bytes.
+	RSS int64
+	// CPU is the user and system time the group's processes have used so
+	// far.
+	CPU time.Duration
+}
+
+// Process is a running member of a process group.
+type Process struct {
+	PID     int
+	Command []string
+}
+
+// Peak is the most a server's processes used while they were sampled.
+type Peak struct {
+	// Server is the executable the processes were started as.
+	Server string `json:"server"`
+	// RSS is the highest resident memory of any one of them, in bytes.
+	RSS int64 `json:"peak_rss_bytes"`
+	// CPU is the CPU time they used in total.
+	CPU time.Duration `json:"cpu_ns"`
+	// CPUPercent is the highest CPU use of any one of them between two
+	// samples, where 100 is one core.
+	CPUPercent float64 `json:"peak_cpu_percent"`
+}
+
+func (p Peak) String() string {
+	return fmt.Sprintf("%s: peak RSS %s, CPU %s (peak %.0f%%)", p.Server, FormatBytes(p.RSS), p.CPU.Round(time.Millisecond), p.CPUPercent)
+}
+
+// FormatBytes formats n bytes in MiB, e.g. 512.0 MiB.
+func FormatBytes(n int64) string {
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
+
+// Merge combines the peaks of more into peaks, by server: the highest RSS
+// and CPU use, and the CPU time added up. The result is sorted by server.
+func Merge(peaks, more []Peak) []Peak {
+	for _, m := range more {
+		i := slices.IndexFunc(peaks, func(p Peak) bool { return p.Server == m.Server })
+		if i < 0 {
+			peaks = append(peaks, m)
+			continue
+		}
+		p := &peaks[i]
+		p.RSS = max(p.RSS, m.RSS)
+		p.CPU += m.CPU
+		p.CPUPercent = max(p.CPUPercent, m.CPUPercent)
+	}
+	slices.SortFunc(peaks, func(a, b Peak) int { return strings.Compare(a.Server, b.Server) })
+	return peaks
+}
+
+// Sampler samples the process groups of a Manager's running processes at an
+// interval until it is stopped, and records what each process it reaps used
+// in total, so a server that exits between two samples is still counted.
+type Sampler struct {
+	// Interval is the time between samples.
+	Interval time.Duration
+	// Manager runs the processes to sample. Defaults to subprocess.Default.
+	Manager *subprocess.Manager
+	// Usage samples a process group. Defaults to GroupUsage.
+	Usage func(pgid int) (Usage, error)
+
+	mu      sync.Mutex
+	tracked map[int]*tracked
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// tracked is what one process group was seen using.
+type tracked struct {
+	server     string
+	cpu        time.Duration
+	at         time.Time
+	rss        int64
+	cpuPercent float64
+}
+
+// Start takes a first sample and keeps sampling in the background.
+func (s *Sampler) Start() {
+	if s.Manager == nil {
+		s.Manager = subprocess.Default
+	}
+	if s.Usage == nil {
+		s.Usage = GroupUsage
+	}
+	s.tracked = make(map[int]*tracked)
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	s.Manager.OnExit(s.exited)
+	s.sample()
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.sample()
+			}
+		}
+	}()
+}
+
+// Stop takes a last sample and returns the peaks of every server sampled,
+// sorted by server.
+func (s *Sampler) Stop() []Peak {
+	close(s.stop)
+	<-s.done
+	s.sample()
+	s.Manager.OnExit(nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var peaks []Peak
+	for _, t := range s.tracked {
+		peaks = Merge(peaks, []Peak{{Server: t.server, RSS: t.rss, CPU: t.cpu, CPUPercent: t.cpuPercent}})
+	}
+	return peaks
+}
+
+func (s *Sampler) sample() {
+	now := time.Now()
+	for _, p := range s.Manager.Running() {
+		u, err := s.Usage(p.PID)
+		if err != nil {
+			// The process exited since it was listed.
+			continue
+		}
+		s.mu.Lock()
+		t := s.track(p)
+		if !t.at.IsZero() && now.After(t.at) {
+			t.cpuPercent = max(t.cpuPercent, 100*float64(u.CPU-t.cpu)/float64(now.Sub(t.at)))
+		}
+		t.rss = max(t.rss, u.RSS)
+		// The CPU time of a group drops when a member exits.
+		t.cpu = max(t.cpu, u.CPU)
+		t.at = now
+		s.mu.Unlock()
+	}
+}
+
+// exited records the totals of a process the Manager reaped, which include
+// those of the children it reaped in turn.
+func (s *Sampler) exited(p subprocess.Process, state *os.ProcessState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.track(p)
+	t.rss = max(t.rss, maxRSS(state))
+	t.cpu = max(t.cpu, state.UserTime()+state.SystemTime())
+}
+
+// track returns what p has been seen using. s.mu must be held.
+func (s *Sampler) track(p subprocess.Process) *tracked {
+	t := s.tracked[p.PID]
+	if t == nil {
+		server := "?"
+		if len(p.Command) > 0 {
+			server = filepath.Base(p.Command[0])
+		}
+		t = &tracked{server: server}
+		s.tracked[p.PID] = t
+	}
+	return t
+}
@@ -0,0 +1,68 @@
+package resources
+
+import (
+	"os/exec"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+func TestMerge(t *testing.T) {
+	got := Merge(
+		[]Peak{{Server: "gcloud", RSS: 100, CPU: time.Second, CPUPercent: 50}},
+		[]Peak{{Server: "observability", RSS: 300}, {Server: "gcloud", RSS: 50, CPU: 2 * time.Second, CPUPercent: 90}},
+	)
+	want := []Peak{
+		{Server: "gcloud", RSS: 100, CPU: 3 * time.Second, CPUPercent: 90},
+		{Server: "observability", RSS: 300},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		usage = Usage{RSS: 10 << 20}
+	)
+	m := &subprocess.Manager{}
+	cmd := exec.Command("sleep", "60")
+	if err := m.Start(cmd); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		m.KillAll()
+		m.Wait(cmd)
+	}()
+	s := &Sampler{
+		Interval: time.Millisecond,
+		Manager:  m,
+		Usage: func(pgid int) (Usage, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return usage, nil
+		},
+	}
+	s.Start()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	// The server grows and uses CPU, then frees most of its memory.
+	usage = Usage{RSS: 500 << 20, CPU: 20 * time.Millisecond}
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	usage.RSS = 20 << 20
+	mu.Unlock()
+	peaks := s.Stop()
+	if len(peaks) != 1 {
+		t.Fatalf("Stop() = %+v, want one server", peaks)
+	}
+	p := peaks[0]
+	if p.Server != "sleep" || p.RSS != 500<<20 || p.CPU != 20*time.Millisecond || p.CPUPercent <= 0 {
+		t.Errorf("peak = %+v", p)
+	}
+}
@@ -0,0 +1,113 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clockTicks is the kernel's USER_HZ, the unit of CPU times in
+// /proc/<pid>/stat, which is 100 on every supported architecture.
+const clockTicks = 100
+
+// GroupUsage returns the usage of the processes in process group pgid, read
+// from /proc.
+func GroupUsage(pgid int) (Usage, error) {
+	var (
+		total Usage
+		found bool
+	)
+	err := eachInGroup(pgid, func(_ int, u Usage) {
+		found = true
+		total.RSS += u.RSS
+		total.CPU += u.CPU
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no process in group %d", pgid)
+	}
+	return total, err
+}
+
+// GroupProcesses returns the processes in process group pgid, read from
+// /proc, e.g. to check that a server killed a child it started.
+func GroupProcesses(pgid int) ([]Process, error) {
+	var procs []Process
+	err := eachInGroup(pgid, func(pid int, _ Usage) {
+		cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+		if err != nil {
+			// The process exited since its stat was read.
+			return
+		}
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		procs = append(procs, Process{PID: pid, Command: args})
+	})
+	return procs, err
+}
+
+// eachInGroup calls fn with each process in group pgid and its usage.
+func eachInGroup(pgid int, fn func(pid int, u Usage)) error {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return err
+	}
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The process exited after the glob.
+			continue
+		}
+		group, u, err := parseStat(data)
+		if err != nil || group != pgid {
+			continue
+		}
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			continue
+		}
+		fn(pid, u)
+	}
+	return nil
+}
+
+// parseStat returns the process group and the usage of the process a
+// /proc/<pid>/stat describes.
+func parseStat(data []byte) (pgid int, u Usage, err error) {
+	// The command name in parentheses may contain spaces, so the fields are
+	// counted from after it.
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, Usage{}, fmt.Errorf("malformed stat %q", data)
+	}
+	fields := bytes.Fields(data[end+1:])
+	// state is field 3 of proc(5), so field n is fields[n-3].
+	if len(fields) < 22 {
+		return 0, Usage{}, fmt.Errorf("malformed stat %q", data)
+	}
+	num := func(n int) int64 {
+		v, e := strconv.ParseInt(string(fields[n-3]), 10, 64)
+		if e != nil && err == nil {
+			err = fmt.Errorf("malformed stat field %d: %w", n, e)
+		}
+		return v
+	}
+	pgid = int(num(5))
+	ticks := num(14) + num(15)
+	u.CPU = time.Duration(ticks) * time.Second / clockTicks
+	u.RSS = num(24) * int64(os.Getpagesize())
+	return pgid, u, err
+}
+
+// maxRSS returns the peak resident memory of a reaped process and the
+// children it reaped.
+func maxRSS(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// Linux reports it in KiB.
+		return ru.Maxrss << 10
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package resources
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+func TestParseStat(t *testing.T) {
+	// The command name of pid 1234 contains a space and a parenthesis.
+	stat := "1234 (my (server)) S 1 1234 1234 0 -1 4194560 100 0 0 0 150 50 0 0 20 0 3 0 100 1000000 2560 18446744073709551615"
+	pgid, u, err := parseStat([]byte(stat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgid != 1234 || u.CPU != 2*time.Second || u.RSS != 2560*int64(os.Getpagesize()) {
+		t.Errorf("parseStat() = %d, %+v", pgid, u)
+	}
+	if _, _, err := parseStat([]byte("1234 (server) S 1")); err == nil {
+		t.Error("parseStat() of a truncated stat succeeded")
+	}
+}
+
+func TestGroupUsage(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 60 & sleep 60")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	u, err := GroupUsage(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.RSS <= 0 {
+		t.Errorf("GroupUsage() = %+v, want some memory", u)
+	}
+	procs, err := GroupProcesses(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Some shells exec the last command in place of themselves.
+	var sleeps int
+	for _, p := range procs {
+		if slices.Equal(p.Command, []string{"sleep", "60"}) {
+			sleeps++
+		}
+	}
+	if sleeps < 1 || !slices.ContainsFunc(procs, func(p Process) bool { return p.PID == cmd.Process.Pid }) {
+		t.Errorf("GroupProcesses() = %+v, want sh and its sleeps", procs)
+	}
+	if _, err := GroupUsage(1 << 30); err == nil {
+		t.Error("GroupUsage() of a missing group succeeded")
+	}
+}
+
+// TestSamplerExited checks that a server that exits before the first sample
+// is still counted, from what it used by the time it was reaped.
+func TestSamplerExited(t *testing.T) {
+	m := &subprocess.Manager{}
+	s := &Sampler{Interval: time.Hour, Manager: m}
+	s.Start()
+	if err := m.Run(exec.Command("sh", "-c", "true")); err != nil {
+		t.Fatal(err)
+	}
+	peaks := s.Stop()
+	if len(peaks) != 1 || peaks[0].Server != "sh" || peaks[0].RSS <= 0 {
+		t.Errorf("Stop() = %+v, want the memory sh used", peaks)
+	}
+}
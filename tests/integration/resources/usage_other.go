@@ -0,0 +1,22 @@
+//go:build !linux
+
+package resources
+
+import "os"
+
+// GroupUsage returns ErrUnsupported: only Linux is sampled, through /proc.
+func GroupUsage(pgid int) (Usage, error) {
+	return Usage{}, ErrUnsupported
+}
+
+// GroupProcesses returns ErrUnsupported: only Linux is sampled, through
+// /proc.
+func GroupProcesses(pgid int) ([]Process, error) {
+	return nil, ErrUnsupported
+}
+
+// maxRSS returns 0: the unit of a reaped process's peak memory varies by
+// platform.
+func maxRSS(*os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/tracing"
+)
+
+// runTrace, if set, collects the spans of the run for -otlp-endpoint.
+var runTrace *tracing.Trace
+
+// traceTest records an attempt at a test as a span with one child per tool
+// call, split into its connect and call phases, and an "assert" span for
+// the time after its last call.
+func traceTest(result report.TestResult, calls []client.Invocation) {
+	if runTrace == nil {
+		return
+	}
+	end := result.Started.Add(result.Duration)
+	test := runTrace.Add(tracing.Span{
+		Parent: runTrace.Root,
+		Name:   "test " + result.ID,
+		Start:  result.Started,
+		End:    end,
+		Attributes: map[string]any{
+			"test.id":     result.ID,
+			"test.status": string(result.Status),
+			"test.reason": result.Reason,
+		},
+		Error: failure(result.Status == report.StatusFailed, result.Error),
+	})
+	last := result.Started
+	for _, inv := range calls {
+		m := inv.Metrics
+		if m.Started.IsZero() {
+			continue
+		}
+		name := "session " + m.Server
+		if m.Tool != "" {
+			name = "tools/call " + m.Tool
+		}
+		callEnd := m.Started.Add(m.Total)
+		var msg string
+		if inv.Err != nil {
+			msg = redactor.String(inv.Err.Error())
+		}
+		call := runTrace.Add(tracing.Span{
+			Parent:     test,
+			Name:       name,
+			Start:      m.Started,
+			End:        callEnd,
+			Attributes: map[string]any{"mcp.server": m.Server, "mcp.tool": m.Tool},
+			Error:      msg,
+		})
+		if m.Connect > 0 {
+			runTrace.Add(tracing.Span{Parent: call, Name: "connect", Start: m.Started, End: m.Started.Add(m.Connect)})
+		}
+		if m.Tool != "" && callEnd.After(m.Started.Add(m.Connect)) {
+			runTrace.Add(tracing.Span{
+				Parent:     call,
+				Name:       "call",
+				Start:      m.Started.Add(m.Connect),
+				End:        callEnd,
+				Attributes: map[string]any{"mcp.first_response_ms": m.FirstResponse.Milliseconds()},
+			})
+		}
+		if callEnd.After(last) {
+			last = callEnd
+		}
+	}
+	if len(calls) > 0 && end.After(last) {
+		runTrace.Add(tracing.Span{Parent: test, Name: "assert", Start: last, End: end})
+	}
+}
+
+// traceSuiteSetUp records a suite's beforeAll.
+func traceSuiteSetUp(suite string, start time.Time, err error) {
+	if runTrace == nil {
+		return
+	}
+	var msg string
+	if err != nil {
+		msg = redactor.String(err.Error())
+	}
+	runTrace.Add(tracing.Span{
+		Parent:     runTrace.Root,
+		Name:       "beforeAll " + suite,
+		Start:      start,
+		End:        time.Now(),
+		Attributes: map[string]any{"suite": suite},
+		Error:      msg,
+	})
+}
+
+// otlpSink exports the run's trace, ending it with the run's span.
+func otlpSink(exporter *tracing.Exporter) reportSink {
+	return reportSink{
+		name: "OTLP traces",
+		publish: func(ctx context.Context, run *report.Run) error {
+			passed, failed, skipped := run.Counts()
+			attrs := map[string]any{
+				"run.seed":     run.Seed,
+				"run.harness":  run.Harness,
+				"run.platform": run.Platform,
+				"run.passed":   passed,
+				"run.failed":   failed,
+				"run.skipped":  skipped,
+			}
+			for k, v := range run.Labels {
+				attrs["run.label."+k] = v
+			}
+			runTrace.Add(tracing.Span{
+				ID:         runTrace.Root,
+				Name:       "run",
+				Start:      run.Started,
+				End:        run.Started.Add(run.Duration),
+				Attributes: attrs,
+				Error:      failure(failed > 0, fmt.Sprintf("%d tests failed", failed)),
+			})
+			return exporter.Export(ctx, runTrace)
+		},
+		published: fmt.Sprintf("🔭 Exported trace %s to %s", runTrace.ID, exporter.Endpoint),
+	}
+}
+
+// failure returns msg if failed, else "".
+func failure(failed bool, msg string) string {
+	if !failed {
+		return ""
+	}
+	return msg
+}
@@ -0,0 +1,1016 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/artifacts"
+	"github.com/googleapis/gcloud-mcp/tests/integration/billing"
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+	"github.com/googleapis/gcloud-mcp/tests/integration/chaos"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudconfig"
+	"github.com/googleapis/gcloud-mcp/tests/integration/oracle"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/quarantine"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/repro"
+	"github.com/googleapis/gcloud-mcp/tests/integration/resources"
+	"github.com/googleapis/gcloud-mcp/tests/integration/runner"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+type testCase struct {
+	id string
+	// requires lists the executables the test needs on PATH.
+	requires []string
+	run      func(*testContext) error
+	// suite, if set, is the suite whose hooks wrap the test.
+	suite *testSuite
+	// platforms, if set, are the only platforms the test runs on; elsewhere
+	// it is skipped with reason platform_unsupported.
+	platforms platform.Constraint
+	// steps, if set, describe what the test does for -dry-run to list.
+	steps []string
+	// tags group the test with others for -tags and -skip-tags, in addition
+	// to those testTags derives from its requirements.
+	tags []string
+	// checksProject marks a test that asserts the project gcloud is
+	// configured with; see sandboxFor.
+	checksProject bool
+}
+
+// testContext is handed to each running test.
+type testContext struct {
+	id string
+	// board is shared by every test in the run. Publish with id as the
+	// publisher so consumers can tell where a value came from.
+	board *blackboard.Board
+	// rand is the test's only source of randomness. It is seeded from the run
+	// seed and the test ID, so a rerun with the same seed draws the same
+	// values regardless of which tests run before it.
+	rand *rand.Rand
+	// progress collects the steps the test reports with Progress.
+	progress *progress
+	// artifactsDir is the test's directory under -artifacts, if set, and
+	// artifacts the files SaveArtifact wrote to it.
+	artifactsDir string
+	artifacts    []string
+	// project is the project the test's servers are configured with, if not
+	// testProject; see Project.
+	project string
+	// env is added to the environment of every server the test starts,
+	// before the call's own: its gcloud sandbox's, if any.
+	env []string
+}
+
+// ResourceName returns a new name for a resource the test creates, scoped to
+// the run and recorded, so the harness deletes the resource after the run if
+// the test's own cleanup missed it. Tests name everything they create with
+// it.
+func (t *testContext) ResourceName() string {
+	return resourceNames.Name(t.id)
+}
+
+// Project returns the project the test's servers are configured with: the
+// ephemeral project if the test is destructive and -ephemeral-project is
+// set, else testProject. Tests name it in the arguments of their calls.
+func (t *testContext) Project() string {
+	if t.project != "" {
+		return t.project
+	}
+	return testProject
+}
+
+// checkRequirements verifies that every executable the given tests need is on
+// PATH, and that the environment a registered server needs is set and its
+// manifest env resolves, so a missing install is reported up front instead
+// of mid-test.
+func checkRequirements(tests []testCase) error {
+	for _, tc := range supported(tests, platform.Current()) {
+		for _, bin := range tc.requires {
+			if _, err := exec.LookPath(bin); err != nil {
+				return report.Fail(report.ReasonPrerequisite, "test %s requires %q: %v", tc.id, bin, err)
+			}
+			if s := serverRegistry.ByBin(bin); s != nil {
+				if missing := s.MissingEnv(); len(missing) > 0 {
+					return report.Fail(report.ReasonPrerequisite, "test %s requires %s, which needs $%s set", tc.id, s.Name, strings.Join(missing, ", $"))
+				}
+			}
+			if s := servers.ByBin(bin); s != nil {
+				if _, _, err := s.Environ(); err != nil {
+					return report.Fail(report.ReasonPrerequisite, "test %s requires %s: %v", tc.id, s.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// supported returns the tests whose platform constraint allows p.
+func supported(tests []testCase, p platform.Platform) []testCase {
+	return slices.DeleteFunc(slices.Clone(tests), func(tc testCase) bool { return !tc.platforms.Allows(p) })
+}
+
+// skipUnsupported returns the result of a test that does not run on p.
+func skipUnsupported(tc testCase, p platform.Platform) report.TestResult {
+	logger.Printf("🚫 %s runs only on %s, not %s; skipping it\n", tc.id, tc.platforms, p)
+	return runner.SkipUnsupported(tc.id, tc.platforms, p)
+}
+
+func findTest(id string) (testCase, bool) {
+	for _, tc := range testCases {
+		if tc.id == id {
+			return tc, true
+		}
+	}
+	return testCase{}, false
+}
+
+// platformTestIDs returns the IDs of the registered tests that run on p,
+// which are all a run on p can cover.
+func platformTestIDs(p platform.Platform) []string {
+	var ids []string
+	for _, tc := range supported(testCases, p) {
+		ids = append(ids, tc.id)
+	}
+	return ids
+}
+
+func testIDs() []string {
+	ids := make([]string, len(testCases))
+	for i, tc := range testCases {
+		ids[i] = tc.id
+	}
+	return ids
+}
+
+// runOptions controls how runTests executes and records tests.
+type runOptions struct {
+	// stopOnFailure returns after the first failing test.
+	stopOnFailure bool
+	// artifactsDir, if set, receives a repro script and the output of every
+	// test that did not pass cleanly, kept within artifactBudget.
+	artifactsDir   string
+	artifactBudget artifacts.Budget
+	// seed seeds every test's rand. Zero picks a random seed, which is
+	// recorded in the results so the run can be replayed.
+	seed int64
+	// quarantine excuses the failures of listed tests until their entry
+	// expires; entries expiring within quarantineWarning are warned about.
+	quarantine        *quarantine.List
+	quarantineWarning time.Duration
+	// gcloudSandbox, if set, runs every test against a gcloud configuration
+	// directory of its own, created from these options and removed after the
+	// test.
+	gcloudSandbox *gcloudconfig.Options
+	// mutate replays every passing test against mutants of the responses it
+	// received and fails it if one survives.
+	mutate bool
+	// retries is how many times a failing test is rerun. One that passes on a
+	// retry is recorded as flaky.
+	retries int
+	// prewarm is how many suites with an estimate are set up at a time
+	// before the first test; zero sets every suite up with its first test.
+	prewarm int
+	// deadline, if set, is when the watchdog ends the run.
+	deadline time.Time
+	// wireTrace records every JSON-RPC message of each test's tool calls in
+	// wire.jsonl in its artifacts directory, one file per attempt.
+	wireTrace bool
+	// resourceInterval is how often the memory and CPU of each test's
+	// servers are sampled; zero does not sample them.
+	resourceInterval time.Duration
+	// watchdog, if set, is told which test is running, so it can report the
+	// test and the results so far if the run hangs.
+	watchdog *watchdog
+	// billing, if set, is the run's budget for billable operations. Once a
+	// call exceeds it, the tests after the one making it are not started.
+	billing *billing.Budget
+	// ephemeral, if set, runs the tests tagged destructive in a project of
+	// their own; see ephemeralProject.
+	ephemeral *ephemeralProject
+}
+
+// runTests runs tests one at a time in the given order and records their
+// outcome.
+func runTests(tests []testCase, opts runOptions) *report.Run {
+	seed := runner.Seed(opts.seed)
+	current := platform.Current()
+	run := &report.Run{Started: time.Now(), Seed: seed, Platform: current.String()}
+	// Unsupported tests never start, so they do not hold a suite open.
+	hooks := newSuiteRuns(supported(tests, current), blackboard.New(), seed)
+	hooks.deadline = opts.deadline
+	hooks.prewarm(opts.prewarm, func() (gcloudSandbox, error) { return newGcloudSandbox(opts.gcloudSandbox) })
+	board := hooks.board
+
+	for _, tc := range tests {
+		if !tc.platforms.Allows(current) {
+			run.Tests = append(run.Tests, skipUnsupported(tc, current))
+			continue
+		}
+		if err := opts.billing.Exceeded(); err != nil {
+			run.Tests = append(run.Tests, report.TestResult{
+				ID: tc.id, Started: time.Now(), Status: report.StatusSkipped, Reason: report.ReasonBilling,
+				Error: fmt.Sprintf("not run: the run was aborted: %v", err),
+			})
+			continue
+		}
+		var (
+			result   report.TestResult
+			failures []report.Attempt
+			log      strings.Builder
+		)
+		opts.watchdog.record(run, tc.id)
+		before := board.Clone()
+		for attempt := 1; ; attempt++ {
+			if attempt > 1 {
+				board.Restore(before)
+				logger.Printf("🔁 Retrying %s (attempt %d of %d)\n", tc.id, attempt, opts.retries+1)
+			}
+			var retry bool
+			result, retry = runAttempt(tc, opts, run, hooks, attempt, &log)
+			if !retry {
+				break
+			}
+			failures = append(failures, report.Attempt{Reason: result.Reason, Error: result.Error, Duration: result.Duration, WireTrace: result.WireTrace})
+		}
+		if len(failures) > 0 {
+			classifyRetried(&result, failures)
+		}
+		if entry := opts.quarantine.Lookup(tc.id); entry != nil {
+			applyQuarantine(&result, entry, run.Started, opts.quarantineWarning)
+		}
+		if opts.artifactsDir != "" {
+			writeArtifacts(&result, opts)
+		}
+		run.Tests = append(run.Tests, result)
+		if result.Status == report.StatusFailed && opts.stopOnFailure {
+			break
+		}
+	}
+	opts.watchdog.record(run, "")
+	hooks.close()
+	opts.ephemeral.teardown()
+	if opts.artifactsDir != "" {
+		dirs := make([]string, len(run.Tests))
+		for i, t := range run.Tests {
+			dirs[i] = filepath.Join(opts.artifactsDir, t.ID)
+		}
+		overage, err := opts.artifactBudget.EnforceRun(dirs)
+		if err != nil {
+			logf(slog.LevelError, "❌ error enforcing the run's artifact budget: %v\n", err)
+		}
+		if overage != nil {
+			logger.Printf("📦 Run artifacts were %s\n", overage)
+			run.ArtifactOverage = overage
+			for _, t := range run.Tests {
+				compressedArtifacts(t.Artifacts)
+			}
+		}
+	}
+
+	runner.Summarize(run, board, client.DefaultRecorder.Calls())
+	run.Resources = report.SummarizeResources(run.Tests)
+	run.Billing = opts.billing.Usage()
+	return run
+}
+
+// runAttempt runs tc once, as its attempt'th attempt, and returns its result.
+// If retries remain and the test itself failed, it reports that the test
+// should be retried; a failing cleanup hook, stdout pollution or surviving
+// mutant is not retried. log accumulates the output of every attempt, which
+// result.Log holds.
+func runAttempt(tc testCase, opts runOptions, run *report.Run, hooks *suiteRuns, attempt int, log *strings.Builder) (result report.TestResult, retry bool) {
+	board, seed := hooks.board, run.Seed
+	console := logger.Writer()
+	defer logger.SetOutput(console)
+	var captured bytes.Buffer
+	logger.SetOutput(&teeLog{out: console, capture: &captured})
+	start := time.Now()
+	callsBefore := len(client.DefaultRecorder.Invocations())
+	var (
+		boardBefore *blackboard.Board
+		recorded    []recordedCall
+	)
+	stopTrace := func() string { return "" }
+	if opts.wireTrace {
+		stopTrace = startWireTrace(opts.artifactsDir, tc.id, attempt)
+	}
+	steps := &progress{test: tc.id, started: start}
+	var sampler *resources.Sampler
+	if opts.resourceInterval > 0 {
+		sampler = &resources.Sampler{Interval: opts.resourceInterval}
+		sampler.Start()
+	}
+	defer liveProgress.Store(nil)
+	var (
+		t       *testContext
+		sandbox gcloudSandbox
+	)
+	project, err := opts.ephemeral.projectFor(tc)
+	if err == nil {
+		sandbox, err = newGcloudSandbox(sandboxFor(opts.gcloudSandbox, tc, project))
+	}
+	if err == nil {
+		err = hooks.start(tc.suite, sandbox.env())
+	}
+	if err == nil {
+		if opts.mutate {
+			boardBefore = board.Clone()
+		}
+		t = &testContext{id: tc.id, board: board, rand: testRand(seed, tc.id), progress: steps, project: project, env: sandbox.env()}
+		if opts.artifactsDir != "" {
+			t.artifactsDir = filepath.Join(opts.artifactsDir, tc.id)
+		}
+		liveProgress.Store(steps)
+		err = tc.suite.each(t, func() error {
+			if opts.mutate {
+				toolCallHook = recordingHook(&recorded)
+			}
+			defer func() { toolCallHook = nil }()
+			return tc.run(t)
+		})
+	}
+	// Retrying a call the billing budget refused would only be refused again.
+	retry = attempt <= opts.retries && err != nil && !report.IsSkip(err) && !errors.Is(err, billing.ErrExceeded)
+	if !retry {
+		err = hooks.done(tc.suite, sandbox.env(), err)
+	}
+	var peaks []resources.Peak
+	if sampler != nil {
+		peaks = sampler.Stop()
+	}
+	// Every session is closed by now, so a server still running was leaked.
+	leaked := subprocess.Default.KillAll()
+	tracePath := stopTrace()
+
+	result = report.TestResult{
+		ID:        tc.id,
+		Started:   start,
+		Status:    report.StatusPassed,
+		Duration:  time.Since(start),
+		Leaked:    leaked,
+		Resources: peaks,
+	}
+	if t != nil {
+		result.Artifacts = t.artifacts
+	}
+	for _, p := range leaked {
+		logf(slog.LevelWarn, "🧟 %s leaked %s; killed it\n", tc.id, p)
+	}
+	for _, inv := range client.DefaultRecorder.Invocations()[callsBefore:] {
+		if n := inv.Metrics.ReadinessRetries; n > 0 {
+			logger.Printf("🥶 %s was not ready; retried its handshake %d times, starting in %v\n", inv.Metrics.Server, n, inv.Metrics.Startup.Round(time.Millisecond))
+		}
+		if inv.Metrics.Reconnected != "" {
+			logger.Printf("🔌 %s dropped the connection (%s); reconnected for %s\n", inv.Metrics.Server, inv.Metrics.Reconnected, inv.Metrics.Tool)
+		}
+		for _, d := range inv.Downgrades {
+			logf(slog.LevelWarn, "⚠️  %s fell back from %s to %s: %s\n", inv.Metrics.Server, d.From, d.To, d.Err)
+			result.Downgrades = append(result.Downgrades, d)
+		}
+	}
+	for _, inv := range client.DefaultRecorder.Invocations()[callsBefore:] {
+		result.Timeline = append(result.Timeline, inv.Notifications...)
+	}
+	result.Servers = testedServers(client.DefaultRecorder.Invocations()[callsBefore:])
+	if recorded := steps.recorded(); len(recorded) > 0 {
+		result.Timeline = append(result.Timeline, recorded...)
+		slices.SortStableFunc(result.Timeline, func(a, b client.Notification) int { return a.At.Compare(b.At) })
+	}
+	if err == nil {
+		err = checkPollution(client.DefaultRecorder.Invocations()[callsBefore:], &result)
+	}
+	// Slow startups are recorded even on a test that failed otherwise.
+	if slow := checkStartup(client.DefaultRecorder.Invocations()[callsBefore:], &result); err == nil {
+		err = slow
+	}
+	if over := checkResources(&result); err == nil {
+		err = over
+	}
+	runner.Record(&result, err)
+	switch result.Status {
+	case report.StatusSkipped:
+		logger.Printf("⏭️  %s %v\n", tc.id, err)
+	case report.StatusFailed:
+		var diff string
+		if result.Mismatch != nil {
+			diff = result.Mismatch.Diff
+		}
+		// One write, so the diff is logged at the error's level.
+		logf(slog.LevelError, "❌ %v\n%s", err, diff)
+		result.Repro = reproCommand(tc)
+		result.WireTrace = tracePath
+		for _, inv := range client.DefaultRecorder.Invocations()[callsBefore:] {
+			if inv.Stderr != "" {
+				result.Stderr = append(result.Stderr, report.ServerStderr{Server: inv.Metrics.Server, Text: inv.Stderr})
+			}
+		}
+		if opts.artifactsDir != "" {
+			calls := client.DefaultRecorder.Invocations()[callsBefore:]
+			result.Artifacts = append(result.Artifacts, saveFailureArtifacts(filepath.Join(opts.artifactsDir, tc.id), calls)...)
+			if tracePath != "" {
+				result.Artifacts = append(result.Artifacts, tracePath)
+			}
+			path, err := writeReproScript(opts.artifactsDir, result, calls)
+			if err != nil {
+				logf(slog.LevelError, "❌ error writing repro script for %s: %v\n", tc.id, err)
+			}
+			result.ReproScript = path
+		}
+	}
+	if opts.mutate && result.Status == report.StatusPassed && len(recorded) > 0 {
+		if err := mutationTest(tc, seed, boardBefore, recorded, &result); err != nil {
+			runner.Record(&result, err)
+			result.Repro = reproCommand(tc) + " -mutate"
+			logf(slog.LevelError, "❌ %v\n", err)
+		}
+	}
+	sandbox.remove()
+	log.Write(captured.Bytes())
+	result.Log = log.String()
+	// Everything from here on reads the result, so it is masked once.
+	redactValue(&result)
+	traceTest(result, client.DefaultRecorder.Invocations()[callsBefore:])
+	client.DefaultRecorder.DropOutputs(callsBefore)
+	return result, retry && result.Status == report.StatusFailed
+}
+
+// writeArtifacts saves the output of a test that did not pass cleanly next to
+// its repro script and shrinks the test's artifacts to fit their budget.
+func writeArtifacts(result *report.TestResult, opts runOptions) {
+	if result.Status == report.StatusPassed || result.Status == report.StatusSkipped {
+		return
+	}
+	dir := filepath.Join(opts.artifactsDir, result.ID)
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "output.log"), []byte(result.Log), 0o644)
+	}
+	if err != nil {
+		logf(slog.LevelError, "❌ error writing the output of %s: %v\n", result.ID, err)
+	}
+	overage, err := opts.artifactBudget.EnforceTest(dir)
+	if err != nil {
+		logf(slog.LevelError, "❌ error enforcing the artifact budget of %s: %v\n", result.ID, err)
+	}
+	if overage != nil {
+		logger.Printf("📦 Artifacts of %s were %s\n", result.ID, overage)
+		result.ArtifactOverage = overage
+		compressedArtifacts(result.Artifacts)
+	}
+}
+
+// classifyRetried records on result, the last attempt of a test that failed
+// the earlier attempts, whether it is flaky: it passed on a retry.
+func classifyRetried(result *report.TestResult, failures []report.Attempt) {
+	result.FailedAttempts = failures
+	attempts := len(failures) + 1
+	switch result.Status {
+	case report.StatusPassed:
+		result.Status = report.StatusFlaky
+		result.Reason, result.Error = failures[0].Reason, failures[0].Error
+		logger.Printf("🎲 %s is flaky: it passed on attempt %d of %d\n", result.ID, attempts, attempts)
+	case report.StatusFailed:
+		logf(slog.LevelError, "❌ %s failed all %d attempts\n", result.ID, attempts)
+	}
+}
+
+// gcloudSandbox is the gcloud configuration directory of the running test.
+type gcloudSandbox struct {
+	*gcloudconfig.Sandbox
+}
+
+// newGcloudSandbox creates a sandbox from opts, whose env points the servers
+// a test starts at it. A nil opts leaves the global configuration in place.
+func newGcloudSandbox(opts *gcloudconfig.Options) (gcloudSandbox, error) {
+	if opts == nil {
+		return gcloudSandbox{}, nil
+	}
+	s, err := gcloudconfig.New(*opts)
+	if err != nil {
+		return gcloudSandbox{}, report.Fail(report.ReasonPrerequisite, "%v", err)
+	}
+	return gcloudSandbox{s}, nil
+}
+
+// sandboxFor returns the sandbox options of tc, which runs in project, or nil
+// if opts is nil. The sandbox of a test that checks the configured project
+// keeps the source configuration's, so the test checks the environment's
+// project rather than the one the harness wrote.
+func sandboxFor(opts *gcloudconfig.Options, tc testCase, project string) *gcloudconfig.Options {
+	if opts == nil {
+		return nil
+	}
+	in := *opts
+	in.Project = project
+	if tc.checksProject {
+		in.Project = ""
+	}
+	return &in
+}
+
+// env returns the environment entries that point gcloud at s, or none
+// without a sandbox.
+func (s gcloudSandbox) env() []string {
+	if s.Sandbox == nil {
+		return nil
+	}
+	return s.Env()
+}
+
+func (s gcloudSandbox) remove() {
+	if s.Sandbox == nil {
+		return
+	}
+	if err := s.Remove(); err != nil {
+		logf(slog.LevelWarn, "⚠️  error removing gcloud configuration sandbox: %v\n", err)
+	}
+}
+
+// testedServers returns the names of the registered servers calls reached.
+func testedServers(calls []client.Invocation) []string {
+	var names []string
+	for _, inv := range calls {
+		if name := registeredName(inv.Metrics.Server); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// registeredName returns the name of the registered server whose executable
+// is bin, as client.Metrics records it, or "" if none is.
+func registeredName(bin string) string {
+	for _, s := range serverRegistry.All() {
+		if filepath.Base(s.Bin()) == bin {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// checkPollution records the non-protocol stdout lines of the test's calls
+// on result and returns a failure naming the first, or nil if there were
+// none.
+func checkPollution(invocations []client.Invocation, result *report.TestResult) error {
+	var first string
+	for i, inv := range invocations {
+		for _, p := range inv.Pollution {
+			logger.Printf("🧪 %s wrote to stdout in call %d (%s): %s\n", inv.Metrics.Server, i+1, inv.Call.ToolName, p)
+			if first == "" {
+				first = fmt.Sprintf("%s in call %d (%s): %s", inv.Metrics.Server, i+1, inv.Call.ToolName, p)
+			}
+			result.Pollution = append(result.Pollution, p)
+		}
+	}
+	if first == "" {
+		return nil
+	}
+	return report.Fail(report.ReasonPollution, "server wrote %d non-protocol lines to stdout; first from %s", len(result.Pollution), first)
+}
+
+// checkStartup records on result the server launches of the test's calls
+// that were slower than the startup SLO of their server in the manifest, and
+// fails the test if one of them does not only warn.
+func checkStartup(invocations []client.Invocation, result *report.TestResult) error {
+	var failed *report.SlowStartup
+	for _, inv := range invocations {
+		s := servers.ByBin(inv.Metrics.Server)
+		if s == nil || s.StartupSLO == nil || inv.Metrics.Startup <= s.StartupSLO.Max {
+			continue
+		}
+		slow := report.SlowStartup{Server: inv.Metrics.Server, Startup: inv.Metrics.Startup, SLO: s.StartupSLO.Max}
+		logger.Printf("🐢 %s\n", slow)
+		result.SlowStartups = append(result.SlowStartups, slow)
+		if !s.StartupSLO.Warn && failed == nil {
+			failed = &slow
+		}
+	}
+	if failed == nil {
+		return nil
+	}
+	return report.Fail(report.ReasonStartupSLO, "%s", failed)
+}
+
+// checkResources records on result the servers that used more memory or CPU
+// than the limits of their server in the manifest, and fails the test if one
+// of them does not only warn.
+func checkResources(result *report.TestResult) error {
+	var failed *report.OverLimit
+	for _, p := range result.Resources {
+		s := servers.ByBin(p.Server)
+		if s == nil || s.Limits == nil {
+			continue
+		}
+		var over []report.OverLimit
+		if limit := int64(s.Limits.MaxRSSMB) << 20; limit > 0 && p.RSS > limit {
+			over = append(over, report.OverLimit{Server: p.Server, Resource: "memory", Used: resources.FormatBytes(p.RSS), Limit: resources.FormatBytes(limit)})
+		}
+		if limit := s.Limits.MaxCPU; limit > 0 && p.CPU > limit {
+			over = append(over, report.OverLimit{Server: p.Server, Resource: "CPU", Used: p.CPU.Round(time.Millisecond).String(), Limit: limit.String()})
+		}
+		for _, o := range over {
+			logger.Printf("🧠 %s\n", o)
+			result.OverLimits = append(result.OverLimits, o)
+			if !s.Limits.Warn && failed == nil {
+				failed = &o
+			}
+		}
+	}
+	if failed == nil {
+		return nil
+	}
+	return report.Fail(report.ReasonResourceLimit, "%s", failed)
+}
+
+// applyQuarantine records the test's quarantine entry on its result. While
+// the entry is unexpired a failure is downgraded to quarantined; once it has
+// expired the failure stands, escalated to the entry's owner.
+func applyQuarantine(result *report.TestResult, entry *quarantine.Entry, now time.Time, warnWithin time.Duration) {
+	result.Quarantine = entry
+	state := entry.State(now, warnWithin)
+	switch {
+	case state == quarantine.Expiring:
+		logf(slog.LevelWarn, "⚠️  quarantine of %s expires soon; fix the test or renew the entry\n", entry)
+	case state == quarantine.Expired && result.Status == report.StatusFailed:
+		result.Reason = report.ReasonQuarantineExpired
+		result.Error = fmt.Sprintf("quarantine of %s has expired and the test still fails: %s", entry, result.Error)
+		logf(slog.LevelError, "❌ quarantine of %s has expired; the failure now counts\n", entry)
+		return
+	}
+	switch result.Status {
+	case report.StatusFailed:
+		result.Status = report.StatusQuarantined
+		logger.Printf("🔒 %s failed but is quarantined\n", result.ID)
+	case report.StatusPassed:
+		logger.Printf("ℹ️  %s passed; its quarantine entry can be removed\n", result.ID)
+	}
+}
+
+// testRand derives a test's random source the way runner does, so a seed
+// reproduces the same values in either.
+func testRand(seed int64, id string) *rand.Rand {
+	return runner.Rand(seed, id)
+}
+
+// orderFromReport returns the tests of a previous run in the order they were
+// started, for replaying ordering-dependent failures.
+func orderFromReport(previous *report.Run) ([]testCase, error) {
+	recorded := slices.Clone(previous.Tests)
+	slices.SortStableFunc(recorded, func(a, b report.TestResult) int { return a.Started.Compare(b.Started) })
+
+	tests := make([]testCase, 0, len(recorded))
+	for _, r := range recorded {
+		tc, ok := findTest(r.ID)
+		if !ok {
+			return nil, fmt.Errorf("results file lists test %q, which is not registered in this build", r.ID)
+		}
+		tests = append(tests, tc)
+	}
+	return tests, nil
+}
+
+// invokeTool calls a tool with the harness-wide settings from callDefaults
+// and the test's environment applied. Tests use it instead of calling the
+// client directly.
+func (t *testContext) invokeTool(call client.ToolCall) (*client.Result, error) {
+	call = t.withDefaults(call)
+	if toolCallHook != nil {
+		return toolCallHook(call, invokeServer)
+	}
+	return invokeServer(call)
+}
+
+// openSession opens a session with call's server under the same defaults as
+// invokeTool. Calls made in the session bypass toolCallHook, so -mutate does
+// not replay them.
+func (t *testContext) openSession(call client.ToolCall) (*client.Session, error) {
+	return client.OpenSession(t.withDefaults(call))
+}
+
+// Env returns the environment every server the test starts gets before the
+// call's own, which the tests that start servers without invokeTool pass on.
+func (t *testContext) Env() []string {
+	if t == nil {
+		return nil
+	}
+	return t.env
+}
+
+// withDefaults returns call with the run's defaults and the test's
+// environment filled in.
+func (t *testContext) withDefaults(call client.ToolCall) client.ToolCall {
+	if call.TerminateDuration == 0 {
+		call.TerminateDuration = callDefaults.TerminateDuration
+	}
+	call.ValidateArgs = call.ValidateArgs || callDefaults.ValidateArgs
+	call.ValidateOutput = call.ValidateOutput || callDefaults.ValidateOutput
+	if call.ProtocolVersion == "" {
+		call.ProtocolVersion = callDefaults.ProtocolVersion
+	}
+	call.RecordStdoutPollution = call.RecordStdoutPollution || callDefaults.RecordStdoutPollution
+	if call.OnNotification == nil {
+		call.OnNotification = logNotification
+	}
+	if call.Chaos == nil {
+		call.Chaos = callDefaults.Chaos
+	}
+	if call.WireTrace == nil {
+		call.WireTrace = wireTrace
+	}
+	if call.Reconnect == nil {
+		call.Reconnect = callDefaults.Reconnect
+	}
+	if call.Guard == nil && billingBudget != nil {
+		call.Guard = chargeBilling
+	}
+	if call.TokenBudget == nil && len(call.ServerCmd) > 0 {
+		// Looked up by tool on each call, since a session's calls are not
+		// all of ToolName.
+		server := registeredName(filepath.Base(call.ServerCmd[0]))
+		call.TokenBudget = func(tool string) int { return tokenBudgets.Limit(server, tool) }
+	}
+	if call.OnFault == nil {
+		call.OnFault = logFault
+	}
+	if call.Cache == nil {
+		call.Cache = resultCache
+	}
+	// The call's own Env comes last so it can override the defaults and the
+	// manifest's.
+	manifestEnv, dir := manifestLaunch(call.ServerCmd)
+	call.Env = slices.Concat(t.Env(), emulatorEnv(call.ServerCmd), manifestEnv, call.Env)
+	if call.Dir == "" {
+		call.Dir = dir
+	}
+	if len(call.Endpoints) == 0 {
+		call.Endpoints = serverEndpoints(call.ServerCmd)
+	}
+	if len(call.ServerCmd) > 0 {
+		if s := servers.ByBin(call.ServerCmd[0]); s != nil {
+			// The manifest's RateLimit is shared, so it throttles the
+			// server's calls across parallel tests.
+			if call.RateLimit == nil {
+				call.RateLimit = s.RateLimit
+			}
+			if call.Readiness == nil {
+				call.Readiness = s.Readiness
+			}
+		}
+	}
+	if standIn != nil {
+		// A dry run's stand-in has no tools to validate against, and its empty
+		// results are neither cached nor billed.
+		planned := call
+		call.Dial = func() mcp.Transport { return standIn(planned) }
+		call.ValidateArgs, call.ValidateOutput, call.Cache, call.Guard = false, false, nil, nil
+	}
+	return call
+}
+
+// invokeServer makes call against its server, over each endpoint with
+// -differential, and checks the result against gcloud with -oracle.
+func invokeServer(call client.ToolCall) (*client.Result, error) {
+	var (
+		result *client.Result
+		err    error
+	)
+	switch {
+	case differentialNormalizer != nil && len(call.Endpoints) > 1 && replayable(call):
+		result, err = invokeDifferential(call, differentialNormalizer)
+	case differentialNormalizer != nil && len(call.Endpoints) > 1:
+		logger.Printf("🔀 Calling %s over %s only: it is not read-only or idempotent, so it is not repeated over every transport\n", call.ToolName, call.Endpoints[0])
+		fallthrough
+	default:
+		result, err = client.InvokeMCPTool(call)
+	}
+	if err == nil && result.Metrics.QuotaRetries > 0 {
+		logger.Printf("🐢 %s hit the server's quota; retried %d times, throttled for %v\n", call.ToolName, result.Metrics.QuotaRetries, result.Metrics.Throttled.Round(time.Millisecond))
+	}
+	if err == nil && result.Cached {
+		logger.Printf("♻️  Reused the cached result of an identical %s call\n", call.ToolName)
+		return result, nil
+	}
+	if err == nil && gcloudOracle != nil {
+		err = checkGcloudOracle(call, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// logNotification prints a notification into the running test's log as it
+// arrives.
+func logNotification(n client.Notification) {
+	// A server's debug messages are only logged at -log-level debug.
+	if n.Kind == client.NotificationLog && n.Level == "debug" {
+		logf(slog.LevelDebug, "🐞 %s %s\n", n.Server, n)
+		return
+	}
+	logger.Printf("📣 %s %s\n", n.Server, n)
+}
+
+// logFault prints a fault -chaos injected into the running test's log.
+func logFault(e chaos.Event) {
+	logger.Printf("🌪️  %s\n", e)
+}
+
+// invokeDifferential makes call over each of its endpoints separately and
+// fails with ReasonTransportDiff unless every result, normalized by n, equals
+// the first. It returns the first endpoint's result.
+func invokeDifferential(call client.ToolCall, n *differential.Normalizer) (*client.Result, error) {
+	var (
+		first     *client.Result
+		firstNorm any
+	)
+	for _, e := range call.Endpoints {
+		single := call
+		single.Endpoints = []client.Endpoint{e}
+		result, err := client.InvokeMCPTool(single)
+		if err != nil {
+			return nil, fmt.Errorf("over %s: %w", e, err)
+		}
+		norm := n.Normalize(result.Output + result.ErrorMessage)
+		if first == nil {
+			first, firstNorm = result, norm
+			continue
+		}
+		message := fmt.Sprintf("assertion failed: %s returns a different %s result over %s than over %s",
+			serverName(call), call.ToolName, e, call.Endpoints[0])
+		if err := report.Compare(message, firstNorm, norm); err != nil {
+			return nil, &report.Failure{Reason: report.ReasonTransportDiff, Err: report.MismatchOf(err)}
+		}
+	}
+	logger.Printf("🔀 %s results match over %d transports\n", call.ToolName, len(call.Endpoints))
+	return first, nil
+}
+
+// replayable reports whether -differential may make call once per
+// endpoint: its tool must be annotated read-only or idempotent, or, for
+// run_gcloud_command, whose calls may run any command, it must run a
+// read-only one. Any other call would change something once per transport.
+func replayable(call client.ToolCall) bool {
+	if call.ToolName == oracle.ToolName {
+		return oracle.ReadOnly(oracle.ToolArgs(call.ToolArgs))
+	}
+	tools, err := serverTools.list(call)
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not list the tools of %s to tell whether %s is idempotent: %v\n", serverName(call), call.ToolName, err)
+		return false
+	}
+	i := slices.IndexFunc(tools, func(t *mcp.Tool) bool { return t.Name == call.ToolName })
+	if i < 0 {
+		return false
+	}
+	a := tools[i].Annotations
+	return a != nil && (a.ReadOnlyHint || a.IdempotentHint)
+}
+
+// serverTools caches the tools of each server replayable looks up, so a
+// server is listed once per run rather than once per call.
+var serverTools toolLists
+
+type toolLists struct {
+	mu    sync.Mutex
+	lists map[string][]*mcp.Tool
+}
+
+// list returns the tools of call's server, listed over its first endpoint.
+func (l *toolLists) list(call client.ToolCall) ([]*mcp.Tool, error) {
+	key := strings.Join(call.ServerCmd, " ") + "\x00" + call.Endpoints[0].String()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if tools, ok := l.lists[key]; ok {
+		return tools, nil
+	}
+	tools, err := client.ListTools(client.ToolCall{
+		ServerCmd:         call.ServerCmd,
+		Endpoints:         call.Endpoints[:1],
+		TerminateDuration: call.TerminateDuration,
+		Env:               call.Env,
+		Dir:               call.Dir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l.lists == nil {
+		l.lists = map[string][]*mcp.Tool{}
+	}
+	l.lists[key] = tools
+	return tools, nil
+}
+
+func serverName(call client.ToolCall) string {
+	if len(call.ServerCmd) > 0 {
+		return call.ServerCmd[0]
+	}
+	return "the server"
+}
+
+// listTools lists the tools of the server started by serverCmd, reaching it
+// the same way invokeTool would.
+func (t *testContext) listTools(serverCmd []string) ([]*mcp.Tool, error) {
+	env, dir := manifestLaunch(serverCmd)
+	call := client.ToolCall{
+		ServerCmd:         serverCmd,
+		Endpoints:         serverEndpoints(serverCmd),
+		TerminateDuration: callDefaults.TerminateDuration,
+		Env:               slices.Concat(t.Env(), env),
+		Dir:               dir,
+	}
+	if standIn != nil {
+		call.Dial = func() mcp.Transport { return standIn(call) }
+	}
+	return client.ListTools(call)
+}
+
+// manifestLaunch returns the resolved env and the working directory the
+// manifest sets for stdio launches of the server started by serverCmd.
+// checkRequirements has already reported env that does not resolve.
+func manifestLaunch(serverCmd []string) (env []string, dir string) {
+	if len(serverCmd) == 0 {
+		return nil, ""
+	}
+	s := servers.ByBin(serverCmd[0])
+	if s == nil {
+		return nil, ""
+	}
+	env, _, _ = s.Environ()
+	return env, s.Dir
+}
+
+// serverEndpoints returns the endpoints the manifest declares for the server
+// started by serverCmd, or nil for stdio only.
+func serverEndpoints(serverCmd []string) []client.Endpoint {
+	if len(serverCmd) == 0 {
+		return nil
+	}
+	if s := servers.ByBin(serverCmd[0]); s != nil {
+		return s.Endpoints
+	}
+	return nil
+}
+
+func reproCommand(tc testCase) string {
+	return fmt.Sprintf("integration-test -only %s -fast", tc.id)
+}
+
+func writeReproScript(dir string, result report.TestResult, invocations []client.Invocation) (string, error) {
+	calls := make([]client.ToolCall, len(invocations))
+	for i, inv := range invocations {
+		calls[i] = inv.Call
+		// The sandbox is gone by the time the script runs.
+		calls[i].Env = slices.DeleteFunc(slices.Clone(inv.Call.Env), func(kv string) bool {
+			return strings.HasPrefix(kv, gcloudconfig.EnvVar+"=")
+		})
+		if len(inv.Call.ServerCmd) > 0 {
+			calls[i].Env = manifestReferences(servers.ByBin(inv.Call.ServerCmd[0]), calls[i].Env)
+		}
+	}
+	script, err := repro.Script(result.ID, result.Error, calls, os.Environ(), result.Repro)
+	if err != nil {
+		return "", err
+	}
+	// The script holds the environment and the calls' arguments and metadata,
+	// and is shared like the other artifacts.
+	return repro.Write(dir, result.ID, redactor.String(script))
+}
+
+// manifestReferences returns env with the entries the manifest's env for s
+// resolved to replaced by the references they were resolved from, so a repro
+// script reads secrets where the run did instead of holding them.
+func manifestReferences(s *bootstrap.Server, env []string) []string {
+	if s == nil {
+		return env
+	}
+	resolved, _, err := s.Environ()
+	if err != nil {
+		return env
+	}
+	out := slices.Clone(env)
+	for i, kv := range out {
+		if slices.Contains(resolved, kv) {
+			name, _, _ := strings.Cut(kv, "=")
+			out[i] = name + "=" + s.Env[name]
+		}
+	}
+	return out
+}
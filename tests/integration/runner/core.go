@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// The engine Run and the integration-test command share: how a run's seed
+// is drawn, how a test's error becomes its result and what a finished run
+// summarizes, so both record the same results for the same outcome.
+
+// Seed returns seed, or a random nonzero seed if it is zero.
+func Seed(seed int64) int64 {
+	for seed == 0 {
+		seed = rand.Int64()
+	}
+	return seed
+}
+
+// SkipUnsupported returns the result of test id, which runs only on
+// platforms, on p, where it does not run.
+func SkipUnsupported(id string, platforms platform.Constraint, p platform.Platform) report.TestResult {
+	return report.TestResult{
+		ID:      id,
+		Started: time.Now(),
+		Status:  report.StatusSkipped,
+		Reason:  report.ReasonPlatform,
+		Error:   fmt.Sprintf("skipped: runs only on %s, not %s", platforms, p),
+	}
+}
+
+// Record sets result's status from the error its test returned: skipped for
+// a report.Skip, failed with the error's reason, message and mismatch for
+// any other error, and passed for none.
+func Record(result *report.TestResult, err error) {
+	switch {
+	case report.IsSkip(err):
+		result.Status = report.StatusSkipped
+		result.Error = err.Error()
+	case err != nil:
+		result.Status = report.StatusFailed
+		result.Reason = report.ReasonOf(err)
+		result.Error = err.Error()
+		result.Mismatch = report.MismatchOf(err)
+	default:
+		result.Status = report.StatusPassed
+	}
+}
+
+// Summarize fills in what run records about itself once its tests are done:
+// its duration, what board holds and the latency and startup of calls.
+func Summarize(run *report.Run, board *blackboard.Board, calls []client.Metrics) {
+	run.Duration = time.Since(run.Started)
+	for _, e := range board.Entries() {
+		run.Blackboard = append(run.Blackboard, report.Published{Key: e.Key, Value: fmt.Sprint(e.Value), Publisher: e.Publisher, At: e.At})
+	}
+	run.Latency = report.SummarizeLatency(calls)
+	run.Startup = report.SummarizeStartup(calls)
+}
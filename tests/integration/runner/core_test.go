@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		err        error
+		status     report.Status
+		reason     string
+		isMismatch bool
+	}{
+		{nil, report.StatusPassed, "", false},
+		{report.Skip("not today"), report.StatusSkipped, "", false},
+		{errors.New("boom"), report.StatusFailed, report.ReasonUnknown, false},
+		{report.Compare("greeting", "hi", "bye"), report.StatusFailed, report.ReasonAssertion, true},
+	}
+	for _, tt := range tests {
+		var result report.TestResult
+		Record(&result, tt.err)
+		if result.Status != tt.status || result.Reason != tt.reason || (result.Mismatch != nil) != tt.isMismatch {
+			t.Errorf("Record(%v) = %+v", tt.err, result)
+		}
+	}
+}
+
+func TestSkipUnsupported(t *testing.T) {
+	got := SkipUnsupported("elsewhere", platform.Constraint{"plan9/*"}, platform.Platform{OS: "linux", Arch: "amd64"})
+	if got.Status != report.StatusSkipped || got.Reason != report.ReasonPlatform || got.Error != "skipped: runs only on plan9/*, not linux/amd64" {
+		t.Errorf("SkipUnsupported() = %+v", got)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	if got := Seed(42); got != 42 {
+		t.Errorf("Seed(42) = %d, want it kept", got)
+	}
+	if got := Seed(0); got == 0 {
+		t.Error("Seed(0) = 0, want a drawn seed")
+	}
+}
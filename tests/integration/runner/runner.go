@@ -0,0 +1,285 @@
+// Package runner lets other repositories test their own MCP servers with
+// this harness: they register tests with Register and run them with Run,
+// from their own main or a Go test, and get the harness's client,
+// assertions (package report), fixtures (package blackboard and seeded
+// randomness) and results files without copying them.
+//
+// It is the harness's engine reduced to what every suite needs: the
+// integration-test command runs its tests with the same Seed, Record and
+// Summarize, and layers its own features, such as quarantine, retries and
+// chaos injection, on top.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Options are the optional settings of a registered test.
+type Options struct {
+	// Requires lists the executables the test needs on PATH, e.g. the
+	// server it launches. Run fails up front if one is missing.
+	Requires []string
+	// Platforms, if set, are the only os/arch platforms the test runs on,
+	// e.g. linux or */arm64; elsewhere it is skipped with reason
+	// platform_unsupported.
+	Platforms platform.Constraint
+	// Timeout, if set, bounds the test. A test still running at its timeout
+	// fails with reason hang and is abandoned, so it should honor
+	// T.Context.
+	Timeout time.Duration
+}
+
+type test struct {
+	name string
+	fn   func(*T) error
+	opts Options
+}
+
+// validName matches the names a test can be registered under, which are
+// its IDs in results.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Registry is a set of tests, run in the order they were registered.
+type Registry struct {
+	mu    sync.Mutex
+	tests []test
+}
+
+// Default is the registry Register and Run use.
+var Default = &Registry{}
+
+// Register adds a test to Default; see Registry.Register.
+func Register(name string, fn func(*T) error, opts Options) {
+	Default.Register(name, fn, opts)
+}
+
+// Run runs the tests of Default; see Registry.Run.
+func Run(ctx context.Context, cfg Config) (*report.Run, error) {
+	return Default.Run(ctx, cfg)
+}
+
+// Register adds the test fn under name, which must be lowercase letters,
+// digits and dashes. It panics if name is invalid or taken or opts are
+// invalid, since tests are registered at init time.
+func (r *Registry) Register(name string, fn func(*T) error, opts Options) {
+	if !validName.MatchString(name) || fn == nil {
+		panic(fmt.Sprintf("runner: test %q needs a name of lowercase letters, digits and dashes and a function", name))
+	}
+	if err := opts.Platforms.Validate(); err != nil {
+		panic(fmt.Sprintf("runner: test %s: %v", name, err))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tests {
+		if t.name == name {
+			panic(fmt.Sprintf("runner: test %s is already registered", name))
+		}
+	}
+	r.tests = append(r.tests, test{name: name, fn: fn, opts: opts})
+}
+
+// Tests returns the names of the registered tests in run order.
+func (r *Registry) Tests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.tests))
+	for i, t := range r.tests {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Config configures a Run.
+type Config struct {
+	// Seed derives every test's random source; zero draws one, which the
+	// results record.
+	Seed int64
+	// Match, if set, runs only the tests whose names it matches.
+	Match *regexp.Regexp
+	// Env is added to the environment of every server T.Call starts, before
+	// the call's own.
+	Env []string
+	// TerminateDuration is how long T.Call gives a stdio server to exit
+	// before killing it, unless the call sets its own.
+	TerminateDuration time.Duration
+	// Labels annotate the results.
+	Labels map[string]string
+	// Output receives the tests' logs and the summary. Defaults to
+	// os.Stdout.
+	Output io.Writer
+	// Results and JUnit, if set, are where the results file and JUnit report
+	// are written.
+	Results, JUnit string
+}
+
+// Run runs the registered tests that cfg selects, in order, and returns
+// their results. Every test runs even if an earlier one fails. It returns
+// an error, before running anything, if a test's required executable is
+// missing, and with the results so far if ctx ends; failed tests are not
+// errors.
+func (r *Registry) Run(ctx context.Context, cfg Config) (*report.Run, error) {
+	r.mu.Lock()
+	var selected []test
+	for _, t := range r.tests {
+		if cfg.Match == nil || cfg.Match.MatchString(t.name) {
+			selected = append(selected, t)
+		}
+	}
+	r.mu.Unlock()
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	current := platform.Current()
+	for _, t := range selected {
+		for _, bin := range t.opts.Requires {
+			if _, err := exec.LookPath(bin); err != nil && t.opts.Platforms.Allows(current) {
+				return nil, report.Fail(report.ReasonPrerequisite, "test %s requires %q: %v", t.name, bin, err)
+			}
+		}
+	}
+
+	seed := Seed(cfg.Seed)
+	run := &report.Run{Started: time.Now(), Seed: seed, Platform: current.String()}
+	run.Annotate(nil, cfg.Labels)
+	board := blackboard.New()
+	callsBefore := len(client.DefaultRecorder.Calls())
+	var err error
+	for _, t := range selected {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if !t.opts.Platforms.Allows(current) {
+			fmt.Fprintf(out, "🚫 %s runs only on %s, not %s; skipping it\n", t.name, t.opts.Platforms, current)
+			run.Tests = append(run.Tests, SkipUnsupported(t.name, t.opts.Platforms, current))
+			continue
+		}
+		run.Tests = append(run.Tests, runTest(ctx, t, &cfg, board, seed, out))
+	}
+	Summarize(run, board, client.DefaultRecorder.Calls()[callsBefore:])
+
+	if werr := report.WriteText(out, run); werr != nil && err == nil {
+		err = werr
+	}
+	if cfg.Results != "" {
+		if werr := report.WriteJSON(cfg.Results, run); werr != nil && err == nil {
+			err = fmt.Errorf("writing results file: %w", werr)
+		}
+	}
+	if cfg.JUnit != "" {
+		if werr := report.WriteJUnit(cfg.JUnit, run); werr != nil && err == nil {
+			err = fmt.Errorf("writing JUnit report: %w", werr)
+		}
+	}
+	return run, err
+}
+
+// runTest runs t and returns its result, recovering from a panic as a
+// failure.
+func runTest(ctx context.Context, t test, cfg *Config, board *blackboard.Board, seed int64, out io.Writer) report.TestResult {
+	result := report.TestResult{ID: t.name, Started: time.Now()}
+	if t.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+		defer cancel()
+	}
+	var log strings.Builder
+	tt := &T{ID: t.name, Rand: Rand(seed, t.name), Board: board, ctx: ctx, cfg: cfg, out: io.MultiWriter(out, &log)}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- t.fn(tt)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = report.Fail(report.ReasonHang, "%s did not finish: %v", t.name, ctx.Err())
+	}
+	result.Duration = time.Since(result.Started)
+	Record(&result, err)
+	switch result.Status {
+	case report.StatusSkipped:
+		tt.Logf("⏭️  %s %v", t.name, err)
+	case report.StatusFailed:
+		var diff string
+		if result.Mismatch != nil {
+			diff = "\n" + result.Mismatch.Diff
+		}
+		tt.Logf("❌ %v%s", err, diff)
+	}
+	tt.mu.Lock()
+	result.Log = log.String()
+	tt.mu.Unlock()
+	return result
+}
+
+// Rand returns the random source of test id in a run with seed. The
+// integration-test command derives its tests' the same way, so a seed
+// reproduces the same values in either.
+func Rand(seed int64, id string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return rand.New(rand.NewPCG(uint64(seed), h.Sum64()))
+}
+
+// T is handed to each running test.
+type T struct {
+	// ID is the test's name.
+	ID string
+	// Rand is the test's only source of randomness, derived from the run's
+	// seed and ID, so a rerun with the same seed draws the same values
+	// regardless of which tests run before it.
+	Rand *rand.Rand
+	// Board is shared by every test of the run. Publish with ID as the
+	// publisher so consumers can tell where a value came from.
+	Board *blackboard.Board
+
+	ctx context.Context
+	cfg *Config
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// Context is canceled when the run's context ends or the test times out.
+func (t *T) Context() context.Context {
+	return t.ctx
+}
+
+// Logf writes a line to the run's output and the test's log in the
+// results.
+func (t *T) Logf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.out, strings.TrimSuffix(format, "\n")+"\n", args...)
+}
+
+// Call calls a tool with the run's defaults applied: Config.Env before the
+// call's own and Config.TerminateDuration unless it sets one. Assert on the
+// result with package report.
+func (t *T) Call(call client.ToolCall) (*client.Result, error) {
+	if call.TerminateDuration == 0 {
+		call.TerminateDuration = t.cfg.TerminateDuration
+	}
+	call.Env = append(append([]string(nil), t.cfg.Env...), call.Env...)
+	return client.InvokeMCPTool(call)
+}
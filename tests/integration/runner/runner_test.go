@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/platform"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestRun(t *testing.T) {
+	r := &Registry{}
+	r.Register("passes", func(t *T) error {
+		t.Logf("hello from %s", t.ID)
+		return blackboard.Publish(t.Board, blackboard.NewKey[string]("greeting"), "hi", t.ID)
+	}, Options{})
+	r.Register("fails", func(*T) error {
+		return report.Compare("greeting", "hi", "bye")
+	}, Options{})
+	r.Register("skips", func(*T) error { return report.Skip("not today") }, Options{})
+	r.Register("panics", func(*T) error { panic("boom") }, Options{})
+	r.Register("hangs", func(t *T) error {
+		<-t.Context().Done()
+		time.Sleep(time.Second)
+		return nil
+	}, Options{Timeout: 10 * time.Millisecond})
+	r.Register("elsewhere", func(*T) error { return errors.New("ran") }, Options{Platforms: platform.Constraint{"plan9/*"}, Requires: []string{"no-such-binary"}})
+	if got := strings.Join(r.Tests(), ","); got != "passes,fails,skips,panics,hangs,elsewhere" {
+		t.Errorf("Tests() = %s", got)
+	}
+
+	results := filepath.Join(t.TempDir(), "results.json")
+	var out strings.Builder
+	run, err := r.Run(context.Background(), Config{Seed: 7, Labels: map[string]string{"team": "storage"}, Output: &out, Results: results})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		status report.Status
+		reason string
+	}{
+		"passes":    {report.StatusPassed, ""},
+		"fails":     {report.StatusFailed, report.ReasonAssertion},
+		"skips":     {report.StatusSkipped, ""},
+		"panics":    {report.StatusFailed, report.ReasonUnknown},
+		"hangs":     {report.StatusFailed, report.ReasonHang},
+		"elsewhere": {report.StatusSkipped, report.ReasonPlatform},
+	}
+	if len(run.Tests) != len(want) {
+		t.Fatalf("got %d results, want %d", len(run.Tests), len(want))
+	}
+	for _, res := range run.Tests {
+		if w := want[res.ID]; res.Status != w.status || res.Reason != w.reason {
+			t.Errorf("%s = %s (%s), want %s (%s)", res.ID, res.Status, res.Reason, w.status, w.reason)
+		}
+	}
+	if !strings.Contains(run.Tests[0].Log, "hello from passes") || !strings.Contains(out.String(), "hello from passes") {
+		t.Errorf("log not captured: %q", run.Tests[0].Log)
+	}
+	if run.Tests[1].Mismatch == nil {
+		t.Error("failed comparison has no mismatch")
+	}
+	if len(run.Blackboard) != 1 || run.Blackboard[0].Publisher != "passes" {
+		t.Errorf("blackboard = %+v", run.Blackboard)
+	}
+
+	read, err := report.ReadJSON(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Seed != 7 || read.Labels["team"] != "storage" || len(read.Tests) != len(want) {
+		t.Errorf("results file = seed %d, labels %v, %d tests", read.Seed, read.Labels, len(read.Tests))
+	}
+}
+
+func TestRunMatch(t *testing.T) {
+	r := &Registry{}
+	var ran []string
+	for _, name := range []string{"storage-list", "storage-get", "gcloud-list"} {
+		r.Register(name, func(t *T) error {
+			ran = append(ran, t.ID)
+			return nil
+		}, Options{})
+	}
+	if _, err := r.Run(context.Background(), Config{Match: regexp.MustCompile(`^storage-`), Output: io.Discard}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ran, ","); got != "storage-list,storage-get" {
+		t.Errorf("ran %s", got)
+	}
+}
+
+func TestRunRequires(t *testing.T) {
+	r := &Registry{}
+	ran := false
+	r.Register("needs-server", func(*T) error {
+		ran = true
+		return nil
+	}, Options{Requires: []string{"no-such-mcp-server"}})
+	_, err := r.Run(context.Background(), Config{Output: io.Discard})
+	if report.ReasonOf(err) != report.ReasonPrerequisite || ran {
+		t.Errorf("Run() = %v with the test run %v, want a prerequisite failure before running", err, ran)
+	}
+}
+
+func TestRand(t *testing.T) {
+	a, b := Rand(42, "storage-list"), Rand(42, "storage-list")
+	for range 3 {
+		if x, y := a.Int64(), b.Int64(); x != y {
+			t.Fatalf("same seed and ID drew %d and %d", x, y)
+		}
+	}
+	if Rand(42, "storage-list").Int64() == Rand(42, "storage-get").Int64() {
+		t.Error("different IDs drew the same value")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	r := &Registry{}
+	r.Register("taken", func(*T) error { return nil }, Options{})
+	for name, register := range map[string]func(){
+		"duplicate":   func() { r.Register("taken", func(*T) error { return nil }, Options{}) },
+		"invalid":     func() { r.Register("Not Valid", func(*T) error { return nil }, Options{}) },
+		"no function": func() { r.Register("nil", nil, Options{}) },
+		"platforms": func() {
+			r.Register("bad-platform", func(*T) error { return nil }, Options{Platforms: platform.Constraint{"a/b/c"}})
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Register did not panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
@@ -0,0 +1,211 @@
+// Package safety compiles an inventory of the tools a server advertises that
+// can change or destroy state, for security review. A tool is listed when its
+// annotations say it is destructive or its name or description suggests so.
+package safety
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// destructiveVerbs are name components that suggest a tool changes state.
+var destructiveVerbs = []string{
+	"delete", "remove", "rm", "destroy", "drop", "purge", "truncate", "wipe",
+	"erase", "overwrite", "revoke", "disable", "terminate", "kill", "reset",
+	"uninstall", "undeploy", "deploy", "update", "patch", "set", "write",
+	"move", "mv",
+}
+
+// executionWords are name components of tools that run caller-supplied
+// commands, whose effects are only as limited as the command.
+var executionWords = []string{"command", "exec", "shell", "script"}
+
+// descriptionPatterns flag descriptions that admit to destructive effects or
+// to running arbitrary commands.
+var descriptionPatterns = []struct {
+	signal  string
+	pattern *regexp.Regexp
+}{
+	{"description mentions deletion", regexp.MustCompile(`(?i)\b(delet|remov|destroy|purg|drop|truncat|wip|eras|overwrit)(e|es|ed|ing|ion)?\b`)},
+	{"description mentions irreversibility", regexp.MustCompile(`(?i)\b(irreversibl[ey]|permanent(ly)?|cannot be undone)\b`)},
+	{"runs arbitrary commands", regexp.MustCompile(`(?i)\b(run|runs|execute|executes)\b.{0,40}\b(any|arbitrary)?\s*(gcloud |shell )?commands?\b`)},
+}
+
+// Finding is a tool the inventory lists, with the signals that put it there.
+type Finding struct {
+	Tool        string `json:"tool"`
+	Description string `json:"description,omitempty"`
+	// Annotations summarizes the tool's behavior hints, e.g. "destructive",
+	// "read-only" or "unannotated".
+	Annotations string   `json:"annotations"`
+	Signals     []string `json:"signals"`
+}
+
+// Inventory is the destructive surface of one server version.
+type Inventory struct {
+	Server    string    `json:"server"`
+	Version   string    `json:"version"`
+	Generated time.Time `json:"generated"`
+	// Reviewed is the number of tools the server advertised.
+	Reviewed int       `json:"reviewed"`
+	Findings []Finding `json:"findings"`
+}
+
+// Classify returns why t looks destructive, or nil if nothing suggests it.
+func Classify(t *mcp.Tool) []string {
+	var signals []string
+	if a := t.Annotations; a != nil && !a.ReadOnlyHint {
+		switch {
+		case a.DestructiveHint == nil:
+			// As in annotations, destructiveHint defaults to true.
+			signals = append(signals, "annotated without readOnlyHint, so destructive by default")
+		case *a.DestructiveHint:
+			signals = append(signals, "annotated destructiveHint")
+		}
+	}
+	words := nameWords(t.Name)
+	for _, verb := range destructiveVerbs {
+		if slices.Contains(words, verb) {
+			signals = append(signals, fmt.Sprintf("name contains %q", verb))
+		}
+	}
+	for _, word := range executionWords {
+		if slices.Contains(words, word) {
+			signals = append(signals, fmt.Sprintf("name suggests command execution (%q)", word))
+			break
+		}
+	}
+	for _, p := range descriptionPatterns {
+		if p.pattern.MatchString(t.Description) {
+			signals = append(signals, p.signal)
+		}
+	}
+	if len(signals) > 0 && t.Annotations != nil && t.Annotations.ReadOnlyHint {
+		signals = append(signals, "annotated readOnlyHint despite the above")
+	}
+	return signals
+}
+
+// Build returns the inventory of tools advertised by version of server.
+func Build(server, version string, tools []*mcp.Tool, now time.Time) *Inventory {
+	inv := &Inventory{Server: server, Version: version, Generated: now.UTC(), Reviewed: len(tools), Findings: []Finding{}}
+	for _, t := range tools {
+		signals := Classify(t)
+		if len(signals) == 0 {
+			continue
+		}
+		inv.Findings = append(inv.Findings, Finding{
+			Tool:        t.Name,
+			Description: t.Description,
+			Annotations: annotations(t.Annotations),
+			Signals:     signals,
+		})
+	}
+	slices.SortFunc(inv.Findings, func(a, b Finding) int { return strings.Compare(a.Tool, b.Tool) })
+	return inv
+}
+
+func annotations(a *mcp.ToolAnnotations) string {
+	switch {
+	case a == nil:
+		return "unannotated"
+	case a.ReadOnlyHint:
+		return "read-only"
+	case a.DestructiveHint == nil || *a.DestructiveHint:
+		// Per the specification, destructiveHint defaults to true.
+		return "destructive"
+	default:
+		return "non-destructive"
+	}
+}
+
+// nameWords splits a tool name such as run_gcloudCommand into lower-case
+// words.
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for i, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && len(word) > 0 && !unicode.IsUpper(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// FileName returns the file name of inv under a review directory, one per
+// server version, e.g. gcloud@0.3.0.json.
+func FileName(inv *Inventory) string {
+	version := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, inv.Version)
+	return inv.Server + "@" + version + ".json"
+}
+
+// Save writes inv as indented JSON into dir, replacing an earlier inventory
+// of the same server version, and returns the file's path.
+func Save(dir string, inv *Inventory) (string, error) {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName(inv))
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// WriteMarkdown writes the inventories as a review document with one table
+// per server.
+func WriteMarkdown(w io.Writer, inventories []*Inventory) error {
+	var b strings.Builder
+	b.WriteString("# Destructive capability inventory\n")
+	for _, inv := range inventories {
+		fmt.Fprintf(&b, "\n## %s %s\n\n", inv.Server, inv.Version)
+		fmt.Fprintf(&b, "%d of %d tools flagged on %s.\n", len(inv.Findings), inv.Reviewed, inv.Generated.Format(time.DateOnly))
+		if len(inv.Findings) == 0 {
+			continue
+		}
+		b.WriteString("\n| Tool | Annotations | Signals | Description |\n| --- | --- | --- | --- |\n")
+		for _, f := range inv.Findings {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", f.Tool, f.Annotations, strings.Join(f.Signals, "; "), cell(f.Description))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cell makes s fit in a Markdown table cell.
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 160 {
+		s = string(r[:160]) + "…"
+	}
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,105 @@
+package safety
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		tool *mcp.Tool
+		want []string
+	}{
+		{&mcp.Tool{Name: "list_buckets", Description: "Lists the buckets in a project."}, nil},
+		{&mcp.Tool{Name: "delete_object", Description: "Deletes an object."}, []string{`name contains "delete"`, "description mentions deletion"}},
+		{&mcp.Tool{Name: "updateBucketLabels"}, []string{`name contains "update"`}},
+		{&mcp.Tool{Name: "run_gcloud_command", Description: "Runs a gcloud command."}, []string{`name suggests command execution ("command")`, "runs arbitrary commands"}},
+		{&mcp.Tool{Name: "mutate", Annotations: &mcp.ToolAnnotations{DestructiveHint: boolPtr(true)}}, []string{"annotated destructiveHint"}},
+		{&mcp.Tool{Name: "get_settings", Annotations: &mcp.ToolAnnotations{DestructiveHint: boolPtr(false)}}, nil},
+		{&mcp.Tool{Name: "rotate_keys", Annotations: &mcp.ToolAnnotations{IdempotentHint: true}}, []string{"annotated without readOnlyHint, so destructive by default"}},
+		{
+			&mcp.Tool{Name: "purge_logs", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+			[]string{`name contains "purge"`, "annotated readOnlyHint despite the above"},
+		},
+		// "reset" inside a word is not a verb.
+		{&mcp.Tool{Name: "presets_list"}, nil},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.tool); !slices.Equal(got, tt.want) {
+			t.Errorf("Classify(%s) = %q, want %q", tt.tool.Name, got, tt.want)
+		}
+	}
+}
+
+func TestNameWords(t *testing.T) {
+	tests := map[string][]string{
+		"run_gcloud_command": {"run", "gcloud", "command"},
+		"deleteBucket":       {"delete", "bucket"},
+		"list-IAM-policies":  {"list", "iam", "policies"},
+		"getURLMap":          {"get", "urlmap"},
+	}
+	for in, want := range tests {
+		if got := nameWords(in); !slices.Equal(got, want) {
+			t.Errorf("nameWords(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildAndSave(t *testing.T) {
+	tools := []*mcp.Tool{
+		{Name: "write_object"},
+		{Name: "list_objects", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+		{Name: "delete_bucket", Annotations: &mcp.ToolAnnotations{}},
+	}
+	inv := Build("storage", "1.2.0", tools, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if inv.Reviewed != 3 || len(inv.Findings) != 2 {
+		t.Fatalf("Build = %+v, want 2 of 3 tools flagged", inv)
+	}
+	if f := inv.Findings[0]; f.Tool != "delete_bucket" || f.Annotations != "destructive" {
+		t.Errorf("first finding = %+v, want delete_bucket annotated destructive by default", f)
+	}
+	if f := inv.Findings[1]; f.Tool != "write_object" || f.Annotations != "unannotated" {
+		t.Errorf("second finding = %+v", f)
+	}
+
+	dir := t.TempDir()
+	path, err := Save(dir, inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "storage@1.2.0.json") {
+		t.Errorf("Save wrote %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Inventory
+	if err := json.Unmarshal(data, &back); err != nil || len(back.Findings) != 2 {
+		t.Errorf("saved inventory does not round-trip: %v\n%s", err, data)
+	}
+
+	var md strings.Builder
+	if err := WriteMarkdown(&md, []*Inventory{inv}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## storage 1.2.0", "2 of 3 tools flagged on 2026-10-01.", "| `delete_bucket` | destructive |"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestFileNameSanitizesVersion(t *testing.T) {
+	if got := FileName(&Inventory{Server: "gcloud", Version: "^1.0 || next"}); got != "gcloud@^1.0____next.json" {
+		t.Errorf("FileName = %s", got)
+	}
+}
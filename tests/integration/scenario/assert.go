@@ -0,0 +1,71 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Assertion is a check written in Go that steps name in assert, for domain
+// rules too involved for expect, such as every name of a bucket list being a
+// valid bucket name. It gets the step's response as a Document and returns
+// an error if the response is wrong; a report.Failure keeps its reason, and
+// any other error fails the step with ReasonAssertion.
+type Assertion func(doc any) error
+
+// Assertions is a set of assertions by name.
+type Assertions struct {
+	byName map[string]Assertion
+}
+
+// DefaultAssertions is the set RegisterAssertion adds to and Load resolves
+// the names of steps' assertions in.
+var DefaultAssertions = &Assertions{}
+
+// RegisterAssertion adds an assertion to the default set.
+func RegisterAssertion(name string, fn Assertion) { DefaultAssertions.Register(name, fn) }
+
+// Register adds fn as name. It panics if name is empty or already
+// registered, since assertions are registered at init time.
+func (a *Assertions) Register(name string, fn Assertion) {
+	if name == "" || fn == nil {
+		panic(fmt.Sprintf("scenario: assertion %q needs a name and a function", name))
+	}
+	if _, ok := a.byName[name]; ok {
+		panic("scenario: assertion " + name + " is already registered")
+	}
+	if a.byName == nil {
+		a.byName = map[string]Assertion{}
+	}
+	a.byName[name] = fn
+}
+
+// Lookup returns the assertion registered as name, or nil.
+func (a *Assertions) Lookup(name string) Assertion {
+	return a.byName[name]
+}
+
+// Names returns the registered names, sorted.
+func (a *Assertions) Names() []string {
+	return slices.Sorted(maps.Keys(a.byName))
+}
+
+// assert runs the assertions named by step on doc, in order, and returns the
+// first failure.
+func assert(step Step, doc any) error {
+	for _, name := range step.Assert {
+		err := DefaultAssertions.Lookup(name)(doc)
+		if err == nil {
+			continue
+		}
+		var f *report.Failure
+		if errors.As(err, &f) {
+			return fmt.Errorf("assertion %s: %w", name, err)
+		}
+		return report.Fail(report.ReasonAssertion, "assertion %s failed: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package scenario
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func init() {
+	RegisterAssertion("hasObjects", func(doc any) error {
+		n, err := Eval(doc, "$.structuredContent.objects")
+		if err != nil {
+			return err
+		}
+		if n == 0.0 {
+			return errors.New("the store is empty")
+		}
+		return nil
+	})
+	RegisterAssertion("isUnserved", func(any) error {
+		return report.Fail(report.ReasonToolError, "the store served a call")
+	})
+}
+
+func TestAssertions(t *testing.T) {
+	a := &Assertions{}
+	a.Register("b", func(any) error { return nil })
+	a.Register("a", func(any) error { return nil })
+	if got := strings.Join(a.Names(), ","); got != "a,b" || a.Lookup("a") == nil || a.Lookup("c") != nil {
+		t.Errorf("Names() = %s, Lookup(a) = %v, Lookup(c) = %v", got, a.Lookup("a"), a.Lookup("c"))
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	a.Register("a", func(any) error { return nil })
+}
+
+func TestRunAssert(t *testing.T) {
+	st := &store{objects: map[string]string{}}
+	for _, tt := range []struct {
+		steps  []Step
+		reason string
+		want   string
+	}{
+		{[]Step{{Call: "create", Args: map[string]any{"name": "a"}}, {Call: "count", Assert: []string{"hasObjects"}}}, "", ""},
+		{[]Step{{Call: "delete", Args: map[string]any{"name": "a"}}, {Call: "count", Assert: []string{"hasObjects"}}}, report.ReasonAssertion, "assertion hasObjects failed: the store is empty"},
+		{[]Step{{Call: "count", Assert: []string{"isUnserved"}}}, report.ReasonToolError, "assertion isUnserved: the store served a call"},
+	} {
+		s := &Scenario{Name: "assert", Server: "store", Steps: tt.steps}
+		err := s.Run(Vars{}, st.do, func(string, ...any) {})
+		if tt.reason == "" && err != nil || tt.reason != "" && (report.ReasonOf(err) != tt.reason || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Run(%+v) = %v, want %q containing %q", tt.steps, err, tt.reason, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Document parses a JSON response into the document captures and
+// expectations are evaluated against. Text fields that hold JSON objects or
+// arrays, such as a tool's text content or a resource's text, are parsed
+// too, so a path can reach into them: $.content[0].text.name.
+func Document(data []byte) (any, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return decodeText(v), nil
+}
+
+func decodeText(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if s, ok := e.(string); ok && k == "text" {
+				var parsed any
+				trimmed := strings.TrimSpace(s)
+				if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+					if json.Unmarshal([]byte(trimmed), &parsed) == nil {
+						v[k] = parsed
+					}
+				}
+				continue
+			}
+			v[k] = decodeText(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = decodeText(e)
+		}
+	}
+	return v
+}
+
+// Eval returns the value at path in doc. Paths are the dot and bracket
+// subset of JSONPath: $ is the document, .name or ['name'] a member of an
+// object and [n] an element of an array, counting from the end if negative.
+func Eval(doc any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("path %q does not start with $", path)
+	}
+	v := doc
+	for rest != "" {
+		var seg string
+		index, isIndex := 0, false
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			seg, rest = rest[:end], rest[end:]
+			if seg == "" {
+				return nil, fmt.Errorf("path %q has an empty member name", path)
+			}
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end == -1 {
+				return nil, fmt.Errorf("path %q has an unterminated ['", path)
+			}
+			seg, rest = rest[2:end], rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("path %q has an unterminated [", path)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("path %q has a bad index %q", path, rest[1:end])
+			}
+			index, isIndex, rest = n, true, rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", path, rest)
+		}
+		consumed := strings.TrimSuffix(path, rest)
+		if isIndex {
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not an array", strings.TrimSuffix(consumed, fmt.Sprintf("[%d]", index)))
+			}
+			if index < 0 {
+				index += len(arr)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("%s is out of range: the array has %d elements", consumed, len(arr))
+			}
+			v = arr[index]
+			continue
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an object", parent(consumed))
+		}
+		if v, ok = obj[seg]; !ok {
+			return nil, fmt.Errorf("%s does not exist", consumed)
+		}
+	}
+	return v, nil
+}
+
+// parent returns the path of the object a member path names a key of.
+func parent(path string) string {
+	if strings.HasSuffix(path, "']") {
+		return path[:strings.LastIndex(path, "['")]
+	}
+	return path[:strings.LastIndex(path, ".")]
+}
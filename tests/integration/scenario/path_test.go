@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	doc, err := Document([]byte(`{"content":[{"type":"text","text":"{\"name\":\"b\",\"items\":[1,2,3]}"},{"type":"text","text":"plain"}],"a.b":{"c":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.content[0].text.name", "b"},
+		{"$.content[0].text.items[-1]", 3.0},
+		{"$.content[1].text", "plain"},
+		{"$['a.b'].c", true},
+		{"$.content[1].type", "text"},
+	}
+	for _, tt := range tests {
+		got, err := Eval(doc, tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("Eval(%s) = %v, %v, want %v", tt.path, got, err, tt.want)
+		}
+	}
+	for path, want := range map[string]string{
+		"content[0]":               "does not start with $",
+		"$.content[2]":             "$.content[2] is out of range: the array has 2 elements",
+		"$.content[0].text.size":   "$.content[0].text.size does not exist",
+		"$.content.type":           "$.content is not an object",
+		"$.content[0].type[0]":     "$.content[0].type is not an array",
+		"$.content[x]":             `bad index "x"`,
+		"$['a.b'":                  "unterminated ['",
+		"$.content[1].text.length": "$.content[1].text is not an object",
+	} {
+		if _, err := Eval(doc, path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Eval(%s) = %v, want an error containing %q", path, err, want)
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	vars := Vars{"bucket": "b-1", "count": 3.0, "labels": map[string]any{"a": "x"}}
+	got, err := vars.Substitute(map[string]any{
+		"name":    "gs://${bucket}/object",
+		"count":   "${count}",
+		"summary": "${bucket} has ${count} objects labelled ${labels}",
+		"args":    []any{"${bucket}", 7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := got.(map[string]any)
+	if m["name"] != "gs://b-1/object" || m["count"] != 3.0 || m["summary"] != `b-1 has 3 objects labelled {"a":"x"}` || m["args"].([]any)[0] != "b-1" {
+		t.Errorf("Substitute() = %v", got)
+	}
+	if _, err := vars.Substitute([]any{"${missing}"}); err == nil || !strings.Contains(err.Error(), "undefined variable ${missing}") {
+		t.Errorf("Substitute(missing) = %v", err)
+	}
+}
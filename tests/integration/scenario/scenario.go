@@ -0,0 +1,268 @@
+// Package scenario runs multi-step tool workflows declared in YAML: ordered
+// tool calls and resource reads against one server, with values captured
+// from earlier responses by JSONPath and substituted into the arguments of
+// later steps as ${name}.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+	"github.com/googleapis/gcloud-mcp/tests/integration/differential"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// File is the parsed scenario file.
+type File struct {
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// Scenario is one workflow, run as the test scenario-<name>.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Server is the registered name of the server the steps run against, in
+	// one session.
+	Server string `yaml:"server"`
+	// Tags group the scenario's test with others for -tags and -skip-tags;
+	// like names, they are lowercase letters, digits and dashes.
+	Tags  []string `yaml:"tags,omitempty"`
+	Steps []Step   `yaml:"steps"`
+}
+
+// Step is one call or read of a scenario.
+type Step struct {
+	// Name identifies the step in logs and failures; it defaults to the tool
+	// or the read.
+	Name string `yaml:"name,omitempty"`
+	// Call is the tool to call with Args. Exactly one of Call and Read is set.
+	Call string         `yaml:"call,omitempty"`
+	Args map[string]any `yaml:"args,omitempty"`
+	// Read is the URI of a resource to read with resources/read.
+	Read string `yaml:"read,omitempty"`
+	// Capture maps variable names to JSONPaths of the response whose values
+	// later steps can use as ${name}.
+	Capture map[string]string `yaml:"capture,omitempty"`
+	// Expect maps JSONPaths of the response to the values they must have.
+	Expect map[string]any `yaml:"expect,omitempty"`
+	// Assert names Go assertions, registered with RegisterAssertion, that
+	// the response must pass after Expect.
+	Assert []string `yaml:"assert,omitempty"`
+	// ExpectError, if set, is text the tool's error must contain; the call
+	// must then fail.
+	ExpectError string `yaml:"expect_error,omitempty"`
+	// Cleanup steps run even after an earlier step failed, e.g. to delete
+	// what the scenario created.
+	Cleanup bool `yaml:"cleanup,omitempty"`
+	// Repeat, if above 1, makes the call or read that many times in a row;
+	// every response must equal the first once VolatileFields and
+	// differential.DefaultVolatileFields are ignored. Expect and Capture
+	// apply to the first.
+	Repeat         int      `yaml:"repeat,omitempty"`
+	VolatileFields []string `yaml:"volatile_fields,omitempty"`
+}
+
+func (s Step) String() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Call != "":
+		return s.Call
+	}
+	return "read " + s.Read
+}
+
+// validName matches the names a scenario's test ID can carry.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Load reads a scenario file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*File, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse scenarios %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, s := range f.Scenarios {
+		if !validName.MatchString(s.Name) {
+			return nil, fmt.Errorf("%s: scenario name %q must be lowercase letters, digits and dashes", path, s.Name)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("%s: scenario %s is declared more than once", path, s.Name)
+		}
+		seen[s.Name] = true
+		if s.Server == "" || len(s.Steps) == 0 {
+			return nil, fmt.Errorf("%s: scenario %s needs a server and steps", path, s.Name)
+		}
+		for _, tag := range s.Tags {
+			if !validName.MatchString(tag) {
+				return nil, fmt.Errorf("%s: scenario %s tag %q must be lowercase letters, digits and dashes", path, s.Name, tag)
+			}
+		}
+		for i, step := range s.Steps {
+			if (step.Call == "") == (step.Read == "") {
+				return nil, fmt.Errorf("%s: scenario %s step %d needs exactly one of call and read", path, s.Name, i+1)
+			}
+			if step.Read != "" && (step.Args != nil || step.ExpectError != "") {
+				return nil, fmt.Errorf("%s: scenario %s step %d reads a resource, which takes no args or expect_error", path, s.Name, i+1)
+			}
+			if step.ExpectError != "" && len(step.Capture)+len(step.Expect)+len(step.Assert) > 0 {
+				return nil, fmt.Errorf("%s: scenario %s step %d expects an error, so it has nothing to capture, expect or assert", path, s.Name, i+1)
+			}
+			for _, name := range step.Assert {
+				if DefaultAssertions.Lookup(name) == nil {
+					return nil, fmt.Errorf("%s: scenario %s step %d asserts %s, which is not registered; known assertions: %s", path, s.Name, i+1, name, strings.Join(DefaultAssertions.Names(), ", "))
+				}
+			}
+			if step.Repeat < 0 || (step.Repeat > 0 && step.ExpectError != "") {
+				return nil, fmt.Errorf("%s: scenario %s step %d: repeat must be positive and cannot be combined with expect_error", path, s.Name, i+1)
+			}
+			if step.VolatileFields != nil && step.Repeat < 2 {
+				return nil, fmt.Errorf("%s: scenario %s step %d has volatile_fields but is not repeated", path, s.Name, i+1)
+			}
+		}
+	}
+	return &f, nil
+}
+
+// Response is what a step's call or read returned.
+type Response struct {
+	// Doc is the response as a Document.
+	Doc any
+	// ToolError is the error message of a tool result with isError set.
+	ToolError string
+}
+
+// Uses reports whether a step of s refers to the variable name, so a
+// built-in that costs something to provide is only set for the scenarios
+// that need it.
+func (s *Scenario) Uses(name string) bool {
+	data, err := json.Marshal(s.Steps)
+	return err == nil && strings.Contains(string(data), "${"+name+"}")
+}
+
+// Do performs a step, calling its tool with args or reading the resource at
+// uri, both with the variables substituted.
+type Do func(step Step, args map[string]any, uri string) (*Response, error)
+
+// Run runs the steps of s in order with vars, which starts with the
+// built-ins and gains each step's captures. After the first failure only
+// cleanup steps run; Run returns the first failure, or the first failed
+// cleanup.
+func (s *Scenario) Run(vars Vars, do Do, logf func(format string, args ...any)) error {
+	var first error
+	for i, step := range s.Steps {
+		if first != nil && !step.Cleanup {
+			continue
+		}
+		err := s.runStep(vars, step, do)
+		if err != nil {
+			err = fmt.Errorf("step %d (%s): %w", i+1, step, err)
+			if first == nil {
+				first = err
+			} else {
+				// Only the first failure is returned.
+				logf("❌ %v", err)
+			}
+			continue
+		}
+		logf("👣 Step %d (%s) passed", i+1, step)
+	}
+	return first
+}
+
+func (s *Scenario) runStep(vars Vars, step Step, do Do) error {
+	var args map[string]any
+	if step.Args != nil {
+		v, err := vars.Substitute(step.Args)
+		if err != nil {
+			return report.Fail(report.ReasonAssertion, "%v", err)
+		}
+		args = v.(map[string]any)
+	}
+	uri, err := vars.Substitute(step.Read)
+	if err != nil {
+		return report.Fail(report.ReasonAssertion, "%v", err)
+	}
+	resp, err := do(step, args, fmt.Sprint(uri))
+	if err != nil {
+		return err
+	}
+	switch {
+	case step.ExpectError != "" && resp.ToolError == "":
+		return report.Fail(report.ReasonUnexpected, "%s succeeded, want an error containing %q", step.Call, step.ExpectError)
+	case step.ExpectError != "":
+		if !strings.Contains(resp.ToolError, step.ExpectError) {
+			return report.Fail(report.ReasonToolError, "%s failed with %q, want an error containing %q", step.Call, resp.ToolError, step.ExpectError)
+		}
+		return nil
+	case resp.ToolError != "":
+		return report.Fail(report.ReasonToolError, "tool failed: %s", resp.ToolError)
+	}
+	if step.Repeat > 1 {
+		if err := repeat(step, resp, func() (*Response, error) { return do(step, args, fmt.Sprint(uri)) }); err != nil {
+			return err
+		}
+	}
+	// Sorted, so the first reported mismatch is stable.
+	for _, path := range slices.Sorted(maps.Keys(step.Expect)) {
+		got, err := Eval(resp.Doc, path)
+		if err != nil {
+			return report.Fail(report.ReasonAssertion, "assertion failed: %v", err)
+		}
+		want, err := vars.Substitute(step.Expect[path])
+		if err != nil {
+			return report.Fail(report.ReasonAssertion, "%v", err)
+		}
+		if err := report.Compare("assertion failed: "+path+" has an unexpected value", want, got); err != nil {
+			return err
+		}
+	}
+	if err := assert(step, resp.Doc); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(step.Capture)) {
+		v, err := Eval(resp.Doc, step.Capture[name])
+		if err != nil {
+			return report.Fail(report.ReasonParse, "capturing %s: %v", name, err)
+		}
+		vars[name] = v
+	}
+	return nil
+}
+
+// repeat makes step's call or read again until it has first plus
+// step.Repeat-1 responses, and fails with ReasonInconsistent unless they all
+// equal first after normalization.
+func repeat(step Step, first *Response, again func() (*Response, error)) error {
+	docs := make([]string, 0, step.Repeat)
+	for i := range step.Repeat {
+		resp := first
+		if i > 0 {
+			var err error
+			if resp, err = again(); err != nil {
+				return fmt.Errorf("repeat %d of %d: %w", i+1, step.Repeat, err)
+			}
+			if resp.ToolError != "" {
+				return report.Fail(report.ReasonInconsistent, "tool failed on repeat %d of %d: %s", i+1, step.Repeat, resp.ToolError)
+			}
+		}
+		data, err := json.Marshal(resp.Doc)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, string(data))
+	}
+	n := differential.New(slices.Concat(differential.DefaultVolatileFields, step.VolatileFields))
+	return n.Consistent(step.String(), docs)
+}
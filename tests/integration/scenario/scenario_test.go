@@ -0,0 +1,174 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if f, err := Load(filepath.Join(dir, "missing.yaml"), true); err != nil || len(f.Scenarios) != 0 {
+		t.Errorf("Load(missing) = %v, %v", f, err)
+	}
+	for body, want := range map[string]string{
+		"scenarios:\n  - {name: Bad, server: s, steps: [{call: t}]}\n":                                             "lowercase",
+		"scenarios:\n  - {name: a, server: s, steps: [{call: t}]}\n  - {name: a, server: s, steps: [{call: t}]}\n": "more than once",
+		"scenarios:\n  - {name: a, steps: [{call: t}]}\n":                                                          "needs a server and steps",
+		"scenarios:\n  - {name: a, server: s, tags: [Smoke], steps: [{call: t}]}\n":                                "tag \"Smoke\"",
+		"scenarios:\n  - {name: a, server: s, steps: [{call: t, read: u}]}\n":                                      "exactly one of call and read",
+		"scenarios:\n  - {name: a, server: s, steps: [{read: u, args: {a: 1}}]}\n":                                 "takes no args",
+		"scenarios:\n  - {name: a, server: s, steps: [{call: t, expect_error: x, capture: {v: $.a}}]}\n":           "nothing to capture",
+		"scenarios:\n  - {name: a, server: s, steps: [{call: t, repeat: 3, expect_error: x}]}\n":                   "cannot be combined with expect_error",
+		"scenarios:\n  - {name: a, server: s, steps: [{call: t, volatile_fields: [etag]}]}\n":                      "not repeated",
+	} {
+		path := filepath.Join(dir, "scenarios.yaml")
+		os.WriteFile(path, []byte(body), 0o644)
+		if _, err := Load(path, false); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) = %v, want an error containing %q", body, err, want)
+		}
+	}
+}
+
+// store is a fake server with create and delete tools over named objects,
+// also readable as store://<name>, and a count tool returning how many calls
+// it has served.
+type store struct {
+	objects map[string]string
+	calls   []string
+}
+
+func (s *store) do(step Step, args map[string]any, uri string) (*Response, error) {
+	s.calls = append(s.calls, step.String())
+	var out any
+	switch {
+	case step.Read != "":
+		text, ok := s.objects[strings.TrimPrefix(uri, "store://")]
+		if !ok {
+			return nil, fmt.Errorf("no resource %s", uri)
+		}
+		out = map[string]any{"contents": []any{map[string]any{"uri": uri, "text": text}}}
+	case step.Call == "create":
+		name := fmt.Sprint(args["name"])
+		if _, ok := s.objects[name]; ok {
+			return &Response{ToolError: name + " already exists"}, nil
+		}
+		s.objects[name] = fmt.Sprint(args["body"])
+		out = map[string]any{"structuredContent": map[string]any{"uri": "store://" + name, "size": len(s.objects[name])}}
+	case step.Call == "count":
+		out = map[string]any{"structuredContent": map[string]any{"objects": len(s.objects), "served": len(s.calls)}}
+	case step.Call == "delete":
+		delete(s.objects, fmt.Sprint(args["name"]))
+		out = map[string]any{"content": []any{}}
+	default:
+		return &Response{ToolError: "unknown tool " + step.Call}, nil
+	}
+	data, _ := json.Marshal(out)
+	doc, err := Document(data)
+	return &Response{Doc: doc}, err
+}
+
+func TestRun(t *testing.T) {
+	s := &Scenario{Name: "roundtrip", Server: "store", Steps: []Step{
+		{Call: "create", Args: map[string]any{"name": "obj-${random}", "body": `{"greeting":"hi"}`},
+			Capture: map[string]string{"uri": "$.structuredContent.uri"}, Expect: map[string]any{"$.structuredContent.size": 17}},
+		{Name: "create again", Call: "create", Args: map[string]any{"name": "obj-${random}"}, ExpectError: "already exists"},
+		{Read: "${uri}", Expect: map[string]any{"$.contents[0].text.greeting": "hi", "$.contents[0].uri": "${uri}"}},
+		{Call: "delete", Args: map[string]any{"name": "obj-${random}"}, Cleanup: true},
+	}}
+	st := &store{objects: map[string]string{}}
+	var logs []string
+	logf := func(format string, args ...any) { logs = append(logs, fmt.Sprintf(format, args...)) }
+	vars := Vars{"random": "1a2b"}
+	if err := s.Run(vars, st.do, logf); err != nil {
+		t.Fatalf("Run() = %v\n%s", err, strings.Join(logs, "\n"))
+	}
+	if vars["uri"] != "store://obj-1a2b" || len(st.objects) != 0 {
+		t.Errorf("vars = %v, objects = %v", vars, st.objects)
+	}
+	if got := strings.Join(st.calls, ","); got != "create,create again,read ${uri},delete" {
+		t.Errorf("calls = %s", got)
+	}
+}
+
+func TestRunCleansUpAfterAFailure(t *testing.T) {
+	s := &Scenario{Name: "failing", Server: "store", Steps: []Step{
+		{Call: "create", Args: map[string]any{"name": "obj", "body": "x"}},
+		{Read: "store://obj", Expect: map[string]any{"$.contents[0].text": "y"}},
+		{Call: "create", Args: map[string]any{"name": "other"}},
+		{Call: "delete", Args: map[string]any{"name": "obj"}, Cleanup: true},
+	}}
+	st := &store{objects: map[string]string{}}
+	err := s.Run(Vars{}, st.do, func(string, ...any) {})
+	if report.ReasonOf(err) != report.ReasonAssertion || !strings.Contains(err.Error(), "step 2 (read store://obj)") {
+		t.Errorf("Run() = %v", err)
+	}
+	if len(st.objects) != 0 || len(st.calls) != 3 {
+		t.Errorf("objects = %v after calls %v, want the cleanup to run and the third step not to", st.objects, st.calls)
+	}
+	m := report.MismatchOf(err)
+	if m == nil || m.Expected != "y" || m.Actual != "x" {
+		t.Errorf("mismatch = %+v", m)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	st := &store{objects: map[string]string{"taken": "x"}}
+	for _, tt := range []struct {
+		step   Step
+		reason string
+		want   string
+	}{
+		{Step{Call: "create", Args: map[string]any{"name": "taken"}}, report.ReasonToolError, "already exists"},
+		{Step{Call: "create", Args: map[string]any{"name": "new"}, ExpectError: "exists"}, report.ReasonUnexpected, "want an error"},
+		{Step{Call: "create", Args: map[string]any{"name": "${nope}"}}, report.ReasonAssertion, "undefined variable ${nope}"},
+		{Step{Call: "create", Args: map[string]any{"name": "n2"}, Capture: map[string]string{"v": "$.missing"}}, report.ReasonParse, "capturing v: $.missing does not exist"},
+	} {
+		s := &Scenario{Name: "f", Server: "store", Steps: []Step{tt.step}}
+		err := s.Run(Vars{}, st.do, func(string, ...any) {})
+		if report.ReasonOf(err) != tt.reason || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Run(%+v) = %v (%s), want %s containing %q", tt.step, err, report.ReasonOf(err), tt.reason, tt.want)
+		}
+	}
+}
+
+func TestRunRepeat(t *testing.T) {
+	st := &store{objects: map[string]string{"a": `{"etag":"1"}`}}
+	s := &Scenario{Name: "repeat", Server: "store", Steps: []Step{
+		{Read: "store://a", Repeat: 3, Capture: map[string]string{"text": "$.contents[0].text"}},
+		{Call: "count", Repeat: 2, VolatileFields: []string{"served"}, Expect: map[string]any{"$.structuredContent.objects": 1}},
+	}}
+	vars := Vars{}
+	if err := s.Run(vars, st.do, func(string, ...any) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.calls) != 5 || vars["text"] == nil {
+		t.Errorf("calls = %v, vars = %v, want every repeat made and the first captured", st.calls, vars)
+	}
+
+	s.Steps = []Step{{Name: "count twice", Call: "count", Repeat: 2}}
+	err := s.Run(Vars{}, st.do, func(string, ...any) {})
+	if report.ReasonOf(err) != report.ReasonInconsistent || !strings.Contains(err.Error(), "count twice returned a different result on call 2 of 2") {
+		t.Errorf("Run() = %v, want an inconsistent_result failure", err)
+	}
+	if m := report.MismatchOf(err); m == nil || !strings.Contains(m.Diff, `"served": 6`) {
+		t.Errorf("mismatch = %+v, want a diff of served", m)
+	}
+}
+
+func TestUses(t *testing.T) {
+	s := &Scenario{Steps: []Step{
+		{Call: "create", Args: map[string]any{"spec": map[string]any{"name": "${resource}"}}},
+		{Read: "${uri}"},
+	}}
+	for name, want := range map[string]bool{"resource": true, "uri": true, "random": false, "res": false} {
+		if got := s.Uses(name); got != want {
+			t.Errorf("Uses(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
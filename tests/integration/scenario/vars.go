@@ -0,0 +1,67 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// reference matches a ${name} variable reference.
+var reference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Vars are the variables of a running scenario: the built-ins it starts with
+// and those its steps captured so far.
+type Vars map[string]any
+
+// Substitute returns v with every ${name} in its strings replaced by the
+// variable's value. A string that is a single reference takes the value
+// itself, keeping its type, so "${count}" can pass a number; elsewhere
+// non-string values are written as JSON. Maps and slices are copied, not
+// modified.
+func (vars Vars) Substitute(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if m := reference.FindStringSubmatch(v); m != nil && m[0] == v {
+			value, ok := vars[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable %s", m[0])
+			}
+			return value, nil
+		}
+		var err error
+		out := reference.ReplaceAllStringFunc(v, func(ref string) string {
+			value, ok := vars[reference.FindStringSubmatch(ref)[1]]
+			if !ok {
+				err = fmt.Errorf("undefined variable %s", ref)
+				return ref
+			}
+			if s, ok := value.(string); ok {
+				return s
+			}
+			data, _ := json.Marshal(value)
+			return string(data)
+		})
+		return out, err
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			s, err := vars.Substitute(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = s
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			s, err := vars.Substitute(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = s
+		}
+		return out, nil
+	}
+	return v, nil
+}
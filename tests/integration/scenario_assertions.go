@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/naming"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/scenario"
+)
+
+// The assertions scenario steps can name in assert. Add one here, rather
+// than a handwritten test, when a check is too involved for expect.
+func init() {
+	scenario.RegisterAssertion("isValidBucketList", isValidBucketList)
+}
+
+// isValidBucketList checks storage-mcp's list_buckets response: either "No
+// buckets found." or one valid, distinct bucket name per line.
+func isValidBucketList(doc any) error {
+	v, err := scenario.Eval(doc, "$.content[0].text")
+	if err != nil {
+		return err
+	}
+	text, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("text content is %T, want the bucket names", v)
+	}
+	_, err = listedBuckets(text)
+	return err
+}
+
+// listedBuckets returns the bucket names in the text of a list_buckets
+// result, failing if one is not a valid bucket name or appears twice.
+func listedBuckets(text string) ([]string, error) {
+	if text == "No buckets found." {
+		return nil, nil
+	}
+	names := strings.Split(text, "\n")
+	for i, name := range names {
+		if err := naming.ValidBucketName(name); err != nil {
+			return nil, report.Fail(report.ReasonAssertion, "assertion failed: list_buckets listed %q: %v", name, err)
+		}
+		if slices.Contains(names[:i], name) {
+			return nil, report.Fail(report.ReasonAssertion, "assertion failed: list_buckets listed %s twice", name)
+		}
+	}
+	return names, nil
+}
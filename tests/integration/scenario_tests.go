@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/oracle"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/scenario"
+)
+
+// defaultScenarioFile declares the scenario-* tests; it may be absent.
+const defaultScenarioFile = "scenarios.yaml"
+
+// exampleScenarioServer is the server name scenarios use for the embedded
+// example server, which is not registered.
+const exampleScenarioServer = "example"
+
+// registerScenarios adds a test for each scenario of the file at path, which
+// may be missing if it is the default one, to testCases.
+func registerScenarios(path string) error {
+	f, err := scenario.Load(path, path == defaultScenarioFile)
+	if err != nil {
+		return err
+	}
+	tests, err := scenarioTests(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	testCases = append(slices.Clip(testCases), tests...)
+	return nil
+}
+
+// scenarioTests returns a scenario-<name> test for each scenario of f.
+func scenarioTests(f *scenario.File) ([]testCase, error) {
+	var tests []testCase
+	for _, s := range f.Scenarios {
+		tc := testCase{id: "scenario-" + s.Name, tags: s.Tags}
+		for _, step := range s.Steps {
+			tc.steps = append(tc.steps, describeStep(step))
+		}
+		if s.Server != exampleScenarioServer {
+			server := serverRegistry.Lookup(s.Server)
+			if server == nil {
+				return nil, fmt.Errorf("scenario %s runs against unregistered server %q", s.Name, s.Server)
+			}
+			tc.requires = server.Command[:1]
+		}
+		tc.run = func(t *testContext) error {
+			return runScenario(t, &s)
+		}
+		tests = append(tests, tc)
+	}
+	return tests, nil
+}
+
+// runScenario runs s in one session with its server, starting with the
+// built-in variables project, test and random.
+func runScenario(t *testContext, s *scenario.Scenario) error {
+	logger.Printf("🚀 Starting %s scenario (%d steps)...\n", s.Name, len(s.Steps))
+	serverCmd := exampleServerCmd()
+	if s.Server != exampleScenarioServer {
+		serverCmd = serverRegistry.Lookup(s.Server).Command
+	}
+	session, err := t.openSession(client.ToolCall{ServerCmd: serverCmd})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	vars := scenario.Vars{
+		"project": t.Project(),
+		"test":    t.id,
+		"run":     runID,
+		"random":  fmt.Sprintf("%08x", t.rand.Uint32()),
+	}
+	// Only a name generated is cleaned up after the run, which lists the
+	// project.
+	if s.Uses("resource") {
+		vars["resource"] = t.ResourceName()
+	}
+	// Progress counts calls, each repeat included.
+	done, total := 0, 0
+	for _, step := range s.Steps {
+		total += max(step.Repeat, 1)
+	}
+	repeatable := map[string]error{}
+	do := func(step scenario.Step, args map[string]any, uri string) (*scenario.Response, error) {
+		t.Progress(step.String(), 100*done/total)
+		done++
+		if step.Repeat > 1 && step.Call == oracle.ToolName {
+			// Whether a gcloud call is repeatable depends on its command.
+			if err := checkRepeatable(session, step.Call, args); err != nil {
+				return nil, err
+			}
+		} else if step.Repeat > 1 && step.Call != "" {
+			if _, ok := repeatable[step.Call]; !ok {
+				repeatable[step.Call] = checkRepeatable(session, step.Call, args)
+			}
+			if err := repeatable[step.Call]; err != nil {
+				return nil, err
+			}
+		}
+		if step.Read != "" {
+			res, err := session.ReadResource(uri)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(res)
+			if err != nil {
+				return nil, err
+			}
+			return documentResponse(data)
+		}
+		if args == nil {
+			args = map[string]any{}
+		}
+		result, err := session.CallTool(step.Call, args)
+		if err != nil && step.ExpectError != "" {
+			// A JSON-RPC error satisfies expect_error as an isError result does.
+			return &scenario.Response{ToolError: err.Error()}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error calling %s: %w", step.Call, err)
+		}
+		if result.IsError {
+			return &scenario.Response{ToolError: strings.TrimSpace(result.Text())}, nil
+		}
+		return documentResponse([]byte(result.Output))
+	}
+	logf := func(format string, args ...any) { logger.Printf(format+"\n", args...) }
+	if err := s.Run(vars, do, logf); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: all %d steps of %s passed\n", len(s.Steps), s.Name)
+	return nil
+}
+
+// describeStep returns what step does, as -dry-run lists it.
+func describeStep(step scenario.Step) string {
+	action := "read " + step.Read
+	if step.Call != "" {
+		args := []byte("{}")
+		if step.Args != nil {
+			args, _ = json.Marshal(step.Args)
+		}
+		action = "call " + step.Call + " " + string(args)
+	}
+	if step.Name != "" {
+		action = step.Name + ": " + action
+	}
+	if step.Repeat > 1 {
+		action += fmt.Sprintf(" (%d times)", step.Repeat)
+	}
+	if step.Cleanup {
+		action += " (cleanup)"
+	}
+	return action
+}
+
+func documentResponse(data []byte) (*scenario.Response, error) {
+	doc, err := scenario.Document(data)
+	if err != nil {
+		return nil, report.Fail(report.ReasonParse, "error parsing response: %v\nOutput: %s", err, data)
+	}
+	return &scenario.Response{Doc: doc}, nil
+}
@@ -0,0 +1,158 @@
+// Package selfupdate compares the running harness build with the latest
+// tagged release, so a stale install on a shared runner is called out in the
+// summary instead of surfacing as a confusing mismatch.
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+)
+
+// DefaultManifest is the release manifest CI publishes for every tag.
+const DefaultManifest = "gs://gcloud-mcp-testing-releases/integration-test/latest.json"
+
+// Release is the content of a release manifest.
+type Release struct {
+	Version string `json:"version"`
+	// Notes links the release notes or download page.
+	Notes string `json:"notes,omitempty"`
+}
+
+// Checker fetches the release manifest.
+type Checker struct {
+	// Manifest is the manifest's gs:// or https:// URL. Defaults to
+	// DefaultManifest. gs:// objects are read anonymously, so the manifest
+	// must be publicly readable.
+	Manifest   string
+	HTTPClient *http.Client
+	// Cache, if set, keeps the manifest between runs.
+	Cache *cache.Cache
+}
+
+// Latest fetches and parses the release manifest.
+func (c *Checker) Latest(ctx context.Context) (*Release, error) {
+	return cache.Fetch(c.Cache, "release-manifest:"+c.manifest(), func() (*Release, error) { return c.fetch(ctx) })
+}
+
+func (c *Checker) fetch(ctx context.Context) (*Release, error) {
+	url, err := manifestURL(c.manifest())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release manifest: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release manifest %s: %s", c.manifest(), resp.Status)
+	}
+	var r Release
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse release manifest %s: %w", c.manifest(), err)
+	}
+	if _, ok := parse(r.Version); !ok {
+		return nil, fmt.Errorf("release manifest %s: invalid version %q", c.manifest(), r.Version)
+	}
+	return &r, nil
+}
+
+// Check returns the latest release if it is newer than running, or nil if
+// running is up to date. Development builds, whose version is not of the
+// form vMAJOR.MINOR.PATCH, are never out of date and are not checked.
+func (c *Checker) Check(ctx context.Context, running string) (*Release, error) {
+	current, ok := parse(running)
+	if !ok {
+		return nil, nil
+	}
+	latest, err := c.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if v, _ := parse(latest.Version); v.newer(current) {
+		return latest, nil
+	}
+	return nil, nil
+}
+
+func (c *Checker) manifest() string {
+	if c.Manifest == "" {
+		return DefaultManifest
+	}
+	return c.Manifest
+}
+
+// manifestURL turns a gs://bucket/object URL into its public HTTPS URL.
+func manifestURL(manifest string) (string, error) {
+	if rest, ok := strings.CutPrefix(manifest, "gs://"); ok {
+		bucket, object, _ := strings.Cut(rest, "/")
+		if bucket == "" || object == "" {
+			return "", fmt.Errorf("invalid release manifest %q: want gs://BUCKET/OBJECT", manifest)
+		}
+		return "https://storage.googleapis.com/" + bucket + "/" + object, nil
+	}
+	if !strings.HasPrefix(manifest, "https://") && !strings.HasPrefix(manifest, "http://") {
+		return "", fmt.Errorf("invalid release manifest %q: want a gs:// or https:// URL", manifest)
+	}
+	return manifest, nil
+}
+
+// version is a parsed vMAJOR.MINOR.PATCH[-PRERELEASE] version.
+type version struct {
+	core       [3]int
+	prerelease bool
+}
+
+// parse parses v, ignoring build metadata.
+func parse(v string) (version, bool) {
+	v, ok := strings.CutPrefix(v, "v")
+	if !ok {
+		return version{}, false
+	}
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, hasPre := strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return version{}, false
+	}
+	var out version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		out.core[i] = n
+	}
+	out.prerelease = hasPre && pre != ""
+	return out, true
+}
+
+// newer reports whether v is a later release than w. Prereleases of the same
+// version are not ordered among themselves.
+func (v version) newer(w version) bool {
+	for i := range v.core {
+		if v.core[i] != w.core[i] {
+			return v.core[i] > w.core[i]
+		}
+	}
+	return w.prerelease && !v.prerelease
+}
@@ -0,0 +1,86 @@
+package selfupdate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version": "v1.4.0", "notes": "https://example.com/v1.4.0"}`))
+	}))
+	defer srv.Close()
+	c := &Checker{Manifest: srv.URL + "/latest.json"}
+
+	tests := map[string]bool{
+		"v1.3.9":         true,
+		"v1.4.0-rc.1":    true,
+		"v1.4.0":         false,
+		"v1.4.0+linux":   false,
+		"v2.0.0":         false,
+		"dev":            false,
+		"v1.4":           false,
+		"(devel)":        false,
+		"v0.9.0-nightly": true,
+	}
+	for running, outdated := range tests {
+		latest, err := c.Check(context.Background(), running)
+		if err != nil {
+			t.Fatalf("Check(%s): %v", running, err)
+		}
+		if got := latest != nil; got != outdated {
+			t.Errorf("Check(%s) = %+v, want out of date %t", running, latest, outdated)
+		}
+		if latest != nil && latest.Notes != "https://example.com/v1.4.0" {
+			t.Errorf("Check(%s) notes = %q", running, latest.Notes)
+		}
+	}
+}
+
+func TestLatestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing.json":
+			http.NotFound(w, r)
+		case "/bad.json":
+			w.Write([]byte(`{"version": "latest"}`))
+		}
+	}))
+	defer srv.Close()
+	for _, manifest := range []string{srv.URL + "/missing.json", srv.URL + "/bad.json", "gs://bucket-only", "manifest.json"} {
+		c := &Checker{Manifest: manifest}
+		if r, err := c.Latest(context.Background()); err == nil {
+			t.Errorf("Latest(%s) = %+v, want an error", manifest, r)
+		}
+	}
+}
+
+func TestManifestURL(t *testing.T) {
+	got, err := manifestURL("gs://releases/integration-test/latest.json")
+	if want := "https://storage.googleapis.com/releases/integration-test/latest.json"; err != nil || got != want {
+		t.Errorf("manifestURL = %s, %v, want %s", got, err, want)
+	}
+}
+
+func TestLatestCached(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"version": "v1.4.0"}`))
+	}))
+	defer srv.Close()
+	c := &Checker{Manifest: srv.URL + "/latest.json", Cache: &cache.Cache{Dir: t.TempDir(), TTL: time.Hour}}
+	for range 2 {
+		if r, err := c.Latest(context.Background()); err != nil || r.Version != "v1.4.0" {
+			t.Fatalf("Latest = %+v, %v", r, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("fetched the manifest %d times, want once", requests)
+	}
+}
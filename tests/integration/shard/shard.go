@@ -0,0 +1,44 @@
+// Package shard partitions the registered tests across parallel CI jobs.
+//
+// A test's shard depends only on its ID and the shard count, so every job
+// computes the same partition without coordinating, and adding a test moves
+// no other test to a different shard.
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Spec selects one shard of a run split into Count shards.
+type Spec struct {
+	Index int `json:"index"`
+	Count int `json:"count"`
+}
+
+// Validate checks that the spec names an existing shard.
+func (s Spec) Validate() error {
+	if s.Count < 1 {
+		return fmt.Errorf("shard count must be at least 1, got %d", s.Count)
+	}
+	if s.Index < 0 || s.Index >= s.Count {
+		return fmt.Errorf("shard index must be between 0 and %d, got %d", s.Count-1, s.Index)
+	}
+	return nil
+}
+
+// Contains reports whether test id belongs to the shard.
+func (s Spec) Contains(id string) bool {
+	return Of(id, s.Count) == s.Index
+}
+
+func (s Spec) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Of returns the shard, from 0 to count-1, that test id belongs to.
+func Of(id string, count int) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % uint32(count))
+}
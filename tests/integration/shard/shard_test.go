@@ -0,0 +1,46 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShardsPartitionTests(t *testing.T) {
+	const count = 4
+	var ids []string
+	for i := range 100 {
+		ids = append(ids, fmt.Sprintf("test-%d", i))
+	}
+	sizes := make([]int, count)
+	for _, id := range ids {
+		in := 0
+		for index := range count {
+			if (Spec{Index: index, Count: count}).Contains(id) {
+				in++
+				sizes[index]++
+			}
+		}
+		if in != 1 {
+			t.Errorf("%s is in %d shards, want 1", id, in)
+		}
+	}
+	for index, n := range sizes {
+		if n == 0 {
+			t.Errorf("shard %d of %d is empty: %v", index, count, sizes)
+		}
+	}
+	if Of("gcloud-tool-call", count) != Of("gcloud-tool-call", count) {
+		t.Error("Of is not deterministic")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, s := range []Spec{{0, 0}, {-1, 2}, {2, 2}} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%v) = nil, want an error", s)
+		}
+	}
+	if err := (Spec{Index: 1, Count: 2}).Validate(); err != nil {
+		t.Errorf("Validate(1/2) = %v", err)
+	}
+}
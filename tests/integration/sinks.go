@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/notify"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// sinkTimeout bounds each reporting sink, so an unreachable backend delays the
+// end of the run instead of hanging it.
+const sinkTimeout = 30 * time.Second
+
+// reportSink is an optional backend the run's results are published to, as
+// opposed to the local results, JUnit and artifact files.
+type reportSink struct {
+	name    string
+	publish func(ctx context.Context, run *report.Run) error
+	// published is logged after a successful publish.
+	published string
+}
+
+// publishSinks publishes results to each sink in turn. A sink that fails or
+// times out never fails the run: it is recorded in results.Degraded, which the
+// summary prints, and the run carries on with local-only reporting.
+func publishSinks(results *report.Run, sinks []reportSink) {
+	for _, s := range sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+		err := s.publish(ctx, results)
+		cancel()
+		if err != nil {
+			results.Degraded = append(results.Degraded, report.Degradation{Sink: s.name, Error: err.Error()})
+			continue
+		}
+		logger.Println(s.published)
+	}
+}
+
+// webhookSink posts a failed run's summary to the -notify-webhook url, with
+// a link to runURL.
+func webhookSink(url, runURL string) reportSink {
+	webhook := &notify.Webhook{URL: url, RunURL: runURL}
+	return reportSink{
+		name:      "webhook",
+		publish:   webhook.Notify,
+		published: "📣 Posted the failure summary to the webhook",
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// framingTest returns a test that feeds noise to the client ahead of
+// gcloud-mcp's stdout and checks that the corruption is reported at the
+// expected line rather than as an opaque connection failure.
+func framingTest(id, noise string, wantLine int) testCase {
+	return testCase{
+		id:       id,
+		requires: gcloudServer.Command[:1],
+		tags:     smoke,
+		run: func(t *testContext) error {
+			return testStdioFraming(t, noise, wantLine)
+		},
+	}
+}
+
+func testStdioFraming(t *testContext, noise string, wantLine int) error {
+	logger.Printf("🚀 Starting stdio framing test with %q ahead of the server's output...\n", noise)
+	// Called directly rather than through invokeTool, whose
+	// -detect-stdout-pollution would skip the noise instead of failing on it.
+	_, err := client.InvokeMCPTool(client.ToolCall{
+		ServerCmd:         gcloudServer.Command,
+		ToolName:          "run_gcloud_command",
+		ToolArgs:          map[string]any{"args": []string{"version"}},
+		StdoutNoise:       []byte(noise),
+		TerminateDuration: callDefaults.TerminateDuration,
+		Env:               t.Env(),
+	})
+	var fe *client.FramingError
+	if !errors.As(err, &fe) {
+		return &report.Failure{Reason: report.ReasonAssertion, Err: &report.Mismatch{
+			Message:  "assertion failed: corrupt stdio output was not reported as a framing error",
+			Expected: client.ErrFraming.Error(),
+			Actual:   fmt.Sprint(err),
+		}}
+	}
+	if err := report.Compare("assertion failed: framing error points at the wrong line", wantLine, fe.Line); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: %v\n", fe)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// roundtripContent is the object storage-roundtrip writes and reads back.
+const roundtripContent = "written by the storage-roundtrip test\n"
+
+// testStorageRoundtrip creates a bucket in the test's project, checks that
+// list_buckets lists it among valid bucket names, writes an object to it and
+// reads it back through storage-mcp, then deletes the bucket. It is tagged destructive, so -ephemeral-project runs it outside the
+// shared test project.
+func testStorageRoundtrip(t *testContext) error {
+	logger.Println("🚀 Starting storage-mcp bucket roundtrip integration test...")
+	session, err := t.openSession(client.ToolCall{ServerCmd: storageServer.Command})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	bucket := t.ResourceName()
+	t.Progress("creating bucket "+bucket, 0)
+	var created struct {
+		Success bool `json:"success"`
+	}
+	if err := callStorage(session, "create_bucket", map[string]any{"project_id": t.Project(), "bucket_name": bucket}, &created); err != nil {
+		return err
+	}
+	if !created.Success {
+		return report.Fail(report.ReasonToolError, "create_bucket did not create gs://%s", bucket)
+	}
+	defer func() {
+		if err := callStorage(session, "delete_bucket", map[string]any{"bucket_name": bucket, "force": true}, nil); err != nil {
+			logf(slog.LevelWarn, "⚠️  could not delete gs://%s: %v\n", bucket, err)
+		}
+	}()
+	t.Progress("listing buckets", 15)
+	listed, err := session.CallTool("list_buckets", map[string]any{"project_id": t.Project()})
+	if err != nil {
+		return fmt.Errorf("error calling list_buckets: %w", err)
+	}
+	if listed.IsError {
+		return report.Fail(report.ReasonToolError, "list_buckets failed: %s", listed.Output)
+	}
+	buckets, err := listedBuckets(listed.Text())
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(buckets, bucket) {
+		return report.Fail(report.ReasonAssertion, "assertion failed: list_buckets did not list the created gs://%s: %q", bucket, buckets)
+	}
+	t.Progress("writing an object", 30)
+	write := map[string]any{
+		"bucket_name":  bucket,
+		"object_name":  "roundtrip.txt",
+		"content":      base64.StdEncoding.EncodeToString([]byte(roundtripContent)),
+		"content_type": "text/plain",
+	}
+	if err := callStorage(session, "write_object", write, nil); err != nil {
+		return err
+	}
+	t.Progress("reading the object back", 60)
+	var read struct {
+		Content string `json:"content"`
+	}
+	if err := callStorage(session, "read_object_content", map[string]any{"bucket_name": bucket, "object_name": "roundtrip.txt"}, &read); err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: read_object_content returned other content than was written", roundtripContent, read.Content); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: gs://%s/roundtrip.txt read back as written\n", bucket)
+	return nil
+}
+
+// testStorageEmulatorObjects lists the emulator's test bucket and reads each
+// object it was seeded with through storage-mcp, checking that the server
+// sees what the harness wrote. It runs only with -use-emulators.
+func testStorageEmulatorObjects(t *testContext) error {
+	logger.Println("🚀 Starting storage-mcp emulator objects integration test...")
+	if storageEmulator == nil {
+		return report.Skip("no storage emulator; pass -use-emulators")
+	}
+	session, err := t.openSession(client.ToolCall{ServerCmd: storageServer.Command})
+	if err != nil {
+		return fmt.Errorf("opening session failed: %w", err)
+	}
+	defer session.Close()
+	t.Progress("listing objects", 0)
+	var listed struct {
+		Objects []string `json:"objects"`
+	}
+	if err := callStorage(session, "list_objects", map[string]any{"bucket_name": storageBucket}, &listed); err != nil {
+		return err
+	}
+	for i, o := range emulatorObjects {
+		if !slices.Contains(listed.Objects, o.name) {
+			return report.Fail(report.ReasonAssertion, "assertion failed: list_objects did not list %s of gs://%s: %q", o.name, storageBucket, listed.Objects)
+		}
+		t.Progress("reading "+o.name, 20+60*i/len(emulatorObjects))
+		var read struct {
+			Content string `json:"content"`
+		}
+		if err := callStorage(session, "read_object_content", map[string]any{"bucket_name": storageBucket, "object_name": o.name}, &read); err != nil {
+			return err
+		}
+		if err := report.Compare("assertion failed: read_object_content returned other content than the emulator was seeded with for "+o.name, o.content, read.Content); err != nil {
+			return err
+		}
+	}
+	logger.Printf("✅ Assertion passed: storage-mcp listed and read the %d objects gs://%s was seeded with\n", len(emulatorObjects), storageBucket)
+	return nil
+}
+
+// callStorage calls a storage-mcp tool and decodes the JSON text it returns
+// into out, if not nil. storage-mcp reports most failures as a successful
+// result whose JSON has an error field, so those fail the call too.
+func callStorage(session *client.Session, tool string, args map[string]any, out any) error {
+	result, err := session.CallTool(tool, args)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", tool, err)
+	}
+	if result.IsError {
+		return report.Fail(report.ReasonToolError, "%s failed: %s", tool, result.Output)
+	}
+	var failed struct {
+		Error     string `json:"error"`
+		ErrorType string `json:"error_type"`
+	}
+	if err := json.Unmarshal([]byte(result.Text()), &failed); err != nil {
+		return report.Fail(report.ReasonParse, "%s did not return JSON: %v\nOutput: %s", tool, err, result.Text())
+	}
+	if failed.Error != "" {
+		return report.Fail(report.ReasonToolError, "%s failed: %s (%s)", tool, failed.Error, failed.ErrorType)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(result.Text()), out); err != nil {
+		return report.Fail(report.ReasonParse, "%s returned unexpected JSON: %v\nOutput: %s", tool, err, result.Text())
+	}
+	return nil
+}
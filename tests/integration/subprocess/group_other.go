@@ -0,0 +1,15 @@
+//go:build !unix
+
+package subprocess
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Without process groups only the process itself can be killed.
+func setProcessGroup(*exec.Cmd) {}
+
+func killGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,180 @@
+// Package subprocess starts the harness's child processes, MCP servers and
+// the Gemini CLI, each in a process group of its own, and tracks them until
+// they are reaped.
+//
+// A server launched through npx is a tree of processes, and killing only the
+// one the harness started orphans the rest. Owning the whole group lets the
+// harness clean up after a test that left a server running, after a run that
+// was aborted, and after a child whose own children outlived it.
+package subprocess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Process is a tracked child process.
+type Process struct {
+	PID     int       `json:"pid"`
+	Command []string  `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("%s (running for %s)", p.Label(), time.Since(p.Started).Round(time.Second))
+}
+
+// Label names the process by PID and command line.
+func (p Process) Label() string {
+	return fmt.Sprintf("pid %d: %s", p.PID, strings.Join(p.Command, " "))
+}
+
+// Manager tracks the child processes started through it.
+type Manager struct {
+	// Refuse, if set, is called in place of starting each command, and Start
+	// returns its error, e.g. for a dry run to list the commands a test would
+	// run without running them.
+	Refuse func(cmd *exec.Cmd) error
+
+	mu       sync.Mutex
+	running  map[*exec.Cmd]Process
+	shutDown bool
+	onExit   func(Process, *os.ProcessState)
+}
+
+// ErrShutdown is returned by Start after Shutdown.
+var ErrShutdown = errors.New("not starting a process while the harness shuts down")
+
+// Default tracks every child process the harness starts.
+var Default = &Manager{}
+
+// outputDelay bounds how long Wait keeps reading a process's output after it
+// exited, which matters when its orphaned children still hold the pipes.
+const outputDelay = time.Second
+
+// Start starts cmd in a new process group and tracks it until Wait returns.
+// If cmd was made with exec.CommandContext, cancelling the context kills the
+// whole group.
+func (m *Manager) Start(cmd *exec.Cmd) error {
+	if m.Refuse != nil {
+		return m.Refuse(cmd)
+	}
+	if m.isShutDown() {
+		return ErrShutdown
+	}
+	setProcessGroup(cmd)
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = outputDelay
+	}
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error { return killGroup(cmd.Process) }
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Shutdown may have killed the others while cmd started.
+	if m.shutDown {
+		killGroup(cmd.Process)
+		return ErrShutdown
+	}
+	if m.running == nil {
+		m.running = make(map[*exec.Cmd]Process)
+	}
+	m.running[cmd] = Process{PID: cmd.Process.Pid, Command: cmd.Args, Started: time.Now()}
+	return nil
+}
+
+// Wait waits for cmd, which Start started, to exit, then kills what is left
+// of its process group and stops tracking it. Children left holding cmd's
+// output do not make a successful cmd fail.
+func (m *Manager) Wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	killGroup(cmd.Process)
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	m.mu.Lock()
+	p, onExit := m.running[cmd], m.onExit
+	delete(m.running, cmd)
+	m.mu.Unlock()
+	if onExit != nil && cmd.ProcessState != nil {
+		onExit(p, cmd.ProcessState)
+	}
+	return err
+}
+
+// OnExit makes Wait call fn with each process it reaps and the process's
+// final state, e.g. to record the resources it used, in place of any earlier
+// fn. A nil fn stops the calls.
+func (m *Manager) OnExit(fn func(Process, *os.ProcessState)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExit = fn
+}
+
+// Run starts cmd and waits for it, like cmd.Run.
+func (m *Manager) Run(cmd *exec.Cmd) error {
+	if err := m.Start(cmd); err != nil {
+		return err
+	}
+	return m.Wait(cmd)
+}
+
+// Running returns the processes started and not yet waited for, oldest
+// first. It is safe to call while they run, e.g. to see which server a hung
+// run is waiting on.
+func (m *Manager) Running() []Process {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Process, 0, len(m.running))
+	for _, p := range m.running {
+		out = append(out, p)
+	}
+	slices.SortFunc(out, func(a, b Process) int { return a.Started.Compare(b.Started) })
+	return out
+}
+
+// KillAll kills the process groups of every running process and returns the
+// processes it killed, oldest first. Their Wait calls still return as usual.
+func (m *Manager) KillAll() []Process {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var killed []Process
+	for cmd, p := range m.running {
+		if killGroup(cmd.Process) == nil {
+			killed = append(killed, p)
+		}
+	}
+	slices.SortFunc(killed, func(a, b Process) int { return a.Started.Compare(b.Started) })
+	return killed
+}
+
+// Kill kills the process group of cmd, which Start started, as a crash
+// would. Its Wait still returns as usual.
+func (m *Manager) Kill(cmd *exec.Cmd) error {
+	return killGroup(cmd.Process)
+}
+
+// Shutdown kills every running process like KillAll and makes later Starts
+// fail with ErrShutdown, so a run being stopped starts no more servers while
+// it writes its results.
+func (m *Manager) Shutdown() []Process {
+	m.mu.Lock()
+	m.shutDown = true
+	m.mu.Unlock()
+	return m.KillAll()
+}
+
+func (m *Manager) isShutDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shutDown
+}
@@ -0,0 +1,139 @@
+//go:build unix
+
+package subprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWaitKillsOrphanedChildren(t *testing.T) {
+	var m Manager
+	// The shell exits at once, leaving sleep behind in its process group, as
+	// npx leaves node behind.
+	cmd := exec.Command("sh", "-c", "sleep 60 & echo $!")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := m.Run(cmd); err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(pid, 0) == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if syscall.Kill(pid, 0) == nil {
+		t.Errorf("grandchild %d outlived Wait", pid)
+	}
+	if running := m.Running(); len(running) != 0 {
+		t.Errorf("Running after Wait = %v", running)
+	}
+}
+
+func TestKillAll(t *testing.T) {
+	var m Manager
+	cmd := exec.Command("sleep", "60")
+	if err := m.Start(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if running := m.Running(); len(running) != 1 || running[0].PID != cmd.Process.Pid {
+		t.Fatalf("Running = %v", running)
+	}
+	killed := m.KillAll()
+	if len(killed) != 1 || killed[0].Command[0] != "sleep" {
+		t.Errorf("KillAll = %v", killed)
+	}
+	if err := m.Wait(cmd); err == nil {
+		t.Error("Wait of a killed process succeeded")
+	}
+	if killed := m.KillAll(); len(killed) != 0 {
+		t.Errorf("second KillAll = %v", killed)
+	}
+}
+
+func TestContextCancelKillsGroup(t *testing.T) {
+	var m Manager
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 60; true")
+	start := time.Now()
+	if err := m.Run(cmd); err == nil {
+		t.Error("Run of a cancelled command succeeded")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("cancelled Run took %s", d)
+	}
+}
+
+func TestRefuse(t *testing.T) {
+	refused := errors.New("refused")
+	var got []string
+	m := Manager{Refuse: func(cmd *exec.Cmd) error {
+		got = cmd.Args
+		return refused
+	}}
+	cmd := exec.Command("sh", "-c", "exit 0")
+	if err := m.Run(cmd); err != refused {
+		t.Fatalf("Run() = %v, want the refusal", err)
+	}
+	if cmd.Process != nil || len(m.Running()) != 0 || strings.Join(got, " ") != "sh -c exit 0" {
+		t.Errorf("refused command started or was not passed on: process %v, args %q", cmd.Process, got)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var m Manager
+	cmd := exec.Command("sleep", "60")
+	if err := m.Start(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if killed := m.Shutdown(); len(killed) != 1 {
+		t.Errorf("Shutdown = %v", killed)
+	}
+	m.Wait(cmd)
+	next := exec.Command("sleep", "60")
+	if err := m.Start(next); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Start after Shutdown = %v, want ErrShutdown", err)
+	}
+	if next.Process != nil {
+		t.Error("Start after Shutdown started the process")
+	}
+}
+
+func TestOnExit(t *testing.T) {
+	var (
+		m      Manager
+		exited []Process
+	)
+	m.OnExit(func(p Process, state *os.ProcessState) {
+		if !state.Exited() {
+			t.Errorf("state of %s = %v", p, state)
+		}
+		exited = append(exited, p)
+	})
+	cmd := exec.Command("true")
+	if err := m.Run(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if len(exited) != 1 || exited[0].PID != cmd.Process.Pid {
+		t.Errorf("OnExit called with %v", exited)
+	}
+	m.OnExit(nil)
+	if err := m.Run(exec.Command("true")); err != nil {
+		t.Fatal(err)
+	}
+	if len(exited) != 1 {
+		t.Errorf("OnExit(nil) still called with %v", exited[1:])
+	}
+}
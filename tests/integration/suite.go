@@ -0,0 +1,245 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// testSuite holds the setup and cleanup shared by a group of tests. Add a test
+// to the suite with add. Every hook is optional.
+//
+// beforeAll runs once, before the first of the suite's tests that runs, and
+// afterAll after the last, even if beforeAll failed, so it must cope with
+// partial setup. beforeEach and afterEach wrap every test, including its
+// -mutate reruns; afterEach runs even if beforeEach or the test failed. Hooks
+// run in the gcloud sandbox and log of the test they run with, and receive
+// that test's context, except that beforeAll and afterAll get one with the
+// suite's name as the ID for publishing to the blackboard.
+//
+// A suite whose beforeAll is slow, e.g. because it creates cloud resources,
+// declares how long it usually takes as estimate. Such suites are prewarmed:
+// their beforeAll runs in parallel with the others' before the first test,
+// in a gcloud sandbox of its own and with its log on the console, rather than
+// in series as each suite's first test comes up. A suite that would not be
+// set up before the run's -timeout skips its tests instead of starting.
+type testSuite struct {
+	name string
+	// estimate is how long beforeAll usually takes; zero sets the suite up
+	// when its first test runs.
+	estimate   time.Duration
+	beforeAll  func(*testContext) error
+	afterAll   func(*testContext) error
+	beforeEach func(*testContext) error
+	afterEach  func(*testContext) error
+}
+
+// add returns tc as a test of s.
+func (s *testSuite) add(tc testCase) testCase {
+	tc.suite = s
+	return tc
+}
+
+// each runs run between s's beforeEach and afterEach hooks.
+func (s *testSuite) each(t *testContext, run func() error) error {
+	if s == nil {
+		return run()
+	}
+	var err error
+	if s.beforeEach != nil {
+		if err = s.beforeEach(t); err != nil {
+			err = fmt.Errorf("beforeEach of suite %s failed: %w", s.name, err)
+		}
+	}
+	if err == nil {
+		err = run()
+	}
+	if s.afterEach != nil {
+		err = withCleanupError(err, s.afterEach(t), "afterEach of suite "+s.name)
+	}
+	return err
+}
+
+// withCleanupError returns the outcome of a test whose cleanup returned
+// cleanupErr: a cleanup failure fails a test that passed or was skipped, and
+// is only logged for one that already failed.
+func withCleanupError(err, cleanupErr error, hook string) error {
+	switch {
+	case cleanupErr == nil:
+		return err
+	case err == nil || report.IsSkip(err):
+		return fmt.Errorf("%s failed: %w", hook, cleanupErr)
+	}
+	logf(slog.LevelError, "❌ %s failed: %v\n", hook, cleanupErr)
+	return err
+}
+
+// suiteRuns tracks the suites of the tests of one run.
+type suiteRuns struct {
+	board *blackboard.Board
+	seed  int64
+	// deadline, if set, is when the run is ended; a suite whose estimate
+	// does not fit before it is not set up.
+	deadline time.Time
+	// remaining counts the tests of each suite that have yet to finish.
+	remaining map[*testSuite]int
+	// setUp holds the outcome of beforeAll for every suite it ran for and
+	// whose afterAll has not run yet.
+	setUp map[*testSuite]error
+	order []*testSuite
+}
+
+func newSuiteRuns(tests []testCase, board *blackboard.Board, seed int64) *suiteRuns {
+	r := &suiteRuns{board: board, seed: seed, remaining: map[*testSuite]int{}, setUp: map[*testSuite]error{}}
+	for _, tc := range tests {
+		if tc.suite != nil {
+			r.remaining[tc.suite]++
+		}
+	}
+	return r
+}
+
+func (r *suiteRuns) context(s *testSuite, env []string) *testContext {
+	return &testContext{id: s.name, board: r.board, rand: testRand(r.seed, s.name), progress: &progress{test: s.name, started: time.Now()}, env: env}
+}
+
+// start runs s's beforeAll, with the servers it starts getting env, if it has
+// not run yet and returns its outcome.
+func (r *suiteRuns) start(s *testSuite, env []string) error {
+	if s == nil {
+		return nil
+	}
+	err, ok := r.setUp[s]
+	if !ok {
+		if err := r.fits(s); err != nil {
+			return err
+		}
+		err = r.setUpSuite(s, env)
+		r.setUp[s] = err
+		r.order = append(r.order, s)
+	}
+	if err != nil {
+		return fmt.Errorf("beforeAll of suite %s failed: %w", s.name, err)
+	}
+	return nil
+}
+
+// fits returns a skip if s's setup is not expected to finish before the
+// run's deadline.
+func (r *suiteRuns) fits(s *testSuite) error {
+	if r.deadline.IsZero() || s.estimate == 0 {
+		return nil
+	}
+	if left := time.Until(r.deadline); left < s.estimate {
+		return report.Skip("suite %s takes about %s to set up, more than the %s left before -timeout", s.name, s.estimate, left.Round(time.Second))
+	}
+	return nil
+}
+
+// setUpSuite runs s's beforeAll, if it has one, with env for the servers it
+// starts.
+func (r *suiteRuns) setUpSuite(s *testSuite, env []string) error {
+	if s.beforeAll == nil {
+		return nil
+	}
+	logger.Printf("🧰 Setting up suite %s\n", s.name)
+	start := time.Now()
+	err := s.beforeAll(r.context(s, env))
+	traceSuiteSetUp(s.name, start, err)
+	if s.estimate > 0 {
+		logger.Printf("🧰 Set up suite %s in %s (estimate %s)\n", s.name, time.Since(start).Round(time.Millisecond), s.estimate)
+	}
+	return err
+}
+
+// prewarm sets up, up to parallel at a time and longest estimate first, the
+// suites with an estimate that fits before the deadline, so their tests find
+// them ready. sandbox gives them a gcloud configuration while they run.
+func (r *suiteRuns) prewarm(parallel int, sandbox func() (gcloudSandbox, error)) {
+	var slow []*testSuite
+	for s := range r.remaining {
+		if s.estimate > 0 && s.beforeAll != nil && r.fits(s) == nil {
+			slow = append(slow, s)
+		}
+	}
+	if parallel <= 0 || len(slow) == 0 {
+		return
+	}
+	slices.SortFunc(slow, func(a, b *testSuite) int {
+		return cmp.Or(cmp.Compare(b.estimate, a.estimate), strings.Compare(a.name, b.name))
+	})
+	sb, err := sandbox()
+	if err != nil {
+		logf(slog.LevelError, "❌ error creating the gcloud sandbox for prewarming; suites set up with their first test: %v\n", err)
+		return
+	}
+	defer sb.remove()
+	names := make([]string, len(slow))
+	for i, s := range slow {
+		names[i] = s.name
+	}
+	logger.Printf("🔥 Prewarming suites %s, %d at a time\n", strings.Join(names, ", "), parallel)
+	errs := make([]error, len(slow))
+	limit := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, s := range slow {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			errs[i] = r.setUpSuite(s, sb.env())
+		}()
+	}
+	wg.Wait()
+	for i, s := range slow {
+		r.setUp[s] = errs[i]
+		r.order = append(r.order, s)
+	}
+}
+
+// done records that a test of s finished with err, tearing s down after its
+// last test with env for the servers afterAll starts, and returns the test's
+// outcome.
+func (r *suiteRuns) done(s *testSuite, env []string, err error) error {
+	if s == nil {
+		return err
+	}
+	if r.remaining[s]--; r.remaining[s] == 0 {
+		if _, ok := r.setUp[s]; ok {
+			err = withCleanupError(err, r.tearDown(s, env), "afterAll of suite "+s.name)
+		}
+	}
+	return err
+}
+
+func (r *suiteRuns) tearDown(s *testSuite, env []string) error {
+	delete(r.setUp, s)
+	if s.afterAll == nil {
+		return nil
+	}
+	logger.Printf("🧹 Tearing down suite %s\n", s.name)
+	return s.afterAll(r.context(s, env))
+}
+
+// close tears down the suites whose remaining tests did not run, e.g. after
+// stopOnFailure.
+func (r *suiteRuns) close() {
+	for i := len(r.order) - 1; i >= 0; i-- {
+		s := r.order[i]
+		if _, ok := r.setUp[s]; !ok {
+			continue
+		}
+		// No test and so no sandbox is left.
+		if err := r.tearDown(s, nil); err != nil {
+			logf(slog.LevelError, "❌ afterAll of suite %s failed: %v\n", s.name, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// A toolTable is a test template for checking one tool over many argument
+// sets: each row is a test of its own, <id>-<row name>, reported, retried and
+// selected with -run and -only like any other, so adding a case is a line in
+// a table rather than a new function.
+type toolTable struct {
+	// id prefixes the IDs of the rows' tests.
+	id        string
+	serverCmd []string
+	// requires lists the executables the rows need, as in testCase.
+	requires []string
+	suite    *testSuite
+	tags     []string
+	tool     string
+	// cacheable marks the calls read-only, so -result-cache-ttl reuses them.
+	cacheable bool
+	// stdout, if set, extracts the part of a result's text the rows check,
+	// e.g. gcloudStdout; the whole text by default.
+	stdout func(*client.Result) string
+	rows   []toolRow
+}
+
+// toolRow is one argument set of a toolTable and what the call must return.
+type toolRow struct {
+	name string
+	args map[string]any
+	// json requires the checked text to be JSON.
+	json bool
+	// contains lists text the checked text must all contain.
+	contains []string
+	// wantError, if set, is text the call's error must contain; the call
+	// must then fail.
+	wantError string
+	// checksProject marks a row that checks the configured project, as in
+	// testCase.
+	checksProject bool
+}
+
+// tests returns a test per row of tt.
+func (tt toolTable) tests() []testCase {
+	tests := make([]testCase, len(tt.rows))
+	for i, row := range tt.rows {
+		args, _ := json.Marshal(row.args)
+		tc := testCase{
+			id:            tt.id + "-" + row.name,
+			requires:      tt.requires,
+			tags:          tt.tags,
+			steps:         []string{"call " + tt.tool + " " + string(args)},
+			checksProject: row.checksProject,
+			run:           func(t *testContext) error { return tt.run(t, row) },
+		}
+		if tt.suite != nil {
+			tc = tt.suite.add(tc)
+		}
+		tests[i] = tc
+	}
+	return tests
+}
+
+func (tt toolTable) run(t *testContext, row toolRow) error {
+	logger.Printf("🚀 Starting %s-%s table test...\n", tt.id, row.name)
+	call := client.ToolCall{ServerCmd: tt.serverCmd, ToolName: tt.tool, ToolArgs: row.args, Cacheable: tt.cacheable}
+	if row.wantError != "" {
+		call.ExpectError = &client.ExpectedError{Message: row.wantError}
+	}
+	result, err := t.invokeTool(call)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", tt.tool, err)
+	}
+	if row.wantError != "" {
+		logger.Printf("✅ Assertion passed: %s failed as expected: %s\n", tt.tool, strings.SplitN(result.ErrorMessage, "\n", 2)[0])
+		return nil
+	}
+	if result.IsError {
+		return report.Fail(report.ReasonToolError, "%s failed: %s", tt.tool, result.Output)
+	}
+	text := result.Text()
+	if tt.stdout != nil {
+		text = tt.stdout(result)
+	}
+	if row.json && !json.Valid([]byte(text)) {
+		return report.Fail(report.ReasonParse, "%s did not return JSON\nOutput: %s", tt.tool, text)
+	}
+	for _, want := range row.contains {
+		if !strings.Contains(text, want) {
+			return report.Fail(report.ReasonAssertion, "assertion failed: %s output does not contain %q\nOutput: %s", tt.tool, want, text)
+		}
+	}
+	logger.Printf("✅ Assertion passed: %s returned what %s-%s expects\n", tt.tool, tt.id, row.name)
+	return nil
+}
+
+// gcloudReadTable runs run_gcloud_command over read-only commands, each a
+// gcloud-read-* test. Add a row rather than a test function for another
+// command.
+var gcloudReadTable = toolTable{
+	id:        "gcloud-read",
+	serverCmd: gcloudServer.Command,
+	requires:  gcloudServer.Command[:1],
+	suite:     gcloudSuite,
+	tool:      "run_gcloud_command",
+	cacheable: true,
+	stdout:    gcloudStdout,
+	rows: []toolRow{
+		{name: "version", args: gcloudArgs("version", "--format=json"), json: true, contains: []string{`"Google Cloud SDK"`}},
+		{name: "config-list", args: gcloudArgs("config", "list", "--format=json"), checksProject: true, json: true, contains: []string{testProject}},
+		{name: "config-get-project", args: gcloudArgs("config", "get-value", "project"), checksProject: true, contains: []string{testProject}},
+		{name: "configurations-list", args: gcloudArgs("config", "configurations", "list", "--format=json"), json: true},
+		{name: "auth-list", args: gcloudArgs("auth", "list", "--format=json"), json: true},
+		{name: "project-describe", args: gcloudArgs("projects", "describe", testProject, "--format=json"), json: true, contains: []string{`"projectId": "` + testProject + `"`}},
+		{name: "services-list", args: gcloudArgs("services", "list", "--enabled", "--format=json"), json: true, contains: []string{"storage.googleapis.com"}},
+		{name: "buckets-list", args: gcloudArgs("storage", "buckets", "list", "--format=json"), json: true},
+		{name: "logs-list", args: gcloudArgs("logging", "logs", "list", "--limit=5", "--format=json"), json: true},
+		{name: "topics-list", args: gcloudArgs("pubsub", "topics", "list", "--format=json"), json: true},
+	},
+}
+
+// exampleAddTable checks the example server's add tool over a few operands,
+// a table that runs without GCP.
+var exampleAddTable = toolTable{
+	id:        "example-add-table",
+	serverCmd: exampleServerCmd(),
+	tags:      smoke,
+	tool:      "add",
+	rows: []toolRow{
+		{name: "positive", args: map[string]any{"a": 2, "b": 40}, json: true, contains: []string{`"sum":42`}},
+		{name: "negative", args: map[string]any{"a": -5, "b": 3}, json: true, contains: []string{`"sum":-2`}},
+		{name: "zero", args: map[string]any{"a": 0, "b": 0}, json: true, contains: []string{`"sum":0`}},
+		{name: "missing-operand", args: map[string]any{"a": 1}, wantError: "missing properties"},
+	},
+}
+
+// gcloudArgs returns run_gcloud_command's arguments for a gcloud command.
+func gcloudArgs(args ...string) map[string]any {
+	return map[string]any{"args": args}
+}
+
+// gcloudStdout returns the text of a run_gcloud_command result up to the
+// STDERR section it appends when the command wrote to stderr.
+func gcloudStdout(result *client.Result) string {
+	text := result.Text()
+	if i := strings.Index(text, "STDERR"); i != -1 {
+		text = text[:i]
+	}
+	return text
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tags group tests across suites, so that e.g. a pull request runs only the
+// smoke tests while the nightly run runs everything. -tags and -skip-tags
+// select tests by them.
+const (
+	// tagSmoke marks the quick tests that together show a change did not
+	// break the harness or the servers' basics, for a run of about a minute.
+	tagSmoke = "smoke"
+	// tagSlow marks tests that take well over a few seconds, e.g. those
+	// prompting a model or fuzzing a server.
+	tagSlow = "slow"
+	// tagDestructive marks tests that create, change or delete cloud
+	// resources rather than only read them.
+	tagDestructive = "destructive"
+	// tagRequiresGCP marks tests whose servers reach Google Cloud. It is
+	// implied by requiring a registered server, so it is never set by hand.
+	tagRequiresGCP = "requires-gcp"
+)
+
+// The tags of tests that have a single one, to keep their registrations
+// short.
+var (
+	smoke = []string{tagSmoke}
+	slow  = []string{tagSlow}
+)
+
+// testTags returns the tags of tc, with those its requirements imply, sorted.
+func testTags(tc testCase) []string {
+	tags := slices.Clone(tc.tags)
+	for _, bin := range tc.requires {
+		if serverRegistry.ByBin(bin) != nil {
+			tags = append(tags, tagRequiresGCP)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// knownTags returns the harness's own tags and every tag of tests, sorted.
+func knownTags(tests []testCase) []string {
+	tags := []string{tagSmoke, tagSlow, tagDestructive, tagRequiresGCP}
+	for _, tc := range tests {
+		tags = append(tags, testTags(tc)...)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// tagFilter selects the tests with any of include, or every test if it is
+// empty, except those with any of exclude.
+type tagFilter struct {
+	include, exclude []string
+}
+
+// parseTagFilter parses the comma-separated tags of -tags and -skip-tags,
+// which must be tags of tests.
+func parseTagFilter(include, exclude string, tests []testCase) (tagFilter, error) {
+	known := knownTags(tests)
+	parse := func(flag, list string) ([]string, error) {
+		var tags []string
+		for _, tag := range strings.Split(list, ",") {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			if !slices.Contains(known, tag) {
+				return nil, fmt.Errorf("-%s: no test is tagged %q; known tags: %s", flag, tag, strings.Join(known, ", "))
+			}
+			tags = append(tags, tag)
+		}
+		return tags, nil
+	}
+	var f tagFilter
+	var err error
+	if f.include, err = parse("tags", include); err != nil {
+		return tagFilter{}, err
+	}
+	if f.exclude, err = parse("skip-tags", exclude); err != nil {
+		return tagFilter{}, err
+	}
+	return f, nil
+}
+
+// active reports whether f selects a subset of the tests.
+func (f tagFilter) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+func (f tagFilter) selects(tc testCase) bool {
+	tags := testTags(tc)
+	has := func(tag string) bool { return slices.Contains(tags, tag) }
+	if len(f.include) > 0 && !slices.ContainsFunc(f.include, has) {
+		return false
+	}
+	return !slices.ContainsFunc(f.exclude, has)
+}
+
+func (f tagFilter) String() string {
+	var parts []string
+	if len(f.include) > 0 {
+		parts = append(parts, "tagged "+strings.Join(f.include, " or "))
+	}
+	if len(f.exclude) > 0 {
+		parts = append(parts, "not tagged "+strings.Join(f.exclude, " or "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// harnessArtifacts are the files the runner writes to a test's artifacts
+// directory itself, which SaveArtifact refuses to overwrite.
+var harnessArtifacts = []string{"repro.sh", "output.log", "wire.jsonl", "tool-outputs.jsonl"}
+
+// retryTrace matches the wire traces of a test's retries, which are the
+// runner's too.
+var retryTrace = regexp.MustCompile(`^wire-[0-9]+\.jsonl$`)
+
+// SaveArtifact writes data as name to the test's artifacts directory, for a
+// file a reader of its failure needs that the log does not hold, such as an
+// object the test downloaded. The results list it under artifacts, also when
+// the test passes. name is a base name, and secrets in data are masked. It
+// does nothing without -artifacts.
+func (t *testContext) SaveArtifact(name string, data []byte) error {
+	if t.artifactsDir == "" {
+		return nil
+	}
+	if slices.Contains(harnessArtifacts, name) || retryTrace.MatchString(name) {
+		return fmt.Errorf("artifact %s would overwrite the runner's own", name)
+	}
+	path, err := saveArtifact(t.artifactsDir, name, []byte(redactor.String(string(data))))
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(t.artifacts, path) {
+		t.artifacts = append(t.artifacts, path)
+	}
+	return nil
+}
+
+// saveArtifact writes data as name to dir and returns its path.
+func saveArtifact(dir, name string, data []byte) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("artifact name %q must be a file name", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("saving artifact %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// saveFailureArtifacts saves what a failed test's tool calls left behind to
+// dir: each stdio server launch's stderr as stderr-<server>.log, numbered
+// from the second launch of a server on, and the raw result of every call in
+// tool-outputs.jsonl. It returns the paths it wrote.
+func saveFailureArtifacts(dir string, invocations []client.Invocation) []string {
+	var paths []string
+	save := func(name string, data []byte) {
+		path, err := saveArtifact(dir, name, data)
+		if err != nil {
+			logf(slog.LevelError, "❌ error saving %s: %v\n", name, err)
+			return
+		}
+		paths = append(paths, path)
+	}
+	launches := map[string]int{}
+	var outputs strings.Builder
+	for _, inv := range invocations {
+		if inv.Stderr != "" {
+			server := inv.Metrics.Server
+			launches[server]++
+			name := "stderr-" + server + ".log"
+			if n := launches[server]; n > 1 {
+				name = fmt.Sprintf("stderr-%s-%d.log", server, n)
+			}
+			save(name, []byte(redactor.String(inv.Stderr)))
+		}
+		if inv.Call.ToolName == "" {
+			continue
+		}
+		line := toolOutput{Server: inv.Metrics.Server, Tool: inv.Call.ToolName, Args: inv.Call.ToolArgs}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(inv.Output)); err == nil {
+			line.Output = json.RawMessage(compact.Bytes())
+		} else if inv.Output != "" {
+			line.Output = inv.Output
+		}
+		if inv.Err != nil {
+			line.Error = inv.Err.Error()
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			logf(slog.LevelError, "❌ error saving the output of %s: %v\n", inv.Call.ToolName, err)
+			continue
+		}
+		outputs.Write(redactor.JSON(data))
+		outputs.WriteByte('\n')
+	}
+	if outputs.Len() > 0 {
+		save("tool-outputs.jsonl", []byte(outputs.String()))
+	}
+	return paths
+}
+
+// toolOutput is a line of tool-outputs.jsonl. Output is the result as JSON,
+// or the text of an expected error.
+type toolOutput struct {
+	Server string `json:"server"`
+	Tool   string `json:"tool"`
+	Args   any    `json:"arguments,omitempty"`
+	Output any    `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// compressedArtifacts points the paths of artifacts an artifact budget
+// compressed at their .gz replacement.
+func compressedArtifacts(paths []string) {
+	for i, p := range paths {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			continue
+		}
+		if _, err := os.Stat(p + ".gz"); err == nil {
+			paths[i] = p + ".gz"
+		}
+	}
+}
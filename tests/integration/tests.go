@@ -0,0 +1,457 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/blackboard"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/coverage"
+	"github.com/googleapis/gcloud-mcp/tests/integration/geminicli"
+	"github.com/googleapis/gcloud-mcp/tests/integration/registry"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// projectIDKey holds the active gcloud project as reported through gcloud-mcp.
+var projectIDKey = blackboard.NewKey[string]("gcloud.project")
+
+// testProject is the project the test environment is configured with.
+const testProject = "gcloud-mcp-testing"
+
+// serverRegistry lists the servers under test. A new server needs only a
+// registration here: gemini-mcp-list expects it to be connected and it gets
+// tool-catalog and conformance tests.
+var (
+	serverRegistry = &registry.ServerRegistry{}
+
+	gcloudServer = serverRegistry.Register(registry.Server{
+		Name:      "gcloud",
+		Command:   []string{"gcloud-mcp"},
+		Transport: client.TransportStdio,
+		Tools:     []string{"run_gcloud_command"},
+	})
+	_ = serverRegistry.Register(registry.Server{
+		Name:      "observability",
+		Command:   []string{"observability-mcp"},
+		Transport: client.TransportStdio,
+	})
+	storageServer = serverRegistry.Register(registry.Server{
+		Name:      "storage",
+		Command:   []string{"storage-mcp"},
+		Transport: client.TransportStdio,
+		Tools:     []string{"list_objects", "read_object_content"},
+	})
+)
+
+var testCases = slices.Concat([]testCase{
+	{id: "gemini-mcp-list", requires: []string{"gemini"}, run: testGeminiMcpList},
+	gcloudSuite.add(testCase{id: "gcloud-tool-call", requires: gcloudServer.Command[:1], checksProject: true, tags: smoke, run: testCallGcloudMCPTool}),
+	gcloudSuite.add(testCase{id: "gcloud-denied-command", requires: gcloudServer.Command[:1], run: testGcloudDeniedCommand}),
+	gcloudSuite.add(testCase{id: "gcloud-iam-denied", requires: gcloudServer.Command[:1], run: testGcloudIAMDenied}),
+	gcloudSuite.add(testCase{id: "gcloud-meta-propagated", requires: gcloudServer.Command[:1], checksProject: true, run: testGcloudMetaPropagated}),
+	gcloudSuite.add(testCase{id: "gcloud-notifications", requires: gcloudServer.Command[:1], run: testGcloudNotifications}),
+	gcloudSuite.add(testCase{id: "gcloud-roots-changed", requires: gcloudServer.Command[:1], checksProject: true, run: testGcloudRootsChanged}),
+	gcloudSuite.add(testCase{id: "gcloud-cancel", requires: gcloudServer.Command[:1], platforms: linuxOnly, tags: slow, run: testGcloudCancel}),
+}, gcloudReadTable.tests(), []testCase{
+	{id: "gemini-prompt-project", requires: []string{"gemini", gcloudServer.Bin()}, checksProject: true, tags: slow, run: testGeminiPromptProject},
+}, catalogTests(), conformanceTests(), protocolVersionTests(), fuzzTests(), []testCase{
+	{id: "storage-resource-link", requires: storageServer.Command[:1], run: testStorageResourceLink},
+	{id: "storage-emulator-objects", requires: storageServer.Command[:1], run: testStorageEmulatorObjects},
+	{id: "storage-roundtrip", requires: storageServer.Command[:1], tags: []string{tagDestructive}, run: testStorageRoundtrip},
+	{id: "transport-parity", run: testTransportParity},
+	framingTest("stdio-banner-detected", "gcloud-mcp v0.0.0 starting...\n", 1),
+	framingTest("stdio-partial-frame-detected", "\n"+`{"jsonrpc":"2.0","method":"notifications/message","par`+"\n", 2),
+	// The example tests run against the embedded example server.
+	{id: "example-echo", tags: smoke, run: testExampleEcho},
+	{id: "example-add", tags: smoke, run: testExampleAdd},
+	{id: "example-countdown", tags: smoke, run: testExampleCountdown},
+	{id: "example-notifications", tags: smoke, run: testExampleNotifications},
+	{id: "example-chart", tags: smoke, run: testExampleChart},
+	{id: "example-resource-link", tags: smoke, run: testExampleResourceLink},
+	{id: "example-cancel", platforms: linuxOnly, tags: smoke, run: testExampleCancel},
+	{id: "example-repeat", tags: smoke, run: testExampleRepeat},
+	{id: "example-reconnect", tags: smoke, run: testExampleReconnect},
+}, exampleAddTable.tests())
+
+// suites declare how much of each group of tests a run must execute, rather
+// than skip or filter out, for its outcome to count.
+var suites = []coverage.Policy{
+	{Suite: "gemini", Tests: []string{"gemini-*"}, MinExecuted: 1},
+	{Suite: "gcloud", Tests: []string{"gcloud-*"}, MinPercent: 50},
+	// The registered servers' catalog tests skip until their snapshots are
+	// checked in; tool-catalog-example has one.
+	{Suite: "catalog", Tests: []string{"tool-catalog-*"}, MinExecuted: 1},
+	// transport-parity skips unless a server in the manifest declares several
+	// endpoints, which the checked-in one does not, so it is left out until CI
+	// runs with a multi-endpoint manifest.
+	{Suite: "protocol", Tests: []string{"stdio-*"}, MinPercent: 100},
+	// conformance-example needs nothing but the harness, so at least it runs.
+	{Suite: "conformance", Tests: []string{"conformance-*"}, MinExecuted: 1},
+	// protocol-versions-example needs nothing but the harness.
+	{Suite: "protocol-versions", Tests: []string{"protocol-versions-*"}, MinExecuted: 1},
+	// The registered servers' fuzz tests skip without -fuzz; fuzz-example
+	// always runs.
+	{Suite: "fuzz", Tests: []string{"fuzz-*"}, MinExecuted: 1},
+	// The example tests need nothing but the harness, so they always run.
+	{Suite: "example", Tests: []string{"example-*"}, MinPercent: 100},
+}
+
+// gcloudSuite starts gcloud-mcp once before its tests, so the first of them
+// does not pay for npx fetching the package and its latency is comparable
+// with the others'.
+var gcloudSuite = &testSuite{
+	name: "gcloud",
+	// npx fetches the package on a cold cache.
+	estimate: 20 * time.Second,
+	beforeAll: func(t *testContext) error {
+		tools, err := t.listTools(gcloudServer.Command)
+		if err != nil {
+			return fmt.Errorf("error warming up gcloud-mcp: %w", err)
+		}
+		logger.Printf("🔥 Warmed up gcloud-mcp (%d tools)\n", len(tools))
+		return nil
+	},
+}
+
+func testGeminiMcpList(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp integration test...")
+
+	// The text output format depends on the CLI version, so a version
+	// without a known format fails as such rather than as a mismatch.
+	version, err := detectGeminiVersion(t)
+	if err != nil {
+		return err
+	}
+	format, err := geminicli.ListFormatFor(version)
+	if err != nil {
+		return report.Fail(report.ReasonGeminiVersion, "%v", err)
+	}
+	logger.Printf("♊ Gemini CLI %s\n", version)
+
+	var (
+		listed []geminicli.Server
+		output []byte
+	)
+	for attempt := 1; ; attempt++ {
+		if listed, output, err = listGeminiServers(t, format); err != nil {
+			return err
+		}
+		var pending []string
+		for _, l := range listed {
+			if l.Pending() {
+				pending = append(pending, l.Name)
+			}
+		}
+		if len(pending) == 0 || attempt == geminiListAttempts {
+			break
+		}
+		logger.Printf("⏳ %s still starting; listing again in %s\n", strings.Join(pending, ", "), geminiListInterval)
+		time.Sleep(geminiListInterval)
+	}
+	// All returns the servers in a fixed order, so the first reported
+	// mismatch is stable.
+	for _, s := range serverRegistry.All() {
+		entry := servers.ByName(s.Name)
+		optional := entry != nil && entry.Optional
+		want := geminicli.Server{Name: s.Name, Command: "npx -y " + s.Bin(), Transport: s.Transport, Status: geminicli.StatusConnected}
+		i := slices.IndexFunc(listed, func(l geminicli.Server) bool { return l.Name == s.Name })
+		if i < 0 {
+			if optional {
+				logf(slog.LevelWarn, "⚠️  The optional %s server is not listed.\n", s.Name)
+				continue
+			}
+			return report.Fail(report.ReasonAssertion, "assertion failed: gemini mcp list does not list the %s server:\n%s", s.Name, output)
+		}
+		got := listed[i]
+		if optional && got.Status != geminicli.StatusConnected {
+			logf(slog.LevelWarn, "⚠️  The optional %s server is %s.\n", s.Name, got.Status)
+			continue
+		}
+		// The command may pass the server arguments after its executable.
+		if strings.HasPrefix(got.Command, want.Command+" ") {
+			got.Command = want.Command
+		}
+		// The scope is checked where the manifest declares one, and the
+		// trust where the manifest lists the server; either only for a
+		// server the settings files configure.
+		if entry != nil && entry.Scope != "" && got.Scope != "" {
+			want.Scope = entry.Scope
+		} else {
+			got.Scope = ""
+		}
+		if entry != nil && got.Trust != nil {
+			want.Trust = &entry.Trust
+		} else {
+			got.Trust = nil
+		}
+		if err := report.Compare(fmt.Sprintf("assertion failed: gemini mcp list shows the %s server differently", s.Name), want, got); err != nil {
+			return err
+		}
+		logger.Printf("✅ Assertion passed: The %s server is listed as connected.\n", s.Name)
+	}
+	return nil
+}
+
+func testCallGcloudMCPTool(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp tool call integration test...")
+	gcloudToolCall := client.ToolCall{
+		ServerCmd: gcloudServer.Command,
+		ToolName:  "run_gcloud_command",
+		ToolArgs: map[string]any{
+			"args": []string{"config", "list", "--format=json"},
+		},
+		Cacheable: true,
+	}
+
+	result, err := t.invokeTool(gcloudToolCall)
+	if err != nil {
+		return fmt.Errorf("error executing command: %w", err)
+	}
+	project, err := configuredProject(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: gcloud config reports an unexpected project", testProject, project); err != nil {
+		return err
+	}
+	logger.Printf("✅ Assertion passed: Tool call was successful\n")
+	return blackboard.Publish(t.board, projectIDKey, project, t.id)
+}
+
+// configuredProject returns core/project from the result of `gcloud config
+// list --format=json`.
+func configuredProject(result *client.Result) (string, error) {
+	if result.IsError {
+		return "", report.Fail(report.ReasonToolError, "gcloud config list failed: %s", result.Output)
+	}
+	if len(result.Content) == 0 {
+		return "", report.Fail(report.ReasonParse, "MCP output content is empty")
+	}
+
+	parsedText := gcloudStdout(result)
+
+	type gcloudConfig struct {
+		Core struct {
+			Project string `json:"project"`
+		} `json:"core"`
+	}
+	var config gcloudConfig
+	if err := json.Unmarshal([]byte(parsedText), &config); err != nil {
+		return "", report.Fail(report.ReasonParse, "error parsing gcloud config from MCP output: %v\nOutput: %s", err, parsedText)
+	}
+	return config.Core.Project, nil
+}
+
+func testGcloudDeniedCommand(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp denylist integration test...")
+	deniedToolCall := client.ToolCall{
+		ServerCmd: gcloudServer.Command,
+		ToolName:  "run_gcloud_command",
+		ToolArgs: map[string]any{
+			"args": []string{"interactive"},
+		},
+		ExpectError: &client.ExpectedError{Message: "Execution denied"},
+	}
+
+	result, err := t.invokeTool(deniedToolCall)
+	if err != nil {
+		return fmt.Errorf("denylisted command was not rejected: %w", err)
+	}
+	logger.Printf("✅ Assertion passed: Denylisted command was rejected: %s\n", strings.SplitN(result.ErrorMessage, "\n", 2)[0])
+	return nil
+}
+
+// lowPrivilegeSA is a service account without access to the test project's
+// resources, used to check that IAM denials surface as tool errors.
+var lowPrivilegeSA = os.Getenv("LOW_PRIVILEGE_SERVICE_ACCOUNT")
+
+func testGcloudIAMDenied(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp IAM denial integration test...")
+	if lowPrivilegeSA == "" {
+		return report.Skip("no low-privilege service account configured; set -low-privilege-sa or $LOW_PRIVILEGE_SERVICE_ACCOUNT")
+	}
+	deniedToolCall := client.ToolCall{
+		ServerCmd: gcloudServer.Command,
+		ToolName:  "run_gcloud_command",
+		ToolArgs: map[string]any{
+			"args": []string{"storage", "buckets", "list", "--project", testProject},
+		},
+		ImpersonateServiceAccount: lowPrivilegeSA,
+		// Both the "does not have storage.buckets.list access" and the
+		// "Permission 'storage.buckets.list' denied" forms name the permission.
+		ExpectError: &client.ExpectedError{Message: "storage.buckets.list"},
+	}
+
+	result, err := t.invokeTool(deniedToolCall)
+	if err != nil {
+		return fmt.Errorf("request as %s was not denied: %w", lowPrivilegeSA, err)
+	}
+	logger.Printf("✅ Assertion passed: Request as %s was denied: %s\n", lowPrivilegeSA, strings.SplitN(result.ErrorMessage, "\n", 2)[0])
+	return nil
+}
+
+// traceIDKey is the _meta key the harness uses to correlate a tool call with
+// server-side logs. Keys outside the MCP-reserved prefixes are free for
+// clients to use.
+const traceIDKey = "io.github.gcloud-mcp-integration/traceId"
+
+func testGcloudMetaPropagated(tc *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp _meta propagation integration test...")
+	traceID := fmt.Sprintf("%s-%016x", tc.id, tc.rand.Uint64())
+	metaToolCall := client.ToolCall{
+		ServerCmd: gcloudServer.Command,
+		ToolName:  "run_gcloud_command",
+		ToolArgs: map[string]any{
+			"args": []string{"config", "list", "--format=json"},
+		},
+		Meta: map[string]any{"progressToken": traceID, traceIDKey: traceID},
+	}
+
+	// A server must accept a progress token and unknown _meta keys, and
+	// gcloud-mcp reports progress for the token. What it echoes back,
+	// progress notifications or the trace ID in the result's _meta, must
+	// carry them unchanged.
+	result, err := tc.invokeTool(metaToolCall)
+	if err != nil {
+		return fmt.Errorf("call with _meta failed: %w", err)
+	}
+	project, err := configuredProject(result)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare("assertion failed: gcloud config reports an unexpected project with _meta set", testProject, project); err != nil {
+		return err
+	}
+	echoed := 0
+	for _, n := range result.Notifications {
+		if n.Kind != client.NotificationProgress {
+			continue
+		}
+		if err := report.Compare("assertion failed: server reported progress for another token", traceID, n.Token); err != nil {
+			return err
+		}
+		echoed++
+	}
+	if got, ok := result.Meta[traceIDKey]; ok {
+		if err := report.Compare("assertion failed: server echoed a different trace ID", traceID, fmt.Sprint(got)); err != nil {
+			return err
+		}
+		echoed++
+	}
+	if echoed == 0 {
+		return report.Fail(report.ReasonAssertion, "assertion failed: gcloud-mcp echoed neither the progress token nor %s", traceIDKey)
+	}
+	logger.Printf("✅ Assertion passed: Server echoed %s=%s unchanged %d times\n", traceIDKey, traceID, echoed)
+	return nil
+}
+
+func testGcloudNotifications(t *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp logging and list_changed notification integration test...")
+	toolCall := client.ToolCall{
+		ServerCmd: gcloudServer.Command,
+		ToolName:  "run_gcloud_command",
+		ToolArgs: map[string]any{
+			"args": []string{"config", "list", "--format=json"},
+		},
+		LogLevel: "debug",
+	}
+
+	result, err := t.invokeTool(toolCall)
+	if errors.Is(err, client.ErrNoLogging) {
+		// gcloud-mcp advertises only tools; its list_changed notifications
+		// are still checked.
+		logf(slog.LevelWarn, "⚠️  gcloud-mcp does not advertise logging, checking its list_changed notifications only")
+		toolCall.LogLevel = ""
+		result, err = t.invokeTool(toolCall)
+	}
+	if err != nil {
+		return fmt.Errorf("call failed: %w", err)
+	}
+	if _, err := configuredProject(result); err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, n := range result.Notifications {
+		counts[n.Kind]++
+		if n.Kind != client.NotificationListChanged {
+			continue
+		}
+		// A server may only announce changes to lists it advertised
+		// listChanged for.
+		caps := result.Capabilities
+		advertised := caps != nil && (n.Message == "tools" && caps.Tools != nil && caps.Tools.ListChanged ||
+			n.Message == "prompts" && caps.Prompts != nil && caps.Prompts.ListChanged ||
+			n.Message == "resources" && caps.Resources != nil && caps.Resources.ListChanged)
+		if !advertised {
+			return report.Fail(report.ReasonAssertion, "assertion failed: server sent %s without advertising listChanged for %s", n, n.Message)
+		}
+	}
+	logger.Printf("✅ Assertion passed: Server sent %d log and %d list_changed notifications, each for a list it advertised\n",
+		counts[client.NotificationLog], counts[client.NotificationListChanged])
+	return nil
+}
+
+func testGcloudRootsChanged(tc *testContext) error {
+	logger.Println("🚀 Starting gcloud-mcp roots/list_changed integration test...")
+	first, err := os.MkdirTemp("", tc.id+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(first)
+	second, err := os.MkdirTemp("", tc.id+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(second)
+
+	session, err := tc.openSession(client.ToolCall{ServerCmd: gcloudServer.Command, Roots: []string{first}})
+	if err != nil {
+		return fmt.Errorf("opening session with roots failed: %w", err)
+	}
+	defer session.Close()
+	configList := map[string]any{"args": []string{"config", "list", "--format=json"}}
+
+	// A server must keep serving the session after the client's roots change.
+	// gcloud-mcp runs gcloud the same way whatever the roots are, so the
+	// command's output must not change either; a server that lists the roots
+	// must list them again once they changed.
+	var outputs []string
+	var listed []int
+	for _, step := range []struct {
+		name  string
+		roots []string
+	}{{"before", nil}, {"after", []string{second}}} {
+		if step.roots != nil {
+			if err := session.SetRoots(step.roots...); err != nil {
+				return err
+			}
+		}
+		result, err := session.CallTool("run_gcloud_command", configList)
+		if err != nil {
+			return fmt.Errorf("call %s the roots changed failed: %w", step.name, err)
+		}
+		project, err := configuredProject(result)
+		if err != nil {
+			return err
+		}
+		if err := report.Compare("assertion failed: gcloud config reports an unexpected project "+step.name+" the roots changed", testProject, project); err != nil {
+			return err
+		}
+		outputs = append(outputs, gcloudStdout(result))
+		listed = append(listed, session.RootsListed())
+	}
+	if err := report.Compare("assertion failed: run_gcloud_command returned other output after the roots changed", outputs[0], outputs[1]); err != nil {
+		return err
+	}
+	if listed[0] > 0 && listed[1] == listed[0] {
+		return report.Fail(report.ReasonAssertion, "assertion failed: server listed the roots %d times but not again after roots/list_changed", listed[0])
+	}
+	logger.Printf("✅ Assertion passed: Server returned the same output after roots/list_changed and listed the roots %d times\n", listed[1])
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package tokens measures how much of a model's context a tool result takes
+// and checks it against per-tool budgets, so a tool whose output grows
+// large enough to crowd out Gemini's context fails a test instead of
+// going unnoticed.
+package tokens
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/config"
+)
+
+// bytesPerToken is the usual rule of thumb for English text and JSON with
+// Gemini's and similar tokenizers. Estimates are for budgets, not billing.
+const bytesPerToken = 4
+
+// Size is the size of a tool result.
+type Size struct {
+	Bytes int `json:"bytes"`
+	// Tokens approximates the tokens the result takes in a model's context.
+	Tokens int `json:"tokens"`
+}
+
+// Estimate approximates the tokens of text: one per bytesPerToken
+// characters, rounded up.
+func Estimate(text string) int {
+	return (utf8.RuneCountInString(text) + bytesPerToken - 1) / bytesPerToken
+}
+
+// Measure returns the size of result as a model receives it: the text of
+// its text blocks, and the compact JSON of its other blocks and of its
+// structured content.
+func Measure(result *mcp.CallToolResult) Size {
+	var text string
+	for _, c := range result.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			text += t.Text
+			continue
+		}
+		if data, err := json.Marshal(c); err == nil {
+			text += string(data)
+		}
+	}
+	if result.StructuredContent != nil {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			text += string(data)
+		}
+	}
+	return Size{Bytes: len(text), Tokens: Estimate(text)}
+}
+
+// Budget caps the output of one tool.
+type Budget struct {
+	// Server is the registered name of the tool's server; empty applies the
+	// budget to the tool on every server.
+	Server    string `yaml:"server,omitempty"`
+	Tool      string `yaml:"tool"`
+	MaxTokens int    `yaml:"max_tokens"`
+}
+
+// Budgets is the parsed budget file.
+type Budgets struct {
+	Entries []Budget `yaml:"budgets"`
+}
+
+// Load reads a budget file, which may be missing if optional is set (see
+// config.Read).
+func Load(path string, optional bool) (*Budgets, error) {
+	data, err := config.Read(path, optional)
+	if err != nil {
+		return nil, err
+	}
+	var b Budgets
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse token budgets %s: %w", path, err)
+	}
+	seen := map[[2]string]bool{}
+	for i, e := range b.Entries {
+		if e.Tool == "" || e.MaxTokens <= 0 {
+			return nil, fmt.Errorf("%s: budget %d needs a tool and a positive max_tokens", path, i+1)
+		}
+		key := [2]string{e.Server, e.Tool}
+		if seen[key] {
+			return nil, fmt.Errorf("%s: tool %s has more than one budget", path, e)
+		}
+		seen[key] = true
+	}
+	return &b, nil
+}
+
+func (e Budget) String() string {
+	if e.Server == "" {
+		return e.Tool
+	}
+	return e.Server + "/" + e.Tool
+}
+
+// Limit returns the token budget of tool on server, preferring a budget for
+// that server over one for the tool everywhere, or 0 if it has none.
+func (b *Budgets) Limit(server, tool string) int {
+	if b == nil {
+		return 0
+	}
+	limit := 0
+	for _, e := range b.Entries {
+		switch {
+		case e.Tool != tool:
+		case e.Server == server:
+			return e.MaxTokens
+		case e.Server == "":
+			limit = e.MaxTokens
+		}
+	}
+	return limit
+}
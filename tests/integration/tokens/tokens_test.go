@@ -0,0 +1,102 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestEstimate(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		// Characters, not bytes: each is three bytes of UTF-8.
+		{"日本語です", 2},
+	} {
+		if got := Estimate(tc.text); got != tc.want {
+			t.Errorf("Estimate(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: strings.Repeat("x", 10)},
+			&mcp.TextContent{Text: strings.Repeat("y", 6)},
+		},
+		StructuredContent: map[string]any{"n": 1},
+	}
+	// 16 bytes of text and 7 of {"n":1}.
+	if got := Measure(result); got.Bytes != 23 || got.Tokens != 6 {
+		t.Errorf("Measure = %+v, want 23 bytes and 6 tokens", got)
+	}
+	image := &mcp.CallToolResult{Content: []mcp.Content{&mcp.ImageContent{MIMEType: "image/png", Data: []byte("png")}}}
+	if got := Measure(image); got.Bytes == 0 {
+		t.Errorf("Measure(image) = %+v, want the size of its JSON", got)
+	}
+}
+
+func write(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "token_budgets.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLimit(t *testing.T) {
+	b, err := Load(write(t, `
+budgets:
+  - tool: run_gcloud_command
+    max_tokens: 8000
+  - server: gcloud
+    tool: run_gcloud_command
+    max_tokens: 2000
+  - tool: list_objects
+    max_tokens: 500
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		server, tool string
+		want         int
+	}{
+		{"gcloud", "run_gcloud_command", 2000},
+		{"other", "run_gcloud_command", 8000},
+		{"storage", "list_objects", 500},
+		{"storage", "read_object", 0},
+	} {
+		if got := b.Limit(tc.server, tc.tool); got != tc.want {
+			t.Errorf("Limit(%s, %s) = %d, want %d", tc.server, tc.tool, got, tc.want)
+		}
+	}
+	var none *Budgets
+	if none.Limit("gcloud", "run_gcloud_command") != 0 {
+		t.Error("a nil Budgets has a limit")
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	for _, content := range []string{
+		"budgets: [{tool: a}]",
+		"budgets: [{max_tokens: 10}]",
+		"budgets: [{tool: a, max_tokens: 10}, {tool: a, max_tokens: 20}]",
+	} {
+		if _, err := Load(write(t, content), false); err == nil {
+			t.Errorf("Load(%q) succeeded", content)
+		}
+	}
+	if b, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), true); err != nil || b.Limit("a", "b") != 0 {
+		t.Errorf("Load(missing, optional) = %v, %v", b, err)
+	}
+}
@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/gcloudauth"
+)
+
+// GoogleEndpoint is Google Cloud's OTLP endpoint, which stores spans in
+// Cloud Trace.
+const GoogleEndpoint = "https://telemetry.googleapis.com"
+
+// Exporter sends traces to an OTLP/HTTP endpoint in the JSON encoding.
+type Exporter struct {
+	// Endpoint is the base URL of the collector; spans are posted to
+	// Endpoint/v1/traces.
+	Endpoint string
+	// Headers are added to every request, e.g. an API key.
+	Headers map[string]string
+	// Service is the service.name of the spans.
+	Service string
+	// Project, if set, is the Google Cloud project authenticated exports are
+	// billed to and stored in.
+	Project string
+	// Token returns the bearer token of each export. Defaults, for
+	// GoogleEndpoint only, to gcloudauth.AccessToken.
+	Token      func(context.Context) (string, error)
+	HTTPClient *http.Client
+}
+
+// Export sends every span of t.
+func (e *Exporter) Export(ctx context.Context, t *Trace) error {
+	body, err := json.Marshal(e.request(t))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.Endpoint, "/")+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	if e.Token != nil || strings.TrimSuffix(e.Endpoint, "/") == GoogleEndpoint {
+		token, err := e.token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if e.Project != "" {
+			req.Header.Set("x-goog-user-project", e.Project)
+		}
+	}
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("OTLP export to %s failed: %s: %s", e.Endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (e *Exporter) token(ctx context.Context) (string, error) {
+	if e.Token != nil {
+		return e.Token(ctx)
+	}
+	return gcloudauth.AccessToken(ctx)
+}
+
+// The OTLP/JSON encoding of ExportTraceServiceRequest: IDs are hex and
+// 64-bit integers strings.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []wireSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type wireSpan struct {
+	TraceID      string     `json:"traceId"`
+	SpanID       string     `json:"spanId"`
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Kind         int        `json:"kind"`
+	Start        string     `json:"startTimeUnixNano"`
+	End          string     `json:"endTimeUnixNano"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+	Status       *status    `json:"status,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	String *string  `json:"stringValue,omitempty"`
+	Bool   *bool    `json:"boolValue,omitempty"`
+	Int    *string  `json:"intValue,omitempty"`
+	Double *float64 `json:"doubleValue,omitempty"`
+}
+
+type status struct {
+	// Code 2 is STATUS_CODE_ERROR.
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// spanKindInternal is SPAN_KIND_INTERNAL.
+const spanKindInternal = 1
+
+func (e *Exporter) request(t *Trace) exportRequest {
+	service := e.Service
+	if service == "" {
+		service = "mcp-integration"
+	}
+	var spans []wireSpan
+	for _, s := range t.Spans() {
+		w := wireSpan{
+			TraceID:    t.ID.String(),
+			SpanID:     s.ID.String(),
+			Name:       s.Name,
+			Kind:       spanKindInternal,
+			Start:      unixNano(s.Start),
+			End:        unixNano(s.End),
+			Attributes: attributes(s.Attributes),
+		}
+		if !s.Parent.IsZero() {
+			w.ParentSpanID = s.Parent.String()
+		}
+		if s.Error != "" {
+			w.Status = &status{Code: 2, Message: s.Error}
+		}
+		spans = append(spans, w)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: attributes(map[string]any{"service.name": service})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "integration"}, Spans: spans}},
+	}}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// attributes encodes attrs sorted by key.
+func attributes(attrs map[string]any) []keyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out []keyValue
+	for _, k := range keys {
+		var v anyValue
+		switch a := attrs[k].(type) {
+		case string:
+			v.String = &a
+		case bool:
+			v.Bool = &a
+		case int:
+			s := strconv.Itoa(a)
+			v.Int = &s
+		case int64:
+			s := strconv.FormatInt(a, 10)
+			v.Int = &s
+		case float64:
+			v.Double = &a
+		default:
+			s := fmt.Sprint(a)
+			v.String = &s
+		}
+		out = append(out, keyValue{Key: k, Value: v})
+	}
+	return out
+}
+
+// ParseHeaders parses headers in the format of OTEL_EXPORTER_OTLP_HEADERS:
+// comma-separated key=value pairs with URL-encoded values.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q; want key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %q: %w", pair, err)
+		}
+		headers[strings.TrimSpace(k)] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,92 @@
+// Package tracing records the phases of an integration run as OpenTelemetry
+// spans, the run, each test, its tool calls with their connect and call
+// phases, and the assertions after them, and exports them over OTLP/HTTP,
+// so a failed nightly run can be explored in Cloud Trace or any other OTLP
+// backend with the timing of each phase.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero reports whether id is unset.
+func (id SpanID) IsZero() bool { return id == SpanID{} }
+
+// Span is one timed phase.
+type Span struct {
+	ID     SpanID
+	Parent SpanID
+	Name   string
+	Start  time.Time
+	End    time.Time
+	// Attributes hold string, bool, int, int64 and float64 values.
+	Attributes map[string]any
+	// Error, if set, marks the span as failed with this message.
+	Error string
+}
+
+// Trace collects the spans of one run. Its methods are safe for concurrent
+// use, and do nothing on a nil Trace, so a run without tracing need not
+// check.
+type Trace struct {
+	ID TraceID
+	// Root is the ID of the run's span, the parent of the top-level spans.
+	Root SpanID
+
+	mu    sync.Mutex
+	spans []Span
+}
+
+// New returns a trace with random IDs.
+func New() *Trace {
+	t := &Trace{}
+	rand.Read(t.ID[:])
+	t.Root = newSpanID()
+	return t
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id.IsZero() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// Add records s, giving it an ID if it has none, and returns its ID.
+func (t *Trace) Add(s Span) SpanID {
+	if t == nil {
+		return SpanID{}
+	}
+	if s.ID.IsZero() {
+		s.ID = newSpanID()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, s)
+	return s.ID
+}
+
+// Spans returns the spans recorded so far.
+func (t *Trace) Spans() []Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.spans)
+}
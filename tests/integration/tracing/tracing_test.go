@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	var got exportRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if p := r.Header.Get("x-goog-user-project"); p != "test-project" {
+			t.Errorf("x-goog-user-project = %q", p)
+		}
+		if key := r.Header.Get("x-api-key"); key != "secret" {
+			t.Errorf("x-api-key = %q", key)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	tr := New()
+	start := time.Unix(100, 0)
+	test := tr.Add(Span{Parent: tr.Root, Name: "test a", Start: start, End: start.Add(time.Second), Error: "wrong output",
+		Attributes: map[string]any{"test.id": "a", "attempt": 2, "retried": true}})
+	tr.Add(Span{Parent: test, Name: "connect", Start: start, End: start.Add(time.Millisecond)})
+	tr.Add(Span{ID: tr.Root, Name: "run", Start: start, End: start.Add(2 * time.Second)})
+
+	e := &Exporter{
+		Endpoint: srv.URL + "/",
+		Headers:  map[string]string{"x-api-key": "secret"},
+		Project:  "test-project",
+		Token:    func(context.Context) (string, error) { return "tok", nil },
+	}
+	if err := e.Export(context.Background(), tr); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("request = %+v", got)
+	}
+	if a := got.ResourceSpans[0].Resource.Attributes; len(a) != 1 || *a[0].Value.String != "mcp-integration" {
+		t.Errorf("resource attributes = %+v", a)
+	}
+	spans := map[string]wireSpan{}
+	for _, s := range got.ResourceSpans[0].ScopeSpans[0].Spans {
+		if s.TraceID != tr.ID.String() {
+			t.Errorf("%s has trace ID %s, want %s", s.Name, s.TraceID, tr.ID)
+		}
+		spans[s.Name] = s
+	}
+	run, testSpan, connect := spans["run"], spans["test a"], spans["connect"]
+	if run.SpanID != tr.Root.String() || run.ParentSpanID != "" {
+		t.Errorf("run span = %+v", run)
+	}
+	if testSpan.ParentSpanID != run.SpanID || connect.ParentSpanID != testSpan.SpanID {
+		t.Errorf("spans are not nested: %+v", spans)
+	}
+	if testSpan.Start != "100000000000" || testSpan.End != "101000000000" {
+		t.Errorf("test span ran %s..%s", testSpan.Start, testSpan.End)
+	}
+	if testSpan.Status == nil || testSpan.Status.Code != 2 || testSpan.Status.Message != "wrong output" || connect.Status != nil {
+		t.Errorf("statuses = %+v, %+v", testSpan.Status, connect.Status)
+	}
+	// Attributes are sorted by key and typed.
+	a := testSpan.Attributes
+	if len(a) != 3 || a[0].Key != "attempt" || *a[0].Value.Int != "2" || !*a[1].Value.Bool || *a[2].Value.String != "a" {
+		t.Errorf("attributes = %+v", a)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	err := (&Exporter{Endpoint: srv.URL}).Export(context.Background(), New())
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Export = %v", err)
+	}
+}
+
+func TestNilTrace(t *testing.T) {
+	var tr *Trace
+	if id := tr.Add(Span{Name: "x"}); !id.IsZero() || tr.Spans() != nil {
+		t.Errorf("nil trace recorded a span")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h, err := ParseHeaders("api-key=a%20b, x-tenant = ci ,")
+	if err != nil || len(h) != 2 || h["api-key"] != "a b" || h["x-tenant"] != "ci" {
+		t.Errorf("ParseHeaders = %v, %v", h, err)
+	}
+	if _, err := ParseHeaders("no-value"); err == nil {
+		t.Error("ParseHeaders(no-value) succeeded")
+	}
+}
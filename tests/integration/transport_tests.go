@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/catalog"
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/features"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// testTransportParity lists the tools of every manifest server that declares
+// more than one endpoint over each endpoint separately and checks that they
+// all return the same catalog.
+func testTransportParity(t *testContext) error {
+	logger.Println("🚀 Starting transport parity test...")
+	if !features.Enabled(client.FeatureNetworkTransports) {
+		return report.Skip("network transports are disabled; enable feature %s", client.FeatureNetworkTransports)
+	}
+	checked := 0
+	for _, s := range servers.Servers {
+		if len(s.Endpoints) < 2 {
+			continue
+		}
+		var first *catalog.Snapshot
+		for _, e := range s.Endpoints {
+			tools, err := client.ListTools(client.ToolCall{
+				ServerCmd:         []string{s.Bin},
+				Endpoints:         []client.Endpoint{e},
+				TerminateDuration: callDefaults.TerminateDuration,
+				Env:               t.Env(),
+			})
+			if err != nil {
+				return fmt.Errorf("error listing %s tools over %s: %w", s.Name, e, err)
+			}
+			got, err := catalog.FromTools(s.Name, tools)
+			if err != nil {
+				return report.Fail(report.ReasonParse, "error reading %s tool catalog over %s: %v", s.Name, e, err)
+			}
+			if first == nil {
+				first = got
+				continue
+			}
+			message := fmt.Sprintf("assertion failed: %s lists different tools over %s than over %s", s.Name, e, s.Endpoints[0])
+			if err := report.Compare(message, first, got); err != nil {
+				return err
+			}
+		}
+		logger.Printf("✅ Assertion passed: %s lists the same %d tools over %d transports\n", s.Name, len(first.Tools), len(s.Endpoints))
+		checked++
+	}
+	if checked == 0 {
+		return report.Skip("no server in the manifest declares more than one endpoint")
+	}
+	return nil
+}
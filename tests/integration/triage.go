@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/triage"
+)
+
+// runTriage implements `triage [-artifacts DIR] [-out FILE] <results.json>`,
+// an interactive walk through a run's failures that records a disposition
+// for each in a JSON file.
+func runTriage(args []string) int {
+	fs := flag.NewFlagSet("triage", flag.ContinueOnError)
+	artifactsDir := fs.String("artifacts", "", "the run's -artifacts directory, to browse each failure's artifacts")
+	outPath := fs.String("out", "", "file the decisions are written to after each one (default: the results file with a .triage.json suffix)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: integration-test triage [-artifacts DIR] [-out FILE] <results.json>")
+		return exitUsage
+	}
+	results, err := report.ReadJSON(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	if *outPath == "" {
+		*outPath = strings.TrimSuffix(fs.Arg(0), ".json") + ".triage.json"
+	}
+	decisions, err := triage.Load(*outPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	if decisions != nil && !decisions.Of(results) {
+		fmt.Fprintf(os.Stderr, "%s is the triage of another run; pass -out to start a new one\n", *outPath)
+		return exitUsage
+	}
+	if decisions != nil {
+		logger.Printf("📝 Resuming %s: %d decisions so far\n", *outPath, len(decisions.Decisions))
+	}
+	session := &triage.Session{
+		Run:       results,
+		Artifacts: *artifactsDir,
+		Triage:    decisions,
+		Author:    currentUser(),
+		Save:      func(f *triage.File) error { return f.Save(*outPath) },
+	}
+	if err := session.Interact(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	return exitPass
+}
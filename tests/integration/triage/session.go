@@ -0,0 +1,332 @@
+package triage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+const help = `Commands:
+  n, p                  next or previous failure
+  <number>              go to that failure
+  l                     list the failures and their dispositions
+  log                   the test's output
+  trace                 its notifications, earlier attempts, downgrades and leaked processes
+  diff                  the expected and actual values of its failed comparison
+  files                 its artifacts
+  cat <file>            print one of its artifacts
+  bug|flake|env [note]  record the failure's disposition and go to the next undecided one
+  q                     quit
+`
+
+// Session is an interactive triage of a run's failures.
+type Session struct {
+	Run *report.Run
+	// Artifacts is the run's -artifacts directory, or "" without one.
+	Artifacts string
+	// Triage holds the decisions so far, e.g. from an earlier session.
+	Triage *File
+	// Author signs the decisions.
+	Author string
+	// Save, if set, is called after every decision.
+	Save func(*File) error
+
+	failures []report.TestResult
+	current  int
+}
+
+// Failures returns the tests of run to triage: the failed and flaky ones, in
+// run order.
+func Failures(run *report.Run) []report.TestResult {
+	var out []report.TestResult
+	for _, t := range run.Tests {
+		if t.Status == report.StatusFailed || t.Status == report.StatusFlaky {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Interact runs the session, reading commands from in until it ends or
+// reads q, starting at the first undecided failure.
+func (s *Session) Interact(in io.Reader, out io.Writer) error {
+	if s.Triage == nil {
+		s.Triage = New(s.Run)
+	}
+	s.failures = Failures(s.Run)
+	if len(s.failures) == 0 {
+		fmt.Fprintln(out, "✅ Nothing to triage: no test failed or was flaky.")
+		return nil
+	}
+	s.current = 0
+	s.next()
+	s.show(out)
+	lines := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "triage [%d/%d]> ", s.current+1, len(s.failures))
+		if !lines.Scan() {
+			fmt.Fprintln(out)
+			return lines.Err()
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(lines.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "":
+		case "q", "quit":
+			return nil
+		case "?", "h", "help":
+			fmt.Fprint(out, help)
+		case "n":
+			s.move(out, s.current+1)
+		case "p":
+			s.move(out, s.current-1)
+		case "l":
+			s.list(out)
+		case "log":
+			s.log(out)
+		case "trace":
+			s.trace(out)
+		case "diff":
+			s.diff(out)
+		case "files":
+			s.files(out)
+		case "cat":
+			s.cat(out, arg)
+		case string(Bug), string(Flake), string(Env):
+			if err := s.decide(out, Disposition(cmd), arg); err != nil {
+				return err
+			}
+		default:
+			if i, err := strconv.Atoi(cmd); err == nil {
+				s.move(out, i-1)
+				continue
+			}
+			fmt.Fprintf(out, "unknown command %q\n%s", cmd, help)
+		}
+	}
+}
+
+// next moves from the current failure to the first undecided one at or
+// after it, wrapping around. It stays put if all are decided.
+func (s *Session) next() {
+	for i := range s.failures {
+		j := (s.current + i) % len(s.failures)
+		if s.Triage.Lookup(s.failures[j].ID) == nil {
+			s.current = j
+			return
+		}
+	}
+}
+
+func (s *Session) move(out io.Writer, i int) {
+	if i < 0 || i >= len(s.failures) {
+		fmt.Fprintf(out, "no failure %d; there are %d\n", i+1, len(s.failures))
+		return
+	}
+	s.current = i
+	s.show(out)
+}
+
+func (s *Session) test() report.TestResult {
+	return s.failures[s.current]
+}
+
+// show prints the current failure's summary.
+func (s *Session) show(out io.Writer) {
+	t := s.test()
+	fmt.Fprintf(out, "\n── [%d/%d] %s ──\n", s.current+1, len(s.failures), t.ID)
+	fmt.Fprintf(out, "status:   %s (%s)\n", t.Status, t.Reason)
+	fmt.Fprintf(out, "error:    %s\n", t.Error)
+	fmt.Fprintf(out, "duration: %s\n", t.Duration.Round(time.Millisecond))
+	if t.Repro != "" {
+		fmt.Fprintf(out, "repro:    %s\n", t.Repro)
+	}
+	if t.WireTrace != "" {
+		fmt.Fprintf(out, "wire:     %s\n", t.WireTrace)
+	}
+	if d := s.Triage.Lookup(t.ID); d != nil {
+		fmt.Fprintf(out, "decided:  %s\n", describe(d))
+	}
+	var more []string
+	if t.Log != "" {
+		more = append(more, "log")
+	}
+	if len(t.Timeline)+len(t.FailedAttempts)+len(t.Downgrades)+len(t.Leaked) > 0 {
+		more = append(more, "trace")
+	}
+	if t.Mismatch != nil {
+		more = append(more, "diff")
+	}
+	if s.artifactDir() != "" {
+		more = append(more, "files")
+	}
+	if len(more) > 0 {
+		fmt.Fprintf(out, "see:      %s\n", strings.Join(more, ", "))
+	}
+}
+
+func describe(d *Decision) string {
+	s := string(d.Disposition)
+	if d.Note != "" {
+		s += ": " + d.Note
+	}
+	if d.Author != "" {
+		s += " (" + d.Author + ")"
+	}
+	return s
+}
+
+func (s *Session) list(out io.Writer) {
+	for i, t := range s.failures {
+		marker := " "
+		if i == s.current {
+			marker = ">"
+		}
+		decided := "undecided"
+		if d := s.Triage.Lookup(t.ID); d != nil {
+			decided = describe(d)
+		}
+		fmt.Fprintf(out, "%s %2d. %-40s %-20s %s\n", marker, i+1, t.ID, t.Reason, decided)
+	}
+}
+
+func (s *Session) log(out io.Writer) {
+	if t := s.test(); t.Log != "" {
+		fmt.Fprint(out, t.Log)
+		if !strings.HasSuffix(t.Log, "\n") {
+			fmt.Fprintln(out)
+		}
+		return
+	}
+	fmt.Fprintln(out, "The test logged nothing.")
+}
+
+func (s *Session) trace(out io.Writer) {
+	t := s.test()
+	if len(t.Timeline)+len(t.FailedAttempts)+len(t.Downgrades)+len(t.Leaked) == 0 {
+		fmt.Fprintln(out, "The test has no trace.")
+		return
+	}
+	for _, n := range t.Timeline {
+		fmt.Fprintf(out, "%s  %s %s\n", n.At.Format("15:04:05.000"), n.Server, n)
+	}
+	for i, a := range t.FailedAttempts {
+		fmt.Fprintf(out, "attempt %d failed after %s (%s): %s\n", i+1, a.Duration.Round(time.Millisecond), a.Reason, a.Error)
+	}
+	for _, d := range t.Downgrades {
+		fmt.Fprintf(out, "fell back from %s: %s\n", d.From, d.Err)
+	}
+	for _, p := range t.Leaked {
+		fmt.Fprintf(out, "leaked process %v\n", p)
+	}
+}
+
+func (s *Session) diff(out io.Writer) {
+	m := s.test().Mismatch
+	switch {
+	case m == nil:
+		fmt.Fprintln(out, "The test failed no comparison.")
+	case m.Diff != "":
+		fmt.Fprintln(out, m.Message)
+		fmt.Fprint(out, m.Diff)
+	default:
+		fmt.Fprintf(out, "%s\nexpected: %s\nactual:   %s\n", m.Message, m.Expected, m.Actual)
+	}
+}
+
+// artifactDir returns the current test's artifacts directory, or "" if it
+// has none.
+func (s *Session) artifactDir() string {
+	if s.Artifacts == "" {
+		return ""
+	}
+	dir := filepath.Join(s.Artifacts, s.test().ID)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+func (s *Session) files(out io.Writer) {
+	dir := s.artifactDir()
+	if dir == "" {
+		fmt.Fprintln(out, "The test has no artifacts.")
+		return
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(out, "%10d  %s\n", info.Size(), filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+	}
+}
+
+// cat prints an artifact of the current test, decompressing one the
+// artifact budget gzipped.
+func (s *Session) cat(out io.Writer, name string) {
+	dir := s.artifactDir()
+	if dir == "" || !filepath.IsLocal(name) {
+		fmt.Fprintf(out, "no artifact %q; see files\n", name)
+		return
+	}
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		return
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			fmt.Fprintf(out, "❌ %v\n", err)
+			return
+		}
+		r = gz
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+	}
+	fmt.Fprintln(out)
+}
+
+// decide records the current failure's disposition, saves the triage and
+// moves on to the next undecided failure.
+func (s *Session) decide(out io.Writer, disposition Disposition, note string) error {
+	t := s.test()
+	s.Triage.Decide(Decision{Test: t.ID, Reason: t.Reason, Disposition: disposition, Note: note, Author: s.Author, At: time.Now()})
+	if s.Save != nil {
+		if err := s.Save(s.Triage); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "📝 %s: %s\n", t.ID, disposition)
+	before := s.current
+	s.next()
+	if s.Triage.Lookup(s.test().ID) != nil {
+		fmt.Fprintf(out, "✅ All %d failures are triaged; q to quit.\n", len(s.failures))
+		return nil
+	}
+	if s.current != before {
+		s.show(out)
+	}
+	return nil
+}
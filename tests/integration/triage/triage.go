@@ -0,0 +1,116 @@
+// Package triage records what the people going through a run's failures
+// decided about each: a bug, a flake or a problem with the environment. The
+// decisions are kept in a JSON file next to the results, for trend tooling
+// to count how often each test fails for each cause.
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Disposition is the cause a failure was attributed to.
+type Disposition string
+
+const (
+	// Bug is a genuine defect in a server or the harness.
+	Bug Disposition = "bug"
+	// Flake is a failure that does not reproduce.
+	Flake Disposition = "flake"
+	// Env is a failure caused by the environment the run was in, e.g.
+	// credentials, quotas or an outage.
+	Env Disposition = "env"
+)
+
+// Dispositions lists the valid dispositions.
+var Dispositions = []Disposition{Bug, Flake, Env}
+
+// ParseDisposition returns the disposition named s.
+func ParseDisposition(s string) (Disposition, error) {
+	if d := Disposition(s); slices.Contains(Dispositions, d) {
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown disposition %q; want bug, flake or env", s)
+}
+
+// Decision is the disposition of one failed test.
+type Decision struct {
+	Test        string      `json:"test"`
+	Reason      string      `json:"reason,omitempty"`
+	Disposition Disposition `json:"disposition"`
+	Note        string      `json:"note,omitempty"`
+	Author      string      `json:"author,omitempty"`
+	At          time.Time   `json:"at"`
+}
+
+// File is the triage of one run.
+type File struct {
+	// Started, Seed and Harness identify the run the decisions are about.
+	Started   time.Time  `json:"started"`
+	Seed      int64      `json:"seed"`
+	Harness   string     `json:"harness,omitempty"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// New returns an empty triage of run.
+func New(run *report.Run) *File {
+	return &File{Started: run.Started, Seed: run.Seed, Harness: run.Harness}
+}
+
+// Of reports whether f is the triage of run.
+func (f *File) Of(run *report.Run) bool {
+	return f.Started.Equal(run.Started) && f.Seed == run.Seed
+}
+
+// Lookup returns the decision about test, or nil.
+func (f *File) Lookup(test string) *Decision {
+	for i := range f.Decisions {
+		if f.Decisions[i].Test == test {
+			return &f.Decisions[i]
+		}
+	}
+	return nil
+}
+
+// Decide records d, replacing any earlier decision about the same test.
+func (f *File) Decide(d Decision) {
+	if old := f.Lookup(d.Test); old != nil {
+		*old = d
+		return
+	}
+	f.Decisions = append(f.Decisions, d)
+	slices.SortFunc(f.Decisions, func(a, b Decision) int { return strings.Compare(a.Test, b.Test) })
+}
+
+// Load reads the triage Save wrote. A missing file is nil.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse triage %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Save writes f to path.
+func (f *File) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
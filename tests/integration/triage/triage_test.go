@@ -0,0 +1,145 @@
+package triage
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+func run() *report.Run {
+	return &report.Run{
+		Started: time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC),
+		Seed:    42,
+		Tests: []report.TestResult{
+			{ID: "gcloud-tool-call", Status: report.StatusFailed, Reason: report.ReasonAssertion, Error: "wrong output",
+				Log: "🚀 calling\n", Mismatch: &report.Mismatch{Message: "output differs", Diff: "-a\n+b\n"}},
+			{ID: "example-echo", Status: report.StatusPassed},
+			{ID: "storage-list", Status: report.StatusFlaky, Reason: report.ReasonHang, Error: "timed out",
+				FailedAttempts: []report.Attempt{{Reason: report.ReasonHang, Error: "timed out", Duration: time.Second}}},
+			{ID: "gemini-mcp-list", Status: report.StatusFailed, Reason: report.ReasonToolError, Error: "no credentials"},
+		},
+	}
+}
+
+func TestDecideReplacesEarlierDecision(t *testing.T) {
+	f := New(run())
+	f.Decide(Decision{Test: "b", Disposition: Bug})
+	f.Decide(Decision{Test: "a", Disposition: Env})
+	f.Decide(Decision{Test: "b", Disposition: Flake, Note: "retried fine"})
+	if len(f.Decisions) != 2 || f.Decisions[0].Test != "a" || f.Lookup("b").Disposition != Flake {
+		t.Errorf("Decisions = %+v", f.Decisions)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.triage.json")
+	if f, err := Load(path); err != nil || f != nil {
+		t.Fatalf("Load(missing) = %v, %v", f, err)
+	}
+	r := run()
+	f := New(r)
+	f.Decide(Decision{Test: "gcloud-tool-call", Disposition: Bug})
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Of(r) || loaded.Lookup("gcloud-tool-call") == nil {
+		t.Errorf("Load = %+v", loaded)
+	}
+	r.Seed++
+	if loaded.Of(r) {
+		t.Error("Of matched a different run")
+	}
+}
+
+func TestParseDisposition(t *testing.T) {
+	if d, err := ParseDisposition("flake"); err != nil || d != Flake {
+		t.Errorf("ParseDisposition(flake) = %q, %v", d, err)
+	}
+	if _, err := ParseDisposition("wontfix"); err == nil {
+		t.Error("ParseDisposition(wontfix) succeeded")
+	}
+}
+
+func TestInteract(t *testing.T) {
+	artifacts := t.TempDir()
+	dir := filepath.Join(artifacts, "gcloud-tool-call")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "repro.sh"), []byte("#!/bin/sh\necho repro"), 0o755)
+	gz, _ := os.Create(filepath.Join(dir, "output.log.gz"))
+	w := gzip.NewWriter(gz)
+	w.Write([]byte("compressed output"))
+	w.Close()
+	gz.Close()
+
+	var saves int
+	s := &Session{Run: run(), Artifacts: artifacts, Author: "alice", Save: func(*File) error { saves++; return nil }}
+	in := strings.Join([]string{
+		"diff",
+		"files",
+		"cat output.log.gz",
+		"cat ../escape",
+		"bug wrong project in the output",
+		"trace",
+		"flake",
+		"l",
+		"1",
+		"env",
+		"env expired credentials",
+		"q",
+	}, "\n")
+	var out strings.Builder
+	if err := s.Interact(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"── [1/3] gcloud-tool-call ──",
+		"see:      log, diff, files",
+		"-a\n+b\n",
+		"repro.sh",
+		"compressed output",
+		`no artifact "../escape"`,
+		"── [2/3] storage-list ──",
+		"attempt 1 failed after 1s (hang): timed out",
+		"── [3/3] gemini-mcp-list ──",
+		"   1. gcloud-tool-call",
+		"bug: wrong project in the output (alice)",
+		"✅ All 3 failures are triaged",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	// Going back to the first failure and redeciding it replaces the decision.
+	want := map[string]Disposition{"gcloud-tool-call": Env, "storage-list": Flake, "gemini-mcp-list": Env}
+	if len(s.Triage.Decisions) != 3 || saves != 4 {
+		t.Fatalf("Decisions = %+v after %d saves", s.Triage.Decisions, saves)
+	}
+	for test, d := range want {
+		if got := s.Triage.Lookup(test); got == nil || got.Disposition != d || got.Author != "alice" {
+			t.Errorf("decision about %s = %+v, want %s", test, got, d)
+		}
+	}
+}
+
+func TestInteractResumesAtUndecided(t *testing.T) {
+	r := run()
+	f := New(r)
+	f.Decide(Decision{Test: "gcloud-tool-call", Disposition: Bug})
+	s := &Session{Run: r, Triage: f}
+	var out strings.Builder
+	if err := s.Interact(strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "── [2/3] storage-list ──") {
+		t.Errorf("output = %s", out.String())
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/selfupdate"
+)
+
+// version is the harness release, set when building a release with
+// -ldflags "-X main.version=v1.2.0".
+var version string
+
+// updateCheckTimeout bounds the release manifest fetch, so an offline runner
+// only loses a few seconds.
+const updateCheckTimeout = 5 * time.Second
+
+// harnessVersion returns version, or for `go install` builds the module
+// version, or "dev".
+func harnessVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// commitEnv are the variables CI systems put the commit SHA under build in:
+// Cloud Build, GitHub Actions and GitLab CI.
+var commitEnv = []string{"COMMIT_SHA", "GITHUB_SHA", "CI_COMMIT_SHA"}
+
+// detectCommit returns the SHA of the checkout under test: from CI's
+// environment, else from git in the working directory, else "".
+func detectCommit() string {
+	for _, name := range commitEnv {
+		if sha := os.Getenv(name); sha != "" {
+			return sha
+		}
+	}
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// checkForUpdate records on results the newer release c finds, if any. A
+// failed check is logged and otherwise ignored.
+func checkForUpdate(results *report.Run, c *selfupdate.Checker) {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	latest, err := c.Check(ctx, results.Harness)
+	if err != nil {
+		logf(slog.LevelWarn, "⚠️  could not check for a newer harness release: %v\n", err)
+		return
+	}
+	if latest != nil {
+		results.Upgrade = &report.Upgrade{Latest: latest.Version, Notes: latest.Notes}
+	}
+}
+
+// runVersion implements `version [-release-manifest URL]`, printing the
+// harness version and exiting 1 if a newer release is available or the check
+// fails.
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	c := &selfupdate.Checker{}
+	fs.StringVar(&c.Manifest, "release-manifest", selfupdate.DefaultManifest, "gs:// or https:// URL of the latest release manifest")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	running := harnessVersion()
+	fmt.Printf("integration-test %s\n", running)
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	latest, err := c.Check(ctx, running)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFail
+	case latest != nil:
+		logger.Printf("⬆️  %s is available: %s\n", latest.Version, latest.Notes)
+		return exitFail
+	}
+	return exitPass
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/bootstrap"
+	"github.com/googleapis/gcloud-mcp/tests/integration/cache"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+)
+
+// Labels a version matrix tags each version's results with.
+const (
+	matrixServerLabel  = "matrix_server"
+	matrixTagLabel     = "matrix_tag"
+	matrixVersionLabel = "matrix_version"
+)
+
+// versionMatrix is the version-matrix.json of one server.
+type versionMatrix struct {
+	Server      string              `json:"server"`
+	Package     string              `json:"package"`
+	Baseline    string              `json:"baseline"`
+	Versions    []matrixVersion     `json:"versions"`
+	Regressions []report.Regression `json:"regressions,omitempty"`
+}
+
+// matrixVersion is one version of a versionMatrix: what tag resolved to and
+// where its results are, or why it has none.
+type matrixVersion struct {
+	Tag     string `json:"tag"`
+	Version string `json:"version,omitempty"`
+	Results string `json:"results,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runVersionMatrix implements `version-matrix -server NAME[,NAME...]
+// [-versions latest,previous,...] [-baseline TAG] [-out DIR] [-- run
+// flags]`: it installs each version of each server into its own npm prefix,
+// runs the tests against it and tabulates the outcomes by version, failing
+// on tests that pass on the baseline and fail on another.
+func runVersionMatrix(args []string) int {
+	fs := flag.NewFlagSet("version-matrix", flag.ContinueOnError)
+	serverNames := fs.String("server", "", "comma-separated servers of the manifest whose versions to test, one at a time")
+	versions := fs.String("versions", "latest,"+bootstrap.Previous, "comma-separated npm versions, ranges or dist-tags to test; previous is the release before latest")
+	baselineTag := fs.String("baseline", "", "version the others are compared with (default previous if listed, else the first)")
+	outDir := fs.String("out", "version-matrix", "directory each version's npm prefix, results and the matrix are written to")
+	manifestPath := fs.String("manifest", defaultManifestFile, "YAML manifest of the servers")
+	lookupCacheTTL := fs.Duration("lookup-cache-ttl", cache.DefaultTTL, "reuse npm version resolutions for this long (0 to always repeat them)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	tags := strings.Split(*versions, ",")
+	if *serverNames == "" || slices.Contains(tags, "") {
+		fmt.Fprintln(os.Stderr, "usage: integration-test version-matrix -server NAME[,NAME...] [-versions latest,previous,...] [-baseline TAG] [-out DIR] [-- run flags]")
+		return exitUsage
+	}
+	baseline := *baselineTag
+	switch {
+	case baseline == "" && slices.Contains(tags, bootstrap.Previous):
+		baseline = bootstrap.Previous
+	case baseline == "":
+		baseline = tags[0]
+	case !slices.Contains(tags, baseline):
+		fmt.Fprintf(os.Stderr, "-baseline %s is not one of -versions\n", baseline)
+		return exitUsage
+	}
+	manifest, err := bootstrap.Load(*manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	var servers []*bootstrap.Server
+	for _, name := range strings.Split(*serverNames, ",") {
+		i := slices.IndexFunc(manifest.Servers, func(s bootstrap.Server) bool { return s.Name == name })
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "%s does not list server %q\n", *manifestPath, name)
+			return exitUsage
+		}
+		servers = append(servers, &manifest.Servers[i])
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFail
+	}
+	absOut, err := filepath.Abs(*outDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	code := exitPass
+	lookups := cache.Default(*lookupCacheTTL)
+	for _, s := range servers {
+		m := versionMatrix{Server: s.Name, Package: s.Package, Baseline: baseline}
+		var runs []*report.Run
+		for _, tag := range tags {
+			m.Versions = append(m.Versions, matrixVersion{Tag: tag})
+			v := &m.Versions[len(m.Versions)-1]
+			dir := filepath.Join(absOut, s.Name, tag)
+			run, version, err := testVersion(exe, s, tag, dir, lookups, fs.Args())
+			v.Version = version
+			if err != nil {
+				logf(slog.LevelError, "❌ %s@%s: %v\n", s.Package, tag, err)
+				v.Error = err.Error()
+				if errors.Is(err, errMatrixUsage) {
+					return exitUsage
+				}
+				code = exitFail
+				continue
+			}
+			v.Results = filepath.Join(dir, "results.json")
+			runs = append(runs, run)
+		}
+
+		matrix := report.NewMatrixBy(runs, tags, func(r *report.Run) string { return r.Labels[matrixTagLabel] })
+		m.Regressions = matrix.Regressions(baseline)
+		logger.Printf("🧮 %s versions:\n", s.Name)
+		for _, v := range m.Versions {
+			logger.Printf("   %s = %s\n", v.Tag, v.Version)
+		}
+		if err := matrix.Write(summaryOut); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFail
+		}
+		for _, r := range m.Regressions {
+			logf(slog.LevelError, "❌ Regression from %s: %s\n", baseline, r)
+			code = exitFail
+		}
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(absOut, s.Name, "version-matrix.json"), append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			logf(slog.LevelError, "❌ error writing the version matrix of %s: %v\n", s.Name, err)
+			code = exitFail
+		}
+	}
+	return code
+}
+
+// errMatrixUsage reports run flags the harness rejected, which no other
+// version will accept either.
+var errMatrixUsage = errors.New("the run flags were rejected")
+
+// testVersion installs tag of s into dir and runs the tests against it with
+// the run flags runArgs, returning the run's results and the version tag
+// resolved to.
+func testVersion(exe string, s *bootstrap.Server, tag, dir string, lookups *cache.Cache, runArgs []string) (*report.Run, string, error) {
+	ctx := context.Background()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, "", err
+	}
+	b := &bootstrap.Bootstrapper{Prefix: filepath.Join(dir, "npm"), Cache: lookups, Log: logger.Writer()}
+	pinned := *s
+	pinned.Version = tag
+	version, err := b.Resolve(ctx, &pinned)
+	if err != nil {
+		return nil, "", err
+	}
+	bin, err := b.Install(ctx, s, version)
+	if err != nil {
+		return nil, version, err
+	}
+	if _, err := os.Stat(bin); err != nil {
+		return nil, version, fmt.Errorf("%s@%s does not install %s", s.Package, version, s.Bin)
+	}
+	results := filepath.Join(dir, "results.json")
+	args := append(slices.Clip(runArgs),
+		"-results", results,
+		"-label", matrixServerLabel+"="+s.Name,
+		"-label", matrixTagLabel+"="+tag,
+		"-label", matrixVersionLabel+"="+version,
+	)
+	logger.Printf("🧮 Running the tests against %s@%s (%s)\n", s.Package, version, tag)
+	cmd := exec.CommandContext(ctx, exe, args...)
+	// The version's bin directory comes first, so the tests start it.
+	cmd.Env = append(os.Environ(), "PATH="+filepath.Dir(bin)+string(os.PathListSeparator)+os.Getenv("PATH"))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit) && exit.ExitCode() == exitUsage:
+		return nil, version, errMatrixUsage
+	case errors.As(err, &exit) && exit.ExitCode() == exitFail:
+		// Failed tests are in the results.
+	case err != nil:
+		return nil, version, fmt.Errorf("running the tests: %w", err)
+	}
+	run, err := report.ReadJSON(results)
+	if err != nil {
+		return nil, version, err
+	}
+	return run, version, nil
+}
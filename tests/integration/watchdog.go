@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+	"github.com/googleapis/gcloud-mcp/tests/integration/report"
+	"github.com/googleapis/gcloud-mcp/tests/integration/subprocess"
+)
+
+// watchdog ends a run that is still going at its deadline, which almost
+// always means a server stopped answering, with the diagnostics a CI job
+// killed from outside never gets: the goroutine stacks, the server processes
+// still running and the results of the tests that finished. It also ends a
+// run interrupted by SIGINT or SIGTERM, with the results so far.
+type watchdog struct {
+	timeout time.Duration
+	// timer is nil without a timeout.
+	timer *time.Timer
+	// dumpDir, if set, also receives the dump as watchdog.txt.
+	dumpDir string
+	// partial writes the results of a run the watchdog ends.
+	partial func(*report.Run)
+	// ended is set by whichever of the timer, a signal and the run finishing
+	// comes first.
+	ended atomic.Bool
+
+	mu      sync.Mutex
+	run     report.Run
+	current string
+	since   time.Time
+}
+
+// activeWatchdog is the watchdog of the run in progress, which a signal
+// interrupts.
+var activeWatchdog atomic.Pointer[watchdog]
+
+// exitCleanups release what the run holds outside the process, such as an
+// ephemeral project or a lease, when the watchdog ends the run: os.Exit
+// skips the deferred releases of the normal path.
+var exitCleanups struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// onWatchdogExit registers f to run before the watchdog exits the process,
+// after the functions registered later. f must be safe to call again from
+// the normal path.
+func onWatchdogExit(f func()) {
+	exitCleanups.mu.Lock()
+	defer exitCleanups.mu.Unlock()
+	exitCleanups.fns = append(exitCleanups.fns, f)
+}
+
+// runExitCleanups runs the functions onWatchdogExit registered, last first.
+func runExitCleanups() {
+	exitCleanups.mu.Lock()
+	fns := slices.Clone(exitCleanups.fns)
+	exitCleanups.mu.Unlock()
+	for _, f := range slices.Backward(fns) {
+		f()
+	}
+}
+
+// startWatchdog starts the watchdog of a run, which fires after timeout if
+// it is positive.
+func startWatchdog(timeout time.Duration, dumpDir string, partial func(*report.Run)) *watchdog {
+	w := &watchdog{timeout: timeout, dumpDir: dumpDir, partial: partial}
+	if timeout > 0 {
+		w.timer = time.AfterFunc(timeout, w.fire)
+	}
+	activeWatchdog.Store(w)
+	return w
+}
+
+// record tells the watchdog how far run has got and which test, if any, is
+// running now.
+func (w *watchdog) record(run *report.Run, current string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.run = *run
+	w.run.Tests = slices.Clone(run.Tests)
+	w.current, w.since = current, time.Now()
+}
+
+// stop disarms the watchdog. If it has already fired or the run was
+// interrupted, stop blocks while the watchdog writes the results and exits.
+func (w *watchdog) stop() {
+	if w == nil {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if !w.ended.CompareAndSwap(false, true) {
+		select {}
+	}
+}
+
+func (w *watchdog) fire() {
+	if !w.ended.CompareAndSwap(false, true) {
+		return
+	}
+	w.mu.Lock()
+	run, current, since := w.run, w.current, w.since
+	w.mu.Unlock()
+
+	var dump bytes.Buffer
+	fmt.Fprintf(&dump, "⏰ Watchdog: the run did not finish within -timeout %s", w.timeout)
+	live := liveProgress.Load()
+	switch {
+	case live != nil && live.test == current:
+		fmt.Fprintf(&dump, "; %s", live.status())
+	case current != "":
+		fmt.Fprintf(&dump, "; %s has been running for %s", current, time.Since(since).Round(time.Second))
+	}
+	fmt.Fprintln(&dump)
+	fmt.Fprintln(&dump, "\n🖥️  Server processes still running:")
+	servers := subprocess.Default.Running()
+	for _, s := range servers {
+		fmt.Fprintf(&dump, "  %s\n", s)
+	}
+	if len(servers) == 0 {
+		fmt.Fprintln(&dump, "  none")
+	}
+	fmt.Fprintln(&dump, "\n🧵 Goroutines:")
+	pprof.Lookup("goroutine").WriteTo(&dump, 2)
+	os.Stderr.Write(dump.Bytes())
+	if w.dumpDir != "" {
+		path := filepath.Join(w.dumpDir, "watchdog.txt")
+		err := os.MkdirAll(w.dumpDir, 0o755)
+		if err == nil {
+			err = os.WriteFile(path, dump.Bytes(), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ error writing the watchdog dump: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "📝 Wrote the watchdog dump to %s\n", path)
+		}
+	}
+
+	w.end(&run, current, since, report.ReasonHang, fmt.Sprintf("still running when the %s watchdog fired", w.timeout))
+	subprocess.Default.KillAll()
+	services.KillAll()
+	runExitCleanups()
+	os.Exit(exitFail)
+}
+
+// interrupt ends the run on sig: it cancels runCtx, kills the servers
+// before the test in progress can start more, then writes the results so
+// far with that test aborted, and exits. It returns at once if the run has
+// already ended.
+func (w *watchdog) interrupt(sig os.Signal) {
+	if !w.ended.CompareAndSwap(false, true) {
+		return
+	}
+	cancelRun()
+	for _, p := range slices.Concat(subprocess.Default.Shutdown(), services.Shutdown()) {
+		fmt.Fprintf(os.Stderr, "🧹 Killed %s\n", p.Label())
+	}
+	fmt.Fprintf(os.Stderr, "❌ Stopped by %v; writing the results so far\n", sig)
+	w.mu.Lock()
+	run, current, since := w.run, w.current, w.since
+	w.mu.Unlock()
+	run.Interrupted = sig.String()
+	w.end(&run, current, since, report.ReasonAborted, fmt.Sprintf("still running when the run was stopped by %v", sig))
+	runExitCleanups()
+	os.Exit(exitFail)
+}
+
+// end records current, the test in progress if any, as failed with reason
+// and writes run's results.
+func (w *watchdog) end(run *report.Run, current string, since time.Time, reason, message string) {
+	if current != "" {
+		run.Tests = append(run.Tests, report.TestResult{
+			ID:       current,
+			Started:  since,
+			Status:   report.StatusFailed,
+			Reason:   reason,
+			Error:    message,
+			Duration: time.Since(since),
+		})
+		if live := liveProgress.Load(); live != nil && live.test == current {
+			run.Tests[len(run.Tests)-1].Timeline = live.recorded()
+		}
+	}
+	run.Duration = time.Since(run.Started)
+	run.Latency = report.SummarizeLatency(client.DefaultRecorder.Calls())
+	run.Startup = report.SummarizeStartup(client.DefaultRecorder.Calls())
+	run.Resources = report.SummarizeResources(run.Tests)
+	w.partial(run)
+}
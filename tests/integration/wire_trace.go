@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/googleapis/gcloud-mcp/tests/integration/client"
+)
+
+// wireTrace, if set, records the JSON-RPC messages of the running test's
+// tool calls; invokeTool sets it on every call.
+var wireTrace *client.WireTrace
+
+// startWireTrace starts recording the messages of the running test's attempt
+// to wireTraceName(attempt) in its artifacts directory under dir. The
+// returned function stops it and returns the trace's path, or "" if it could
+// not be written.
+func startWireTrace(dir, test string, attempt int) (stop func() string) {
+	path := filepath.Join(dir, test, wireTraceName(attempt))
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	var f *os.File
+	if err == nil {
+		f, err = os.Create(path)
+	}
+	if err != nil {
+		logf(slog.LevelError, "❌ error creating the wire trace of %s: %v\n", test, err)
+		return func() string { return "" }
+	}
+	wireTrace = client.NewWireTrace(f)
+	wireTrace.Redact = redactor.JSON
+	return func() string {
+		err := wireTrace.Err()
+		wireTrace = nil
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			logf(slog.LevelError, "❌ error writing the wire trace of %s: %v\n", test, err)
+			return ""
+		}
+		return path
+	}
+}
+
+// wireTraceName returns the file name of the wire trace of a test's attempt:
+// wire.jsonl, numbered from the second attempt on, so a retry keeps the
+// trace of the attempt that failed.
+func wireTraceName(attempt int) string {
+	if attempt <= 1 {
+		return "wire.jsonl"
+	}
+	return fmt.Sprintf("wire-%d.jsonl", attempt)
+}